@@ -45,7 +45,8 @@ type commonInputConfig struct {
 	KeepNull             bool                    `config:"keep_null"`
 
 	PublisherPipeline struct {
-		DisableHost bool `config:"disable_host"` // Disable addition of host.name.
+		DisableHost bool           `config:"disable_host"` // Disable addition of host.name.
+		Priority    *beat.Priority `config:"priority"`     // Queue scheduling class of the input's events.
 	} `config:"publisher_pipeline"`
 
 	// implicit event fields
@@ -79,6 +80,7 @@ func (f *onCreateFactory) Create(pipeline beat.PipelineConnector, cfg *conf.C) (
 //   - *tags*: add additional tags to the events
 //   - *processors*: list of local processors to be added to the processing pipeline
 //   - *keep_null*: keep or remove 'null' from events to be published
+//   - *publisher_pipeline.priority*: queue scheduling class of the events (high, normal or low)
 //   - *_module_name* (hidden setting): Add fields describing the module name
 //   - *_ fileset_name* (hidden setting):
 //   - *pipeline*: Configure the ES Ingest Node pipeline name to be used for events from this input
@@ -187,6 +189,9 @@ func newCommonConfigEditor(
 		clientCfg.Processing.Processor = procs
 		clientCfg.Processing.KeepNull = config.KeepNull
 		clientCfg.Processing.DisableHost = config.PublisherPipeline.DisableHost
+		if config.PublisherPipeline.Priority != nil {
+			clientCfg.Priority = *config.PublisherPipeline.Priority
+		}
 
 		return clientCfg, nil
 	}, nil
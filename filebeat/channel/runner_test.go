@@ -194,6 +194,28 @@ func TestProcessorsForConfigIsFlat(t *testing.T) {
 	assert.Len(t, lst.(*processors.Processors).List, 2) //nolint:errcheck //Safe to ignore in tests
 }
 
+func TestCommonConfigPriority(t *testing.T) {
+	logger := logptest.NewTestingLogger(t, "")
+
+	config := conf.MustNewConfigFrom(map[string]any{"publisher_pipeline.priority": "high"})
+	editor, err := newCommonConfigEditor(beat.Info{Logger: logger}, config)
+	require.NoError(t, err)
+	clientCfg, err := editor(beat.ClientConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, beat.HighPriority, clientCfg.Priority)
+
+	// Without the setting, the priority chosen by the input is kept.
+	editor, err = newCommonConfigEditor(beat.Info{Logger: logger}, conf.NewConfig())
+	require.NoError(t, err)
+	clientCfg, err = editor(beat.ClientConfig{Priority: beat.LowPriority})
+	require.NoError(t, err)
+	assert.Equal(t, beat.LowPriority, clientCfg.Priority)
+
+	config = conf.MustNewConfigFrom(map[string]any{"publisher_pipeline.priority": "urgent"})
+	_, err = newCommonConfigEditor(beat.Info{Logger: logger}, config)
+	assert.ErrorContains(t, err, "invalid priority")
+}
+
 // setRawIndex is a bare-bones processor to set the raw_index field to a
 // constant string in the event metadata. It is used to test order of operations
 // for processorsForConfig.
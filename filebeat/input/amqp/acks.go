@@ -0,0 +1,163 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package amqp
+
+import (
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/acker"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// ackHandler acknowledges deliveries to the broker once the publisher
+// pipeline acknowledged the events created from them. Each delivery is
+// published as a single event, holding the delivery in its Private field.
+type ackHandler struct {
+	log *logp.Logger
+
+	// pending counts the events published and not acknowledged yet, acked
+	// is signalled each time it decreases.
+	pending atomic.Int64
+	acked   chan struct{}
+
+	// ackChan receives, for each batch of events acknowledged by the
+	// pipeline, the last delivery tag acknowledged on each channel.
+	ackChan chan map[amqp.Acknowledger]uint64
+	closing chan struct{}
+	done    chan struct{}
+}
+
+// delivery is the private data of the events, identifying the delivery to
+// acknowledge.
+type delivery struct {
+	acknowledger amqp.Acknowledger
+	tag          uint64
+}
+
+func newACKHandler(log *logp.Logger) *ackHandler {
+	handler := &ackHandler{
+		log:   log,
+		acked: make(chan struct{}, 1),
+		// The buffer lets the pipeline move on to the next batch while the
+		// previous acknowledgments are sent to the broker.
+		ackChan: make(chan map[amqp.Acknowledger]uint64, 10),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go handler.run()
+	return handler
+}
+
+func (ah *ackHandler) pipelineEventListener() beat.EventListener {
+	return acker.ConnectionOnly(
+		acker.EventPrivateReporter(func(_ int, privates []any) {
+			if tags := lastDeliveryTags(privates); len(tags) > 0 {
+				select {
+				case ah.ackChan <- tags:
+				case <-ah.closing:
+					// The deliveries weren't acknowledged, the broker will
+					// redeliver them.
+				}
+			}
+			ah.pending.Add(-int64(len(privates)))
+			select {
+			case ah.acked <- struct{}{}:
+			default:
+			}
+		}),
+	)
+}
+
+// lastDeliveryTags returns the last delivery tag of each channel. The
+// pipeline acknowledges events in the order they were published, which is
+// the order they were delivered on each channel, so acknowledging the last
+// delivery acknowledges all the previous ones too.
+func lastDeliveryTags(privates []any) map[amqp.Acknowledger]uint64 {
+	var tags map[amqp.Acknowledger]uint64
+	for _, private := range privates {
+		d, ok := private.(delivery)
+		if !ok || d.acknowledger == nil {
+			continue
+		}
+		if tags == nil {
+			tags = make(map[amqp.Acknowledger]uint64)
+		}
+		tags[d.acknowledger] = max(tags[d.acknowledger], d.tag)
+	}
+	return tags
+}
+
+// add records an event is about to be published.
+func (ah *ackHandler) add() {
+	ah.pending.Add(1)
+}
+
+// wait waits until the published events are acknowledged, or the timeout
+// expires.
+func (ah *ackHandler) wait(timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for ah.pending.Load() > 0 {
+		select {
+		case <-ah.acked:
+		case <-timer.C:
+			ah.log.Debugw("Timed out waiting for acknowledgments", "pending", ah.pending.Load())
+			return
+		}
+	}
+}
+
+// Close stops the handler once the acknowledgments received so far were
+// sent to the broker.
+func (ah *ackHandler) Close() {
+	close(ah.closing)
+	<-ah.done
+}
+
+func (ah *ackHandler) run() {
+	defer close(ah.done)
+	for {
+		select {
+		case tags := <-ah.ackChan:
+			ah.ack(tags)
+		case <-ah.closing:
+			for {
+				select {
+				case tags := <-ah.ackChan:
+					ah.ack(tags)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (ah *ackHandler) ack(tags map[amqp.Acknowledger]uint64) {
+	for acknowledger, tag := range tags {
+		if err := acknowledger.Ack(tag, true); err != nil {
+			// The channel was closed, so the broker requeued the
+			// deliveries and will deliver them again.
+			ah.log.Debugw("Failed to acknowledge deliveries", "delivery_tag", tag, "error", err)
+		}
+	}
+}
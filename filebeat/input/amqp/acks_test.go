@@ -0,0 +1,131 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package amqp
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+)
+
+type fakeAcknowledger struct {
+	mu   sync.Mutex
+	acks []uint64
+}
+
+func (f *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if multiple {
+		f.acks = append(f.acks, tag)
+	}
+	return nil
+}
+
+func (f *fakeAcknowledger) Nack(uint64, bool, bool) error { return nil }
+
+func (f *fakeAcknowledger) Reject(uint64, bool) error { return nil }
+
+func (f *fakeAcknowledger) acked() []uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.acks
+}
+
+func TestLastDeliveryTags(t *testing.T) {
+	ch1, ch2 := &fakeAcknowledger{}, &fakeAcknowledger{}
+	tags := lastDeliveryTags([]any{
+		delivery{acknowledger: ch1, tag: 1},
+		delivery{acknowledger: ch1, tag: 2},
+		nil,
+		delivery{acknowledger: ch2, tag: 7},
+		delivery{acknowledger: ch1, tag: 3},
+	})
+	assert.Len(t, tags, 2)
+	assert.Equal(t, uint64(3), tags[ch1])
+	assert.Equal(t, uint64(7), tags[ch2])
+
+	assert.Nil(t, lastDeliveryTags([]any{nil, "other"}))
+}
+
+func TestACKHandler(t *testing.T) {
+	ch1, ch2 := &fakeAcknowledger{}, &fakeAcknowledger{}
+	handler := newACKHandler(logptest.NewTestingLogger(t, ""))
+	listener := handler.pipelineEventListener()
+
+	events := []any{
+		delivery{acknowledger: ch1, tag: 1},
+		delivery{acknowledger: ch1, tag: 2},
+		delivery{acknowledger: ch2, tag: 1},
+		delivery{acknowledger: ch1, tag: 3},
+	}
+	for _, private := range events {
+		listener.AddEvent(beat.Event{Private: private}, true)
+	}
+
+	// Nothing is acknowledged to the broker before the pipeline ACKs.
+	assert.Empty(t, ch1.acked())
+	assert.Empty(t, ch2.acked())
+
+	listener.ACKEvents(2)
+	listener.ACKEvents(2)
+	handler.Close()
+
+	assert.Equal(t, []uint64{2, 3}, ch1.acked())
+	assert.Equal(t, []uint64{1}, ch2.acked())
+
+	// ACKs received after the handler was closed are dropped.
+	listener.AddEvent(beat.Event{Private: delivery{acknowledger: ch1, tag: 4}}, true)
+	listener.ACKEvents(1)
+	assert.Equal(t, []uint64{2, 3}, ch1.acked())
+}
+
+func TestACKHandlerWait(t *testing.T) {
+	ch := &fakeAcknowledger{}
+	handler := newACKHandler(logptest.NewTestingLogger(t, ""))
+	defer handler.Close()
+	listener := handler.pipelineEventListener()
+
+	for tag := uint64(1); tag <= 2; tag++ {
+		handler.add()
+		listener.AddEvent(beat.Event{Private: delivery{acknowledger: ch, tag: tag}}, true)
+	}
+
+	// The wait times out while events are pending.
+	start := time.Now()
+	handler.wait(50 * time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		handler.wait(time.Minute)
+		close(done)
+	}()
+	listener.ACKEvents(1)
+	listener.ACKEvents(1)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("wait didn't return once the events were acknowledged")
+	}
+}
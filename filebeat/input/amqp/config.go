@@ -0,0 +1,204 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package amqp
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+type config struct {
+	Hosts             []string          `config:"hosts" validate:"required"`
+	Username          string            `config:"username"`
+	Password          string            `config:"password"`
+	SASL              saslConfig        `config:"sasl"`
+	Vhost             string            `config:"vhost"`
+	TLS               *tlscommon.Config `config:"ssl"`
+	Heartbeat         time.Duration     `config:"heartbeat" validate:"min=0"`
+	Exchanges         []exchangeConfig  `config:"exchanges"`
+	Queue             queueConfig       `config:"queue"`
+	PrefetchCount     int               `config:"prefetch_count" validate:"min=0"`
+	ConsumerTag       string            `config:"consumer_tag"`
+	ConnectBackoff    time.Duration     `config:"connect_backoff" validate:"min=0"`
+	MaxConnectBackoff time.Duration     `config:"max_connect_backoff" validate:"min=0"`
+	WaitClose         time.Duration     `config:"wait_close" validate:"min=0"`
+}
+
+type saslConfig struct {
+	Mechanism string `config:"mechanism"`
+}
+
+// exchangeConfig describes an exchange declared before consuming, so the
+// queue can be bound to it.
+type exchangeConfig struct {
+	Name       string         `config:"name" validate:"required"`
+	Type       string         `config:"type"`
+	Durable    bool           `config:"durable"`
+	AutoDelete bool           `config:"auto_delete"`
+	Internal   bool           `config:"internal"`
+	Arguments  map[string]any `config:"arguments"`
+}
+
+type queueConfig struct {
+	Name       string          `config:"name"`
+	Declare    bool            `config:"declare"`
+	Durable    bool            `config:"durable"`
+	AutoDelete bool            `config:"auto_delete"`
+	Exclusive  bool            `config:"exclusive"`
+	Arguments  map[string]any  `config:"arguments"`
+	Bindings   []bindingConfig `config:"bindings"`
+}
+
+type bindingConfig struct {
+	Exchange   string         `config:"exchange" validate:"required"`
+	RoutingKey string         `config:"routing_key"`
+	Arguments  map[string]any `config:"arguments"`
+}
+
+const (
+	saslMechanismPlain     = "PLAIN"
+	saslMechanismAMQPlain  = "AMQPLAIN"
+	saslMechanismExternal  = "EXTERNAL"
+	defaultExchangeType    = amqp.ExchangeDirect
+	defaultPrefetchCount   = 1000
+	defaultConnectBackoff  = time.Second
+	defaultMaxConnBackoff  = time.Minute
+	defaultWaitClose       = 5 * time.Second
+	defaultHeartbeatPeriod = 10 * time.Second
+)
+
+var exchangeTypes = []string{
+	amqp.ExchangeDirect,
+	amqp.ExchangeFanout,
+	amqp.ExchangeTopic,
+	amqp.ExchangeHeaders,
+}
+
+func defaultConfig() config {
+	return config{
+		Heartbeat:         defaultHeartbeatPeriod,
+		PrefetchCount:     defaultPrefetchCount,
+		ConnectBackoff:    defaultConnectBackoff,
+		MaxConnectBackoff: defaultMaxConnBackoff,
+		WaitClose:         defaultWaitClose,
+	}
+}
+
+// Validate validates the config.
+func (c *config) Validate() error {
+	if len(c.Hosts) == 0 {
+		return errors.New("no hosts configured")
+	}
+	for _, host := range c.Hosts {
+		uri, err := amqp.ParseURI(host)
+		if err != nil {
+			return fmt.Errorf("invalid host %q: %w", redactURL(host), err)
+		}
+		if c.TLS.IsEnabled() && uri.Scheme != "amqps" {
+			return fmt.Errorf("host %q must use the amqps scheme when ssl is enabled", redactURL(host))
+		}
+	}
+
+	if c.Queue.Name == "" {
+		return errors.New("no queue configured")
+	}
+
+	switch strings.ToUpper(c.SASL.Mechanism) {
+	case "", saslMechanismPlain, saslMechanismAMQPlain:
+		if c.Username != "" && c.Password == "" {
+			return errors.New("password must be set when username is configured")
+		}
+	case saslMechanismExternal:
+		if !c.TLS.IsEnabled() || c.TLS.Certificate.Certificate == "" {
+			return errors.New("the EXTERNAL sasl mechanism requires an ssl client certificate")
+		}
+	default:
+		return fmt.Errorf("unsupported sasl mechanism %q", c.SASL.Mechanism)
+	}
+
+	for i := range c.Exchanges {
+		if c.Exchanges[i].Type == "" {
+			c.Exchanges[i].Type = defaultExchangeType
+		}
+		if !slices.Contains(exchangeTypes, c.Exchanges[i].Type) {
+			return fmt.Errorf("exchange %q has unsupported type %q", c.Exchanges[i].Name, c.Exchanges[i].Type)
+		}
+		if err := table(c.Exchanges[i].Arguments).Validate(); err != nil {
+			return fmt.Errorf("exchange %q arguments: %w", c.Exchanges[i].Name, err)
+		}
+	}
+	if err := table(c.Queue.Arguments).Validate(); err != nil {
+		return fmt.Errorf("queue arguments: %w", err)
+	}
+	for _, b := range c.Queue.Bindings {
+		if err := table(b.Arguments).Validate(); err != nil {
+			return fmt.Errorf("binding to exchange %q arguments: %w", b.Exchange, err)
+		}
+	}
+	return nil
+}
+
+// table converts arguments unpacked from the configuration to an AMQP
+// table. Unsigned integers aren't valid AMQP field values, so they are
+// converted to signed ones.
+func table(args map[string]any) amqp.Table {
+	if len(args) == 0 {
+		return nil
+	}
+	t := make(amqp.Table, len(args))
+	for k, v := range args {
+		t[k] = fieldValue(v)
+	}
+	return t
+}
+
+func fieldValue(v any) any {
+	switch v := v.(type) {
+	case uint64:
+		return int64(v) //nolint:gosec // G115: configuration values don't overflow int64
+	case uint:
+		return int64(v) //nolint:gosec // G115: configuration values don't overflow int64
+	case map[string]any:
+		return table(v)
+	case []any:
+		values := make([]any, len(v))
+		for i, e := range v {
+			values[i] = fieldValue(e)
+		}
+		return values
+	default:
+		return v
+	}
+}
+
+// redactURL removes the password from host URLs before they are logged.
+func redactURL(host string) string {
+	uri, err := amqp.ParseURI(host)
+	if err != nil || uri.Password == "" {
+		return host
+	}
+	uri.Password = "xxxxx"
+	return uri.String()
+}
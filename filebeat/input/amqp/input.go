@@ -0,0 +1,391 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	input "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/backoff"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	"github.com/elastic/beats/v7/libbeat/management/status"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+const pluginName = "amqp"
+
+// Plugin creates a new amqp input plugin, consuming messages from an AMQP
+// 0-9-1 broker.
+func Plugin(log *logp.Logger) input.Plugin {
+	return input.Plugin{
+		Name:       pluginName,
+		Stability:  feature.Beta,
+		Deprecated: false,
+		Info:       "AMQP input",
+		Doc:        "The AMQP input consumes messages from a queue of an AMQP 0-9-1 broker, such as RabbitMQ",
+		Manager:    input.ConfigureWith(configure, log),
+	}
+}
+
+func configure(cfg *conf.C, logger *logp.Logger) (input.Input, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	var tlsConfig *tlscommon.TLSConfig
+	if config.TLS.IsEnabled() {
+		var err error
+		tlsConfig, err = tlscommon.LoadTLSConfig(config.TLS, logger)
+		if err != nil {
+			return nil, fmt.Errorf("loading ssl configuration: %w", err)
+		}
+	}
+	return &amqpInput{config: config, tlsConfig: tlsConfig}, nil
+}
+
+type amqpInput struct {
+	config    config
+	tlsConfig *tlscommon.TLSConfig
+}
+
+func (in *amqpInput) Name() string { return pluginName }
+
+// Test connects to the brokers, and checks the queue exists unless the
+// input declares it.
+func (in *amqpInput) Test(ctx input.TestContext) error {
+	for _, host := range in.config.Hosts {
+		if err := in.test(host); err != nil {
+			return fmt.Errorf("testing host %q: %w", redactURL(host), err)
+		}
+	}
+	return nil
+}
+
+func (in *amqpInput) test(host string) error {
+	conn, err := in.dial(host)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("opening channel: %w", err)
+	}
+	defer ch.Close()
+
+	if !in.config.Queue.Declare {
+		if _, err := ch.QueueDeclarePassive(in.config.Queue.Name, false, false, false, false, nil); err != nil {
+			return fmt.Errorf("checking queue %q: %w", in.config.Queue.Name, err)
+		}
+	}
+	return nil
+}
+
+func (in *amqpInput) Run(ctx input.Context, pipeline beat.Pipeline) error {
+	log := ctx.Logger.Named("amqp input").With("queue", in.config.Queue.Name)
+
+	acks := newACKHandler(log)
+	client, err := pipeline.ConnectWith(beat.ClientConfig{
+		EventListener: acks.pipelineEventListener(),
+		WaitClose:     in.config.WaitClose,
+	})
+	if err != nil {
+		acks.Close()
+		return err
+	}
+	// The pipeline client is closed before the broker connection, waiting
+	// up to wait_close for the published events to be acknowledged, so their
+	// deliveries can still be acknowledged to the broker.
+	var closeOnce sync.Once
+	closeClient := func() {
+		closeOnce.Do(func() {
+			client.Close()
+			acks.wait(in.config.WaitClose)
+			acks.Close()
+		})
+	}
+	defer closeClient()
+
+	log.Info("Starting AMQP input")
+	defer log.Info("AMQP input stopped")
+	ctx.UpdateStatus(status.Starting, "")
+
+	consumerTag := in.config.ConsumerTag
+	if consumerTag == "" {
+		id, err := uuid.NewV4()
+		if err != nil {
+			return fmt.Errorf("generating consumer tag: %w", err)
+		}
+		consumerTag = "filebeat-" + id.String()
+	}
+
+	goContext := input.GoContextFromCanceler(ctx.Cancelation)
+	connectDelay := backoff.NewEqualJitterBackoff(in.config.ConnectBackoff, in.config.MaxConnectBackoff)
+	for i := 0; goContext.Err() == nil; i++ {
+		// Try the next host each time the connection fails.
+		host := in.config.Hosts[i%len(in.config.Hosts)]
+		c := consumer{
+			input:       in,
+			log:         log.With("host", redactURL(host)),
+			client:      client,
+			acks:        acks,
+			consumerTag: consumerTag,
+			onConsuming: func() {
+				connectDelay.Reset()
+				ctx.UpdateStatus(status.Running, "")
+			},
+			onStopping: closeClient,
+		}
+		err := c.run(goContext, host)
+		if err == nil {
+			break
+		}
+		log.Errorw("AMQP consumer failed", "host", redactURL(host), "error", err)
+		ctx.UpdateStatus(status.Degraded, "AMQP consumer failed: "+err.Error())
+		connectDelay.Wait(goContext)
+	}
+
+	ctx.UpdateStatus(status.Stopped, "")
+	if errors.Is(ctx.Cancelation.Err(), context.Canceled) {
+		return nil
+	}
+	return ctx.Cancelation.Err()
+}
+
+func (in *amqpInput) dial(host string) (*amqp.Connection, error) {
+	cfg := amqp.Config{
+		Vhost:      in.config.Vhost,
+		Heartbeat:  in.config.Heartbeat,
+		Properties: amqp.NewConnectionProperties(),
+	}
+	cfg.Properties.SetClientConnectionName("filebeat")
+
+	switch strings.ToUpper(in.config.SASL.Mechanism) {
+	case saslMechanismExternal:
+		cfg.SASL = []amqp.Authentication{&amqp.ExternalAuth{}}
+	case saslMechanismAMQPlain:
+		cfg.SASL = []amqp.Authentication{&amqp.AMQPlainAuth{Username: in.config.Username, Password: in.config.Password}}
+	default:
+		// Without a username, the credentials of the host URL are used.
+		if in.config.Username != "" {
+			cfg.SASL = []amqp.Authentication{&amqp.PlainAuth{Username: in.config.Username, Password: in.config.Password}}
+		}
+	}
+
+	if in.tlsConfig != nil {
+		uri, err := amqp.ParseURI(host)
+		if err != nil {
+			return nil, err
+		}
+		cfg.TLSClientConfig = in.tlsConfig.BuildModuleClientConfig(uri.Host)
+	}
+
+	conn, err := amqp.DialConfig(host, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connecting: %w", err)
+	}
+	return conn, nil
+}
+
+// consumer consumes the queue over a single connection.
+type consumer struct {
+	input       *amqpInput
+	log         *logp.Logger
+	client      beat.Client
+	acks        *ackHandler
+	consumerTag string
+
+	// onConsuming is called once the consumer receives deliveries.
+	onConsuming func()
+	// onStopping is called when the input stops, before the connection is
+	// closed.
+	onStopping func()
+}
+
+// run consumes the queue until ctx is cancelled, returning nil, or the
+// connection fails.
+func (c *consumer) run(ctx context.Context, host string) error {
+	config := c.input.config
+
+	conn, err := c.input.dial(host)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("opening channel: %w", err)
+	}
+	chClosed := ch.NotifyClose(make(chan *amqp.Error, 1))
+
+	if err := c.declare(ch); err != nil {
+		return err
+	}
+	if err := ch.Qos(config.PrefetchCount, 0, false); err != nil {
+		return fmt.Errorf("setting prefetch count: %w", err)
+	}
+	deliveries, err := ch.Consume(config.Queue.Name, c.consumerTag, false, config.Queue.Exclusive, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("consuming queue %q: %w", config.Queue.Name, err)
+	}
+	c.log.Infow("Consuming queue", "consumer_tag", c.consumerTag)
+	c.onConsuming()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Stop the deliveries, then wait for the pipeline to acknowledge
+			// the published events while the channel is still open. The
+			// deliveries that weren't acknowledged are requeued by the broker
+			// when the connection is closed.
+			if err := ch.Cancel(c.consumerTag, false); err != nil {
+				c.log.Debugw("Failed to cancel consumer", "error", err)
+			}
+			c.onStopping()
+			return nil
+		case d, ok := <-deliveries:
+			if !ok {
+				// The deliveries are closed when the channel is, wait for the
+				// reason.
+				deliveries = nil
+				continue
+			}
+			c.acks.add()
+			c.client.Publish(newEvent(d, config.Queue.Name))
+		case err := <-chClosed:
+			return fmt.Errorf("channel closed: %w", closeError(err))
+		case err := <-connClosed:
+			return fmt.Errorf("connection closed: %w", closeError(err))
+		}
+	}
+}
+
+// declare declares the exchanges, the queue and its bindings.
+func (c *consumer) declare(ch *amqp.Channel) error {
+	config := c.input.config
+	for _, e := range config.Exchanges {
+		if err := ch.ExchangeDeclare(e.Name, e.Type, e.Durable, e.AutoDelete, e.Internal, false, table(e.Arguments)); err != nil {
+			return fmt.Errorf("declaring exchange %q: %w", e.Name, err)
+		}
+	}
+
+	q := config.Queue
+	if q.Declare {
+		if _, err := ch.QueueDeclare(q.Name, q.Durable, q.AutoDelete, q.Exclusive, false, table(q.Arguments)); err != nil {
+			return fmt.Errorf("declaring queue %q: %w", q.Name, err)
+		}
+	}
+	for _, b := range q.Bindings {
+		if err := ch.QueueBind(q.Name, b.RoutingKey, b.Exchange, false, table(b.Arguments)); err != nil {
+			return fmt.Errorf("binding queue %q to exchange %q: %w", q.Name, b.Exchange, err)
+		}
+	}
+	return nil
+}
+
+func closeError(err *amqp.Error) error {
+	if err == nil {
+		return errors.New("closed without error")
+	}
+	return err
+}
+
+// newEvent creates the event of a delivery, which is acknowledged once the
+// event is.
+func newEvent(d amqp.Delivery, queue string) beat.Event {
+	fields := mapstr.M{
+		"queue":       queue,
+		"exchange":    d.Exchange,
+		"routing_key": d.RoutingKey,
+		"redelivered": d.Redelivered,
+	}
+	for k, v := range map[string]string{
+		"message_id":       d.MessageId,
+		"correlation_id":   d.CorrelationId,
+		"content_type":     d.ContentType,
+		"content_encoding": d.ContentEncoding,
+		"type":             d.Type,
+		"app_id":           d.AppId,
+		"user_id":          d.UserId,
+		"reply_to":         d.ReplyTo,
+	} {
+		if v != "" {
+			fields[k] = v
+		}
+	}
+	if d.Priority != 0 {
+		fields["priority"] = d.Priority
+	}
+	if len(d.Headers) > 0 {
+		fields["headers"] = headerValue(d.Headers)
+	}
+
+	ts := d.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	return beat.Event{
+		Timestamp: ts,
+		Fields: mapstr.M{
+			"message": string(d.Body),
+			"amqp":    fields,
+		},
+		Private: delivery{acknowledger: d.Acknowledger, tag: d.DeliveryTag},
+	}
+}
+
+// headerValue converts header values to the types of event fields.
+func headerValue(v any) any {
+	switch v := v.(type) {
+	case amqp.Table:
+		m := make(mapstr.M, len(v))
+		for k, e := range v {
+			m[k] = headerValue(e)
+		}
+		return m
+	case []any:
+		values := make([]any, len(v))
+		for i, e := range v {
+			values[i] = headerValue(e)
+		}
+		return values
+	case []byte:
+		return string(v)
+	case amqp.Decimal:
+		return float64(v.Value) / math.Pow10(int(v.Scale))
+	default:
+		return v
+	}
+}
@@ -0,0 +1,215 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package amqp
+
+import (
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config  map[string]any
+		wantErr string
+	}{
+		"minimal": {
+			config: map[string]any{
+				"hosts":      []string{"amqp://localhost:5672"},
+				"queue.name": "logs",
+			},
+		},
+		"bindings": {
+			config: map[string]any{
+				"hosts": []string{"amqp://localhost:5672"},
+				"exchanges": []map[string]any{
+					{"name": "logs", "type": "topic", "durable": true},
+				},
+				"queue": map[string]any{
+					"name":      "logs",
+					"declare":   true,
+					"arguments": map[string]any{"x-queue-type": "quorum", "x-max-length": 1000},
+					"bindings": []map[string]any{
+						{"exchange": "logs", "routing_key": "app.#"},
+					},
+				},
+			},
+		},
+		"missing hosts": {
+			config: map[string]any{
+				"queue.name": "logs",
+			},
+			wantErr: "missing required field accessing 'hosts'",
+		},
+		"missing queue": {
+			config: map[string]any{
+				"hosts": []string{"amqp://localhost:5672"},
+			},
+			wantErr: "no queue configured",
+		},
+		"invalid host": {
+			config: map[string]any{
+				"hosts":      []string{"http://localhost:5672"},
+				"queue.name": "logs",
+			},
+			wantErr: "invalid host",
+		},
+		"ssl requires amqps": {
+			config: map[string]any{
+				"hosts":       []string{"amqp://localhost:5672"},
+				"queue.name":  "logs",
+				"ssl.enabled": true,
+			},
+			wantErr: "must use the amqps scheme",
+		},
+		"username without password": {
+			config: map[string]any{
+				"hosts":      []string{"amqp://localhost:5672"},
+				"queue.name": "logs",
+				"username":   "guest",
+			},
+			wantErr: "password must be set",
+		},
+		"external without certificate": {
+			config: map[string]any{
+				"hosts":          []string{"amqps://localhost:5671"},
+				"queue.name":     "logs",
+				"sasl.mechanism": "EXTERNAL",
+			},
+			wantErr: "requires an ssl client certificate",
+		},
+		"unsupported mechanism": {
+			config: map[string]any{
+				"hosts":          []string{"amqp://localhost:5672"},
+				"queue.name":     "logs",
+				"sasl.mechanism": "SCRAM-SHA-256",
+			},
+			wantErr: "unsupported sasl mechanism",
+		},
+		"unsupported exchange type": {
+			config: map[string]any{
+				"hosts":      []string{"amqp://localhost:5672"},
+				"queue.name": "logs",
+				"exchanges":  []map[string]any{{"name": "logs", "type": "x-delayed-message"}},
+			},
+			wantErr: "unsupported type",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := defaultConfig()
+			err := conf.MustNewConfigFrom(tc.config).Unpack(&c)
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	c := defaultConfig()
+	err := conf.MustNewConfigFrom(map[string]any{
+		"hosts":      []string{"amqp://localhost:5672"},
+		"queue.name": "logs",
+		"exchanges":  []map[string]any{{"name": "logs"}},
+	}).Unpack(&c)
+	require.NoError(t, err)
+
+	assert.Equal(t, amqp.ExchangeDirect, c.Exchanges[0].Type)
+	assert.Equal(t, defaultPrefetchCount, c.PrefetchCount)
+	assert.Equal(t, defaultWaitClose, c.WaitClose)
+}
+
+func TestTable(t *testing.T) {
+	args := map[string]any{
+		"x-max-length": uint64(1000),
+		"x-queue-type": "quorum",
+		"nested":       map[string]any{"list": []any{uint64(1), "a"}},
+	}
+	tbl := table(args)
+	require.NoError(t, tbl.Validate())
+	assert.Equal(t, amqp.Table{
+		"x-max-length": int64(1000),
+		"x-queue-type": "quorum",
+		"nested":       amqp.Table{"list": []any{int64(1), "a"}},
+	}, tbl)
+
+	assert.Nil(t, table(nil))
+}
+
+func TestNewEvent(t *testing.T) {
+	ack := &fakeAcknowledger{}
+	ts := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	event := newEvent(amqp.Delivery{
+		Acknowledger: ack,
+		DeliveryTag:  42,
+		Exchange:     "logs",
+		RoutingKey:   "app.web",
+		Redelivered:  true,
+		MessageId:    "m-1",
+		ContentType:  "application/json",
+		Priority:     5,
+		Timestamp:    ts,
+		Headers: amqp.Table{
+			"host":     "web-1",
+			"attempts": int32(2),
+			"raw":      []byte("abc"),
+			"price":    amqp.Decimal{Scale: 2, Value: 1234},
+			"nested":   amqp.Table{"tags": []any{"a", []byte("b")}},
+		},
+		Body: []byte(`{"msg":"hello"}`),
+	}, "logs-queue")
+
+	assert.Equal(t, ts, event.Timestamp)
+	assert.Equal(t, mapstr.M{
+		"message": `{"msg":"hello"}`,
+		"amqp": mapstr.M{
+			"queue":        "logs-queue",
+			"exchange":     "logs",
+			"routing_key":  "app.web",
+			"redelivered":  true,
+			"message_id":   "m-1",
+			"content_type": "application/json",
+			"priority":     uint8(5),
+			"headers": mapstr.M{
+				"host":     "web-1",
+				"attempts": int32(2),
+				"raw":      "abc",
+				"price":    12.34,
+				"nested":   mapstr.M{"tags": []any{"a", "b"}},
+			},
+		},
+	}, event.Fields)
+	assert.Equal(t, delivery{acknowledger: ack, tag: 42}, event.Private)
+}
+
+func TestNewEventWithoutTimestamp(t *testing.T) {
+	before := time.Now()
+	event := newEvent(amqp.Delivery{Body: []byte("hello")}, "logs")
+	assert.False(t, event.Timestamp.Before(before))
+	assert.Equal(t, "hello", event.Fields["message"])
+}
@@ -18,11 +18,16 @@
 package inputs
 
 import (
+	"github.com/elastic/beats/v7/filebeat/input/amqp"
+	"github.com/elastic/beats/v7/filebeat/input/evtx"
 	"github.com/elastic/beats/v7/filebeat/input/filestream"
 	"github.com/elastic/beats/v7/filebeat/input/kafka"
 	"github.com/elastic/beats/v7/filebeat/input/logv2"
+	"github.com/elastic/beats/v7/filebeat/input/nats"
+	"github.com/elastic/beats/v7/filebeat/input/net/snmptrap"
 	"github.com/elastic/beats/v7/filebeat/input/net/tcp"
 	"github.com/elastic/beats/v7/filebeat/input/net/udp"
+	"github.com/elastic/beats/v7/filebeat/input/otlp"
 	"github.com/elastic/beats/v7/filebeat/input/unix"
 	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
 	"github.com/elastic/beats/v7/libbeat/beat"
@@ -39,8 +44,14 @@ func Init(info beat.Info, components statestore.States) []v2.Plugin {
 
 func genericInputs(log *logp.Logger, components statestore.States) []v2.Plugin {
 	return []v2.Plugin{
+		amqp.Plugin(log),
 		filestream.Plugin(log, components),
+		filestream.ArchivePlugin(log, components),
+		evtx.Plugin(log, components),
 		kafka.Plugin(log),
+		nats.Plugin(log),
+		otlp.Plugin(log),
+		snmptrap.Plugin(),
 		tcp.Plugin(),
 		udp.Plugin(),
 		unix.Plugin(),
@@ -0,0 +1,203 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package evtx provides an input that reads exported Windows event log
+// files. The files are parsed natively, so the input runs on every platform.
+package evtx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	input "github.com/elastic/beats/v7/filebeat/input/v2"
+	cursor "github.com/elastic/beats/v7/filebeat/input/v2/input-cursor"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	"github.com/elastic/beats/v7/libbeat/statestore"
+	"github.com/elastic/beats/v7/winlogbeat/checkpoint"
+	"github.com/elastic/beats/v7/winlogbeat/eventlog"
+	"github.com/elastic/beats/v7/winlogbeat/sys/evtx"
+	"github.com/elastic/beats/v7/winlogbeat/sys/winevent"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const pluginName = "evtx"
+
+// Plugin creates a stateful input Plugin reading exported Windows event log
+// files.
+func Plugin(log *logp.Logger, store statestore.States) input.Plugin {
+	return input.Plugin{
+		Name:       pluginName,
+		Stability:  feature.Beta,
+		Deprecated: false,
+		Info:       "Windows event log files",
+		Doc:        "The evtx input reads exported Windows event log files on any platform",
+		Manager: &cursor.InputManager{
+			Logger:     log,
+			StateStore: store,
+			Type:       pluginName,
+			Configure:  configure,
+		},
+	}
+}
+
+type fileSource string
+
+func (f fileSource) Name() string { return "evtx::" + string(f) }
+
+type evtxInput struct {
+	includeXML bool
+}
+
+func configure(cfg *conf.C, log *logp.Logger) ([]cursor.Source, cursor.Input, error) {
+	var config config
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, nil, err
+	}
+
+	var sources []cursor.Source
+	seen := map[string]bool{}
+	for _, pattern := range config.Paths {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid path pattern %q: %w", pattern, err)
+		}
+		for _, path := range matches {
+			if !seen[path] {
+				seen[path] = true
+				sources = append(sources, fileSource(path))
+			}
+		}
+	}
+	if len(sources) == 0 {
+		log.Warnf("No files match the evtx input paths %v", config.Paths)
+	}
+
+	return sources, evtxInput{includeXML: config.IncludeXML}, nil
+}
+
+func (evtxInput) Name() string { return pluginName }
+
+func (evtxInput) Test(source cursor.Source, _ input.TestContext) error {
+	f, err := evtx.Open(string(source.(fileSource)))
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (in evtxInput) Run(
+	ctx input.Context,
+	source cursor.Source,
+	cursor cursor.Cursor,
+	pub cursor.Publisher,
+) error {
+	path := string(source.(fileSource))
+	log := ctx.Logger.With("file", path)
+	return in.read(ctx.Cancelation, log, path, initCheckpoint(log, cursor), pub)
+}
+
+// read publishes the records of a file written after the checkpoint.
+func (in evtxInput) read(
+	cancel input.Canceler,
+	log *logp.Logger,
+	path string,
+	cp checkpoint.EventLogState,
+	pub cursor.Publisher,
+) error {
+	f, err := evtx.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %q: %w", path, err)
+	}
+	defer f.Close()
+
+	if cp.RecordNumber > 0 {
+		log.Infof("Resuming after record %d", cp.RecordNumber)
+	}
+	var count int
+	for cancel.Err() == nil {
+		r, err := f.Next()
+		if errors.Is(err, io.EOF) {
+			log.Infof("Finished reading %d records", count)
+			return nil
+		}
+		var recordErr *evtx.RecordError
+		if errors.As(err, &recordErr) {
+			log.Warnw("Skipping record that couldn't be decoded.", "error", err)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %q: %w", path, err)
+		}
+		if r.ID <= cp.RecordNumber {
+			continue
+		}
+
+		record, err := in.newRecord(path, r)
+		if err != nil {
+			log.Warnw("Skipping record that couldn't be parsed.", "record_id", r.ID, "error", err)
+			continue
+		}
+		if err := pub.Publish(record.ToEvent(), record.Offset); err != nil {
+			return err
+		}
+		count++
+	}
+	return nil
+}
+
+// newRecord parses the XML of a record into the structure winlogbeat
+// renders events from.
+func (in evtxInput) newRecord(path string, r evtx.Record) (eventlog.Record, error) {
+	event, err := winevent.UnmarshalXML([]byte(r.XML))
+	if err != nil {
+		return eventlog.Record{}, err
+	}
+	// The files don't contain the provider metadata, so names are only
+	// available for the well-known values.
+	winevent.EnrichRawValuesWithNames(nil, &event)
+
+	record := eventlog.Record{
+		Event: event,
+		File:  path,
+		Offset: checkpoint.EventLogState{
+			Name:         path,
+			RecordNumber: r.ID,
+			Timestamp:    r.Written,
+		},
+	}
+	if in.includeXML {
+		record.XML = r.XML
+	}
+	return record, nil
+}
+
+func initCheckpoint(log *logp.Logger, cursor cursor.Cursor) checkpoint.EventLogState {
+	var cp checkpoint.EventLogState
+	if cursor.IsNew() {
+		return cp
+	}
+
+	if err := cursor.Unpack(&cp); err != nil {
+		log.Errorf("Reset evtx position. Failed to read checkpoint from registry: %v", err)
+		return checkpoint.EventLogState{}
+	}
+
+	return cp
+}
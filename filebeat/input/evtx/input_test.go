@@ -0,0 +1,148 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package evtx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/winlogbeat/checkpoint"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const testdata = "../../../winlogbeat/sys/wineventlog/testdata"
+
+type testPublisher struct {
+	events  []beat.Event
+	cursors []checkpoint.EventLogState
+}
+
+func (p *testPublisher) Publish(event beat.Event, cursor any) error {
+	p.events = append(p.events, event)
+	p.cursors = append(p.cursors, cursor.(checkpoint.EventLogState))
+	return nil
+}
+
+func TestConfigure(t *testing.T) {
+	logger := logptest.NewTestingLogger(t, "")
+	cfg := conf.MustNewConfigFrom(mapstr.M{
+		"paths": []string{
+			filepath.Join(testdata, "ec*.evtx"),
+			filepath.Join(testdata, "ec1.evtx"),
+		},
+	})
+	sources, _, err := configure(cfg, logger)
+	require.NoError(t, err)
+
+	var names []string
+	for _, s := range sources {
+		names = append(names, s.Name())
+	}
+	assert.Equal(t, []string{
+		"evtx::" + filepath.Join(testdata, "ec1.evtx"),
+		"evtx::" + filepath.Join(testdata, "ec2.evtx"),
+		"evtx::" + filepath.Join(testdata, "ec3.evtx"),
+		"evtx::" + filepath.Join(testdata, "ec3and4.evtx"),
+		"evtx::" + filepath.Join(testdata, "ec4.evtx"),
+	}, names)
+
+	_, _, err = configure(conf.NewConfig(), logger)
+	assert.Error(t, err, "paths are required")
+}
+
+func TestRead(t *testing.T) {
+	logger := logptest.NewTestingLogger(t, "")
+	path := filepath.Join(testdata, "sysmon-9.01.evtx")
+	in := evtxInput{includeXML: true}
+
+	var pub testPublisher
+	err := in.read(context.Background(), logger, path, checkpoint.EventLogState{}, &pub)
+	require.NoError(t, err)
+	require.Len(t, pub.events, 32)
+
+	fields := pub.events[0].Fields
+	assert.Equal(t, mapstr.M{
+		"code":     "16",
+		"kind":     "event",
+		"provider": "Microsoft-Windows-Sysmon",
+		"created":  fields["event"].(mapstr.M)["created"],
+		"original": fields["event"].(mapstr.M)["original"],
+	}, fields["event"])
+	assert.Equal(t, "information", fields["log"].(mapstr.M)["level"])
+	assert.Equal(t, path, fields["log"].(mapstr.M)["file"].(mapstr.M)["path"])
+	winlog := fields["winlog"].(mapstr.M)
+	assert.Equal(t, "Microsoft-Windows-Sysmon/Operational", winlog["channel"])
+	assert.Equal(t, "vagrant-2012-r2", winlog["computer_name"])
+	assert.Equal(t, uint64(1), winlog["record_id"])
+	assert.Equal(t, "2019-03-18 16:57:37.933", winlog["event_data"].(mapstr.M)["UtcTime"])
+	assert.Equal(t, checkpoint.EventLogState{
+		Name:         path,
+		RecordNumber: 1,
+		Timestamp:    pub.cursors[0].Timestamp,
+	}, pub.cursors[0])
+	assert.False(t, pub.cursors[0].Timestamp.IsZero())
+
+	// Resuming from the checkpoint of the tenth record publishes the others.
+	var resumed testPublisher
+	err = in.read(context.Background(), logger, path, pub.cursors[9], &resumed)
+	require.NoError(t, err)
+	require.Len(t, resumed.events, 22)
+	assert.Equal(t, pub.cursors[10:], resumed.cursors)
+}
+
+func TestReadWithoutXML(t *testing.T) {
+	logger := logptest.NewTestingLogger(t, "")
+	var pub testPublisher
+	err := evtxInput{}.read(context.Background(), logger, filepath.Join(testdata, "ec1.evtx"), checkpoint.EventLogState{}, &pub)
+	require.NoError(t, err)
+	require.Len(t, pub.events, 1)
+
+	_, err = pub.events[0].Fields.GetValue("event.original")
+	assert.ErrorIs(t, err, mapstr.ErrKeyNotFound)
+	data, err := pub.events[0].Fields.GetValue("winlog.event_data.param1")
+	require.NoError(t, err)
+	assert.Equal(t, "My custom error event for the application log", data)
+}
+
+func TestReadCanceled(t *testing.T) {
+	logger := logptest.NewTestingLogger(t, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var pub testPublisher
+	err := evtxInput{}.read(ctx, logger, filepath.Join(testdata, "sysmon-9.01.evtx"), checkpoint.EventLogState{}, &pub)
+	require.NoError(t, err)
+	assert.Empty(t, pub.events)
+}
+
+func TestReadInvalidFile(t *testing.T) {
+	logger := logptest.NewTestingLogger(t, "")
+	path := filepath.Join(t.TempDir(), "invalid.evtx")
+	require.NoError(t, os.WriteFile(path, []byte("not an event log"), 0o600))
+
+	err := evtxInput{}.read(context.Background(), logger, path, checkpoint.EventLogState{}, &testPublisher{})
+	assert.Error(t, err)
+}
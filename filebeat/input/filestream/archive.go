@@ -0,0 +1,418 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filestream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	loginp "github.com/elastic/beats/v7/filebeat/input/filestream/internal/input-logfile"
+	input "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/libbeat/common/match"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	"github.com/elastic/beats/v7/libbeat/reader"
+	"github.com/elastic/beats/v7/libbeat/reader/readfile"
+	"github.com/elastic/beats/v7/libbeat/reader/readfile/encoding"
+	"github.com/elastic/beats/v7/libbeat/statestore"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const archivePluginName = "archive"
+
+// archiveConfig stores the options of the archive input.
+type archiveConfig struct {
+	Reader readerConfig `config:",inline"`
+
+	ID    string   `config:"id"`
+	Paths []string `config:"paths"`
+
+	Scanner archiveScannerConfig `config:"prospector.scanner"`
+	Members archiveMembersConfig `config:"members"`
+
+	// CleanRemoved removes the states of the members of archives that are
+	// not found by the first complete scan after the input starts.
+	CleanRemoved bool `config:"clean_removed"`
+}
+
+type archiveScannerConfig struct {
+	CheckInterval time.Duration `config:"check_interval" validate:"nonzero"`
+}
+
+// archiveMembersConfig selects the archive members to read, by their path
+// inside the archive.
+type archiveMembersConfig struct {
+	Include []match.Matcher `config:"include"`
+	Exclude []match.Matcher `config:"exclude"`
+}
+
+func defaultArchiveConfig() archiveConfig {
+	return archiveConfig{
+		Reader: defaultReaderConfig(),
+		Paths:  []string{},
+		Scanner: archiveScannerConfig{
+			CheckInterval: 10 * time.Second,
+		},
+		CleanRemoved: true,
+	}
+}
+
+func (c *archiveConfig) Validate() error {
+	if len(c.Paths) == 0 {
+		return fmt.Errorf("no path is configured")
+	}
+	return nil
+}
+
+// selected returns true if the member at path must be read.
+func (c *archiveMembersConfig) selected(path string) bool {
+	if len(c.Include) > 0 && !matchAny(c.Include, []byte(path)) {
+		return false
+	}
+	return !matchAny(c.Exclude, []byte(path))
+}
+
+// archiveMeta is the metadata stored in the registry for archive members.
+type archiveMeta struct {
+	Source      string `json:"source" struct:"source"`
+	Fingerprint string `json:"fingerprint" struct:"fingerprint"`
+	Member      string `json:"member" struct:"member"`
+}
+
+// archiveMemberSource implements the Source interface for a member of an
+// archive. Its identity is the fingerprint of the archive and the path of
+// the member inside it, so the same archive is never read twice, even if
+// it's copied or moved.
+type archiveMemberSource struct {
+	archivePath string
+	fingerprint string
+	member      string
+	format      archiveFormat
+	compression *compressionFormat
+}
+
+// Name returns the registry identifier of the archive member.
+func (s archiveMemberSource) Name() string {
+	return archivePluginName + "::" + s.fingerprint + "::" + s.member
+}
+
+// LogPath returns the path used in logs.
+func (s archiveMemberSource) LogPath() string {
+	return s.archivePath + "::" + s.member
+}
+
+// archiveInput is the harvester of the archive input. It reads archive
+// members as if they were files that are not updated anymore: each member
+// is read once, up to its end, and its harvester is closed.
+type archiveInput struct {
+	readerConfig    readerConfig
+	encodingFactory encoding.EncodingFactory
+	hasLineFilter   bool
+}
+
+// ArchivePlugin creates a new archive input plugin, reading the members of
+// tar and zip archives as individual files.
+func ArchivePlugin(log *logp.Logger, store statestore.States) input.Plugin {
+	return input.Plugin{
+		Name:       archivePluginName,
+		Stability:  feature.Beta,
+		Deprecated: false,
+		Info:       "archive input",
+		Doc:        "The archive input collects logs from the members of tar and zip archives",
+		Manager: &loginp.InputManager{
+			Logger:              log,
+			StateStore:          store,
+			Type:                archivePluginName,
+			Configure:           configureArchive,
+			DefaultCleanTimeout: -1,
+		},
+	}
+}
+
+func configureArchive(
+	cfg *conf.C,
+	log *logp.Logger,
+	_ *loginp.SourceIdentifier) (loginp.Prospector, loginp.Harvester, error) {
+
+	c := defaultArchiveConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, nil, err
+	}
+
+	encodingFactory, ok := encoding.FindEncoding(c.Reader.Encoding)
+	if !ok || encodingFactory == nil {
+		return nil, nil, fmt.Errorf("unknown encoding('%v')", c.Reader.Encoding)
+	}
+
+	prospector := &archiveProspector{
+		logger:        log.Named("archive_prospector"),
+		paths:         c.Paths,
+		checkInterval: c.Scanner.CheckInterval,
+		members:       c.Members,
+		cleanRemoved:  c.CleanRemoved,
+		archives:      map[string]archiveCacheEntry{},
+	}
+
+	harvester := &archiveInput{
+		readerConfig:    c.Reader,
+		encodingFactory: encodingFactory,
+		hasLineFilter:   len(c.Reader.IncludeLines) > 0 || len(c.Reader.ExcludeLines) > 0,
+	}
+
+	return prospector, harvester, nil
+}
+
+func (inp *archiveInput) Name() string { return archivePluginName }
+
+func (inp *archiveInput) Test(src loginp.Source, _ input.TestContext) error {
+	as, ok := src.(archiveMemberSource)
+	if !ok {
+		return fmt.Errorf("not archive member source")
+	}
+
+	r, err := openArchiveMember(as)
+	if err != nil {
+		return err
+	}
+	return r.Close()
+}
+
+// archiveSession implements loginp.HarvesterSession for an archive member.
+// Archive members are read sequentially, so unlike the filestream session
+// the reader pipeline is built once and kept until the member is read.
+type archiveSession struct {
+	inp     *archiveInput
+	log     *logp.Logger
+	src     archiveMemberSource
+	metrics *loginp.Metrics
+
+	member   io.ReadCloser // nil until the first slice, or when closed
+	pipeline reader.Reader
+	state    state
+	done     bool
+
+	// next is the message read ahead of the one being published, so the last
+	// message of the member is published with EOF set in its cursor.
+	next    reader.Message
+	nextErr error
+}
+
+// OpenSession opens (or resumes) the reading of an archive member. Members
+// that were read up to their end are not read again. It implements
+// loginp.Harvester.
+func (inp *archiveInput) OpenSession(
+	ctx input.Context,
+	src loginp.Source,
+	_ string,
+	cursor loginp.Cursor,
+	metrics *loginp.Metrics,
+) (loginp.HarvesterSession, error) {
+	as, ok := src.(archiveMemberSource)
+	if !ok {
+		return nil, fmt.Errorf("not archive member source")
+	}
+
+	s := &archiveSession{
+		inp:     inp,
+		log:     ctx.Logger,
+		src:     as,
+		metrics: metrics,
+	}
+
+	if !cursor.IsNew() {
+		if err := cursor.Unpack(&s.state); err != nil {
+			s.log.Errorf("Cannot unpack cursor of archive member '%s', reading it from the start: %v", as.LogPath(), err)
+			s.state = state{}
+		}
+	}
+
+	if s.state.EOF {
+		s.log.Debugf("Archive member already read to EOF, not reading it again, member '%s'", as.LogPath())
+		s.done = true
+	}
+
+	return s, nil
+}
+
+// open opens the archive member, skips the data already published and
+// builds the reader pipeline.
+func (s *archiveSession) open() error {
+	member, err := openArchiveMember(s.src)
+	if err != nil {
+		return fmt.Errorf("cannot open archive member '%s': %w", s.src.LogPath(), err)
+	}
+
+	// Archive members can't be seeked, the data already published is read
+	// and discarded instead.
+	if s.state.Offset > 0 {
+		if _, err := io.CopyN(io.Discard, member, s.state.Offset); err != nil {
+			member.Close()
+			return fmt.Errorf("cannot skip to offset %d of archive member '%s': %w", s.state.Offset, s.src.LogPath(), err)
+		}
+	}
+
+	enc, err := s.inp.encodingFactory(member)
+	if err != nil {
+		member.Close()
+		return fmt.Errorf("failed to initialize encoding: %w", err)
+	}
+
+	cfg := s.inp.readerConfig
+	var r reader.Reader
+	r, err = readfile.NewEncodeReader(member, readfile.Config{
+		Codec:      enc,
+		BufferSize: cfg.BufferSize,
+		Terminator: cfg.LineTerminator,
+		MaxBytes:   cfg.MaxBytes * 4,
+		// The member doesn't grow, its last line must be read even if it
+		// isn't terminated.
+		CollectOnEOF: true,
+	}, s.log)
+	if err != nil {
+		member.Close()
+		return err
+	}
+
+	r = readfile.NewStripNewline(r, cfg.LineTerminator)
+	r = newArchiveMetaReader(r, s.src, s.state.Offset)
+	r = cfg.Parsers.Create(r, s.log)
+	r = readfile.NewLimitReader(r, cfg.MaxBytes)
+
+	s.member = member
+	s.pipeline = r
+	s.next, s.nextErr = r.Next()
+	return nil
+}
+
+// ReadSlice reads and publishes the events of the archive member up to its
+// end. It implements loginp.HarvesterSession.
+func (s *archiveSession) ReadSlice(
+	ctx input.Context,
+	p loginp.Publisher,
+) (loginp.SliceVerdict, error) {
+	if s.done {
+		return loginp.SliceDone, nil
+	}
+
+	if s.pipeline == nil {
+		if err := s.open(); err != nil {
+			s.log.Errorf("Archive member could not be opened for reading: %v", err)
+			s.metrics.ProcessingErrors.Inc()
+			s.done = true
+			return loginp.SliceDone, err
+		}
+	}
+
+	for ctx.Cancelation.Err() == nil {
+		message, err := s.next, s.nextErr
+		if err == nil {
+			s.next, s.nextErr = s.pipeline.Next()
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			s.log.Errorf("Read line error: %v", err)
+			s.metrics.ProcessingErrors.Inc()
+			s.done = true
+			return loginp.SliceDone, nil
+		}
+		// With CollectOnEOF an unterminated last line comes with io.EOF,
+		// a terminated one is followed by an empty message with io.EOF.
+		eof := errors.Is(err, io.EOF)
+		last := eof || (errors.Is(s.nextErr, io.EOF) && s.next.IsEmpty())
+
+		s.state.Offset += int64(message.Bytes) + int64(message.Offset)
+		s.state.EOF = last
+
+		if message.Bytes > 0 {
+			s.metrics.MessagesRead.Inc()
+		}
+
+		if !message.IsEmpty() && !(s.inp.hasLineFilter && s.inp.readerConfig.isDroppedLine(s.log, message.Content)) {
+			//nolint:gosec // message.Bytes is always positive
+			s.metrics.BytesProcessed.Add(uint64(message.Bytes))
+
+			if err := p.Publish(message.ToEvent(), s.state); err != nil {
+				s.metrics.ProcessingErrors.Inc()
+				return loginp.SliceDone, err
+			}
+
+			s.metrics.EventsProcessed.Inc()
+			s.metrics.ProcessingTime.Update(time.Since(message.Ts).Nanoseconds())
+		}
+
+		if eof {
+			s.log.Debugf("EOF has been reached. Closing. Member='%s'", s.src.LogPath())
+			s.done = true
+			return loginp.SliceDone, nil
+		}
+	}
+
+	return loginp.SliceDone, ctx.Cancelation.Err()
+}
+
+// Poll resumes the session until the member is read. It implements
+// loginp.HarvesterSession.
+func (s *archiveSession) Poll() loginp.PollResult {
+	if s.done {
+		return loginp.PollClose
+	}
+	return loginp.PollResume
+}
+
+// Offset returns the current read offset in the uncompressed member.
+func (s *archiveSession) Offset() int64 { return s.state.Offset }
+
+// IsCompressed always returns false, archive members are reported as plain files.
+func (s *archiveSession) IsCompressed() bool { return false }
+
+// Close releases the archive file held by the session.
+func (s *archiveSession) Close() error {
+	if s.member == nil {
+		return nil
+	}
+
+	// The pipeline closes the member reader, which closes the archive file.
+	err := s.pipeline.Close()
+	s.member = nil
+	s.pipeline = nil
+	if err != nil {
+		s.log.Errorf("Error closing archive member reader: %v", err)
+	}
+	return err
+}
+
+// archiveMemberSources returns the sources of the selected members of the
+// archive at path.
+func archiveMemberSources(path string, info archiveInfo, members archiveMembersConfig) []archiveMemberSource {
+	sources := make([]archiveMemberSource, 0, len(info.members))
+	for _, m := range info.members {
+		if !members.selected(m.path) {
+			continue
+		}
+		sources = append(sources, archiveMemberSource{
+			archivePath: path,
+			fingerprint: info.fingerprint,
+			member:      m.path,
+			format:      info.format,
+			compression: info.compression,
+		})
+	}
+	return slices.Clip(sources)
+}
@@ -0,0 +1,247 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filestream
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	loginp "github.com/elastic/beats/v7/filebeat/input/filestream/internal/input-logfile"
+	input "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/libbeat/common/transform/typeconv"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// archiveProspector periodically scans the configured paths for archives,
+// and starts a harvester for each of their selected members.
+type archiveProspector struct {
+	logger        *logp.Logger
+	paths         []string
+	checkInterval time.Duration
+	members       archiveMembersConfig
+	cleanRemoved  bool
+	// cleaned is true once the states of the removed archives have been
+	// cleaned.
+	cleaned bool
+
+	// archives caches the archives found by the previous scans, so
+	// unchanged archives are neither listed nor hashed again.
+	archives map[string]archiveCacheEntry
+}
+
+type archiveCacheEntry struct {
+	size    int64
+	modTime time.Time
+	// fingerprint is the fingerprint of the archive, empty if the file is
+	// not an archive or couldn't be read.
+	fingerprint string
+	// started is true once the harvesters of the archive members have been
+	// started.
+	started bool
+}
+
+// Init is a no-op: the states of the removed archives are cleaned by the
+// first scan that sees all the archives, see cleanRemovedStates.
+func (p *archiveProspector) Init(
+	_,
+	_ loginp.StoreUpdater,
+	_ func(loginp.Source) string,
+) error {
+	return nil
+}
+
+// TakeOver is a no-op: there is no other input that archive members can be
+// taken over from.
+func (p *archiveProspector) TakeOver(loginp.StoreUpdater, func(loginp.Source) string) error {
+	return nil
+}
+
+// Run scans the configured paths every check_interval until the input is
+// stopped.
+func (p *archiveProspector) Run(
+	ctx input.Context,
+	s loginp.StateMetadataUpdater,
+	hg loginp.HarvesterGroup,
+	metrics *loginp.Metrics,
+) {
+	p.logger.Debug("Starting prospector")
+	defer p.logger.Debug("Prospector has stopped")
+	defer metrics.Cleanup()
+	defer func() {
+		if err := hg.StopHarvesters(); err != nil {
+			ctx.Logger.Errorf("Error while stopping harvester group: %v", err)
+		}
+	}()
+
+	tick := time.NewTicker(p.checkInterval)
+	defer tick.Stop()
+	for {
+		p.scan(ctx, s, hg)
+
+		select {
+		case <-ctx.Cancelation.Done():
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// scan starts the harvesters of the archives that were not seen yet, or that
+// changed since the previous scan.
+func (p *archiveProspector) scan(ctx input.Context, s loginp.StateMetadataUpdater, hg loginp.HarvesterGroup) {
+	// complete is false if an archive couldn't be read, then the states of
+	// its members must be kept.
+	complete := true
+	found := map[string]struct{}{}
+	for _, pattern := range p.paths {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			p.logger.Errorf("Glob pattern '%s' failed: %v", pattern, err)
+			complete = false
+			continue
+		}
+
+		for _, path := range matches {
+			if ctx.Cancelation.Err() != nil {
+				return
+			}
+
+			fi, err := os.Stat(path)
+			if err != nil {
+				p.logger.Debugf("Cannot stat '%s': %v", path, err)
+				continue
+			}
+			if !fi.Mode().IsRegular() {
+				continue
+			}
+			found[path] = struct{}{}
+
+			cached, ok := p.archives[path]
+			if ok && cached.size == fi.Size() && cached.modTime.Equal(fi.ModTime()) && cached.started {
+				continue
+			}
+			p.archives[path] = archiveCacheEntry{size: fi.Size(), modTime: fi.ModTime()}
+
+			fingerprint, ok := p.startArchive(ctx, path, s, hg)
+			if !ok {
+				complete = false
+				continue
+			}
+			p.archives[path] = archiveCacheEntry{
+				size:        fi.Size(),
+				modTime:     fi.ModTime(),
+				fingerprint: fingerprint,
+				started:     true,
+			}
+		}
+	}
+
+	for path := range p.archives {
+		if _, ok := found[path]; !ok {
+			delete(p.archives, path)
+		}
+	}
+
+	if complete && p.cleanRemoved && !p.cleaned {
+		p.cleanRemovedStates(s)
+		p.cleaned = true
+	}
+}
+
+// cleanRemovedStates removes the states of the members of the archives that
+// were not found by the scan. Archives are identified by their fingerprint,
+// so the states of an archive that was moved or renamed are kept.
+func (p *archiveProspector) cleanRemovedStates(s loginp.StateMetadataUpdater) {
+	fingerprints := make(map[string]struct{}, len(p.archives))
+	for _, cached := range p.archives {
+		if cached.fingerprint != "" {
+			fingerprints[cached.fingerprint] = struct{}{}
+		}
+	}
+
+	var removed []archiveMemberSource
+	s.IterateOnPrefix(func(_ string, meta any) {
+		var am archiveMeta
+		if err := typeconv.Convert(&am, meta); err != nil || am.Fingerprint == "" {
+			return
+		}
+		if _, ok := fingerprints[am.Fingerprint]; !ok {
+			removed = append(removed, archiveMemberSource{
+				archivePath: am.Source,
+				fingerprint: am.Fingerprint,
+				member:      am.Member,
+			})
+		}
+	})
+
+	// The states are removed once the iteration is over, as it holds the
+	// store lock.
+	for _, src := range removed {
+		p.logger.Debugf("Archive of %s was removed, its state is cleaned", src.LogPath())
+		if err := s.Remove(src); err != nil {
+			p.logger.Errorf("Failed to remove state of %s: %v", src.LogPath(), err)
+		}
+	}
+}
+
+// startArchive lists the members of the archive at path and starts their
+// harvesters. It returns the fingerprint of the archive, and false if the
+// archive couldn't be read, so it's retried on the next scan.
+func (p *archiveProspector) startArchive(
+	ctx input.Context,
+	path string,
+	s loginp.StateMetadataUpdater,
+	hg loginp.HarvesterGroup,
+) (string, bool) {
+	info, err := readArchive(path)
+	if err != nil {
+		if errors.Is(err, errNotArchive) {
+			p.logger.Warnf("File '%s' is not a zip or tar archive, it will be ignored", path)
+			// Not retried until the file changes.
+			return "", true
+		}
+		// The archive might still be being written, retry on the next scan.
+		p.logger.Debugf("Cannot read archive '%s', it will be retried: %v", path, err)
+		return "", false
+	}
+
+	p.logger.Debugf("Archive '%s' (%s) with %d members found", path, info.format, len(info.members))
+	for _, src := range archiveMemberSources(path, info, p.members) {
+		err := s.UpdateMetadata(src, archiveMeta{
+			Source:      path,
+			Fingerprint: info.fingerprint,
+			Member:      src.member,
+		})
+		if err != nil {
+			p.logger.Errorf("Failed to set cursor meta data of entry %s: %v", src.Name(), err)
+		}
+		hg.Start(ctx, src)
+	}
+	return info.fingerprint, true
+}
+
+func (p *archiveProspector) Test() error {
+	for _, pattern := range p.paths {
+		if _, err := filepath.Glob(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
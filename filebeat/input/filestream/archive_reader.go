@@ -0,0 +1,332 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filestream
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/elastic/beats/v7/libbeat/reader"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// archiveFormat is the container format of an archive.
+type archiveFormat string
+
+const (
+	archiveZip archiveFormat = "zip"
+	archiveTar archiveFormat = "tar"
+)
+
+const (
+	zipMagic      = "PK\x03\x04"
+	zipEmptyMagic = "PK\x05\x06" // end of central directory of an empty archive
+	tarMagic      = "ustar"      // POSIX and GNU tar
+	tarMagicAt    = 257
+	tarBlockSize  = 512
+)
+
+var errNotArchive = errors.New("not a zip or tar archive")
+
+// archiveInfo describes an archive and its regular file members.
+type archiveInfo struct {
+	format archiveFormat
+	// compression is the compression format of a compressed tar archive,
+	// nil for plain tar and zip archives.
+	compression *compressionFormat
+	// fingerprint is the hex encoded SHA-256 of the archive file.
+	fingerprint string
+	members     []archiveMember
+}
+
+type archiveMember struct {
+	path string
+	size int64
+}
+
+// readArchive detects the format of the archive at path, and lists its
+// members. It returns errNotArchive if the file isn't an archive. Archives
+// are listed in full, so an archive that is still being written fails to
+// be read instead of returning only some of its members.
+func readArchive(path string) (archiveInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return archiveInfo{}, err
+	}
+	defer f.Close()
+
+	var info archiveInfo
+	info.format, info.compression, err = detectArchive(f)
+	if err != nil {
+		return archiveInfo{}, err
+	}
+
+	switch info.format {
+	case archiveZip:
+		info.members, err = listZip(f)
+	case archiveTar:
+		info.members, err = listTar(f, info.compression)
+	}
+	if err != nil {
+		return archiveInfo{}, fmt.Errorf("cannot list members of %s archive: %w", info.format, err)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return archiveInfo{}, err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return archiveInfo{}, fmt.Errorf("cannot compute archive fingerprint: %w", err)
+	}
+	info.fingerprint = hex.EncodeToString(h.Sum(nil))
+
+	return info, nil
+}
+
+// detectArchive detects the format of the archive f from its magic bytes.
+// Tar archives can be compressed in any of the formats of the 'compression'
+// option.
+func detectArchive(f *os.File) (archiveFormat, *compressionFormat, error) {
+	header := make([]byte, tarBlockSize)
+	n, err := f.ReadAt(header, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", nil, fmt.Errorf("failed to read magic bytes: %w", err)
+	}
+	header = header[:n]
+
+	if bytes.HasPrefix(header, []byte(zipMagic)) || bytes.HasPrefix(header, []byte(zipEmptyMagic)) {
+		return archiveZip, nil, nil
+	}
+	if isTarHeader(header) {
+		return archiveTar, nil, nil
+	}
+
+	format, compressed, err := detectCompression(f)
+	if err != nil || !compressed {
+		return "", nil, errors.Join(errNotArchive, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", nil, err
+	}
+	dec, err := format.newReader(f)
+	if err != nil {
+		return "", nil, errors.Join(errNotArchive, err)
+	}
+	defer dec.Close()
+
+	header = make([]byte, tarBlockSize)
+	if _, err := io.ReadFull(dec, header); err != nil || !isTarHeader(header) {
+		return "", nil, errNotArchive
+	}
+	return archiveTar, &format, nil
+}
+
+func isTarHeader(header []byte) bool {
+	return len(header) >= tarMagicAt+len(tarMagic) &&
+		string(header[tarMagicAt:tarMagicAt+len(tarMagic)]) == tarMagic
+}
+
+func listZip(f *os.File) ([]archiveMember, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(f, fi.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	var members []archiveMember
+	for _, zf := range zr.File {
+		if !zf.Mode().IsRegular() {
+			continue
+		}
+		members = append(members, archiveMember{
+			path: zf.Name,
+			size: int64(zf.UncompressedSize64), //nolint:gosec // sizes of real archives fit in int64
+		})
+	}
+	return members, nil
+}
+
+func listTar(f *os.File, compression *compressionFormat) ([]archiveMember, error) {
+	tr, closer, err := newTarReader(f, compression)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	var members []archiveMember
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return members, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !hdr.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		members = append(members, archiveMember{
+			path: hdr.Name,
+			size: hdr.Size,
+		})
+	}
+}
+
+// newTarReader returns a tar reader of the (possibly compressed) archive f,
+// read from its start. The returned closer releases the decompressor.
+func newTarReader(f *os.File, compression *compressionFormat) (*tar.Reader, io.Closer, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	if compression == nil {
+		return tar.NewReader(f), io.NopCloser(nil), nil
+	}
+
+	dec, err := compression.newReader(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create %s reader: %w", compression.name, err)
+	}
+	return tar.NewReader(dec), dec, nil
+}
+
+// openArchiveMember opens the member of the archive described by src. The
+// returned reader yields the uncompressed content of the member, closing it
+// closes the archive file.
+func openArchiveMember(src archiveMemberSource) (io.ReadCloser, error) {
+	f, err := os.Open(src.archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader
+	var closer io.Closer = io.NopCloser(nil)
+	switch src.format {
+	case archiveZip:
+		r, err = openZipMember(f, src.member)
+		if rc, ok := r.(io.Closer); ok {
+			closer = rc
+		}
+	case archiveTar:
+		r, closer, err = openTarMember(f, src.compression, src.member)
+	default:
+		err = fmt.Errorf("unknown archive format %q", src.format)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &archiveMemberReader{Reader: r, member: closer, file: f}, nil
+}
+
+func openZipMember(f *os.File, member string) (io.Reader, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(f, fi.Size())
+	if err != nil {
+		return nil, err
+	}
+	for _, zf := range zr.File {
+		if zf.Name == member && zf.Mode().IsRegular() {
+			return zf.Open()
+		}
+	}
+	return nil, fmt.Errorf("member %q not found: %w", member, os.ErrNotExist)
+}
+
+func openTarMember(f *os.File, compression *compressionFormat, member string) (io.Reader, io.Closer, error) {
+	tr, closer, err := newTarReader(f, compression)
+	if err != nil {
+		return nil, nil, err
+	}
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			closer.Close()
+			if errors.Is(err, io.EOF) {
+				return nil, nil, fmt.Errorf("member %q not found: %w", member, os.ErrNotExist)
+			}
+			return nil, nil, err
+		}
+		if hdr.Name == member && hdr.FileInfo().Mode().IsRegular() {
+			return tr, closer, nil
+		}
+	}
+}
+
+// archiveMemberReader reads the content of an archive member, and releases
+// the member and archive file on Close.
+type archiveMemberReader struct {
+	io.Reader
+	member io.Closer
+	file   *os.File
+}
+
+func (r *archiveMemberReader) Close() error {
+	return errors.Join(r.member.Close(), r.file.Close())
+}
+
+// archiveMetaReader adds the metadata of the archive member to every
+// message. log.file.path is the path of the member inside the archive.
+type archiveMetaReader struct {
+	reader reader.Reader
+	src    archiveMemberSource
+	offset int64
+}
+
+func newArchiveMetaReader(r reader.Reader, src archiveMemberSource, offset int64) *archiveMetaReader {
+	return &archiveMetaReader{reader: r, src: src, offset: offset}
+}
+
+func (r *archiveMetaReader) Next() (reader.Message, error) {
+	message, err := r.reader.Next()
+	if message.IsEmpty() {
+		r.offset += int64(message.Bytes)
+		return message, err
+	}
+
+	message.Fields["log"] = mapstr.M{
+		"offset": r.offset,
+		"file": mapstr.M{
+			"path": r.src.member,
+			"archive": mapstr.M{
+				"path":        r.src.archivePath,
+				"fingerprint": r.src.fingerprint,
+			},
+		},
+	}
+	r.offset += int64(message.Bytes)
+
+	return message, err
+}
+
+func (r *archiveMetaReader) Close() error {
+	return r.reader.Close()
+}
@@ -0,0 +1,367 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filestream
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	loginp "github.com/elastic/beats/v7/filebeat/input/filestream/internal/input-logfile"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/match"
+	"github.com/elastic/beats/v7/libbeat/reader/readfile/encoding"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// archiveTestMembers are the members of the archives built by the tests, in
+// the order they are written.
+var archiveTestMembers = []struct {
+	name    string
+	content string
+}{
+	{"logs/app.log", "app line 1\napp line 2\napp line 3\n"},
+	{"logs/db.log", "db line 1\ndb line 2 without newline"},
+	{"README", "not a log\n"},
+}
+
+func writeTarArchive(t *testing.T, path string, gzipped bool) {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "logs/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	for _, m := range archiveTestMembers {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:    m.name,
+			Mode:    0o644,
+			Size:    int64(len(m.content)),
+			ModTime: time.Unix(1700000000, 0),
+		}))
+		_, err := tw.Write([]byte(m.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	data := buf.Bytes()
+	if gzipped {
+		var gzBuf bytes.Buffer
+		gw := gzip.NewWriter(&gzBuf)
+		_, err := gw.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, gw.Close())
+		data = gzBuf.Bytes()
+	}
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+}
+
+func writeZipArchive(t *testing.T, path string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	_, err := zw.Create("logs/")
+	require.NoError(t, err)
+	for _, m := range archiveTestMembers {
+		w, err := zw.Create(m.name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(m.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+}
+
+func TestReadArchive(t *testing.T) {
+	dir := t.TempDir()
+	tarPath := filepath.Join(dir, "bundle.tar")
+	tarGzPath := filepath.Join(dir, "bundle.tar.gz")
+	zipPath := filepath.Join(dir, "bundle.zip")
+	writeTarArchive(t, tarPath, false)
+	writeTarArchive(t, tarGzPath, true)
+	writeZipArchive(t, zipPath)
+
+	testCases := map[string]struct {
+		path        string
+		format      archiveFormat
+		compression string
+	}{
+		"tar":    {path: tarPath, format: archiveTar},
+		"tar.gz": {path: tarGzPath, format: archiveTar, compression: CompressionGZIP},
+		"zip":    {path: zipPath, format: archiveZip},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			info, err := readArchive(tc.path)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.format, info.format)
+			if tc.compression == "" {
+				assert.Nil(t, info.compression)
+			} else {
+				require.NotNil(t, info.compression)
+				assert.Equal(t, tc.compression, info.compression.name)
+			}
+			assert.Len(t, info.fingerprint, 64)
+
+			// Directories are not listed.
+			require.Len(t, info.members, len(archiveTestMembers))
+			for i, m := range archiveTestMembers {
+				assert.Equal(t, m.name, info.members[i].path)
+				assert.Equal(t, int64(len(m.content)), info.members[i].size)
+			}
+
+			// Reading the same archive gives the same fingerprint.
+			again, err := readArchive(tc.path)
+			require.NoError(t, err)
+			assert.Equal(t, info.fingerprint, again.fingerprint)
+		})
+	}
+
+	t.Run("plain log file", func(t *testing.T) {
+		path := filepath.Join(dir, "plain.log")
+		require.NoError(t, os.WriteFile(path, []byte("a log line\n"), 0o644))
+		_, err := readArchive(path)
+		assert.ErrorIs(t, err, errNotArchive)
+	})
+
+	t.Run("incomplete archive", func(t *testing.T) {
+		data, err := os.ReadFile(tarGzPath)
+		require.NoError(t, err)
+		path := filepath.Join(dir, "incomplete.tar.gz")
+		require.NoError(t, os.WriteFile(path, data[:len(data)-20], 0o644))
+
+		_, err = readArchive(path)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, errNotArchive)
+	})
+}
+
+func newTestArchiveInput(t *testing.T) *archiveInput {
+	t.Helper()
+	cfg := defaultReaderConfig()
+	encodingFactory, ok := encoding.FindEncoding(cfg.Encoding)
+	require.True(t, ok)
+	return &archiveInput{readerConfig: cfg, encodingFactory: encodingFactory}
+}
+
+func testArchiveSource(t *testing.T, path, member string) archiveMemberSource {
+	t.Helper()
+	info, err := readArchive(path)
+	require.NoError(t, err)
+	return archiveMemberSource{
+		archivePath: path,
+		fingerprint: info.fingerprint,
+		member:      member,
+		format:      info.format,
+		compression: info.compression,
+	}
+}
+
+// readArchiveMember reads the member with a session resuming from cursor,
+// and returns the published events and their cursor states.
+func readArchiveMember(t *testing.T, inp *archiveInput, src archiveMemberSource, cursor loginp.Cursor) ([]mapstr.M, []state) {
+	t.Helper()
+	sess, err := inp.OpenSession(backgroundCtx(), src, "id", cursor, testMetrics(t))
+	require.NoError(t, err)
+	t.Cleanup(func() { sess.Close() })
+
+	pub := &statePublisher{}
+	verdict, err := sess.ReadSlice(backgroundCtx(), pub)
+	require.NoError(t, err)
+	require.Equal(t, loginp.SliceDone, verdict)
+	require.Equal(t, loginp.PollClose, sess.Poll())
+	require.NoError(t, sess.Close())
+
+	events := make([]mapstr.M, 0, len(pub.events))
+	for _, e := range pub.events {
+		events = append(events, e.Fields)
+	}
+	return events, pub.states
+}
+
+func TestArchiveSession(t *testing.T) {
+	dir := t.TempDir()
+	tarGzPath := filepath.Join(dir, "bundle.tar.gz")
+	zipPath := filepath.Join(dir, "bundle.zip")
+	writeTarArchive(t, tarGzPath, true)
+	writeZipArchive(t, zipPath)
+
+	for _, path := range []string{tarGzPath, zipPath} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			inp := newTestArchiveInput(t)
+
+			t.Run("reads a member to its end", func(t *testing.T) {
+				src := testArchiveSource(t, path, "logs/db.log")
+				events, states := readArchiveMember(t, inp, src, loginp.NewCursorForTest("id", 0, 0))
+
+				require.Len(t, events, 2)
+				assert.Equal(t, "db line 1", events[0]["message"])
+				assert.Equal(t, "db line 2 without newline", events[1]["message"])
+
+				for field, want := range map[string]any{
+					"log.offset":                   int64(len("db line 1\n")),
+					"log.file.path":                "logs/db.log",
+					"log.file.archive.path":        path,
+					"log.file.archive.fingerprint": src.fingerprint,
+				} {
+					got, err := events[1].GetValue(field)
+					require.NoError(t, err, field)
+					assert.Equal(t, want, got, field)
+				}
+
+				assert.Equal(t, state{Offset: 10}, states[0])
+				assert.Equal(t, state{Offset: int64(len(archiveTestMembers[1].content)), EOF: true}, states[1])
+			})
+
+			t.Run("resumes from the cursor offset", func(t *testing.T) {
+				src := testArchiveSource(t, path, "logs/app.log")
+				offset := int64(len("app line 1\n"))
+				events, states := readArchiveMember(t, inp, src, loginp.NewCursorForTest("id", offset, 0))
+
+				require.Len(t, events, 2)
+				assert.Equal(t, "app line 2", events[0]["message"])
+				assert.Equal(t, "app line 3", events[1]["message"])
+				assert.True(t, states[len(states)-1].EOF)
+			})
+
+			t.Run("missing member", func(t *testing.T) {
+				src := testArchiveSource(t, path, "logs/missing.log")
+				sess, err := inp.OpenSession(backgroundCtx(), src, "id", loginp.NewCursorForTest("id", 0, 0), testMetrics(t))
+				require.NoError(t, err)
+				_, err = sess.ReadSlice(backgroundCtx(), &statePublisher{})
+				assert.ErrorIs(t, err, os.ErrNotExist)
+				assert.Equal(t, loginp.PollClose, sess.Poll())
+			})
+		})
+	}
+
+	t.Run("member read to EOF is not read again", func(t *testing.T) {
+		inp := newTestArchiveInput(t)
+		s := &archiveSession{
+			inp:     inp,
+			log:     logp.NewNopLogger(),
+			src:     testArchiveSource(t, zipPath, "logs/app.log"),
+			metrics: testMetrics(t),
+			state:   state{Offset: 33, EOF: true},
+			done:    true,
+		}
+		pub := &statePublisher{}
+		verdict, err := s.ReadSlice(backgroundCtx(), pub)
+		require.NoError(t, err)
+		assert.Equal(t, loginp.SliceDone, verdict)
+		assert.Empty(t, pub.events)
+	})
+}
+
+func TestArchiveProspector(t *testing.T) {
+	dir := t.TempDir()
+	writeTarArchive(t, filepath.Join(dir, "a.tar.gz"), true)
+	writeZipArchive(t, filepath.Join(dir, "b.zip"))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.log"), []byte("plain\n"), 0o644))
+
+	newProspector := func() *archiveProspector {
+		return &archiveProspector{
+			logger:        logp.NewNopLogger(),
+			paths:         []string{filepath.Join(dir, "*")},
+			checkInterval: time.Second,
+			members: archiveMembersConfig{
+				Include: []match.Matcher{match.MustCompile(`\.log$`)},
+			},
+			archives: map[string]archiveCacheEntry{},
+		}
+	}
+
+	t.Run("starts the selected members once", func(t *testing.T) {
+		p := newProspector()
+		hg := newTestHarvesterGroup()
+		updater := newMockMetadataUpdater()
+
+		p.scan(backgroundCtx(), updater, hg)
+		require.Len(t, hg.events, 4)
+		for _, e := range hg.events {
+			assert.IsType(t, harvesterStart(""), e)
+			assert.NotContains(t, e.String(), "README")
+		}
+		assert.Equal(t, 4, updater.UpdateMetadataCalled)
+
+		// Unchanged archives are not started again.
+		p.scan(backgroundCtx(), updater, hg)
+		assert.Len(t, hg.events, 4)
+	})
+
+	t.Run("identity does not depend on the archive path", func(t *testing.T) {
+		data, err := os.ReadFile(filepath.Join(dir, "b.zip"))
+		require.NoError(t, err)
+		copyDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(copyDir, "copy.zip"), data, 0o644))
+
+		a := testArchiveSource(t, filepath.Join(dir, "b.zip"), "logs/app.log")
+		b := testArchiveSource(t, filepath.Join(copyDir, "copy.zip"), "logs/app.log")
+		assert.Equal(t, a.Name(), b.Name())
+		assert.NotEqual(t, a.LogPath(), b.LogPath())
+	})
+
+	t.Run("cleans the states of removed archives only", func(t *testing.T) {
+		// The archive was moved while the input was stopped.
+		data, err := os.ReadFile(filepath.Join(dir, "b.zip"))
+		require.NoError(t, err)
+		movedDir := t.TempDir()
+		moved := filepath.Join(movedDir, "moved.zip")
+		require.NoError(t, os.WriteFile(moved, data, 0o644))
+
+		src := testArchiveSource(t, filepath.Join(dir, "b.zip"), "logs/app.log")
+		gone := archiveMemberSource{fingerprint: strings.Repeat("0", 64), member: "app.log"}
+		for _, cleanRemoved := range []bool{false, true} {
+			updater := newMockMetadataUpdater()
+			updater.setRaw(src.Name(), archiveMeta{Source: src.archivePath, Fingerprint: src.fingerprint, Member: src.member})
+			updater.setRaw(gone.Name(), archiveMeta{Source: filepath.Join(dir, "gone.zip"), Fingerprint: gone.fingerprint, Member: gone.member})
+
+			p := newProspector()
+			p.paths = []string{filepath.Join(movedDir, "*")}
+			p.cleanRemoved = cleanRemoved
+			p.scan(backgroundCtx(), updater, newTestHarvesterGroup())
+
+			assert.True(t, updater.has(src.Name()), "the state of a moved archive must be kept")
+			assert.Equal(t, !cleanRemoved, updater.has(gone.Name()))
+		}
+	})
+}
+
+// statePublisher records published events and their cursor states.
+type statePublisher struct {
+	countingPublisher
+	states []state
+}
+
+func (p *statePublisher) Publish(e beat.Event, cursor any) error {
+	if err := p.countingPublisher.Publish(e, cursor); err != nil {
+		return err
+	}
+	p.states = append(p.states, cursor.(state)) //nolint:errcheck // it's a test
+	return nil
+}
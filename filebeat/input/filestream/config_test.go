@@ -79,6 +79,9 @@ func TestConfigValidate(t *testing.T) {
 		}{
 			{name: "none is valid", compression: CompressionNone},
 			{name: "gzip is valid", compression: CompressionGZIP},
+			{name: "zstd is valid", compression: CompressionZSTD},
+			{name: "bzip2 is valid", compression: CompressionBZIP2},
+			{name: "xz is valid", compression: CompressionXZ},
 			{name: "auto is valid", compression: CompressionAuto},
 			{name: "invalid value returns error", compression: "invalid", wantErr: `invalid compression value "invalid"`},
 		}
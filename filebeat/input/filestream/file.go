@@ -19,6 +19,7 @@ package filestream
 
 import (
 	"bytes"
+	"compress/bzip2"
 	"errors"
 	"fmt"
 	"io"
@@ -26,12 +27,90 @@ import (
 	"os"
 
 	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
 )
 
-const (
-	magicHeader = "\x1f\x8b" // RFC 1952 magic bytes
+// compressionFormat is a compression format filestream can decompress.
+type compressionFormat struct {
+	// name is the value of the 'compression' option selecting the format.
+	name string
+	// magic is the magic bytes compressed files start with.
+	magic string
+	// check optionally checks the header of the files starting with the
+	// magic bytes.
+	check func(header []byte) bool
+	// newReader returns a reader decompressing r.
+	newReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	gzipFormat = compressionFormat{
+		name:  CompressionGZIP,
+		magic: "\x1f\x8b", // RFC 1952
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	}
+	zstdFormat = compressionFormat{
+		name:  CompressionZSTD,
+		magic: "\x28\xb5\x2f\xfd", // RFC 8878
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+			return d.IOReadCloser(), nil
+		},
+	}
+	bzip2Format = compressionFormat{
+		name:  CompressionBZIP2,
+		magic: "BZh",
+		// The magic bytes are followed by the block size, from '1' to '9'.
+		check: func(header []byte) bool {
+			return len(header) > 3 && header[3] >= '1' && header[3] <= '9'
+		},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(bzip2.NewReader(r)), nil
+		},
+	}
+	xzFormat = compressionFormat{
+		name:  CompressionXZ,
+		magic: "\xfd7zXZ\x00",
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			xzr, err := xz.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(xzr), nil
+		},
+	}
+
+	// compressionFormats are the formats detected by the "auto" compression
+	// mode.
+	compressionFormats = []compressionFormat{gzipFormat, zstdFormat, bzip2Format, xzFormat}
 )
 
+// matches returns true if header is the header of a file compressed in the
+// format.
+func (f compressionFormat) matches(header []byte) bool {
+	if !bytes.HasPrefix(header, []byte(f.magic)) {
+		return false
+	}
+	return f.check == nil || f.check(header)
+}
+
+// lookupCompressionFormat returns the compression format selected by the
+// 'compression' option value name.
+func lookupCompressionFormat(name string) (compressionFormat, bool) {
+	for _, format := range compressionFormats {
+		if format.name == name {
+			return format, true
+		}
+	}
+	return compressionFormat{}, false
+}
+
 type File interface {
 	fs.File
 	io.ReadSeekCloser
@@ -40,8 +119,8 @@ type File interface {
 	Name() string
 	// OSFile returns the underlying *os.File.
 	OSFile() *os.File
-	// IsGZIP returns true if the file is a GZIP file.
-	IsGZIP() bool
+	// IsCompressed returns true if the file is a compressed file.
+	IsCompressed() bool
 }
 
 // plainFile is a wrapper around an *os.File that implements the File interface.
@@ -50,7 +129,7 @@ type plainFile struct {
 	*os.File
 }
 
-func (pf *plainFile) IsGZIP() bool {
+func (pf *plainFile) IsCompressed() bool {
 	return false
 }
 
@@ -64,61 +143,66 @@ func (pf *plainFile) OSFile() *os.File {
 	return pf.File
 }
 
-type gzipSeekerReader struct {
-	f        *os.File     // underlying gzip-compressed file
-	gzr      *gzip.Reader // reader that yields uncompressed bytes
-	buffSize int64        // buffer size used when emulating seeks
+// compressedSeekerReader reads the decompressed data of a compressed file.
+// Offsets are in the decompressed data, seeks are emulated by decompressing
+// the file from its start.
+type compressedSeekerReader struct {
+	f        *os.File          // underlying compressed file
+	format   compressionFormat // compression format of f
+	dec      io.ReadCloser     // reader that yields uncompressed bytes
+	buffSize int64             // buffer size used when emulating seeks
 
 	// offset is the current offset in the *decompressed* stream. It's updated
 	// by read.
 	offset int64
 }
 
-func newGzipSeekerReader(f *os.File, buffSize int) (*gzipSeekerReader, error) {
-	gzr, err := gzip.NewReader(f)
+func newCompressedSeekerReader(f *os.File, format compressionFormat, buffSize int) (*compressedSeekerReader, error) {
+	dec, err := format.newReader(f)
 	if err != nil {
-		return nil, fmt.Errorf("could not create gzip reader: %w", err)
+		return nil, fmt.Errorf("could not create %s reader: %w", format.name, err)
 	}
 
-	return &gzipSeekerReader{
+	return &compressedSeekerReader{
 		f:        f,
-		gzr:      gzr,
+		format:   format,
+		dec:      dec,
 		buffSize: int64(buffSize),
 		offset:   0,
 	}, nil
 }
 
-func (r *gzipSeekerReader) IsGZIP() bool {
+func (r *compressedSeekerReader) IsCompressed() bool {
 	return true
 }
 
 // Stat returns Stat() of the underlying *os.File.
-func (r *gzipSeekerReader) Stat() (fs.FileInfo, error) {
+func (r *compressedSeekerReader) Stat() (fs.FileInfo, error) {
 	return r.f.Stat()
 }
 
 // Name returns Name() of the underlying *os.File.
-func (r *gzipSeekerReader) Name() string {
+func (r *compressedSeekerReader) Name() string {
 	return r.f.Name()
 }
 
 // OSFile returns the underlying *os.File.
-func (r *gzipSeekerReader) OSFile() *os.File {
+func (r *compressedSeekerReader) OSFile() *os.File {
 	return r.f
 }
 
 // Read reads plain data, decompressing it on the fly.
-func (r *gzipSeekerReader) Read(p []byte) (n int, err error) {
-	n, err = r.gzr.Read(p)
+func (r *compressedSeekerReader) Read(p []byte) (n int, err error) {
+	n, err = r.dec.Read(p)
 
 	r.offset += int64(n)
 	return n, err
 }
 
-func (r *gzipSeekerReader) Close() error {
-	gzerr := r.gzr.Close()
-	if gzerr != nil {
-		gzerr = fmt.Errorf("could not close gzip reader: %w", gzerr)
+func (r *compressedSeekerReader) Close() error {
+	decerr := r.dec.Close()
+	if decerr != nil {
+		decerr = fmt.Errorf("could not close %s reader: %w", r.format.name, decerr)
 	}
 
 	plainerr := r.f.Close()
@@ -126,13 +210,13 @@ func (r *gzipSeekerReader) Close() error {
 		plainerr = fmt.Errorf("could not close plain file: %w", plainerr)
 	}
 
-	return errors.Join(gzerr, plainerr)
+	return errors.Join(decerr, plainerr)
 }
 
 // Seek seeks to offset within the *decompressed* data stream.
-func (r *gzipSeekerReader) Seek(offset int64, whence int) (int64, error) {
+func (r *compressedSeekerReader) Seek(offset int64, whence int) (int64, error) {
 	if whence >= io.SeekEnd {
-		return 0, fmt.Errorf("gzipSeekerReader: SeekEnd (2) is unsupported")
+		return 0, fmt.Errorf("compressedSeekerReader: SeekEnd (2) is unsupported")
 	}
 
 	finalOffset := offset
@@ -144,7 +228,7 @@ func (r *gzipSeekerReader) Seek(offset int64, whence int) (int64, error) {
 
 	if finalOffset < 0 {
 		return 0, fmt.Errorf(
-			"gzipSeekerReader: final offset must be non-negative, got: %d",
+			"compressedSeekerReader: final offset must be non-negative, got: %d",
 			finalOffset)
 	}
 
@@ -154,14 +238,17 @@ func (r *gzipSeekerReader) Seek(offset int64, whence int) (int64, error) {
 		n, err := r.f.Seek(0, 0)
 		if err != nil {
 			return n, fmt.Errorf(
-				"gzipSeekerReader: could not seek to 0: %w", err)
+				"compressedSeekerReader: could not seek to 0: %w", err)
 		}
 
-		err = r.gzr.Reset(r.f)
+		dec, err := r.format.newReader(r.f)
 		if err != nil {
 			return n, fmt.Errorf(
-				"gzipSeekerReader: could not reset gzip reader: %w", err)
+				"compressedSeekerReader: could not reset %s reader: %w",
+				r.format.name, err)
 		}
+		_ = r.dec.Close()
+		r.dec = dec
 		r.offset = 0
 
 		// nothing to advance, we're done
@@ -175,38 +262,20 @@ func (r *gzipSeekerReader) Seek(offset int64, whence int) (int64, error) {
 		return finalOffset, nil
 	}
 
-	// Calculate how many bytes we need to advance from current position
-	bytesToAdvance := finalOffset - r.offset
-
-	var err error
-	if bytesToAdvance <= r.buffSize {
-		_, err = r.Read(make([]byte, bytesToAdvance))
-		if err != nil && !errors.Is(err, io.EOF) {
-			return r.offset, fmt.Errorf(
-				"gzipSeekerReader: could read bytesToAdvance=%d: %w",
-				bytesToAdvance, err)
-		}
-
-		r.offset = finalOffset
-		return r.offset, nil
-	}
-
-	chunks := bytesToAdvance / r.buffSize
-	leftover := bytesToAdvance % r.buffSize
-	buff := make([]byte, r.buffSize)
-	for i := range chunks {
-		_, err = r.gzr.Read(buff)
-		if err != nil && !errors.Is(err, io.EOF) {
-			return r.offset, fmt.Errorf(
-				"gzipSeekerReader: could read chunk %d: %w", i, err)
+	// Decompress and discard the data up to the target offset. Decompressors
+	// can return less data than requested, so read until the target offset
+	// or the end of the data is reached.
+	buff := make([]byte, min(finalOffset-r.offset, r.buffSize))
+	for r.offset < finalOffset {
+		n := min(finalOffset-r.offset, int64(len(buff)))
+		_, err := io.ReadFull(r, buff[:n])
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
 		}
-	}
-
-	if leftover > 0 {
-		_, err = r.Read(make([]byte, leftover))
-		if err != nil && !errors.Is(err, io.EOF) {
+		if err != nil {
 			return r.offset, fmt.Errorf(
-				"gzipSeekerReader: could read leftover %d: %w", leftover, err)
+				"compressedSeekerReader: could not advance to offset %d: %w",
+				finalOffset, err)
 		}
 	}
 
@@ -214,26 +283,31 @@ func (r *gzipSeekerReader) Seek(offset int64, whence int) (int64, error) {
 	return finalOffset, nil
 }
 
-// IsGZIP reports whether the file f starts with the GZIP magic header bytes as
-// defined by RFC 1952. The file offset is reset to the original position before
-// returning.
-func IsGZIP(f *os.File) (bool, error) {
+// detectCompression returns the compression format of the file f, detected
+// from its magic bytes. It returns false if f isn't compressed in any of the
+// supported formats. The file offset is reset to the original position
+// before returning.
+func detectCompression(f *os.File) (compressionFormat, bool, error) {
 	// Remember current offset so we can reset it afterward.
 	offset, err := f.Seek(0, io.SeekCurrent)
 	if err != nil {
-		return false, err
+		return compressionFormat{}, false, err
 	}
 	// Ensure we always reset the offset.
 	defer func() { _, _ = f.Seek(offset, io.SeekStart) }()
 
-	// Read magic bytes, 2 bytes.
-	header := make([]byte, len(magicHeader))
-	if _, err := f.ReadAt(header, 0); err != nil {
-		if errors.Is(err, io.EOF) {
-			return false, nil // empty or too short file – definitely not gzip
-		}
-		return false, fmt.Errorf("GZIP: failed to read magic bytes: %w", err)
+	// Read enough bytes for the longest magic bytes.
+	header := make([]byte, 6)
+	n, err := f.ReadAt(header, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return compressionFormat{}, false, fmt.Errorf("failed to read magic bytes: %w", err)
 	}
+	header = header[:n] // empty or short files can't match all formats
 
-	return bytes.Equal(header, []byte(magicHeader)), nil
+	for _, format := range compressionFormats {
+		if format.matches(header) {
+			return format, true, nil
+		}
+	}
+	return compressionFormat{}, false, nil
 }
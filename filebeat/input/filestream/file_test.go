@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -34,7 +35,7 @@ import (
 )
 
 var (
-	magicBytes   = []byte(magicHeader)
+	magicBytes   = []byte(gzipFormat.magic)
 	plainContent = []byte(
 		"People assume that time is a strict progression of cause to effect, " +
 			"but actually from a non-linear, non-subjective viewpoint, it's " +
@@ -42,7 +43,7 @@ var (
 )
 
 var _ File = (*plainFile)(nil)
-var _ File = (*gzipSeekerReader)(nil)
+var _ File = (*compressedSeekerReader)(nil)
 
 func TestPlainFile(t *testing.T) {
 	testContent := []byte("hello world")
@@ -50,8 +51,8 @@ func TestPlainFile(t *testing.T) {
 
 	pf := newPlainFile(osFile)
 
-	t.Run("IsGZIP returns false", func(t *testing.T) {
-		assert.False(t, pf.IsGZIP())
+	t.Run("IsCompressed returns false", func(t *testing.T) {
+		assert.False(t, pf.IsCompressed())
 	})
 
 	t.Run("OSFile returns underlying os.File", func(t *testing.T) {
@@ -59,34 +60,34 @@ func TestPlainFile(t *testing.T) {
 	})
 }
 
-func TestGzipSeekerReader(t *testing.T) {
-	t.Run("newGzipSeekerReader success", func(t *testing.T) {
+func TestCompressedSeekerReader_GZIP(t *testing.T) {
+	t.Run("newCompressedSeekerReader success", func(t *testing.T) {
 		osFile := createAndOpenFile(t, newGzippedDataSource(t))
-		gsr, err := newGzipSeekerReader(osFile, 1024)
+		gsr, err := newCompressedSeekerReader(osFile, gzipFormat, 1024)
 		require.NoError(t, err)
 		require.NotNil(t, gsr)
 	})
 
-	t.Run("newGzipSeekerReader error on non-gzip file", func(t *testing.T) {
+	t.Run("newCompressedSeekerReader error on non-gzip file", func(t *testing.T) {
 		osFile := createAndOpenFile(t, []byte("not gzip content"))
 
-		gsr, err := newGzipSeekerReader(osFile, 1024)
+		gsr, err := newCompressedSeekerReader(osFile, gzipFormat, 1024)
 		assert.Error(t, err)
 		assert.Nil(t, gsr)
 		assert.Contains(t, err.Error(), "could not create gzip reader")
 		assert.Contains(t, err.Error(), gzip.ErrHeader.Error())
 	})
-	t.Run("IsGZIP returns true", func(t *testing.T) {
+	t.Run("IsCompressed returns true", func(t *testing.T) {
 		osFile := createAndOpenFile(t, newGzippedDataSource(t))
-		gsr, err := newGzipSeekerReader(osFile, 1024)
+		gsr, err := newCompressedSeekerReader(osFile, gzipFormat, 1024)
 		require.NoError(t, err)
 
-		assert.True(t, gsr.IsGZIP())
+		assert.True(t, gsr.IsCompressed())
 	})
 
 	t.Run("OSFile returns underlying os.File", func(t *testing.T) {
 		osFile := createAndOpenFile(t, newGzippedDataSource(t))
-		gsr, err := newGzipSeekerReader(osFile, 1024)
+		gsr, err := newCompressedSeekerReader(osFile, gzipFormat, 1024)
 		require.NoError(t, err)
 
 		assert.Exactly(t, osFile, gsr.OSFile())
@@ -94,7 +95,7 @@ func TestGzipSeekerReader(t *testing.T) {
 
 	t.Run("Stat proxies to underlying file", func(t *testing.T) {
 		osFile := createAndOpenFile(t, newGzippedDataSource(t))
-		gsr, err := newGzipSeekerReader(osFile, 1024)
+		gsr, err := newCompressedSeekerReader(osFile, gzipFormat, 1024)
 		require.NoError(t, err)
 
 		gsrFi, err := gsr.Stat()
@@ -109,7 +110,7 @@ func TestGzipSeekerReader(t *testing.T) {
 
 	t.Run("Name proxies to underlying file", func(t *testing.T) {
 		osFile := createAndOpenFile(t, newGzippedDataSource(t))
-		gsr, err := newGzipSeekerReader(osFile, 1024)
+		gsr, err := newCompressedSeekerReader(osFile, gzipFormat, 1024)
 		require.NoError(t, err)
 
 		assert.Equal(t, osFile.Name(), gsr.Name())
@@ -117,7 +118,7 @@ func TestGzipSeekerReader(t *testing.T) {
 
 	t.Run("Read reads decompressed content", func(t *testing.T) {
 		osFile := createAndOpenFile(t, newGzippedDataSource(t))
-		gsr, err := newGzipSeekerReader(osFile, 1024)
+		gsr, err := newCompressedSeekerReader(osFile, gzipFormat, 1024)
 		require.NoError(t, err, "could not create gzip seeker reader")
 
 		readBuf := make([]byte, len(plainContent))
@@ -144,7 +145,7 @@ func TestGzipSeekerReader(t *testing.T) {
 			content,
 			gziptest.CorruptCRC)
 		osFile := createAndOpenFile(t, corrupted)
-		gsr, err := newGzipSeekerReader(osFile, buffSize)
+		gsr, err := newCompressedSeekerReader(osFile, gzipFormat, buffSize)
 		require.NoError(t, err, "could not create gzip seeker reader")
 
 		buff := make([]byte, buffSize)
@@ -299,7 +300,7 @@ func TestGzipSeekerReader(t *testing.T) {
 				osFile := createAndOpenFile(t, newGzippedDataSource(t))
 				defer osFile.Close()
 
-				gsr, err := newGzipSeekerReader(osFile, tc.buffSize)
+				gsr, err := newCompressedSeekerReader(osFile, gzipFormat, tc.buffSize)
 				require.NoError(t, err)
 				require.NotNil(t, gsr)
 
@@ -360,7 +361,7 @@ func TestFileImplementations_SeekAtEOF(t *testing.T) {
 	contentLen := int64(len(plainContent))
 
 	// buffer size chosen to hit all code dealing with advancing offset on
-	// gzipSeekerReader.
+	// compressedSeekerReader.
 	readBuffSize := 64
 	t.Run("seek to exactly the end of the file", func(t *testing.T) {
 		plainOSFile, err := os.Open(plainFilename)
@@ -371,7 +372,7 @@ func TestFileImplementations_SeekAtEOF(t *testing.T) {
 		gzipOSFile, err := os.Open(gzipFilename)
 		require.NoError(t, err)
 		defer gzipOSFile.Close()
-		gzipF, err := newGzipSeekerReader(gzipOSFile, readBuffSize)
+		gzipF, err := newCompressedSeekerReader(gzipOSFile, gzipFormat, readBuffSize)
 		require.NoError(t, err)
 
 		// Seek to EOF
@@ -408,7 +409,7 @@ func TestFileImplementations_SeekAtEOF(t *testing.T) {
 		gzipOSFile, err := os.Open(gzipFilename)
 		require.NoError(t, err)
 		defer gzipOSFile.Close()
-		gzipF, err := newGzipSeekerReader(gzipOSFile, readBuffSize)
+		gzipF, err := newCompressedSeekerReader(gzipOSFile, gzipFormat, readBuffSize)
 		require.NoError(t, err)
 
 		seekTo := contentLen + 42
@@ -435,7 +436,7 @@ func TestFileImplementations_SeekAtEOF(t *testing.T) {
 	})
 }
 
-func TestIsGZIP(t *testing.T) {
+func TestDetectCompression_GZIP(t *testing.T) {
 	var buf bytes.Buffer
 	gzWriter := gzip.NewWriter(&buf)
 	_, err := gzWriter.Write([]byte("hello gzip"))
@@ -519,7 +520,7 @@ func TestIsGZIP(t *testing.T) {
 				originalFileOffset = offset
 			}
 
-			isGzip, err := IsGZIP(f)
+			_, isGzip, err := detectCompression(f)
 
 			if tc.wantErrStr != "" {
 				require.Error(t, err)
@@ -553,7 +554,7 @@ func TestIsGZIP(t *testing.T) {
 		f := createAndOpenFile(t, validGzipContent)
 		f.Close() // Close the file to cause Seek to fail
 
-		isGzip, err := IsGZIP(f)
+		_, isGzip, err := detectCompression(f)
 		require.Error(t, err, "Expected an error when initial Seek fails")
 
 		isClosedErr := errors.Is(err, os.ErrClosed) || strings.Contains(err.Error(),
@@ -572,8 +573,8 @@ func TestIsGZIP(t *testing.T) {
 			f.Close()
 		})
 
-		isGzip, err := IsGZIP(f)
-		wantErrMsg := "GZIP: failed to read magic bytes:"
+		_, isGzip, err := detectCompression(f)
+		wantErrMsg := "failed to read magic bytes:"
 
 		assert.ErrorContains(t, err, wantErrMsg)
 		assert.False(t, isGzip,
@@ -613,3 +614,111 @@ func newGzippedDataSource(t *testing.T) []byte {
 	require.NoError(t, err, "failed to close gzip writer")
 	return tempBuffer.Bytes()
 }
+
+// compressedLogContent is the decompressed content of the
+// testdata/compressed.log.* files.
+func compressedLogContent() []byte {
+	var buf bytes.Buffer
+	for i := range 100 {
+		fmt.Fprintf(&buf, "compressed line %03d\n", i)
+	}
+	return buf.Bytes()
+}
+
+func TestCompressedSeekerReader_Formats(t *testing.T) {
+	want := compressedLogContent()
+	formats := map[string]compressionFormat{
+		"compressed.log.zst": zstdFormat,
+		"compressed.log.bz2": bzip2Format,
+		"compressed.log.xz":  xzFormat,
+	}
+
+	for name, format := range formats {
+		open := func(t *testing.T) *compressedSeekerReader {
+			osFile, err := os.Open(filepath.Join("testdata", name))
+			require.NoError(t, err)
+			r, err := newCompressedSeekerReader(osFile, format, 64)
+			require.NoError(t, err)
+			t.Cleanup(func() { r.Close() })
+			return r
+		}
+
+		t.Run(format.name, func(t *testing.T) {
+			t.Run("detects format", func(t *testing.T) {
+				osFile, err := os.Open(filepath.Join("testdata", name))
+				require.NoError(t, err)
+				defer osFile.Close()
+
+				got, compressed, err := detectCompression(osFile)
+				require.NoError(t, err)
+				assert.True(t, compressed)
+				assert.Equal(t, format.name, got.name)
+			})
+
+			t.Run("reads decompressed content", func(t *testing.T) {
+				r := open(t)
+				got, err := io.ReadAll(r)
+				require.NoError(t, err)
+				assert.Equal(t, string(want), string(got))
+				assert.Equal(t, int64(len(want)), r.offset)
+			})
+
+			// Resuming reads a new reader from the offset stored in the
+			// registry, the same way initFileOffset does.
+			t.Run("resumes from decompressed offset", func(t *testing.T) {
+				offset := int64(len(want) / 2)
+				r := open(t)
+				n, err := r.Seek(offset, io.SeekCurrent)
+				require.NoError(t, err)
+				assert.Equal(t, offset, n)
+
+				got, err := io.ReadAll(r)
+				require.NoError(t, err)
+				assert.Equal(t, string(want[offset:]), string(got))
+			})
+
+			t.Run("seeks backwards", func(t *testing.T) {
+				r := open(t)
+				_, err := io.ReadAll(r)
+				require.NoError(t, err)
+
+				n, err := r.Seek(20, io.SeekStart)
+				require.NoError(t, err)
+				assert.Equal(t, int64(20), n)
+
+				got := make([]byte, 20)
+				_, err = io.ReadFull(r, got)
+				require.NoError(t, err)
+				assert.Equal(t, string(want[20:40]), string(got))
+			})
+		})
+	}
+}
+
+func TestDetectCompression(t *testing.T) {
+	testCases := map[string]struct {
+		content        []byte
+		wantCompressed bool
+		wantFormat     string
+	}{
+		"gzip":            {content: []byte("\x1f\x8b\x08"), wantCompressed: true, wantFormat: CompressionGZIP},
+		"zstd":            {content: []byte("\x28\xb5\x2f\xfd\x04"), wantCompressed: true, wantFormat: CompressionZSTD},
+		"bzip2":           {content: []byte("BZh91AY"), wantCompressed: true, wantFormat: CompressionBZIP2},
+		"xz":              {content: []byte("\xfd7zXZ\x00\x00"), wantCompressed: true, wantFormat: CompressionXZ},
+		"plain":           {content: plainContent},
+		"plain with BZh":  {content: []byte("BZh is not bzip2\n")},
+		"truncated magic": {content: []byte("\xfd7zX")},
+		"empty":           {},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			f := createAndOpenFile(t, tc.content)
+
+			format, compressed, err := detectCompression(f)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantCompressed, compressed)
+			assert.Equal(t, tc.wantFormat, format.name)
+		})
+	}
+}
@@ -160,7 +160,7 @@ func (f *logFile) errorChecks(err error) error {
 }
 
 func (f *logFile) handleEOF() error {
-	if f.closeOnEOF || f.file.IsGZIP() {
+	if f.closeOnEOF || f.file.IsCompressed() {
 		return io.EOF
 	}
 
@@ -853,7 +853,7 @@ func (s *fileScanner) getIngestTarget(filename string) (it ingestTarget, err err
 //   - dataSize in (offset, offset+length) under non-growing mode: return
 //     errFileTooSmall (today's static-fingerprint behaviour).
 //
-// GZIP is honoured: all reads are on the decompressed stream.
+// Compression is honoured: all reads are on the decompressed stream.
 func (s *fileScanner) toFileDescriptor(it *ingestTarget) (fd loginp.FileDescriptor, err error) {
 	fd.Filename = it.filename
 	fd.Info = it.info
@@ -889,29 +889,30 @@ func (s *fileScanner) toFileDescriptor(it *ingestTarget) (fd loginp.FileDescript
 		}
 	}()
 
+	var format compressionFormat
 	switch s.compression {
 	case CompressionNone:
-		// fd.GZIP stays false
-	case CompressionGZIP:
-		fd.GZIP = true
+		// fd.Compressed stays false
 	case CompressionAuto:
 		osFile, err := opener.Open()
 		if err != nil {
 			return fd, fmt.Errorf("fileScanner: failed to open %q to create FileDescriptor: %w", it.originalFilename, err)
 		}
 
-		fd.GZIP, err = IsGZIP(osFile)
+		format, fd.Compressed, err = detectCompression(osFile)
 		if err != nil {
-			return fd, fmt.Errorf("failed to check if %q is gzip: %w",
+			return fd, fmt.Errorf("failed to detect compression of %q: %w",
 				it.originalFilename, err)
 		}
+	default:
+		format, fd.Compressed = lookupCompressionFormat(s.compression)
 	}
 
 	// Fast path for non-GZIP files we know the size from lstat and can
 	// reject too-small files in static mode without opening the file. This
 	// preserves the no-open guarantee for static fingerprint on
 	// unreadable/permission-denied small files.
-	if !fd.GZIP {
+	if !fd.Compressed {
 		// size <= offset we cannot read anything from the offset, regardless of mode.
 		if it.info.Size() <= offset {
 			return fd, fmt.Errorf(
@@ -928,16 +929,16 @@ func (s *fileScanner) toFileDescriptor(it *ingestTarget) (fd loginp.FileDescript
 	// Wrap the open file (plain or GZIP) so subsequent reads/seeks operate
 	// on the decompressed stream when applicable.
 	var file File
-	if fd.GZIP {
+	if fd.Compressed {
 		osFile, err := opener.Open()
 		if err != nil {
 			return fd, fmt.Errorf("fileScanner: failed to open %q to create FileDescriptor: %w", it.originalFilename, err)
 		}
 
 		// Check if there is enough *decompressed* data for fingerprint
-		file, err = newGzipSeekerReader(osFile, int(threshold))
+		file, err = newCompressedSeekerReader(osFile, format, int(threshold))
 		if err != nil {
-			return fd, fmt.Errorf("failed to create gzip seeker: %w", err)
+			return fd, fmt.Errorf("failed to create %s seeker: %w", format.name, err)
 		}
 		defer file.Close()
 	} else {
@@ -501,7 +501,7 @@ func (w *fileWatcher) reconcileClosedHarvester(prevDesc *loginp.FileDescriptor,
 
 // tracksHarvesterProgress reports whether a file contributes to the harvester progress metrics.
 func tracksHarvesterProgress(fd *loginp.FileDescriptor, opts loginp.FileScanOptions) bool {
-	return !fd.GZIP && fd.Info.Size() > 0 && !isFileIgnored(*fd, opts)
+	return !fd.Compressed && fd.Info.Size() > 0 && !isFileIgnored(*fd, opts)
 }
 
 // isFileIgnored returns true when a file is ignored, no matter the reason.
@@ -2236,7 +2236,7 @@ func TestFileWatcherHarvesterMetrics(t *testing.T) {
 		return loginp.FileDescriptor{
 			Filename:    name,
 			Fingerprint: loginp.FingerprintID{Sum: name},
-			GZIP:        gzip,
+			Compressed:  gzip,
 			Info:        file.ExtendFileInfo(&testFileInfo{name: name, size: size, time: modTime}),
 		}
 	}
@@ -486,7 +486,7 @@ func (inp *filestream) buildPipeline(
 
 	r = readfile.NewLimitReader(r, inp.readerConfig.MaxBytes)
 
-	if f.IsGZIP() {
+	if f.IsCompressed() {
 		r = NewEOFLookaheadReader(r, io.EOF)
 	}
 
@@ -551,7 +551,7 @@ func (inp *filestream) openFile(
 	// written to disk when filebeat picks it up. It should only grow, not
 	// shrink.
 	// Therefore, only check truncation for plain files.
-	if !f.IsGZIP() && fi.Size() < offset {
+	if !f.IsCompressed() && fi.Size() < offset {
 		// if the file was truncated we need to reset the offset and notify
 		// all callers so they can also reset their offsets
 		truncated = true
@@ -580,46 +580,46 @@ func (inp *filestream) openFile(
 //
 // The behavior depends on the compression setting:
 //   - "" (none): returns a plain file reader (plainFile)
-//   - "gzip": always creates a gzipSeekerReader (errors if file is not gzip)
-//   - "auto": auto-detects gzip files; returns gzipSeekerReader for gzip files,
-//     plainFile otherwise
+//   - "gzip", "zstd", "bzip2", "xz": always creates a compressedSeekerReader
+//     for that format (errors if the file isn't in that format)
+//   - "auto": detects the compression format from the magic bytes; returns
+//     a compressedSeekerReader for compressed files, plainFile otherwise
 //
 // It returns an error if any happens.
 func (inp *filestream) newFile(rawFile *os.File) (File, error) {
+	var format compressionFormat
 	switch inp.compression {
 	case CompressionNone:
 		return newPlainFile(rawFile), nil
 
-	case CompressionGZIP:
-		f, err := newGzipSeekerReader(rawFile, inp.readerConfig.BufferSize)
-		if err != nil {
-			return nil, fmt.Errorf(
-				"failed to create gzip reader for %s: %w", rawFile.Name(), err)
-		}
-		return f, nil
-
 	case CompressionAuto:
-		isGZIP, err := IsGZIP(rawFile)
+		var compressed bool
+		var err error
+		format, compressed, err = detectCompression(rawFile)
 		if err != nil {
 			return nil, fmt.Errorf(
-				"gzip detection error on %s: %w", rawFile.Name(), err)
+				"compression detection error on %s: %w", rawFile.Name(), err)
 		}
 
-		if !isGZIP {
+		if !compressed {
 			return newPlainFile(rawFile), nil
 		}
 
-		f, err := newGzipSeekerReader(rawFile, inp.readerConfig.BufferSize)
-		if err != nil {
-			return nil, fmt.Errorf(
-				"failed to create gzip reader for %s: %w", rawFile.Name(), err)
+	default:
+		var found bool
+		format, found = lookupCompressionFormat(inp.compression)
+		if !found {
+			// This should not happen as validation catches invalid values
+			return nil, fmt.Errorf("invalid compression mode: %q", inp.compression)
 		}
-		return f, nil
+	}
 
-	default:
-		// This should not happen as validation catches invalid values
-		return nil, fmt.Errorf("invalid compression mode: %q", inp.compression)
+	f, err := newCompressedSeekerReader(rawFile, format, inp.readerConfig.BufferSize)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to create %s reader for %s: %w", format.name, rawFile.Name(), err)
 	}
+	return f, nil
 }
 
 func checkFileBeforeOpening(fi os.FileInfo) error {
@@ -643,17 +643,17 @@ func (inp *filestream) initFileOffset(file File, offset int64) error {
 
 // isDroppedLine decides if the line is exported or not based on
 // the include_lines and exclude_lines options.
-func (inp *filestream) isDroppedLine(log *logp.Logger, line []byte) bool {
-	if len(inp.readerConfig.IncludeLines) > 0 {
-		if !matchAny(inp.readerConfig.IncludeLines, line) {
+func (c *readerConfig) isDroppedLine(log *logp.Logger, line []byte) bool {
+	if len(c.IncludeLines) > 0 {
+		if !matchAny(c.IncludeLines, line) {
 			if log.IsDebug() {
 				log.Debugf("Drop line as it does not match any of the include patterns %s", line)
 			}
 			return true
 		}
 	}
-	if len(inp.readerConfig.ExcludeLines) > 0 {
-		if matchAny(inp.readerConfig.ExcludeLines, line) {
+	if len(c.ExcludeLines) > 0 {
+		if matchAny(c.ExcludeLines, line) {
 			if log.IsDebug() {
 				log.Debugf("Drop line as it does match one of the exclude patterns %s", line)
 			}
@@ -517,7 +517,7 @@ func TestNewFile(t *testing.T) {
 		"compression_gzip_with_gzip_file_returns_gzip_reader": {
 			compression:  CompressionGZIP,
 			filePath:     gzippedFilePath,
-			expectedType: &gzipSeekerReader{},
+			expectedType: &compressedSeekerReader{},
 		},
 		"compression_gzip_with_plain_file_returns_error": {
 			compression:   CompressionGZIP,
@@ -533,21 +533,37 @@ func TestNewFile(t *testing.T) {
 		"compression_auto_with_gzip_file_returns_gzip_reader": {
 			compression:  CompressionAuto,
 			filePath:     gzippedFilePath,
-			expectedType: &gzipSeekerReader{},
+			expectedType: &compressedSeekerReader{},
+		},
+		"compression_auto_with_zstd_file_returns_compressed_reader": {
+			compression:  CompressionAuto,
+			filePath:     filepath.Join("testdata", "compressed.log.zst"),
+			expectedType: &compressedSeekerReader{},
+		},
+		"compression_xz_with_xz_file_returns_compressed_reader": {
+			compression:  CompressionXZ,
+			filePath:     filepath.Join("testdata", "compressed.log.xz"),
+			expectedType: &compressedSeekerReader{},
+		},
+		"compression_xz_with_plain_file_returns_error": {
+			compression:   CompressionXZ,
+			filePath:      plainFilePath,
+			expectError:   true,
+			errorContains: "failed to create xz reader",
 		},
 		"compression_auto_with_unreadable_file_returns_error": {
 			compression: CompressionAuto,
 			filePath:    plainFilePath, // content doesn't matter
 			setup: func(t *testing.T, filePath string) *os.File {
 				// Return a file that is already closed to trigger a read error
-				// in IsGZIP
+				// in detectCompression
 				f, err := os.Open(filePath)
 				require.NoError(t, err)
 				f.Close()
 				return f
 			},
 			expectError:   true,
-			errorContains: "gzip detection error",
+			errorContains: "compression detection error",
 		},
 	}
 
@@ -160,8 +160,9 @@ type FileDescriptor struct {
 	// Fingerprint is the file-identity material for the "fingerprint" identity.
 	// It is the zero value when fingerprinting is disabled or produced nothing.
 	Fingerprint FingerprintID
-	// GZIP indicates if the file is compressed with GZIP.
-	GZIP bool
+	// Compressed indicates if the file is compressed, with any of the
+	// supported compression formats.
+	Compressed bool
 
 	// bytesIngested is the number of bytes already ingested by the harvester for this file.
 	bytesIngested int64
@@ -95,7 +95,7 @@ type sourceState struct {
 	status         sourceStatus
 	holdsSlot      bool // occupies one of the harvesterLimit open slots
 	setUp          bool // resources (lock/client/session) acquired
-	isGZIP         bool // source reads a GZIP file; for the GZIP lifecycle metrics
+	isCompressed   bool // source reads a compressed file; for the GZIP lifecycle metrics
 	backoff        time.Duration
 	nextCheck      time.Time
 	nextStateCheck time.Time
@@ -434,14 +434,14 @@ func (g *harvesterRunner) setup(state *sourceState) error {
 		return err
 	}
 	state.session = session
-	state.isGZIP = session.IsGZIP()
+	state.isCompressed = session.IsCompressed()
 
 	g.metrics.FilesActive.Inc()
 	g.metrics.HarvesterRunning.Inc()
 	g.metrics.FilesOpened.Inc()
 	g.metrics.HarvesterOpenFiles.Inc()
 	g.metrics.HarvesterStarted.Inc()
-	if state.isGZIP {
+	if state.isCompressed {
 		g.metrics.FilesGZIPActive.Inc()
 		g.metrics.HarvesterGZIPRunning.Inc()
 		g.metrics.FilesGZIPOpened.Inc()
@@ -813,7 +813,7 @@ func (g *harvesterRunner) finish(state *sourceState) {
 		g.metrics.FilesClosed.Inc()
 		g.metrics.HarvesterOpenFiles.Dec()
 		g.metrics.HarvesterClosed.Inc()
-		if state.isGZIP {
+		if state.isCompressed {
 			g.metrics.FilesGZIPActive.Dec()
 			g.metrics.HarvesterGZIPRunning.Dec()
 			g.metrics.FilesGZIPClosed.Inc()
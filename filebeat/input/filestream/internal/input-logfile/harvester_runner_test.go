@@ -1452,7 +1452,7 @@ func blockUntilCancelled(_ int, ctx v2.Context) (SliceVerdict, error) {
 type fakeHarvester struct {
 	mu       sync.Mutex
 	openErr  error
-	gzip     bool // sessions report IsGZIP() == true
+	gzip     bool // sessions report IsCompressed() == true
 	readFn   func(call int, ctx v2.Context) (SliceVerdict, error)
 	pollFn   func(call int) PollResult
 	sessions []*fakeSession
@@ -1547,7 +1547,7 @@ func (s *fakeSession) Offset() int64 {
 	return s.offset
 }
 
-func (s *fakeSession) IsGZIP() bool {
+func (s *fakeSession) IsCompressed() bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	return s.gzip
@@ -121,7 +121,7 @@ func (inp *filestream) OpenSession(
 	s.enc = enc
 	s.readOffset = s.state.Offset
 
-	if !fs.desc.GZIP {
+	if !fs.desc.Compressed {
 		s.metricsOffset, s.cleanupMetricsOffset = metrics.RegisterHarvesterOffset(id, s.state.Offset)
 	}
 
@@ -151,7 +151,7 @@ func (s *harvestSession) ReadSlice(
 		return loginp.SliceDone, nil
 	}
 
-	isGZIP := s.src.desc.GZIP
+	isCompressed := s.src.desc.Compressed
 
 	// Position the file at the last published offset (undoing any read-ahead
 	// from the previous slice) and build a fresh non-blocking pipeline for this
@@ -210,7 +210,7 @@ func (s *harvestSession) ReadSlice(
 			default:
 				s.log.Errorf("Read line error: %v", err)
 				s.metrics.ProcessingErrors.Inc()
-				if isGZIP {
+				if isCompressed {
 					s.metrics.ProcessingGZIPErrors.Inc()
 				}
 				return loginp.SliceDone, nil
@@ -225,7 +225,7 @@ func (s *harvestSession) ReadSlice(
 		if flags, ferr := message.Fields.GetValue("log.flags"); ferr == nil {
 			if flagsList, ok := flags.([]string); ok && slices.Contains(flagsList, "truncated") {
 				s.metrics.MessagesTruncated.Add(1)
-				if isGZIP {
+				if isCompressed {
 					// Truncation shouldn't happen for GZIP files, but as
 					// we cannot guarantee it, we account for it anyway.
 					s.metrics.MessagesGZIPTruncated.Add(1)
@@ -233,7 +233,7 @@ func (s *harvestSession) ReadSlice(
 			}
 		}
 		s.metrics.MessagesRead.Inc()
-		if isGZIP {
+		if isCompressed {
 			s.metrics.MessagesGZIPRead.Inc()
 		}
 
@@ -243,13 +243,13 @@ func (s *harvestSession) ReadSlice(
 		// be closed as inactive. Mark activity here, before the drop check.
 		s.lastData = time.Now()
 
-		if message.IsEmpty() || (s.inp.hasLineFilter && s.inp.isDroppedLine(s.log, message.Content)) {
+		if message.IsEmpty() || (s.inp.hasLineFilter && s.inp.readerConfig.isDroppedLine(s.log, message.Content)) {
 			continue
 		}
 
 		//nolint:gosec // message.Bytes is always positive
 		s.metrics.BytesProcessed.Add(uint64(message.Bytes))
-		if isGZIP {
+		if isCompressed {
 			//nolint:gosec // message.Bytes is always positive
 			s.metrics.BytesGZIPProcessed.Add(uint64(message.Bytes))
 		}
@@ -258,7 +258,7 @@ func (s *harvestSession) ReadSlice(
 			_ = mapstr.AddTags(message.Fields, []string{"take_over"})
 		}
 
-		if isGZIP {
+		if isCompressed {
 			if perr, ok := (message.Private).(error); ok && errors.Is(perr, io.EOF) {
 				s.state.EOF = true
 			}
@@ -266,7 +266,7 @@ func (s *harvestSession) ReadSlice(
 
 		if err := p.Publish(message.ToEvent(), s.state); err != nil {
 			s.metrics.ProcessingErrors.Inc()
-			if isGZIP {
+			if isCompressed {
 				s.metrics.ProcessingGZIPErrors.Inc()
 			}
 			return loginp.SliceDone, err
@@ -274,7 +274,7 @@ func (s *harvestSession) ReadSlice(
 
 		s.metrics.EventsProcessed.Inc()
 		s.metrics.ProcessingTime.Update(time.Since(message.Ts).Nanoseconds())
-		if isGZIP {
+		if isCompressed {
 			s.metrics.EventsGZIPProcessed.Inc()
 			s.metrics.ProcessingGZIPTime.Update(time.Since(message.Ts).Nanoseconds())
 		}
@@ -326,7 +326,7 @@ func (s *harvestSession) Poll() loginp.PollResult {
 
 	// GZIP offsets are tracked on the decompressed stream, so a size comparison
 	// is invalid; resume until the session reads to EOF (SliceDone).
-	if s.src.desc.GZIP || fi.Size() != s.readOffset {
+	if s.src.desc.Compressed || fi.Size() != s.readOffset {
 		return loginp.PollResume
 	}
 
@@ -347,8 +347,8 @@ func (s *harvestSession) Poll() loginp.PollResult {
 // Offset returns the current read offset.
 func (s *harvestSession) Offset() int64 { return s.state.Offset }
 
-// IsGZIP reports whether the session reads a GZIP-compressed source.
-func (s *harvestSession) IsGZIP() bool { return s.src.desc.GZIP }
+// IsCompressed reports whether the session reads a compressed source.
+func (s *harvestSession) IsCompressed() bool { return s.src.desc.Compressed }
 
 // Close releases the file handle held by the session.
 func (s *harvestSession) Close() error {
@@ -22,14 +22,17 @@
 package journald
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/elastic/go-ucfg"
 
 	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalctl"
 	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalfield"
+	"github.com/elastic/beats/v7/filebeat/inputsource/tcp"
 
 	"github.com/elastic/beats/v7/libbeat/reader/parser"
 )
@@ -99,6 +102,20 @@ type config struct {
 	// it defaults to `journalctl`, which assumes that the `journalctl`
 	// binary is available in the system's `PATH` environment variable.
 	JournalctlPath string `config:"journalctl_path"`
+
+	// Remote configures the input to receive the journal entries uploaded
+	// by systemd-journal-upload, instead of reading the local journal.
+	Remote remoteConfig `config:"remote"`
+}
+
+// remoteConfig stores the options of the receiver of journal entries
+// uploaded in the Journal Export Format.
+type remoteConfig struct {
+	Enabled bool `config:"enabled"`
+
+	// The max_message_size option is the maximum size of a journal entry,
+	// the timeout applies to the request headers and idle connections.
+	tcp.Config `config:",inline"`
 }
 
 // Validate checks the configuration. It is called by go-ucfg when
@@ -115,6 +132,11 @@ func (c config) Validate() error {
 		}
 	}
 
+	// The remote receiver doesn't read journal files, nor run journalctl.
+	if c.Remote.Enabled && (len(c.Paths) > 0 || c.Merge || c.Chroot != "") {
+		return errors.New("paths, merge and chroot cannot be used with remote enabled")
+	}
+
 	return nil
 }
 
@@ -149,5 +171,13 @@ func defaultConfig() config {
 		Seek:               journalctl.SeekHead,
 		SaveRemoteHostname: false,
 		JournalctlPath:     defaultJournalCtlPath,
+		Remote: remoteConfig{
+			Config: tcp.Config{
+				// The default port of systemd-journal-remote.
+				Host:           "localhost:19532",
+				Timeout:        time.Minute,
+				MaxMessageSize: 10 * humanize.MiByte,
+			},
+		},
 	}
 }
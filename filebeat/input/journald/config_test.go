@@ -99,3 +99,45 @@ func TestConfigValidateFacilities(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigValidateRemote(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name: "remote",
+			yaml: "remote.enabled: true",
+		},
+		{
+			name: "remote disabled with paths",
+			yaml: "{remote.enabled: false, paths: [/var/log/journal]}",
+		},
+		{
+			name:    "remote with paths",
+			yaml:    "{remote.enabled: true, paths: [/var/log/journal]}",
+			wantErr: "cannot be used with remote enabled",
+		},
+		{
+			name:    "remote with merge",
+			yaml:    "{remote.enabled: true, merge: true}",
+			wantErr: "cannot be used with remote enabled",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := conf.NewConfigWithYAML([]byte(tc.yaml), "source")
+			require.NoError(t, err)
+
+			config := defaultConfig()
+			err = c.Unpack(&config)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -39,6 +39,7 @@ import (
 	"github.com/elastic/beats/v7/libbeat/statestore"
 	conf "github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
 )
 
 //go:generate moq -out journalReadMock_test.go . journalReader
@@ -63,6 +64,11 @@ type journald struct {
 	Merge              bool
 	Chroot             string
 	JournalctlPath     string
+
+	// Remote is set when the input receives uploaded journal entries
+	// instead of reading the local journal.
+	Remote    *remoteConfig
+	RemoteTLS *tlscommon.TLSConfig
 }
 
 type checkpoint struct {
@@ -118,6 +124,17 @@ func Configure(cfg *conf.C, logger *logp.Logger) ([]cursor.Source, cursor.Input,
 		paths = []string{localSystemJournalID}
 	}
 
+	var remote *remoteConfig
+	var remoteTLS *tlscommon.TLSConfig
+	if config.Remote.Enabled {
+		var err error
+		if remoteTLS, err = tlscommon.LoadTLSServerConfig(config.Remote.TLS, logger); err != nil {
+			return nil, nil, fmt.Errorf("loading remote ssl configuration: %w", err)
+		}
+		remote = &config.Remote
+		paths = []string{remoteJournalID}
+	}
+
 	sources := make([]cursor.Source, len(paths))
 	for i, p := range paths {
 		sources[i] = pathSource(p)
@@ -163,12 +180,22 @@ func Configure(cfg *conf.C, logger *logp.Logger) ([]cursor.Source, cursor.Input,
 		Merge:              config.Merge,
 		Chroot:             config.Chroot,
 		JournalctlPath:     config.JournalctlPath,
+		Remote:             remote,
+		RemoteTLS:          remoteTLS,
 	}, nil
 }
 
 func (inp *journald) Name() string { return pluginName }
 
 func (inp *journald) Test(src cursor.Source, ctx input.TestContext) error {
+	if inp.Remote != nil {
+		l, err := inp.listenRemote(ctx.Logger)
+		if err != nil {
+			return err
+		}
+		return l.Close()
+	}
+
 	reader, err := journalctl.New(
 		ctx.Logger.With("input_id", inp.ID),
 		ctx.Cancelation,
@@ -201,6 +228,10 @@ func (inp *journald) Run(
 		With("input_id", inp.ID)
 
 	ctx.UpdateStatus(status.Starting, "Starting")
+	if inp.Remote != nil {
+		return inp.runRemote(ctx, logger, publisher)
+	}
+
 	currentCheckpoint := initCheckpoint(logger, cursor)
 
 	mode := inp.Seek
@@ -0,0 +1,212 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+// Package journalexport reads journal entries serialized in the Journal
+// Export Format, see https://systemd.io/JOURNAL_EXPORT_FORMATS/.
+package journalexport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalctl"
+	input "github.com/elastic/beats/v7/filebeat/input/v2"
+)
+
+// ErrEntryTooLarge is returned when an entry is larger than the maximum
+// entry size of the Reader.
+var ErrEntryTooLarge = errors.New("journal entry too large")
+
+// Reader reads journal entries in the Journal Export Format. The entries
+// have the same fields as the entries read from the JSON output of
+// journalctl.
+type Reader struct {
+	r            *bufio.Reader
+	maxEntrySize int
+}
+
+// NewReader creates a Reader of the entries of r. Entries larger than
+// maxEntrySize bytes fail to be read.
+func NewReader(r io.Reader, maxEntrySize int) *Reader {
+	return &Reader{
+		r:            bufio.NewReader(r),
+		maxEntrySize: maxEntrySize,
+	}
+}
+
+// Close is a no-op, the underlying reader is owned by the caller.
+func (r *Reader) Close() error {
+	return nil
+}
+
+// Next returns the next entry. It returns io.EOF when all entries were
+// read, and io.ErrUnexpectedEOF if the last entry is incomplete.
+func (r *Reader) Next(cancel input.Canceler) (journalctl.JournalEntry, error) {
+	if err := cancel.Err(); err != nil {
+		return journalctl.JournalEntry{}, journalctl.ErrCancelled
+	}
+
+	fields := map[string]any{}
+	size := 0
+	for {
+		line, err := r.readLine(r.maxEntrySize - size)
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(fields) > 0 {
+				// The last entry isn't required to be terminated by an
+				// empty line.
+				return newEntry(fields)
+			}
+			return journalctl.JournalEntry{}, err
+		}
+		size += len(line) + 1
+
+		if len(line) == 0 {
+			if len(fields) == 0 {
+				// Skip extra empty lines between entries.
+				continue
+			}
+			return newEntry(fields)
+		}
+
+		name, value, found := bytes.Cut(line, []byte("="))
+		if !found {
+			// Binary field: the name is followed by the little endian 64-bit
+			// size of the value, the value and a new line.
+			data, err := r.readBinary(r.maxEntrySize - size)
+			if err != nil {
+				return journalctl.JournalEntry{}, fmt.Errorf("cannot read field %q: %w", line, err)
+			}
+			size += 8 + len(data) + 1
+			fields[string(line)] = fieldValue(data)
+			continue
+		}
+		fields[string(name)] = string(value)
+	}
+}
+
+// readLine reads a line, without its terminating new line. The line must
+// not be longer than limit bytes.
+func (r *Reader) readLine(limit int) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > limit {
+			return nil, ErrEntryTooLarge
+		}
+		if err == nil {
+			return line[:len(line)-1], nil
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			if errors.Is(err, io.EOF) && len(line) > 0 {
+				return line, io.ErrUnexpectedEOF
+			}
+			return line, err
+		}
+	}
+}
+
+// readBinary reads the value of a binary field. The value must not be
+// longer than limit bytes.
+func (r *Reader) readBinary(limit int) ([]byte, error) {
+	var sizeBuf [8]byte
+	if _, err := io.ReadFull(r.r, sizeBuf[:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	size := binary.LittleEndian.Uint64(sizeBuf[:])
+	if limit < 8 || size > uint64(limit-8) { //nolint:gosec // limit-8 is positive
+		return nil, ErrEntryTooLarge
+	}
+
+	data := make([]byte, size+1)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if data[size] != '\n' {
+		return nil, errors.New("binary field not terminated by a new line")
+	}
+	return data[:size], nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// fieldValue returns the value of a binary field like the JSON output of
+// journalctl: a string if it's printable UTF-8, an array of numbers
+// otherwise.
+func fieldValue(data []byte) any {
+	if isPrintable(data) {
+		return string(data)
+	}
+	values := make([]any, len(data))
+	for i, b := range data {
+		values[i] = float64(b)
+	}
+	return values
+}
+
+func isPrintable(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if r != '\n' && r != '\t' && !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func newEntry(fields map[string]any) (journalctl.JournalEntry, error) {
+	entry := journalctl.JournalEntry{Fields: fields}
+
+	var err error
+	if entry.RealtimeTimestamp, err = timestamp(fields, "__REALTIME_TIMESTAMP"); err != nil {
+		return journalctl.JournalEntry{}, err
+	}
+	if entry.MonotonicTimestamp, err = timestamp(fields, "__MONOTONIC_TIMESTAMP"); err != nil {
+		return journalctl.JournalEntry{}, err
+	}
+	entry.Cursor, _ = fields["__CURSOR"].(string)
+
+	return entry, nil
+}
+
+func timestamp(fields map[string]any, name string) (uint64, error) {
+	s, ok := fields[name].(string)
+	if !ok {
+		return 0, fmt.Errorf("entry without '%s'", name)
+	}
+	ts, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not convert '%s' to uint64: %w", name, err)
+	}
+	return ts, nil
+}
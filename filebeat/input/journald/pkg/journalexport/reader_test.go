@@ -0,0 +1,155 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package journalexport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalctl"
+)
+
+func readAll(t *testing.T, r *Reader) ([]journalctl.JournalEntry, error) {
+	t.Helper()
+
+	var entries []journalctl.JournalEntry
+	for {
+		entry, err := r.Next(t.Context())
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func openTestdata(t *testing.T, name string) *os.File {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("..", "..", "testdata", name))
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestReader(t *testing.T) {
+	entries, err := readAll(t, NewReader(openTestdata(t, "journal1.export"), 1<<20))
+	require.NoError(t, err)
+	require.Len(t, entries, 10)
+
+	first := entries[0]
+	assert.Equal(t, uint64(1758137056706827), first.RealtimeTimestamp)
+	assert.Equal(t, "s=", first.Cursor[:2])
+	assert.Equal(t, first.Cursor, first.Fields["__CURSOR"])
+	assert.Contains(t, first.Fields, "MESSAGE")
+}
+
+func TestReaderBinaryFields(t *testing.T) {
+	entries, err := readAll(t, NewReader(openTestdata(t, "binary.export"), 1<<20))
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+
+	msg, ok := entries[0].Fields["MESSAGE"].([]any)
+	require.True(t, ok, "non printable binary fields must be arrays of numbers, got %T", entries[0].Fields["MESSAGE"])
+	require.NotEmpty(t, msg)
+	assert.Equal(t, float64(0), msg[0])
+	assert.Equal(t, "journal", entries[0].Fields["_TRANSPORT"])
+}
+
+func TestReaderPrintableBinaryField(t *testing.T) {
+	data := "__REALTIME_TIMESTAMP=1\n__MONOTONIC_TIMESTAMP=2\nMESSAGE\n" +
+		"\x0b\x00\x00\x00\x00\x00\x00\x00line1\nline2\n\n"
+
+	entries, err := readAll(t, NewReader(strings.NewReader(data), 1<<20))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "line1\nline2", entries[0].Fields["MESSAGE"])
+	assert.Equal(t, uint64(1), entries[0].RealtimeTimestamp)
+	assert.Equal(t, uint64(2), entries[0].MonotonicTimestamp)
+}
+
+func TestReaderErrors(t *testing.T) {
+	tests := map[string]struct {
+		data    string
+		maxSize int
+		wantErr error
+	}{
+		"entry too large": {
+			data:    "__REALTIME_TIMESTAMP=1\n__MONOTONIC_TIMESTAMP=2\nMESSAGE=" + strings.Repeat("a", 100) + "\n\n",
+			maxSize: 64,
+			wantErr: ErrEntryTooLarge,
+		},
+		"binary field too large": {
+			data:    "__REALTIME_TIMESTAMP=1\nMESSAGE\n\xff\xff\xff\xff\x00\x00\x00\x00",
+			maxSize: 64,
+			wantErr: ErrEntryTooLarge,
+		},
+		"truncated line": {
+			data:    "__REALTIME_TIMESTAMP=1\n__MONOTONIC_TIMESTAMP=2\nMESS",
+			maxSize: 64,
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		"truncated binary field": {
+			data:    "__REALTIME_TIMESTAMP=1\nMESSAGE\n\x05\x00\x00\x00\x00\x00\x00\x00ab",
+			maxSize: 64,
+			wantErr: io.ErrUnexpectedEOF,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := readAll(t, NewReader(strings.NewReader(tc.data), tc.maxSize))
+			require.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+
+	t.Run("missing timestamp", func(t *testing.T) {
+		_, err := readAll(t, NewReader(strings.NewReader("MESSAGE=foo\n\n"), 64))
+		require.ErrorContains(t, err, "__REALTIME_TIMESTAMP")
+	})
+}
+
+func TestReaderLastEntryWithoutEmptyLine(t *testing.T) {
+	data := "__REALTIME_TIMESTAMP=1\n__MONOTONIC_TIMESTAMP=2\nMESSAGE=a\n\n\n" +
+		"__REALTIME_TIMESTAMP=3\n__MONOTONIC_TIMESTAMP=4\nMESSAGE=b\n"
+
+	entries, err := readAll(t, NewReader(strings.NewReader(data), 64))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "b", entries[1].Fields["MESSAGE"])
+}
+
+func TestReaderCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := NewReader(strings.NewReader(""), 64).Next(ctx)
+	require.ErrorIs(t, err, journalctl.ErrCancelled)
+}
@@ -0,0 +1,230 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package journalfield
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// coredumpMessageID is the MESSAGE_ID of the entries logged by
+// systemd-coredump.
+const coredumpMessageID = "fc2e22bc6ee647b6b90729ab34a250b1"
+
+// unitSuffixes are the suffixes of the systemd unit types.
+var unitSuffixes = []string{
+	".service", ".socket", ".target", ".device", ".mount", ".automount",
+	".swap", ".timer", ".path", ".slice", ".scope",
+}
+
+// Filter selects journal entries from their raw fields, with the same
+// semantics as the options and matches the input passes to journalctl. It's
+// used for the entries that are not read by journalctl.
+//
+// An entry is selected if it belongs to one of the units, has one of the
+// syslog identifiers, and is selected by the matches. Matches are grouped by
+// '+': an entry is selected by the matches if it's selected by any group. An
+// entry is selected by a group if, for each field of the group, the entry
+// has one of the values of the field.
+type Filter struct {
+	units       []string
+	identifiers []string
+	groups      []map[string][]string
+}
+
+// NewFilter creates a Filter for the configured units, syslog identifiers,
+// transports, matches and facilities.
+func NewFilter(
+	units []string,
+	identifiers []string,
+	transports []string,
+	matches IncludeMatches,
+	facilities []int,
+) Filter {
+	f := Filter{identifiers: identifiers}
+	for _, u := range units {
+		f.units = append(f.units, mangleUnitName(u))
+	}
+
+	// Transports and facilities are added after the matches, like the
+	// arguments of journalctl.
+	terms := make([]string, 0, len(matches.Matches)+len(transports)+len(facilities))
+	for _, m := range matches.Matches {
+		terms = append(terms, m.String())
+	}
+	for _, t := range transports {
+		terms = append(terms, "_TRANSPORT="+t)
+	}
+	for _, facility := range facilities {
+		terms = append(terms, "SYSLOG_FACILITY="+strconv.Itoa(facility))
+	}
+
+	group := map[string][]string{}
+	for _, term := range terms {
+		if term == "+" {
+			if len(group) > 0 {
+				f.groups = append(f.groups, group)
+			}
+			group = map[string][]string{}
+			continue
+		}
+		field, value, _ := strings.Cut(term, "=")
+		group[field] = append(group[field], value)
+	}
+	if len(group) > 0 {
+		f.groups = append(f.groups, group)
+	}
+
+	return f
+}
+
+// Match returns true if the entry with the raw fields is selected.
+func (f Filter) Match(fields map[string]any) bool {
+	return f.matchUnits(fields) && f.matchIdentifiers(fields) && f.matchGroups(fields)
+}
+
+// matchUnits matches the entries of the units, including the entries logged
+// about them by systemd and systemd-coredump, like 'journalctl --unit'.
+func (f Filter) matchUnits(fields map[string]any) bool {
+	if len(f.units) == 0 {
+		return true
+	}
+
+	for _, unit := range f.units {
+		switch {
+		case matchUnit(unit, fields["_SYSTEMD_UNIT"]):
+			return true
+		case fieldIs(fields, "_PID", "1") && matchUnit(unit, fields["UNIT"]):
+			return true
+		case fieldIs(fields, "_UID", "0") && matchUnit(unit, fields["OBJECT_SYSTEMD_UNIT"]):
+			return true
+		case fieldIs(fields, "_UID", "0") && fieldIs(fields, "MESSAGE_ID", coredumpMessageID) &&
+			matchUnit(unit, fields["COREDUMP_UNIT"]):
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) matchIdentifiers(fields map[string]any) bool {
+	if len(f.identifiers) == 0 {
+		return true
+	}
+
+	identifier, ok := fieldString(fields["SYSLOG_IDENTIFIER"])
+	if !ok {
+		return false
+	}
+	for _, i := range f.identifiers {
+		if i == identifier {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) matchGroups(fields map[string]any) bool {
+	if len(f.groups) == 0 {
+		return true
+	}
+
+	for _, group := range f.groups {
+		if matchGroup(group, fields) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchGroup(group map[string][]string, fields map[string]any) bool {
+	for field, values := range group {
+		value, ok := fieldString(fields[field])
+		if !ok {
+			return false
+		}
+		found := false
+		for _, v := range values {
+			if v == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// mangleUnitName adds the '.service' suffix to unit names without a unit
+// type suffix, like journalctl does.
+func mangleUnitName(unit string) string {
+	for _, suffix := range unitSuffixes {
+		if strings.HasSuffix(unit, suffix) {
+			return unit
+		}
+	}
+	if strings.ContainsAny(unit, "*?[") {
+		// Glob patterns are used as is.
+		return unit
+	}
+	return unit + ".service"
+}
+
+// matchUnit matches the unit, or the unit glob pattern, with value.
+func matchUnit(unit string, value any) bool {
+	s, ok := fieldString(value)
+	if !ok {
+		return false
+	}
+	matched, err := path.Match(unit, s)
+	return err == nil && matched
+}
+
+func fieldIs(fields map[string]any, field, want string) bool {
+	s, ok := fieldString(fields[field])
+	return ok && s == want
+}
+
+// fieldString returns the string value of a raw field. Binary fields are
+// represented as a []any of float64 values, like in the JSON output of
+// journalctl.
+func fieldString(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case []any:
+		b := make([]byte, len(v))
+		for i, e := range v {
+			f, ok := e.(float64)
+			if !ok {
+				return "", false
+			}
+			b[i] = byte(f)
+		}
+		return string(b), true
+	case nil:
+		return "", false
+	default:
+		return fmt.Sprint(v), true
+	}
+}
@@ -0,0 +1,126 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package journalfield
+
+import "testing"
+
+func TestFilter(t *testing.T) {
+	sshd := map[string]any{
+		"_SYSTEMD_UNIT":     "sshd.service",
+		"SYSLOG_IDENTIFIER": "sshd",
+		"_TRANSPORT":        "syslog",
+		"SYSLOG_FACILITY":   "4",
+		"_PID":              "42",
+	}
+	systemdAboutSshd := map[string]any{
+		"_SYSTEMD_UNIT":     "init.scope",
+		"SYSLOG_IDENTIFIER": "systemd",
+		"UNIT":              "sshd.service",
+		"_TRANSPORT":        "journal",
+		"_PID":              "1",
+	}
+	kernel := map[string]any{
+		"SYSLOG_IDENTIFIER": "kernel",
+		"_TRANSPORT":        "kernel",
+		"SYSLOG_FACILITY":   "0",
+		"_HOSTNAME":         []any{float64('h'), float64('o'), float64('s'), float64('t')},
+	}
+
+	cases := []struct {
+		name        string
+		units       []string
+		identifiers []string
+		transports  []string
+		matches     []string
+		facilities  []int
+		want        []map[string]any
+	}{
+		{
+			name: "no filter",
+			want: []map[string]any{sshd, systemdAboutSshd, kernel},
+		},
+		{
+			name:  "unit without suffix",
+			units: []string{"sshd"},
+			want:  []map[string]any{sshd, systemdAboutSshd},
+		},
+		{
+			name:  "unit glob",
+			units: []string{"*.scope"},
+			want:  []map[string]any{systemdAboutSshd},
+		},
+		{
+			name:        "units and identifiers",
+			units:       []string{"sshd"},
+			identifiers: []string{"systemd", "kernel"},
+			want:        []map[string]any{systemdAboutSshd},
+		},
+		{
+			name:       "transports",
+			transports: []string{"syslog", "kernel"},
+			want:       []map[string]any{sshd, kernel},
+		},
+		{
+			name:       "facilities",
+			facilities: []int{0},
+			want:       []map[string]any{kernel},
+		},
+		{
+			name:    "matches of different fields",
+			matches: []string{"_TRANSPORT=syslog", "SYSLOG_FACILITY=0"},
+			want:    []map[string]any{},
+		},
+		{
+			name:    "disjunction",
+			matches: []string{"_TRANSPORT=syslog", "+", "_PID=1"},
+			want:    []map[string]any{sshd, systemdAboutSshd},
+		},
+		{
+			name:    "binary field",
+			matches: []string{"_HOSTNAME=host"},
+			want:    []map[string]any{kernel},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var matches IncludeMatches
+			for _, m := range tc.matches {
+				matches.Matches = append(matches.Matches, Matcher{m})
+			}
+			f := NewFilter(tc.units, tc.identifiers, tc.transports, matches, tc.facilities)
+
+			var got []map[string]any
+			for _, entry := range []map[string]any{sshd, systemdAboutSshd, kernel} {
+				if f.Match(entry) {
+					got = append(got, entry)
+				}
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("expecting %d entries to match, got %d: %v", len(tc.want), len(got), got)
+			}
+			for i := range got {
+				if got[i]["SYSLOG_IDENTIFIER"] != tc.want[i]["SYSLOG_IDENTIFIER"] {
+					t.Errorf("expecting entry %d to be %v, got %v", i, tc.want[i], got[i])
+				}
+			}
+		})
+	}
+}
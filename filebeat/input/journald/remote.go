@@ -0,0 +1,273 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package journald
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/elastic/go-concert/ctxtool"
+
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalctl"
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalexport"
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalfield"
+	input "github.com/elastic/beats/v7/filebeat/input/v2"
+	cursor "github.com/elastic/beats/v7/filebeat/input/v2/input-cursor"
+	"github.com/elastic/beats/v7/filebeat/inputsource/tcp"
+	"github.com/elastic/beats/v7/libbeat/management/status"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// remoteJournalID is the source of the entries received from remote hosts.
+const remoteJournalID = "REMOTE_JOURNAL"
+
+const (
+	// remoteUploadPath is the path systemd-journal-upload uploads to.
+	remoteUploadPath = "/upload"
+
+	contentTypeJournalExport = "application/vnd.fdo.journal"
+)
+
+// runRemote receives the journal entries uploaded by systemd-journal-upload
+// until the input is stopped. The uploaders keep track of the entries they
+// uploaded, so no state is stored in the registry.
+func (inp *journald) runRemote(ctx input.Context, logger *logp.Logger, publisher cursor.Publisher) error {
+	l, err := inp.listenRemote(logger)
+	if err != nil {
+		wrappedErr := fmt.Errorf("could not start remote journal receiver: %w", err)
+		ctx.UpdateStatus(status.Failed, wrappedErr.Error())
+		return wrappedErr
+	}
+
+	server := &http.Server{
+		Handler: &remoteHandler{
+			inp:       inp,
+			publisher: publisher,
+			canceler:  ctx.Cancelation,
+			filter: journalfield.NewFilter(
+				inp.Units,
+				inp.Identifiers,
+				inp.Transports,
+				inp.Matches,
+				inp.Facilities,
+			),
+			logger: logger,
+		},
+		// Uploads stream the entries as they are logged, so the request
+		// bodies are read without a timeout.
+		ReadHeaderTimeout: inp.Remote.Timeout,
+		IdleTimeout:       inp.Remote.Timeout,
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Cancelation.Done():
+			server.Close()
+		case <-done:
+		}
+	}()
+
+	ctx.UpdateStatus(status.Running, "Running")
+	err = server.Serve(l)
+	if errors.Is(err, http.ErrServerClosed) || ctx.Cancelation.Err() != nil {
+		return nil
+	}
+	msg := fmt.Sprintf("remote journal receiver failed: %s", err)
+	ctx.UpdateStatus(status.Failed, msg)
+	logger.Error(msg)
+	return err
+}
+
+// listenRemote listens on the host of the remote receiver.
+func (inp *journald) listenRemote(logger *logp.Logger) (net.Listener, error) {
+	var t *tls.Config
+	if inp.RemoteTLS != nil {
+		t = inp.RemoteTLS.BuildServerConfig(inp.Remote.Host)
+	}
+	l, err := tcp.Listen(&inp.Remote.Config, t, logger)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", inp.Remote.Host, err)
+	}
+	return l, nil
+}
+
+// remoteHandler receives journal entries in the Journal Export Format, like
+// systemd-journal-remote. The entries of each upload go through their own
+// parsers.
+type remoteHandler struct {
+	inp       *journald
+	publisher cursor.Publisher
+	canceler  input.Canceler
+	filter    journalfield.Filter
+	logger    *logp.Logger
+}
+
+func (h *remoteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != remoteUploadPath {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, fmt.Sprintf("unsupported method %s", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || contentType != contentTypeJournalExport {
+		http.Error(w, fmt.Sprintf("unsupported content type %q", r.Header.Get("Content-Type")), http.StatusUnsupportedMediaType)
+		return
+	}
+	if enc := r.Header.Get("Content-Encoding"); enc != "" && enc != "identity" {
+		http.Error(w, fmt.Sprintf("unsupported content encoding %q", enc), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	reqCtx, cancel := ctxtool.MergeContexts(r.Context(), ctxtool.FromCanceller(h.canceler))
+	defer cancel()
+
+	logger := h.logger.With("remote_address", r.RemoteAddr)
+	entries := &filteringReader{
+		r:      journalexport.NewReader(r.Body, int(h.inp.Remote.MaxMessageSize)),
+		filter: h.filter,
+	}
+	parser := h.inp.Parsers.Create(
+		&readerAdapter{
+			r:                  entries,
+			converter:          journalfield.NewConverter(logger, nil),
+			canceler:           reqCtx,
+			saveRemoteHostname: h.inp.SaveRemoteHostname,
+		}, logger)
+	defer parser.Close()
+
+	acks := newUploadACKs()
+	count := 0
+	for {
+		entry, err := parser.Next()
+		if err != nil {
+			switch {
+			case errors.Is(err, io.EOF):
+				logger.Debugf("Received %d journal entries", count)
+				// The upload is only accepted once its entries are
+				// acknowledged, so the uploader sends them again if they are
+				// lost.
+				select {
+				case <-acks.wait():
+				case <-reqCtx.Done():
+					http.Error(w, "receiver is stopping", http.StatusServiceUnavailable)
+					return
+				}
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(http.StatusAccepted)
+				_, _ = io.WriteString(w, "OK.\n")
+			case errors.Is(err, journalctl.ErrCancelled):
+				http.Error(w, "receiver is stopping", http.StatusServiceUnavailable)
+			case errors.Is(err, journalexport.ErrEntryTooLarge):
+				logger.Errorf("Could not read journal entry: %s", err)
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			default:
+				logger.Errorf("Could not read journal entry: %s", err)
+				http.Error(w, fmt.Sprintf("could not read journal entry: %s", err), http.StatusBadRequest)
+			}
+			return
+		}
+
+		event := entry.ToEvent()
+		// The position of the entries is tracked by the uploaders, the
+		// handler only waits for their acknowledgement.
+		acks.add()
+		event.Private = acks
+		if err := h.publisher.Publish(event, nil); err != nil {
+			http.Error(w, "receiver is stopping", http.StatusServiceUnavailable)
+			return
+		}
+		count++
+	}
+}
+
+// uploadACKs tracks the acknowledgement of the events of an upload.
+type uploadACKs struct {
+	mu      sync.Mutex
+	pending int
+	waiting bool
+	done    chan struct{}
+}
+
+func newUploadACKs() *uploadACKs {
+	return &uploadACKs{done: make(chan struct{})}
+}
+
+func (a *uploadACKs) add() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pending++
+}
+
+// EventACKed implements cursor.ACKNotifier.
+func (a *uploadACKs) EventACKed() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pending--
+	if a.waiting && a.pending == 0 {
+		close(a.done)
+	}
+}
+
+// wait returns a channel that is closed once all the events are
+// acknowledged. It must be called once all the events are published.
+func (a *uploadACKs) wait() <-chan struct{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.waiting = true
+	if a.pending == 0 {
+		close(a.done)
+	}
+	return a.done
+}
+
+// filteringReader skips the entries that aren't selected by the filter,
+// which selects the entries journalctl would read for the input.
+type filteringReader struct {
+	r      journalReader
+	filter journalfield.Filter
+}
+
+func (f *filteringReader) Close() error {
+	return f.r.Close()
+}
+
+func (f *filteringReader) Next(cancel input.Canceler) (journalctl.JournalEntry, error) {
+	for {
+		entry, err := f.r.Next(cancel)
+		if err != nil {
+			return entry, err
+		}
+		if f.filter.Match(entry.Fields) {
+			return entry, nil
+		}
+	}
+}
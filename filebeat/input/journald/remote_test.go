@@ -0,0 +1,216 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package journald
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalfield"
+	cursor "github.com/elastic/beats/v7/filebeat/input/v2/input-cursor"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+type eventsPublisher struct {
+	events  []beat.Event
+	cursors []any
+	// noACK is set to not acknowledge the events.
+	noACK bool
+}
+
+func (p *eventsPublisher) Publish(event beat.Event, c any) error {
+	p.events = append(p.events, event)
+	p.cursors = append(p.cursors, c)
+	if n, ok := event.Private.(cursor.ACKNotifier); ok && !p.noACK {
+		n.EventACKed()
+	}
+	return nil
+}
+
+func newRemoteHandler(t *testing.T, cfg mapstr.M) (*remoteHandler, *eventsPublisher) {
+	t.Helper()
+
+	logger := logptest.NewTestingLogger(t, "")
+	c := conf.MustNewConfigFrom(mapstr.M{"remote.enabled": true})
+	require.NoError(t, c.Merge(cfg))
+	_, inp, err := Configure(c, logger)
+	require.NoError(t, err)
+	j, ok := inp.(*journald)
+	require.True(t, ok)
+
+	publisher := &eventsPublisher{}
+	return &remoteHandler{
+		inp:       j,
+		publisher: publisher,
+		canceler:  t.Context(),
+		filter:    journalfield.NewFilter(j.Units, j.Identifiers, j.Transports, j.Matches, j.Facilities),
+		logger:    logger,
+	}, publisher
+}
+
+func uploadRequest(t *testing.T, file string) *http.Request {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", file))
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+
+	req := httptest.NewRequest(http.MethodPost, remoteUploadPath, f)
+	req.Header.Set("Content-Type", contentTypeJournalExport)
+	return req
+}
+
+func TestRemoteHandler(t *testing.T) {
+	t.Run("upload", func(t *testing.T) {
+		h, publisher := newRemoteHandler(t, mapstr.M{})
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, uploadRequest(t, "input-multiline-parser.export"))
+
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+		require.Len(t, publisher.events, 8)
+		for i, c := range publisher.cursors {
+			assert.Nil(t, c, "event %d must not update the cursor", i)
+			assert.Implements(t, (*cursor.ACKNotifier)(nil), publisher.events[i].Private, "event %d must notify the handler of its acknowledgement", i)
+		}
+
+		msg, err := publisher.events[0].Fields.GetValue("message")
+		require.NoError(t, err)
+		assert.Equal(t, "pam_unix(sudo:session): session closed for user root", msg)
+		hostname, err := publisher.events[0].Fields.GetValue("host.hostname")
+		require.NoError(t, err)
+		assert.Equal(t, "x-wing", hostname)
+	})
+
+	t.Run("upload not acknowledged", func(t *testing.T) {
+		h, publisher := newRemoteHandler(t, mapstr.M{})
+		publisher.noACK = true
+
+		ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+		defer cancel()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, uploadRequest(t, "input-multiline-parser.export").WithContext(ctx))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Len(t, publisher.events, 8)
+	})
+
+	t.Run("filtering", func(t *testing.T) {
+		h, publisher := newRemoteHandler(t, mapstr.M{
+			"syslog_identifiers": []string{"sudo"},
+		})
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, uploadRequest(t, "input-multiline-parser.export"))
+
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+		require.Len(t, publisher.events, 1)
+		for _, event := range publisher.events {
+			identifier, err := event.Fields.GetValue("log.syslog.appname")
+			require.NoError(t, err)
+			assert.Equal(t, "sudo", identifier)
+		}
+	})
+
+	t.Run("parsers", func(t *testing.T) {
+		h, publisher := newRemoteHandler(t, mapstr.M{
+			"parsers": []mapstr.M{
+				{
+					"multiline": mapstr.M{
+						"type":        "count",
+						"count_lines": 4,
+					},
+				},
+			},
+		})
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, uploadRequest(t, "input-multiline-parser.export"))
+
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+		require.Len(t, publisher.events, 2)
+	})
+
+	t.Run("entry too large", func(t *testing.T) {
+		h, publisher := newRemoteHandler(t, mapstr.M{
+			"remote.max_message_size": "100B",
+		})
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, uploadRequest(t, "input-multiline-parser.export"))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		assert.Empty(t, publisher.events)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		h, publisher := newRemoteHandler(t, mapstr.M{})
+
+		req := httptest.NewRequest(http.MethodPost, remoteUploadPath, strings.NewReader("MESSAGE=no timestamps\n\n"))
+		req.Header.Set("Content-Type", contentTypeJournalExport)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Empty(t, publisher.events)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		h, _ := newRemoteHandler(t, mapstr.M{})
+
+		tests := []struct {
+			name        string
+			method      string
+			path        string
+			contentType string
+			encoding    string
+			want        int
+		}{
+			{name: "path", method: http.MethodPost, path: "/", contentType: contentTypeJournalExport, want: http.StatusNotFound},
+			{name: "method", method: http.MethodGet, path: remoteUploadPath, contentType: contentTypeJournalExport, want: http.StatusMethodNotAllowed},
+			{name: "content type", method: http.MethodPost, path: remoteUploadPath, contentType: "application/json", want: http.StatusUnsupportedMediaType},
+			{name: "content encoding", method: http.MethodPost, path: remoteUploadPath, contentType: contentTypeJournalExport, encoding: "gzip", want: http.StatusUnsupportedMediaType},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(""))
+				req.Header.Set("Content-Type", tc.contentType)
+				if tc.encoding != "" {
+					req.Header.Set("Content-Encoding", tc.encoding)
+				}
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, req)
+				assert.Equal(t, tc.want, rec.Code)
+			})
+		}
+	})
+}
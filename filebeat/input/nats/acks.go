@@ -0,0 +1,122 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package nats
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/acker"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// ackHandler acknowledges messages to the server once the publisher
+// pipeline acknowledged the events created from them, and limits the number
+// of messages in flight. Each message is published as a single event,
+// holding the message in its Private field.
+//
+// JetStream acknowledges each message on its own, in any order, and an
+// acknowledgement is only a message published to the reply subject of the
+// message, so the messages are acknowledged from the pipeline callback
+// without waiting for the server.
+type ackHandler struct {
+	log *logp.Logger
+
+	// inFlight holds a token for each message received and not acknowledged
+	// by the pipeline yet.
+	inFlight chan struct{}
+	// acked is signalled each time messages are acknowledged.
+	acked chan struct{}
+}
+
+func newACKHandler(log *logp.Logger, maxInFlight int) *ackHandler {
+	return &ackHandler{
+		log:      log,
+		inFlight: make(chan struct{}, maxInFlight),
+		acked:    make(chan struct{}, 1),
+	}
+}
+
+// acquire waits until a message can be received without exceeding the
+// maximum number of messages in flight. It returns false if ctx is
+// cancelled first.
+func (ah *ackHandler) acquire(ctx context.Context) bool {
+	select {
+	case ah.inFlight <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release frees the slot of a message that won't be acknowledged.
+func (ah *ackHandler) release(n int) {
+	for range n {
+		select {
+		case <-ah.inFlight:
+		default:
+			return
+		}
+	}
+}
+
+func (ah *ackHandler) pipelineEventListener() beat.EventListener {
+	return acker.ConnectionOnly(
+		acker.EventPrivateReporter(func(_ int, privates []any) {
+			n := 0
+			for _, private := range privates {
+				msg, ok := private.(jetstream.Msg)
+				if !ok {
+					continue
+				}
+				n++
+				if err := msg.Ack(); err != nil {
+					// The connection is closed, the server redelivers the
+					// message once its ack_wait expires.
+					ah.log.Debugw("Failed to acknowledge message", "subject", msg.Subject(), "error", err)
+				}
+			}
+			if n == 0 {
+				return
+			}
+			ah.release(n)
+			select {
+			case ah.acked <- struct{}{}:
+			default:
+			}
+		}),
+	)
+}
+
+// wait waits until the messages in flight are acknowledged, or the timeout
+// expires.
+func (ah *ackHandler) wait(timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for len(ah.inFlight) > 0 {
+		select {
+		case <-ah.acked:
+		case <-timer.C:
+			ah.log.Debugw("Timed out waiting for acknowledgments", "in_flight", len(ah.inFlight))
+			return
+		}
+	}
+}
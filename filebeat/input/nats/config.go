@@ -0,0 +1,168 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package nats
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+type config struct {
+	Hosts             []string              `config:"hosts" validate:"required"`
+	Stream            string                `config:"stream" validate:"required"`
+	Consumer          consumerConfig        `config:"consumer"`
+	MaxInFlight       int                   `config:"max_in_flight" validate:"min=1"`
+	Username          string                `config:"username"`
+	Password          string                `config:"password"`
+	Token             string                `config:"token"`
+	CredentialsFile   string                `config:"credentials_file"`
+	NKeySeedFile      string                `config:"nkey_seed_file"`
+	TLS               *tlscommon.Config     `config:"ssl"`
+	SubjectFields     []subjectFieldsConfig `config:"subject_fields"`
+	ConnectBackoff    time.Duration         `config:"connect_backoff" validate:"min=0"`
+	MaxConnectBackoff time.Duration         `config:"max_connect_backoff" validate:"min=0"`
+	WaitClose         time.Duration         `config:"wait_close" validate:"min=0"`
+}
+
+// consumerConfig identifies the durable consumer, and describes it when the
+// input creates it.
+type consumerConfig struct {
+	Name           string        `config:"name"`
+	Create         bool          `config:"create"`
+	FilterSubjects []string      `config:"filter_subjects"`
+	DeliverPolicy  deliverPolicy `config:"deliver_policy"`
+	AckWait        time.Duration `config:"ack_wait" validate:"min=0"`
+	MaxDeliver     int           `config:"max_deliver"`
+}
+
+// subjectFieldsConfig adds fields to the events of the messages whose
+// subject matches the pattern.
+type subjectFieldsConfig struct {
+	Subject string   `config:"subject" validate:"required"`
+	Fields  mapstr.M `config:"fields" validate:"required"`
+}
+
+type deliverPolicy jetstream.DeliverPolicy
+
+var deliverPolicies = map[string]deliverPolicy{
+	"all":  deliverPolicy(jetstream.DeliverAllPolicy),
+	"last": deliverPolicy(jetstream.DeliverLastPolicy),
+	"new":  deliverPolicy(jetstream.DeliverNewPolicy),
+}
+
+// Unpack validates and unpacks the deliver_policy config option.
+func (p *deliverPolicy) Unpack(value string) error {
+	policy, ok := deliverPolicies[strings.ToLower(value)]
+	if !ok {
+		return fmt.Errorf("invalid deliver_policy '%s', must be one of all, last or new", value)
+	}
+	*p = policy
+	return nil
+}
+
+func defaultConfig() config {
+	return config{
+		MaxInFlight: 1024,
+		Consumer: consumerConfig{
+			DeliverPolicy: deliverPolicy(jetstream.DeliverAllPolicy),
+		},
+		ConnectBackoff:    time.Second,
+		MaxConnectBackoff: time.Minute,
+		WaitClose:         5 * time.Second,
+	}
+}
+
+// Validate validates the config.
+func (c *config) Validate() error {
+	if len(c.Hosts) == 0 {
+		return errors.New("no hosts configured")
+	}
+	if c.Consumer.Name == "" {
+		return errors.New("no consumer configured")
+	}
+
+	var auth []string
+	if c.Username != "" {
+		if c.Password == "" {
+			return errors.New("password must be set when username is configured")
+		}
+		auth = append(auth, "username")
+	}
+	if c.Token != "" {
+		auth = append(auth, "token")
+	}
+	if c.CredentialsFile != "" {
+		auth = append(auth, "credentials_file")
+	}
+	if c.NKeySeedFile != "" {
+		auth = append(auth, "nkey_seed_file")
+	}
+	if len(auth) > 1 {
+		return fmt.Errorf("only one authentication method can be configured, got %s", strings.Join(auth, ", "))
+	}
+
+	for _, s := range c.Consumer.FilterSubjects {
+		if err := validateSubject(s); err != nil {
+			return fmt.Errorf("invalid filter subject: %w", err)
+		}
+	}
+	for _, sf := range c.SubjectFields {
+		if err := validateSubject(sf.Subject); err != nil {
+			return fmt.Errorf("invalid subject_fields subject: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateSubject checks a subject pattern has no empty token, and only has
+// the '>' wildcard as its last token.
+func validateSubject(subject string) error {
+	tokens := strings.Split(subject, ".")
+	for i, t := range tokens {
+		if t == "" {
+			return fmt.Errorf("subject %q has an empty token", subject)
+		}
+		if t == ">" && i != len(tokens)-1 {
+			return fmt.Errorf("subject %q has '>' before its last token", subject)
+		}
+	}
+	return nil
+}
+
+// subjectMatches reports whether subject matches the pattern, where '*'
+// matches any token and a final '>' matches one or more tokens.
+func subjectMatches(pattern, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+	for i, p := range patternTokens {
+		if p == ">" {
+			return len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) || (p != "*" && p != subjectTokens[i]) {
+			return false
+		}
+	}
+	return len(patternTokens) == len(subjectTokens)
+}
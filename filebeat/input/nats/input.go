@@ -0,0 +1,375 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package nats
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	input "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/backoff"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	"github.com/elastic/beats/v7/libbeat/management/status"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+const (
+	pluginName = "nats"
+
+	// pullBatchSize is the maximum number of messages buffered by the
+	// client. Buffered messages count against the ack wait of the consumer,
+	// so the buffer is kept small.
+	pullBatchSize = 100
+)
+
+// Plugin creates a new nats input plugin, consuming messages from NATS
+// JetStream consumers.
+func Plugin(log *logp.Logger) input.Plugin {
+	return input.Plugin{
+		Name:       pluginName,
+		Stability:  feature.Beta,
+		Deprecated: false,
+		Info:       "NATS JetStream input",
+		Doc:        "The NATS input consumes messages from a durable NATS JetStream consumer",
+		Manager:    input.ConfigureWith(configure, log),
+	}
+}
+
+func configure(cfg *conf.C, logger *logp.Logger) (input.Input, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	var tlsConfig *tlscommon.TLSConfig
+	if config.TLS.IsEnabled() {
+		var err error
+		tlsConfig, err = tlscommon.LoadTLSConfig(config.TLS, logger)
+		if err != nil {
+			return nil, fmt.Errorf("loading ssl configuration: %w", err)
+		}
+	}
+	return &natsInput{config: config, tlsConfig: tlsConfig}, nil
+}
+
+type natsInput struct {
+	config    config
+	tlsConfig *tlscommon.TLSConfig
+}
+
+func (in *natsInput) Name() string { return pluginName }
+
+// Test connects to the servers, and checks the stream and the consumer
+// exist unless the input creates the consumer.
+func (in *natsInput) Test(ctx input.TestContext) error {
+	nc, err := in.connect(ctx.Logger)
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return err
+	}
+	goContext := input.GoContextFromCanceler(ctx.Cancelation)
+	if in.config.Consumer.Create {
+		_, err = js.Stream(goContext, in.config.Stream)
+	} else {
+		_, err = js.Consumer(goContext, in.config.Stream, in.config.Consumer.Name)
+	}
+	return err
+}
+
+func (in *natsInput) Run(ctx input.Context, pipeline beat.Pipeline) error {
+	log := ctx.Logger.Named("nats input").With("stream", in.config.Stream, "consumer", in.config.Consumer.Name)
+
+	acks := newACKHandler(log, in.config.MaxInFlight)
+	client, err := pipeline.ConnectWith(beat.ClientConfig{
+		EventListener: acks.pipelineEventListener(),
+		WaitClose:     in.config.WaitClose,
+	})
+	if err != nil {
+		return err
+	}
+	// Messages can only be acknowledged while the connection is open, so the
+	// pipeline client is closed first, waiting up to wait_close for the
+	// messages in flight to be acknowledged.
+	var closeOnce sync.Once
+	closeClient := func() {
+		closeOnce.Do(func() {
+			client.Close()
+			acks.wait(in.config.WaitClose)
+		})
+	}
+	defer closeClient()
+
+	log.Info("Starting NATS input")
+	defer log.Info("NATS input stopped")
+	ctx.UpdateStatus(status.Starting, "")
+
+	goContext := input.GoContextFromCanceler(ctx.Cancelation)
+	connectDelay := backoff.NewEqualJitterBackoff(in.config.ConnectBackoff, in.config.MaxConnectBackoff)
+	for goContext.Err() == nil {
+		c := consumer{
+			input:  in,
+			log:    log,
+			client: client,
+			acks:   acks,
+			onConsuming: func() {
+				connectDelay.Reset()
+				ctx.UpdateStatus(status.Running, "")
+			},
+			onStopping: closeClient,
+		}
+		err := c.run(goContext)
+		if err == nil {
+			break
+		}
+		log.Errorw("NATS consumer failed", "error", err)
+		ctx.UpdateStatus(status.Degraded, "NATS consumer failed: "+err.Error())
+		connectDelay.Wait(goContext)
+	}
+
+	ctx.UpdateStatus(status.Stopped, "")
+	if errors.Is(ctx.Cancelation.Err(), context.Canceled) {
+		return nil
+	}
+	return ctx.Cancelation.Err()
+}
+
+func (in *natsInput) connect(log *logp.Logger) (*nats.Conn, error) {
+	opts := []nats.Option{
+		nats.Name("filebeat"),
+		// The client reconnects to the servers in the background, while the
+		// messages iterator resumes pulling messages once reconnected.
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Warnw("Disconnected from NATS server", "error", err)
+			}
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			log.Infow("Reconnected to NATS server", "server", nc.ConnectedUrlRedacted())
+		}),
+	}
+
+	switch {
+	case in.config.Username != "":
+		opts = append(opts, nats.UserInfo(in.config.Username, in.config.Password))
+	case in.config.Token != "":
+		opts = append(opts, nats.Token(in.config.Token))
+	case in.config.CredentialsFile != "":
+		opts = append(opts, nats.UserCredentials(in.config.CredentialsFile))
+	case in.config.NKeySeedFile != "":
+		opt, err := nats.NkeyOptionFromSeed(in.config.NKeySeedFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading nkey seed: %w", err)
+		}
+		opts = append(opts, opt)
+	}
+
+	if in.tlsConfig != nil {
+		opts = append(opts, nats.Secure(in.buildTLSConfig()))
+	}
+
+	nc, err := nats.Connect(strings.Join(in.config.Hosts, ","), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("connecting: %w", err)
+	}
+	return nc, nil
+}
+
+// buildTLSConfig returns the TLS configuration of the connections. The
+// client connects to any server of the cluster, and sets the server name
+// of each connection, so the certificate is verified against the server
+// name of the connection.
+func (in *natsInput) buildTLSConfig() *tls.Config {
+	cfg := in.tlsConfig.BuildModuleClientConfig("")
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		verify := in.tlsConfig.BuildModuleClientConfig(cs.ServerName).VerifyConnection
+		if verify == nil {
+			return nil
+		}
+		return verify(cs)
+	}
+	return cfg
+}
+
+// consumer consumes the durable consumer over a single connection.
+type consumer struct {
+	input  *natsInput
+	log    *logp.Logger
+	client beat.Client
+	acks   *ackHandler
+
+	// onConsuming is called once the consumer pulls messages.
+	onConsuming func()
+	// onStopping is called when the input stops, before the connection is
+	// closed.
+	onStopping func()
+}
+
+// run consumes messages until ctx is cancelled, returning nil, or the
+// consumer fails.
+func (c *consumer) run(ctx context.Context) error {
+	config := c.input.config
+
+	nc, err := c.input.connect(c.log)
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return err
+	}
+	cons, err := c.consumer(ctx, js)
+	if err != nil {
+		return err
+	}
+
+	iter, err := cons.Messages(jetstream.PullMaxMessages(min(config.MaxInFlight, pullBatchSize)))
+	if err != nil {
+		return fmt.Errorf("pulling messages: %w", err)
+	}
+	defer iter.Stop()
+	c.log.Infow("Consuming messages", "server", nc.ConnectedUrlRedacted())
+	c.onConsuming()
+
+	for {
+		if !c.acks.acquire(ctx) {
+			break
+		}
+		msg, err := iter.Next(jetstream.NextContext(ctx))
+		if err != nil {
+			c.acks.release(1)
+			if ctx.Err() != nil {
+				break
+			}
+			return fmt.Errorf("receiving message: %w", err)
+		}
+		event, err := newEvent(msg, config.SubjectFields)
+		if err != nil {
+			// The message can't be from JetStream, there's nothing to
+			// acknowledge.
+			c.acks.release(1)
+			c.log.Warnw("Dropping message", "subject", msg.Subject(), "error", err)
+			continue
+		}
+		c.client.Publish(event)
+	}
+
+	// Stop pulling messages, then wait for the pipeline to acknowledge the
+	// published events while the connection is still open. The messages
+	// that weren't acknowledged are redelivered once their ack wait
+	// expires.
+	iter.Stop()
+	c.onStopping()
+	return nil
+}
+
+// consumer returns the durable consumer, creating or updating it if the
+// input is configured to.
+func (c *consumer) consumer(ctx context.Context, js jetstream.JetStream) (jetstream.Consumer, error) {
+	config := c.input.config
+	if !config.Consumer.Create {
+		cons, err := js.Consumer(ctx, config.Stream, config.Consumer.Name)
+		if err != nil {
+			return nil, fmt.Errorf("getting consumer: %w", err)
+		}
+		return cons, nil
+	}
+
+	cons, err := js.CreateOrUpdateConsumer(ctx, config.Stream, jetstream.ConsumerConfig{
+		Durable:        config.Consumer.Name,
+		DeliverPolicy:  jetstream.DeliverPolicy(config.Consumer.DeliverPolicy),
+		AckPolicy:      jetstream.AckExplicitPolicy,
+		AckWait:        config.Consumer.AckWait,
+		MaxDeliver:     config.Consumer.MaxDeliver,
+		FilterSubjects: config.Consumer.FilterSubjects,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating consumer: %w", err)
+	}
+	return cons, nil
+}
+
+// newEvent creates the event of a message, which is acknowledged once the
+// event is.
+func newEvent(msg jetstream.Msg, subjectFields []subjectFieldsConfig) (beat.Event, error) {
+	meta, err := msg.Metadata()
+	if err != nil {
+		return beat.Event{}, err
+	}
+
+	fields := mapstr.M{
+		"subject":  msg.Subject(),
+		"stream":   meta.Stream,
+		"consumer": meta.Consumer,
+		"sequence": mapstr.M{
+			"stream":   meta.Sequence.Stream,
+			"consumer": meta.Sequence.Consumer,
+		},
+		"num_delivered": meta.NumDelivered,
+		"num_pending":   meta.NumPending,
+	}
+	if headers := msg.Headers(); len(headers) > 0 {
+		h := make(mapstr.M, len(headers))
+		for k, values := range headers {
+			if len(values) == 1 {
+				h[k] = values[0]
+			} else {
+				h[k] = values
+			}
+		}
+		fields["headers"] = h
+	}
+
+	ts := meta.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	event := beat.Event{
+		Timestamp: ts,
+		Fields: mapstr.M{
+			"message": string(msg.Data()),
+			"nats":    fields,
+		},
+		Private: msg,
+	}
+	for _, sf := range subjectFields {
+		if subjectMatches(sf.Subject, msg.Subject()) {
+			event.Fields.DeepUpdate(sf.Fields.Clone())
+		}
+	}
+	return event, nil
+}
@@ -0,0 +1,273 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package nats
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// fakeMsg is a JetStream message recording its acknowledgments.
+type fakeMsg struct {
+	jetstream.Msg
+
+	subject  string
+	data     []byte
+	headers  nats.Header
+	metadata *jetstream.MsgMetadata
+
+	mu    sync.Mutex
+	acked int
+}
+
+func (m *fakeMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	if m.metadata == nil {
+		return nil, errors.New("message is not bound to a subscription")
+	}
+	return m.metadata, nil
+}
+
+func (m *fakeMsg) Data() []byte         { return m.data }
+func (m *fakeMsg) Headers() nats.Header { return m.headers }
+func (m *fakeMsg) Subject() string      { return m.subject }
+
+func (m *fakeMsg) Ack() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.acked++
+	return nil
+}
+
+func (m *fakeMsg) ackCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.acked
+}
+
+func TestConfigValidate(t *testing.T) {
+	base := func() map[string]any {
+		return map[string]any{
+			"hosts":         []string{"nats://localhost:4222"},
+			"stream":        "LOGS",
+			"consumer.name": "filebeat",
+		}
+	}
+	tests := map[string]struct {
+		config  map[string]any
+		wantErr string
+	}{
+		"minimal": {},
+		"create consumer": {
+			config: map[string]any{
+				"consumer.create":          true,
+				"consumer.filter_subjects": []string{"logs.*.app", "audit.>"},
+				"consumer.deliver_policy":  "new",
+				"consumer.ack_wait":        "1m",
+			},
+		},
+		"missing consumer": {
+			config:  map[string]any{"consumer.name": ""},
+			wantErr: "no consumer configured",
+		},
+		"invalid deliver policy": {
+			config:  map[string]any{"consumer.deliver_policy": "oldest"},
+			wantErr: "invalid deliver_policy",
+		},
+		"invalid max in flight": {
+			config:  map[string]any{"max_in_flight": 0},
+			wantErr: "requires value >= 1",
+		},
+		"username without password": {
+			config:  map[string]any{"username": "filebeat"},
+			wantErr: "password must be set",
+		},
+		"several authentication methods": {
+			config: map[string]any{
+				"token":            "secret",
+				"credentials_file": "/etc/nats/filebeat.creds",
+			},
+			wantErr: "only one authentication method",
+		},
+		"invalid filter subject": {
+			config:  map[string]any{"consumer.filter_subjects": []string{"logs.>.app"}},
+			wantErr: "invalid filter subject",
+		},
+		"invalid subject fields": {
+			config: map[string]any{
+				"subject_fields": []map[string]any{
+					{"subject": "logs..app", "fields": map[string]any{"service.name": "app"}},
+				},
+			},
+			wantErr: "has an empty token",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := conf.MustNewConfigFrom(base())
+			require.NoError(t, cfg.Merge(tc.config))
+			c := defaultConfig()
+			err := cfg.Unpack(&c)
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSubjectMatches(t *testing.T) {
+	tests := []struct {
+		pattern, subject string
+		want             bool
+	}{
+		{"logs.app", "logs.app", true},
+		{"logs.app", "logs.web", false},
+		{"logs.*", "logs.app", true},
+		{"logs.*", "logs.app.error", false},
+		{"logs.*.error", "logs.app.error", true},
+		{"logs.>", "logs.app", true},
+		{"logs.>", "logs.app.error", true},
+		{"logs.>", "logs", false},
+		{">", "logs", true},
+		{"logs.app.error", "logs.app", false},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, subjectMatches(tc.pattern, tc.subject), "%s matching %s", tc.pattern, tc.subject)
+	}
+}
+
+func TestNewEvent(t *testing.T) {
+	ts := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	msg := &fakeMsg{
+		subject: "logs.web.nginx",
+		data:    []byte("GET /index.html 200"),
+		headers: nats.Header{
+			"Nats-Msg-Id": []string{"m-1"},
+			"Tags":        []string{"a", "b"},
+		},
+		metadata: &jetstream.MsgMetadata{
+			Sequence:     jetstream.SequencePair{Stream: 42, Consumer: 7},
+			NumDelivered: 2,
+			NumPending:   10,
+			Timestamp:    ts,
+			Stream:       "LOGS",
+			Consumer:     "filebeat",
+		},
+	}
+	subjectFields := []subjectFieldsConfig{
+		{Subject: "logs.*.nginx", Fields: mapstr.M{"service": mapstr.M{"name": "nginx"}}},
+		{Subject: "logs.web.>", Fields: mapstr.M{"labels": mapstr.M{"tier": "web"}}},
+		{Subject: "logs.db.>", Fields: mapstr.M{"labels": mapstr.M{"tier": "db"}}},
+	}
+
+	event, err := newEvent(msg, subjectFields)
+	require.NoError(t, err)
+	assert.Equal(t, ts, event.Timestamp)
+	assert.Equal(t, mapstr.M{
+		"message": "GET /index.html 200",
+		"nats": mapstr.M{
+			"subject":  "logs.web.nginx",
+			"stream":   "LOGS",
+			"consumer": "filebeat",
+			"sequence": mapstr.M{
+				"stream":   uint64(42),
+				"consumer": uint64(7),
+			},
+			"num_delivered": uint64(2),
+			"num_pending":   uint64(10),
+			"headers": mapstr.M{
+				"Nats-Msg-Id": "m-1",
+				"Tags":        []string{"a", "b"},
+			},
+		},
+		"service": mapstr.M{"name": "nginx"},
+		"labels":  mapstr.M{"tier": "web"},
+	}, event.Fields)
+	assert.Equal(t, msg, event.Private)
+
+	// The fields of the configuration aren't shared between events.
+	event.Fields.Put("service.name", "changed")
+	assert.Equal(t, "nginx", subjectFields[0].Fields["service"].(mapstr.M)["name"])
+
+	_, err = newEvent(&fakeMsg{subject: "core"}, nil)
+	assert.Error(t, err)
+}
+
+func TestACKHandler(t *testing.T) {
+	handler := newACKHandler(logptest.NewTestingLogger(t, ""), 2)
+	listener := handler.pipelineEventListener()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msgs := []*fakeMsg{{subject: "a"}, {subject: "b"}, {subject: "c"}}
+
+	// Only max_in_flight messages can be received before the pipeline ACKs.
+	require.True(t, handler.acquire(ctx))
+	listener.AddEvent(beat.Event{Private: msgs[0]}, true)
+	require.True(t, handler.acquire(ctx))
+	listener.AddEvent(beat.Event{Private: msgs[1]}, true)
+
+	acquired := make(chan bool)
+	go func() { acquired <- handler.acquire(ctx) }()
+	select {
+	case <-acquired:
+		t.Fatal("acquired more than max_in_flight slots")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Zero(t, msgs[0].ackCount())
+
+	listener.ACKEvents(1)
+	require.True(t, <-acquired)
+	assert.Equal(t, 1, msgs[0].ackCount())
+	listener.AddEvent(beat.Event{Private: msgs[2]}, true)
+	listener.ACKEvents(2)
+
+	for _, msg := range msgs {
+		assert.Equal(t, 1, msg.ackCount(), "message %s", msg.subject)
+	}
+
+	// Waiting for the messages in flight times out while they aren't
+	// acknowledged.
+	require.True(t, handler.acquire(ctx))
+	start := time.Now()
+	handler.wait(50 * time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	handler.release(1)
+	handler.wait(time.Minute)
+
+	// Waiting for a slot stops when the input is cancelled.
+	require.True(t, handler.acquire(ctx))
+	require.True(t, handler.acquire(ctx))
+	cancel()
+	assert.False(t, handler.acquire(ctx))
+}
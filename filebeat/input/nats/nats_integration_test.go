@@ -0,0 +1,175 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build integration
+
+package nats
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+)
+
+func getTestNATSHost() string {
+	host := os.Getenv("NATS_HOST")
+	if host == "" {
+		host = "localhost"
+	}
+	port := os.Getenv("NATS_PORT")
+	if port == "" {
+		port = "4222"
+	}
+	return fmt.Sprintf("nats://%s:%s", host, port)
+}
+
+// ackingPipeline publishes the events of its client to a channel, and lets
+// the test acknowledge them.
+type ackingPipeline struct {
+	mu       sync.Mutex
+	listener beat.EventListener
+	events   chan beat.Event
+}
+
+func (p *ackingPipeline) ConnectWith(cfg beat.ClientConfig) (beat.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.listener = cfg.EventListener
+	return &ackingClient{pipeline: p}, nil
+}
+
+func (p *ackingPipeline) Connect() (beat.Client, error) {
+	return p.ConnectWith(beat.ClientConfig{})
+}
+
+func (p *ackingPipeline) Disconnect(context.Context) error { return nil }
+
+func (p *ackingPipeline) ack(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.listener.ACKEvents(n)
+}
+
+type ackingClient struct {
+	pipeline *ackingPipeline
+}
+
+func (c *ackingClient) Publish(event beat.Event) {
+	c.pipeline.mu.Lock()
+	c.pipeline.listener.AddEvent(event, true)
+	c.pipeline.mu.Unlock()
+	c.pipeline.events <- event
+}
+
+func (c *ackingClient) PublishAll(events []beat.Event) {
+	for _, event := range events {
+		c.Publish(event)
+	}
+}
+
+func (c *ackingClient) Close() error { return nil }
+
+func TestInput(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	nc, err := nats.Connect(getTestNATSHost())
+	require.NoError(t, err)
+	defer nc.Close()
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	streamName := fmt.Sprintf("FILEBEAT_TEST_%d", time.Now().UnixNano())
+	_, err = js.CreateStream(ctx, jetstream.StreamConfig{
+		Name:     streamName,
+		Subjects: []string{streamName + ".>"},
+	})
+	require.NoError(t, err)
+	defer func() {
+		_ = js.DeleteStream(context.Background(), streamName)
+	}()
+
+	const count = 10
+	for i := range count {
+		_, err := js.Publish(ctx, fmt.Sprintf("%s.app%d", streamName, i%2), []byte(fmt.Sprintf("message %d", i)))
+		require.NoError(t, err)
+	}
+
+	cfg := conf.MustNewConfigFrom(map[string]any{
+		"hosts":           []string{getTestNATSHost()},
+		"stream":          streamName,
+		"consumer.name":   "filebeat",
+		"consumer.create": true,
+		"max_in_flight":   4,
+		"wait_close":      "5s",
+		"subject_fields": []map[string]any{
+			{"subject": streamName + ".app0", "fields": map[string]any{"service.name": "app0"}},
+		},
+	})
+	inp, err := Plugin(logptest.NewTestingLogger(t, "")).Manager.Create(cfg)
+	require.NoError(t, err)
+
+	pipeline := &ackingPipeline{events: make(chan beat.Event, count)}
+	inputCtx, stopInput := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- inp.Run(v2.Context{
+			Logger:      logptest.NewTestingLogger(t, ""),
+			ID:          "nats-test",
+			Cancelation: inputCtx,
+		}, pipeline)
+	}()
+
+	for i := range count {
+		select {
+		case event := <-pipeline.events:
+			assert.Equal(t, fmt.Sprintf("message %d", i), event.Fields["message"])
+			serviceName, _ := event.Fields.GetValue("service.name")
+			if i%2 == 0 {
+				assert.Equal(t, "app0", serviceName)
+			} else {
+				assert.Nil(t, serviceName)
+			}
+			pipeline.ack(1)
+		case <-ctx.Done():
+			t.Fatalf("timeout waiting for message %d", i)
+		}
+	}
+
+	stopInput()
+	require.NoError(t, <-done)
+
+	cons, err := js.Consumer(ctx, streamName, "filebeat")
+	require.NoError(t, err)
+	info, err := cons.Info(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(count), info.AckFloor.Stream, "all messages should be acknowledged")
+	assert.Zero(t, info.NumAckPending)
+}
@@ -58,6 +58,16 @@ type Input interface {
 	Run(v2.Context, chan<- DataMetadata, Metrics) error
 }
 
+// EventBuilder is implemented by the net inputs that create structured
+// events from the received data. Other inputs publish the data as the
+// message of the event.
+type EventBuilder interface {
+	// BuildEvent creates the event of the received data. It's called by
+	// the pipeline workers, concurrently. If it returns an error, the data
+	// is dropped.
+	BuildEvent(DataMetadata) (beat.Event, error)
+}
+
 // Metrics is an interface to abstract the metrics
 // from input/netmetrics
 type Metrics interface {
@@ -219,18 +229,10 @@ func (w wrapper) publishLoop(ctx v2.Context, id int, client beat.Client, metrics
 			return
 		case d := <-w.evtChan:
 			start := time.Now()
-			evt := beat.Event{
-				Timestamp: d.Timestamp,
-				Fields: mapstr.M{
-					"message": string(d.Data),
-				},
-			}
-			if d.Metadata.RemoteAddr != nil {
-				evt.Fields["log"] = mapstr.M{
-					"source": mapstr.M{
-						"address": d.Metadata.RemoteAddr.String(),
-					},
-				}
+			evt, err := w.buildEvent(d)
+			if err != nil {
+				logger.Warnw("Dropping received data", "error", err)
+				continue
 			}
 
 			client.Publish(evt)
@@ -238,3 +240,26 @@ func (w wrapper) publishLoop(ctx v2.Context, id int, client beat.Client, metrics
 		}
 	}
 }
+
+// buildEvent creates the event of d, using the input's EventBuilder if it
+// implements it.
+func (w wrapper) buildEvent(d DataMetadata) (beat.Event, error) {
+	if b, ok := w.inp.(EventBuilder); ok {
+		return b.BuildEvent(d)
+	}
+
+	evt := beat.Event{
+		Timestamp: d.Timestamp,
+		Fields: mapstr.M{
+			"message": string(d.Data),
+		},
+	}
+	if d.Metadata.RemoteAddr != nil {
+		evt.Fields["log"] = mapstr.M{
+			"source": mapstr.M{
+				"address": d.Metadata.RemoteAddr.String(),
+			},
+		}
+	}
+	return evt, nil
+}
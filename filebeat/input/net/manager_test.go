@@ -35,8 +35,10 @@ import (
 	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
 	"github.com/elastic/beats/v7/filebeat/input/v2/testpipeline"
 	"github.com/elastic/beats/v7/libbeat/beat"
+	pubtest "github.com/elastic/beats/v7/libbeat/publisher/testing"
 	conf "github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
 	"github.com/elastic/elastic-agent-libs/monitoring"
 )
 
@@ -144,6 +146,53 @@ func TestPublishLoop(t *testing.T) {
 	}
 }
 
+// eventBuilderInput is an input creating its events with an EventBuilder.
+type eventBuilderInput struct {
+	inputMock
+}
+
+func (*eventBuilderInput) BuildEvent(d DataMetadata) (beat.Event, error) {
+	if string(d.Data) == "invalid" {
+		return beat.Event{}, errors.New("invalid data")
+	}
+	return beat.Event{
+		Timestamp: d.Timestamp,
+		Fields:    mapstr.M{"decoded": string(d.Data)},
+	}, nil
+}
+
+func TestPublishLoopEventBuilder(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	v2Ctx := v2.Context{
+		Logger:      logp.NewNopLogger(),
+		Cancelation: ctx,
+	}
+
+	w := wrapper{
+		inp:     &eventBuilderInput{},
+		evtChan: make(chan DataMetadata),
+	}
+	published := make(chan beat.Event, 2)
+	metrics := &metricsMock{
+		EventPublishedFunc: func(start time.Time) {},
+		EventReceivedFunc:  func(len int, timestamp time.Time) {},
+	}
+	go w.publishLoop(v2Ctx, 0, pubtest.ChClient(published), metrics)
+
+	for _, data := range []string{"invalid", "valid"} {
+		w.evtChan <- DataMetadata{Timestamp: time.Now(), Data: []byte(data)}
+	}
+
+	select {
+	case evt := <-published:
+		assert.Equal(t, mapstr.M{"decoded": "valid"}, evt.Fields)
+	case <-time.After(time.Second):
+		t.Fatal("event was not published")
+	}
+	assert.Empty(t, published, "the invalid data must be dropped")
+}
+
 func TestInitWorkers(t *testing.T) {
 	expectedClients := 2
 	v2Ctx := v2.Context{
@@ -0,0 +1,169 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package snmptrap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gosnmp/gosnmp"
+
+	"github.com/elastic/beats/v7/filebeat/inputsource/udp"
+)
+
+type config struct {
+	udp.Config `config:",inline"`
+
+	// Communities are the accepted SNMPv1 and SNMPv2c communities. All
+	// communities are accepted if it's empty.
+	Communities []string     `config:"communities"`
+	Users       []userConfig `config:"users"`
+	MIBDirs     []string     `config:"mib_dirs"`
+}
+
+// userConfig holds the USM credentials of an SNMPv3 user.
+type userConfig struct {
+	Username     string       `config:"username" validate:"required"`
+	AuthProtocol authProtocol `config:"auth_protocol"`
+	AuthPassword string       `config:"auth_password"`
+	PrivProtocol privProtocol `config:"priv_protocol"`
+	PrivPassword string       `config:"priv_password"`
+}
+
+type authProtocol gosnmp.SnmpV3AuthProtocol
+
+var authProtocols = map[string]authProtocol{
+	"":       authProtocol(gosnmp.NoAuth),
+	"md5":    authProtocol(gosnmp.MD5),
+	"sha":    authProtocol(gosnmp.SHA),
+	"sha224": authProtocol(gosnmp.SHA224),
+	"sha256": authProtocol(gosnmp.SHA256),
+	"sha384": authProtocol(gosnmp.SHA384),
+	"sha512": authProtocol(gosnmp.SHA512),
+}
+
+// Unpack validates and unpacks the auth_protocol config option.
+func (p *authProtocol) Unpack(value string) error {
+	protocol, ok := authProtocols[strings.ToLower(value)]
+	if !ok {
+		return fmt.Errorf("invalid auth_protocol '%s', must be one of md5, sha, sha224, sha256, sha384 or sha512", value)
+	}
+	*p = protocol
+	return nil
+}
+
+type privProtocol gosnmp.SnmpV3PrivProtocol
+
+var privProtocols = map[string]privProtocol{
+	"":        privProtocol(gosnmp.NoPriv),
+	"des":     privProtocol(gosnmp.DES),
+	"aes":     privProtocol(gosnmp.AES),
+	"aes192":  privProtocol(gosnmp.AES192),
+	"aes256":  privProtocol(gosnmp.AES256),
+	"aes192c": privProtocol(gosnmp.AES192C),
+	"aes256c": privProtocol(gosnmp.AES256C),
+}
+
+// Unpack validates and unpacks the priv_protocol config option.
+func (p *privProtocol) Unpack(value string) error {
+	protocol, ok := privProtocols[strings.ToLower(value)]
+	if !ok {
+		return fmt.Errorf("invalid priv_protocol '%s', must be one of des, aes, aes192, aes256, aes192c or aes256c", value)
+	}
+	*p = protocol
+	return nil
+}
+
+func defaultConfig() config {
+	return config{
+		Config: udp.Config{
+			Host:           "localhost:162",
+			MaxMessageSize: 64 * humanize.KiByte,
+			Timeout:        time.Minute * 5,
+		},
+	}
+}
+
+// Validate validates the config.
+func (c *config) Validate() error {
+	seen := map[string]bool{}
+	for _, u := range c.Users {
+		if seen[u.Username] {
+			return fmt.Errorf("user %s is configured more than once", u.Username)
+		}
+		seen[u.Username] = true
+	}
+	return nil
+}
+
+// Validate validates the credentials of a user.
+func (u *userConfig) Validate() error {
+	if !u.hasAuth() && u.hasPriv() {
+		return errors.New("auth_protocol must be set when priv_protocol is configured")
+	}
+	if u.hasAuth() && u.AuthPassword == "" {
+		return errors.New("auth_password must be set when auth_protocol is configured")
+	}
+	if u.hasPriv() && u.PrivPassword == "" {
+		return errors.New("priv_password must be set when priv_protocol is configured")
+	}
+	return nil
+}
+
+// The protocols are zero when they aren't configured.
+func (u *userConfig) hasAuth() bool {
+	return gosnmp.SnmpV3AuthProtocol(u.AuthProtocol) > gosnmp.NoAuth
+}
+
+func (u *userConfig) hasPriv() bool {
+	return gosnmp.SnmpV3PrivProtocol(u.PrivProtocol) > gosnmp.NoPriv
+}
+
+// msgFlags returns the minimum security level of the traps of the user.
+func (u *userConfig) msgFlags() gosnmp.SnmpV3MsgFlags {
+	switch {
+	case u.hasPriv():
+		return gosnmp.AuthPriv
+	case u.hasAuth():
+		return gosnmp.AuthNoPriv
+	default:
+		return gosnmp.NoAuthNoPriv
+	}
+}
+
+// securityParameters returns the USM parameters of the user. The keys are
+// localized with the engine ID of each trap.
+func (u *userConfig) securityParameters() *gosnmp.UsmSecurityParameters {
+	sp := &gosnmp.UsmSecurityParameters{
+		UserName:                 u.Username,
+		AuthenticationProtocol:   gosnmp.NoAuth,
+		AuthenticationPassphrase: u.AuthPassword,
+		PrivacyProtocol:          gosnmp.NoPriv,
+		PrivacyPassphrase:        u.PrivPassword,
+	}
+	if u.hasAuth() {
+		sp.AuthenticationProtocol = gosnmp.SnmpV3AuthProtocol(u.AuthProtocol)
+	}
+	if u.hasPriv() {
+		sp.PrivacyProtocol = gosnmp.SnmpV3PrivProtocol(u.PrivProtocol)
+	}
+	return sp
+}
@@ -0,0 +1,267 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package snmptrap
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gosnmp/gosnmp"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const (
+	sysUpTimeOID   = "1.3.6.1.2.1.1.3.0"
+	trapOIDOID     = "1.3.6.1.6.3.1.1.4.1.0"
+	genericTrapOID = "1.3.6.1.6.3.1.1.5"
+
+	// enterpriseSpecific is the generic trap number of the SNMPv1 traps
+	// identified by their enterprise and specific trap number.
+	enterpriseSpecific = 6
+)
+
+// decoder decodes and authenticates traps.
+type decoder struct {
+	communities map[string]bool
+	users       map[string]userConfig
+	usm         *gosnmp.SnmpV3SecurityParametersTable
+	mibs        *mibTree
+}
+
+func newDecoder(config config, mibs *mibTree) (*decoder, error) {
+	d := &decoder{
+		users: map[string]userConfig{},
+		usm:   gosnmp.NewSnmpV3SecurityParametersTable(gosnmp.Logger{}),
+		mibs:  mibs,
+	}
+	if len(config.Communities) > 0 {
+		d.communities = map[string]bool{}
+		for _, c := range config.Communities {
+			d.communities[c] = true
+		}
+	}
+	for _, u := range config.Users {
+		if err := d.usm.Add(u.Username, u.securityParameters()); err != nil {
+			return nil, fmt.Errorf("user %s: %w", u.Username, err)
+		}
+		d.users[u.Username] = u
+	}
+	return d, nil
+}
+
+// decode decodes a trap, checking its community or its USM credentials.
+func (d *decoder) decode(data []byte) (*gosnmp.SnmpPacket, error) {
+	x := &gosnmp.GoSNMP{
+		// Traps of any version are decoded, the version is only checked
+		// when authenticating SNMPv3 traps.
+		Version:                     gosnmp.Version3,
+		TrapSecurityParametersTable: d.usm,
+	}
+	packet, err := x.UnmarshalTrap(data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	switch packet.PDUType {
+	case gosnmp.Trap, gosnmp.SNMPv2Trap, gosnmp.InformRequest:
+	default:
+		return nil, fmt.Errorf("unexpected %s PDU", packet.PDUType)
+	}
+
+	if packet.Version == gosnmp.Version3 {
+		sp, ok := packet.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+		if !ok {
+			return nil, errors.New("unsupported security model")
+		}
+		user, ok := d.users[sp.UserName]
+		if !ok {
+			return nil, fmt.Errorf("unknown user %s", sp.UserName)
+		}
+		// The trap is authenticated and decrypted according to its own
+		// flags, so they must match the credentials of the user.
+		if packet.MsgFlags&gosnmp.AuthPriv < user.msgFlags() {
+			return nil, fmt.Errorf("security level of user %s is lower than configured", sp.UserName)
+		}
+	} else if d.communities != nil && !d.communities[packet.Community] {
+		return nil, errors.New("unknown community")
+	}
+	return packet, nil
+}
+
+// newEvent creates the event of a trap.
+func (d *decoder) newEvent(packet *gosnmp.SnmpPacket, ts time.Time) beat.Event {
+	trap := mapstr.M{}
+	switch packet.Version {
+	case gosnmp.Version1:
+		trap["version"] = "1"
+	case gosnmp.Version2c:
+		trap["version"] = "2c"
+	case gosnmp.Version3:
+		trap["version"] = "3"
+		if sp, ok := packet.SecurityParameters.(*gosnmp.UsmSecurityParameters); ok {
+			trap["user"] = sp.UserName
+		}
+		if packet.ContextName != "" {
+			trap["context_name"] = packet.ContextName
+		}
+		if packet.ContextEngineID != "" {
+			trap["context_engine_id"] = hex.EncodeToString([]byte(packet.ContextEngineID))
+		}
+	}
+	if packet.PDUType == gosnmp.InformRequest {
+		trap["type"] = "inform"
+	} else {
+		trap["type"] = "trap"
+	}
+
+	variables := packet.Variables
+	var trapOID string
+	if packet.PDUType == gosnmp.Trap {
+		enterprise := trimOID(packet.Enterprise)
+		trap["enterprise"] = enterprise
+		trap["agent_address"] = packet.AgentAddress
+		trap["generic"] = packet.GenericTrap
+		trap["specific"] = packet.SpecificTrap
+		trap["uptime"] = packet.Timestamp
+		// The SNMPv2 OID of the trap, see RFC 3584.
+		if packet.GenericTrap == enterpriseSpecific {
+			trapOID = enterprise + ".0." + strconv.Itoa(packet.SpecificTrap)
+		} else {
+			trapOID = genericTrapOID + "." + strconv.Itoa(packet.GenericTrap+1)
+		}
+	} else {
+		// The first variables are sysUpTime.0 and snmpTrapOID.0.
+		for len(variables) > 0 {
+			v := variables[0]
+			if oid := trimOID(v.Name); oid == sysUpTimeOID {
+				trap["uptime"] = v.Value
+			} else if oid == trapOIDOID {
+				if s, ok := v.Value.(string); ok {
+					trapOID = trimOID(s)
+				}
+			} else {
+				break
+			}
+			variables = variables[1:]
+		}
+	}
+
+	message := "SNMP trap"
+	if trapOID != "" {
+		trap["oid"] = trapOID
+		name := d.mibs.name(trapOID)
+		if name != trapOID {
+			trap["name"] = name
+		}
+		message = name
+	}
+
+	varbinds := make([]mapstr.M, 0, len(variables))
+	for _, v := range variables {
+		varbinds = append(varbinds, d.varbind(v))
+	}
+
+	return beat.Event{
+		Timestamp: ts,
+		Fields: mapstr.M{
+			"message": message,
+			"snmp": mapstr.M{
+				"trap":     trap,
+				"varbinds": varbinds,
+			},
+		},
+	}
+}
+
+// response returns the Response PDU acknowledging an inform, see RFC 3416
+// section 4.2.7. It holds the variables of the inform, and is secured with
+// the credentials the inform was authenticated with.
+func (d *decoder) response(inform *gosnmp.SnmpPacket) ([]byte, error) {
+	resp := *inform
+	resp.PDUType = gosnmp.GetResponse
+	resp.Error = gosnmp.NoError
+	resp.ErrorIndex = 0
+	// Responses aren't reportable, see RFC 3412 section 6.4.
+	resp.MsgFlags &= gosnmp.AuthPriv
+	return resp.MarshalMsg()
+}
+
+// varbind returns the fields of a variable binding. The value is rendered as
+// a string, using the names of enumerated values when their object is known.
+func (d *decoder) varbind(v gosnmp.SnmpPDU) mapstr.M {
+	oid := trimOID(v.Name)
+	fields := mapstr.M{
+		"oid":  oid,
+		"type": v.Type.String(),
+	}
+	node, suffix := d.mibs.lookup(oid)
+	if node != nil {
+		name := node.module + "::" + node.name
+		if suffix != "" {
+			name += "." + suffix
+		}
+		fields["name"] = name
+	}
+
+	switch value := v.Value.(type) {
+	case nil:
+	case []byte:
+		fields["value"] = formatOctets(value)
+	case string:
+		if v.Type == gosnmp.ObjectIdentifier {
+			value = trimOID(value)
+		}
+		fields["value"] = value
+	case int:
+		if node != nil && node.enums[int64(value)] != "" {
+			fields["value"] = node.enums[int64(value)]
+		} else {
+			fields["value"] = strconv.Itoa(value)
+		}
+	default:
+		fields["value"] = fmt.Sprint(value)
+	}
+	return fields
+}
+
+// formatOctets returns octet strings as text when they are printable, and
+// as colon separated hexadecimal bytes otherwise.
+func formatOctets(b []byte) string {
+	if utf8.Valid(b) && strings.IndexFunc(string(b), func(r rune) bool {
+		return !unicode.IsPrint(r) && !unicode.IsSpace(r)
+	}) < 0 {
+		return string(b)
+	}
+	s := make([]string, len(b))
+	for i, c := range b {
+		s[i] = hex.EncodeToString([]byte{c})
+	}
+	return strings.Join(s, ":")
+}
+
+func trimOID(oid string) string {
+	return strings.TrimPrefix(oid, ".")
+}
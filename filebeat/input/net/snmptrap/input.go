@@ -0,0 +1,157 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package snmptrap
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+
+	netinput "github.com/elastic/beats/v7/filebeat/input/net"
+	"github.com/elastic/beats/v7/filebeat/input/netmetrics"
+	input "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/filebeat/inputsource"
+	"github.com/elastic/beats/v7/filebeat/inputsource/udp"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	"github.com/elastic/beats/v7/libbeat/management/status"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+	"github.com/elastic/go-concert/ctxtool"
+)
+
+const inputName = "snmptrap"
+
+func Plugin() input.Plugin {
+	return input.Plugin{
+		Name:       inputName,
+		Stability:  feature.Beta,
+		Deprecated: false,
+		Info:       "SNMP trap receiver",
+		Manager:    netinput.NewManager(configure),
+	}
+}
+
+func configure(cfg *conf.C) (netinput.Input, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	mibs, err := loadMIBTree(config.MIBDirs, logp.NewLogger(inputName))
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := newDecoder(config, mibs)
+	if err != nil {
+		return nil, err
+	}
+	return &server{config: config, decoder: decoder}, nil
+}
+
+type server struct {
+	config
+	decoder *decoder
+	metrics *netmetrics.UDP
+
+	// conn sends the responses to informs. It's set by Run.
+	conn packetWriter
+}
+
+// packetWriter sends datagrams from the socket receiving the traps.
+type packetWriter interface {
+	WriteTo(b []byte, addr net.Addr) (int, error)
+}
+
+func (s *server) Name() string { return inputName }
+
+func (s *server) Test(_ input.TestContext) error {
+	l, err := net.ListenPacket("udp", s.Host)
+	if err != nil {
+		return err
+	}
+	return l.Close()
+}
+
+func (s *server) InitMetrics(id string, reg *monitoring.Registry, logger *logp.Logger) netinput.Metrics {
+	//nolint:gosec // read_buffer is a byte size, never negative
+	s.metrics = netmetrics.NewUDP(reg, s.Host, uint64(s.ReadBuffer), time.Second, logger)
+	return s.metrics
+}
+
+func (s *server) Run(ctx input.Context, evtChan chan<- netinput.DataMetadata, metrics netinput.Metrics) error {
+	logger := ctx.Logger
+	defer s.metrics.Close()
+
+	server := udp.New(&s.Config, func(data []byte, metadata inputsource.NetworkMetadata) {
+		now := time.Now()
+		metrics.EventReceived(len(data), now)
+		if metadata.Truncated {
+			logger.Warnw("Dropping truncated trap", "bytes", len(data))
+			return
+		}
+
+		select {
+		case evtChan <- netinput.DataMetadata{
+			Data:      data,
+			Metadata:  metadata,
+			Timestamp: now,
+		}:
+		case <-ctx.Cancelation.Done():
+		}
+	}, logger)
+	s.conn = server
+
+	logger.Debug("snmptrap input initialized")
+	ctx.UpdateStatus(status.Running, "")
+
+	return server.Run(ctxtool.FromCanceller(ctx.Cancelation))
+}
+
+// BuildEvent decodes a trap and creates its event. Informs are acknowledged
+// to their sender with a Response PDU. If it can't be sent, the inform is
+// dropped, since the sender retransmits it.
+func (s *server) BuildEvent(d netinput.DataMetadata) (beat.Event, error) {
+	packet, err := s.decoder.decode(d.Data)
+	if err != nil {
+		return beat.Event{}, err
+	}
+	evt := s.decoder.newEvent(packet, d.Timestamp)
+	if packet.PDUType == gosnmp.InformRequest {
+		resp, err := s.decoder.response(packet)
+		if err != nil {
+			return beat.Event{}, fmt.Errorf("encoding inform response: %w", err)
+		}
+		if _, err := s.conn.WriteTo(resp, d.Metadata.RemoteAddr); err != nil {
+			return beat.Event{}, fmt.Errorf("sending inform response: %w", err)
+		}
+	}
+	// On Windows the conn returns a nil RemoteAddr for truncated datagrams.
+	if d.Metadata.RemoteAddr != nil {
+		evt.Fields["log"] = mapstr.M{
+			"source": mapstr.M{
+				"address": d.Metadata.RemoteAddr.String(),
+			},
+		}
+	}
+	return evt, nil
+}
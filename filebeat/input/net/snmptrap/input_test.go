@@ -0,0 +1,426 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package snmptrap
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	netinput "github.com/elastic/beats/v7/filebeat/input/net"
+	"github.com/elastic/beats/v7/filebeat/inputsource"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config  map[string]any
+		wantErr string
+	}{
+		"default": {},
+		"users": {
+			config: map[string]any{"users": []map[string]any{
+				{"username": "noauth"},
+				{"username": "auth", "auth_protocol": "SHA256", "auth_password": "authpass"},
+				{"username": "priv", "auth_protocol": "sha", "auth_password": "authpass", "priv_protocol": "aes", "priv_password": "privpass"},
+			}},
+		},
+		"missing username": {
+			config:  map[string]any{"users": []map[string]any{{"auth_protocol": "md5", "auth_password": "authpass"}}},
+			wantErr: "string value is not set",
+		},
+		"duplicate user": {
+			config:  map[string]any{"users": []map[string]any{{"username": "u"}, {"username": "u"}}},
+			wantErr: "user u is configured more than once",
+		},
+		"invalid auth protocol": {
+			config:  map[string]any{"users": []map[string]any{{"username": "u", "auth_protocol": "sha1024"}}},
+			wantErr: "invalid auth_protocol 'sha1024'",
+		},
+		"invalid priv protocol": {
+			config:  map[string]any{"users": []map[string]any{{"username": "u", "priv_protocol": "rot13"}}},
+			wantErr: "invalid priv_protocol 'rot13'",
+		},
+		"priv without auth": {
+			config:  map[string]any{"users": []map[string]any{{"username": "u", "priv_protocol": "aes", "priv_password": "privpass"}}},
+			wantErr: "auth_protocol must be set when priv_protocol is configured",
+		},
+		"missing auth password": {
+			config:  map[string]any{"users": []map[string]any{{"username": "u", "auth_protocol": "md5"}}},
+			wantErr: "auth_password must be set",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := defaultConfig()
+			err := conf.MustNewConfigFrom(tc.config).Unpack(&config)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// encodeTrap returns the datagram of a trap sent by x.
+func encodeTrap(t *testing.T, x *gosnmp.GoSNMP, trap gosnmp.SnmpTrap) []byte {
+	t.Helper()
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	x.Target = "127.0.0.1"
+	x.Port = uint16(l.LocalAddr().(*net.UDPAddr).Port) //nolint:gosec // port numbers fit in uint16
+	x.Timeout = time.Second
+	require.NoError(t, x.Connect())
+	defer x.Conn.Close()
+	_, err = x.SendTrap(trap)
+	require.NoError(t, err)
+
+	buf := make([]byte, 65536)
+	require.NoError(t, l.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := l.ReadFrom(buf)
+	require.NoError(t, err)
+	return buf[:n]
+}
+
+func newTestServer(t *testing.T, config map[string]any) *server {
+	t.Helper()
+	if _, ok := config["mib_dirs"]; !ok {
+		config["mib_dirs"] = []string{writeTestMIBs(t)}
+	}
+	inp, err := configure(conf.MustNewConfigFrom(config))
+	require.NoError(t, err)
+	return inp.(*server)
+}
+
+var testV2Trap = gosnmp.SnmpTrap{
+	Variables: []gosnmp.SnmpPDU{
+		{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(4200)},
+		{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.4.1.99999.0.1"},
+		{Name: ".1.3.6.1.4.1.99999.1.1.1.2.4", Type: gosnmp.Integer, Value: 3},
+		{Name: ".1.3.6.1.4.1.99999.1.1.1.3.4", Type: gosnmp.Integer, Value: 7},
+		{Name: ".1.3.6.1.2.1.1.5.0", Type: gosnmp.OctetString, Value: "unit-4"},
+		{Name: ".1.3.6.1.4.1.12345.1", Type: gosnmp.OctetString, Value: []byte{0x00, 0x1b, 0xff}},
+		{Name: ".1.3.6.1.4.1.12345.2", Type: gosnmp.IPAddress, Value: "192.0.2.1"},
+	},
+}
+
+var testV2Varbinds = []mapstr.M{
+	{"oid": "1.3.6.1.4.1.99999.1.1.1.2.4", "name": "ACME-MIB::acmeUnitStatus.4", "type": "Integer", "value": "failed"},
+	{"oid": "1.3.6.1.4.1.99999.1.1.1.3.4", "name": "ACME-MIB::acmeUnitPower.4", "type": "Integer", "value": "7"},
+	{"oid": "1.3.6.1.2.1.1.5.0", "name": "SNMPv2-MIB::sysName.0", "type": "OctetString", "value": "unit-4"},
+	{"oid": "1.3.6.1.4.1.12345.1", "name": "SNMPv2-SMI::enterprises.12345.1", "type": "OctetString", "value": "00:1b:ff"},
+	{"oid": "1.3.6.1.4.1.12345.2", "name": "SNMPv2-SMI::enterprises.12345.2", "type": "IPAddress", "value": "192.0.2.1"},
+}
+
+func TestBuildEventV2c(t *testing.T) {
+	s := newTestServer(t, map[string]any{"communities": []string{"public"}})
+	data := encodeTrap(t, &gosnmp.GoSNMP{Version: gosnmp.Version2c, Community: "public"}, testV2Trap)
+
+	ts := time.Now()
+	evt, err := s.BuildEvent(netinput.DataMetadata{
+		Data:      data,
+		Timestamp: ts,
+		Metadata: inputsource.NetworkMetadata{
+			RemoteAddr: &net.UDPAddr{IP: net.IPv4(192, 0, 2, 10), Port: 50000},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ts, evt.Timestamp)
+	assert.Equal(t, mapstr.M{
+		"message": "ACME-MIB::acmeUnitFailed",
+		"log":     mapstr.M{"source": mapstr.M{"address": "192.0.2.10:50000"}},
+		"snmp": mapstr.M{
+			"trap": mapstr.M{
+				"version": "2c",
+				"type":    "trap",
+				"oid":     "1.3.6.1.4.1.99999.0.1",
+				"name":    "ACME-MIB::acmeUnitFailed",
+				"uptime":  uint32(4200),
+			},
+			"varbinds": testV2Varbinds,
+		},
+	}, evt.Fields)
+
+	data = encodeTrap(t, &gosnmp.GoSNMP{Version: gosnmp.Version2c, Community: "private"}, testV2Trap)
+	_, err = s.BuildEvent(netinput.DataMetadata{Data: data})
+	assert.ErrorContains(t, err, "unknown community")
+
+	_, err = s.BuildEvent(netinput.DataMetadata{Data: []byte("not a trap")})
+	assert.Error(t, err)
+}
+
+func TestBuildEventV1(t *testing.T) {
+	s := newTestServer(t, map[string]any{})
+
+	tests := map[string]struct {
+		generic, specific int
+		wantOID, wantName string
+	}{
+		"generic": {
+			generic:  2,
+			wantOID:  "1.3.6.1.6.3.1.1.5.3",
+			wantName: "IF-MIB::linkDown",
+		},
+		"enterprise specific": {
+			generic:  6,
+			specific: 2,
+			wantOID:  "1.3.6.1.4.1.99999.0.2",
+			wantName: "ACME-V1-MIB::acmeFanFailure",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := encodeTrap(t, &gosnmp.GoSNMP{Version: gosnmp.Version1, Community: "public"}, gosnmp.SnmpTrap{
+				Enterprise:   ".1.3.6.1.4.1.99999",
+				AgentAddress: "192.0.2.20",
+				GenericTrap:  tc.generic,
+				SpecificTrap: tc.specific,
+				Timestamp:    300,
+				Variables: []gosnmp.SnmpPDU{
+					{Name: ".1.3.6.1.2.1.1.5.0", Type: gosnmp.OctetString, Value: "unit-4"},
+				},
+			})
+
+			evt, err := s.BuildEvent(netinput.DataMetadata{Data: data})
+			require.NoError(t, err)
+			assert.Equal(t, mapstr.M{
+				"message": tc.wantName,
+				"snmp": mapstr.M{
+					"trap": mapstr.M{
+						"version":       "1",
+						"type":          "trap",
+						"enterprise":    "1.3.6.1.4.1.99999",
+						"agent_address": "192.0.2.20",
+						"generic":       tc.generic,
+						"specific":      tc.specific,
+						"uptime":        uint(300),
+						"oid":           tc.wantOID,
+						"name":          tc.wantName,
+					},
+					"varbinds": []mapstr.M{
+						{"oid": "1.3.6.1.2.1.1.5.0", "name": "SNMPv2-MIB::sysName.0", "type": "OctetString", "value": "unit-4"},
+					},
+				},
+			}, evt.Fields)
+		})
+	}
+}
+
+func TestBuildEventV3(t *testing.T) {
+	s := newTestServer(t, map[string]any{
+		"users": []map[string]any{
+			{"username": "priv", "auth_protocol": "sha256", "auth_password": "authpass", "priv_protocol": "aes", "priv_password": "privpass"},
+			{"username": "noauth"},
+		},
+	})
+
+	sender := func(flags gosnmp.SnmpV3MsgFlags, sp *gosnmp.UsmSecurityParameters) *gosnmp.GoSNMP {
+		sp.AuthoritativeEngineID = "\x80\x00\x1f\x88\x04acme"
+		sp.AuthoritativeEngineBoots = 1
+		sp.AuthoritativeEngineTime = 100
+		return &gosnmp.GoSNMP{
+			Version:            gosnmp.Version3,
+			SecurityModel:      gosnmp.UserSecurityModel,
+			MsgFlags:           flags,
+			SecurityParameters: sp,
+			ContextName:        "unit-4",
+		}
+	}
+
+	t.Run("auth priv", func(t *testing.T) {
+		data := encodeTrap(t, sender(gosnmp.AuthPriv, &gosnmp.UsmSecurityParameters{
+			UserName:                 "priv",
+			AuthenticationProtocol:   gosnmp.SHA256,
+			AuthenticationPassphrase: "authpass",
+			PrivacyProtocol:          gosnmp.AES,
+			PrivacyPassphrase:        "privpass",
+		}), testV2Trap)
+
+		evt, err := s.BuildEvent(netinput.DataMetadata{Data: data})
+		require.NoError(t, err)
+		trap, err := evt.Fields.GetValue("snmp.trap")
+		require.NoError(t, err)
+		assert.Equal(t, mapstr.M{
+			"version":      "3",
+			"type":         "trap",
+			"user":         "priv",
+			"context_name": "unit-4",
+			"oid":          "1.3.6.1.4.1.99999.0.1",
+			"name":         "ACME-MIB::acmeUnitFailed",
+			"uptime":       uint32(4200),
+		}, trap)
+		varbinds, err := evt.Fields.GetValue("snmp.varbinds")
+		require.NoError(t, err)
+		assert.Equal(t, testV2Varbinds, varbinds)
+	})
+
+	t.Run("no auth", func(t *testing.T) {
+		data := encodeTrap(t, sender(gosnmp.NoAuthNoPriv, &gosnmp.UsmSecurityParameters{
+			UserName: "noauth",
+		}), testV2Trap)
+		_, err := s.BuildEvent(netinput.DataMetadata{Data: data})
+		require.NoError(t, err)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		data := encodeTrap(t, sender(gosnmp.AuthPriv, &gosnmp.UsmSecurityParameters{
+			UserName:                 "priv",
+			AuthenticationProtocol:   gosnmp.SHA256,
+			AuthenticationPassphrase: "wrongpass",
+			PrivacyProtocol:          gosnmp.AES,
+			PrivacyPassphrase:        "privpass",
+		}), testV2Trap)
+		_, err := s.BuildEvent(netinput.DataMetadata{Data: data})
+		assert.Error(t, err)
+	})
+
+	t.Run("lower security level", func(t *testing.T) {
+		data := encodeTrap(t, sender(gosnmp.NoAuthNoPriv, &gosnmp.UsmSecurityParameters{
+			UserName: "priv",
+		}), testV2Trap)
+		_, err := s.BuildEvent(netinput.DataMetadata{Data: data})
+		assert.ErrorContains(t, err, "security level of user priv is lower than configured")
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		data := encodeTrap(t, sender(gosnmp.NoAuthNoPriv, &gosnmp.UsmSecurityParameters{
+			UserName: "unknown",
+		}), testV2Trap)
+		_, err := s.BuildEvent(netinput.DataMetadata{Data: data})
+		assert.Error(t, err)
+	})
+}
+
+// packetRecorder records the datagrams sent by the server.
+type packetRecorder struct {
+	data [][]byte
+	addr []net.Addr
+}
+
+func (r *packetRecorder) WriteTo(b []byte, addr net.Addr) (int, error) {
+	r.data = append(r.data, b)
+	r.addr = append(r.addr, addr)
+	return len(b), nil
+}
+
+func TestBuildEventInform(t *testing.T) {
+	s := newTestServer(t, map[string]any{
+		"communities": []string{"public"},
+		"users": []map[string]any{
+			{"username": "priv", "auth_protocol": "sha256", "auth_password": "authpass", "priv_protocol": "aes", "priv_password": "privpass"},
+		},
+	})
+	remote := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 10), Port: 50000}
+
+	t.Run("v2c", func(t *testing.T) {
+		recorder := &packetRecorder{}
+		s.conn = recorder
+		inform := &gosnmp.SnmpPacket{
+			Version:   gosnmp.Version2c,
+			Community: "public",
+			PDUType:   gosnmp.InformRequest,
+			RequestID: 42,
+			Variables: testV2Trap.Variables,
+		}
+		data, err := inform.MarshalMsg()
+		require.NoError(t, err)
+
+		evt, err := s.BuildEvent(netinput.DataMetadata{
+			Data:     data,
+			Metadata: inputsource.NetworkMetadata{RemoteAddr: remote},
+		})
+		require.NoError(t, err)
+		typ, err := evt.Fields.GetValue("snmp.trap.type")
+		require.NoError(t, err)
+		assert.Equal(t, "inform", typ)
+
+		require.Len(t, recorder.data, 1)
+		assert.Equal(t, remote, recorder.addr[0])
+		resp, err := (&gosnmp.GoSNMP{Version: gosnmp.Version2c, Community: "public"}).SnmpDecodePacket(recorder.data[0])
+		require.NoError(t, err)
+		assert.Equal(t, gosnmp.GetResponse, resp.PDUType)
+		assert.Equal(t, uint32(42), resp.RequestID)
+		assert.Equal(t, gosnmp.NoError, resp.Error)
+		assert.Len(t, resp.Variables, len(testV2Trap.Variables))
+	})
+
+	t.Run("v3", func(t *testing.T) {
+		recorder := &packetRecorder{}
+		s.conn = recorder
+		sp := &gosnmp.UsmSecurityParameters{
+			UserName:                 "priv",
+			AuthenticationProtocol:   gosnmp.SHA256,
+			AuthenticationPassphrase: "authpass",
+			PrivacyProtocol:          gosnmp.AES,
+			PrivacyPassphrase:        "privpass",
+			AuthoritativeEngineID:    "\x80\x00\x1f\x88\x04beat",
+			AuthoritativeEngineBoots: 1,
+			AuthoritativeEngineTime:  100,
+		}
+		require.NoError(t, sp.InitSecurityKeys())
+		inform := &gosnmp.SnmpPacket{
+			Version:            gosnmp.Version3,
+			SecurityModel:      gosnmp.UserSecurityModel,
+			MsgFlags:           gosnmp.AuthPriv | gosnmp.Reportable,
+			SecurityParameters: sp,
+			MsgID:              7,
+			MsgMaxSize:         65507,
+			PDUType:            gosnmp.InformRequest,
+			RequestID:          43,
+			Variables:          testV2Trap.Variables,
+		}
+		data, err := inform.MarshalMsg()
+		require.NoError(t, err)
+
+		_, err = s.BuildEvent(netinput.DataMetadata{
+			Data:     data,
+			Metadata: inputsource.NetworkMetadata{RemoteAddr: remote},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, recorder.data, 1)
+		resp, err := (&gosnmp.GoSNMP{
+			Version:            gosnmp.Version3,
+			SecurityModel:      gosnmp.UserSecurityModel,
+			MsgFlags:           gosnmp.AuthPriv,
+			SecurityParameters: sp,
+		}).SnmpDecodePacket(recorder.data[0])
+		require.NoError(t, err)
+		assert.Equal(t, gosnmp.GetResponse, resp.PDUType)
+		assert.Equal(t, uint32(43), resp.RequestID)
+		assert.Equal(t, uint32(7), resp.MsgID)
+		assert.Equal(t, gosnmp.AuthPriv, resp.MsgFlags)
+	})
+
+	t.Run("trap", func(t *testing.T) {
+		recorder := &packetRecorder{}
+		s.conn = recorder
+		data := encodeTrap(t, &gosnmp.GoSNMP{Version: gosnmp.Version2c, Community: "public"}, testV2Trap)
+		_, err := s.BuildEvent(netinput.DataMetadata{Data: data})
+		require.NoError(t, err)
+		assert.Empty(t, recorder.data)
+	})
+}
@@ -0,0 +1,620 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package snmptrap
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// mibTree resolves OIDs to the names of the objects and notifications
+// defined by MIB modules.
+type mibTree struct {
+	nodes map[string]*mibNode // by dotted OID
+}
+
+// mibNode is a named node of the OID tree.
+type mibNode struct {
+	module string
+	name   string
+	// enums are the named values of the INTEGER syntax of an object.
+	enums map[int64]string
+}
+
+// builtinNodes are the nodes of the SMI and SNMPv2-MIB modules needed to
+// decode traps, so traps can be decoded without loading any MIB.
+var builtinNodes = []struct {
+	module, name, oid string
+}{
+	{"SNMPv2-SMI", "iso", "1"},
+	{"SNMPv2-SMI", "org", "1.3"},
+	{"SNMPv2-SMI", "dod", "1.3.6"},
+	{"SNMPv2-SMI", "internet", "1.3.6.1"},
+	{"SNMPv2-SMI", "directory", "1.3.6.1.1"},
+	{"SNMPv2-SMI", "mgmt", "1.3.6.1.2"},
+	{"SNMPv2-SMI", "mib-2", "1.3.6.1.2.1"},
+	{"SNMPv2-SMI", "transmission", "1.3.6.1.2.1.10"},
+	{"SNMPv2-SMI", "experimental", "1.3.6.1.3"},
+	{"SNMPv2-SMI", "private", "1.3.6.1.4"},
+	{"SNMPv2-SMI", "enterprises", "1.3.6.1.4.1"},
+	{"SNMPv2-SMI", "security", "1.3.6.1.5"},
+	{"SNMPv2-SMI", "snmpV2", "1.3.6.1.6"},
+	{"SNMPv2-SMI", "snmpDomains", "1.3.6.1.6.1"},
+	{"SNMPv2-SMI", "snmpProxys", "1.3.6.1.6.2"},
+	{"SNMPv2-SMI", "snmpModules", "1.3.6.1.6.3"},
+	{"SNMPv2-MIB", "system", "1.3.6.1.2.1.1"},
+	{"SNMPv2-MIB", "sysDescr", "1.3.6.1.2.1.1.1"},
+	{"SNMPv2-MIB", "sysObjectID", "1.3.6.1.2.1.1.2"},
+	{"SNMPv2-MIB", "sysUpTime", "1.3.6.1.2.1.1.3"},
+	{"SNMPv2-MIB", "sysContact", "1.3.6.1.2.1.1.4"},
+	{"SNMPv2-MIB", "sysName", "1.3.6.1.2.1.1.5"},
+	{"SNMPv2-MIB", "sysLocation", "1.3.6.1.2.1.1.6"},
+	{"SNMPv2-MIB", "snmpTrapOID", "1.3.6.1.6.3.1.1.4.1"},
+	{"SNMPv2-MIB", "snmpTrapEnterprise", "1.3.6.1.6.3.1.1.4.3"},
+	{"SNMPv2-MIB", "snmpTraps", "1.3.6.1.6.3.1.1.5"},
+	{"SNMPv2-MIB", "coldStart", "1.3.6.1.6.3.1.1.5.1"},
+	{"SNMPv2-MIB", "warmStart", "1.3.6.1.6.3.1.1.5.2"},
+	{"IF-MIB", "linkDown", "1.3.6.1.6.3.1.1.5.3"},
+	{"IF-MIB", "linkUp", "1.3.6.1.6.3.1.1.5.4"},
+	{"SNMPv2-MIB", "authenticationFailure", "1.3.6.1.6.3.1.1.5.5"},
+	{"RFC1213-MIB", "egpNeighborLoss", "1.3.6.1.6.3.1.1.5.6"},
+}
+
+// builtinRoots are the root nodes of the OID tree.
+var builtinRoots = map[string]uint32{
+	"ccitt":           0,
+	"iso":             1,
+	"joint-iso-ccitt": 2,
+}
+
+func newMIBTree() *mibTree {
+	t := &mibTree{nodes: map[string]*mibNode{}}
+	for _, n := range builtinNodes {
+		t.nodes[n.oid] = &mibNode{module: n.module, name: n.name}
+	}
+	return t
+}
+
+// loadMIBTree parses the MIB modules of the files in dirs. Files that can't
+// be parsed are skipped.
+func loadMIBTree(dirs []string, log *logp.Logger) (*mibTree, error) {
+	var modules []*mibModule
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("reading MIB directory: %w", err)
+		}
+		for _, e := range entries {
+			if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("reading MIB file: %w", err)
+			}
+			parsed, err := parseMIB(string(data))
+			if err != nil {
+				log.Warnw("Skipping MIB file", "file", path, "error", err)
+				continue
+			}
+			modules = append(modules, parsed...)
+		}
+	}
+
+	t := newMIBTree()
+	t.add(modules, log)
+	return t, nil
+}
+
+// lookup returns the node of oid, or of its longest prefix, with the arcs
+// of oid after the node.
+func (t *mibTree) lookup(oid string) (*mibNode, string) {
+	prefix := oid
+	for prefix != "" {
+		if n, ok := t.nodes[prefix]; ok {
+			return n, strings.TrimPrefix(strings.TrimPrefix(oid, prefix), ".")
+		}
+		i := strings.LastIndexByte(prefix, '.')
+		if i < 0 {
+			break
+		}
+		prefix = prefix[:i]
+	}
+	return nil, oid
+}
+
+// name returns the MODULE::name.suffix name of oid, or oid itself if no
+// node is known.
+func (t *mibTree) name(oid string) string {
+	n, suffix := t.lookup(oid)
+	if n == nil {
+		return oid
+	}
+	name := n.module + "::" + n.name
+	if suffix != "" {
+		name += "." + suffix
+	}
+	return name
+}
+
+// mibModule holds the definitions of a MIB module.
+type mibModule struct {
+	name    string
+	imports map[string]string // name to module
+	defs    []*mibDef
+	byName  map[string]*mibDef
+	// types are the enums of the textual conventions and types.
+	types map[string]map[int64]string
+}
+
+// mibDef is the assignment of an OID value to a name.
+type mibDef struct {
+	name string
+	// parent is the name of the node the OID value is relative to.
+	parent string
+	arcs   []uint32
+	// syntax is the type of an object, when it isn't an enumerated INTEGER.
+	syntax string
+	enums  map[int64]string
+}
+
+// add resolves the OIDs of the definitions of modules and adds them to t.
+func (t *mibTree) add(modules []*mibModule, log *logp.Logger) {
+	byName := map[string]*mibModule{}
+	for _, m := range modules {
+		if _, ok := byName[m.name]; !ok {
+			byName[m.name] = m
+		}
+	}
+
+	r := resolver{modules: byName, oids: map[string]map[string]string{}}
+	for name := range byName {
+		r.names = append(r.names, name)
+	}
+	sort.Strings(r.names)
+	for _, m := range modules {
+		for _, def := range m.defs {
+			oid, err := r.resolve(m, def.name, 0)
+			if err != nil {
+				log.Debugw("Can't resolve MIB object", "module", m.name, "name", def.name, "error", err)
+				continue
+			}
+			enums := def.enums
+			if enums == nil && def.syntax != "" {
+				enums = r.typeEnums(m, def.syntax)
+			}
+			t.nodes[oid] = &mibNode{module: m.name, name: def.name, enums: enums}
+		}
+	}
+}
+
+// maxResolveDepth bounds the resolution of OIDs, in case the definitions
+// have a cycle.
+const maxResolveDepth = 128
+
+var errUnknownName = errors.New("unknown name")
+
+type resolver struct {
+	modules map[string]*mibModule
+	names   []string                     // sorted module names
+	oids    map[string]map[string]string // module, name to OID
+}
+
+// resolve returns the OID of name as seen from module m: defined in m,
+// imported by m, defined by any other module, or built in.
+func (r *resolver) resolve(m *mibModule, name string, depth int) (string, error) {
+	if depth > maxResolveDepth {
+		return "", fmt.Errorf("resolving %s: too deep", name)
+	}
+	if oid, ok := r.oids[m.name][name]; ok {
+		return oid, nil
+	}
+
+	def, defModule := r.find(m, name)
+	if def == nil {
+		if n, ok := builtinRoots[name]; ok {
+			return strconv.FormatUint(uint64(n), 10), nil
+		}
+		for _, n := range builtinNodes {
+			if n.name == name {
+				return n.oid, nil
+			}
+		}
+		return "", fmt.Errorf("%w %s", errUnknownName, name)
+	}
+
+	var oid string
+	if def.parent != "" {
+		parent, err := r.resolve(defModule, def.parent, depth+1)
+		if err != nil {
+			return "", err
+		}
+		oid = parent
+	}
+	for _, arc := range def.arcs {
+		if oid != "" {
+			oid += "."
+		}
+		oid += strconv.FormatUint(uint64(arc), 10)
+	}
+
+	if r.oids[defModule.name] == nil {
+		r.oids[defModule.name] = map[string]string{}
+	}
+	r.oids[defModule.name][name] = oid
+	return oid, nil
+}
+
+// find returns the definition of name as seen from module m, and the module
+// defining it.
+func (r *resolver) find(m *mibModule, name string) (*mibDef, *mibModule) {
+	if def := m.def(name); def != nil {
+		return def, m
+	}
+	if from, ok := m.imports[name]; ok {
+		if im, ok := r.modules[from]; ok {
+			if def := im.def(name); def != nil {
+				return def, im
+			}
+		}
+	}
+	// Search the other modules in a stable order.
+	for _, n := range r.names {
+		if def := r.modules[n].def(name); def != nil {
+			return def, r.modules[n]
+		}
+	}
+	return nil, nil
+}
+
+// typeEnums returns the enums of the type name as seen from module m.
+func (r *resolver) typeEnums(m *mibModule, name string) map[int64]string {
+	if enums, ok := m.types[name]; ok {
+		return enums
+	}
+	if from, ok := m.imports[name]; ok {
+		if im, ok := r.modules[from]; ok {
+			return im.types[name]
+		}
+	}
+	return nil
+}
+
+func (m *mibModule) def(name string) *mibDef {
+	return m.byName[name]
+}
+
+func (m *mibModule) addDef(def *mibDef) {
+	m.defs = append(m.defs, def)
+	if _, ok := m.byName[def.name]; !ok {
+		m.byName[def.name] = def
+	}
+}
+
+// oidMacros are the macros whose value is an OID.
+var oidMacros = map[string]bool{
+	"OBJECT-TYPE":        true,
+	"OBJECT-IDENTITY":    true,
+	"MODULE-IDENTITY":    true,
+	"NOTIFICATION-TYPE":  true,
+	"TRAP-TYPE":          true,
+	"OBJECT-GROUP":       true,
+	"NOTIFICATION-GROUP": true,
+	"MODULE-COMPLIANCE":  true,
+	"AGENT-CAPABILITIES": true,
+}
+
+// parseMIB parses the MIB modules of src. Only the definitions needed to
+// name OIDs are parsed: OID assignments, the macros assigning OIDs, and
+// the enums of the INTEGER syntaxes.
+func parseMIB(src string) ([]*mibModule, error) {
+	p := mibParser{toks: tokenizeMIB(src)}
+	var modules []*mibModule
+	for p.pos < len(p.toks) {
+		if p.peek(1) != "DEFINITIONS" {
+			p.pos++
+			continue
+		}
+		m := &mibModule{
+			name:    p.next(),
+			imports: map[string]string{},
+			byName:  map[string]*mibDef{},
+			types:   map[string]map[int64]string{},
+		}
+		for p.pos < len(p.toks) && p.next() != "BEGIN" {
+		}
+		if err := p.parseModule(m); err != nil {
+			return nil, fmt.Errorf("module %s: %w", m.name, err)
+		}
+		modules = append(modules, m)
+	}
+	if len(modules) == 0 {
+		return nil, errors.New("no MIB module found")
+	}
+	return modules, nil
+}
+
+type mibParser struct {
+	toks []string
+	pos  int
+}
+
+func (p *mibParser) peek(n int) string {
+	if p.pos+n >= len(p.toks) {
+		return ""
+	}
+	return p.toks[p.pos+n]
+}
+
+func (p *mibParser) next() string {
+	tok := p.peek(0)
+	p.pos++
+	return tok
+}
+
+func (p *mibParser) parseModule(m *mibModule) error {
+	for p.pos < len(p.toks) {
+		tok := p.peek(0)
+		switch {
+		case tok == "END":
+			p.pos++
+			return nil
+		case tok == "IMPORTS":
+			p.pos++
+			p.parseImports(m)
+		case !isIdentifier(tok):
+			p.pos++
+		case p.peek(1) == "MACRO":
+			// Macro definitions have their own END.
+			for p.pos < len(p.toks) && p.next() != "END" {
+			}
+		case p.peek(1) == "OBJECT" && p.peek(2) == "IDENTIFIER" && p.peek(3) == "::=":
+			p.pos += 4
+			def, err := p.parseOIDValue(tok)
+			if err != nil {
+				return err
+			}
+			m.addDef(def)
+		case oidMacros[p.peek(1)]:
+			p.pos += 2
+			def, err := p.parseMacro(tok, p.toks[p.pos-1])
+			if err != nil {
+				return err
+			}
+			m.addDef(def)
+		case p.peek(1) == "::=":
+			p.pos += 2
+			if p.peek(0) == "TEXTUAL-CONVENTION" {
+				for p.pos < len(p.toks) && p.peek(0) != "SYNTAX" {
+					p.pos++
+				}
+				p.pos++
+			}
+			if _, enums := p.parseSyntax(); enums != nil {
+				m.types[tok] = enums
+			}
+		default:
+			p.pos++
+		}
+	}
+	return errors.New("missing END")
+}
+
+// parseImports parses the names imported by the module, up to the ';'.
+func (p *mibParser) parseImports(m *mibModule) {
+	var names []string
+	for p.pos < len(p.toks) {
+		tok := p.next()
+		switch tok {
+		case ";":
+			return
+		case ",":
+		case "FROM":
+			from := p.next()
+			for _, n := range names {
+				m.imports[n] = from
+			}
+			names = names[:0]
+		default:
+			names = append(names, tok)
+		}
+	}
+}
+
+// parseMacro parses the body of a macro assigning an OID to name, up to its
+// value.
+func (p *mibParser) parseMacro(name, macro string) (*mibDef, error) {
+	var syntax, enterprise string
+	var enums map[int64]string
+	for p.pos < len(p.toks) && p.peek(0) != "::=" {
+		switch tok := p.next(); {
+		case tok == "SYNTAX" && macro == "OBJECT-TYPE":
+			syntax, enums = p.parseSyntax()
+		case tok == "ENTERPRISE" && macro == "TRAP-TYPE":
+			enterprise = p.next()
+		}
+	}
+	p.pos++ // ::=
+
+	if macro == "TRAP-TYPE" {
+		// The OID of SNMPv1 traps is the enterprise, 0 and the trap number,
+		// see RFC 3584.
+		n, err := strconv.ParseUint(p.next(), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trap number of %s: %w", name, err)
+		}
+		return &mibDef{name: name, parent: enterprise, arcs: []uint32{0, uint32(n)}}, nil
+	}
+
+	def, err := p.parseOIDValue(name)
+	if err != nil {
+		return nil, err
+	}
+	def.syntax = syntax
+	def.enums = enums
+	return def, nil
+}
+
+// parseOIDValue parses an OID value like { parent 1 2 } or
+// { iso org(3) dod(6) }.
+func (p *mibParser) parseOIDValue(name string) (*mibDef, error) {
+	if p.next() != "{" {
+		return nil, fmt.Errorf("invalid OID value of %s", name)
+	}
+	def := &mibDef{name: name}
+	for first := true; p.pos < len(p.toks); first = false {
+		tok := p.next()
+		if tok == "}" {
+			if def.parent == "" && len(def.arcs) == 0 {
+				return nil, fmt.Errorf("empty OID value of %s", name)
+			}
+			return def, nil
+		}
+		if n, err := strconv.ParseUint(tok, 10, 32); err == nil {
+			def.arcs = append(def.arcs, uint32(n))
+			continue
+		}
+		if p.peek(0) == "(" {
+			// name(number)
+			n, err := strconv.ParseUint(p.peek(1), 10, 32)
+			if err != nil || p.peek(2) != ")" {
+				return nil, fmt.Errorf("invalid OID value of %s", name)
+			}
+			p.pos += 3
+			def.arcs = append(def.arcs, uint32(n))
+			continue
+		}
+		if !first {
+			return nil, fmt.Errorf("invalid OID value of %s", name)
+		}
+		def.parent = tok
+	}
+	return nil, fmt.Errorf("unterminated OID value of %s", name)
+}
+
+// parseSyntax parses a type, returning its name, and its enums if it is an
+// enumerated INTEGER.
+func (p *mibParser) parseSyntax() (string, map[int64]string) {
+	typ := p.next()
+	if typ == "OCTET" || typ == "OBJECT" {
+		typ += " " + p.next()
+	}
+	if p.peek(0) != "{" {
+		return typ, nil
+	}
+
+	// Named numbers, or the members of a SEQUENCE.
+	p.pos++
+	enums := map[int64]string{}
+	for depth := 1; p.pos < len(p.toks) && depth > 0; {
+		tok := p.next()
+		switch {
+		case tok == "{":
+			depth++
+		case tok == "}":
+			depth--
+		case isIdentifier(tok) && p.peek(0) == "(" && p.peek(2) == ")":
+			if n, err := strconv.ParseInt(p.peek(1), 10, 64); err == nil {
+				enums[n] = tok
+			}
+			p.pos += 3
+		}
+	}
+	if typ != "INTEGER" && typ != "Integer32" || len(enums) == 0 {
+		return typ, nil
+	}
+	return typ, enums
+}
+
+func isIdentifier(tok string) bool {
+	if tok == "" {
+		return false
+	}
+	r := rune(tok[0])
+	return unicode.IsLetter(r)
+}
+
+// tokenizeMIB splits src into tokens, dropping comments and the contents of
+// strings.
+func tokenizeMIB(src string) []string {
+	var toks []string
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f':
+			i++
+		case strings.HasPrefix(src[i:], "--"):
+			// Comments end at the end of the line or at the next "--".
+			i += 2
+			for i < len(src) && src[i] != '\n' {
+				if strings.HasPrefix(src[i:], "--") {
+					i += 2
+					break
+				}
+				i++
+			}
+		case c == '"':
+			end := strings.IndexByte(src[i+1:], '"')
+			if end < 0 {
+				return toks
+			}
+			toks = append(toks, `""`)
+			i += end + 2
+		case c == '\'':
+			// Binary and hexadecimal strings: 'FF'H.
+			end := strings.IndexByte(src[i+1:], '\'')
+			if end < 0 {
+				return toks
+			}
+			toks = append(toks, src[i:i+end+2])
+			i += end + 2
+			if i < len(src) && (src[i] == 'H' || src[i] == 'h' || src[i] == 'B' || src[i] == 'b') {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "::="):
+			toks = append(toks, "::=")
+			i += 3
+		case strings.HasPrefix(src[i:], ".."):
+			toks = append(toks, "..")
+			i += 2
+		case isWordByte(c):
+			start := i
+			for i < len(src) && isWordByte(src[i]) && !strings.HasPrefix(src[i:], "--") {
+				i++
+			}
+			toks = append(toks, src[start:i])
+		default:
+			toks = append(toks, string(c))
+			i++
+		}
+	}
+	return toks
+}
+
+func isWordByte(c byte) bool {
+	return c == '-' || c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
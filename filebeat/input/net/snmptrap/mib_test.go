@@ -0,0 +1,176 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package snmptrap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+)
+
+const testTCMIB = `
+ACME-TC DEFINITIONS ::= BEGIN
+
+IMPORTS
+    TEXTUAL-CONVENTION FROM SNMPv2-TC;
+
+AcmeStatus ::= TEXTUAL-CONVENTION
+    STATUS      current
+    DESCRIPTION "The status of a unit."
+    SYNTAX      INTEGER { ok(1), degraded(2), failed(3) }
+
+END
+`
+
+const testMIB = `
+-- The MIB of the ACME appliances.
+ACME-MIB DEFINITIONS ::= BEGIN
+
+IMPORTS
+    MODULE-IDENTITY, OBJECT-TYPE, NOTIFICATION-TYPE, Integer32, enterprises
+        FROM SNMPv2-SMI
+    AcmeStatus
+        FROM ACME-TC;
+
+acme MODULE-IDENTITY
+    LAST-UPDATED "202605040000Z"
+    ORGANIZATION "ACME"
+    CONTACT-INFO "support@acme.example"
+    DESCRIPTION  "The ACME MIB, with { braces } in a string."
+    ::= { enterprises 99999 }
+
+acmeObjects OBJECT IDENTIFIER ::= { acme 1 }
+acmeNotifications OBJECT IDENTIFIER ::= { acme 0 }
+
+acmeUnitTable OBJECT-TYPE
+    SYNTAX      SEQUENCE OF AcmeUnitEntry
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "The units."
+    ::= { acmeObjects 1 }
+
+acmeUnitEntry OBJECT-TYPE
+    SYNTAX      AcmeUnitEntry
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "A unit."
+    INDEX       { acmeUnitIndex }
+    ::= { acmeUnitTable 1 }
+
+AcmeUnitEntry ::= SEQUENCE {
+    acmeUnitIndex  Integer32,
+    acmeUnitStatus AcmeStatus,
+    acmeUnitPower  INTEGER
+}
+
+acmeUnitIndex OBJECT-TYPE
+    SYNTAX      Integer32 (1..64)
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "The index of the unit."
+    ::= { acmeUnitEntry 1 }
+
+acmeUnitStatus OBJECT-TYPE
+    SYNTAX      AcmeStatus
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "The status of the unit."
+    ::= { acmeUnitEntry 2 }
+
+acmeUnitPower OBJECT-TYPE
+    SYNTAX      INTEGER { off(0), on(1) }
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "The power of the unit."
+    ::= { acmeUnitEntry 3 }
+
+acmeUnitFailed NOTIFICATION-TYPE
+    OBJECTS     { acmeUnitStatus }
+    STATUS      current
+    DESCRIPTION "A unit failed."
+    ::= { acmeNotifications 1 }
+
+END
+`
+
+const testV1MIB = `
+ACME-V1-MIB DEFINITIONS ::= BEGIN
+
+IMPORTS
+    TRAP-TYPE FROM RFC-1215
+    acme FROM ACME-MIB;
+
+acmeFanFailure TRAP-TYPE
+    ENTERPRISE  acme
+    DESCRIPTION "A fan failed."
+    ::= 2
+
+END
+`
+
+func writeTestMIBs(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"ACME-TC.txt":     testTCMIB,
+		"ACME-MIB.txt":    testMIB,
+		"ACME-V1-MIB.mib": testV1MIB,
+		"broken.txt":      "BROKEN DEFINITIONS ::= BEGIN foo ::= {",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(src), 0o600))
+	}
+	return dir
+}
+
+func TestLoadMIBTree(t *testing.T) {
+	tree, err := loadMIBTree([]string{writeTestMIBs(t)}, logptest.NewTestingLogger(t, ""))
+	require.NoError(t, err)
+
+	tests := map[string]string{
+		"1.3.6.1.4.1.99999":           "ACME-MIB::acme",
+		"1.3.6.1.4.1.99999.0.1":       "ACME-MIB::acmeUnitFailed",
+		"1.3.6.1.4.1.99999.1.1.1.2.4": "ACME-MIB::acmeUnitStatus.4",
+		"1.3.6.1.4.1.99999.0.2":       "ACME-V1-MIB::acmeFanFailure",
+		"1.3.6.1.4.1.12345.1":         "SNMPv2-SMI::enterprises.12345.1",
+		"1.3.6.1.6.3.1.1.5.3":         "IF-MIB::linkDown",
+		"1.3.6.1.2.1.1.3.0":           "SNMPv2-MIB::sysUpTime.0",
+		"2.999":                       "2.999",
+	}
+	for oid, want := range tests {
+		assert.Equal(t, want, tree.name(oid), oid)
+	}
+
+	status, _ := tree.lookup("1.3.6.1.4.1.99999.1.1.1.2.4")
+	require.NotNil(t, status)
+	assert.Equal(t, map[int64]string{1: "ok", 2: "degraded", 3: "failed"}, status.enums)
+
+	power, suffix := tree.lookup("1.3.6.1.4.1.99999.1.1.1.3.7")
+	require.NotNil(t, power)
+	assert.Equal(t, "7", suffix)
+	assert.Equal(t, map[int64]string{0: "off", 1: "on"}, power.enums)
+}
+
+func TestLoadMIBTreeMissingDir(t *testing.T) {
+	_, err := loadMIBTree([]string{filepath.Join(t.TempDir(), "missing")}, logptest.NewTestingLogger(t, ""))
+	assert.ErrorContains(t, err, "reading MIB directory")
+}
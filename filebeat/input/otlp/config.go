@@ -0,0 +1,67 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"errors"
+	"time"
+
+	"github.com/elastic/beats/v7/filebeat/inputsource/tcp"
+)
+
+type config struct {
+	HTTP serverConfig `config:"http"`
+	GRPC serverConfig `config:"grpc"`
+}
+
+// serverConfig configures the listener of an OTLP transport.
+type serverConfig struct {
+	Enabled    bool `config:"enabled"`
+	tcp.Config `config:",inline"`
+}
+
+const defaultMaxMessageSize = 20 * 1024 * 1024 // 20MiB
+
+func defaultConfig() config {
+	return config{
+		HTTP: serverConfig{
+			Enabled: true,
+			Config: tcp.Config{
+				Host:           "localhost:4318",
+				Timeout:        time.Minute,
+				MaxMessageSize: defaultMaxMessageSize,
+			},
+		},
+		GRPC: serverConfig{
+			Enabled: true,
+			Config: tcp.Config{
+				Host:           "localhost:4317",
+				Timeout:        time.Minute,
+				MaxMessageSize: defaultMaxMessageSize,
+			},
+		},
+	}
+}
+
+// Validate validates the config.
+func (c *config) Validate() error {
+	if !c.HTTP.Enabled && !c.GRPC.Enabled {
+		return errors.New("at least one of http and grpc must be enabled")
+	}
+	return nil
+}
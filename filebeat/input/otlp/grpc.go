@@ -0,0 +1,63 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/encoding/gzip" // Register the gzip compressor used by exporters.
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// grpcHandler serves OTLP/gRPC log export requests.
+type grpcHandler struct {
+	plogotlp.UnimplementedGRPCServer
+
+	publisher *publisher
+	log       *logp.Logger
+}
+
+func newGRPCServer(config serverConfig, publisher *publisher, log *logp.Logger) *grpc.Server {
+	// TLS is handled by the listener, see tcp.Listen.
+	server := grpc.NewServer(
+		grpc.MaxRecvMsgSize(int(config.MaxMessageSize)),
+		grpc.ConnectionTimeout(config.Timeout),
+		grpc.KeepaliveParams(keepalive.ServerParameters{MaxConnectionIdle: config.Timeout}),
+	)
+	plogotlp.RegisterGRPCServer(server, &grpcHandler{publisher: publisher, log: log})
+	return server
+}
+
+func (h *grpcHandler) Export(_ context.Context, req plogotlp.ExportRequest) (plogotlp.ExportResponse, error) {
+	logs := req.Logs()
+	resp, rejected := h.publisher.publish(logs)
+	if rejected > 0 && rejected == int64(logs.LogRecordCount()) {
+		h.log.Debugw("Rejected export request, the pipeline queue is full", "records", rejected)
+		return plogotlp.NewExportResponse(), status.Error(codes.Unavailable, errPipelineFull)
+	}
+	if rejected > 0 {
+		h.log.Debugw("Rejected records of export request, the pipeline queue is full", "records", rejected)
+	}
+	return resp, nil
+}
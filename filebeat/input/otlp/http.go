@@ -0,0 +1,186 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const (
+	logsPath = "/v1/logs"
+
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeJSON     = "application/json"
+
+	// retryAfter is the number of seconds exporters are asked to wait
+	// before retrying requests rejected because the pipeline is full.
+	retryAfter = "1"
+)
+
+// httpHandler serves OTLP/HTTP log export requests.
+type httpHandler struct {
+	publisher      *publisher
+	maxMessageSize int64
+	log            *logp.Logger
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != logsPath {
+		http.NotFound(w, r)
+		return
+	}
+
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (contentType != contentTypeProtobuf && contentType != contentTypeJSON) {
+		// The error is encoded as protobuf, the default OTLP encoding.
+		writeStatus(w, contentTypeProtobuf, http.StatusUnsupportedMediaType,
+			fmt.Sprintf("unsupported content type %q", r.Header.Get("Content-Type")))
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeStatus(w, contentType, http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method %s", r.Method))
+		return
+	}
+
+	req, err := h.readRequest(w, r, contentType)
+	if err != nil {
+		code := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		writeStatus(w, contentType, code, err.Error())
+		return
+	}
+
+	logs := req.Logs()
+	resp, rejected := h.publisher.publish(logs)
+	if rejected > 0 && rejected == int64(logs.LogRecordCount()) {
+		h.log.Debugw("Rejected export request, the pipeline queue is full", "records", rejected)
+		w.Header().Set("Retry-After", retryAfter)
+		writeStatus(w, contentType, http.StatusServiceUnavailable, errPipelineFull)
+		return
+	}
+	if rejected > 0 {
+		h.log.Debugw("Rejected records of export request, the pipeline queue is full", "records", rejected)
+	}
+
+	var body []byte
+	if contentType == contentTypeJSON {
+		body, err = resp.MarshalJSON()
+	} else {
+		body, err = resp.MarshalProto()
+	}
+	if err != nil {
+		writeStatus(w, contentType, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// readRequest reads and decodes the export request of r.
+func (h *httpHandler) readRequest(w http.ResponseWriter, r *http.Request, contentType string) (plogotlp.ExportRequest, error) {
+	req := plogotlp.NewExportRequest()
+
+	var body io.Reader = http.MaxBytesReader(w, r.Body, h.maxMessageSize)
+	switch r.Header.Get("Content-Encoding") {
+	case "", "identity":
+	case "gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return req, fmt.Errorf("reading gzip body: %w", err)
+		}
+		defer zr.Close()
+		// The decompressed request is limited as well.
+		body = io.LimitReader(zr, h.maxMessageSize+1)
+	default:
+		return req, fmt.Errorf("unsupported content encoding %q", r.Header.Get("Content-Encoding"))
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return req, fmt.Errorf("reading body: %w", err)
+	}
+	if int64(len(data)) > h.maxMessageSize {
+		return req, &http.MaxBytesError{Limit: h.maxMessageSize}
+	}
+
+	if contentType == contentTypeJSON {
+		err = req.UnmarshalJSON(data)
+	} else {
+		err = req.UnmarshalProto(data)
+	}
+	if err != nil {
+		return req, fmt.Errorf("decoding export request: %w", err)
+	}
+	return req, nil
+}
+
+// writeStatus writes an error response, with a google.rpc.Status body as
+// required by the OTLP/HTTP specification.
+func writeStatus(w http.ResponseWriter, contentType string, code int, msg string) {
+	st := status.New(httpToGRPCCode(code), msg).Proto()
+
+	var body []byte
+	var err error
+	if contentType == contentTypeJSON {
+		body, err = protojson.Marshal(st)
+	} else {
+		body, err = proto.Marshal(st)
+	}
+	if err != nil {
+		http.Error(w, msg, code)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	_, _ = w.Write(body)
+}
+
+// httpToGRPCCode returns the status code of the HTTP status code of an
+// error response.
+func httpToGRPCCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusMethodNotAllowed:
+		return codes.Unimplemented
+	case http.StatusRequestEntityTooLarge:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,200 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"golang.org/x/sync/errgroup"
+
+	input "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/filebeat/inputsource/tcp"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	"github.com/elastic/beats/v7/libbeat/management/status"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+const pluginName = "otlp"
+
+// Plugin creates a new otlp input plugin, receiving logs exported over
+// OTLP/HTTP and OTLP/gRPC.
+func Plugin(log *logp.Logger) input.Plugin {
+	return input.Plugin{
+		Name:       pluginName,
+		Stability:  feature.Beta,
+		Deprecated: false,
+		Info:       "OTLP logs receiver",
+		Doc:        "The OTLP input receives logs exported with the OpenTelemetry protocol over HTTP and gRPC",
+		Manager:    input.ConfigureWith(configure, log),
+	}
+}
+
+func configure(cfg *conf.C, logger *logp.Logger) (input.Input, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	in := &otlpInput{config: config}
+	var err error
+	if in.httpTLS, err = tlscommon.LoadTLSServerConfig(config.HTTP.TLS, logger); err != nil {
+		return nil, fmt.Errorf("loading http ssl configuration: %w", err)
+	}
+	if in.grpcTLS, err = tlscommon.LoadTLSServerConfig(config.GRPC.TLS, logger); err != nil {
+		return nil, fmt.Errorf("loading grpc ssl configuration: %w", err)
+	}
+	return in, nil
+}
+
+type otlpInput struct {
+	config  config
+	httpTLS *tlscommon.TLSConfig
+	grpcTLS *tlscommon.TLSConfig
+}
+
+func (in *otlpInput) Name() string { return pluginName }
+
+// Test checks the enabled servers can listen on their host.
+func (in *otlpInput) Test(ctx input.TestContext) error {
+	listeners, err := in.listen(ctx.Logger)
+	for _, l := range listeners {
+		l.Close()
+	}
+	return err
+}
+
+func (in *otlpInput) Run(ctx input.Context, pipeline beat.Pipeline) error {
+	log := ctx.Logger.Named("otlp input")
+
+	// Events are dropped rather than waiting for the queue, so the senders
+	// are asked to retry while the pipeline is blocked.
+	client, err := pipeline.ConnectWith(beat.ClientConfig{
+		PublishMode:    beat.DropIfFull,
+		ClientListener: dropListener{},
+	})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	pub := &publisher{client: client}
+
+	ctx.UpdateStatus(status.Starting, "")
+	listeners, err := in.listen(log)
+	if err != nil {
+		for _, l := range listeners {
+			l.Close()
+		}
+		ctx.UpdateStatus(status.Failed, "Failed to listen: "+err.Error())
+		return err
+	}
+
+	goContext := input.GoContextFromCanceler(ctx.Cancelation)
+	g, gctx := errgroup.WithContext(goContext)
+	if l := listeners[serverHTTP]; l != nil {
+		server := &http.Server{
+			Handler: &httpHandler{
+				publisher:      pub,
+				maxMessageSize: int64(in.config.HTTP.MaxMessageSize),
+				log:            log,
+			},
+			ReadHeaderTimeout: in.config.HTTP.Timeout,
+			ReadTimeout:       in.config.HTTP.Timeout,
+			IdleTimeout:       in.config.HTTP.Timeout,
+		}
+		g.Go(func() error {
+			err := server.Serve(l)
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			return fmt.Errorf("http server: %w", err)
+		})
+		g.Go(func() error {
+			<-gctx.Done()
+			return server.Close()
+		})
+	}
+	if l := listeners[serverGRPC]; l != nil {
+		server := newGRPCServer(in.config.GRPC, pub, log)
+		g.Go(func() error {
+			if err := server.Serve(l); err != nil {
+				return fmt.Errorf("grpc server: %w", err)
+			}
+			return nil
+		})
+		g.Go(func() error {
+			<-gctx.Done()
+			server.GracefulStop()
+			return nil
+		})
+	}
+
+	log.Info("Starting OTLP input")
+	defer log.Info("OTLP input stopped")
+	ctx.UpdateStatus(status.Running, "")
+
+	err = g.Wait()
+	if err != nil && goContext.Err() == nil {
+		ctx.UpdateStatus(status.Failed, err.Error())
+		return err
+	}
+	ctx.UpdateStatus(status.Stopped, "")
+	return nil
+}
+
+const (
+	serverHTTP = "http"
+	serverGRPC = "grpc"
+)
+
+// listen listens on the hosts of the enabled servers. On error, the
+// returned listeners must still be closed.
+func (in *otlpInput) listen(log *logp.Logger) (map[string]net.Listener, error) {
+	listeners := map[string]net.Listener{}
+	if in.config.HTTP.Enabled {
+		var t *tls.Config
+		if in.httpTLS != nil {
+			t = in.httpTLS.BuildServerConfig(in.config.HTTP.Host)
+		}
+		l, err := tcp.Listen(&in.config.HTTP.Config, t, log)
+		if err != nil {
+			return listeners, fmt.Errorf("listening for http on %s: %w", in.config.HTTP.Host, err)
+		}
+		listeners[serverHTTP] = l
+	}
+	if in.config.GRPC.Enabled {
+		var t *tls.Config
+		if in.grpcTLS != nil {
+			t = in.grpcTLS.BuildServerConfig(in.config.GRPC.Host)
+			// gRPC clients require HTTP/2 to be negotiated.
+			t.NextProtos = []string{"h2"}
+		}
+		l, err := tcp.Listen(&in.config.GRPC.Config, t, log)
+		if err != nil {
+			return listeners, fmt.Errorf("listening for grpc on %s: %w", in.config.GRPC.Host, err)
+		}
+		listeners[serverGRPC] = l
+	}
+	return listeners, nil
+}
@@ -0,0 +1,276 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	pubtest "github.com/elastic/beats/v7/libbeat/publisher/testing"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config  map[string]any
+		wantErr string
+	}{
+		"default": {},
+		"grpc only": {
+			config: map[string]any{"http.enabled": false},
+		},
+		"no server": {
+			config:  map[string]any{"http.enabled": false, "grpc.enabled": false},
+			wantErr: "at least one of http and grpc must be enabled",
+		},
+		"missing host": {
+			config:  map[string]any{"http.host": ""},
+			wantErr: "need to specify the host",
+		},
+		"invalid network": {
+			config:  map[string]any{"grpc.network": "udp"},
+			wantErr: "invalid network value",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := defaultConfig()
+			err := conf.MustNewConfigFrom(tc.config).Unpack(&config)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// newTestPublisher returns a publisher whose client queues capacity events,
+// and drops the next ones like a full pipeline.
+func newTestPublisher(capacity int) (*publisher, *[]beat.Event) {
+	var events []beat.Event
+	client := &pubtest.FakeClient{
+		PublishFunc: func(event beat.Event) {
+			if len(events) >= capacity {
+				dropListener{}.DroppedOnPublish(event)
+				return
+			}
+			events = append(events, event)
+		},
+	}
+	return &publisher{client: client}, &events
+}
+
+func newTestRequest(records int) plogotlp.ExportRequest {
+	logs := plog.NewLogs()
+	sl := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty()
+	for range records {
+		sl.LogRecords().AppendEmpty().Body().SetStr("hello")
+	}
+	return plogotlp.NewExportRequestFromLogs(logs)
+}
+
+func TestHTTPHandler(t *testing.T) {
+	protoBody := func(t *testing.T, records int) []byte {
+		body, err := newTestRequest(records).MarshalProto()
+		require.NoError(t, err)
+		return body
+	}
+
+	tests := map[string]struct {
+		method          string
+		path            string
+		contentType     string
+		contentEncoding string
+		body            func(t *testing.T) []byte
+		capacity        int
+		wantCode        int
+		wantEvents      int
+		wantRejected    int64
+	}{
+		"protobuf": {
+			body:       func(t *testing.T) []byte { return protoBody(t, 3) },
+			capacity:   100,
+			wantCode:   http.StatusOK,
+			wantEvents: 3,
+		},
+		"json": {
+			contentType: contentTypeJSON,
+			body: func(t *testing.T) []byte {
+				body, err := newTestRequest(2).MarshalJSON()
+				require.NoError(t, err)
+				return body
+			},
+			capacity:   100,
+			wantCode:   http.StatusOK,
+			wantEvents: 2,
+		},
+		"gzip": {
+			contentEncoding: "gzip",
+			body: func(t *testing.T) []byte {
+				var buf bytes.Buffer
+				zw := gzip.NewWriter(&buf)
+				_, err := zw.Write(protoBody(t, 2))
+				require.NoError(t, err)
+				require.NoError(t, zw.Close())
+				return buf.Bytes()
+			},
+			capacity:   100,
+			wantCode:   http.StatusOK,
+			wantEvents: 2,
+		},
+		"partial success": {
+			body:         func(t *testing.T) []byte { return protoBody(t, 3) },
+			capacity:     1,
+			wantCode:     http.StatusOK,
+			wantEvents:   1,
+			wantRejected: 2,
+		},
+		"pipeline full": {
+			body:     func(t *testing.T) []byte { return protoBody(t, 3) },
+			capacity: 0,
+			wantCode: http.StatusServiceUnavailable,
+		},
+		"invalid body": {
+			body:     func(*testing.T) []byte { return []byte("not protobuf") },
+			capacity: 100,
+			wantCode: http.StatusBadRequest,
+		},
+		"too large": {
+			body:     func(*testing.T) []byte { return make([]byte, 2048) },
+			capacity: 100,
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+		"unsupported content type": {
+			contentType: "text/plain",
+			body:        func(t *testing.T) []byte { return protoBody(t, 1) },
+			capacity:    100,
+			wantCode:    http.StatusUnsupportedMediaType,
+		},
+		"unsupported method": {
+			method:   http.MethodGet,
+			body:     func(*testing.T) []byte { return nil },
+			capacity: 100,
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		"unknown path": {
+			path:     "/v1/traces",
+			body:     func(t *testing.T) []byte { return protoBody(t, 1) },
+			capacity: 100,
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pub, events := newTestPublisher(tc.capacity)
+			handler := &httpHandler{publisher: pub, maxMessageSize: 1024, log: logptest.NewTestingLogger(t, "")}
+
+			method, path, contentType := tc.method, tc.path, tc.contentType
+			if method == "" {
+				method = http.MethodPost
+			}
+			if path == "" {
+				path = logsPath
+			}
+			if contentType == "" {
+				contentType = contentTypeProtobuf
+			}
+			req := httptest.NewRequest(method, path, bytes.NewReader(tc.body(t)))
+			req.Header.Set("Content-Type", contentType)
+			if tc.contentEncoding != "" {
+				req.Header.Set("Content-Encoding", tc.contentEncoding)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.wantCode, rec.Code, rec.Body.String())
+			assert.Len(t, *events, tc.wantEvents)
+			if tc.wantCode == http.StatusServiceUnavailable {
+				assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+			}
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			resp := plogotlp.NewExportResponse()
+			if contentType == contentTypeJSON {
+				require.NoError(t, resp.UnmarshalJSON(rec.Body.Bytes()))
+			} else {
+				require.NoError(t, resp.UnmarshalProto(rec.Body.Bytes()))
+			}
+			assert.Equal(t, tc.wantRejected, resp.PartialSuccess().RejectedLogRecords())
+		})
+	}
+}
+
+func TestGRPCServer(t *testing.T) {
+	export := func(t *testing.T, capacity, records int) (plogotlp.ExportResponse, []beat.Event, error) {
+		pub, events := newTestPublisher(capacity)
+		config := defaultConfig().GRPC
+		server := newGRPCServer(config, pub, logptest.NewTestingLogger(t, ""))
+
+		l, err := net.Listen("tcp", "localhost:0")
+		require.NoError(t, err)
+		go func() { _ = server.Serve(l) }()
+		t.Cleanup(server.Stop)
+
+		conn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		resp, err := plogotlp.NewGRPCClient(conn).Export(context.Background(), newTestRequest(records))
+		return resp, *events, err
+	}
+
+	t.Run("success", func(t *testing.T) {
+		resp, events, err := export(t, 100, 3)
+		require.NoError(t, err)
+		assert.Len(t, events, 3)
+		assert.Zero(t, resp.PartialSuccess().RejectedLogRecords())
+	})
+
+	t.Run("partial success", func(t *testing.T) {
+		resp, events, err := export(t, 2, 3)
+		require.NoError(t, err)
+		assert.Len(t, events, 2)
+		assert.Equal(t, int64(1), resp.PartialSuccess().RejectedLogRecords())
+		assert.Equal(t, errPipelineFull, resp.PartialSuccess().ErrorMessage())
+	})
+
+	t.Run("pipeline full", func(t *testing.T) {
+		_, events, err := export(t, 0, 3)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Empty(t, events)
+	})
+}
@@ -0,0 +1,233 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/otel/otelmap"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// resourceFields maps the semantic convention resource attributes to their
+// ECS fields. The other resource attributes are kept under
+// otlp.resource.attributes.
+var resourceFields = map[string]string{
+	"service.name":                "service.name",
+	"service.version":             "service.version",
+	"service.instance.id":         "service.node.name",
+	"deployment.environment":      "service.environment",
+	"deployment.environment.name": "service.environment",
+	"host.name":                   "host.name",
+	"host.id":                     "host.id",
+	"host.arch":                   "host.architecture",
+	"host.ip":                     "host.ip",
+	"host.mac":                    "host.mac",
+	"os.type":                     "host.os.type",
+	"os.name":                     "host.os.name",
+	"os.version":                  "host.os.version",
+	"os.description":              "host.os.full",
+	"process.pid":                 "process.pid",
+	"process.executable.name":     "process.name",
+	"process.executable.path":     "process.executable",
+	"process.command_line":        "process.command_line",
+	"container.id":                "container.id",
+	"container.name":              "container.name",
+	"container.runtime":           "container.runtime",
+	"container.image.name":        "container.image.name",
+	"cloud.provider":              "cloud.provider",
+	"cloud.platform":              "cloud.service.name",
+	"cloud.region":                "cloud.region",
+	"cloud.availability_zone":     "cloud.availability_zone",
+	"cloud.account.id":            "cloud.account.id",
+	"k8s.namespace.name":          "kubernetes.namespace",
+	"k8s.node.name":               "kubernetes.node.name",
+	"k8s.pod.name":                "kubernetes.pod.name",
+	"k8s.pod.uid":                 "kubernetes.pod.uid",
+	"k8s.container.name":          "kubernetes.container.name",
+	"k8s.deployment.name":         "kubernetes.deployment.name",
+}
+
+// recordFields maps the semantic convention log record attributes to their
+// ECS fields. The other attributes are kept under otlp.attributes.
+var recordFields = map[string]string{
+	"exception.type":       "error.type",
+	"exception.message":    "error.message",
+	"exception.stacktrace": "error.stack_trace",
+	"log.file.path":        "log.file.path",
+	"code.function":        "log.origin.function",
+	"code.function.name":   "log.origin.function",
+	"code.filepath":        "log.origin.file.name",
+	"code.file.path":       "log.origin.file.name",
+	"code.lineno":          "log.origin.file.line",
+	"code.line.number":     "log.origin.file.line",
+}
+
+// bodymapMode is the mapping mode of the scopes whose record bodies hold
+// whole Beats documents, as sent by the otlp output.
+const bodymapMode = "bodymap"
+
+// logsToEvents converts the records of logs into events, in order.
+func logsToEvents(logs plog.Logs) []beat.Event {
+	events := make([]beat.Event, 0, logs.LogRecordCount())
+	now := time.Now()
+
+	resourceLogs := logs.ResourceLogs()
+	for i := 0; i < resourceLogs.Len(); i++ {
+		rl := resourceLogs.At(i)
+		resource := mapAttributes(rl.Resource().Attributes(), resourceFields, "otlp.resource.attributes")
+
+		scopeLogs := rl.ScopeLogs()
+		for j := 0; j < scopeLogs.Len(); j++ {
+			sl := scopeLogs.At(j)
+			scope := sl.Scope()
+			records := sl.LogRecords()
+
+			if mode, ok := scope.Attributes().Get("elastic.mapping.mode"); ok && mode.AsString() == bodymapMode {
+				for k := 0; k < records.Len(); k++ {
+					events = append(events, documentToEvent(records.At(k), now))
+				}
+				continue
+			}
+
+			base := resource.Clone()
+			if scope.Name() != "" {
+				_, _ = base.Put("otlp.scope.name", scope.Name())
+			}
+			if scope.Version() != "" {
+				_, _ = base.Put("otlp.scope.version", scope.Version())
+			}
+			if scope.Attributes().Len() > 0 {
+				_, _ = base.Put("otlp.scope.attributes", otelmap.ToMapstr(scope.Attributes()))
+			}
+			for k := 0; k < records.Len(); k++ {
+				events = append(events, recordToEvent(records.At(k), base, now))
+			}
+		}
+	}
+	return events
+}
+
+// recordToEvent creates the event of a log record, adding the fields of its
+// resource and scope in base.
+func recordToEvent(record plog.LogRecord, base mapstr.M, now time.Time) beat.Event {
+	fields := base.Clone()
+	fields.DeepUpdate(mapAttributes(record.Attributes(), recordFields, "otlp.attributes"))
+
+	body := record.Body()
+	switch body.Type() {
+	case pcommon.ValueTypeEmpty:
+	case pcommon.ValueTypeStr:
+		fields["message"] = body.Str()
+	case pcommon.ValueTypeMap:
+		_, _ = fields.Put("otlp.body", otelmap.ToMapstr(body.Map()))
+	default:
+		fields["message"] = body.AsString()
+	}
+
+	if text := record.SeverityText(); text != "" {
+		_, _ = fields.Put("log.level", text)
+	}
+	if number := record.SeverityNumber(); number != plog.SeverityNumberUnspecified {
+		_, _ = fields.Put("event.severity", int64(number))
+	}
+	if traceID := record.TraceID(); !traceID.IsEmpty() {
+		_, _ = fields.Put("trace.id", traceID.String())
+	}
+	if spanID := record.SpanID(); !spanID.IsEmpty() {
+		_, _ = fields.Put("span.id", spanID.String())
+	}
+
+	return beat.Event{
+		Timestamp: recordTimestamp(record, now),
+		Fields:    fields,
+	}
+}
+
+// documentToEvent creates the event of a record whose body holds a whole
+// Beats document, reversing the encoding of the otlp output: @timestamp and
+// @metadata are restored, and the other body fields become the event
+// fields. The resource and scope describe the Beat that sent the document,
+// so they aren't added.
+func documentToEvent(record plog.LogRecord, now time.Time) beat.Event {
+	if record.Body().Type() != pcommon.ValueTypeMap {
+		return recordToEvent(record, mapstr.M{}, now)
+	}
+
+	fields := otelmap.ToMapstr(record.Body().Map())
+	event := beat.Event{Timestamp: recordTimestamp(record, now)}
+	if ts, ok := fields["@timestamp"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			event.Timestamp = t
+		}
+	}
+	delete(fields, "@timestamp")
+
+	if meta, ok := fields["@metadata"].(map[string]any); ok {
+		// beat and version are set again by the outputs of this Beat.
+		delete(meta, "beat")
+		delete(meta, "version")
+		if len(meta) > 0 {
+			event.Meta = meta
+		}
+	}
+	delete(fields, "@metadata")
+
+	event.Fields = fields
+	return event
+}
+
+// mapAttributes converts attrs into fields, putting the attributes found in
+// ecs at their ECS field, and the others in a map at the rest key.
+func mapAttributes(attrs pcommon.Map, ecs map[string]string, rest string) mapstr.M {
+	fields := mapstr.M{}
+	if attrs.Len() == 0 {
+		return fields
+	}
+
+	others := pcommon.NewMap()
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		field, ok := ecs[k]
+		if !ok {
+			v.CopyTo(others.PutEmpty(k))
+			return true
+		}
+		_, _ = fields.Put(field, v.AsRaw())
+		return true
+	})
+	if others.Len() > 0 {
+		_, _ = fields.Put(rest, otelmap.ToMapstr(others))
+	}
+	return fields
+}
+
+// recordTimestamp returns the time of the record, falling back to the time
+// it was observed, then to now.
+func recordTimestamp(record plog.LogRecord, now time.Time) time.Time {
+	if ts := record.Timestamp(); ts != 0 {
+		return ts.AsTime()
+	}
+	if ts := record.ObservedTimestamp(); ts != 0 {
+		return ts.AsTime()
+	}
+	return now
+}
@@ -0,0 +1,135 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestLogsToEvents(t *testing.T) {
+	ts := time.Date(2026, 5, 4, 10, 30, 0, 0, time.UTC)
+
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "checkout")
+	rl.Resource().Attributes().PutStr("service.instance.id", "checkout-1")
+	rl.Resource().Attributes().PutStr("k8s.pod.name", "checkout-7d9f")
+	rl.Resource().Attributes().PutStr("telemetry.sdk.language", "go")
+
+	sl := rl.ScopeLogs().AppendEmpty()
+	sl.Scope().SetName("checkout/payments")
+	sl.Scope().SetVersion("1.2.0")
+
+	record := sl.LogRecords().AppendEmpty()
+	record.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+	record.Body().SetStr("payment declined")
+	record.SetSeverityText("WARN")
+	record.SetSeverityNumber(plog.SeverityNumberWarn)
+	record.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	record.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8})
+	record.Attributes().PutStr("exception.type", "CardDeclined")
+	record.Attributes().PutInt("order.items", 3)
+
+	observed := sl.LogRecords().AppendEmpty()
+	observed.SetObservedTimestamp(pcommon.NewTimestampFromTime(ts.Add(time.Second)))
+	observed.Body().SetEmptyMap().PutStr("user", "alice")
+
+	events := logsToEvents(logs)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, ts, events[0].Timestamp.UTC())
+	assert.Equal(t, mapstr.M{
+		"message": "payment declined",
+		"service": mapstr.M{
+			"name": "checkout",
+			"node": mapstr.M{"name": "checkout-1"},
+		},
+		"kubernetes": mapstr.M{"pod": mapstr.M{"name": "checkout-7d9f"}},
+		"log":        mapstr.M{"level": "WARN"},
+		"event":      mapstr.M{"severity": int64(plog.SeverityNumberWarn)},
+		"trace":      mapstr.M{"id": "0102030405060708090a0b0c0d0e0f10"},
+		"span":       mapstr.M{"id": "0102030405060708"},
+		"error":      mapstr.M{"type": "CardDeclined"},
+		"otlp": mapstr.M{
+			"resource": mapstr.M{"attributes": mapstr.M{"telemetry.sdk.language": "go"}},
+			"scope": mapstr.M{
+				"name":    "checkout/payments",
+				"version": "1.2.0",
+			},
+			"attributes": mapstr.M{"order.items": int64(3)},
+		},
+	}, normalize(events[0].Fields))
+
+	assert.Equal(t, ts.Add(time.Second), events[1].Timestamp.UTC())
+	body, err := events[1].Fields.GetValue("otlp.body")
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{"user": "alice"}, body)
+	_, err = events[1].Fields.GetValue("message")
+	assert.ErrorIs(t, err, mapstr.ErrKeyNotFound)
+}
+
+func TestLogsToEventsBodymap(t *testing.T) {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "filebeat")
+	sl := rl.ScopeLogs().AppendEmpty()
+	sl.Scope().Attributes().PutStr("elastic.mapping.mode", "bodymap")
+
+	body := sl.LogRecords().AppendEmpty().Body().SetEmptyMap()
+	body.PutStr("@timestamp", "2026-05-04T10:30:00.123Z")
+	body.PutStr("message", "hello")
+	body.PutEmptyMap("log").PutEmptyMap("file").PutStr("path", "/var/log/app.log")
+	meta := body.PutEmptyMap("@metadata")
+	meta.PutStr("beat", "filebeat")
+	meta.PutStr("version", "9.5.0")
+	meta.PutStr("pipeline", "app-logs")
+
+	events := logsToEvents(logs)
+	require.Len(t, events, 1)
+	assert.Equal(t, time.Date(2026, 5, 4, 10, 30, 0, 123e6, time.UTC), events[0].Timestamp.UTC())
+	assert.Equal(t, mapstr.M{
+		"message": "hello",
+		"log":     mapstr.M{"file": mapstr.M{"path": "/var/log/app.log"}},
+	}, normalize(events[0].Fields))
+	assert.Equal(t, mapstr.M{"pipeline": "app-logs"}, events[0].Meta)
+}
+
+// normalize converts the nested maps of m to mapstr.M, so the fields can be
+// compared regardless of how their maps were created.
+func normalize(m mapstr.M) mapstr.M {
+	out := make(mapstr.M, len(m))
+	for k, v := range m {
+		switch v := v.(type) {
+		case mapstr.M:
+			out[k] = normalize(v)
+		case map[string]any:
+			out[k] = normalize(v)
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+)
+
+// errPipelineFull is the error message of the export requests whose records
+// were rejected.
+const errPipelineFull = "the pipeline queue is full, retry later"
+
+// publisher publishes the records of export requests. The pipeline client
+// drops the events it can't queue instead of blocking, so exporters are told
+// to retry the rejected records rather than waiting on a blocked pipeline.
+type publisher struct {
+	client beat.Client
+}
+
+// export tracks the events of one export request. Events hold their export
+// in their private field, so the events dropped by the client can be
+// counted.
+type export struct {
+	// rejected is only updated from Publish calls made by the goroutine
+	// handling the request.
+	rejected int64
+}
+
+// publish publishes the records of logs, returning the response to the
+// export request and the number of records rejected.
+func (p *publisher) publish(logs plog.Logs) (plogotlp.ExportResponse, int64) {
+	exp := &export{}
+	events := logsToEvents(logs)
+	for i := range events {
+		events[i].Private = exp
+	}
+	p.client.PublishAll(events)
+
+	resp := plogotlp.NewExportResponse()
+	if exp.rejected > 0 {
+		resp.PartialSuccess().SetRejectedLogRecords(exp.rejected)
+		resp.PartialSuccess().SetErrorMessage(errPipelineFull)
+	}
+	return resp, exp.rejected
+}
+
+// dropListener counts the events dropped by the pipeline client against
+// their export.
+type dropListener struct{}
+
+func (dropListener) Closing()   {}
+func (dropListener) Closed()    {}
+func (dropListener) NewEvent()  {}
+func (dropListener) Filtered()  {}
+func (dropListener) Published() {}
+
+func (dropListener) DroppedOnPublish(event beat.Event) {
+	if exp, ok := event.Private.(*export); ok {
+		exp.rejected++
+	}
+}
@@ -211,6 +211,11 @@ func newInputACKHandler(log *logp.Logger) beat.EventListener {
 				continue
 			}
 
+			if notifier, ok := current.(ACKNotifier); ok {
+				notifier.EventACKed()
+				continue
+			}
+
 			if _, ok := current.(*updateOp); !ok {
 				continue
 			}
@@ -33,6 +33,12 @@ type Publisher interface {
 	Publish(event beat.Event, cursor any) error
 }
 
+// ACKNotifier can be set as the Private field of the events published without
+// cursor state, to be notified when the events are acknowledged.
+type ACKNotifier interface {
+	EventACKed()
+}
+
 // cursorPublisher implements the Publisher interface and used internally by the managedInput.
 // When publishing an event with cursor state updates, the cursorPublisher
 // updates the in memory state and create an updateOp that is used to schedule
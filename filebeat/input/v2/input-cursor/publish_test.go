@@ -26,6 +26,7 @@ import (
 
 	"github.com/elastic/beats/v7/libbeat/beat"
 	pubtest "github.com/elastic/beats/v7/libbeat/publisher/testing"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
 )
 
 func TestPublish(t *testing.T) {
@@ -72,6 +73,20 @@ func TestPublish(t *testing.T) {
 	})
 }
 
+type countingNotifier struct{ acked int }
+
+func (n *countingNotifier) EventACKed() { n.acked++ }
+
+func TestInputACKHandler(t *testing.T) {
+	notifier := &countingNotifier{}
+	handler := newInputACKHandler(logptest.NewTestingLogger(t, ""))
+	for _, private := range []any{notifier, nil, notifier} {
+		handler.AddEvent(beat.Event{Private: private}, true)
+	}
+	handler.ACKEvents(3)
+	assert.Equal(t, 2, notifier.acked)
+}
+
 func TestOp_Execute(t *testing.T) {
 	t.Run("applying final op marks the key as finished", func(t *testing.T) {
 		store := testOpenStore(t, "test", createSampleStore(t, nil))
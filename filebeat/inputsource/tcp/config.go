@@ -62,3 +62,10 @@ func (c *Config) Validate() error {
 	}
 	return nil
 }
+
+func (c *Config) network() string {
+	if c.Network != "" {
+		return c.Network
+	}
+	return networkTCP
+}
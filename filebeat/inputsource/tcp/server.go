@@ -70,34 +70,37 @@ func New(
 }
 
 func (s *Server) createServer() (net.Listener, error) {
+	var t *tls.Config
+	if s.tlsConfig != nil {
+		t = s.tlsConfig.BuildServerConfig(s.config.Host)
+	}
+	return Listen(s.config, t, s.logger)
+}
+
+// Listen listens for TCP connections on the host of config, accepting at
+// most config.MaxConnections connections at once. The connections use TLS if
+// tlsConfig isn't nil.
+func Listen(config *Config, tlsConfig *tls.Config, logger *logp.Logger) (net.Listener, error) {
 	var l net.Listener
 	var err error
-	network := s.network()
-	if s.tlsConfig != nil {
-		t := s.tlsConfig.BuildServerConfig(s.config.Host)
-		l, err = tls.Listen(network, s.config.Host, t)
+	network := config.network()
+	if tlsConfig != nil {
+		l, err = tls.Listen(network, config.Host, tlsConfig)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		l, err = (&net.ListenConfig{}).Listen(context.Background(), network, s.config.Host)
+		l, err = (&net.ListenConfig{}).Listen(context.Background(), network, config.Host)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	// Log the bound address so an ephemeral (host ...:0) port can be discovered.
-	s.logger.Infof("Started listening for TCP connection on: %s", l.Addr())
+	logger.Infof("Started listening for TCP connection on: %s", l.Addr())
 
-	if s.config.MaxConnections > 0 {
-		return netutil.LimitListener(l, s.config.MaxConnections), nil
+	if config.MaxConnections > 0 {
+		return netutil.LimitListener(l, config.MaxConnections), nil
 	}
 	return l, nil
 }
-
-func (s *Server) network() string {
-	if s.config.Network != "" {
-		return s.config.Network
-	}
-	return networkTCP
-}
@@ -19,6 +19,7 @@ package udp
 
 import (
 	"net"
+	"sync"
 
 	"github.com/elastic/beats/v7/filebeat/inputsource"
 	"github.com/elastic/beats/v7/filebeat/inputsource/common/dgram"
@@ -36,6 +37,9 @@ type Server struct {
 
 	localaddress string
 	logger       *logp.Logger
+
+	mu   sync.Mutex
+	conn net.PacketConn
 }
 
 // New returns a new UDPServer instance.
@@ -68,6 +72,9 @@ func (u *Server) createConn() (net.PacketConn, error) {
 	}
 
 	u.localaddress = listener.LocalAddr().String()
+	u.mu.Lock()
+	u.conn = listener
+	u.mu.Unlock()
 
 	// Log the bound address so an ephemeral (host ...:0) port can be discovered.
 	u.logger.Infof("Started listening for UDP connection on: %s", u.localaddress)
@@ -75,6 +82,18 @@ func (u *Server) createConn() (net.PacketConn, error) {
 	return listener, err
 }
 
+// WriteTo sends a datagram to addr from the listening socket, so the inputs
+// can reply to the datagrams they receive.
+func (u *Server) WriteTo(b []byte, addr net.Addr) (int, error) {
+	u.mu.Lock()
+	conn := u.conn
+	u.mu.Unlock()
+	if conn == nil {
+		return 0, net.ErrClosed
+	}
+	return conn.WriteTo(b, addr)
+}
+
 func (u *Server) network() string {
 	if u.config.Network != "" {
 		return u.config.Network
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/elastic/beats/v7/filebeat/inputsource"
 	"github.com/elastic/elastic-agent-libs/logp/logptest"
@@ -115,3 +116,36 @@ func testReceiveEventFromUDPWithNetwork(t *testing.T, network string) {
 		})
 	}
 }
+
+func TestWriteToUDP(t *testing.T) {
+	ch := make(chan info)
+	config := &Config{
+		Host:           "localhost:0",
+		MaxMessageSize: maxMessageSize,
+		Timeout:        timeout,
+	}
+	fn := func(message []byte, metadata inputsource.NetworkMetadata) {
+		ch <- info{message: message, mt: metadata}
+	}
+	s := New(config, fn, logptest.NewTestingLogger(t, ""))
+	_, err := s.WriteTo([]byte("early"), &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9})
+	assert.ErrorIs(t, err, net.ErrClosed)
+
+	require.NoError(t, s.Start())
+	defer s.Stop()
+
+	conn, err := net.Dial(s.network(), s.localaddress)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	info := <-ch
+
+	_, err = s.WriteTo([]byte("pong"), info.mt.RemoteAddr)
+	require.NoError(t, err)
+	buf := make([]byte, maxMessageSize)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "pong", string(buf[:n]))
+}
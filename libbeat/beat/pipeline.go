@@ -19,6 +19,8 @@ package beat
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/elastic/elastic-agent-libs/mapstr"
@@ -70,6 +72,11 @@ type Client interface {
 type ClientConfig struct {
 	PublishMode PublishMode
 
+	// Priority sets the scheduling class of the client's events. Queues that
+	// support priorities keep a lane per class, so a busy low priority client
+	// doesn't delay the events of higher priority clients.
+	Priority Priority
+
 	Processing ProcessingConfig
 
 	// WaitClose sets the maximum duration to wait on ACK, if client still has events
@@ -203,6 +210,49 @@ const (
 	DropIfFull
 )
 
+// Priority enum sets the scheduling class of a client's events in the
+// publisher pipeline queue.
+type Priority uint8
+
+const (
+	// NormalPriority is the default priority.
+	NormalPriority Priority = iota
+
+	// HighPriority events get the largest share of the queue's output while
+	// other clients have events waiting. Meant for low volume, latency
+	// sensitive events such as security alerts.
+	HighPriority
+
+	// LowPriority events get the smallest share of the queue's output while
+	// other clients have events waiting. Meant for bulk data such as debug
+	// logs.
+	LowPriority
+)
+
+var priorityNames = map[Priority]string{
+	NormalPriority: "normal",
+	HighPriority:   "high",
+	LowPriority:    "low",
+}
+
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("Priority(%d)", uint8(p))
+}
+
+// Unpack parses a priority name from the configuration.
+func (p *Priority) Unpack(s string) error {
+	for priority, name := range priorityNames {
+		if strings.EqualFold(s, name) {
+			*p = priority
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid priority %q, must be one of high, normal or low", s)
+}
+
 type CombinedClientListener struct {
 	A, B ClientListener
 }
@@ -0,0 +1,264 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/beats/v7/libbeat/cmd/instance"
+	"github.com/elastic/beats/v7/libbeat/common/cli"
+	"github.com/elastic/beats/v7/libbeat/outputs/codec/json"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/diskqueue"
+)
+
+// errExportLimit stops reading frames once the export limit is reached.
+var errExportLimit = errors.New("export limit reached")
+
+// diskQueueContext holds what the diskqueue subcommands need to find and
+// decode the queue of the configured beat.
+type diskQueueContext struct {
+	beat     *instance.Beat
+	settings diskqueue.Settings
+}
+
+func genDiskQueueCmd(settings instance.Settings) *cobra.Command {
+	diskQueueCmd := &cobra.Command{
+		Use:   "diskqueue",
+		Short: "Inspect the disk queue",
+		Long: `Inspect and export the contents of the disk queue.
+
+The beat must not be running while these commands are used.`,
+	}
+
+	diskQueueCmd.AddCommand(genListDiskQueueCmd(settings))
+	diskQueueCmd.AddCommand(genExportDiskQueueCmd(settings))
+
+	return diskQueueCmd
+}
+
+func genListDiskQueueCmd(settings instance.Settings) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the disk queue segments and their pending events",
+		Run: cli.RunWith(func(cmd *cobra.Command, args []string) error {
+			dq, err := newDiskQueueContext(settings)
+			if err != nil {
+				return err
+			}
+			return dq.list(os.Stdout)
+		}),
+	}
+}
+
+func genExportDiskQueueCmd(settings instance.Settings) *cobra.Command {
+	var (
+		flagOutput  string
+		flagSegment int64
+		flagSkip    uint64
+		flagLimit   uint64
+		flagAll     bool
+		flagACK     bool
+	)
+	command := &cobra.Command{
+		Use:   "export",
+		Short: "Export the events in the disk queue as NDJSON",
+		Long: `Export the events in the disk queue as newline delimited JSON.
+
+By default only the events that were not acknowledged yet are exported,
+starting from the current read position of the queue. The queue state is
+not modified unless --ack is given, in which case the read position is
+advanced past the last exported event, so the beat won't publish the
+exported events again.`,
+		Run: cli.RunWith(func(cmd *cobra.Command, args []string) error {
+			if flagACK && (flagAll || flagSkip > 0 || flagSegment >= 0) {
+				return errors.New("--ack can't be combined with --all, --skip or --segment")
+			}
+			dq, err := newDiskQueueContext(settings)
+			if err != nil {
+				return err
+			}
+
+			out := os.Stdout
+			if flagOutput != "" && flagOutput != "-" {
+				out, err = os.OpenFile(flagOutput, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+				if err != nil {
+					return fmt.Errorf("error creating output file: %w", err)
+				}
+				defer out.Close()
+			}
+
+			exported, last, err := dq.export(out, flagSegment, flagSkip, flagLimit, flagAll)
+			if err != nil {
+				return err
+			}
+			if out != os.Stdout {
+				if err := out.Sync(); err != nil {
+					return fmt.Errorf("error writing output file: %w", err)
+				}
+			}
+			fmt.Fprintf(os.Stderr, "Exported %d events\n", exported)
+
+			if !flagACK || exported == 0 {
+				return nil
+			}
+			position := diskqueue.QueuePosition{
+				SegmentID:  last.SegmentID,
+				ByteIndex:  last.Offset + last.Size,
+				FrameIndex: last.Index + 1,
+			}
+			if err := diskqueue.WriteQueuePosition(dq.settings, dq.beat.Info.Paths, position); err != nil {
+				return fmt.Errorf("error updating queue read position: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Read position advanced to segment %d, frame %d\n", position.SegmentID, position.FrameIndex)
+			return nil
+		}),
+	}
+	command.Flags().StringVarP(&flagOutput, "output", "o", "", "Write the events to this file instead of stdout")
+	command.Flags().Int64Var(&flagSegment, "segment", -1, "Only export events from this segment")
+	command.Flags().Uint64Var(&flagSkip, "skip", 0, "Skip this many events before exporting")
+	command.Flags().Uint64Var(&flagLimit, "limit", 0, "Maximum number of events to export, 0 means no limit")
+	command.Flags().BoolVar(&flagAll, "all", false, "Also export acknowledged events that are still on disk")
+	command.Flags().BoolVar(&flagACK, "ack", false, "Advance the queue read position past the exported events")
+	return command
+}
+
+func newDiskQueueContext(settings instance.Settings) (*diskQueueContext, error) {
+	b, err := instance.NewInitializedBeat(settings)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing beat: %w", err)
+	}
+
+	queueConfig := b.Config.Pipeline.Queue
+	if !queueConfig.IsSet() || queueConfig.Name() != diskqueue.QueueType {
+		return nil, errors.New("the disk queue is not enabled in the configuration")
+	}
+	qSettings, err := diskqueue.SettingsForUserConfig(queueConfig.Config())
+	if err != nil {
+		return nil, fmt.Errorf("error reading disk queue settings: %w", err)
+	}
+	return &diskQueueContext{beat: b, settings: qSettings}, nil
+}
+
+func (dq *diskQueueContext) list(w io.Writer) error {
+	segments, err := diskqueue.ListSegments(dq.beat.Info.Logger, dq.settings, dq.beat.Info.Paths)
+	if err != nil {
+		return fmt.Errorf("error reading disk queue segments: %w", err)
+	}
+	position, err := diskqueue.ReadQueuePosition(dq.settings, dq.beat.Info.Paths)
+	if err != nil {
+		return fmt.Errorf("error reading disk queue position: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SEGMENT\tFRAMES\tPENDING\tBYTES\tCOMPRESSION\tENCRYPTED")
+	var totalPending, totalBytes uint64
+	for _, segment := range segments {
+		pending := position.PendingFrames(segment)
+		totalPending += pending
+		totalBytes += segment.Size
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%s\t%t\n",
+			segment.ID, segment.FrameCount, pending, segment.Size, segmentCompression(segment), segment.Encrypted)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nRead position: segment %d, frame %d\n", position.SegmentID, position.FrameIndex)
+	fmt.Fprintf(w, "Total: %d segments, %d pending events, %d bytes\n", len(segments), totalPending, totalBytes)
+	return nil
+}
+
+// export writes the selected events to w, one JSON document per line, and
+// returns the number of exported events and the last exported frame.
+func (dq *diskQueueContext) export(w io.Writer, segmentID int64, skip, limit uint64, all bool) (uint64, diskqueue.Frame, error) {
+	segments, err := diskqueue.ListSegments(dq.beat.Info.Logger, dq.settings, dq.beat.Info.Paths)
+	if err != nil {
+		return 0, diskqueue.Frame{}, fmt.Errorf("error reading disk queue segments: %w", err)
+	}
+	position, err := diskqueue.ReadQueuePosition(dq.settings, dq.beat.Info.Paths)
+	if err != nil {
+		return 0, diskqueue.Frame{}, fmt.Errorf("error reading disk queue position: %w", err)
+	}
+
+	encoder := json.New(dq.beat.Info.Version, json.Config{})
+	out := bufio.NewWriter(w)
+	var exported uint64
+	var last diskqueue.Frame
+	for _, segment := range segments {
+		if segmentID >= 0 && segment.ID != uint64(segmentID) {
+			continue
+		}
+		if !all && position.PendingFrames(segment) == 0 {
+			continue
+		}
+		err := diskqueue.ReadFrames(dq.settings, dq.beat.Info.Paths, segment, func(frame diskqueue.Frame) error {
+			if !all && frame.SegmentID == position.SegmentID && frame.Index < position.FrameIndex {
+				return nil
+			}
+			if skip > 0 {
+				skip--
+				return nil
+			}
+			if limit > 0 && exported >= limit {
+				return errExportLimit
+			}
+			serialized, err := encoder.Encode(dq.beat.Info.Beat, &frame.Event.Content)
+			if err != nil {
+				return fmt.Errorf("error encoding event of segment %d frame %d: %w", frame.SegmentID, frame.Index, err)
+			}
+			if _, err := out.Write(serialized); err != nil {
+				return err
+			}
+			if err := out.WriteByte('\n'); err != nil {
+				return err
+			}
+			exported++
+			last = frame
+			return nil
+		})
+		if errors.Is(err, errExportLimit) {
+			break
+		}
+		if err != nil {
+			// Flush what was exported so far, the events up to the broken
+			// frame are still useful.
+			_ = out.Flush()
+			return exported, last, err
+		}
+	}
+	return exported, last, out.Flush()
+}
+
+// segmentCompression describes the compression of the segment, e.g. "zstd",
+// or "zstd level 3" if it was not written at the default level.
+func segmentCompression(segment diskqueue.SegmentInfo) string {
+	switch {
+	case !segment.Compressed:
+		return "none"
+	case segment.CompressionLevel == 0:
+		return segment.CompressionAlgorithm.String()
+	default:
+		return fmt.Sprintf("%v level %d", segment.CompressionAlgorithm, segment.CompressionLevel)
+	}
+}
@@ -63,6 +63,7 @@ import (
 	"github.com/elastic/beats/v7/libbeat/publisher/pipeline"
 	"github.com/elastic/beats/v7/libbeat/publisher/processing"
 	"github.com/elastic/beats/v7/libbeat/publisher/queue/diskqueue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/hybridqueue"
 	"github.com/elastic/beats/v7/libbeat/version"
 	"github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/file"
@@ -1599,20 +1600,24 @@ func (bc *beatConfig) Validate() error {
 		if bc.Pipeline.Queue.IsSet() && outputPC.Queue.IsSet() {
 			return fmt.Errorf("top level queue and output level queue settings defined, only one is allowed")
 		}
-		// elastic-agent doesn't support disk queue yet
-		if bc.Management.Enabled() && outputPC.Queue.Config().Enabled() && outputPC.Queue.Name() == diskqueue.QueueType {
-			return fmt.Errorf("disk queue is not supported when management is enabled")
+		// elastic-agent doesn't support disk-backed queues yet
+		if bc.Management.Enabled() && outputPC.Queue.Config().Enabled() && isDiskBackedQueue(outputPC.Queue.Name()) {
+			return fmt.Errorf("%s queue is not supported when management is enabled", outputPC.Queue.Name())
 		}
 	}
 
-	// elastic-agent doesn't support disk queue yet
-	if bc.Management.Enabled() && bc.Pipeline.Queue.Config().Enabled() && bc.Pipeline.Queue.Name() == diskqueue.QueueType {
-		return fmt.Errorf("disk queue is not supported when management is enabled")
+	// elastic-agent doesn't support disk-backed queues yet
+	if bc.Management.Enabled() && bc.Pipeline.Queue.Config().Enabled() && isDiskBackedQueue(bc.Pipeline.Queue.Name()) {
+		return fmt.Errorf("%s queue is not supported when management is enabled", bc.Pipeline.Queue.Name())
 	}
 
 	return nil
 }
 
+func isDiskBackedQueue(queueType string) bool {
+	return queueType == diskqueue.QueueType || queueType == hybridqueue.QueueType
+}
+
 // runShutdownWatchdog releases the publisher pipeline if a Beater's Run does not
 // return within grace after it was told to stop, as a backstop against a hung
 // beater (for example one blocked in a guaranteed Publish). It returns
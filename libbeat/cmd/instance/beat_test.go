@@ -573,6 +573,22 @@ output:
 `),
 			expectValidationError: "disk queue is not supported when management is enabled accessing config",
 		},
+		"managementTopLevelHybridQueue": {
+			input: []byte(`
+name: mockbeat
+management:
+  enabled: true
+queue:
+  hybrid:
+    disk:
+      max_size: 1G
+output:
+  elasticsearch:
+    hosts:
+      - "localhost:9200"
+`),
+			expectValidationError: "hybrid queue is not supported when management is enabled accessing config",
+		},
 		"managementFalseOutputLevelDiskQueue": {
 			input: []byte(`
 name: mockbeat
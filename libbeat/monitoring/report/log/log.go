@@ -78,6 +78,11 @@ var gauges = map[string]bool{
 	"filebeat.filestream.files_ignored":          true,
 	"filebeat.filestream.files_empty":            true,
 	"filebeat.filestream.scan_errors":            true,
+
+	// Per-priority queue gauges
+	"libbeat.pipeline.queue.priority.high.filled.events":   true,
+	"libbeat.pipeline.queue.priority.normal.filled.events": true,
+	"libbeat.pipeline.queue.priority.low.filled.events":    true,
 }
 
 // IsGauge returns true when the given metric key name represents a gauge value.
@@ -0,0 +1,382 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpout
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common/fmtstr"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/beats/v7/libbeat/outputs/codec"
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/testing"
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+)
+
+// maxResponseBodySize bounds how much of a response is read, responses are
+// only used for logging.
+const maxResponseBodySize = 64 * 1024
+
+type clientSettings struct {
+	url       string
+	config    httpConfig
+	codec     codec.Codec
+	index     string
+	userAgent string
+	observer  outputs.Observer
+}
+
+type client struct {
+	log              *logp.Logger
+	observer         outputs.Observer
+	url              string
+	method           string
+	headers          map[string]*fmtstr.EventFormatString
+	codec            codec.Codec
+	index            string
+	batchFormat      string
+	contentType      string
+	compressionLevel int
+	http             *http.Client
+}
+
+// requestGroup holds the events of a batch that share the same rendered
+// headers and can therefore be sent in a single request.
+type requestGroup struct {
+	headers map[string]string
+	events  []publisher.Event
+}
+
+// publishResult counts the outcome of sending some events. Each event is
+// counted in exactly one of acked, dropped or retry.
+type publishResult struct {
+	acked   int
+	dropped int
+	retry   []publisher.Event
+}
+
+func newClient(s clientSettings, log *logp.Logger) (*client, error) {
+	httpClient, err := s.config.Transport.Client(
+		httpcommon.WithLogger(log),
+		httpcommon.WithIOStats(s.observer),
+		httpcommon.WithKeepaliveSettings{IdleConnTimeout: s.config.Transport.IdleConnTimeout},
+		httpcommon.WithHeaderRoundTripper(map[string]string{"User-Agent": s.userAgent}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := "application/x-ndjson"
+	if s.config.BatchFormat == batchFormatJSONArray {
+		contentType = "application/json"
+	}
+
+	return &client{
+		log:              log,
+		observer:         s.observer,
+		url:              s.url,
+		method:           s.config.Method,
+		headers:          s.config.Headers,
+		codec:            s.codec,
+		index:            s.index,
+		batchFormat:      s.config.BatchFormat,
+		contentType:      contentType,
+		compressionLevel: s.config.CompressionLevel,
+		http:             httpClient,
+	}, nil
+}
+
+func (c *client) Connect(context.Context) error {
+	return nil
+}
+
+func (c *client) Close() error {
+	c.http.CloseIdleConnections()
+	return nil
+}
+
+func (c *client) String() string {
+	return "http(" + c.url + ")"
+}
+
+func (c *client) Test(d testing.Driver) {
+	d.Run("http: "+c.url, func(d testing.Driver) {
+		req, err := http.NewRequest(http.MethodHead, c.url, nil)
+		d.Fatal("create request", err)
+		resp, err := c.http.Do(req)
+		d.Fatal("talk to server", err)
+		resp.Body.Close()
+	})
+}
+
+// Publish sends the batch to the endpoint. Events are grouped by their
+// rendered headers, and each group is sent as one request. Once all groups
+// have been handled the batch is acknowledged, with the events that got a
+// retryable response handed back to the pipeline.
+func (c *client) Publish(ctx context.Context, batch publisher.Batch) error {
+	events := batch.Events()
+	c.observer.NewBatch(len(events))
+
+	groups, dropped := c.groupEvents(events)
+	result := publishResult{dropped: dropped}
+
+	var publishErr error
+	for _, group := range groups {
+		if publishErr != nil {
+			// A previous request failed at the connection level, don't
+			// bother sending the rest and retry them with the failed events.
+			result.retry = append(result.retry, group.events...)
+			continue
+		}
+		publishErr = c.publishGroup(ctx, group.headers, group.events, &result)
+	}
+
+	c.observer.AckedEvents(result.acked)
+	c.observer.PermanentErrors(result.dropped)
+	c.observer.RetryableErrors(len(result.retry))
+
+	if len(result.retry) > 0 {
+		batch.RetryEvents(result.retry)
+	} else {
+		batch.ACK()
+	}
+	return publishErr
+}
+
+// groupEvents renders the configured headers for every event and groups
+// events with identical headers together, keeping their relative order. Events whose headers can't be rendered are
+// dropped and counted in the returned value.
+func (c *client) groupEvents(events []publisher.Event) ([]requestGroup, int) {
+	if len(c.headers) == 0 {
+		return []requestGroup{{events: events}}, 0
+	}
+
+	keys := make([]string, 0, len(c.headers))
+	for k := range c.headers {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var groups []requestGroup
+	index := map[string]int{}
+	dropped := 0
+	var sb strings.Builder
+	for i := range events {
+		headers := make(map[string]string, len(keys))
+		sb.Reset()
+		var err error
+		for _, k := range keys {
+			var v string
+			v, err = c.headers[k].Run(&events[i].Content)
+			if err != nil {
+				err = fmt.Errorf("failed to render header %q: %w", k, err)
+				break
+			}
+			headers[k] = v
+			sb.WriteString(k)
+			sb.WriteByte(0)
+			sb.WriteString(v)
+			sb.WriteByte(0)
+		}
+		if err != nil {
+			c.log.Errorf("Dropping event: %v", err)
+			dropped++
+			continue
+		}
+
+		key := sb.String()
+		idx, ok := index[key]
+		if !ok {
+			idx = len(groups)
+			index[key] = idx
+			groups = append(groups, requestGroup{headers: headers})
+		}
+		groups[idx].events = append(groups[idx].events, events[i])
+	}
+	return groups, dropped
+}
+
+// publishGroup sends events with the same headers and records the outcome
+// in result. A non-nil error is returned for connection failures and
+// retryable responses, so the backoff client slows down before the next
+// attempt.
+func (c *client) publishGroup(
+	ctx context.Context,
+	headers map[string]string,
+	events []publisher.Event,
+	result *publishResult,
+) error {
+	body, okEvents := c.encodeEvents(events)
+	result.dropped += len(events) - len(okEvents)
+	if len(okEvents) == 0 {
+		return nil
+	}
+
+	begin := time.Now()
+	status, respBody, err := c.send(ctx, headers, body)
+	if err != nil {
+		c.log.Errorf("Failed to send %d events to %s: %v", len(okEvents), c.url, err)
+		result.retry = append(result.retry, okEvents...)
+		return err
+	}
+
+	switch classifyStatus(status) {
+	case statusOK:
+		duration := time.Since(begin)
+		c.observer.ReportLatency(duration)
+		c.log.Debugf("%d events have been sent to %s in %v.", len(okEvents), c.url, duration)
+		result.acked += len(okEvents)
+		return nil
+
+	case statusTooLarge:
+		if len(okEvents) == 1 {
+			c.log.Errorf("Dropping event that exceeds the endpoint request size limit (status=%d): %s", status, respBody)
+			result.dropped++
+			return nil
+		}
+		c.observer.BatchSplit()
+		mid := len(okEvents) / 2
+		if err := c.publishGroup(ctx, headers, okEvents[:mid], result); err != nil {
+			result.retry = append(result.retry, okEvents[mid:]...)
+			return err
+		}
+		return c.publishGroup(ctx, headers, okEvents[mid:], result)
+
+	case statusRetry:
+		c.log.Warnf("Endpoint answered with retryable status %d, retrying %d events: %s", status, len(okEvents), respBody)
+		result.retry = append(result.retry, okEvents...)
+		return fmt.Errorf("http output received retryable status %d", status)
+
+	default:
+		c.log.Warnw(fmt.Sprintf("Endpoint rejected %d events (status=%d): %s, dropping events!", len(okEvents), status, respBody), logp.TypeKey, logp.EventType)
+		result.dropped += len(okEvents)
+		return nil
+	}
+}
+
+// encodeEvents serializes events into a request body using the configured
+// batch format. Events that fail to encode are left out of the returned
+// events.
+func (c *client) encodeEvents(events []publisher.Event) ([]byte, []publisher.Event) {
+	var buf bytes.Buffer
+	okEvents := make([]publisher.Event, 0, len(events))
+
+	if c.batchFormat == batchFormatJSONArray {
+		buf.WriteByte('[')
+	}
+	for i := range events {
+		serialized, err := c.codec.Encode(c.index, &events[i].Content)
+		if err != nil {
+			c.log.Errorf("Failed to encode event, dropping event: %v", err)
+			continue
+		}
+		if c.batchFormat == batchFormatJSONArray {
+			if len(okEvents) > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(serialized)
+		} else {
+			buf.Write(serialized)
+			buf.WriteByte('\n')
+		}
+		okEvents = append(okEvents, events[i])
+	}
+	if c.batchFormat == batchFormatJSONArray {
+		buf.WriteByte(']')
+	}
+	return buf.Bytes(), okEvents
+}
+
+func (c *client) send(ctx context.Context, headers map[string]string, body []byte) (int, []byte, error) {
+	var reader io.Reader = bytes.NewReader(body)
+	if c.compressionLevel > 0 {
+		var buf bytes.Buffer
+		zw, err := gzip.NewWriterLevel(&buf, c.compressionLevel)
+		if err != nil {
+			return 0, nil, err
+		}
+		if _, err := zw.Write(body); err != nil {
+			return 0, nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return 0, nil, err
+		}
+		reader = &buf
+	}
+
+	req, err := http.NewRequestWithContext(ctx, c.method, c.url, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", c.contentType)
+	if c.compressionLevel > 0 {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodySize))
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, bytes.TrimSpace(respBody), nil
+}
+
+type statusClass uint8
+
+const (
+	statusOK statusClass = iota
+	statusRetry
+	statusTooLarge
+	statusDrop
+)
+
+// classifyStatus decides what happens to the events of a request based on
+// the response status. Throttling, timeouts and server errors are retried,
+// any other client error means the events can never be accepted, so they
+// are dropped.
+func classifyStatus(status int) statusClass {
+	switch {
+	case status >= 200 && status < 300:
+		return statusOK
+	case status == http.StatusRequestEntityTooLarge:
+		return statusTooLarge
+	case status == http.StatusRequestTimeout,
+		status == http.StatusTooManyRequests,
+		status >= 500:
+		return statusRetry
+	default:
+		return statusDrop
+	}
+}
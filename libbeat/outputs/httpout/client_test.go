@@ -0,0 +1,233 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpout
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	_ "github.com/elastic/beats/v7/libbeat/outputs/codec/format"
+	jsoncodec "github.com/elastic/beats/v7/libbeat/outputs/codec/json"
+	"github.com/elastic/beats/v7/libbeat/outputs/outest"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+type request struct {
+	headers http.Header
+	body    string
+}
+
+func newTestServer(t *testing.T, status func(n int) int) (*httptest.Server, func() []request) {
+	var mu sync.Mutex
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body = zr
+		}
+		b, err := io.ReadAll(body)
+		require.NoError(t, err)
+
+		mu.Lock()
+		requests = append(requests, request{headers: r.Header.Clone(), body: string(b)})
+		n := len(requests)
+		mu.Unlock()
+		w.WriteHeader(status(n))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []request {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+func newTestClient(t *testing.T, url string, settings map[string]any) *client {
+	t.Helper()
+	cfg := defaultConfig()
+	require.NoError(t, config.MustNewConfigFrom(settings).Unpack(&cfg))
+	c, err := newClient(clientSettings{
+		url:      url,
+		config:   cfg,
+		codec:    jsoncodec.New("9.9.9", jsoncodec.Config{}),
+		index:    "test",
+		observer: outputs.NewNilObserver(),
+	}, logptest.NewTestingLogger(t, ""))
+	require.NoError(t, err)
+	return c
+}
+
+func testEvents(messages ...string) []beat.Event {
+	events := make([]beat.Event, len(messages))
+	for i, m := range messages {
+		events[i] = beat.Event{Fields: mapstr.M{"message": m, "tenant": "t" + m}}
+	}
+	return events
+}
+
+func TestPublishBatchFormats(t *testing.T) {
+	t.Run("ndjson", func(t *testing.T) {
+		srv, requests := newTestServer(t, func(int) int { return http.StatusOK })
+		c := newTestClient(t, srv.URL, map[string]any{})
+
+		batch := outest.NewBatch(testEvents("1", "2")...)
+		require.NoError(t, c.Publish(context.Background(), batch))
+		assert.Equal(t, outest.BatchACK, batch.Signals[0].Tag)
+
+		reqs := requests()
+		require.Len(t, reqs, 1)
+		assert.Equal(t, "application/x-ndjson", reqs[0].headers.Get("Content-Type"))
+		lines := strings.Split(strings.TrimSpace(reqs[0].body), "\n")
+		require.Len(t, lines, 2)
+		for _, l := range lines {
+			assert.True(t, json.Valid([]byte(l)), l)
+		}
+	})
+
+	t.Run("json_array gzip", func(t *testing.T) {
+		srv, requests := newTestServer(t, func(int) int { return http.StatusOK })
+		c := newTestClient(t, srv.URL, map[string]any{"batch_format": "json_array", "compression_level": 5})
+
+		batch := outest.NewBatch(testEvents("1", "2", "3")...)
+		require.NoError(t, c.Publish(context.Background(), batch))
+
+		reqs := requests()
+		require.Len(t, reqs, 1)
+		assert.Equal(t, "gzip", reqs[0].headers.Get("Content-Encoding"))
+		var docs []map[string]any
+		require.NoError(t, json.Unmarshal([]byte(reqs[0].body), &docs))
+		assert.Len(t, docs, 3)
+	})
+}
+
+func TestPublishHeaderGroups(t *testing.T) {
+	srv, requests := newTestServer(t, func(int) int { return http.StatusNoContent })
+	c := newTestClient(t, srv.URL, map[string]any{
+		"headers": map[string]any{
+			"X-Tenant": "%{[tenant]}",
+			"X-Static": "value",
+		},
+	})
+
+	events := testEvents("1", "2")
+	events = append(events, beat.Event{Fields: mapstr.M{"message": "3", "tenant": "t1"}})
+	events = append(events, beat.Event{Fields: mapstr.M{"message": "no tenant"}})
+	batch := outest.NewBatch(events...)
+	require.NoError(t, c.Publish(context.Background(), batch))
+	assert.Equal(t, outest.BatchACK, batch.Signals[0].Tag)
+
+	reqs := requests()
+	require.Len(t, reqs, 2)
+	assert.Equal(t, "t1", reqs[0].headers.Get("X-Tenant"))
+	assert.Equal(t, "value", reqs[0].headers.Get("X-Static"))
+	assert.Equal(t, 2, strings.Count(reqs[0].body, "\n"))
+	assert.Equal(t, "t2", reqs[1].headers.Get("X-Tenant"))
+	assert.Equal(t, 1, strings.Count(reqs[1].body, "\n"))
+}
+
+func TestPublishStatusClassification(t *testing.T) {
+	tests := map[string]struct {
+		status   int
+		signal   outest.BatchSignalTag
+		retried  int
+		wantErr  bool
+		requests int
+	}{
+		"created":           {status: http.StatusCreated, signal: outest.BatchACK, requests: 1},
+		"bad request":       {status: http.StatusBadRequest, signal: outest.BatchACK, requests: 1},
+		"unauthorized":      {status: http.StatusUnauthorized, signal: outest.BatchACK, requests: 1},
+		"too many requests": {status: http.StatusTooManyRequests, signal: outest.BatchRetryEvents, retried: 2, wantErr: true, requests: 1},
+		"server error":      {status: http.StatusInternalServerError, signal: outest.BatchRetryEvents, retried: 2, wantErr: true, requests: 1},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv, requests := newTestServer(t, func(int) int { return tc.status })
+			c := newTestClient(t, srv.URL, map[string]any{})
+
+			batch := outest.NewBatch(testEvents("1", "2")...)
+			err := c.Publish(context.Background(), batch)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			require.Len(t, batch.Signals, 1)
+			assert.Equal(t, tc.signal, batch.Signals[0].Tag)
+			assert.Len(t, batch.Signals[0].Events, tc.retried)
+			assert.Len(t, requests(), tc.requests)
+		})
+	}
+}
+
+func TestPublishSplitsTooLargeRequests(t *testing.T) {
+	// The first request carrying all events is too large, the halves are
+	// accepted.
+	srv, requests := newTestServer(t, func(n int) int {
+		if n == 1 {
+			return http.StatusRequestEntityTooLarge
+		}
+		return http.StatusOK
+	})
+	c := newTestClient(t, srv.URL, map[string]any{})
+
+	batch := outest.NewBatch(testEvents("1", "2", "3", "4")...)
+	require.NoError(t, c.Publish(context.Background(), batch))
+	assert.Equal(t, outest.BatchACK, batch.Signals[0].Tag)
+
+	reqs := requests()
+	require.Len(t, reqs, 3)
+	assert.Equal(t, 2, strings.Count(reqs[1].body, "\n"))
+	assert.Equal(t, 2, strings.Count(reqs[2].body, "\n"))
+}
+
+func TestMakeHTTP(t *testing.T) {
+	cfg := config.MustNewConfigFrom(map[string]any{
+		"hosts": []string{"https://hec.example.com:8088"},
+		"path":  "/services/collector/event",
+		"codec": map[string]any{"format": map[string]any{"string": "%{[message]}"}},
+	})
+	info := beat.Info{Beat: "test", Logger: logptest.NewTestingLogger(t, "")}
+
+	group, err := makeHTTP(nil, info, outputs.NewNilObserver(), cfg)
+	require.NoError(t, err)
+	require.Len(t, group.Clients, 1)
+	assert.Equal(t, "backoff(http(https://hec.example.com:8088/services/collector/event))", group.Clients[0].String())
+
+	_, err = makeHTTP(nil, info, outputs.NewNilObserver(), config.MustNewConfigFrom(map[string]any{
+		"hosts":        []string{"localhost"},
+		"batch_format": "xml",
+	}))
+	assert.Error(t, err)
+}
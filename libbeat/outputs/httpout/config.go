@@ -0,0 +1,88 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpout
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common/fmtstr"
+	"github.com/elastic/beats/v7/libbeat/outputs/codec"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+)
+
+const (
+	batchFormatNDJSON    = "ndjson"
+	batchFormatJSONArray = "json_array"
+)
+
+type httpConfig struct {
+	Protocol         string                               `config:"protocol"`
+	Path             string                               `config:"path"`
+	Method           string                               `config:"method"`
+	Headers          map[string]*fmtstr.EventFormatString `config:"headers"`
+	Codec            codec.Config                         `config:"codec"`
+	BatchFormat      string                               `config:"batch_format"`
+	CompressionLevel int                                  `config:"compression_level" validate:"min=0, max=9"`
+	LoadBalance      bool                                 `config:"loadbalance"`
+	BulkMaxSize      int                                  `config:"bulk_max_size"`
+	MaxRetries       int                                  `config:"max_retries"`
+	Backoff          backoff                              `config:"backoff"`
+	Queue            config.Namespace                     `config:"queue"`
+
+	Transport httpcommon.HTTPTransportSettings `config:",inline"`
+}
+
+type backoff struct {
+	Init time.Duration
+	Max  time.Duration
+}
+
+func defaultConfig() httpConfig {
+	return httpConfig{
+		Method:           http.MethodPost,
+		BatchFormat:      batchFormatNDJSON,
+		CompressionLevel: 0,
+		LoadBalance:      true,
+		BulkMaxSize:      1600,
+		MaxRetries:       3,
+		Backoff: backoff{
+			Init: 1 * time.Second,
+			Max:  60 * time.Second,
+		},
+		Transport: httpcommon.DefaultHTTPTransportSettings(),
+	}
+}
+
+func (c *httpConfig) Validate() error {
+	switch c.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return fmt.Errorf("http method %q not supported, must be one of POST, PUT or PATCH", c.Method)
+	}
+
+	switch c.BatchFormat {
+	case batchFormatNDJSON, batchFormatJSONArray:
+	default:
+		return fmt.Errorf("batch_format %q not supported, must be one of %q or %q", c.BatchFormat, batchFormatNDJSON, batchFormatJSONArray)
+	}
+
+	return nil
+}
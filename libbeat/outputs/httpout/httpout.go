@@ -0,0 +1,98 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package httpout implements a generic HTTP output that sends batches of
+// encoded events to an HTTP endpoint, such as a webhook, Splunk HEC or a
+// Loki push endpoint.
+package httpout
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/beats/v7/libbeat/outputs/codec"
+	"github.com/elastic/beats/v7/libbeat/outputs/codec/json"
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+const logSelector = "http"
+
+func init() {
+	outputs.RegisterType("http", makeHTTP)
+}
+
+func makeHTTP(
+	_ outputs.IndexManager,
+	beatInfo beat.Info,
+	observer outputs.Observer,
+	cfg *config.C,
+) (outputs.Group, error) {
+	log := beatInfo.Logger.Named(logSelector)
+
+	httpCfg := defaultConfig()
+	if err := cfg.Unpack(&httpCfg); err != nil {
+		return outputs.Fail(err)
+	}
+
+	hosts, err := outputs.ReadHostList(cfg)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+
+	clients := make([]outputs.NetworkClient, len(hosts))
+	for i, host := range hosts {
+		url, err := common.MakeURL(httpCfg.Protocol, httpCfg.Path, host, 0)
+		if err != nil {
+			return outputs.Fail(fmt.Errorf("invalid http output host %q: %w", host, err))
+		}
+
+		// Codecs keep internal buffers, every client needs its own.
+		var enc codec.Codec
+		if httpCfg.Codec.Namespace.IsSet() {
+			enc, err = codec.CreateEncoder(beatInfo, httpCfg.Codec)
+			if err != nil {
+				return outputs.Fail(err)
+			}
+		} else {
+			enc = json.New(beatInfo.Version, json.Config{})
+		}
+
+		client, err := newClient(clientSettings{
+			url:       url,
+			config:    httpCfg,
+			codec:     enc,
+			index:     beatInfo.Beat,
+			userAgent: beatInfo.UserAgent,
+			observer:  observer,
+		}, log)
+		if err != nil {
+			return outputs.Fail(err)
+		}
+		clients[i] = outputs.WithBackoff(client, httpCfg.Backoff.Init, httpCfg.Backoff.Max)
+	}
+
+	return outputs.SuccessNet(httpCfg.Queue,
+		httpCfg.LoadBalance,
+		httpCfg.BulkMaxSize,
+		httpCfg.MaxRetries,
+		nil,
+		beatInfo.Logger,
+		beatInfo.Paths,
+		outputs.NumofWorker(cfg), clients)
+}
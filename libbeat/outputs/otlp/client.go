@@ -0,0 +1,166 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/testing"
+)
+
+// exporter sends a single OTLP logs export request to one endpoint.
+type exporter interface {
+	Connect(ctx context.Context) error
+	Export(ctx context.Context, req plogotlp.ExportRequest) (plogotlp.ExportResponse, error)
+	Close() error
+	String() string
+}
+
+// exportError is returned by exporters when the endpoint answered the
+// request with a failure. It records how the failed events must be handled.
+type exportError struct {
+	err error
+	// permanent is set if sending the same request again cannot succeed.
+	permanent bool
+	// tooLarge is set if the request was rejected because of its size.
+	tooLarge bool
+}
+
+func (e *exportError) Error() string { return e.err.Error() }
+func (e *exportError) Unwrap() error { return e.err }
+
+type client struct {
+	log      *logp.Logger
+	observer outputs.Observer
+	beat     beat.Info
+	exporter exporter
+}
+
+func newClient(exp exporter, beatInfo beat.Info, observer outputs.Observer, log *logp.Logger) *client {
+	return &client{
+		log:      log,
+		observer: observer,
+		beat:     beatInfo,
+		exporter: exp,
+	}
+}
+
+func (c *client) Connect(ctx context.Context) error {
+	return c.exporter.Connect(ctx)
+}
+
+func (c *client) Close() error {
+	return c.exporter.Close()
+}
+
+func (c *client) String() string {
+	return "otlp(" + c.exporter.String() + ")"
+}
+
+func (c *client) Test(d testing.Driver) {
+	d.Run("otlp: "+c.exporter.String(), func(d testing.Driver) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		d.Fatal("connection", c.exporter.Connect(ctx))
+		_, err := c.exporter.Export(ctx, plogotlp.NewExportRequest())
+		d.Fatal("export empty request", err)
+	})
+}
+
+// Publish converts the batch into one OTLP export request. Events are
+// acknowledged once the endpoint accepted the request; records the endpoint
+// reports as rejected in a partial success response are counted as permanent
+// errors and not retried, as required by the OTLP specification.
+func (c *client) Publish(ctx context.Context, batch publisher.Batch) error {
+	events := batch.Events()
+	c.observer.NewBatch(len(events))
+
+	logs, okEvents := c.eventsToLogs(events)
+	c.observer.PermanentErrors(len(events) - len(okEvents))
+	if len(okEvents) == 0 {
+		batch.ACK()
+		return nil
+	}
+
+	begin := time.Now()
+	resp, err := c.exporter.Export(ctx, plogotlp.NewExportRequestFromLogs(logs))
+	if err != nil {
+		return c.handleExportError(batch, okEvents, err)
+	}
+	duration := time.Since(begin)
+	c.observer.ReportLatency(duration)
+	c.log.Debugf("%d events have been sent to %s in %v.", len(okEvents), c.exporter, duration)
+
+	acked := len(okEvents)
+	if rejected := int(resp.PartialSuccess().RejectedLogRecords()); rejected > 0 {
+		if rejected > acked {
+			rejected = acked
+		}
+		c.log.Warnf("OTLP endpoint rejected %d of %d log records: %s",
+			rejected, acked, resp.PartialSuccess().ErrorMessage())
+		c.observer.PermanentErrors(rejected)
+		acked -= rejected
+	}
+	c.observer.AckedEvents(acked)
+	batch.ACK()
+	return nil
+}
+
+func (c *client) handleExportError(batch publisher.Batch, events []publisher.Event, err error) error {
+	var expErr *exportError
+	if !errors.As(err, &expErr) {
+		// Connection level error, retry all events and report the error so
+		// the connection is re-established with backoff.
+		c.log.Errorf("Failed to export %d events: %v", len(events), err)
+		batch.RetryEvents(events)
+		c.observer.RetryableErrors(len(events))
+		return err
+	}
+
+	switch {
+	case expErr.tooLarge:
+		if batch.SplitRetry() {
+			c.observer.BatchSplit()
+			c.observer.RetryableErrors(len(events))
+		} else {
+			c.log.Errorf("Failed to export a single event that exceeds the endpoint request size limit, dropping: %v", err)
+			batch.Drop()
+			c.observer.PermanentErrors(len(events))
+		}
+		return nil
+	case expErr.permanent:
+		c.log.Errorf("OTLP endpoint permanently rejected %d events, dropping: %v", len(events), err)
+		batch.Drop()
+		c.observer.PermanentErrors(len(events))
+		return nil
+	default:
+		c.log.Errorf("Failed to export %d events, retrying: %v", len(events), err)
+		batch.RetryEvents(events)
+		c.observer.RetryableErrors(len(events))
+		return fmt.Errorf("otlp export failed: %w", err)
+	}
+}
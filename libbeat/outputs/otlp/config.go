@@ -0,0 +1,100 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+)
+
+const (
+	protocolHTTP = "http"
+	protocolGRPC = "grpc"
+
+	encodingProtobuf = "protobuf"
+	encodingJSON     = "json"
+
+	compressionGzip = "gzip"
+	compressionNone = "none"
+
+	defaultHTTPPort = 4318
+	defaultGRPCPort = 4317
+)
+
+type otlpConfig struct {
+	Protocol    string            `config:"protocol"`
+	Path        string            `config:"path"`
+	Encoding    string            `config:"encoding"`
+	Compression string            `config:"compression"`
+	Headers     map[string]string `config:"headers"`
+	LoadBalance bool              `config:"loadbalance"`
+	BulkMaxSize int               `config:"bulk_max_size"`
+	MaxRetries  int               `config:"max_retries"`
+	Backoff     backoff           `config:"backoff"`
+	Queue       config.Namespace  `config:"queue"`
+
+	Transport httpcommon.HTTPTransportSettings `config:",inline"`
+}
+
+type backoff struct {
+	Init time.Duration
+	Max  time.Duration
+}
+
+var defaultConfig = otlpConfig{
+	Protocol:    protocolHTTP,
+	Path:        "/v1/logs",
+	Encoding:    encodingProtobuf,
+	Compression: compressionGzip,
+	LoadBalance: true,
+	BulkMaxSize: 1600,
+	MaxRetries:  3,
+	Backoff: backoff{
+		Init: 1 * time.Second,
+		Max:  60 * time.Second,
+	},
+	Transport: httpcommon.DefaultHTTPTransportSettings(),
+}
+
+func (c *otlpConfig) Validate() error {
+	switch c.Protocol {
+	case protocolHTTP, protocolGRPC:
+	default:
+		return fmt.Errorf("otlp protocol %q not supported, must be one of %q or %q", c.Protocol, protocolHTTP, protocolGRPC)
+	}
+
+	switch c.Encoding {
+	case encodingProtobuf, encodingJSON:
+	default:
+		return fmt.Errorf("otlp encoding %q not supported, must be one of %q or %q", c.Encoding, encodingProtobuf, encodingJSON)
+	}
+	if c.Protocol == protocolGRPC && c.Encoding != encodingProtobuf {
+		return fmt.Errorf("otlp encoding %q is only supported with the %q protocol", c.Encoding, protocolHTTP)
+	}
+
+	switch c.Compression {
+	case compressionGzip, compressionNone:
+	default:
+		return fmt.Errorf("otlp compression %q not supported, must be one of %q or %q", c.Compression, compressionGzip, compressionNone)
+	}
+
+	return nil
+}
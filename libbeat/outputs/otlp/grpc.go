@@ -0,0 +1,158 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+// grpcExporter sends export requests to an OTLP/gRPC endpoint.
+type grpcExporter struct {
+	target   string
+	timeout  time.Duration
+	headers  metadata.MD
+	dialOpts []grpc.DialOption
+	callOpts []grpc.CallOption
+
+	conn   *grpc.ClientConn
+	client plogotlp.GRPCClient
+}
+
+func newGRPCExporter(target string, secure bool, cfg otlpConfig, log *logp.Logger) (*grpcExporter, error) {
+	tls, err := tlscommon.LoadTLSConfig(cfg.Transport.TLS, log)
+	if err != nil {
+		return nil, err
+	}
+
+	creds := insecure.NewCredentials()
+	if tls != nil || secure {
+		if tls == nil {
+			tls = &tlscommon.TLSConfig{}
+		}
+		host := target
+		if h, _, err := net.SplitHostPort(target); err == nil {
+			host = h
+		}
+		creds = credentials.NewTLS(tls.BuildModuleClientConfig(host))
+	}
+
+	headers := map[string]string{}
+	if cfg.Transport.Auth != nil {
+		maps.Copy(headers, cfg.Transport.Auth.ToMap())
+	}
+	maps.Copy(headers, cfg.Headers)
+
+	var callOpts []grpc.CallOption
+	if cfg.Compression == compressionGzip {
+		callOpts = append(callOpts, grpc.UseCompressor(gzip.Name))
+	}
+
+	return &grpcExporter{
+		target:   target,
+		timeout:  cfg.Transport.Timeout,
+		headers:  metadata.New(headers),
+		dialOpts: []grpc.DialOption{grpc.WithTransportCredentials(creds)},
+		callOpts: callOpts,
+	}, nil
+}
+
+func (e *grpcExporter) Connect(context.Context) error {
+	if e.conn != nil {
+		return nil
+	}
+	conn, err := grpc.NewClient(e.target, e.dialOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to create gRPC client for %s: %w", e.target, err)
+	}
+	e.conn = conn
+	e.client = plogotlp.NewGRPCClient(conn)
+	return nil
+}
+
+func (e *grpcExporter) Close() error {
+	if e.conn == nil {
+		return nil
+	}
+	err := e.conn.Close()
+	e.conn = nil
+	e.client = nil
+	return err
+}
+
+func (e *grpcExporter) String() string {
+	return e.target
+}
+
+func (e *grpcExporter) Export(ctx context.Context, req plogotlp.ExportRequest) (plogotlp.ExportResponse, error) {
+	if e.client == nil {
+		return plogotlp.NewExportResponse(), fmt.Errorf("gRPC client for %s is not connected", e.target)
+	}
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+	if len(e.headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, e.headers)
+	}
+
+	resp, err := e.client.Export(ctx, req, e.callOpts...)
+	if err != nil {
+		return resp, classifyGRPCStatus(err)
+	}
+	return resp, nil
+}
+
+// classifyGRPCStatus converts a failed call into an exportError, following
+// the retry rules of the OTLP/gRPC specification.
+func classifyGRPCStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	switch st.Code() {
+	case codes.Canceled,
+		codes.DeadlineExceeded,
+		codes.Aborted,
+		codes.OutOfRange,
+		codes.ResourceExhausted,
+		codes.Unavailable,
+		codes.DataLoss:
+		// Transient, retry after backoff. The connection is also re-created
+		// by the backoff client, so return the error unwrapped.
+		return err
+	default:
+		return &exportError{err: err, permanent: true}
+	}
+}
@@ -0,0 +1,177 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+)
+
+const (
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeJSON     = "application/json"
+
+	// maxResponseBodySize bounds how much of a response is read, error
+	// responses are only used for logging.
+	maxResponseBodySize = 64 * 1024
+)
+
+// httpExporter sends export requests to an OTLP/HTTP endpoint.
+type httpExporter struct {
+	url      string
+	encoding string
+	gzip     bool
+	headers  map[string]string
+	client   *http.Client
+}
+
+func newHTTPExporter(
+	url string,
+	cfg otlpConfig,
+	userAgent string,
+	observer outputs.Observer,
+	log *logp.Logger,
+) (*httpExporter, error) {
+	client, err := cfg.Transport.Client(
+		httpcommon.WithLogger(log),
+		httpcommon.WithIOStats(observer),
+		httpcommon.WithKeepaliveSettings{IdleConnTimeout: cfg.Transport.IdleConnTimeout},
+		httpcommon.WithHeaderRoundTripper(map[string]string{"User-Agent": userAgent}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpExporter{
+		url:      url,
+		encoding: cfg.Encoding,
+		gzip:     cfg.Compression == compressionGzip,
+		headers:  cfg.Headers,
+		client:   client,
+	}, nil
+}
+
+func (e *httpExporter) Connect(context.Context) error {
+	return nil
+}
+
+func (e *httpExporter) Close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+func (e *httpExporter) String() string {
+	return e.url
+}
+
+func (e *httpExporter) Export(ctx context.Context, req plogotlp.ExportRequest) (plogotlp.ExportResponse, error) {
+	resp := plogotlp.NewExportResponse()
+
+	var body []byte
+	var err error
+	contentType := contentTypeProtobuf
+	if e.encoding == encodingJSON {
+		contentType = contentTypeJSON
+		body, err = req.MarshalJSON()
+	} else {
+		body, err = req.MarshalProto()
+	}
+	if err != nil {
+		return resp, &exportError{err: fmt.Errorf("failed to encode export request: %w", err), permanent: true}
+	}
+
+	if e.gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(body); err != nil {
+			return resp, err
+		}
+		if err := zw.Close(); err != nil {
+			return resp, err
+		}
+		body = buf.Bytes()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return resp, err
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	if e.gzip {
+		httpReq.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range e.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	httpResp, err := e.client.Do(httpReq)
+	if err != nil {
+		return resp, err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(httpResp.Body, maxResponseBodySize))
+	if err != nil {
+		return resp, err
+	}
+
+	if httpResp.StatusCode >= 200 && httpResp.StatusCode < 300 {
+		if len(respBody) == 0 {
+			return resp, nil
+		}
+		// The request was accepted, an unreadable body only loses the
+		// partial success details.
+		if strings.HasPrefix(httpResp.Header.Get("Content-Type"), contentTypeJSON) {
+			_ = resp.UnmarshalJSON(respBody)
+		} else {
+			_ = resp.UnmarshalProto(respBody)
+		}
+		return resp, nil
+	}
+
+	return resp, classifyHTTPStatus(httpResp.StatusCode, respBody)
+}
+
+// classifyHTTPStatus converts a failed response into an exportError,
+// following the retry rules of the OTLP/HTTP specification: only 429, 502,
+// 503 and 504 are retryable, everything else is permanent.
+func classifyHTTPStatus(status int, body []byte) error {
+	err := &exportError{err: fmt.Errorf("%d %s: %s", status, http.StatusText(status), bytes.TrimSpace(body))}
+	switch status {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+	case http.StatusRequestEntityTooLarge:
+		err.tooLarge = true
+	default:
+		err.permanent = true
+	}
+	return err
+}
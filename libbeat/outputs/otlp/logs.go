@@ -0,0 +1,133 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/otel/otelmap"
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const scopeName = "github.com/elastic/beats/v7/libbeat/outputs/otlp"
+
+// newLogs creates an empty plog.Logs with the resource and scope describing
+// the publishing Beat, and returns the record slice events are appended to.
+func newLogs(info beat.Info, capacity int) (plog.Logs, plog.LogRecordSlice) {
+	logs := plog.NewLogs()
+	resourceLogs := logs.ResourceLogs().AppendEmpty()
+	resource := resourceLogs.Resource().Attributes()
+	resource.PutStr("service.name", info.Beat)
+	resource.PutStr("service.version", info.Version)
+	if info.Name != "" {
+		resource.PutStr("host.name", info.Name)
+	}
+	if info.ID.String() != "" {
+		resource.PutStr("service.instance.id", info.ID.String())
+	}
+
+	scopeLogs := resourceLogs.ScopeLogs().AppendEmpty()
+	scopeLogs.Scope().SetName(scopeName)
+	scopeLogs.Scope().SetVersion(info.Version)
+	// Same mapping mode the Beat receivers use, so the elasticsearchexporter
+	// indexes the body as the original Beats document.
+	scopeLogs.Scope().Attributes().PutStr("elastic.mapping.mode", "bodymap")
+
+	records := scopeLogs.LogRecords()
+	records.EnsureCapacity(capacity)
+	return logs, records
+}
+
+// eventToLogRecord encodes a single event into dst. The full event, including
+// @timestamp and @metadata, is stored as a map in the record body, so the
+// collector can forward the original document unchanged.
+func eventToLogRecord(dst plog.LogRecord, event *beat.Event, info beat.Info) error {
+	dst.SetTimestamp(pcommon.NewTimestampFromTime(event.Timestamp))
+	dst.SetObservedTimestamp(pcommon.NewTimestampFromTime(event.Timestamp))
+
+	fields := event.Fields
+	if fields == nil {
+		fields = mapstr.M{}
+	}
+
+	if level, err := fields.GetValue("log.level"); err == nil {
+		if s, ok := level.(string); ok {
+			dst.SetSeverityText(s)
+			dst.SetSeverityNumber(severityNumber(s))
+		}
+	}
+
+	body := dst.Body().SetEmptyMap()
+	body.EnsureCapacity(len(fields) + 2)
+	if err := otelmap.FromMapstr(body, fields); err != nil {
+		return err
+	}
+	body.PutStr("@timestamp", otelmap.FormatTimestamp(event.Timestamp))
+
+	meta := body.PutEmptyMap("@metadata")
+	if err := otelmap.FromMapstr(meta, event.Meta); err != nil {
+		return err
+	}
+	meta.PutStr("beat", info.Beat)
+	meta.PutStr("version", info.Version)
+	return nil
+}
+
+// eventsToLogs converts the batch events into a plog.Logs. Events that cannot
+// be converted are left out; the returned slice holds the events that made
+// it into the request, in order.
+func (c *client) eventsToLogs(events []publisher.Event) (plog.Logs, []publisher.Event) {
+	logs, records := newLogs(c.beat, len(events))
+	okEvents := events[:0]
+	for i := range events {
+		record := plog.NewLogRecord()
+		if err := eventToLogRecord(record, &events[i].Content, c.beat); err != nil {
+			c.log.Errorf("Failed to convert event to an OTLP log record, dropping event: %v", err)
+			continue
+		}
+		record.MoveTo(records.AppendEmpty())
+		okEvents = append(okEvents, events[i])
+	}
+	return logs, okEvents
+}
+
+// severityNumber maps the common textual log levels to the OTLP severity
+// numbers. Unknown levels are reported as unspecified.
+func severityNumber(level string) plog.SeverityNumber {
+	switch strings.ToLower(level) {
+	case "trace":
+		return plog.SeverityNumberTrace
+	case "debug":
+		return plog.SeverityNumberDebug
+	case "info", "informational", "notice":
+		return plog.SeverityNumberInfo
+	case "warn", "warning":
+		return plog.SeverityNumberWarn
+	case "error", "err":
+		return plog.SeverityNumberError
+	case "critical", "crit", "alert", "fatal", "emergency", "emerg":
+		return plog.SeverityNumberFatal
+	default:
+		return plog.SeverityNumberUnspecified
+	}
+}
@@ -0,0 +1,107 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package otlp implements an output that ships events as OpenTelemetry log
+// records to any OTLP/HTTP or OTLP/gRPC endpoint, such as an OpenTelemetry
+// collector.
+package otlp
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+const logSelector = "otlp"
+
+func init() {
+	outputs.RegisterType("otlp", makeOTLP)
+}
+
+func makeOTLP(
+	_ outputs.IndexManager,
+	beatInfo beat.Info,
+	observer outputs.Observer,
+	cfg *config.C,
+) (outputs.Group, error) {
+	log := beatInfo.Logger.Named(logSelector)
+
+	otlpCfg := defaultConfig
+	if err := cfg.Unpack(&otlpCfg); err != nil {
+		return outputs.Fail(err)
+	}
+
+	hosts, err := outputs.ReadHostList(cfg)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+
+	clients := make([]outputs.NetworkClient, len(hosts))
+	for i, host := range hosts {
+		var exp exporter
+		switch otlpCfg.Protocol {
+		case protocolGRPC:
+			target, secure, err := grpcTarget(host)
+			if err != nil {
+				return outputs.Fail(err)
+			}
+			exp, err = newGRPCExporter(target, secure, otlpCfg, log)
+			if err != nil {
+				return outputs.Fail(err)
+			}
+		default:
+			endpoint, err := common.MakeURL("", otlpCfg.Path, host, defaultHTTPPort)
+			if err != nil {
+				return outputs.Fail(fmt.Errorf("invalid otlp host %q: %w", host, err))
+			}
+			exp, err = newHTTPExporter(endpoint, otlpCfg, beatInfo.UserAgent, observer, log)
+			if err != nil {
+				return outputs.Fail(err)
+			}
+		}
+
+		client := newClient(exp, beatInfo, observer, log)
+		clients[i] = outputs.WithBackoff(client, otlpCfg.Backoff.Init, otlpCfg.Backoff.Max)
+	}
+
+	return outputs.SuccessNet(otlpCfg.Queue,
+		otlpCfg.LoadBalance,
+		otlpCfg.BulkMaxSize,
+		otlpCfg.MaxRetries,
+		nil,
+		beatInfo.Logger,
+		beatInfo.Paths,
+		outputs.NumofWorker(cfg), clients)
+}
+
+// grpcTarget turns a configured host into a gRPC dial target. The scheme,
+// if present, only selects whether TLS is used.
+func grpcTarget(host string) (string, bool, error) {
+	raw, err := common.MakeURL("", "", host, defaultGRPCPort)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid otlp host %q: %w", host, err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid otlp host %q: %w", host, err)
+	}
+	return u.Host, u.Scheme == "https", nil
+}
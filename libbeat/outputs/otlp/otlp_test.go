@@ -0,0 +1,181 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otlp
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/outputs"
+	"github.com/elastic/beats/v7/libbeat/outputs/outest"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		cfg   map[string]any
+		valid bool
+	}{
+		"defaults":             {cfg: map[string]any{}, valid: true},
+		"grpc":                 {cfg: map[string]any{"protocol": "grpc"}, valid: true},
+		"json over http":       {cfg: map[string]any{"encoding": "json"}, valid: true},
+		"json over grpc":       {cfg: map[string]any{"protocol": "grpc", "encoding": "json"}, valid: false},
+		"unknown protocol":     {cfg: map[string]any{"protocol": "thrift"}, valid: false},
+		"unknown compression":  {cfg: map[string]any{"compression": "lz4"}, valid: false},
+		"disabled compression": {cfg: map[string]any{"compression": "none"}, valid: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := defaultConfig
+			err := config.MustNewConfigFrom(tc.cfg).Unpack(&c)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestPublish(t *testing.T) {
+	tests := map[string]struct {
+		status   int
+		response func() []byte
+		signal   outest.BatchSignalTag
+	}{
+		"accepted": {
+			status: http.StatusOK,
+			signal: outest.BatchACK,
+		},
+		"partial success": {
+			status: http.StatusOK,
+			response: func() []byte {
+				resp := plogotlp.NewExportResponse()
+				resp.PartialSuccess().SetRejectedLogRecords(1)
+				b, _ := resp.MarshalProto()
+				return b
+			},
+			signal: outest.BatchACK,
+		},
+		"throttled": {
+			status: http.StatusTooManyRequests,
+			signal: outest.BatchRetryEvents,
+		},
+		"unavailable": {
+			status: http.StatusServiceUnavailable,
+			signal: outest.BatchRetryEvents,
+		},
+		"bad request": {
+			status: http.StatusBadRequest,
+			signal: outest.BatchDrop,
+		},
+		"too large": {
+			status: http.StatusRequestEntityTooLarge,
+			signal: outest.BatchSplitRetry,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var received plogotlp.ExportRequest
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v1/logs", r.URL.Path)
+				assert.Equal(t, contentTypeProtobuf, r.Header.Get("Content-Type"))
+				assert.Equal(t, "bar", r.Header.Get("X-Foo"))
+
+				zr, err := gzip.NewReader(r.Body)
+				require.NoError(t, err)
+				body, err := io.ReadAll(zr)
+				require.NoError(t, err)
+				received = plogotlp.NewExportRequest()
+				require.NoError(t, received.UnmarshalProto(body))
+
+				w.Header().Set("Content-Type", contentTypeProtobuf)
+				w.WriteHeader(tc.status)
+				if tc.response != nil {
+					_, _ = w.Write(tc.response())
+				}
+			}))
+			defer srv.Close()
+
+			c := newTestClient(t, srv.URL)
+			ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+			batch := outest.NewBatch(
+				beat.Event{Timestamp: ts, Fields: mapstr.M{"message": "first", "log": mapstr.M{"level": "error"}}},
+				beat.Event{Timestamp: ts, Fields: mapstr.M{"message": "second"}},
+			)
+
+			err := c.Publish(context.Background(), batch)
+			if tc.signal == outest.BatchRetryEvents {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			require.Len(t, batch.Signals, 1)
+			assert.Equal(t, tc.signal, batch.Signals[0].Tag)
+
+			records := received.Logs().ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+			require.Equal(t, 2, records.Len())
+			first := records.At(0)
+			assert.Equal(t, "error", first.SeverityText())
+			assert.Equal(t, ts, first.Timestamp().AsTime())
+			msg, ok := first.Body().Map().Get("message")
+			require.True(t, ok)
+			assert.Equal(t, "first", msg.Str())
+			tsField, ok := first.Body().Map().Get("@timestamp")
+			require.True(t, ok)
+			assert.Equal(t, "2026-01-02T03:04:05.000Z", tsField.Str())
+		})
+	}
+}
+
+func TestMakeOTLP(t *testing.T) {
+	cfg := config.MustNewConfigFrom(map[string]any{
+		"hosts":    []string{"localhost:4317", "https://collector:4317"},
+		"protocol": "grpc",
+	})
+	info := beat.Info{Beat: "test", Logger: logptest.NewTestingLogger(t, "")}
+
+	group, err := makeOTLP(nil, info, outputs.NewNilObserver(), cfg)
+	require.NoError(t, err)
+	assert.Len(t, group.Clients, 2)
+	assert.Equal(t, 1600, group.BatchSize)
+}
+
+func newTestClient(t *testing.T, url string) *client {
+	t.Helper()
+	logger := logptest.NewTestingLogger(t, "")
+	cfg := defaultConfig
+	cfg.Headers = map[string]string{"X-Foo": "bar"}
+	exp, err := newHTTPExporter(url+cfg.Path, cfg, "test", outputs.NewNilObserver(), logger)
+	require.NoError(t, err)
+	return newClient(exp, beat.Info{Beat: "test", Version: "9.9.9"}, outputs.NewNilObserver(), logger)
+}
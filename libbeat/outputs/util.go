@@ -25,6 +25,7 @@ import (
 	"github.com/elastic/beats/v7/libbeat/publisher/queue"
 	"github.com/elastic/beats/v7/libbeat/publisher/queue/diskqueue"
 	"github.com/elastic/beats/v7/libbeat/publisher/queue/memqueue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/snapshot"
 	"github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
 	"github.com/elastic/elastic-agent-libs/paths"
@@ -53,7 +54,11 @@ func Success(
 			if err != nil {
 				return Group{}, fmt.Errorf("unable to get memory queue settings: %w", err)
 			}
-			q = memqueue.FactoryForSettings[publisher.Event](settings)
+			snapshotSettings, err := snapshot.SettingsForUserConfig(cfg.Config())
+			if err != nil {
+				return Group{}, fmt.Errorf("unable to get memory queue snapshot settings: %w", err)
+			}
+			q = snapshot.FactoryForSettings(snapshotSettings, beatPaths, memqueue.FactoryForSettings[publisher.Event](settings))
 		case diskqueue.QueueType:
 			if management.UnderAgent() {
 				logger = logger.Named("output")
@@ -108,6 +108,9 @@ func noop() {}
 // Store is the interface implemented by metadata providers.
 type Store interface {
 	Put(key string, val any) error
+	// PutNew stores val for key only if key has no unexpired value,
+	// and returns whether val was stored.
+	PutNew(key string, val any) (bool, error)
 	Get(key string) (any, error)
 	Delete(key string) error
 
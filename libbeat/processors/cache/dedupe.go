@@ -0,0 +1,202 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cache
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/fingerprint"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+const (
+	dedupeName    = "dedupe"
+	dedupeLogName = "processor." + dedupeName
+)
+
+func init() {
+	// We cannot use this as a JS plugin as it is stateful and includes a Close method.
+	processors.RegisterPlugin(dedupeName, NewDedupe)
+}
+
+// dedupeInstanceID is used to assign each dedupe instance a unique
+// monitoring namespace.
+var dedupeInstanceID atomic.Uint32
+
+type dedupeConfig struct {
+	// Fields are the fields identifying the duplicate events.
+	Fields []string `config:"fields" validate:"required"`
+
+	// Method is the fingerprint hash method of the fields.
+	Method string `config:"method"`
+
+	// TTL is the time window during which events with the same
+	// fields are dropped.
+	TTL time.Duration `config:"ttl" validate:"required,positive,nonzero"`
+
+	Store *storeConfig `config:"backend" validate:"required"`
+
+	// IgnoreMissing: Ignore the fields missing from the events.
+	IgnoreMissing bool `config:"ignore_missing"`
+}
+
+func defaultDedupeConfig() dedupeConfig {
+	return dedupeConfig{
+		Method: "sha256",
+	}
+}
+
+// dedupe is a processor dropping the events that have the same fields as an
+// event seen within the TTL.
+type dedupe struct {
+	config dedupeConfig
+	hasher *fingerprint.Hasher
+	store  Store
+	cancel func()
+	log    *logp.Logger
+
+	passed  *monitoring.Int // Number of events passed.
+	dropped *monitoring.Int // Number of duplicate events dropped.
+	errors  *monitoring.Int // Number of events that failed to be fingerprinted.
+}
+
+// NewDedupe constructs a new dedupe processor. The resulting processor
+// implements `Close()` to release the cache resources.
+func NewDedupe(cfg *conf.C, log *logp.Logger) (beat.Processor, error) {
+	config := defaultDedupeConfig()
+	err := cfg.Unpack(&config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack the %s configuration: %w", dedupeName, err)
+	}
+	hasher, err := fingerprint.NewHasher(config.Fields, config.Method, config.IgnoreMissing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the %s fingerprint: %w", dedupeName, err)
+	}
+
+	// Logging and metrics (each processor instance has a unique ID).
+	var (
+		id      = int(dedupeInstanceID.Add(1))
+		metrics = monitoring.Default.GetOrCreateRegistry(dedupeLogName+"."+strconv.Itoa(id), monitoring.DoNotReport)
+	)
+	log = log.Named(dedupeLogName).With("instance_id", id)
+	log.Warn(cfgwarn.Beta("The " + dedupeName + " processor is beta."))
+	log.Infow("dedupe processor created", "config", config)
+
+	return newDedupe(config, hasher, metrics, log), nil
+}
+
+func newDedupe(config dedupeConfig, hasher *fingerprint.Hasher, metrics *monitoring.Registry, log *logp.Logger) *dedupe {
+	return &dedupe{
+		config:  config,
+		hasher:  hasher,
+		store:   nil, // initialized in SetPaths
+		log:     log,
+		passed:  monitoring.NewInt(metrics, "passed"),
+		dropped: monitoring.NewInt(metrics, "dropped"),
+		errors:  monitoring.NewInt(metrics, "errors"),
+	}
+}
+
+// Run drops the event if an event with the same fields was seen within the
+// TTL.
+func (p *dedupe) Run(event *beat.Event) (*beat.Event, error) {
+	if p.store == nil {
+		return event, errors.New("dedupe processor store not initialized")
+	}
+
+	if p.config.IgnoreMissing && !p.hasAnyField(event) {
+		// All the events without the fields would have the same fingerprint,
+		// and all but the first one would be dropped.
+		p.passed.Inc()
+		return event, nil
+	}
+
+	sum, err := p.hasher.Sum(event)
+	if err != nil {
+		p.errors.Inc()
+		return event, fmt.Errorf("error applying %s processor: %w", dedupeName, err)
+	}
+	key := hex.EncodeToString(sum)
+
+	stored, err := p.store.PutNew(key, true)
+	if err != nil {
+		p.errors.Inc()
+		return event, fmt.Errorf("error applying %s processor: failed to put '%s' in %s: %w", dedupeName, key, p.store, err)
+	}
+	if !stored {
+		p.log.Debugw("dropping duplicate event", "backend_id", p.store, "key", key)
+		p.dropped.Inc()
+		return nil, nil
+	}
+	p.passed.Inc()
+	return event, nil
+}
+
+// hasAnyField returns true if the event has one of the configured fields.
+func (p *dedupe) hasAnyField(event *beat.Event) bool {
+	for _, k := range p.config.Fields {
+		if found, _ := event.Fields.HasKey(k); found {
+			return true
+		}
+	}
+	return false
+}
+
+// SetPaths initializes the cache store with the provided paths configuration.
+// This method must be called before the processor can be used.
+func (p *dedupe) SetPaths(path *paths.Path) error {
+	// The stores take their TTL and capacity from a put operation.
+	cfg := config{
+		Put:   &putConfig{TTL: &p.config.TTL},
+		Store: p.config.Store,
+	}
+	src, cancel, err := getStoreFor(cfg, p.log, path)
+	if err != nil {
+		return fmt.Errorf("dedupe processor could not create store: %w", err)
+	}
+
+	p.store = src
+	p.cancel = cancel
+
+	p.log.Infow("initialized dedupe processor", "details", p)
+	return nil
+}
+
+func (p *dedupe) Close() error {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	return nil
+}
+
+// String returns the processor representation formatted as a string
+func (p *dedupe) String() string {
+	return fmt.Sprintf("%s=[store_id=%s, fields=%v, method=%s, ttl=%v, ignore_missing=%t]",
+		dedupeName, p.store, p.config.Fields, p.config.Method, p.config.TTL, p.config.IgnoreMissing)
+}
@@ -0,0 +1,268 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+func newTestDedupe(t *testing.T, cfg mapstr.M, path *paths.Path) *dedupe {
+	t.Helper()
+
+	config, err := conf.NewConfigFrom(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewDedupe(config, logptest.NewTestingLogger(t, ""))
+	if err != nil {
+		t.Fatalf("unexpected error from NewDedupe: %v", err)
+	}
+	d, ok := p.(*dedupe)
+	if !ok {
+		t.Fatal("processor is not a *dedupe")
+	}
+	err = d.SetPaths(path)
+	if err != nil {
+		t.Fatalf("unexpected error from SetPaths: %v", err)
+	}
+	return d
+}
+
+func testPaths(t *testing.T) *paths.Path {
+	tmpDir := t.TempDir()
+	return &paths.Path{
+		Home:   tmpDir,
+		Config: tmpDir,
+		Data:   tmpDir,
+		Logs:   tmpDir,
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	d := newTestDedupe(t, mapstr.M{
+		"fields": []string{"event.id", "host.name"},
+		"ttl":    "1h",
+		"backend": mapstr.M{
+			"memory": mapstr.M{"id": "dedupe_test"},
+		},
+	}, testPaths(t))
+	defer d.Close()
+
+	steps := []struct {
+		event   mapstr.M
+		dropped bool
+	}{
+		{event: mapstr.M{"event": mapstr.M{"id": "1"}, "host": mapstr.M{"name": "a"}, "message": "first"}},
+		{event: mapstr.M{"event": mapstr.M{"id": "1"}, "host": mapstr.M{"name": "a"}, "message": "redelivered"}, dropped: true},
+		{event: mapstr.M{"event": mapstr.M{"id": "1"}, "host": mapstr.M{"name": "b"}}},
+		{event: mapstr.M{"event": mapstr.M{"id": "2"}, "host": mapstr.M{"name": "a"}}},
+		{event: mapstr.M{"event": mapstr.M{"id": "2"}, "host": mapstr.M{"name": "a"}}, dropped: true},
+	}
+	for i, step := range steps {
+		got, err := d.Run(&beat.Event{Fields: step.event})
+		if err != nil {
+			t.Fatalf("unexpected error from Run %d: %v", i, err)
+		}
+		if step.dropped != (got == nil) {
+			t.Errorf("unexpected result %d: dropped=%t want dropped=%t", i, got == nil, step.dropped)
+		}
+	}
+	if got := d.passed.Get(); got != 3 {
+		t.Errorf("unexpected passed count: got:%d want:3", got)
+	}
+	if got := d.dropped.Get(); got != 2 {
+		t.Errorf("unexpected dropped count: got:%d want:2", got)
+	}
+}
+
+func TestDedupeTTL(t *testing.T) {
+	d := newTestDedupe(t, mapstr.M{
+		"fields": []string{"message"},
+		"ttl":    "50ms",
+		"backend": mapstr.M{
+			"memory": mapstr.M{"id": "dedupe_ttl_test"},
+		},
+	}, testPaths(t))
+	defer d.Close()
+
+	run := func() *beat.Event {
+		got, err := d.Run(&beat.Event{Fields: mapstr.M{"message": "hello"}})
+		if err != nil {
+			t.Fatalf("unexpected error from Run: %v", err)
+		}
+		return got
+	}
+	if run() == nil {
+		t.Fatal("first event was dropped")
+	}
+	if run() != nil {
+		t.Fatal("duplicate event within the TTL was not dropped")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if run() == nil {
+		t.Fatal("duplicate event after the TTL was dropped")
+	}
+}
+
+func TestDedupeFileStoreRestart(t *testing.T) {
+	path := testPaths(t)
+	cfg := mapstr.M{
+		"fields": []string{"message"},
+		"ttl":    "1h",
+		"backend": mapstr.M{
+			"file": mapstr.M{"id": "dedupe_restart_test"},
+		},
+	}
+
+	d := newTestDedupe(t, cfg, path)
+	got, err := d.Run(&beat.Event{Fields: mapstr.M{"message": "hello"}})
+	if err != nil || got == nil {
+		t.Fatalf("unexpected result from Run: event=%v err=%v", got, err)
+	}
+	// Closing the last reference to the store writes it to its file.
+	d.Close()
+
+	d = newTestDedupe(t, cfg, path)
+	defer d.Close()
+	got, err = d.Run(&beat.Event{Fields: mapstr.M{"message": "hello"}})
+	if err != nil {
+		t.Fatalf("unexpected error from Run: %v", err)
+	}
+	if got != nil {
+		t.Error("duplicate event seen before the restart was not dropped")
+	}
+}
+
+func TestDedupeMissingFields(t *testing.T) {
+	for _, ignoreMissing := range []bool{false, true} {
+		d := newTestDedupe(t, mapstr.M{
+			"fields":         []string{"message", "event.id"},
+			"ttl":            "1h",
+			"ignore_missing": ignoreMissing,
+			"backend": mapstr.M{
+				"memory": mapstr.M{"id": "dedupe_missing_test"},
+			},
+		}, testPaths(t))
+
+		event := &beat.Event{Fields: mapstr.M{"message": "hello"}}
+		got, err := d.Run(event)
+		if got != event {
+			t.Errorf("ignore_missing=%t: unexpected event from Run: %v", ignoreMissing, got)
+		}
+		if ignoreMissing {
+			if err != nil {
+				t.Errorf("ignore_missing=%t: unexpected error from Run: %v", ignoreMissing, err)
+			}
+		} else {
+			if err == nil || !strings.Contains(err.Error(), "failed to find field [event.id]") {
+				t.Errorf("ignore_missing=%t: unexpected error from Run: %v", ignoreMissing, err)
+			}
+			if got := d.errors.Get(); got != 1 {
+				t.Errorf("ignore_missing=%t: unexpected errors count: got:%d want:1", ignoreMissing, got)
+			}
+		}
+		d.Close()
+	}
+}
+
+func TestDedupeNoFields(t *testing.T) {
+	d := newTestDedupe(t, mapstr.M{
+		"fields":         []string{"event.id"},
+		"ttl":            "1h",
+		"ignore_missing": true,
+		"backend": mapstr.M{
+			"memory": mapstr.M{"id": "dedupe_no_fields_test"},
+		},
+	}, testPaths(t))
+	defer d.Close()
+
+	// Events without any of the fields are not duplicates of each other.
+	for _, msg := range []string{"first", "second"} {
+		event := &beat.Event{Fields: mapstr.M{"message": msg}}
+		got, err := d.Run(event)
+		if err != nil {
+			t.Errorf("unexpected error from Run: %v", err)
+		}
+		if got != event {
+			t.Errorf("event without the fields was dropped: %v", event.Fields)
+		}
+	}
+	if got := d.passed.Get(); got != 2 {
+		t.Errorf("unexpected passed count: got:%d want:2", got)
+	}
+}
+
+func TestNewDedupeErrors(t *testing.T) {
+	backend := mapstr.M{"memory": mapstr.M{"id": "dedupe_errors_test"}}
+	tests := []struct {
+		name    string
+		cfg     mapstr.M
+		wantErr string
+	}{
+		{
+			name:    "no_fields",
+			cfg:     mapstr.M{"ttl": "1h", "backend": backend},
+			wantErr: "missing required field accessing 'fields'",
+		},
+		{
+			name:    "no_ttl",
+			cfg:     mapstr.M{"fields": []string{"message"}, "backend": backend},
+			wantErr: "missing required field accessing 'ttl'",
+		},
+		{
+			name:    "zero_ttl",
+			cfg:     mapstr.M{"fields": []string{"message"}, "ttl": "0s", "backend": backend},
+			wantErr: "accessing 'ttl'",
+		},
+		{
+			name:    "negative_ttl",
+			cfg:     mapstr.M{"fields": []string{"message"}, "ttl": "-1h", "backend": backend},
+			wantErr: "accessing 'ttl'",
+		},
+		{
+			name:    "no_backend",
+			cfg:     mapstr.M{"fields": []string{"message"}, "ttl": "1h"},
+			wantErr: "missing required field accessing 'backend'",
+		},
+		{
+			name:    "invalid_method",
+			cfg:     mapstr.M{"fields": []string{"message"}, "ttl": "1h", "method": "md4", "backend": backend},
+			wantErr: "invalid fingerprinting method [md4]",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := conf.NewConfigFrom(test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = NewDedupe(config, logptest.NewTestingLogger(t, ""))
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("unexpected error from NewDedupe: got:%v want:%s", err, test.wantErr)
+			}
+		})
+	}
+}
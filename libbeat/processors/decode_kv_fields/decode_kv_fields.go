@@ -0,0 +1,247 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_kv_fields
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/checks"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor/registry"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+type decodeKVFields struct {
+	kvConfig
+	fields      map[string]string
+	parser      kvParser
+	includeKeys map[string]struct{}
+	excludeKeys map[string]struct{}
+}
+
+type kvConfig struct {
+	Fields        mapstr.M `config:"fields"`
+	FieldSplit    string   `config:"field_split"`
+	ValueSplit    string   `config:"value_split"`
+	QuoteChars    string   `config:"quote_chars"`
+	EscapeChar    string   `config:"escape_char"`
+	IncludeKeys   []string `config:"include_keys"`
+	ExcludeKeys   []string `config:"exclude_keys"`
+	Prefix        string   `config:"prefix"`
+	TrimKey       string   `config:"trim_key"`
+	TrimValue     string   `config:"trim_value"`
+	IgnoreMissing bool     `config:"ignore_missing"`
+	OverwriteKeys bool     `config:"overwrite_keys"`
+	FailOnError   bool     `config:"fail_on_error"`
+}
+
+var (
+	defaultKVConfig = kvConfig{
+		FieldSplit:  " ",
+		ValueSplit:  "=",
+		QuoteChars:  `"'`,
+		EscapeChar:  `\`,
+		FailOnError: true,
+	}
+)
+
+func init() {
+	processors.RegisterPlugin("decode_kv_fields",
+		checks.ConfigChecked(NewDecodeKVField,
+			checks.RequireFields("fields"),
+			checks.AllowedFields("fields", "field_split", "value_split", "quote_chars", "escape_char",
+				"include_keys", "exclude_keys", "prefix", "trim_key", "trim_value",
+				"ignore_missing", "overwrite_keys", "fail_on_error", "when")))
+
+	jsprocessor.RegisterPlugin("DecodeKVField", NewDecodeKVField)
+}
+
+// NewDecodeKVField construct a new decode_kv_fields processor.
+func NewDecodeKVField(c *config.C, log *logp.Logger) (beat.Processor, error) {
+	config := defaultKVConfig
+
+	err := c.Unpack(&config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack the decode_kv_fields configuration: %w", err)
+	}
+	if len(config.Fields) == 0 {
+		return nil, errors.New("no fields to decode configured")
+	}
+	if config.FieldSplit == "" || config.ValueSplit == "" {
+		return nil, errors.New("field_split and value_split must not be empty")
+	}
+	if strings.ContainsAny(config.FieldSplit, config.ValueSplit) {
+		return nil, fmt.Errorf("field_split '%s' and value_split '%s' must not have characters in common", config.FieldSplit, config.ValueSplit)
+	}
+	f := &decodeKVFields{
+		kvConfig: config,
+		parser: kvParser{
+			fieldSplit: config.FieldSplit,
+			valueSplit: config.ValueSplit,
+			quoteChars: config.QuoteChars,
+		},
+	}
+	// Set escape character as rune
+	switch runes := []rune(config.EscapeChar); len(runes) {
+	case 0:
+		break
+	case 1:
+		f.parser.escapeChar = runes[0]
+	default:
+		return nil, fmt.Errorf("escape_char must be a single character, got %d in string '%s'", len(runes), config.EscapeChar)
+	}
+	if len(config.IncludeKeys) > 0 {
+		f.includeKeys = make(map[string]struct{}, len(config.IncludeKeys))
+		for _, k := range config.IncludeKeys {
+			f.includeKeys[k] = struct{}{}
+		}
+	}
+	if len(config.ExcludeKeys) > 0 {
+		f.excludeKeys = make(map[string]struct{}, len(config.ExcludeKeys))
+		for _, k := range config.ExcludeKeys {
+			f.excludeKeys[k] = struct{}{}
+		}
+	}
+	// Set fields as string -> string
+	f.fields = make(map[string]string, len(config.Fields))
+	for src, dstIf := range config.Fields.Flatten() {
+		dst, ok := dstIf.(string)
+		if !ok {
+			return nil, fmt.Errorf("bad destination mapping for %s: destination field must be string, not %T (got %v)", src, dstIf, dstIf)
+		}
+		f.fields[src] = dst
+	}
+	return f, nil
+}
+
+// Run applies the decode_kv_fields processor to an event.
+func (f *decodeKVFields) Run(event *beat.Event) (*beat.Event, error) {
+	// Each field is decoded into several keys, a failure can happen after
+	// some of them were written.
+	var saved *beat.Event
+	if f.FailOnError {
+		saved = event.Clone()
+	}
+	for src, dest := range f.fields {
+		if err := f.decodeKVField(src, dest, event); err != nil && f.FailOnError {
+			return saved, err
+		}
+	}
+	return event, nil
+}
+
+func (f *decodeKVFields) decodeKVField(src, dest string, event *beat.Event) error {
+	data, err := event.GetValue(src)
+	if err != nil {
+		if f.IgnoreMissing && errors.Is(err, mapstr.ErrKeyNotFound) {
+			return nil
+		}
+		return fmt.Errorf("could not fetch value for field %s: %w", src, err)
+	}
+
+	text, ok := data.(string)
+	if !ok {
+		return fmt.Errorf("field %s is not of string type", src)
+	}
+
+	var (
+		keys   []string
+		values = map[string]any{}
+	)
+	err = f.parser.parse(text, func(key, value string) {
+		if f.TrimKey != "" {
+			key = strings.Trim(key, f.TrimKey)
+		}
+		if key == "" || !f.selected(key) {
+			return
+		}
+		if f.TrimValue != "" {
+			value = strings.Trim(value, f.TrimValue)
+		}
+		key = f.Prefix + key
+
+		// Repeated keys are decoded as an array of their values.
+		switch v := values[key].(type) {
+		case nil:
+			keys = append(keys, key)
+			values[key] = value
+		case string:
+			values[key] = []string{v, value}
+		case []string:
+			values[key] = append(v, value)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("error decoding key-value pairs from field %s: %w", src, err)
+	}
+
+	// The conflicts are checked before the source field is deleted, so the
+	// event is left unchanged on error.
+	if !f.OverwriteKeys {
+		for _, key := range keys {
+			target := targetField(dest, key)
+			if _, err = event.GetValue(target); err == nil {
+				return fmt.Errorf("target field %s already has a value. Set the overwrite_keys flag or drop/rename the field first", target)
+			}
+		}
+	}
+	if dest == src && len(keys) > 0 {
+		// The decoded pairs replace the source field.
+		_ = event.Delete(src)
+	}
+	for _, key := range keys {
+		target := targetField(dest, key)
+		if _, err = event.PutValue(target, values[key]); err != nil {
+			return fmt.Errorf("failed setting field %s: %w", target, err)
+		}
+	}
+	return nil
+}
+
+// selected returns true if the key is decoded according to the include and
+// exclude lists.
+func (f *decodeKVFields) selected(key string) bool {
+	if f.includeKeys != nil {
+		if _, ok := f.includeKeys[key]; !ok {
+			return false
+		}
+	}
+	_, excluded := f.excludeKeys[key]
+	return !excluded
+}
+
+// targetField returns the field of a decoded key, the keys are written at
+// the root of the event if dest is empty.
+func targetField(dest, key string) string {
+	if dest == "" {
+		return key
+	}
+	return dest + "." + key
+}
+
+// String returns a string representation of this processor.
+func (f decodeKVFields) String() string {
+	json, _ := json.Marshal(f.kvConfig)
+	return "decode_kv_fields=" + string(json)
+}
@@ -0,0 +1,471 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_kv_fields
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	cfg "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestDecodeKVField(t *testing.T) {
+	tests := map[string]struct {
+		config   mapstr.M
+		input    beat.Event
+		expected beat.Event
+		fail     bool
+	}{
+		"default settings": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"message": "kv",
+				},
+			},
+			input: beat.Event{
+				Fields: mapstr.M{
+					"message": `action=allow  src=10.0.0.1 msg="connection accepted" path='C:\\Temp' user=a\ b`,
+				},
+			},
+			expected: beat.Event{
+				Fields: mapstr.M{
+					"message": `action=allow  src=10.0.0.1 msg="connection accepted" path='C:\\Temp' user=a\ b`,
+					"kv": mapstr.M{
+						"action": "allow",
+						"src":    "10.0.0.1",
+						"msg":    "connection accepted",
+						"path":   `C:\Temp`,
+						"user":   "a b",
+					},
+				},
+			},
+		},
+
+		"self target": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"message": "message",
+				},
+			},
+			input: beat.Event{
+				Fields: mapstr.M{
+					"message": "a=1 b=2",
+				},
+			},
+			expected: beat.Event{
+				Fields: mapstr.M{
+					"message": mapstr.M{"a": "1", "b": "2"},
+				},
+			},
+		},
+
+		"root target": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"message": "",
+				},
+				"prefix": "fw.",
+			},
+			input: beat.Event{
+				Fields: mapstr.M{
+					"message": "a=1 b=2",
+					"fw":      mapstr.M{"name": "edge"},
+				},
+			},
+			expected: beat.Event{
+				Fields: mapstr.M{
+					"message": "a=1 b=2",
+					"fw":      mapstr.M{"name": "edge", "a": "1", "b": "2"},
+				},
+			},
+		},
+
+		"custom splits": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"message": "kv",
+				},
+				"field_split": ",;",
+				"value_split": ":",
+			},
+			input: beat.Event{
+				Fields: mapstr.M{
+					"message": "a:1,b:x=y;c:,d",
+				},
+			},
+			expected: beat.Event{
+				Fields: mapstr.M{
+					"message": "a:1,b:x=y;c:,d",
+					"kv":      mapstr.M{"a": "1", "b": "x=y", "c": ""},
+				},
+			},
+		},
+
+		"quoted keys and separators": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"message": "kv",
+				},
+			},
+			input: beat.Event{
+				Fields: mapstr.M{
+					"message": `"user name"="x \"y\" z" 'a=b'=c`,
+				},
+			},
+			expected: beat.Event{
+				Fields: mapstr.M{
+					"message": `"user name"="x \"y\" z" 'a=b'=c`,
+					"kv":      mapstr.M{"user name": `x "y" z`, "a=b": "c"},
+				},
+			},
+		},
+
+		"quotes and escapes disabled": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"message": "kv",
+				},
+				"quote_chars": "",
+				"escape_char": "",
+			},
+			input: beat.Event{
+				Fields: mapstr.M{
+					"message": `a="x b=\y`,
+				},
+			},
+			expected: beat.Event{
+				Fields: mapstr.M{
+					"message": `a="x b=\y`,
+					"kv":      mapstr.M{"a": `"x`, "b": `\y`},
+				},
+			},
+		},
+
+		"repeated keys": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"message": "kv",
+				},
+			},
+			input: beat.Event{
+				Fields: mapstr.M{
+					"message": "tag=a tag=b tag=c id=1",
+				},
+			},
+			expected: beat.Event{
+				Fields: mapstr.M{
+					"message": "tag=a tag=b tag=c id=1",
+					"kv":      mapstr.M{"tag": []string{"a", "b", "c"}, "id": "1"},
+				},
+			},
+		},
+
+		"include and exclude keys": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"message": "kv",
+				},
+				"include_keys": []string{"a", "b"},
+				"exclude_keys": []string{"b"},
+			},
+			input: beat.Event{
+				Fields: mapstr.M{
+					"message": "a=1 b=2 c=3",
+				},
+			},
+			expected: beat.Event{
+				Fields: mapstr.M{
+					"message": "a=1 b=2 c=3",
+					"kv":      mapstr.M{"a": "1"},
+				},
+			},
+		},
+
+		"trim keys and values": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"message": "kv",
+				},
+				"field_split": ",",
+				"trim_key":    " ",
+				"trim_value":  " <>",
+			},
+			input: beat.Event{
+				Fields: mapstr.M{
+					"message": " a = <1> , b =2",
+				},
+			},
+			expected: beat.Event{
+				Fields: mapstr.M{
+					"message": " a = <1> , b =2",
+					"kv":      mapstr.M{"a": "1", "b": "2"},
+				},
+			},
+		},
+
+		"unterminated quote": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"message": "kv",
+				},
+			},
+			input: beat.Event{
+				Fields: mapstr.M{
+					"message": `a=1 msg="unterminated`,
+				},
+			},
+			expected: beat.Event{
+				Fields: mapstr.M{
+					"message": `a=1 msg="unterminated`,
+				},
+			},
+			fail: true,
+		},
+
+		"non existing field": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"field": "my.field",
+				},
+			},
+			fail: true,
+		},
+
+		"ignore missing": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"my_field": "my_field",
+				},
+
+				"ignore_missing": true,
+			},
+		},
+
+		"bad type": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"message": "message",
+				},
+			},
+			input: beat.Event{
+				Fields: mapstr.M{
+					"message": 42,
+				},
+			},
+			fail: true,
+		},
+
+		"overwrite keys failure": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"message": "kv",
+				},
+			},
+			input: beat.Event{
+				Fields: mapstr.M{
+					"message": "a=1 b=2",
+					"kv":      mapstr.M{"b": 42},
+				},
+			},
+			expected: beat.Event{
+				Fields: mapstr.M{
+					"message": "a=1 b=2",
+					"kv":      mapstr.M{"b": 42},
+				},
+			},
+			fail: true,
+		},
+
+		"target is the source with a conflict": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"message": "message",
+				},
+				"overwrite_keys": false,
+				"fail_on_error":  false,
+			},
+			input: beat.Event{
+				Fields: mapstr.M{
+					"message":   "a=1 b=2",
+					"message.a": "x",
+				},
+			},
+			// The conflict leaves the event unchanged.
+			expected: beat.Event{
+				Fields: mapstr.M{
+					"message":   "a=1 b=2",
+					"message.a": "x",
+				},
+			},
+		},
+
+		"overwrite keys": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"message": "kv",
+				},
+				"overwrite_keys": true,
+			},
+			input: beat.Event{
+				Fields: mapstr.M{
+					"message": "a=1 b=2",
+					"kv":      mapstr.M{"b": 42, "c": 3},
+				},
+			},
+			expected: beat.Event{
+				Fields: mapstr.M{
+					"message": "a=1 b=2",
+					"kv":      mapstr.M{"a": "1", "b": "2", "c": 3},
+				},
+			},
+		},
+
+		"ignore errors": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"a": "a",
+					"b": "b",
+					"c": "c",
+				},
+				"fail_on_error": false,
+			},
+			input: beat.Event{
+				Fields: mapstr.M{
+					"a": "x=1",
+					"b": `y="2`,
+					"c": "z=3",
+				},
+			},
+			expected: beat.Event{
+				Fields: mapstr.M{
+					"a": mapstr.M{"x": "1"},
+					"b": `y="2`,
+					"c": mapstr.M{"z": "3"},
+				},
+			},
+		},
+
+		"restore on errors": {
+			config: mapstr.M{
+				"fields": mapstr.M{
+					"message": "",
+				},
+				"fail_on_error": true,
+			},
+			input: beat.Event{
+				Fields: mapstr.M{
+					"message": "a=1 a.b=2",
+				},
+			},
+			expected: beat.Event{
+				Fields: mapstr.M{
+					"message": "a=1 a.b=2",
+				},
+			},
+			fail: true,
+		},
+	}
+
+	for title, tt := range tests {
+		t.Run(title, func(t *testing.T) {
+			processor, err := NewDecodeKVField(cfg.MustNewConfigFrom(tt.config), logptest.NewTestingLogger(t, ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := processor.Run(&tt.input)
+			if tt.expected.Fields != nil {
+				assert.Equal(t, tt.expected.Fields.Flatten(), result.Fields.Flatten())
+				assert.Equal(t, tt.expected.Meta.Flatten(), result.Meta.Flatten())
+			}
+			if tt.fail {
+				assert.Error(t, err)
+				t.Log("got expected error", err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("supports metadata as a target", func(t *testing.T) {
+		config := mapstr.M{
+			"fields": mapstr.M{
+				"@metadata": mapstr.M{
+					"field": "@metadata.kv",
+				},
+			},
+		}
+
+		event := &beat.Event{
+			Meta: mapstr.M{
+				"field": "a=1",
+			},
+			Fields: mapstr.M{},
+		}
+		expMeta := mapstr.M{
+			"field": "a=1",
+			"kv":    mapstr.M{"a": "1"},
+		}
+
+		processor, err := NewDecodeKVField(cfg.MustNewConfigFrom(config), logptest.NewTestingLogger(t, ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := processor.Run(event)
+		assert.NoError(t, err)
+		assert.Equal(t, expMeta, result.Meta)
+		assert.Equal(t, event.Fields, result.Fields)
+	})
+}
+
+func TestNewDecodeKVFieldErrors(t *testing.T) {
+	tests := map[string]struct {
+		config mapstr.M
+		err    string
+	}{
+		"empty field split": {
+			config: mapstr.M{"field_split": ""},
+			err:    "field_split and value_split must not be empty",
+		},
+		"overlapping splits": {
+			config: mapstr.M{"field_split": " =", "value_split": "=:"},
+			err:    "must not have characters in common",
+		},
+		"long escape char": {
+			config: mapstr.M{"escape_char": "\\\\"},
+			err:    "escape_char must be a single character",
+		},
+		"bad destination": {
+			config: mapstr.M{"fields": mapstr.M{"message": 1}},
+			err:    "destination field must be string",
+		},
+	}
+
+	for title, tt := range tests {
+		t.Run(title, func(t *testing.T) {
+			config := mapstr.M{"fields": mapstr.M{"message": "kv"}}
+			config.DeepUpdate(tt.config)
+			_, err := NewDecodeKVField(cfg.MustNewConfigFrom(config), logptest.NewTestingLogger(t, ""))
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_kv_fields
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+var errUnterminatedQuote = errors.New("unterminated quoted string")
+
+// kvParser splits strings into key-value pairs.
+type kvParser struct {
+	fieldSplit string // Characters separating the pairs.
+	valueSplit string // Characters separating the key from the value.
+	quoteChars string // Characters quoting keys and values.
+	escapeChar rune   // Character escaping the next character, 0 if disabled.
+}
+
+// parse calls fn for each key-value pair of s, in order. Pairs without a
+// value separator are skipped.
+func (p *kvParser) parse(s string, fn func(key, value string)) error {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if strings.ContainsRune(p.fieldSplit, r) {
+			i += size
+			continue
+		}
+
+		key, next, err := p.token(s, i, p.fieldSplit+p.valueSplit)
+		if err != nil {
+			return err
+		}
+		i = next
+		if i == len(s) {
+			break
+		}
+		r, size = utf8.DecodeRuneInString(s[i:])
+		i += size
+		if !strings.ContainsRune(p.valueSplit, r) {
+			continue
+		}
+
+		value, next, err := p.token(s, i, p.fieldSplit)
+		if err != nil {
+			return err
+		}
+		i = next
+		fn(key, value)
+	}
+	return nil
+}
+
+// token reads a key or a value starting at s[i], until one of the stop
+// characters that is neither quoted nor escaped. It returns the unquoted and
+// unescaped token and the index of the stop character, or len(s).
+func (p *kvParser) token(s string, i int, stop string) (string, int, error) {
+	var b strings.Builder
+
+	// A key or value starting with a quote character is quoted until the
+	// same unescaped quote character.
+	if r, size := utf8.DecodeRuneInString(s[i:]); i < len(s) && strings.ContainsRune(p.quoteChars, r) {
+		end := p.unescape(&b, s, i+size, string(r))
+		if end == len(s) {
+			return "", 0, errUnterminatedQuote
+		}
+		i = end + size
+	}
+
+	end := p.unescape(&b, s, i, stop)
+	return b.String(), end, nil
+}
+
+// unescape writes s[i:] to b up to the first unescaped stop character, and
+// returns the index of the stop character, or len(s).
+func (p *kvParser) unescape(b *strings.Builder, s string, i int, stop string) int {
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if strings.ContainsRune(stop, r) {
+			return i
+		}
+		if p.escapeChar != 0 && r == p.escapeChar && i+size < len(s) {
+			next, nextSize := utf8.DecodeRuneInString(s[i+size:])
+			b.WriteRune(next)
+			i += size + nextSize
+			continue
+		}
+		b.WriteRune(r)
+		i += size
+	}
+	return i
+}
@@ -43,8 +43,7 @@ func init() {
 
 type fingerprint struct {
 	config Config
-	fields []string
-	hash   hashMethod
+	hasher *Hasher
 }
 
 // New constructs a new fingerprint processor.
@@ -54,15 +53,9 @@ func New(cfg *config.C, log *logp.Logger) (beat.Processor, error) {
 		return nil, makeErrConfigUnpack(err)
 	}
 
-	// The fields array must be sorted, to guarantee that we always
-	// get the same hash for a similar set of configured keys.
-	slices.Sort(config.Fields)
-	fields := slices.Compact(config.Fields)
-
 	p := &fingerprint{
 		config: config,
-		hash:   config.Method.Hash,
-		fields: fields,
+		hasher: newHasher(config.Fields, config.Method.Hash, config.IgnoreMissing),
 	}
 
 	return p, nil
@@ -70,13 +63,12 @@ func New(cfg *config.C, log *logp.Logger) (beat.Processor, error) {
 
 // Run enriches the given event with a fingerprint.
 func (p *fingerprint) Run(event *beat.Event) (*beat.Event, error) {
-	hashFn := p.hash()
-
-	if err := p.writeFields(hashFn, event); err != nil {
+	sum, err := p.hasher.Sum(event)
+	if err != nil {
 		return nil, makeErrComputeFingerprint(err)
 	}
 
-	encodedHash := p.config.Encoding.Encode(hashFn.Sum(nil))
+	encodedHash := p.config.Encoding.Encode(sum)
 
 	if _, err := event.PutValue(p.config.TargetField, encodedHash); err != nil {
 		return nil, makeErrComputeFingerprint(err)
@@ -90,11 +82,55 @@ func (p *fingerprint) String() string {
 	return procName + "=" + string(json)
 }
 
-func (p *fingerprint) writeFields(to io.Writer, event *beat.Event) error {
-	for _, k := range p.fields {
+// Hasher computes the hash of the values of a set of event fields, like the
+// fingerprint processor. It's used by the processors identifying events by
+// their fields.
+type Hasher struct {
+	fields        []string
+	hash          hashMethod
+	ignoreMissing bool
+}
+
+// NewHasher returns a Hasher of the fields with the named hash method. If
+// ignoreMissing is true, the fields missing from the events are ignored,
+// otherwise they fail to be hashed.
+func NewHasher(fields []string, method string, ignoreMissing bool) (*Hasher, error) {
+	if len(fields) == 0 {
+		return nil, errNoFields
+	}
+	var m namedHashMethod
+	if err := m.Unpack(method); err != nil {
+		return nil, err
+	}
+	return newHasher(fields, m.Hash, ignoreMissing), nil
+}
+
+func newHasher(fields []string, hash hashMethod, ignoreMissing bool) *Hasher {
+	// The fields array must be sorted, to guarantee that we always
+	// get the same hash for a similar set of configured keys.
+	fields = slices.Clone(fields)
+	slices.Sort(fields)
+	return &Hasher{
+		fields:        slices.Compact(fields),
+		hash:          hash,
+		ignoreMissing: ignoreMissing,
+	}
+}
+
+// Sum returns the hash of the fields of the event.
+func (h *Hasher) Sum(event *beat.Event) ([]byte, error) {
+	hashFn := h.hash()
+	if err := h.writeFields(hashFn, event); err != nil {
+		return nil, err
+	}
+	return hashFn.Sum(nil), nil
+}
+
+func (h *Hasher) writeFields(to io.Writer, event *beat.Event) error {
+	for _, k := range h.fields {
 		v, err := event.GetValue(k)
 		if err != nil {
-			if p.config.IgnoreMissing {
+			if h.ignoreMissing {
 				continue
 			}
 			return makeErrMissingField(k, err)
@@ -0,0 +1,101 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package geoip
+
+import (
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+// lookupCache caches the results of the lookups of IP addresses, the records
+// found in the successCache and the failures in the failureCache. Both are
+// bounded LRU caches that are purged when a database is reloaded.
+type lookupCache struct {
+	success *lru.Cache[string, mapstr.M] // nil if disabled.
+	failure *lru.Cache[string, error]    // nil if disabled.
+	stats   cacheStats
+}
+
+type cacheStats struct {
+	Hit  *monitoring.Int
+	Miss *monitoring.Int
+}
+
+func newLookupCache(reg *monitoring.Registry, conf cacheConfig) (*lookupCache, error) {
+	c := &lookupCache{
+		stats: cacheStats{
+			Hit:  monitoring.NewInt(reg, "hits"),
+			Miss: monitoring.NewInt(reg, "misses"),
+		},
+	}
+
+	var err error
+	if conf.SuccessCache.Enabled {
+		if c.success, err = lru.New[string, mapstr.M](conf.SuccessCache.MaxCapacity); err != nil {
+			return nil, err
+		}
+	}
+	if conf.FailureCache.Enabled {
+		if c.failure, err = lru.New[string, error](conf.FailureCache.MaxCapacity); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Lookup returns the result of lookup for ip, from the cache if it contains
+// ip. The returned fields must not be modified.
+func (c *lookupCache) Lookup(ip string, lookup func(string) (mapstr.M, error)) (mapstr.M, error) {
+	if c.success != nil {
+		if fields, found := c.success.Get(ip); found {
+			c.stats.Hit.Inc()
+			return fields, nil
+		}
+	}
+	if c.failure != nil {
+		if err, found := c.failure.Get(ip); found {
+			c.stats.Hit.Inc()
+			return nil, err
+		}
+	}
+	c.stats.Miss.Inc()
+
+	fields, err := lookup(ip)
+	if err != nil {
+		if c.failure != nil {
+			c.failure.Add(ip, err)
+		}
+		return nil, err
+	}
+	if c.success != nil {
+		c.success.Add(ip, fields)
+	}
+	return fields, nil
+}
+
+// Purge removes all the results from the cache.
+func (c *lookupCache) Purge() {
+	if c.success != nil {
+		c.success.Purge()
+	}
+	if c.failure != nil {
+		c.failure.Purge()
+	}
+}
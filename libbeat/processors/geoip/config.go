@@ -0,0 +1,88 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// config defines the configuration options for the geoip processor.
+type config struct {
+	cacheConfig    `config:",inline"`
+	Fields         mapstr.M      `config:"fields"          validate:"required"` // Mapping of source IP fields to target fields.
+	Databases      []string      `config:"databases"       validate:"required"` // Paths of the MMDB files.
+	ReloadInterval time.Duration `config:"reload_interval" validate:"min=0"`    // Interval between checks for database changes, 0 disables reloading.
+	IgnoreMissing  bool          `config:"ignore_missing"`                      // Ignore events without the source fields.
+	TagOnFailure   []string      `config:"tag_on_failure"`                      // Tags to append when a failure occurs.
+	reverseFlat    map[string]string
+}
+
+// cacheConfig defines the parameters of the success and failure caches.
+type cacheConfig struct {
+	SuccessCache cacheSettings `config:"success_cache"`
+	FailureCache cacheSettings `config:"failure_cache"`
+}
+
+// cacheSettings define the caching behavior for an individual cache.
+type cacheSettings struct {
+	// Disable the use of the cache.
+	Enabled bool `config:"enabled"`
+
+	// Max capacity of the cache. When capacity is reached the least recently
+	// used item is evicted from the cache.
+	MaxCapacity int `config:"capacity.max" validate:"min=1"`
+}
+
+// Validate validates the data contained in the config.
+func (c *config) Validate() error {
+	if len(c.Databases) == 0 {
+		return errors.New("at least one database must be configured")
+	}
+
+	// Flatten the mapping of source fields to target fields.
+	c.reverseFlat = map[string]string{}
+	for k, v := range c.Fields.Flatten() {
+		target, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("target field for geoip lookup of %v "+
+				"must be a string but got %T", k, v)
+		}
+		c.reverseFlat[k] = target
+	}
+	return nil
+}
+
+func defaultConfig() config {
+	return config{
+		cacheConfig: cacheConfig{
+			SuccessCache: cacheSettings{
+				Enabled:     true,
+				MaxCapacity: 10000,
+			},
+			FailureCache: cacheSettings{
+				Enabled:     true,
+				MaxCapacity: 1000,
+			},
+		},
+		ReloadInterval: time.Minute,
+	}
+}
@@ -0,0 +1,238 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// databaseKind is the kind of records of a database.
+type databaseKind uint8
+
+const (
+	kindGeo databaseKind = iota // City, Country and Enterprise databases.
+	kindASN                     // ASN databases.
+)
+
+// database is a MMDB file. The file is read in memory, so it can be replaced
+// while it's being used.
+type database struct {
+	path string
+
+	mutex   sync.RWMutex // mutex synchronizes access to reader and kind.
+	reader  *maxminddb.Reader
+	kind    databaseKind
+	modTime time.Time
+	size    int64
+}
+
+func openDatabase(path string) (*database, error) {
+	db := &database{path: path}
+	if _, err := db.reload(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// reload reads the database again if the file was modified since it was
+// last read. It returns true if the database was reloaded. On error the
+// previous version of the database is kept.
+func (db *database) reload() (bool, error) {
+	info, err := os.Stat(db.path)
+	if err != nil {
+		return false, err
+	}
+
+	db.mutex.RLock()
+	unchanged := db.reader != nil && info.ModTime().Equal(db.modTime) && info.Size() == db.size
+	db.mutex.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(db.path)
+	if err != nil {
+		return false, err
+	}
+	reader, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return false, fmt.Errorf("failed to read database %s: %w", db.path, err)
+	}
+	kind, err := detectKind(reader.Metadata.DatabaseType)
+	if err != nil {
+		return false, fmt.Errorf("failed to read database %s: %w", db.path, err)
+	}
+
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	db.reader = reader
+	db.kind = kind
+	db.modTime = info.ModTime()
+	db.size = info.Size()
+	return true, nil
+}
+
+// detectKind returns the kind of records of the database type, the type is
+// for example GeoLite2-City or DBIP-ASN-Lite.
+func detectKind(databaseType string) (databaseKind, error) {
+	switch {
+	case strings.Contains(databaseType, "ASN"):
+		return kindASN, nil
+	case strings.Contains(databaseType, "City"),
+		strings.Contains(databaseType, "Country"),
+		strings.Contains(databaseType, "Enterprise"):
+		return kindGeo, nil
+	default:
+		return 0, fmt.Errorf("unsupported database type %q", databaseType)
+	}
+}
+
+var errNotFound = errors.New("not found")
+
+// lookup adds the fields of the record of ip to fields, unless they were
+// already added by another database of the same kind. It returns
+// errNotFound if the database has no record for ip.
+func (db *database) lookup(ip net.IP, fields mapstr.M) error {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	key := "geo"
+	if db.kind == kindASN {
+		key = "as"
+	}
+	if _, found := fields[key]; found {
+		// A previous database of the same kind already has a record for ip.
+		return nil
+	}
+
+	switch db.kind {
+	case kindASN:
+		var rec asnRecord
+		if _, ok, err := db.reader.LookupNetwork(ip, &rec); err != nil || !ok {
+			return notFound(err)
+		}
+		rec.addTo(fields)
+	default:
+		var rec geoRecord
+		if _, ok, err := db.reader.LookupNetwork(ip, &rec); err != nil || !ok {
+			return notFound(err)
+		}
+		rec.addTo(fields)
+	}
+	return nil
+}
+
+func notFound(err error) error {
+	if err != nil {
+		return err
+	}
+	return errNotFound
+}
+
+type names map[string]string
+
+// geoRecord is a record of City, Country and Enterprise databases.
+type geoRecord struct {
+	City struct {
+		Names names `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Continent struct {
+		Code  string `maxminddb:"code"`
+		Names names  `maxminddb:"names"`
+	} `maxminddb:"continent"`
+	Country struct {
+		IsoCode string `maxminddb:"iso_code"`
+		Names   names  `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Location struct {
+		Latitude  *float64 `maxminddb:"latitude"`
+		Longitude *float64 `maxminddb:"longitude"`
+		TimeZone  string   `maxminddb:"time_zone"`
+	} `maxminddb:"location"`
+	Postal struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"postal"`
+	Subdivisions []struct {
+		IsoCode string `maxminddb:"iso_code"`
+		Names   names  `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+}
+
+// addTo adds the ECS geo fields of the record to fields.
+func (r *geoRecord) addTo(fields mapstr.M) {
+	geo := mapstr.M{}
+	putString(geo, "city_name", r.City.Names["en"])
+	putString(geo, "continent_code", r.Continent.Code)
+	putString(geo, "continent_name", r.Continent.Names["en"])
+	putString(geo, "country_iso_code", r.Country.IsoCode)
+	putString(geo, "country_name", r.Country.Names["en"])
+	putString(geo, "postal_code", r.Postal.Code)
+	putString(geo, "timezone", r.Location.TimeZone)
+	if len(r.Subdivisions) > 0 {
+		region := r.Subdivisions[0]
+		if region.IsoCode != "" && r.Country.IsoCode != "" {
+			geo["region_iso_code"] = r.Country.IsoCode + "-" + region.IsoCode
+		}
+		putString(geo, "region_name", region.Names["en"])
+	}
+	if r.Location.Latitude != nil && r.Location.Longitude != nil {
+		geo["location"] = mapstr.M{
+			"lat": *r.Location.Latitude,
+			"lon": *r.Location.Longitude,
+		}
+	}
+	if len(geo) > 0 {
+		fields["geo"] = geo
+	}
+}
+
+// asnRecord is a record of ASN databases.
+type asnRecord struct {
+	Number       uint32 `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// addTo adds the ECS as fields of the record to fields.
+func (r *asnRecord) addTo(fields mapstr.M) {
+	as := mapstr.M{}
+	if r.Number != 0 {
+		as["number"] = r.Number
+	}
+	if r.Organization != "" {
+		as["organization"] = mapstr.M{"name": r.Organization}
+	}
+	if len(as) > 0 {
+		fields["as"] = as
+	}
+}
+
+func putString(m mapstr.M, key, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
@@ -0,0 +1,239 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package geoip implements a processor that enriches IP address fields with
+// the ECS geo and as fields, read from local MaxMind or DB-IP MMDB databases.
+// The databases are reloaded when their files change, and the results of the
+// lookups are kept in LRU caches.
+package geoip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor/registry"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+const (
+	procName = "geoip"
+	logName  = "processor." + procName
+)
+
+// instanceID is used to assign each instance a unique monitoring namespace.
+var instanceID atomic.Uint32
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+	jsprocessor.RegisterPlugin("GeoIP", New)
+}
+
+type processor struct {
+	config
+	databases []*database
+	cache     *lookupCache
+	log       *logp.Logger
+
+	reloads        *monitoring.Int
+	reloadFailures *monitoring.Int
+
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// New constructs a new geoip processor.
+func New(cfg *conf.C, log *logp.Logger) (beat.Processor, error) {
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("fail to unpack the %v processor configuration: %w", procName, err)
+	}
+
+	// Logging and metrics (each processor instance has a unique ID).
+	var (
+		id      = int(instanceID.Add(1))
+		metrics = monitoring.Default.GetOrCreateRegistry(logName+"."+strconv.Itoa(id), monitoring.DoNotReport)
+	)
+	log = log.Named(logName).With("instance_id", id)
+	log.Warn(cfgwarn.Beta("The " + procName + " processor is beta."))
+
+	return newGeoIP(c, metrics, log)
+}
+
+func newGeoIP(c config, metrics *monitoring.Registry, log *logp.Logger) (*processor, error) {
+	cache, err := newLookupCache(metrics.GetOrCreateRegistry("cache"), c.cacheConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &processor{
+		config:         c,
+		cache:          cache,
+		log:            log,
+		reloads:        monitoring.NewInt(metrics, "reloads"),
+		reloadFailures: monitoring.NewInt(metrics, "reload_failures"),
+		done:           make(chan struct{}),
+	}
+	for _, path := range c.Databases {
+		db, err := openDatabase(path)
+		if err != nil {
+			return nil, err
+		}
+		p.databases = append(p.databases, db)
+	}
+
+	if c.ReloadInterval > 0 {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.reloadLoop()
+		}()
+	}
+	return p, nil
+}
+
+// reloadLoop reloads the databases whose files changed, until the processor
+// is closed.
+func (p *processor) reloadLoop() {
+	ticker := time.NewTicker(p.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.reload()
+		}
+	}
+}
+
+func (p *processor) reload() {
+	reloaded := false
+	for _, db := range p.databases {
+		ok, err := db.reload()
+		if err != nil {
+			p.reloadFailures.Inc()
+			p.log.Warnw("Failed to reload geoip database, the previous version is kept.", "path", db.path, "error", err)
+			continue
+		}
+		if ok {
+			p.reloads.Inc()
+			p.log.Infow("Reloaded geoip database.", "path", db.path)
+			reloaded = true
+		}
+	}
+	if reloaded {
+		p.cache.Purge()
+	}
+}
+
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	var tagOnce sync.Once
+	for field, target := range p.reverseFlat {
+		if err := p.processField(field, target, event); err != nil {
+			p.log.Debugf("geoip processor failed: %v", err)
+			tagOnce.Do(func() { _ = mapstr.AddTags(event.Fields, p.TagOnFailure) })
+		}
+	}
+	return event, nil
+}
+
+func (p *processor) processField(source, target string, event *beat.Event) error {
+	v, err := event.GetValue(source)
+	if err != nil {
+		if p.IgnoreMissing && errors.Is(err, mapstr.ErrKeyNotFound) {
+			return nil
+		}
+		return fmt.Errorf("could not get value of %s: %w", source, err)
+	}
+
+	ip, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("value of %s is not a string but %T", source, v)
+	}
+
+	fields, err := p.cache.Lookup(ip, p.lookup)
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			// Addresses missing from the databases, like private addresses,
+			// are not failures.
+			return nil
+		}
+		return fmt.Errorf("geoip lookup of %s value '%s' failed: %w", source, ip, err)
+	}
+
+	prefix := ""
+	if target != "" {
+		prefix = target + "."
+	}
+	for key, value := range fields {
+		if _, err := event.PutValue(prefix+key, value.(mapstr.M).Clone()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookup returns the geo and as fields of ip, from the first database of
+// each kind that has a record for ip.
+func (p *processor) lookup(s string) (mapstr.M, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, errors.New("invalid IP address")
+	}
+
+	fields := mapstr.M{}
+	for _, db := range p.databases {
+		if err := db.lookup(ip, fields); err != nil && !errors.Is(err, errNotFound) {
+			return nil, err
+		}
+	}
+	if len(fields) == 0 {
+		return nil, errNotFound
+	}
+	return fields, nil
+}
+
+// Close stops reloading the databases.
+func (p *processor) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.done)
+		p.wg.Wait()
+	})
+	return nil
+}
+
+func (p *processor) String() string {
+	json, _ := json.Marshal(struct {
+		Fields    map[string]string `json:"fields"`
+		Databases []string          `json:"databases"`
+	}{p.reverseFlat, p.Databases})
+	return procName + "=" + string(json)
+}
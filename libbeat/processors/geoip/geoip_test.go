@@ -0,0 +1,329 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package geoip
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+// The test databases are the MaxMind test databases used by the testing
+// environments.
+var (
+	cityDatabase    = filepath.Join("..", "..", "..", "testing", "environments", "GeoLite2-City.mmdb")
+	countryDatabase = filepath.Join("..", "..", "..", "testing", "environments", "GeoLite2-Country.mmdb")
+	asnDatabase     = filepath.Join("..", "..", "..", "testing", "environments", "GeoLite2-ASN.mmdb")
+)
+
+func newTestGeoIP(t *testing.T, cfg map[string]any) *processor {
+	t.Helper()
+
+	c := defaultConfig()
+	require.NoError(t, conf.MustNewConfigFrom(cfg).Unpack(&c))
+	p, err := newGeoIP(c, monitoring.NewRegistry(), logptest.NewTestingLogger(t, ""))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, p.Close()) })
+	return p
+}
+
+func TestGeoIP(t *testing.T) {
+	p := newTestGeoIP(t, map[string]any{
+		"fields": map[string]any{
+			"source.ip":      "source",
+			"destination.ip": "destination",
+		},
+		"databases":      []string{cityDatabase, asnDatabase},
+		"tag_on_failure": []string{"_geoip_lookup_failure"},
+	})
+
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{
+		"source":      mapstr.M{"ip": "89.160.20.112"},
+		"destination": mapstr.M{"ip": "192.168.1.1"},
+	}})
+	require.NoError(t, err)
+
+	assert.Equal(t, mapstr.M{
+		"source": mapstr.M{
+			"ip": "89.160.20.112",
+			"geo": mapstr.M{
+				"city_name":        "Linköping",
+				"continent_code":   "EU",
+				"continent_name":   "Europe",
+				"country_iso_code": "SE",
+				"country_name":     "Sweden",
+				"region_iso_code":  "SE-E",
+				"region_name":      "Östergötland County",
+				"timezone":         "Europe/Stockholm",
+				"location":         mapstr.M{"lat": 58.4167, "lon": 15.6167},
+			},
+			"as": mapstr.M{
+				"number":       uint32(29518),
+				"organization": mapstr.M{"name": "Bredband2 AB"},
+			},
+		},
+		// Private addresses are not found, and are not failures.
+		"destination": mapstr.M{"ip": "192.168.1.1"},
+	}, event.Fields)
+}
+
+func TestGeoIPDatabaseOrder(t *testing.T) {
+	testCases := []struct {
+		name      string
+		databases []string
+		cityName  bool
+	}{
+		{name: "country database first", databases: []string{countryDatabase, cityDatabase}},
+		{name: "city database first", databases: []string{cityDatabase, countryDatabase}, cityName: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestGeoIP(t, map[string]any{
+				"fields":    map[string]any{"source.ip": "source"},
+				"databases": tc.databases,
+			})
+
+			event, err := p.Run(&beat.Event{Fields: mapstr.M{"source": mapstr.M{"ip": "89.160.20.112"}}})
+			require.NoError(t, err)
+
+			// The first database with a record for the address is used.
+			found, err := event.Fields.HasKey("source.geo.city_name")
+			require.NoError(t, err)
+			assert.Equal(t, tc.cityName, found)
+			country, err := event.GetValue("source.geo.country_iso_code")
+			require.NoError(t, err)
+			assert.Equal(t, "SE", country)
+		})
+	}
+}
+
+func TestGeoIPFailures(t *testing.T) {
+	p := newTestGeoIP(t, map[string]any{
+		"fields":         map[string]any{"source.ip": "source"},
+		"databases":      []string{countryDatabase},
+		"tag_on_failure": []string{"_geoip_lookup_failure"},
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"source": mapstr.M{"ip": "not-an-ip"}}})
+		require.NoError(t, err)
+		tags, err := event.GetValue("tags")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"_geoip_lookup_failure"}, tags)
+	})
+
+	t.Run("missing field", func(t *testing.T) {
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{}})
+		require.NoError(t, err)
+		assert.Contains(t, event.Fields, "tags")
+	})
+
+	t.Run("ignore missing", func(t *testing.T) {
+		p := newTestGeoIP(t, map[string]any{
+			"fields":         map[string]any{"source.ip": "source"},
+			"databases":      []string{countryDatabase},
+			"ignore_missing": true,
+			"tag_on_failure": []string{"_geoip_lookup_failure"},
+		})
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{}})
+		require.NoError(t, err)
+		assert.Empty(t, event.Fields)
+	})
+}
+
+func TestGeoIPCache(t *testing.T) {
+	p := newTestGeoIP(t, map[string]any{
+		"fields":    map[string]any{"ip": ""},
+		"databases": []string{countryDatabase},
+	})
+
+	for range 3 {
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"ip": "89.160.20.112"}})
+		require.NoError(t, err)
+		country, err := event.GetValue("geo.country_iso_code")
+		require.NoError(t, err)
+		assert.Equal(t, "SE", country)
+
+		// Events get their own copy of the cached fields.
+		_, err = event.PutValue("geo.country_iso_code", "modified")
+		require.NoError(t, err)
+	}
+	_, err := p.Run(&beat.Event{Fields: mapstr.M{"ip": "192.168.1.1"}})
+	require.NoError(t, err)
+	_, err = p.Run(&beat.Event{Fields: mapstr.M{"ip": "192.168.1.1"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(3), p.cache.stats.Hit.Get())
+	assert.Equal(t, int64(2), p.cache.stats.Miss.Get())
+}
+
+func TestGeoIPReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "GeoIP.mmdb")
+	copyFile(t, countryDatabase, path)
+
+	p := newTestGeoIP(t, map[string]any{
+		"fields":          map[string]any{"ip": ""},
+		"databases":       []string{path},
+		"reload_interval": "10ms",
+	})
+
+	cityName := func() any {
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"ip": "89.160.20.112"}})
+		require.NoError(t, err)
+		v, _ := event.GetValue("geo.city_name")
+		return v
+	}
+	require.Nil(t, cityName(), "country database has no city")
+
+	// Replace the database like the database updaters, and make sure the
+	// modification time changes.
+	tmp := path + ".tmp"
+	copyFile(t, cityDatabase, tmp)
+	require.NoError(t, os.Chtimes(tmp, time.Now(), time.Now().Add(time.Minute)))
+	require.NoError(t, os.Rename(tmp, path))
+
+	require.Eventually(t, func() bool { return cityName() == "Linköping" }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(1), p.reloads.Get())
+
+	// A corrupted database is not loaded.
+	require.NoError(t, os.WriteFile(path, []byte("not a database"), 0o600))
+	require.Eventually(t, func() bool { return p.reloadFailures.Get() > 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "Linköping", cityName())
+}
+
+// TestDatabaseConcurrentReload checks that lookups don't race with reloads,
+// run it with -race.
+func TestDatabaseConcurrentReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "GeoIP.mmdb")
+	copyFile(t, countryDatabase, path)
+	db, err := openDatabase(path)
+	require.NoError(t, err)
+
+	city, err := os.ReadFile(cityDatabase)
+	require.NoError(t, err)
+	asn, err := os.ReadFile(asnDatabase)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 20 {
+			// Alternate the kinds of database, with a modification time that
+			// changes on every iteration.
+			data := city
+			if i%2 == 1 {
+				data = asn
+			}
+			tmp := path + ".tmp"
+			if err := os.WriteFile(tmp, data, 0o600); err != nil {
+				t.Error(err)
+				return
+			}
+			mtime := time.Now().Add(time.Duration(i+1) * time.Minute)
+			if err := os.Chtimes(tmp, mtime, mtime); err != nil {
+				t.Error(err)
+				return
+			}
+			if err := os.Rename(tmp, path); err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := db.reload(); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	ip := net.ParseIP("89.160.20.112")
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		err := db.lookup(ip, mapstr.M{})
+		require.NoError(t, err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name string
+		cfg  map[string]any
+		err  string
+	}{
+		{
+			name: "missing fields",
+			cfg:  map[string]any{"databases": []string{cityDatabase}},
+			err:  "missing required field accessing 'fields'",
+		},
+		{
+			name: "missing databases",
+			cfg:  map[string]any{"fields": map[string]any{"source.ip": "source"}},
+			err:  "missing required field accessing 'databases'",
+		},
+		{
+			name: "invalid target",
+			cfg: map[string]any{
+				"fields":    map[string]any{"source.ip": 1},
+				"databases": []string{cityDatabase},
+			},
+			err: "must be a string",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := defaultConfig()
+			err := conf.MustNewConfigFrom(tc.cfg).Unpack(&c)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+
+	t.Run("unsupported database", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "invalid.mmdb")
+		require.NoError(t, os.WriteFile(path, []byte("not a database"), 0o600))
+
+		_, err := New(conf.MustNewConfigFrom(map[string]any{
+			"fields":    map[string]any{"source.ip": "source"},
+			"databases": []string{path},
+		}), logptest.NewTestingLogger(t, ""))
+		require.Error(t, err)
+	})
+}
+
+func copyFile(t *testing.T, src, dst string) {
+	t.Helper()
+
+	data, err := os.ReadFile(src)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(dst, data, 0o600))
+}
@@ -0,0 +1,59 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grok
+
+import "fmt"
+
+// config defines the configuration options for the grok processor.
+type config struct {
+	Field              string            `config:"field"`                                   // Field to parse.
+	Patterns           []string          `config:"patterns"            validate:"required"` // Patterns tried in order until one matches.
+	PatternDefinitions map[string]string `config:"pattern_definitions"`                     // Custom patterns, overriding the built-in patterns.
+	TargetPrefix       string            `config:"target_prefix"`                           // Prefix of the captured fields, the root of the event if empty.
+	TraceMatch         bool              `config:"trace_match"`                             // Add the index of the matching pattern to the event metadata.
+	IgnoreMissing      bool              `config:"ignore_missing"`                          // Ignore events without the field.
+	IgnoreFailure      bool              `config:"ignore_failure"`                          // Don't return an error when no pattern matches.
+	OverwriteKeys      bool              `config:"overwrite_keys"`                          // Overwrite existing fields with the captured values.
+	groks              []*grok
+}
+
+func defaultConfig() config {
+	return config{
+		Field:         "message",
+		OverwriteKeys: true,
+	}
+}
+
+// Validate compiles the patterns.
+func (c *config) Validate() error {
+	for name := range c.PatternDefinitions {
+		if !patternNameRE.MatchString(name) {
+			return fmt.Errorf("invalid pattern name %q, pattern names can only contain letters, digits and underscores", name)
+		}
+	}
+
+	c.groks = make([]*grok, 0, len(c.Patterns))
+	for _, p := range c.Patterns {
+		g, err := compile(p, c.PatternDefinitions)
+		if err != nil {
+			return err
+		}
+		c.groks = append(c.groks, g)
+	}
+	return nil
+}
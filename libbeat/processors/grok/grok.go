@@ -0,0 +1,228 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grok
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+	"sync"
+
+	gogrok "github.com/elastic/go-grok"
+	"github.com/elastic/go-grok/patterns"
+)
+
+var (
+	errNoMatch = errors.New("no pattern matched")
+
+	// referenceRE matches the references to patterns: %{SYNTAX},
+	// %{SYNTAX:SEMANTIC} and %{SYNTAX:SEMANTIC:TYPE}.
+	referenceRE = regexp.MustCompile(`%\{(\w+)(?::([^:{}]+)(?::(\w+))?)?\}`)
+
+	// patternNameRE matches the valid pattern names.
+	patternNameRE = regexp.MustCompile(`^\w+$`)
+
+	// fieldNameRE matches the field names supported by go-grok.
+	fieldNameRE = regexp.MustCompile(`^[\w.]+$`)
+
+	// bracketFieldRE matches the field references in the [a][b] syntax.
+	bracketFieldRE = regexp.MustCompile(`^(?:\[[^\[\]]+\])+$`)
+)
+
+// builtinPatterns returns the patterns that go-grok.NewComplete looks up,
+// the pattern library shared with the Elasticsearch grok processor.
+var builtinPatterns = sync.OnceValue(func() map[string]string {
+	all := maps.Clone(patterns.Default)
+	for _, p := range []map[string]string{
+		patterns.AWS,
+		patterns.Bind9,
+		patterns.Bro,
+		patterns.Exim,
+		patterns.HAProxy,
+		patterns.Httpd,
+		patterns.Firewalls,
+		patterns.Java,
+		patterns.Junos,
+		patterns.Maven,
+		patterns.MCollective,
+		patterns.MongoDB,
+		patterns.PostgreSQL,
+		patterns.Rails,
+		patterns.Redis,
+		patterns.Ruby,
+		patterns.Squid,
+		patterns.Syslog,
+	} {
+		maps.Copy(all, p)
+	}
+	return all
+})
+
+// builtinFixes returns the normalized definitions of the built-in patterns
+// that use field names not supported by go-grok. They are passed to go-grok
+// as definitions, before the pattern definitions of the configuration.
+var builtinFixes = sync.OnceValue(func() map[string]string {
+	fixes := map[string]string{}
+	for name, definition := range builtinPatterns() {
+		normalized, err := normalize(definition, true)
+		if err != nil || normalized == definition {
+			continue
+		}
+		fixes[name] = normalized
+	}
+	return fixes
+})
+
+// captureTypes are the types captured values can be converted to.
+var captureTypes = map[string]struct{}{
+	"string":  {},
+	"int":     {},
+	"long":    {},
+	"float":   {},
+	"double":  {},
+	"boolean": {},
+}
+
+// grok is a compiled grok pattern.
+type grok struct {
+	raw string
+	g   *gogrok.Grok
+}
+
+// compile compiles pattern with go-grok, looking up the pattern definitions
+// before the built-in patterns.
+//
+// The pattern and definitions are checked before being compiled, as go-grok
+// doesn't support the [a][b] field syntax, only reports unsupported types
+// when parsing, and expands recursive definitions until it gives up.
+func compile(pattern string, definitions map[string]string) (*grok, error) {
+	defs := maps.Clone(builtinFixes())
+	for name, definition := range definitions {
+		normalized, err := normalize(definition, false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid definition of pattern %q: %w", name, err)
+		}
+		defs[name] = normalized
+	}
+	expression, err := normalize(pattern, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReferences(expression, definitions, nil); err != nil {
+		return nil, err
+	}
+
+	g, err := gogrok.NewComplete(defs)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.Compile(expression, true); err != nil {
+		return nil, fmt.Errorf("failed to compile pattern %q: %w", pattern, err)
+	}
+	return &grok{raw: pattern, g: g}, nil
+}
+
+// match matches the pattern against s and returns the converted values of
+// the named captures, keyed by field. errNoMatch is returned if s doesn't
+// match the pattern.
+func (g *grok) match(s string) (map[string]any, error) {
+	// go-grok returns no values both when s doesn't match and when all the
+	// captures are empty.
+	if !g.g.MatchString(s) {
+		return nil, errNoMatch
+	}
+	values, err := g.g.ParseTypedString(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert captured values: %w", err)
+	}
+	return values, nil
+}
+
+// normalize checks the references of pattern, and converts their field
+// names in the [a][b] syntax to the a.b syntax used by go-grok and Beats.
+// If repair is true, stray brackets are removed from the field names,
+// as some built-in patterns have unbalanced brackets.
+func normalize(pattern string, repair bool) (string, error) {
+	var (
+		b    strings.Builder
+		last int
+	)
+	for _, m := range referenceRE.FindAllStringSubmatchIndex(pattern, -1) {
+		if m[4] < 0 {
+			continue
+		}
+		b.WriteString(pattern[last:m[4]])
+		last = m[1]
+
+		field := fieldName(pattern[m[4]:m[5]])
+		if repair {
+			field = strings.Trim(field, "[]")
+		}
+		if !fieldNameRE.MatchString(field) {
+			return "", fmt.Errorf("unsupported field name %q", pattern[m[4]:m[5]])
+		}
+		b.WriteString(field)
+		if m[6] >= 0 {
+			typeName := pattern[m[6]:m[7]]
+			if _, ok := captureTypes[typeName]; !ok {
+				return "", fmt.Errorf("unsupported type %q for field %q", typeName, field)
+			}
+			b.WriteString(":" + typeName)
+		}
+		b.WriteString("}")
+	}
+	b.WriteString(pattern[last:])
+	return b.String(), nil
+}
+
+// checkReferences checks that the patterns referenced by pattern exist and
+// don't reference themselves, stack holds the names of the patterns being
+// checked.
+func checkReferences(pattern string, definitions map[string]string, stack []string) error {
+	for _, m := range referenceRE.FindAllStringSubmatch(pattern, -1) {
+		name := m[1]
+		for _, s := range stack {
+			if s == name {
+				return fmt.Errorf("pattern %q references itself: %s -> %s", name, strings.Join(stack, " -> "), name)
+			}
+		}
+		definition, found := definitions[name]
+		if !found {
+			definition, found = builtinPatterns()[name]
+		}
+		if !found {
+			return fmt.Errorf("unknown pattern %q", name)
+		}
+		if err := checkReferences(definition, definitions, append(stack, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldName converts field references in the [a][b] syntax to the a.b
+// syntax.
+func fieldName(semantic string) string {
+	if !bracketFieldRE.MatchString(semantic) {
+		return semantic
+	}
+	parts := strings.Split(strings.Trim(semantic, "[]"), "][")
+	return strings.Join(parts, ".")
+}
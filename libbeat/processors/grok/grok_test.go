@@ -0,0 +1,201 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grok
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	testCases := []struct {
+		name        string
+		pattern     string
+		definitions map[string]string
+		input       string
+		want        map[string]any
+	}{
+		{
+			name:    "builtin patterns",
+			pattern: `%{IP:client.ip} %{WORD:http.request.method} %{URIPATHPARAM:url.original} %{NUMBER:http.response.body.bytes:long} %{NUMBER:event.duration:double}`,
+			input:   "55.3.244.1 GET /index.html 15824 0.043",
+			want: map[string]any{
+				"client.ip":                "55.3.244.1",
+				"http.request.method":      "GET",
+				"url.original":             "/index.html",
+				"http.response.body.bytes": 15824,
+				"event.duration":           float64(0.043),
+			},
+		},
+		{
+			name:    "bracket field references",
+			pattern: `%{IP:[source][ip]}:%{POSINT:[source][port]:int}`,
+			input:   "10.0.0.1:443",
+			want: map[string]any{
+				"source.ip":   "10.0.0.1",
+				"source.port": 443,
+			},
+		},
+		{
+			name:    "pattern definitions",
+			pattern: `%{LEVEL:log.level} %{PAIR}`,
+			definitions: map[string]string{
+				"LEVEL": `INFO|WARN|ERROR`,
+				"PAIR":  `%{WORD:key}=%{WORD:value}`,
+			},
+			input: "WARN user=alice",
+			want: map[string]any{
+				"log.level": "WARN",
+				"key":       "user",
+				"value":     "alice",
+			},
+		},
+		{
+			name:        "definitions override builtin patterns",
+			pattern:     `%{WORD:word}`,
+			definitions: map[string]string{"WORD": `[a-z]+`},
+			input:       "ABC def",
+			want:        map[string]any{"word": "def"},
+		},
+		{
+			name:    "optional and alternating captures",
+			pattern: `(?:%{INT:count:int}|%{WORD:label})(?: %{BOOL:enabled:boolean})?`,
+			input:   "ready",
+			want:    map[string]any{"label": "ready"},
+		},
+		{
+			name:    "same field in alternatives",
+			pattern: `(?:%{INT:value:int}|%{WORD:value})`,
+			input:   "42",
+			want:    map[string]any{"value": 42},
+		},
+		{
+			name:    "float and boolean",
+			pattern: `%{NUMBER:ratio:float} %{WORD:ok:boolean}`,
+			input:   "0.5 true",
+			want:    map[string]any{"ratio": 0.5, "ok": true},
+		},
+		{
+			// Unlike Elasticsearch, go-grok doesn't return empty captures.
+			name:    "empty capture",
+			pattern: `a=%{DATA:a};`,
+			input:   "a=;",
+			want:    map[string]any{},
+		},
+		{
+			name:    "unnamed references",
+			pattern: `%{SYSLOGTIMESTAMP} %{GREEDYDATA:message}`,
+			input:   "Oct 11 22:14:15 su root failed",
+			want:    map[string]any{"message": "su root failed"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := compile(tc.pattern, tc.definitions)
+			require.NoError(t, err)
+
+			values, err := g.match(tc.input)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, values)
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		pattern     string
+		definitions map[string]string
+		err         string
+	}{
+		{
+			name:    "unknown pattern",
+			pattern: `%{NOT_A_PATTERN:x}`,
+			err:     `unknown pattern "NOT_A_PATTERN"`,
+		},
+		{
+			name:    "unsupported type",
+			pattern: `%{INT:x:short}`,
+			err:     `unsupported type "short" for field "x"`,
+		},
+		{
+			name:    "recursive definitions",
+			pattern: `%{A}`,
+			definitions: map[string]string{
+				"A": `a%{B}`,
+				"B": `b%{A}`,
+			},
+			err: `pattern "A" references itself: A -> B -> A`,
+		},
+		{
+			name:    "unsupported field name",
+			pattern: `%{WORD:@timestamp}`,
+			err:     `unsupported field name "@timestamp"`,
+		},
+		{
+			name:    "unsupported type in definition",
+			pattern: `%{A}`,
+			definitions: map[string]string{
+				"A": `%{INT:x:short}`,
+			},
+			err: `invalid definition of pattern "A": unsupported type "short" for field "x"`,
+		},
+		{
+			name:    "invalid regular expression",
+			pattern: `%{WORD:x}(`,
+			err:     `failed to compile pattern`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := compile(tc.pattern, tc.definitions)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	g, err := compile(`%{WORD:x:int}`, nil)
+	require.NoError(t, err)
+
+	_, err = g.match("!!!")
+	require.ErrorIs(t, err, errNoMatch)
+
+	_, err = g.match("abc")
+	require.ErrorContains(t, err, `failed to convert captured values`)
+
+	// Unlike Elasticsearch, go-grok keeps one type per field, so the values
+	// of a field captured by several references are all converted to the
+	// type of the field.
+	g, err = compile(`(?:%{INT:value:int}|%{WORD:value})`, nil)
+	require.NoError(t, err)
+	_, err = g.match("abc")
+	require.ErrorContains(t, err, `failed to convert captured values`)
+}
+
+func TestBuiltinPatterns(t *testing.T) {
+	// All the built-in patterns must be supported by the regexp package.
+	for name := range builtinPatterns() {
+		_, err := compile("%{"+name+"}", nil)
+		assert.NoError(t, err, "pattern %s", name)
+	}
+}
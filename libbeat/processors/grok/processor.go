@@ -0,0 +1,148 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package grok implements a processor that parses a field with grok patterns
+// compatible with the grok processor of Elasticsearch ingest pipelines.
+package grok
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor/registry"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const (
+	procName = "grok"
+
+	flagParsingError = "grok_parsing_error"
+
+	// matchIndexKey is the metadata key of the index of the matching
+	// pattern, added when trace_match is enabled.
+	matchIndexKey = "grok_match_index"
+)
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+	jsprocessor.RegisterPlugin("Grok", New)
+}
+
+type processor struct {
+	config
+}
+
+// New constructs a new grok processor.
+func New(cfg *conf.C, log *logp.Logger) (beat.Processor, error) {
+	log.Named(procName).Warn(cfgwarn.Beta("The %v processor is beta.", procName))
+
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("fail to unpack the %v processor configuration: %w", procName, err)
+	}
+	return &processor{config: c}, nil
+}
+
+// Run parses the configured field with the first matching pattern and adds
+// the captured fields to the event.
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	v, err := event.GetValue(p.Field)
+	if err != nil {
+		if p.IgnoreMissing && errors.Is(err, mapstr.ErrKeyNotFound) {
+			return event, nil
+		}
+		return p.fail(event, fmt.Errorf("failed to get field %q: %w", p.Field, err))
+	}
+	s, ok := v.(string)
+	if !ok {
+		return p.fail(event, fmt.Errorf("field is not a string, value: `%v`, field: `%s`", v, p.Field))
+	}
+
+	for i, g := range p.groks {
+		values, err := g.match(s)
+		if errors.Is(err, errNoMatch) {
+			continue
+		}
+		if err != nil {
+			return p.fail(event, fmt.Errorf("failed to parse field %q with pattern %q: %w", p.Field, g.raw, err))
+		}
+		if err := p.mapFields(event, values); err != nil {
+			return p.fail(event, err)
+		}
+		if p.TraceMatch {
+			if event.Meta == nil {
+				event.Meta = mapstr.M{}
+			}
+			event.Meta[matchIndexKey] = i
+		}
+		return event, nil
+	}
+	return p.fail(event, fmt.Errorf("failed to parse field %q: %w", p.Field, errNoMatch))
+}
+
+// mapFields adds the captured values to the event. Existing fields are
+// checked before writing any value, so the event is left unchanged on
+// error.
+func (p *processor) mapFields(event *beat.Event, values map[string]any) error {
+	if !p.OverwriteKeys {
+		for k := range values {
+			key := p.targetKey(k)
+			found, err := event.HasKey(key)
+			if found {
+				return fmt.Errorf("cannot override existing key with `%s`", key)
+			}
+			if err != nil && !errors.Is(err, mapstr.ErrKeyNotFound) {
+				return fmt.Errorf("cannot override existing key with `%s`: %w", key, err)
+			}
+		}
+	}
+	for k, v := range values {
+		_, _ = event.PutValue(p.targetKey(k), v)
+	}
+	return nil
+}
+
+func (p *processor) targetKey(k string) string {
+	if p.TargetPrefix == "" {
+		return k
+	}
+	return p.TargetPrefix + "." + k
+}
+
+// fail flags the event with the parsing error, and returns err unless
+// ignore_failure is set.
+func (p *processor) fail(event *beat.Event, err error) (*beat.Event, error) {
+	if err := mapstr.AddTagsWithKey(event.Fields, beat.FlagField, []string{flagParsingError}); err != nil {
+		return event, fmt.Errorf("cannot add new flag the event: %w", err)
+	}
+	if p.IgnoreFailure {
+		return event, nil
+	}
+	return event, err
+}
+
+func (p *processor) String() string {
+	return procName + "=" + strings.Join(p.Patterns, "|") +
+		",field=" + p.Field +
+		",target_prefix=" + p.TargetPrefix
+}
@@ -0,0 +1,317 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grok
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/processors/dissect"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func newTestProcessor(t testing.TB, settings map[string]any) beat.Processor {
+	t.Helper()
+
+	c, err := conf.NewConfigFrom(settings)
+	require.NoError(t, err)
+	p, err := New(c, logptest.NewTestingLogger(t, ""))
+	require.NoError(t, err)
+	return p
+}
+
+func TestProcessor(t *testing.T) {
+	patterns := []string{
+		`%{IPORHOST:source.address} %{WORD:http.request.method} %{NOTSPACE:url.original} %{INT:http.response.status_code:int}(?: %{NUMBER:event.duration:double})?`,
+		`%{IPORHOST:source.address} %{GREEDYDATA:error.message}`,
+	}
+
+	testCases := []struct {
+		name     string
+		settings map[string]any
+		fields   mapstr.M
+		want     mapstr.M
+		meta     mapstr.M
+		err      string
+	}{
+		{
+			name:     "first pattern",
+			settings: map[string]any{"patterns": patterns},
+			fields:   mapstr.M{"message": "10.0.0.1 GET /index.html 200 0.5"},
+			want: mapstr.M{
+				"message": "10.0.0.1 GET /index.html 200 0.5",
+				"source":  mapstr.M{"address": "10.0.0.1"},
+				"http": mapstr.M{
+					"request":  mapstr.M{"method": "GET"},
+					"response": mapstr.M{"status_code": 200},
+				},
+				"url":   mapstr.M{"original": "/index.html"},
+				"event": mapstr.M{"duration": 0.5},
+			},
+		},
+		{
+			name:     "optional capture",
+			settings: map[string]any{"patterns": patterns, "trace_match": true},
+			fields:   mapstr.M{"message": "10.0.0.1 GET /index.html 200"},
+			want: mapstr.M{
+				"message": "10.0.0.1 GET /index.html 200",
+				"source":  mapstr.M{"address": "10.0.0.1"},
+				"http": mapstr.M{
+					"request":  mapstr.M{"method": "GET"},
+					"response": mapstr.M{"status_code": 200},
+				},
+				"url": mapstr.M{"original": "/index.html"},
+			},
+			meta: mapstr.M{matchIndexKey: 0},
+		},
+		{
+			name:     "second pattern",
+			settings: map[string]any{"patterns": patterns, "trace_match": true},
+			fields:   mapstr.M{"message": "10.0.0.1 connection reset"},
+			want: mapstr.M{
+				"message": "10.0.0.1 connection reset",
+				"source":  mapstr.M{"address": "10.0.0.1"},
+				"error":   mapstr.M{"message": "connection reset"},
+			},
+			meta: mapstr.M{matchIndexKey: 1},
+		},
+		{
+			name: "field and target prefix",
+			settings: map[string]any{
+				"patterns":      []string{`%{WORD:key}=%{WORD:value}`},
+				"field":         "event.original",
+				"target_prefix": "parsed",
+			},
+			fields: mapstr.M{"event": mapstr.M{"original": "user=alice"}},
+			want: mapstr.M{
+				"event":  mapstr.M{"original": "user=alice"},
+				"parsed": mapstr.M{"key": "user", "value": "alice"},
+			},
+		},
+		{
+			name: "pattern definitions",
+			settings: map[string]any{
+				"patterns":            []string{`%{LEVEL:log.level}: %{GREEDYDATA:message}`},
+				"pattern_definitions": map[string]any{"LEVEL": `DEBUG|INFO|WARN|ERROR`},
+			},
+			fields: mapstr.M{"message": "ERROR: disk full"},
+			want: mapstr.M{
+				"message": "disk full",
+				"log":     mapstr.M{"level": "ERROR"},
+			},
+		},
+		{
+			name: "overwrite keys disabled",
+			settings: map[string]any{
+				"patterns":       []string{`%{WORD:key}=%{WORD:message}`},
+				"overwrite_keys": false,
+			},
+			fields: mapstr.M{"message": "user=alice"},
+			want: mapstr.M{
+				"message": "user=alice",
+				"log":     mapstr.M{"flags": []string{flagParsingError}},
+			},
+			err: "cannot override existing key with `message`",
+		},
+		{
+			name:     "no match",
+			settings: map[string]any{"patterns": patterns},
+			fields:   mapstr.M{"message": "-"},
+			want: mapstr.M{
+				"message": "-",
+				"log":     mapstr.M{"flags": []string{flagParsingError}},
+			},
+			err: `failed to parse field "message": no pattern matched`,
+		},
+		{
+			name:     "no match ignore failure",
+			settings: map[string]any{"patterns": patterns, "ignore_failure": true},
+			fields:   mapstr.M{"message": "-"},
+			want: mapstr.M{
+				"message": "-",
+				"log":     mapstr.M{"flags": []string{flagParsingError}},
+			},
+		},
+		{
+			name: "conversion failure",
+			settings: map[string]any{
+				"patterns": []string{`%{NUMBER:value:int}`},
+			},
+			fields: mapstr.M{"message": "1.5"},
+			want: mapstr.M{
+				"message": "1.5",
+				"log":     mapstr.M{"flags": []string{flagParsingError}},
+			},
+			err: `failed to convert captured values`,
+		},
+		{
+			name:     "missing field",
+			settings: map[string]any{"patterns": patterns},
+			fields:   mapstr.M{},
+			want:     mapstr.M{"log": mapstr.M{"flags": []string{flagParsingError}}},
+			err:      `failed to get field "message"`,
+		},
+		{
+			name:     "missing field ignored",
+			settings: map[string]any{"patterns": patterns, "ignore_missing": true},
+			fields:   mapstr.M{},
+			want:     mapstr.M{},
+		},
+		{
+			name:     "not a string",
+			settings: map[string]any{"patterns": patterns},
+			fields:   mapstr.M{"message": 42},
+			want: mapstr.M{
+				"message": 42,
+				"log":     mapstr.M{"flags": []string{flagParsingError}},
+			},
+			err: "field is not a string",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestProcessor(t, tc.settings)
+
+			event, err := p.Run(&beat.Event{Fields: tc.fields})
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.want, event.Fields)
+			assert.Equal(t, tc.meta, event.Meta)
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		settings map[string]any
+		err      string
+	}{
+		{
+			name:     "no patterns",
+			settings: map[string]any{},
+			err:      "missing required field",
+		},
+		{
+			name: "empty patterns",
+			settings: map[string]any{
+				"patterns": []string{},
+			},
+			err: "empty array accessing 'patterns'",
+		},
+		{
+			name: "unknown pattern",
+			settings: map[string]any{
+				"patterns": []string{`%{NOT_A_PATTERN:x}`},
+			},
+			err: `unknown pattern "NOT_A_PATTERN"`,
+		},
+		{
+			name: "invalid pattern name",
+			settings: map[string]any{
+				"patterns":            []string{`%{WORD:x}`},
+				"pattern_definitions": map[string]any{"NOT-VALID": `\w+`},
+			},
+			err: `invalid pattern name "NOT-VALID"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := conf.NewConfigFrom(tc.settings)
+			require.NoError(t, err)
+			_, err = New(c, logptest.NewTestingLogger(t, ""))
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+// BenchmarkProcessor compares the grok processor with the dissect processor
+// parsing the same messages.
+func BenchmarkProcessor(b *testing.B) {
+	benchmarks := []struct {
+		name      string
+		pattern   string
+		tokenizer string
+		msg       string
+	}{
+		{
+			name:      "6_fields",
+			pattern:   `id=%{NOTSPACE:id} status=%{NOTSPACE:status} duration=%{NOTSPACE:duration} uptime=%{NOTSPACE:uptime} success=%{NOTSPACE:success} msg="%{DATA:message}"`,
+			tokenizer: `id=%{id} status=%{status} duration=%{duration} uptime=%{uptime} success=%{success} msg="%{message}"`,
+			msg:       `id=7736 status=202 duration=0.975 uptime=1588975628 success=true msg="Request accepted"`,
+		},
+		{
+			name:      "6_fields_with_conversion",
+			pattern:   `id=%{INT:id:int} status=%{INT:status:int} duration=%{NUMBER:duration:float} uptime=%{INT:uptime:long} success=%{WORD:success:boolean} msg="%{DATA:message}"`,
+			tokenizer: `id=%{id|integer} status=%{status|integer} duration=%{duration|float} uptime=%{uptime|long} success=%{success|boolean} msg="%{message}"`,
+			msg:       `id=7736 status=202 duration=0.975 uptime=1588975628 success=true msg="Request accepted"`,
+		},
+		{
+			name:      "envoy_access_log",
+			pattern:   `%{WORD:log_type} \[%{TIMESTAMP_ISO8601:timestamp}\] "%{WORD:method} %{NOTSPACE:path} %{NOTSPACE:proto}" %{INT:response_code} %{NOTSPACE:response_flags} %{INT:bytes_received} %{INT:bytes_sent} %{INT:duration} %{INT:upstream_service_time}`,
+			tokenizer: `%{log_type} [%{timestamp}] "%{method} %{path} %{proto}" %{response_code} %{response_flags} %{bytes_received} %{bytes_sent} %{duration} %{upstream_service_time}`,
+			msg:       `ACCESS [2026-04-08T12:00:00.000Z] "GET /api/v1/users HTTP/1.1" 200 - 0 1234 42 38`,
+		},
+		{
+			name:      "cisco_asa_ecs",
+			pattern:   `%{WORD:network.direction} %{WORD:network.transport} connection %{WORD:event.outcome} from %{IP:source.address}/%{INT:source.port} to %{IP:destination.address}/%{INT:destination.port} flags %{NOTSPACE} on interface %{NOTSPACE:observer.ingress.interface.name}`,
+			tokenizer: `%{network.direction} %{network.transport} connection %{event.outcome} from %{source.address}/%{source.port} to %{destination.address}/%{destination.port} flags %{} on interface %{observer.ingress.interface.name}`,
+			msg:       `Inbound TCP connection permitted from 192.168.1.100/44523 to 10.0.0.1/443 flags SYN on interface outside`,
+		},
+	}
+
+	run := func(b *testing.B, p beat.Processor, msg string) {
+		b.ReportAllocs()
+		for b.Loop() {
+			event := &beat.Event{Fields: mapstr.M{"message": msg}}
+			if _, err := p.Run(event); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name+"/grok", func(b *testing.B) {
+			p := newTestProcessor(b, map[string]any{
+				"patterns":      []string{bm.pattern},
+				"target_prefix": "parsed",
+			})
+			run(b, p, bm.msg)
+		})
+		b.Run(bm.name+"/dissect", func(b *testing.B) {
+			c, err := conf.NewConfigFrom(map[string]any{
+				"tokenizer":     bm.tokenizer,
+				"target_prefix": "parsed",
+			})
+			require.NoError(b, err)
+			p, err := dissect.NewProcessor(c, logptest.NewTestingLogger(b, ""))
+			require.NoError(b, err)
+			run(b, p, bm.msg)
+		})
+	}
+}
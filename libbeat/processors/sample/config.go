@@ -0,0 +1,77 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sample
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	modeProbabilistic = "probabilistic"
+	modeHash          = "hash"
+	modeReservoir     = "reservoir"
+)
+
+// config for sample processor.
+type config struct {
+	Mode        string          `config:"mode"`         // Sampling mode: probabilistic, hash or reservoir.
+	Rate        float64         `config:"rate"`         // Fraction of events kept in the probabilistic and hash modes.
+	Fields      []string        `config:"fields"`       // Fields hashed in the hash mode.
+	Reservoir   reservoirConfig `config:"reservoir"`    // Settings of the reservoir mode.
+	TargetField string          `config:"target_field"` // Field receiving the sample rate, disabled if empty.
+}
+
+type reservoirConfig struct {
+	Size     int           `config:"size"`     // Number of events kept per interval.
+	Interval time.Duration `config:"interval"` // Duration of the intervals.
+}
+
+func defaultConfig() config {
+	return config{
+		Mode: modeProbabilistic,
+		Reservoir: reservoirConfig{
+			Interval: time.Minute,
+		},
+		TargetField: "sample.rate",
+	}
+}
+
+// Validate checks the settings of the sampling mode.
+func (c *config) Validate() error {
+	switch c.Mode {
+	case modeProbabilistic, modeHash:
+		if c.Rate <= 0 || c.Rate > 1 {
+			return fmt.Errorf("rate must be greater than 0 and at most 1 in %s mode, got %v", c.Mode, c.Rate)
+		}
+		if c.Mode == modeHash && len(c.Fields) == 0 {
+			return errors.New("fields are required in hash mode")
+		}
+	case modeReservoir:
+		if c.Reservoir.Size <= 0 {
+			return fmt.Errorf("reservoir.size must be greater than 0, got %d", c.Reservoir.Size)
+		}
+		if c.Reservoir.Interval <= 0 {
+			return fmt.Errorf("reservoir.interval must be greater than 0, got %v", c.Reservoir.Interval)
+		}
+	default:
+		return fmt.Errorf("invalid mode %q, must be one of %s, %s or %s", c.Mode, modeProbabilistic, modeHash, modeReservoir)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sample
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor/registry"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+const (
+	processorName = "sample"
+	logName       = "processor." + processorName
+)
+
+func init() {
+	processors.RegisterPlugin(processorName, New)
+	jsprocessor.RegisterPlugin("Sample", New)
+}
+
+// instanceID is used to assign each instance a unique monitoring namespace.
+var instanceID atomic.Uint32
+
+// sample is a processor keeping a sample of the events. The kept events
+// record the probability with which they were kept, so the number of
+// original events can be estimated from the sample.
+type sample struct {
+	config  config
+	sampler sampler
+	log     *logp.Logger
+
+	sampled *monitoring.Int // Number of events kept.
+	dropped *monitoring.Int // Number of events dropped.
+}
+
+// reservoirSample is the sample processor in reservoir mode. It keeps a
+// number of events per interval, so each owner of the processor gets its
+// own instance instead of sharing the budget.
+type reservoirSample struct {
+	*sample
+}
+
+func (p reservoirSample) Unshareable() {}
+
+// New constructs a new sample processor.
+func New(cfg *conf.C, log *logp.Logger) (beat.Processor, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("failed to unpack the %s configuration: %w", processorName, err)
+	}
+
+	// Logging and metrics (each processor instance has a unique ID).
+	var (
+		id      = int(instanceID.Add(1))
+		metrics = monitoring.Default.GetOrCreateRegistry(logName+"."+strconv.Itoa(id), monitoring.DoNotReport)
+	)
+	log = log.Named(logName).With("instance_id", id)
+	log.Warn(cfgwarn.Beta("The " + processorName + " processor is beta."))
+
+	p := newSample(config, clockwork.NewRealClock(), metrics, log)
+	if config.Mode == modeReservoir {
+		return reservoirSample{p}, nil
+	}
+	return p, nil
+}
+
+func newSample(config config, clock clockwork.Clock, metrics *monitoring.Registry, log *logp.Logger) *sample {
+	var s sampler
+	switch config.Mode {
+	case modeHash:
+		s = newHashSampler(config.Rate, config.Fields)
+	case modeReservoir:
+		s = newReservoirSampler(config.Reservoir.Size, config.Reservoir.Interval, clock)
+	default:
+		s = probabilisticSampler{rate: config.Rate}
+	}
+	return &sample{
+		config:  config,
+		sampler: s,
+		log:     log,
+		sampled: monitoring.NewInt(metrics, "sampled"),
+		dropped: monitoring.NewInt(metrics, "dropped"),
+	}
+}
+
+// Run drops the events that are not part of the sample, and adds the sample
+// rate to the events that are.
+func (p *sample) Run(event *beat.Event) (*beat.Event, error) {
+	keep, rate := p.sampler.sample(event)
+	if !keep {
+		p.dropped.Inc()
+		return nil, nil
+	}
+	p.sampled.Inc()
+
+	if p.config.TargetField != "" {
+		if _, err := event.PutValue(p.config.TargetField, rate); err != nil {
+			return event, fmt.Errorf("failed to put the sample rate in field %q: %w", p.config.TargetField, err)
+		}
+	}
+	return event, nil
+}
+
+func (p *sample) String() string {
+	switch p.config.Mode {
+	case modeHash:
+		return fmt.Sprintf("%s=[mode=%s, rate=%v, fields=%v, target_field=%s]",
+			processorName, p.config.Mode, p.config.Rate, p.config.Fields, p.config.TargetField)
+	case modeReservoir:
+		return fmt.Sprintf("%s=[mode=%s, size=%d, interval=%v, target_field=%s]",
+			processorName, p.config.Mode, p.config.Reservoir.Size, p.config.Reservoir.Interval, p.config.TargetField)
+	default:
+		return fmt.Sprintf("%s=[mode=%s, rate=%v, target_field=%s]",
+			processorName, p.config.Mode, p.config.Rate, p.config.TargetField)
+	}
+}
@@ -0,0 +1,194 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sample
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+func TestNew(t *testing.T) {
+	cases := map[string]struct {
+		config mapstr.M
+		err    string
+	}{
+		"probabilistic": {
+			config: mapstr.M{"rate": 0.1},
+		},
+		"hash": {
+			config: mapstr.M{"mode": "hash", "rate": 0.1, "fields": []string{"trace.id"}},
+		},
+		"reservoir": {
+			config: mapstr.M{"mode": "reservoir", "reservoir.size": 10},
+		},
+		"missing rate": {
+			config: mapstr.M{},
+			err:    "rate must be greater than 0 and at most 1 in probabilistic mode",
+		},
+		"rate above 1": {
+			config: mapstr.M{"rate": 1.5},
+			err:    "rate must be greater than 0 and at most 1 in probabilistic mode",
+		},
+		"hash without fields": {
+			config: mapstr.M{"mode": "hash", "rate": 0.1},
+			err:    "fields are required in hash mode",
+		},
+		"reservoir without size": {
+			config: mapstr.M{"mode": "reservoir"},
+			err:    "reservoir.size must be greater than 0",
+		},
+		"invalid mode": {
+			config: mapstr.M{"mode": "foo", "rate": 0.1},
+			err:    `invalid mode "foo"`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(conf.MustNewConfigFrom(tc.config), logptest.NewTestingLogger(t, ""))
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestUnshareable(t *testing.T) {
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{"mode": "reservoir", "reservoir.size": 10}), logptest.NewTestingLogger(t, ""))
+	require.NoError(t, err)
+	assert.Implements(t, (*processors.Unshareable)(nil), p)
+
+	p, err = New(conf.MustNewConfigFrom(mapstr.M{"rate": 0.5}), logptest.NewTestingLogger(t, ""))
+	require.NoError(t, err)
+	assert.NotImplements(t, (*processors.Unshareable)(nil), p)
+}
+
+func TestProbabilistic(t *testing.T) {
+	p := newTestSample(t, mapstr.M{"rate": 0.25}, clockwork.NewRealClock())
+
+	const n = 10000
+	kept := runEvents(t, p, n, func(i int) mapstr.M { return mapstr.M{"message": "test"} })
+	assert.InDelta(t, n/4, len(kept), n/20)
+	for _, e := range kept {
+		assert.Equal(t, mapstr.M{"message": "test", "sample": mapstr.M{"rate": 0.25}}, e.Fields)
+	}
+	assert.Equal(t, int64(len(kept)), p.sampled.Get())
+	assert.Equal(t, int64(n-len(kept)), p.dropped.Get())
+}
+
+func TestHash(t *testing.T) {
+	config := mapstr.M{"mode": "hash", "rate": 0.3, "fields": []string{"trace.id"}, "target_field": "sampling"}
+	p := newTestSample(t, config, clockwork.NewRealClock())
+
+	const n = 1000
+	kept := runEvents(t, p, n, func(i int) mapstr.M {
+		return mapstr.M{"trace": mapstr.M{"id": strconv.Itoa(i)}}
+	})
+	assert.InDelta(t, 3*n/10, len(kept), n/10)
+
+	// The events of the same traces are kept, also by other instances.
+	other := newTestSample(t, config, clockwork.NewRealClock())
+	again := runEvents(t, other, n, func(i int) mapstr.M {
+		return mapstr.M{"trace": mapstr.M{"id": strconv.Itoa(i)}}
+	})
+	assert.Equal(t, kept, again)
+	for _, e := range kept {
+		rate, err := e.GetValue("sampling")
+		require.NoError(t, err)
+		assert.Equal(t, 0.3, rate)
+	}
+}
+
+func TestReservoir(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	p := newTestSample(t, mapstr.M{"mode": "reservoir", "reservoir": mapstr.M{"size": 100, "interval": "1m"}}, clock)
+	message := func(int) mapstr.M { return mapstr.M{"message": "test"} }
+
+	// The first events of an interval are all kept.
+	kept := runEvents(t, p, 100, message)
+	assert.Len(t, kept, 100)
+	for _, e := range kept {
+		assert.Equal(t, 1.0, e.Fields["sample"].(mapstr.M)["rate"])
+	}
+
+	// Then the rate decreases with the number of events.
+	kept = runEvents(t, p, 900, message)
+	assert.Less(t, len(kept), 300)
+	estimate := 100.0
+	for _, e := range kept {
+		estimate += 1 / e.Fields["sample"].(mapstr.M)["rate"].(float64)
+	}
+	assert.InDelta(t, 1000, estimate, 300)
+
+	// The next interval starts with the rate of the previous one.
+	clock.Advance(time.Minute)
+	kept = runEvents(t, p, 1000, message)
+	assert.InDelta(t, 100, len(kept), 40)
+	for _, e := range kept {
+		assert.InDelta(t, 0.1, e.Fields["sample"].(mapstr.M)["rate"], 0.001)
+	}
+
+	// After an interval without events, the events are all kept again.
+	clock.Advance(2 * time.Minute)
+	kept = runEvents(t, p, 10, message)
+	assert.Len(t, kept, 10)
+}
+
+func TestTargetFieldDisabled(t *testing.T) {
+	p := newTestSample(t, mapstr.M{"rate": 1, "target_field": ""}, clockwork.NewRealClock())
+
+	kept := runEvents(t, p, 1, func(int) mapstr.M { return mapstr.M{"message": "test"} })
+	require.Len(t, kept, 1)
+	assert.Equal(t, mapstr.M{"message": "test"}, kept[0].Fields)
+}
+
+func newTestSample(t *testing.T, config mapstr.M, clock clockwork.Clock) *sample {
+	t.Helper()
+
+	c := defaultConfig()
+	require.NoError(t, conf.MustNewConfigFrom(config).Unpack(&c))
+	return newSample(c, clock, monitoring.NewRegistry(), logptest.NewTestingLogger(t, ""))
+}
+
+func runEvents(t *testing.T, p *sample, n int, fields func(int) mapstr.M) []beat.Event {
+	t.Helper()
+
+	var kept []beat.Event
+	for i := range n {
+		out, err := p.Run(&beat.Event{Fields: fields(i)})
+		require.NoError(t, err)
+		if out != nil {
+			kept = append(kept, *out)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,149 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sample
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/jonboulle/clockwork"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+)
+
+// sampler decides which events are kept.
+type sampler interface {
+	// sample returns whether the event is kept, and the probability with
+	// which it was kept.
+	sample(event *beat.Event) (bool, float64)
+}
+
+// probabilisticSampler keeps each event with a fixed probability.
+type probabilisticSampler struct {
+	rate float64
+}
+
+func (s probabilisticSampler) sample(*beat.Event) (bool, float64) {
+	return rand.Float64() < s.rate, s.rate
+}
+
+// hashSampler keeps the events whose hash of the fields is in the rate
+// fraction of the hash space, so all the events with the same fields are
+// either kept or dropped, by all the Beats with the same settings.
+type hashSampler struct {
+	rate      float64
+	fields    []string
+	threshold uint64
+}
+
+func newHashSampler(rate float64, fields []string) *hashSampler {
+	s := &hashSampler{rate: rate, fields: fields, threshold: ^uint64(0)}
+	if rate < 1 {
+		s.threshold = uint64(rate * (1 << 64))
+	}
+	return s
+}
+
+func (s *hashSampler) sample(event *beat.Event) (bool, float64) {
+	key, found := s.key(event)
+	if !found {
+		// Events without any of the fields can't be sampled consistently.
+		return rand.Float64() < s.rate, s.rate
+	}
+	return xxhash.Sum64String(key) <= s.threshold, s.rate
+}
+
+// key returns the values of the fields, and whether any of the fields was
+// found in the event.
+func (s *hashSampler) key(event *beat.Event) (string, bool) {
+	var (
+		b     strings.Builder
+		found bool
+	)
+	for _, field := range s.fields {
+		b.WriteByte('|')
+		value, err := event.GetValue(field)
+		if err != nil {
+			continue
+		}
+		found = true
+		fmt.Fprint(&b, value)
+	}
+	return b.String(), found
+}
+
+// reservoirSampler keeps about size events per interval. As the events
+// can't be held back until the end of the interval, each event is kept with
+// the probability size/n, where n is the number of events of the previous
+// interval, or the number of events of the current interval so far if
+// greater. The size first events of a burst are all kept.
+type reservoirSampler struct {
+	size     int
+	interval time.Duration
+	clock    clockwork.Clock
+
+	mu       sync.Mutex
+	start    time.Time // Start of the current interval.
+	previous int       // Number of events of the previous interval.
+	current  int       // Number of events of the current interval.
+}
+
+func newReservoirSampler(size int, interval time.Duration, clock clockwork.Clock) *reservoirSampler {
+	return &reservoirSampler{
+		size:     size,
+		interval: interval,
+		clock:    clock,
+		start:    clock.Now(),
+	}
+}
+
+func (s *reservoirSampler) sample(*beat.Event) (bool, float64) {
+	rate := s.rate()
+	if rate >= 1 {
+		return true, 1
+	}
+	return rand.Float64() < rate, rate
+}
+
+// rate counts an event and returns the probability with which it is kept.
+func (s *reservoirSampler) rate() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elapsed := s.clock.Since(s.start); elapsed >= s.interval {
+		if elapsed >= 2*s.interval {
+			// No event during the previous interval.
+			s.previous = 0
+		} else {
+			s.previous = s.current
+		}
+		s.current = 0
+		s.start = s.start.Add(elapsed.Truncate(s.interval))
+	}
+	s.current++
+
+	n := max(s.previous, s.current)
+	if n <= s.size {
+		return 1
+	}
+	return float64(s.size) / float64(n)
+}
@@ -34,8 +34,10 @@ import (
 	"github.com/elastic/beats/v7/libbeat/publisher/processing"
 	"github.com/elastic/beats/v7/libbeat/publisher/queue"
 	"github.com/elastic/beats/v7/libbeat/publisher/queue/diskqueue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/hybridqueue"
 	"github.com/elastic/beats/v7/libbeat/publisher/queue/memqueue"
 	"github.com/elastic/beats/v7/libbeat/publisher/queue/slabqueue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/snapshot"
 	conf "github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/paths"
 )
@@ -469,6 +471,7 @@ func (p *Pipeline) ConnectWith(cfg beat.ClientConfig) (beat.Client, error) {
 				ackHandler.ACKEvents(count)
 			}
 		},
+		Priority: queuePriority(cfg.Priority),
 	}
 
 	if ackHandler == nil {
@@ -495,6 +498,19 @@ func (p *Pipeline) ConnectWith(cfg beat.ClientConfig) (beat.Client, error) {
 	return client, nil
 }
 
+// queuePriority returns the queue lane for events of the given client
+// priority.
+func queuePriority(priority beat.Priority) queue.Priority {
+	switch priority {
+	case beat.HighPriority:
+		return queue.PriorityHigh
+	case beat.LowPriority:
+		return queue.PriorityLow
+	default:
+		return queue.PriorityNormal
+	}
+}
+
 func (p *Pipeline) createEventProcessing(cfg beat.ProcessingConfig, noPublish bool) (beat.Processor, error) {
 	if p.processors == nil {
 		return nil, nil
@@ -524,24 +540,52 @@ func queueFactoryForUserConfig(queueType string, userConfig *conf.C, paths *path
 		if err != nil {
 			return nil, nil, err
 		}
-		return memqueue.FactoryForSettings[publisher.Event](settings), settings, nil
+		factory, err := withSnapshot(memqueue.FactoryForSettings[publisher.Event](settings), userConfig, paths)
+		if err != nil {
+			return nil, nil, err
+		}
+		return factory, settings, nil
 	case slabqueue.QueueType:
 		settings, err := slabqueue.SettingsForUserConfig(userConfig)
 		if err != nil {
 			return nil, nil, err
 		}
-		return slabqueue.FactoryForSettings[publisher.Event](settings), settings, nil
+		factory, err := withSnapshot(slabqueue.FactoryForSettings[publisher.Event](settings), userConfig, paths)
+		if err != nil {
+			return nil, nil, err
+		}
+		return factory, settings, nil
 	case diskqueue.QueueType:
 		settings, err := diskqueue.SettingsForUserConfig(userConfig)
 		if err != nil {
 			return nil, nil, err
 		}
 		return diskqueue.FactoryForSettings(settings, paths), settings, nil
+	case hybridqueue.QueueType:
+		settings, err := hybridqueue.SettingsForUserConfig(userConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		return hybridqueue.FactoryForSettings(settings, paths), settings, nil
 	default:
 		return nil, nil, fmt.Errorf("unrecognized queue type '%v'", queueType)
 	}
 }
 
+// withSnapshot wraps the factory of an in-memory queue so it saves the
+// events left when it is closed, if configured to.
+func withSnapshot(
+	factory queue.QueueFactory[publisher.Event],
+	userConfig *conf.C,
+	paths *paths.Path,
+) (queue.QueueFactory[publisher.Event], error) {
+	settings, err := snapshot.SettingsForUserConfig(userConfig)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.FactoryForSettings(settings, paths, factory), nil
+}
+
 type noopReloader struct{}
 
 func (n noopReloader) Reload(
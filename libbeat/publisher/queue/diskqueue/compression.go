@@ -18,62 +18,193 @@
 package diskqueue
 
 import (
+	"fmt"
 	"io"
+	"strings"
 
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	lz4V4 "github.com/pierrec/lz4/v4"
 )
 
-// CompressionReader allows reading a stream compressed with LZ4
+// CompressionAlgorithm selects the compression of new segments. The
+// algorithm of each segment is recorded in its header options, so
+// segments written with different algorithms can always be read back.
+type CompressionAlgorithm uint8
+
+const (
+	// CompressionLZ4 is the zero value so that segments written before the
+	// algorithm was recorded in the header are read as LZ4.
+	CompressionLZ4 CompressionAlgorithm = iota
+	CompressionZstd
+	CompressionSnappy
+)
+
+var compressionAlgorithmNames = map[CompressionAlgorithm]string{
+	CompressionLZ4:    "lz4",
+	CompressionZstd:   "zstd",
+	CompressionSnappy: "snappy",
+}
+
+// maxCompressionLevels is the highest compression level accepted for each
+// algorithm. Level 0 always selects the algorithm's default.
+var maxCompressionLevels = map[CompressionAlgorithm]int{
+	CompressionLZ4:    9,
+	CompressionZstd:   22,
+	CompressionSnappy: 0,
+}
+
+var lz4Levels = []lz4V4.CompressionLevel{
+	lz4V4.Fast,
+	lz4V4.Level1, lz4V4.Level2, lz4V4.Level3,
+	lz4V4.Level4, lz4V4.Level5, lz4V4.Level6,
+	lz4V4.Level7, lz4V4.Level8, lz4V4.Level9,
+}
+
+func (a CompressionAlgorithm) String() string {
+	if name, ok := compressionAlgorithmNames[a]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(a))
+}
+
+// ParseCompressionAlgorithm returns the algorithm with the given name.
+func ParseCompressionAlgorithm(name string) (CompressionAlgorithm, error) {
+	for algorithm, algorithmName := range compressionAlgorithmNames {
+		if strings.EqualFold(name, algorithmName) {
+			return algorithm, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown compression algorithm '%s'", name)
+}
+
+func validateCompressionLevel(algorithm CompressionAlgorithm, level int) error {
+	maxLevel, ok := maxCompressionLevels[algorithm]
+	if !ok {
+		return fmt.Errorf("unknown compression algorithm %d", algorithm)
+	}
+	if level < 0 || level > maxLevel {
+		return fmt.Errorf("%v compression level must be between 0 and %d, got %d", algorithm, maxLevel, level)
+	}
+	return nil
+}
+
+// decompressor is the common interface of the stream decoders.
+type decompressor interface {
+	io.Reader
+	Reset(io.Reader) error
+}
+
+// compressor is the common interface of the stream encoders.
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+}
+
+type lz4Decompressor struct{ *lz4V4.Reader }
+
+func (d lz4Decompressor) Reset(r io.Reader) error {
+	d.Reader.Reset(r)
+	return nil
+}
+
+type snappyDecompressor struct{ *snappy.Reader }
+
+func (d snappyDecompressor) Reset(r io.Reader) error {
+	d.Reader.Reset(r)
+	return nil
+}
+
+// CompressionReader allows reading a compressed stream
 type CompressionReader struct {
-	src        io.ReadCloser
-	pLZ4Reader *lz4V4.Reader
+	src          io.ReadCloser
+	decompressor decompressor
+	// zstdDecoder is kept to release its resources on Close.
+	zstdDecoder *zstd.Decoder
 }
 
-// NewCompressionReader returns a new LZ4 frame decoder
-func NewCompressionReader(r io.ReadCloser) *CompressionReader {
-	zr := lz4V4.NewReader(r)
-	return &CompressionReader{
-		src:        r,
-		pLZ4Reader: zr,
+// NewCompressionReader returns a new decoder for the given algorithm
+func NewCompressionReader(r io.ReadCloser, algorithm CompressionAlgorithm) (*CompressionReader, error) {
+	cr := &CompressionReader{src: r}
+	switch algorithm {
+	case CompressionLZ4:
+		cr.decompressor = lz4Decompressor{lz4V4.NewReader(r)}
+	case CompressionZstd:
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("could not create zstd decoder: %w", err)
+		}
+		cr.zstdDecoder = zr
+		cr.decompressor = zr
+	case CompressionSnappy:
+		cr.decompressor = snappyDecompressor{snappy.NewReader(r)}
+	default:
+		return nil, fmt.Errorf("unknown compression algorithm %d", algorithm)
 	}
+	return cr, nil
 }
 
 func (r *CompressionReader) Read(buf []byte) (int, error) {
-	return r.pLZ4Reader.Read(buf)
+	return r.decompressor.Read(buf)
 }
 
 func (r *CompressionReader) Close() error {
+	if r.zstdDecoder != nil {
+		r.zstdDecoder.Close()
+	}
 	return r.src.Close()
 }
 
 // Reset Sets up compression again, assumes that caller has already set
 // the src to the correct position
 func (r *CompressionReader) Reset() error {
-	r.pLZ4Reader.Reset(r.src)
-	return nil
+	return r.decompressor.Reset(r.src)
 }
 
-// CompressionWriter allows writing an LZ4 stream
+// CompressionWriter allows writing a compressed stream
 type CompressionWriter struct {
 	dst        WriteCloseSyncer
-	pLZ4Writer *lz4V4.Writer
+	compressor compressor
 }
 
-// NewCompressionWriter returns a new LZ4 frame encoder
-func NewCompressionWriter(w WriteCloseSyncer) *CompressionWriter {
-	zw := lz4V4.NewWriter(w)
-	return &CompressionWriter{
-		dst:        w,
-		pLZ4Writer: zw,
+// NewCompressionWriter returns a new encoder for the given algorithm and
+// level, level 0 selects the default level of the algorithm.
+func NewCompressionWriter(w WriteCloseSyncer, algorithm CompressionAlgorithm, level int) (*CompressionWriter, error) {
+	if err := validateCompressionLevel(algorithm, level); err != nil {
+		return nil, err
+	}
+	cw := &CompressionWriter{dst: w}
+	switch algorithm {
+	case CompressionLZ4:
+		zw := lz4V4.NewWriter(w)
+		if level > 0 {
+			if err := zw.Apply(lz4V4.CompressionLevelOption(lz4Levels[level])); err != nil {
+				return nil, fmt.Errorf("could not set lz4 compression level: %w", err)
+			}
+		}
+		cw.compressor = zw
+	case CompressionZstd:
+		options := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+		if level > 0 {
+			options = append(options, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		zw, err := zstd.NewWriter(w, options...)
+		if err != nil {
+			return nil, fmt.Errorf("could not create zstd encoder: %w", err)
+		}
+		cw.compressor = zw
+	case CompressionSnappy:
+		cw.compressor = snappy.NewBufferedWriter(w)
 	}
+	return cw, nil
 }
 
 func (w *CompressionWriter) Write(p []byte) (int, error) {
-	return w.pLZ4Writer.Write(p)
+	return w.compressor.Write(p)
 }
 
 func (w *CompressionWriter) Close() error {
-	err := w.pLZ4Writer.Close()
+	err := w.compressor.Close()
 	if err != nil {
 		return err
 	}
@@ -81,6 +212,8 @@ func (w *CompressionWriter) Close() error {
 }
 
 func (w *CompressionWriter) Sync() error {
-	w.pLZ4Writer.Flush()
+	if err := w.compressor.Flush(); err != nil {
+		return err
+	}
 	return w.dst.Sync()
 }
@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type nopWriteCloser struct {
@@ -66,7 +67,8 @@ func TestCompressionReader(t *testing.T) {
 	for name, tc := range tests {
 		dst := make([]byte, len(tc.plaintext))
 		src := bytes.NewReader(tc.compressed)
-		cr := NewCompressionReader(io.NopCloser(src))
+		cr, err := NewCompressionReader(io.NopCloser(src), CompressionLZ4)
+		require.NoError(t, err, name)
 		n, err := cr.Read(dst)
 		assert.NoError(t, err, name)
 		assert.Equal(t, len(tc.plaintext), n, name)
@@ -94,7 +96,8 @@ func TestCompressionWriter(t *testing.T) {
 
 	for name, tc := range tests {
 		var dst bytes.Buffer
-		cw := NewCompressionWriter(NopWriteCloseSyncer(NopWriteCloser(&dst)))
+		cw, err := NewCompressionWriter(NopWriteCloseSyncer(NopWriteCloser(&dst)), CompressionLZ4, 0)
+		require.NoError(t, err, name)
 		n, err := cw.Write(tc.plaintext)
 		cw.Close()
 		assert.NoError(t, err, name)
@@ -113,29 +116,41 @@ type nopWriteCloseSyncer struct {
 
 func (nopWriteCloseSyncer) Sync() error { return nil }
 
+var testCompressionAlgorithms = []CompressionAlgorithm{
+	CompressionLZ4,
+	CompressionZstd,
+	CompressionSnappy,
+}
+
 func TestCompressionRoundTrip(t *testing.T) {
 	tests := map[string]struct {
 		plaintext []byte
 	}{
 		"no repeat":  {plaintext: []byte("abcdefghijklmnopqrstuvwxzy01234567890ABCDEFGHIJKLMNOPQRSTUVWXYZ")},
-		"256 repeat": {plaintext: []byte("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")},
+		"256 repeat": {plaintext: []byte("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")},
 	}
-	for name, tc := range tests {
-		pr, pw := io.Pipe()
-		src := bytes.NewReader(tc.plaintext)
-		var dst bytes.Buffer
-
-		go func() {
-			cw := NewCompressionWriter(NopWriteCloseSyncer(pw))
-			_, err := io.Copy(cw, src)
+	for _, algorithm := range testCompressionAlgorithms {
+		for name, tc := range tests {
+			name := algorithm.String() + " " + name
+			pr, pw := io.Pipe()
+			src := bytes.NewReader(tc.plaintext)
+			var dst bytes.Buffer
+
+			go func() {
+				cw, err := NewCompressionWriter(NopWriteCloseSyncer(pw), algorithm, 0)
+				assert.NoError(t, err, name)
+				_, err = io.Copy(cw, src)
+				assert.NoError(t, err, name)
+				cw.Close()
+			}()
+
+			cr, err := NewCompressionReader(pr, algorithm)
+			require.NoError(t, err, name)
+			_, err = io.Copy(&dst, cr)
 			assert.NoError(t, err, name)
-			cw.Close()
-		}()
-
-		cr := NewCompressionReader(pr)
-		_, err := io.Copy(&dst, cr)
-		assert.NoError(t, err, name)
-		assert.Equal(t, tc.plaintext, dst.Bytes(), name)
+			assert.Equal(t, tc.plaintext, dst.Bytes(), name)
+			cr.Close()
+		}
 	}
 }
 
@@ -144,30 +159,59 @@ func TestCompressionSync(t *testing.T) {
 		plaintext []byte
 	}{
 		"no repeat":  {plaintext: []byte("abcdefghijklmnopqrstuvwxzy01234567890ABCDEFGHIJKLMNOPQRSTUVWXYZ")},
-		"256 repeat": {plaintext: []byte("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")},
+		"256 repeat": {plaintext: []byte("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")},
 	}
-	for name, tc := range tests {
-		pr, pw := io.Pipe()
-		var dst bytes.Buffer
-		go func() {
-			cw := NewCompressionWriter(NopWriteCloseSyncer(pw))
-			src1 := bytes.NewReader(tc.plaintext)
-			_, err := io.Copy(cw, src1)
-			assert.NoError(t, err, name)
-			// prior to v4.1.15 of pierrec/lz4 there was a
-			// bug that prevented writing after a Flush.
-			// The call to Sync here exercises Flush.
-			err = cw.Sync()
+	for _, algorithm := range testCompressionAlgorithms {
+		for name, tc := range tests {
+			name := algorithm.String() + " " + name
+			pr, pw := io.Pipe()
+			var dst bytes.Buffer
+			go func() {
+				cw, err := NewCompressionWriter(NopWriteCloseSyncer(pw), algorithm, 0)
+				assert.NoError(t, err, name)
+				src1 := bytes.NewReader(tc.plaintext)
+				_, err = io.Copy(cw, src1)
+				assert.NoError(t, err, name)
+				// prior to v4.1.15 of pierrec/lz4 there was a
+				// bug that prevented writing after a Flush.
+				// The call to Sync here exercises Flush.
+				err = cw.Sync()
+				assert.NoError(t, err, name)
+				src2 := bytes.NewReader(tc.plaintext)
+				_, err = io.Copy(cw, src2)
+				assert.NoError(t, err, name)
+				cw.Close()
+			}()
+			cr, err := NewCompressionReader(pr, algorithm)
+			require.NoError(t, err, name)
+			_, err = io.Copy(&dst, cr)
 			assert.NoError(t, err, name)
-			src2 := bytes.NewReader(tc.plaintext)
-			_, err = io.Copy(cw, src2)
-			assert.NoError(t, err, name)
-			cw.Close()
-		}()
-		cr := NewCompressionReader(pr)
-		_, err := io.Copy(&dst, cr)
-		assert.NoError(t, err, name)
-		assert.Equal(t, tc.plaintext, dst.Bytes()[:len(tc.plaintext)], name)
-		assert.Equal(t, tc.plaintext, dst.Bytes()[len(tc.plaintext):], name)
+			assert.Equal(t, tc.plaintext, dst.Bytes()[:len(tc.plaintext)], name)
+			assert.Equal(t, tc.plaintext, dst.Bytes()[len(tc.plaintext):], name)
+			cr.Close()
+		}
+	}
+}
+
+func TestCompressionLevels(t *testing.T) {
+	plaintext := bytes.Repeat([]byte("compression level test "), 1000)
+	for _, algorithm := range testCompressionAlgorithms {
+		for level := 0; level <= maxCompressionLevels[algorithm]; level++ {
+			var dst bytes.Buffer
+			cw, err := NewCompressionWriter(NopWriteCloseSyncer(NopWriteCloser(&dst)), algorithm, level)
+			require.NoError(t, err, "%v level %d", algorithm, level)
+			_, err = cw.Write(plaintext)
+			require.NoError(t, err)
+			require.NoError(t, cw.Close())
+
+			cr, err := NewCompressionReader(io.NopCloser(&dst), algorithm)
+			require.NoError(t, err)
+			decompressed, err := io.ReadAll(cr)
+			require.NoError(t, err, "%v level %d", algorithm, level)
+			assert.Equal(t, plaintext, decompressed, "%v level %d", algorithm, level)
+			cr.Close()
+		}
+		_, err := NewCompressionWriter(NopWriteCloseSyncer(NopWriteCloser(&bytes.Buffer{})), algorithm, maxCompressionLevels[algorithm]+1)
+		assert.Error(t, err, "%v level above maximum must be rejected", algorithm)
 	}
 }
@@ -64,8 +64,19 @@ type Settings struct {
 	RetryInterval    time.Duration
 	MaxRetryInterval time.Duration
 
-	// UseCompression enables or disables LZ4 compression
+	// UseCompression enables or disables compression of new segments
 	UseCompression bool
+
+	// CompressionAlgorithm and CompressionLevel select how new segments
+	// are compressed when UseCompression is set. Level 0 is the default
+	// level of the algorithm.
+	CompressionAlgorithm CompressionAlgorithm
+	CompressionLevel     int
+
+	// EncryptionKey enables AES-GCM encryption of new segments when set.
+	// It is also required to read segments that were written encrypted.
+	// Use EncryptionKeyFromSecret to derive it from a user secret.
+	EncryptionKey []byte
 }
 
 // userConfig holds the parameters for a disk queue that are configurable
@@ -80,6 +91,16 @@ type userConfig struct {
 
 	RetryInterval    *time.Duration `config:"retry_interval" validate:"positive"`
 	MaxRetryInterval *time.Duration `config:"max_retry_interval" validate:"positive"`
+
+	// Compression is the algorithm used for new segments: "none" (the
+	// default), "lz4", "zstd" or "snappy".
+	Compression      string `config:"compression"`
+	CompressionLevel int    `config:"compression_level"`
+
+	// EncryptionKey is a secret the segment encryption key is derived from.
+	// It is usually a reference to a keystore entry, e.g.
+	// "${DISKQUEUE_ENCRYPTION_KEY}".
+	EncryptionKey string `config:"encryption_key"`
 }
 
 func (c *userConfig) Validate() error {
@@ -112,6 +133,18 @@ func (c *userConfig) Validate() error {
 			*c.MaxRetryInterval, *c.RetryInterval)
 	}
 
+	if c.Compression != "" && c.Compression != "none" {
+		algorithm, err := ParseCompressionAlgorithm(c.Compression)
+		if err != nil {
+			return fmt.Errorf("disk queue compression: %w", err)
+		}
+		if err := validateCompressionLevel(algorithm, c.CompressionLevel); err != nil {
+			return fmt.Errorf("disk queue compression_level: %w", err)
+		}
+	} else if c.CompressionLevel != 0 {
+		return errors.New("disk queue compression_level requires compression to be enabled")
+	}
+
 	return nil
 }
 
@@ -164,6 +197,17 @@ func SettingsForUserConfig(config *config.C) (Settings, error) {
 		settings.MaxRetryInterval = *userConfig.MaxRetryInterval
 	}
 
+	if userConfig.Compression != "" && userConfig.Compression != "none" {
+		// Validate() ensures the algorithm is known.
+		settings.UseCompression = true
+		settings.CompressionAlgorithm, _ = ParseCompressionAlgorithm(userConfig.Compression)
+		settings.CompressionLevel = userConfig.CompressionLevel
+	}
+
+	if userConfig.EncryptionKey != "" {
+		settings.EncryptionKey = EncryptionKeyFromSecret(userConfig.EncryptionKey)
+	}
+
 	return settings, nil
 }
 
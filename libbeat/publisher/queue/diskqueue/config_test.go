@@ -22,7 +22,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/paths"
 )
 
@@ -57,3 +59,67 @@ func TestDirectoryPath(t *testing.T) {
 		})
 	}
 }
+
+func TestSettingsForUserConfigCompression(t *testing.T) {
+	tests := map[string]struct {
+		config    map[string]any
+		err       bool
+		compress  bool
+		algorithm CompressionAlgorithm
+		level     int
+	}{
+		"disabled by default": {
+			config: map[string]any{},
+		},
+		"none": {
+			config: map[string]any{"compression": "none"},
+		},
+		"lz4": {
+			config:    map[string]any{"compression": "lz4"},
+			compress:  true,
+			algorithm: CompressionLZ4,
+		},
+		"zstd with level": {
+			config:    map[string]any{"compression": "zstd", "compression_level": 3},
+			compress:  true,
+			algorithm: CompressionZstd,
+			level:     3,
+		},
+		"snappy": {
+			config:    map[string]any{"compression": "snappy"},
+			compress:  true,
+			algorithm: CompressionSnappy,
+		},
+		"unknown algorithm": {
+			config: map[string]any{"compression": "gzip"},
+			err:    true,
+		},
+		"level out of range": {
+			config: map[string]any{"compression": "lz4", "compression_level": 12},
+			err:    true,
+		},
+		"snappy has no levels": {
+			config: map[string]any{"compression": "snappy", "compression_level": 1},
+			err:    true,
+		},
+		"level without compression": {
+			config: map[string]any{"compression_level": 1},
+			err:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.config["max_size"] = "1GB"
+			settings, err := SettingsForUserConfig(config.MustNewConfigFrom(test.config))
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.compress, settings.UseCompression)
+			assert.Equal(t, test.algorithm, settings.CompressionAlgorithm)
+			assert.Equal(t, test.level, settings.CompressionLevel)
+		})
+	}
+}
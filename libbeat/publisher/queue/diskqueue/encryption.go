@@ -0,0 +1,223 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package diskqueue
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// encryptionChunkSize is the maximum amount of plaintext sealed in a single
+// AES-GCM chunk. Smaller chunks are written when the writer is synced.
+const encryptionChunkSize = 64 * 1024
+
+// Each sealed chunk is prefixed with its length. The AEAD prepends a random
+// nonce and appends the authentication tag, so the on-disk length is always
+// bigger than the plaintext length.
+const encryptionChunkHeaderSize = 4
+
+// EncryptionKeyFromSecret derives the 256 bit AES key used for segment
+// encryption from a user provided secret of any length.
+func EncryptionKeyFromSecret(secret string) []byte {
+	key := sha256.Sum256([]byte(secret))
+	return key[:]
+}
+
+func newSegmentAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not create AES cipher: %w", err)
+	}
+	aead, err := cipher.NewGCMWithRandomNonce(block)
+	if err != nil {
+		return nil, fmt.Errorf("could not create AES-GCM cipher: %w", err)
+	}
+	return aead, nil
+}
+
+// chunkAdditionalData binds a sealed chunk to its position in the segment,
+// so chunks can't be reordered or dropped without detection.
+func chunkAdditionalData(buf *[8]byte, index uint64) []byte {
+	binary.LittleEndian.PutUint64(buf[:], index)
+	return buf[:]
+}
+
+// EncryptionReader allows reading a stream written by EncryptionWriter
+type EncryptionReader struct {
+	src  io.ReadCloser
+	aead cipher.AEAD
+
+	// index of the next chunk to be read
+	index uint64
+	// decrypted data from the current chunk not yet returned to the caller,
+	// a sub slice of buffer
+	plaintext []byte
+	buffer    []byte
+	sealed    []byte
+	ad        [8]byte
+}
+
+// NewEncryptionReader returns a new AES-GCM decrypter
+func NewEncryptionReader(r io.ReadCloser, key []byte) (*EncryptionReader, error) {
+	aead, err := newSegmentAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptionReader{
+		src:  r,
+		aead: aead,
+	}, nil
+}
+
+func (r *EncryptionReader) Read(buf []byte) (int, error) {
+	for len(r.plaintext) == 0 {
+		if err := r.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(buf, r.plaintext)
+	r.plaintext = r.plaintext[n:]
+	return n, nil
+}
+
+func (r *EncryptionReader) readChunk() error {
+	var header [encryptionChunkHeaderSize]byte
+	if _, err := io.ReadFull(r.src, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("truncated encrypted chunk header: %w", err)
+		}
+		return err
+	}
+	size := binary.LittleEndian.Uint32(header[:])
+	if size < uint32(r.aead.NonceSize()+r.aead.Overhead()) || size > 2*encryptionChunkSize {
+		return fmt.Errorf("invalid encrypted chunk size %d", size)
+	}
+
+	if cap(r.sealed) < int(size) {
+		r.sealed = make([]byte, size)
+	}
+	r.sealed = r.sealed[:size]
+	if _, err := io.ReadFull(r.src, r.sealed); err != nil {
+		return fmt.Errorf("truncated encrypted chunk: %w", err)
+	}
+
+	plaintext, err := r.aead.Open(r.buffer[:0], nil, r.sealed, chunkAdditionalData(&r.ad, r.index))
+	if err != nil {
+		return fmt.Errorf("could not decrypt chunk %d: %w", r.index, err)
+	}
+	r.buffer = plaintext
+	r.plaintext = plaintext
+	r.index++
+	return nil
+}
+
+func (r *EncryptionReader) Close() error {
+	return r.src.Close()
+}
+
+// Reset Sets up decryption again, assumes that caller has already set
+// the src to the correct position
+func (r *EncryptionReader) Reset() error {
+	r.index = 0
+	r.plaintext = nil
+	return nil
+}
+
+// EncryptionWriter allows writing an AES-GCM encrypted stream. Data is
+// buffered and sealed in chunks of at most encryptionChunkSize bytes, a
+// partial chunk is written on Sync and Close.
+type EncryptionWriter struct {
+	dst  WriteCloseSyncer
+	aead cipher.AEAD
+
+	// index of the next chunk to be written
+	index  uint64
+	buffer []byte
+	sealed []byte
+	ad     [8]byte
+}
+
+// NewEncryptionWriter returns a new AES-GCM encrypter
+func NewEncryptionWriter(w WriteCloseSyncer, key []byte) (*EncryptionWriter, error) {
+	aead, err := newSegmentAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptionWriter{
+		dst:    w,
+		aead:   aead,
+		buffer: make([]byte, 0, encryptionChunkSize),
+	}, nil
+}
+
+func (w *EncryptionWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), encryptionChunkSize-len(w.buffer))
+		w.buffer = append(w.buffer, p[:n]...)
+		p = p[n:]
+		written += n
+		if len(w.buffer) == encryptionChunkSize {
+			if err := w.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// flush seals the buffered plaintext and writes it as a single chunk.
+func (w *EncryptionWriter) flush() error {
+	if len(w.buffer) == 0 {
+		return nil
+	}
+	if cap(w.sealed) < encryptionChunkHeaderSize {
+		w.sealed = make([]byte, encryptionChunkHeaderSize)
+	}
+	sealed := w.aead.Seal(w.sealed[:encryptionChunkHeaderSize], nil, w.buffer, chunkAdditionalData(&w.ad, w.index))
+	binary.LittleEndian.PutUint32(sealed, uint32(len(sealed)-encryptionChunkHeaderSize)) //nolint:gosec // G115 - bounded by encryptionChunkSize
+	w.sealed = sealed
+
+	// The chunk is written with a single call, so a reader never observes
+	// a partially written chunk unless the write itself failed.
+	if _, err := w.dst.Write(sealed); err != nil {
+		return err
+	}
+	w.index++
+	w.buffer = w.buffer[:0]
+	return nil
+}
+
+func (w *EncryptionWriter) Close() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.dst.Close()
+}
+
+func (w *EncryptionWriter) Sync() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.dst.Sync()
+}
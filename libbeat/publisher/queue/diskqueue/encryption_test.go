@@ -0,0 +1,111 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package diskqueue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	tests := map[string]struct {
+		plaintext []byte
+	}{
+		"small":           {plaintext: []byte("abcdefghijklmnopqrstuvwxzy01234567890ABCDEFGHIJKLMNOPQRSTUVWXYZ")},
+		"multiple chunks": {plaintext: bytes.Repeat([]byte("0123456789"), encryptionChunkSize/4)},
+	}
+	key := EncryptionKeyFromSecret("secret")
+	for name, tc := range tests {
+		var sealed bytes.Buffer
+		ew, err := NewEncryptionWriter(NopWriteCloseSyncer(NopWriteCloser(&sealed)), key)
+		require.NoError(t, err, name)
+		n, err := ew.Write(tc.plaintext)
+		require.NoError(t, err, name)
+		assert.Equal(t, len(tc.plaintext), n, name)
+		require.NoError(t, ew.Close(), name)
+
+		assert.NotContains(t, sealed.String(), string(tc.plaintext[:16]), name)
+
+		er, err := NewEncryptionReader(io.NopCloser(&sealed), key)
+		require.NoError(t, err, name)
+		var dst bytes.Buffer
+		_, err = io.Copy(&dst, er)
+		require.NoError(t, err, name)
+		assert.Equal(t, tc.plaintext, dst.Bytes(), name)
+	}
+}
+
+func TestEncryptionSync(t *testing.T) {
+	key := EncryptionKeyFromSecret("secret")
+	pr, pw := io.Pipe()
+	plaintext := []byte("abc")
+	go func() {
+		ew, err := NewEncryptionWriter(NopWriteCloseSyncer(pw), key)
+		assert.NoError(t, err)
+		_, err = ew.Write(plaintext)
+		assert.NoError(t, err)
+		// Sync must make the buffered data available to readers.
+		assert.NoError(t, ew.Sync())
+		_, err = ew.Write(plaintext)
+		assert.NoError(t, err)
+		ew.Close()
+	}()
+
+	er, err := NewEncryptionReader(pr, key)
+	require.NoError(t, err)
+	var dst bytes.Buffer
+	_, err = io.Copy(&dst, er)
+	require.NoError(t, err)
+	assert.Equal(t, append(plaintext, plaintext...), dst.Bytes())
+}
+
+func TestEncryptionDetectsTampering(t *testing.T) {
+	key := EncryptionKeyFromSecret("secret")
+	var sealed bytes.Buffer
+	ew, err := NewEncryptionWriter(NopWriteCloseSyncer(NopWriteCloser(&sealed)), key)
+	require.NoError(t, err)
+	_, err = ew.Write([]byte("first"))
+	require.NoError(t, err)
+	require.NoError(t, ew.Sync())
+	_, err = ew.Write([]byte("second"))
+	require.NoError(t, err)
+	require.NoError(t, ew.Close())
+
+	t.Run("modified ciphertext", func(t *testing.T) {
+		data := bytes.Clone(sealed.Bytes())
+		data[len(data)-1] ^= 0xff
+		er, err := NewEncryptionReader(io.NopCloser(bytes.NewReader(data)), key)
+		require.NoError(t, err)
+		_, err = io.ReadAll(er)
+		assert.Error(t, err)
+	})
+
+	t.Run("dropped chunk", func(t *testing.T) {
+		data := sealed.Bytes()
+		firstSize := encryptionChunkHeaderSize + int(binary.LittleEndian.Uint32(data))
+		er, err := NewEncryptionReader(io.NopCloser(bytes.NewReader(data[firstSize:])), key)
+		require.NoError(t, err)
+		_, err = io.ReadAll(er)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,237 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package diskqueue
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+// The functions in this file give read-only access to the contents of a
+// disk queue that is not in use, for offline inspection tools. None of them
+// modify the queue unless explicitly documented.
+
+// SegmentInfo describes a segment file of a disk queue.
+type SegmentInfo struct {
+	ID         uint64
+	Path       string
+	Version    uint32
+	FrameCount uint32
+	// Size is the size of the segment file on disk, including its header.
+	Size       uint64
+	Compressed bool
+	// CompressionAlgorithm and CompressionLevel are only meaningful for
+	// compressed segments.
+	CompressionAlgorithm CompressionAlgorithm
+	CompressionLevel     int
+	Encrypted            bool
+}
+
+// QueuePosition is the position of the oldest unacknowledged frame, as
+// stored in the queue state file.
+type QueuePosition struct {
+	SegmentID uint64
+	// ByteIndex is the offset of the frame within its segment, including
+	// the segment header. Zero means the first frame of the segment.
+	ByteIndex uint64
+	// FrameIndex is the index of the frame within its segment.
+	FrameIndex uint64
+}
+
+// Frame is a single decoded frame of a segment.
+type Frame struct {
+	SegmentID uint64
+	// Index is the index of the frame within its segment.
+	Index uint64
+	// Offset is the offset of the frame within the segment, including the
+	// segment header, in uncompressed and unencrypted bytes.
+	Offset uint64
+	// Size is the size of the frame, including its header and footer.
+	Size  uint64
+	Event publisher.Event
+}
+
+// ListSegments returns the segments found in the queue directory, ordered by
+// segment ID.
+func ListSegments(logger *logp.Logger, settings Settings, paths *paths.Path) ([]SegmentInfo, error) {
+	segments, err := scanExistingSegments(logger, settings.directoryPath(paths))
+	if err != nil {
+		return nil, err
+	}
+	scanTransformedSegments(logger, settings, paths, segments)
+
+	infos := make([]SegmentInfo, 0, len(segments))
+	for _, segment := range segments {
+		header := segmentHeader{options: segment.options}
+		info := SegmentInfo{
+			ID:                   uint64(segment.id),
+			Path:                 settings.segmentPath(segment.id, paths),
+			Version:              *segment.schemaVersion,
+			FrameCount:           segment.frameCount,
+			Compressed:           segment.options&ENABLE_COMPRESSION == ENABLE_COMPRESSION,
+			CompressionAlgorithm: header.compressionAlgorithm(),
+			CompressionLevel:     header.compressionLevel(),
+			Encrypted:            segment.options&ENABLE_ENCRYPTION == ENABLE_ENCRYPTION,
+		}
+		// The segment byte count is the logical size of its frames, report
+		// what is actually used on disk instead.
+		if stat, err := os.Stat(info.Path); err == nil {
+			info.Size = uint64(stat.Size()) //nolint:gosec // G115 - file sizes are never negative
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// PendingFrames returns the number of frames of the segment that were not
+// acknowledged yet, according to the read position.
+func (position QueuePosition) PendingFrames(segment SegmentInfo) uint64 {
+	switch {
+	case segment.ID < position.SegmentID:
+		return 0
+	case segment.ID > position.SegmentID:
+		return uint64(segment.FrameCount)
+	case position.FrameIndex >= uint64(segment.FrameCount):
+		return 0
+	default:
+		return uint64(segment.FrameCount) - position.FrameIndex
+	}
+}
+
+// PendingEvents returns the number of events in the queue that were not
+// acknowledged yet.
+func PendingEvents(logger *logp.Logger, settings Settings, paths *paths.Path) (uint64, error) {
+	segments, err := ListSegments(logger, settings, paths)
+	if errors.Is(err, os.ErrNotExist) {
+		// The queue was never created.
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	position, err := ReadQueuePosition(settings, paths)
+	if err != nil {
+		return 0, err
+	}
+	var pending uint64
+	for _, segment := range segments {
+		pending += position.PendingFrames(segment)
+	}
+	return pending, nil
+}
+
+// ReadQueuePosition returns the read position stored in the queue state
+// file. A queue without a state file, or with one that was never written, is
+// positioned at the beginning of its oldest segment, which is reported as the
+// zero position.
+func ReadQueuePosition(settings Settings, paths *paths.Path) (QueuePosition, error) {
+	position, err := queuePositionFromPath(settings.stateFilePath(paths))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, io.EOF) {
+			return QueuePosition{}, nil
+		}
+		return QueuePosition{}, err
+	}
+	if position.frameIndex == 0 {
+		// Same as in NewQueue, a position without a frame index points to the
+		// beginning of the segment.
+		position.byteIndex = 0
+	}
+	return QueuePosition{
+		SegmentID:  uint64(position.segmentID),
+		ByteIndex:  position.byteIndex,
+		FrameIndex: position.frameIndex,
+	}, nil
+}
+
+// WriteQueuePosition overwrites the read position in the queue state file.
+// Frames before the position are considered acknowledged and are deleted
+// the next time the queue is opened. It must never be called while the
+// queue is in use.
+func WriteQueuePosition(settings Settings, paths *paths.Path, position QueuePosition) error {
+	file, err := os.OpenFile(settings.stateFilePath(paths), os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("couldn't open state file: %w", err)
+	}
+	err = writeQueuePositionToHandle(file, queuePosition{
+		segmentID:  segmentID(position.SegmentID),
+		byteIndex:  position.ByteIndex,
+		frameIndex: position.FrameIndex,
+	})
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("couldn't write state file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("couldn't sync state file: %w", err)
+	}
+	return file.Close()
+}
+
+// ReadFrames decodes the frames of the given segment in order and calls fn
+// for each of them, until fn returns an error or the end of the segment is
+// reached. Compressed and encrypted segments are supported, the latter
+// require settings.EncryptionKey. Frame checksums are always verified.
+func ReadFrames(settings Settings, paths *paths.Path, segment SegmentInfo, fn func(Frame) error) error {
+	version := segment.Version
+	qs := &queueSegment{
+		id:            segmentID(segment.ID),
+		schemaVersion: &version,
+	}
+	handle, err := qs.getReader(settings, paths)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	offset := qs.headerSize()
+	if _, err := handle.Seek(int64(offset), io.SeekStart); err != nil { //nolint:gosec // G115 - header size is tiny
+		return fmt.Errorf("couldn't seek to the first frame of segment %d: %w", segment.ID, err)
+	}
+
+	rl := &readerLoop{settings: settings, paths: paths, decoder: newEventDecoder()}
+	rl.decoder.serializationFormat = handle.serializationFormat
+	for index := uint64(0); ; index++ {
+		frame, err := rl.nextFrame(handle, math.MaxUint64)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("segment %d frame %d: %w", segment.ID, index, err)
+		}
+		err = fn(Frame{
+			SegmentID: segment.ID,
+			Index:     index,
+			Offset:    offset,
+			Size:      frame.bytesOnDisk,
+			Event:     frame.event,
+		})
+		if err != nil {
+			return err
+		}
+		offset += frame.bytesOnDisk
+	}
+}
@@ -0,0 +1,100 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package diskqueue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/publisher/queue"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+func TestInspectQueue(t *testing.T) {
+	for name, encrypt := range map[string]bool{"plain": false, "encrypted": true} {
+		t.Run(name, func(t *testing.T) {
+			logger := logptest.NewTestingLogger(t, "")
+			settings := DefaultSettings()
+			settings.Path = t.TempDir()
+			settings.UseCompression = true
+			if encrypt {
+				settings.EncryptionKey = EncryptionKeyFromSecret("secret")
+			}
+
+			// Publish three events and acknowledge the first one.
+			q, err := NewQueue(logger, nil, settings, nil, &paths.Path{})
+			require.NoError(t, err)
+			producer := q.Producer(queue.ProducerConfig{})
+			publishAndACKSingleEvent(t, q, producer, "event-1")
+			for _, msg := range []string{"event-2", "event-3"} {
+				_, ok := producer.Publish(makeDiskQueueTestEvent(msg))
+				require.True(t, ok)
+				// Reading the event back ensures it was written to disk.
+				require.NotNil(t, readBatch(t, q, 3*time.Second))
+			}
+			producer.Close()
+			closeQueueAndWait(t, q)
+
+			// The writer loop finalizes the segment header asynchronously
+			// after the queue is closed.
+			var segments []SegmentInfo
+			require.Eventually(t, func() bool {
+				segments, err = ListSegments(logger, settings, nil)
+				return err == nil && len(segments) == 1 && segments[0].FrameCount == 3
+			}, 5*time.Second, 10*time.Millisecond)
+			assert.True(t, segments[0].Compressed)
+			assert.Equal(t, encrypt, segments[0].Encrypted)
+
+			position, err := ReadQueuePosition(settings, nil)
+			require.NoError(t, err)
+			assert.Equal(t, segments[0].ID, position.SegmentID)
+			assert.Equal(t, uint64(1), position.FrameIndex)
+			assert.Equal(t, uint64(2), position.PendingFrames(segments[0]))
+			pending, err := PendingEvents(logger, settings, nil)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(2), pending)
+
+			var frames []Frame
+			err = ReadFrames(settings, nil, segments[0], func(frame Frame) error {
+				frames = append(frames, frame)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Len(t, frames, 3)
+			for i, msg := range []string{"event-1", "event-2", "event-3"} {
+				assert.Equal(t, uint64(i), frames[i].Index)
+				assertEventMessage(t, frames[i].Event, msg)
+			}
+			assert.Equal(t, position.ByteIndex, frames[1].Offset, "read position must point to the first unacknowledged frame")
+
+			position = QueuePosition{
+				SegmentID:  frames[2].SegmentID,
+				ByteIndex:  frames[2].Offset,
+				FrameIndex: frames[2].Index,
+			}
+			require.NoError(t, WriteQueuePosition(settings, nil, position))
+			written, err := ReadQueuePosition(settings, nil)
+			require.NoError(t, err)
+			assert.Equal(t, position, written)
+		})
+	}
+}
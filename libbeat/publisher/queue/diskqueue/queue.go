@@ -177,6 +177,7 @@ func NewQueue(
 	if err != nil {
 		return nil, err
 	}
+	scanTransformedSegments(logger, settings, paths, initialSegments)
 	var nextSegmentID segmentID
 	if len(initialSegments) > 0 {
 		// Initialize nextSegmentID to the first ID after the existing segments.
@@ -19,6 +19,7 @@ package diskqueue
 
 import (
 	"flag"
+	"fmt"
 	"math/rand/v2"
 	"path/filepath"
 	"strings"
@@ -151,6 +152,41 @@ func TestQueueDoesNotReplayLastEventAfterRestart(t *testing.T) {
 	closeQueueAndWait(t, run3Queue)
 }
 
+func TestQueueRestartWithCompressionChanges(t *testing.T) {
+	settings := DefaultSettings()
+	settings.Path = t.TempDir()
+	settings.UseCompression = true
+	logger := logptest.NewTestingLogger(t, "")
+
+	// Each run leaves one unacknowledged event behind, which the next run,
+	// using a different compression algorithm, must replay.
+	algorithms := []CompressionAlgorithm{CompressionLZ4, CompressionZstd, CompressionSnappy, CompressionLZ4}
+	for i, algorithm := range algorithms {
+		settings.CompressionAlgorithm = algorithm
+		q, err := NewQueue(logger, nil, settings, nil, &paths.Path{})
+		require.NoError(t, err, "run %d queue should be created successfully", i)
+
+		if i > 0 {
+			batch := readBatch(t, q, 3*time.Second)
+			require.NotNil(t, batch, "run %d should replay the pending event", i)
+			require.Equal(t, 1, batch.Count())
+			assertEventMessage(t, batch.Entry(0), fmt.Sprintf("event-%d", i-1))
+			batch.Done()
+		}
+
+		if i < len(algorithms)-1 {
+			producer := q.Producer(queue.ProducerConfig{})
+			_, ok := producer.Publish(makeDiskQueueTestEvent(fmt.Sprintf("event-%d", i)))
+			require.True(t, ok)
+			// Read the event without acknowledging it, so it is pending on
+			// the next run.
+			require.NotNil(t, readBatch(t, q, 3*time.Second))
+			producer.Close()
+		}
+		closeQueueAndWait(t, q)
+	}
+}
+
 func publishAndACKSingleEvent(
 	t *testing.T,
 	queueInstance *diskQueue,
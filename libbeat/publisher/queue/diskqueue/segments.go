@@ -97,8 +97,14 @@ type queueSegment struct {
 	// maybeReadPending to calculate the position of the first data frame.
 	schemaVersion *uint32
 
+	// If this segment was loaded from a previous session, options holds the
+	// options read from its header.
+	options uint32
+
 	// The number of bytes occupied by this segment on-disk, as of the most
-	// recent completed writerLoop request.
+	// recent completed writerLoop request. For compressed or encrypted
+	// segments this is the size of the data before compression and
+	// encryption, since that is what read positions refer to.
 	byteCount uint64
 
 	// The ID of the first frame that was / will be read from this segment.
@@ -154,8 +160,32 @@ const (
 	_                  uint32 = 1 << iota // 0x1
 	ENABLE_COMPRESSION                    // 0x2
 	ENABLE_PROTOBUF                       // 0x4
+	ENABLE_ENCRYPTION                     // 0x8
+)
+
+// When ENABLE_COMPRESSION is set, the second byte of the options holds the
+// CompressionAlgorithm and the third byte the compression level the segment
+// was written with. Segments written before these were recorded have both
+// set to 0, which is LZ4 at its default level.
+const (
+	compressionAlgorithmShift = 8
+	compressionLevelShift     = 16
 )
 
+func compressionOptions(algorithm CompressionAlgorithm, level int) uint32 {
+	return ENABLE_COMPRESSION |
+		uint32(algorithm)<<compressionAlgorithmShift |
+		uint32(level&0xff)<<compressionLevelShift //nolint:gosec // G115 - level is validated to be at most 22
+}
+
+func (header *segmentHeader) compressionAlgorithm() CompressionAlgorithm {
+	return CompressionAlgorithm((header.options >> compressionAlgorithmShift) & 0xff)
+}
+
+func (header *segmentHeader) compressionLevel() int {
+	return int((header.options >> compressionLevelShift) & 0xff)
+}
+
 // Sort order: we store loaded segments in ascending order by their id.
 type bySegmentID []*queueSegment
 
@@ -201,6 +231,7 @@ func scanExistingSegments(logger *logp.Logger, pathStr string) ([]*queueSegment,
 				segments = append(segments, &queueSegment{
 					id:            segmentID(id),
 					schemaVersion: &header.version,
+					options:       header.options,
 					frameCount:    header.frameCount,
 					byteCount:     uint64(file.Size()),
 				})
@@ -211,6 +242,65 @@ func scanExistingSegments(logger *logp.Logger, pathStr string) ([]*queueSegment,
 	return segments, nil
 }
 
+// scanTransformedSegments fixes up the byte and frame counts of compressed
+// or encrypted segments loaded by scanExistingSegments. Their file size
+// doesn't match the size of the frames they contain, and their frames can't
+// be counted by scanning the raw file, so they are read in full instead.
+// Segments that can't be read keep the counts from their header.
+func scanTransformedSegments(logger *logp.Logger, settings Settings, paths *paths.Path, segments []*queueSegment) {
+	for _, segment := range segments {
+		if segment.options&(ENABLE_COMPRESSION|ENABLE_ENCRYPTION) == 0 {
+			continue
+		}
+		byteCount, frameCount, err := segment.scanFrames(settings, paths)
+		if frameCount == 0 && err != nil {
+			logger.Errorf("couldn't scan segment %d: %v", segment.id, err)
+			continue
+		}
+		if err != nil {
+			logger.Warnf(
+				"error scanning segment %d, data may be incomplete: %v", segment.id, err)
+		}
+		segment.byteCount = byteCount
+		if segment.frameCount == 0 {
+			segment.frameCount = frameCount
+		}
+	}
+}
+
+// scanFrames reads the whole segment and returns its logical size and the
+// number of complete frames it contains.
+func (segment *queueSegment) scanFrames(settings Settings, paths *paths.Path) (uint64, uint32, error) {
+	reader, err := segment.getReader(settings, paths)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer reader.Close()
+
+	byteCount := segment.headerSize()
+	frameCount := uint32(0)
+	in := autoRetryReader{reader}
+	for {
+		var frameLength uint32
+		err := binary.Read(in, binary.LittleEndian, &frameLength)
+		if errors.Is(err, io.EOF) {
+			// EOF at a frame boundary means we successfully scanned all frames.
+			return byteCount, frameCount, nil
+		}
+		if err != nil {
+			return byteCount, frameCount, err
+		}
+		if frameLength <= frameMetadataSize {
+			return byteCount, frameCount, fmt.Errorf("invalid frame length %d", frameLength)
+		}
+		if _, err := io.CopyN(io.Discard, in, int64(frameLength-frameHeaderSize)); err != nil {
+			return byteCount, frameCount, err
+		}
+		byteCount += uint64(frameLength)
+		frameCount++
+	}
+}
+
 // headerSize returns the logical size ("logical" because it may not have
 // been written to disk yet) of this segment file's header region. The
 // segment's first data frame begins immediately after the header.
@@ -256,8 +346,29 @@ func (segment *queueSegment) getReader(queueSettings Settings, paths *paths.Path
 		sr.serializationFormat = SerializationCBOR
 	}
 
+	var src io.ReadCloser = sr.src
+	if (header.options & ENABLE_ENCRYPTION) == ENABLE_ENCRYPTION {
+		if len(queueSettings.EncryptionKey) == 0 {
+			file.Close()
+			return nil, fmt.Errorf(
+				"segment %d is encrypted but no encryption key is configured", segment.id)
+		}
+		sr.er, err = NewEncryptionReader(sr.src, queueSettings.EncryptionKey)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf(
+				"couldn't set up decryption for segment %d: %w", segment.id, err)
+		}
+		src = sr.er
+	}
+
 	if (header.options & ENABLE_COMPRESSION) == ENABLE_COMPRESSION {
-		sr.cr = NewCompressionReader(sr.src)
+		sr.cr, err = NewCompressionReader(src, header.compressionAlgorithm())
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf(
+				"couldn't set up decompression for segment %d: %w", segment.id, err)
+		}
 	}
 	return sr, nil
 }
@@ -274,7 +385,11 @@ func (segment *queueSegment) getWriter(queueSettings Settings, paths *paths.Path
 	}
 
 	if queueSettings.UseCompression {
-		options = options | ENABLE_COMPRESSION
+		options = options | compressionOptions(
+			queueSettings.CompressionAlgorithm, queueSettings.CompressionLevel)
+	}
+	if len(queueSettings.EncryptionKey) > 0 {
+		options = options | ENABLE_ENCRYPTION
 	}
 
 	sw := &segmentWriter{}
@@ -284,8 +399,23 @@ func (segment *queueSegment) getWriter(queueSettings Settings, paths *paths.Path
 		return nil, err
 	}
 
+	var dst WriteCloseSyncer = sw.dst
+	if (options & ENABLE_ENCRYPTION) == ENABLE_ENCRYPTION {
+		sw.ew, err = NewEncryptionWriter(sw.dst, queueSettings.EncryptionKey)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		dst = sw.ew
+	}
+
 	if (options & ENABLE_COMPRESSION) == ENABLE_COMPRESSION {
-		sw.cw = NewCompressionWriter(sw.dst)
+		sw.cw, err = NewCompressionWriter(
+			dst, queueSettings.CompressionAlgorithm, queueSettings.CompressionLevel)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
 	}
 
 	return sw, nil
@@ -332,8 +462,11 @@ func readSegmentHeaderWithFrameCount(path string) (*segmentHeader, error) {
 		return nil, err
 	}
 	// If the header has a positive frame count then there is
-	// no more work to do, so return immediately.
-	if header.frameCount > 0 {
+	// no more work to do, so return immediately. Frames of compressed or
+	// encrypted segments can't be found in the raw file, these are counted
+	// by scanTransformedSegments.
+	if header.frameCount > 0 ||
+		header.options&(ENABLE_COMPRESSION|ENABLE_ENCRYPTION) != 0 {
 		return header, nil
 	}
 	// If we made it here, we loaded a valid header but the frame count is
@@ -444,6 +577,7 @@ func (segments *diskQueueSegments) sizeOnDisk() uint64 {
 // less compressable.
 type segmentReader struct {
 	src                 io.ReadSeekCloser
+	er                  *EncryptionReader
 	cr                  *CompressionReader
 	serializationFormat SerializationFormat
 }
@@ -452,6 +586,9 @@ func (r *segmentReader) Read(p []byte) (int, error) {
 	if r.cr != nil {
 		return r.cr.Read(p)
 	}
+	if r.er != nil {
+		return r.er.Read(p)
+	}
 	return r.src.Read(p)
 }
 
@@ -459,25 +596,41 @@ func (r *segmentReader) Close() error {
 	if r.cr != nil {
 		return r.cr.Close()
 	}
+	if r.er != nil {
+		return r.er.Close()
+	}
 	return r.src.Close()
 }
 
 func (r *segmentReader) Seek(offset int64, whence int) (int64, error) {
-	if r.cr != nil {
-		//can't seek before segment header
-		if (offset + int64(whence)) < segmentHeaderSize {
-			return 0, fmt.Errorf("illegal seek offset %d, whence %d", offset, whence)
-		}
-		if _, err := r.src.Seek(segmentHeaderSize, io.SeekStart); err != nil {
-			return 0, fmt.Errorf("could not seek past segment header: %w", err)
+	if r.cr == nil && r.er == nil {
+		return r.src.Seek(offset, whence)
+	}
+
+	// Compressed and encrypted streams can't be seeked directly, start
+	// over after the header and discard data up to the requested offset.
+	//can't seek before segment header
+	if (offset + int64(whence)) < segmentHeaderSize {
+		return 0, fmt.Errorf("illegal seek offset %d, whence %d", offset, whence)
+	}
+	if _, err := r.src.Seek(segmentHeaderSize, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("could not seek past segment header: %w", err)
+	}
+	var src io.Reader = r.src
+	if r.er != nil {
+		if err := r.er.Reset(); err != nil {
+			return 0, fmt.Errorf("could not reset decryption: %w", err)
 		}
+		src = r.er
+	}
+	if r.cr != nil {
 		if err := r.cr.Reset(); err != nil {
 			return 0, fmt.Errorf("could not reset compression: %w", err)
 		}
-		written, err := io.CopyN(io.Discard, r.cr, (offset+int64(whence))-segmentHeaderSize)
-		return written + segmentHeaderSize, err
+		src = r.cr
 	}
-	return r.src.Seek(offset, whence)
+	written, err := io.CopyN(io.Discard, src, (offset+int64(whence))-segmentHeaderSize)
+	return written + segmentHeaderSize, err
 }
 
 // segmentWriter handles writing of segments.  With Schema version 2
@@ -489,6 +642,7 @@ func (r *segmentReader) Seek(offset int64, whence int) (int64, error) {
 // data less compressable.
 type segmentWriter struct {
 	dst *os.File
+	ew  *EncryptionWriter
 	cw  *CompressionWriter
 }
 
@@ -496,6 +650,9 @@ func (w *segmentWriter) Write(p []byte) (int, error) {
 	if w.cw != nil {
 		return w.cw.Write(p)
 	}
+	if w.ew != nil {
+		return w.ew.Write(p)
+	}
 	return w.dst.Write(p)
 }
 
@@ -503,6 +660,9 @@ func (w *segmentWriter) Close() error {
 	if w.cw != nil {
 		return w.cw.Close()
 	}
+	if w.ew != nil {
+		return w.ew.Close()
+	}
 	return w.dst.Close()
 }
 
@@ -510,6 +670,9 @@ func (w *segmentWriter) Sync() error {
 	if w.cw != nil {
 		return w.cw.Sync()
 	}
+	if w.ew != nil {
+		return w.ew.Sync()
+	}
 	return w.dst.Sync()
 }
 
@@ -18,16 +18,22 @@
 package diskqueue
 
 import (
+	"encoding/binary"
 	"io"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
 )
 
 func TestSegmentsRoundTrip(t *testing.T) {
 	tests := map[string]struct {
 		id        segmentID
 		compress  bool
+		encrypt   bool
 		plaintext []byte
 	}{
 		"No Compression": {
@@ -40,6 +46,17 @@ func TestSegmentsRoundTrip(t *testing.T) {
 			compress:  true,
 			plaintext: []byte("compression only"),
 		},
+		"With Encryption": {
+			id:        3,
+			encrypt:   true,
+			plaintext: []byte("encryption only"),
+		},
+		"With Compression and Encryption": {
+			id:        4,
+			compress:  true,
+			encrypt:   true,
+			plaintext: []byte("compression and encryption"),
+		},
 	}
 	dir := t.TempDir()
 	for name, tc := range tests {
@@ -47,6 +64,9 @@ func TestSegmentsRoundTrip(t *testing.T) {
 		settings := DefaultSettings()
 		settings.Path = dir
 		settings.UseCompression = tc.compress
+		if tc.encrypt {
+			settings.EncryptionKey = EncryptionKeyFromSecret("secret")
+		}
 		qs := &queueSegment{
 			id: tc.id,
 		}
@@ -84,6 +104,7 @@ func TestSegmentReaderSeek(t *testing.T) {
 	tests := map[string]struct {
 		id         segmentID
 		compress   bool
+		encrypt    bool
 		plaintexts [][]byte
 	}{
 		"No Compression": {
@@ -96,12 +117,26 @@ func TestSegmentReaderSeek(t *testing.T) {
 			compress:   true,
 			plaintexts: [][]byte{[]byte("abc"), []byte("defg")},
 		},
+		"With Encryption": {
+			id:         3,
+			encrypt:    true,
+			plaintexts: [][]byte{[]byte("abc"), []byte("defg")},
+		},
+		"With Compression and Encryption": {
+			id:         4,
+			compress:   true,
+			encrypt:    true,
+			plaintexts: [][]byte{[]byte("abc"), []byte("defg")},
+		},
 	}
 	dir := t.TempDir()
 	for name, tc := range tests {
 		settings := DefaultSettings()
 		settings.Path = dir
 		settings.UseCompression = tc.compress
+		if tc.encrypt {
+			settings.EncryptionKey = EncryptionKeyFromSecret("secret")
+		}
 
 		qs := &queueSegment{
 			id: tc.id,
@@ -175,3 +210,141 @@ func TestSegmentReaderSeekLocations(t *testing.T) {
 		assert.Error(t, err, name)
 	}
 }
+
+func TestScanTransformedSegments(t *testing.T) {
+	settings := DefaultSettings()
+	settings.Path = t.TempDir()
+	settings.EncryptionKey = EncryptionKeyFromSecret("secret")
+
+	// Frames are only delimited by their length when scanning, the
+	// checksum is not verified.
+	var frames []byte
+	for _, data := range []string{"abc", "defgh"} {
+		frameLength := uint32(len(data) + frameMetadataSize)
+		frames = binary.LittleEndian.AppendUint32(frames, frameLength)
+		frames = append(frames, data...)
+		frames = binary.LittleEndian.AppendUint32(frames, 0)
+		frames = binary.LittleEndian.AppendUint32(frames, frameLength)
+	}
+	qs := &queueSegment{id: 0}
+	sw, err := qs.getWriter(settings, nil)
+	require.NoError(t, err)
+	_, err = sw.Write(frames)
+	require.NoError(t, err)
+	require.NoError(t, sw.Close())
+
+	logger := logptest.NewTestingLogger(t, "")
+	segments, err := scanExistingSegments(logger, settings.directoryPath(nil))
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	scanTransformedSegments(logger, settings, nil, segments)
+
+	// The counts of the segment refer to the frames, not to the encrypted
+	// file.
+	assert.Equal(t, uint32(2), segments[0].frameCount)
+	assert.Equal(t, segments[0].headerSize()+uint64(len(frames)), segments[0].byteCount)
+}
+
+func TestSegmentsEncryptionUpgrade(t *testing.T) {
+	dir := t.TempDir()
+	plaintext := []byte("written before encryption was enabled")
+
+	settings := DefaultSettings()
+	settings.Path = dir
+	plain := &queueSegment{id: 0}
+	sw, err := plain.getWriter(settings, nil)
+	require.NoError(t, err)
+	_, err = sw.Write(plaintext)
+	require.NoError(t, err)
+	require.NoError(t, sw.Close())
+
+	// Enabling encryption must not prevent reading old segments.
+	settings.EncryptionKey = EncryptionKeyFromSecret("secret")
+	sr, err := plain.getReader(settings, nil)
+	require.NoError(t, err)
+	dst := make([]byte, len(plaintext))
+	_, err = io.ReadFull(sr, dst)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, dst)
+	require.NoError(t, sr.Close())
+
+	encrypted := &queueSegment{id: 1}
+	sw, err = encrypted.getWriter(settings, nil)
+	require.NoError(t, err)
+	_, err = sw.Write(plaintext)
+	require.NoError(t, err)
+	require.NoError(t, sw.Close())
+
+	raw, err := os.ReadFile(settings.segmentPath(encrypted.id, nil))
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), string(plaintext), "segment data must not be stored in plain text")
+
+	// Encrypted segments can't be read without the key...
+	settings.EncryptionKey = nil
+	_, err = encrypted.getReader(settings, nil)
+	assert.Error(t, err)
+
+	// ...or with the wrong key.
+	settings.EncryptionKey = EncryptionKeyFromSecret("wrong")
+	sr, err = encrypted.getReader(settings, nil)
+	require.NoError(t, err)
+	_, err = io.ReadFull(sr, dst)
+	assert.Error(t, err)
+	require.NoError(t, sr.Close())
+}
+
+func TestSegmentsMixedCompression(t *testing.T) {
+	dir := t.TempDir()
+	segments := []struct {
+		id        segmentID
+		compress  bool
+		algorithm CompressionAlgorithm
+		level     int
+	}{
+		{id: 0, compress: false},
+		{id: 1, compress: true, algorithm: CompressionLZ4},
+		{id: 2, compress: true, algorithm: CompressionZstd, level: 19},
+		{id: 3, compress: true, algorithm: CompressionSnappy},
+		{id: 4, compress: true, algorithm: CompressionLZ4, level: 9},
+	}
+
+	// Write each segment as if the configuration changed between them.
+	for _, seg := range segments {
+		settings := DefaultSettings()
+		settings.Path = dir
+		settings.UseCompression = seg.compress
+		settings.CompressionAlgorithm = seg.algorithm
+		settings.CompressionLevel = seg.level
+		qs := &queueSegment{id: seg.id}
+		sw, err := qs.getWriter(settings, nil)
+		require.NoError(t, err)
+		_, err = sw.Write([]byte(seg.algorithm.String()))
+		require.NoError(t, err)
+		require.NoError(t, sw.Close())
+	}
+
+	// All segments must be readable with the current settings, whatever
+	// they are.
+	settings := DefaultSettings()
+	settings.Path = dir
+	settings.UseCompression = true
+	settings.CompressionAlgorithm = CompressionZstd
+	for _, seg := range segments {
+		file, err := os.Open(settings.segmentPath(seg.id, nil))
+		require.NoError(t, err)
+		header, err := readSegmentHeader(file)
+		require.NoError(t, err)
+		file.Close()
+		assert.Equal(t, seg.compress, header.options&ENABLE_COMPRESSION == ENABLE_COMPRESSION)
+		assert.Equal(t, seg.algorithm, header.compressionAlgorithm())
+		assert.Equal(t, seg.level, header.compressionLevel())
+
+		qs := &queueSegment{id: seg.id}
+		sr, err := qs.getReader(settings, nil)
+		require.NoError(t, err)
+		data, err := io.ReadAll(sr)
+		require.NoError(t, err)
+		assert.Equal(t, seg.algorithm.String(), string(data))
+		require.NoError(t, sr.Close())
+	}
+}
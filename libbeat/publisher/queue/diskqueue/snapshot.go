@@ -0,0 +1,117 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package diskqueue
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+)
+
+// WriteSnapshot saves events to a snapshot file at the given path. A
+// snapshot has the layout of an unencrypted, uncompressed queue segment, so
+// events saved by other queues share the disk queue's serialization. The file
+// is written under a temporary name and renamed once complete, so a crash
+// never leaves a truncated snapshot behind.
+//
+// Events that can't be serialized are skipped, and the number of events
+// written is returned.
+func WriteSnapshot(path string, events []publisher.Event) (int, error) {
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't create snapshot file: %w", err)
+	}
+	count, err := writeSnapshotFrames(file, events)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return 0, fmt.Errorf("couldn't write snapshot file: %w", err)
+	}
+	return count, nil
+}
+
+func writeSnapshotFrames(file *os.File, events []publisher.Event) (int, error) {
+	writer := &segmentWriter{dst: file}
+	if err := writer.WriteHeader(0); err != nil {
+		return 0, err
+	}
+
+	encoder := newEventEncoder(SerializationCBOR)
+	buf := bufio.NewWriter(file)
+	count := 0
+	for _, event := range events {
+		serialized, err := encoder.encode(event)
+		if err != nil || len(serialized)+frameMetadataSize > math.MaxUint32 {
+			continue
+		}
+		frameSize := uint32(len(serialized) + frameMetadataSize) //nolint:gosec // G115: checked above
+		_ = binary.Write(buf, binary.LittleEndian, frameSize)
+		_, _ = buf.Write(serialized)
+		_ = binary.Write(buf, binary.LittleEndian, computeChecksum(serialized))
+		_ = binary.Write(buf, binary.LittleEndian, frameSize)
+		count++
+	}
+	if err := buf.Flush(); err != nil {
+		return 0, err
+	}
+	if err := writer.UpdateCount(uint32(count)); err != nil { //nolint:gosec // G115: bounded by the queue size
+		return 0, err
+	}
+	return count, file.Sync()
+}
+
+// ReadSnapshot returns the events saved by WriteSnapshot at the given path.
+// If the file doesn't exist, the returned error wraps os.ErrNotExist.
+func ReadSnapshot(path string) ([]publisher.Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open snapshot file: %w", err)
+	}
+	defer file.Close()
+
+	header, err := readSegmentHeader(file)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read snapshot header: %w", err)
+	}
+	if header.version != currentSegmentVersion || header.options != 0 {
+		return nil, fmt.Errorf("unsupported snapshot version %d with options %#x", header.version, header.options)
+	}
+
+	handle := &segmentReader{src: file, serializationFormat: SerializationCBOR}
+	rl := &readerLoop{decoder: newEventDecoder()}
+	rl.decoder.serializationFormat = handle.serializationFormat
+	events := make([]publisher.Event, 0, header.frameCount)
+	for range header.frameCount {
+		frame, err := rl.nextFrame(handle, math.MaxUint64)
+		if err != nil {
+			return events, fmt.Errorf("couldn't read snapshot event %d: %w", len(events), err)
+		}
+		events = append(events, frame.event)
+	}
+	return events, nil
+}
@@ -0,0 +1,81 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package diskqueue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.snapshot")
+	events := []publisher.Event{
+		makeDiskQueueTestEvent("event-1"),
+		makeDiskQueueTestEvent("event-2"),
+		makeDiskQueueTestEvent("event-3"),
+	}
+
+	count, err := WriteSnapshot(path, events)
+	require.NoError(t, err)
+	assert.Equal(t, len(events), count)
+	assert.NoFileExists(t, path+".tmp")
+
+	restored, err := ReadSnapshot(path)
+	require.NoError(t, err)
+	require.Len(t, restored, len(events))
+	for i, event := range restored {
+		assertEventMessage(t, event, events[i].Content.Fields["message"].(string))
+		assert.True(t, events[i].Content.Timestamp.Equal(event.Content.Timestamp))
+	}
+
+	// Writing again replaces the previous snapshot.
+	_, err = WriteSnapshot(path, events[:1])
+	require.NoError(t, err)
+	restored, err = ReadSnapshot(path)
+	require.NoError(t, err)
+	assert.Len(t, restored, 1)
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := ReadSnapshot(filepath.Join(dir, "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	path := filepath.Join(dir, "queue.snapshot")
+	_, err = WriteSnapshot(path, []publisher.Event{
+		makeDiskQueueTestEvent("event-1"),
+		makeDiskQueueTestEvent("event-2"),
+	})
+	require.NoError(t, err)
+
+	// A truncated snapshot returns the events before the damaged one.
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-1))
+	restored, err := ReadSnapshot(path)
+	assert.Error(t, err)
+	require.Len(t, restored, 1)
+	assertEventMessage(t, restored[0], "event-1")
+}
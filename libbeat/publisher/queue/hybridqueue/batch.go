@@ -0,0 +1,174 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package hybridqueue
+
+import (
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue"
+)
+
+// batch is a queue.Batch of events served either from memory or from the
+// disk queue.
+type batch struct {
+	queue *hybridQueue
+
+	// entries holds the events of a memory batch, disk the batch returned
+	// by the disk queue. Exactly one of them is set.
+	entries   []entry
+	disk      queue.Batch[publisher.Event]
+	byteCount int
+
+	// ackProducers and ackCounts are the producers of the events in the
+	// batch, in publish order, and how many events each of them published.
+	ackProducers []*producer
+	ackCounts    []int
+
+	// next links the batch into the queue's pending list. done is set once
+	// the batch is acknowledged, and finished once Done or Release is
+	// called. All are guarded by the queue's mutex except finished, which
+	// is only accessed by the goroutine owning the batch.
+	next     *batch
+	done     bool
+	finished bool
+}
+
+func (b *batch) Count() int {
+	if b.disk != nil {
+		return b.disk.Count()
+	}
+	return len(b.entries)
+}
+
+func (b *batch) Entry(i int) publisher.Event {
+	if b.disk != nil {
+		return b.disk.Entry(i)
+	}
+	return b.entries[i].event
+}
+
+func (b *batch) FreeEntries() {
+	if b.disk != nil {
+		b.disk.FreeEntries()
+		return
+	}
+	for i := range b.entries {
+		b.entries[i].event = publisher.Event{}
+	}
+}
+
+// Done acknowledges the batch. Producer ACK callbacks are fired once every
+// batch returned by Get before this one has been acknowledged too, so they
+// are always fired in publish order.
+func (b *batch) Done() {
+	b.queue.finishBatch(b, true)
+}
+
+// Release abandons the batch without firing producer ACK callbacks. Events
+// served from disk are left in the disk queue.
+func (b *batch) Release() {
+	b.queue.finishBatch(b, false)
+}
+
+// addProducer records that the next count events of the batch were
+// published by p. Events left on disk by a previous run have no producer.
+func (b *batch) addProducer(p *producer, count int) {
+	if p == nil {
+		return
+	}
+	if n := len(b.ackProducers); n > 0 && b.ackProducers[n-1] == p {
+		b.ackCounts[n-1] += count
+		return
+	}
+	b.ackProducers = append(b.ackProducers, p)
+	b.ackCounts = append(b.ackCounts, count)
+}
+
+func (q *hybridQueue) finishBatch(b *batch, ack bool) {
+	if b.finished {
+		return
+	}
+	b.finished = true
+
+	memEvents := 0
+	if b.disk != nil {
+		if ack {
+			b.disk.Done()
+		} else {
+			b.disk.Release()
+		}
+	} else {
+		memEvents = len(b.entries)
+		// The entries share their backing array with the memory region
+		// they came from, clear them so the events can be collected.
+		clear(b.entries)
+		q.observer.RemoveEvents(memEvents, b.byteCount)
+	}
+
+	// ackMu is held until the callbacks are fired, so batches acknowledged
+	// concurrently can't fire them out of order.
+	q.ackMu.Lock()
+	defer q.ackMu.Unlock()
+	q.mu.Lock()
+	q.memUsed -= memEvents
+	if ack {
+		b.done = true
+	} else {
+		// Remove the batch from the pending list, then drain the prefix
+		// that may now be ready.
+		var prev *batch
+		for cur := q.pendingHead; cur != nil; cur = cur.next {
+			if cur == b {
+				if prev == nil {
+					q.pendingHead = cur.next
+				} else {
+					prev.next = cur.next
+				}
+				if q.pendingTail == cur {
+					q.pendingTail = prev
+				}
+				break
+			}
+			prev = cur
+		}
+		b.next = nil
+	}
+	toAck := q.drainReadyLocked()
+	forced := q.forced
+	q.mu.Unlock()
+
+	if !ack {
+		// Released events count as finished, so the producer's ackWait
+		// isn't stranded.
+		for i, p := range b.ackProducers {
+			p.finishN(b.ackCounts[i])
+		}
+	}
+	if forced {
+		// ackWait was closed by Close, and no ACK callbacks are fired
+		// for a force-closed queue.
+		return
+	}
+	for ab := toAck; ab != nil; ab = ab.next {
+		for i, p := range ab.ackProducers {
+			if p.cfg.ACK != nil {
+				p.cfg.ACK(ab.ackCounts[i])
+			}
+			p.finishN(ab.ackCounts[i])
+		}
+	}
+}
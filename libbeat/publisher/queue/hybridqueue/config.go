@@ -0,0 +1,97 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package hybridqueue
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/diskqueue"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+// The string used to specify this queue in beats configurations.
+const QueueType = "hybrid"
+
+// Settings contains the configuration fields to create a new hybrid queue.
+type Settings struct {
+	// Events is the maximum number of events held in memory, either waiting
+	// to be consumed or in flight at the output. Once it is reached, new
+	// events are written to the disk queue instead.
+	Events int
+
+	// Disk configures the disk queue that overflowing events are written to.
+	Disk diskqueue.Settings
+}
+
+// userConfig holds the parameters for a hybrid queue that are configurable
+// by the end user in the beats yml file.
+type userConfig struct {
+	Events int       `config:"events" validate:"min=32"`
+	Disk   *config.C `config:"disk"`
+}
+
+func (c *userConfig) Validate() error {
+	if c.Disk == nil {
+		return errors.New("hybrid queue requires a disk section")
+	}
+	return nil
+}
+
+func defaultUserConfig() userConfig {
+	return userConfig{
+		Events: 3200, // matches memqueue's DefaultEvents
+	}
+}
+
+// SettingsForUserConfig returns a Settings struct initialized with the
+// end-user-configurable settings in the given config tree.
+func SettingsForUserConfig(cfg *config.C) (Settings, error) {
+	userConfig := defaultUserConfig()
+	if cfg != nil {
+		if err := cfg.Unpack(&userConfig); err != nil {
+			return Settings{}, fmt.Errorf("couldn't unpack hybrid queue config: %w", err)
+		}
+	}
+	diskSettings, err := diskqueue.SettingsForUserConfig(userConfig.Disk)
+	if err != nil {
+		return Settings{}, fmt.Errorf("hybrid queue: %w", err)
+	}
+	return Settings{
+		Events: userConfig.Events,
+		Disk:   diskSettings,
+	}, nil
+}
+
+// FactoryForSettings is a simple wrapper around NewQueue so a concrete
+// Settings object can be wrapped in a queue-agnostic interface for
+// later use by the pipeline.
+func FactoryForSettings(settings Settings, paths *paths.Path) queue.QueueFactory[publisher.Event] {
+	return func(
+		logger *logp.Logger,
+		observer queue.Observer,
+		_ int,
+		encoderFactory queue.EncoderFactory[publisher.Event],
+	) (queue.Queue[publisher.Event], error) {
+		return NewQueue(logger, observer, settings, encoderFactory, paths)
+	}
+}
@@ -0,0 +1,69 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package hybridqueue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestSettingsForUserConfig(t *testing.T) {
+	tests := map[string]struct {
+		config map[string]any
+		err    bool
+		events int
+	}{
+		"defaults": {
+			config: map[string]any{"disk.max_size": "1GB"},
+			events: 3200,
+		},
+		"events": {
+			config: map[string]any{"events": 1024, "disk.max_size": "1GB"},
+			events: 1024,
+		},
+		"disk is required": {
+			config: map[string]any{"events": 1024},
+			err:    true,
+		},
+		"too few events": {
+			config: map[string]any{"events": 8, "disk.max_size": "1GB"},
+			err:    true,
+		},
+		"invalid disk settings": {
+			config: map[string]any{"disk.max_size": "1GB", "disk.compression": "gzip"},
+			err:    true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			settings, err := SettingsForUserConfig(config.MustNewConfigFrom(tc.config))
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.events, settings.Events)
+			assert.Equal(t, uint64(1e9), settings.Disk.MaxBufferSize)
+		})
+	}
+}
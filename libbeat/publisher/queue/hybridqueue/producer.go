@@ -0,0 +1,107 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package hybridqueue
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue"
+)
+
+type producer struct {
+	queue *hybridQueue
+	cfg   queue.ProducerConfig
+
+	// encoder is applied to events kept in memory. Events spilled to disk
+	// are encoded by the disk queue when they are read back.
+	encoder queue.Encoder[publisher.Event]
+
+	nextID atomic.Uint64
+	closed atomic.Bool
+
+	// published counts the events accepted by the queue, and finished the
+	// ones that were acknowledged or released. ackWait is closed once the
+	// producer is closed and finished catches up with published, or when
+	// the queue is force-closed.
+	published atomic.Uint64
+	finished  atomic.Uint64
+	ackWait   chan struct{}
+	ackOnce   sync.Once
+}
+
+// Publish adds an event to the queue, blocking while it has to be written
+// to disk and the disk queue is full.
+func (p *producer) Publish(event publisher.Event) (queue.EntryID, bool) {
+	return p.publish(event, true)
+}
+
+// TryPublish adds an event to the queue, failing instead of blocking when
+// it has to be written to disk and the disk queue is full.
+func (p *producer) TryPublish(event publisher.Event) (queue.EntryID, bool) {
+	return p.publish(event, false)
+}
+
+func (p *producer) publish(event publisher.Event, block bool) (queue.EntryID, bool) {
+	if p.closed.Load() {
+		return 0, false
+	}
+	// Count the event before enqueuing it, so a concurrent Close can't see
+	// finished >= published while it is in flight.
+	p.published.Add(1)
+	if !p.queue.publish(p, event, block) {
+		p.published.Add(^uint64(0)) // -1
+		p.maybeCloseAckWait()
+		return 0, false
+	}
+	return queue.EntryID(p.nextID.Add(1)), true
+}
+
+// Close marks the producer as closed. The queue still fires ACK callbacks
+// for the events published before Close was called.
+func (p *producer) Close() {
+	p.closed.Store(true)
+	p.maybeCloseAckWait()
+}
+
+func (p *producer) ACKWaitChan() <-chan struct{} {
+	return p.ackWait
+}
+
+// finishN advances the number of events that were acknowledged or
+// released.
+func (p *producer) finishN(n int) {
+	p.finished.Add(uint64(n)) //nolint:gosec // G115: n is a batch event count, always positive
+	p.maybeCloseAckWait()
+}
+
+func (p *producer) maybeCloseAckWait() {
+	if p.closed.Load() && p.finished.Load() >= p.published.Load() {
+		p.ackOnce.Do(func() {
+			close(p.ackWait)
+			p.queue.removeProducer(p)
+		})
+	}
+}
+
+// forceCloseAckWait closes ackWait unconditionally, used when the queue is
+// force-closed and no more ACK callbacks will be fired.
+func (p *producer) forceCloseAckWait() {
+	p.ackOnce.Do(func() { close(p.ackWait) })
+}
@@ -0,0 +1,557 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package hybridqueue implements a queue that serves events from memory
+// while the output keeps up, and spills them to a disk queue once its
+// in-memory budget is exhausted.
+//
+// Events are always delivered in publish order: once an event has been
+// written to disk, every following event is written to disk too, until the
+// consumer has read back everything that was spilled. Producer ACK callbacks
+// fire in publish order when the output acknowledges a batch, regardless of
+// whether its events were served from memory or from disk, which is what
+// order-sensitive producers like filestream's registry rely on.
+package hybridqueue
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/diskqueue"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+type hybridQueue struct {
+	logger   *logp.Logger
+	observer queue.Observer
+	settings Settings
+
+	encoderFactory queue.EncoderFactory[publisher.Event]
+
+	// disk holds the events that didn't fit in memory. All spilled events
+	// are written through a single producer, so the order of events on
+	// disk is the order in which they were published to this queue.
+	disk         queue.Queue[publisher.Event]
+	diskProducer queue.Producer[publisher.Event]
+
+	// publishMu serializes publishing, so the order of the producer runs
+	// recorded in the disk regions matches the order of the events written
+	// to the disk queue.
+	publishMu sync.Mutex
+
+	// spilled counts the events handed to the disk queue, and written the
+	// ones it has written to disk, signaling writtenCh. The disk queue drops
+	// pending writes when it is closed, so a graceful close waits for them
+	// to match first.
+	spilled   atomic.Uint64
+	written   atomic.Uint64
+	writtenCh chan struct{}
+
+	// diskFull is set while a producer is blocked waiting for space in the
+	// disk queue, so TryPublish can fail immediately instead of waiting for
+	// publishMu.
+	diskFull atomic.Bool
+
+	// getMu serializes consumers, since reading from a disk region has to
+	// be done without holding mu.
+	getMu sync.Mutex
+
+	// ackMu serializes firing producer ACK callbacks.
+	ackMu sync.Mutex
+
+	mu sync.Mutex
+
+	// regions is the FIFO of events that haven't been handed to the
+	// consumer yet, grouped in runs that are either in memory or on disk.
+	regions []*region
+
+	// memUsed is the number of events held in memory, either waiting in a
+	// memory region or in flight at the output. New events are spilled to
+	// disk once it reaches settings.Events.
+	memUsed int
+
+	// memQueued is the number of events waiting in memory regions.
+	memQueued int
+
+	// diskUnread is the number of events waiting in disk regions. While it
+	// is positive every new event is written to disk, so events can't
+	// overtake the ones that were spilled before them.
+	diskUnread int
+
+	// producers is the set of open producers, so Close can unblock their
+	// ACKWaitChan on force-close.
+	producers map[*producer]struct{}
+
+	// pendingHead/pendingTail is the FIFO of batches that have been returned
+	// from Get but not yet acknowledged, in Get order. Producer ACK callbacks
+	// are fired as the prefix of this list is acknowledged.
+	pendingHead, pendingTail *batch
+
+	closing bool
+	forced  bool
+
+	// notify wakes Get when new events arrive.
+	notify chan struct{}
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+	forceOnce sync.Once
+	forceCh   chan struct{}
+	doneOnce  sync.Once
+	doneCh    chan struct{}
+}
+
+// region is a run of consecutive events that are either all in memory or
+// all on disk.
+type region struct {
+	onDisk bool
+
+	// entries holds the events of a memory region.
+	entries []entry
+
+	// runs holds the producers of the unread events of a disk region, in
+	// publish order, and unread is their total count. The producer of a run
+	// is nil for events left on disk by a previous run of the beat.
+	runs   []producerRun
+	unread int
+}
+
+type entry struct {
+	event     publisher.Event
+	producer  *producer
+	byteCount int
+}
+
+type producerRun struct {
+	producer *producer
+	count    int
+}
+
+// NewQueue returns a hybrid queue using the given settings. Events left in
+// the disk queue by a previous run are served before any new event.
+func NewQueue(
+	logger *logp.Logger,
+	observer queue.Observer,
+	settings Settings,
+	encoderFactory queue.EncoderFactory[publisher.Event],
+	paths *paths.Path,
+) (*hybridQueue, error) {
+	if observer == nil {
+		observer = queue.NewQueueObserver(nil)
+	}
+	logger = logger.Named("hybridqueue")
+
+	// Count the events left by a previous run before the disk queue is
+	// opened, they are the ones it will replay first.
+	pending, err := diskqueue.PendingEvents(logger, settings.Disk, paths)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't count pending disk queue events: %w", err)
+	}
+	disk, err := diskqueue.NewQueue(logger, observer, settings.Disk, encoderFactory, paths)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create disk queue: %w", err)
+	}
+
+	q := &hybridQueue{
+		logger:         logger,
+		observer:       observer,
+		settings:       settings,
+		encoderFactory: encoderFactory,
+		disk:           disk,
+		writtenCh:      make(chan struct{}, 1),
+		producers:      make(map[*producer]struct{}),
+		notify:         make(chan struct{}, 1),
+		closeCh:        make(chan struct{}),
+		forceCh:        make(chan struct{}),
+		doneCh:         make(chan struct{}),
+	}
+	// The disk queue acknowledges events once they are written. Producer
+	// ACKs are only fired once the output acknowledges the events.
+	q.diskProducer = disk.Producer(queue.ProducerConfig{ACK: q.diskWritten})
+	if pending > 0 {
+		logger.Infof("Found %d events in the disk queue, they will be sent before any new event", pending)
+		q.regions = []*region{{
+			onDisk: true,
+			runs:   []producerRun{{count: int(pending)}}, //nolint:gosec // G115: bounded by the disk queue size
+			unread: int(pending),                         //nolint:gosec // G115: bounded by the disk queue size
+		}}
+		q.diskUnread = int(pending) //nolint:gosec // G115: bounded by the disk queue size
+	}
+	return q, nil
+}
+
+// Producer returns a producer that publishes to this queue.
+func (q *hybridQueue) Producer(cfg queue.ProducerConfig) queue.Producer[publisher.Event] {
+	p := &producer{queue: q, cfg: cfg, ackWait: make(chan struct{})}
+	if q.encoderFactory != nil {
+		p.encoder = q.encoderFactory()
+	}
+	q.mu.Lock()
+	if q.closing {
+		q.mu.Unlock()
+		p.forceCloseAckWait()
+		return p
+	}
+	q.producers[p] = struct{}{}
+	q.mu.Unlock()
+	return p
+}
+
+func (q *hybridQueue) removeProducer(p *producer) {
+	q.mu.Lock()
+	delete(q.producers, p)
+	q.mu.Unlock()
+}
+
+// publish adds an event to the queue, in memory if nothing is waiting on
+// disk and the memory budget allows it, or on disk otherwise. If block is
+// false, publish fails instead of waiting for space in the disk queue.
+func (q *hybridQueue) publish(p *producer, event publisher.Event, block bool) bool {
+	if !block && q.diskFull.Load() {
+		return false
+	}
+	q.publishMu.Lock()
+	defer q.publishMu.Unlock()
+
+	q.mu.Lock()
+	if q.closing {
+		q.mu.Unlock()
+		return false
+	}
+	if q.diskUnread == 0 && q.memUsed < q.settings.Events {
+		// Reserve the memory before encoding the event, which is done
+		// without holding mu. Consumers can't change the decision in the
+		// meantime: they only ever lower diskUnread and memUsed.
+		q.memUsed++
+		q.mu.Unlock()
+
+		e := entry{event: event, producer: p}
+		if p.encoder != nil {
+			e.event, e.byteCount = p.encoder.EncodeEntry(event)
+		}
+
+		q.mu.Lock()
+		tail := q.tailRegionLocked(false)
+		tail.entries = append(tail.entries, e)
+		q.memQueued++
+		q.mu.Unlock()
+
+		q.observer.AddEvent(e.byteCount)
+		q.signal()
+		return true
+	}
+	q.mu.Unlock()
+
+	_, ok := q.diskProducer.TryPublish(event)
+	if !ok && block {
+		q.diskFull.Store(true)
+		_, ok = q.diskProducer.Publish(event)
+		q.diskFull.Store(false)
+	}
+	if !ok {
+		return false
+	}
+	q.spilled.Add(1)
+
+	q.mu.Lock()
+	tail := q.tailRegionLocked(true)
+	if n := len(tail.runs); n > 0 && tail.runs[n-1].producer == p {
+		tail.runs[n-1].count++
+	} else {
+		tail.runs = append(tail.runs, producerRun{producer: p, count: 1})
+	}
+	tail.unread++
+	q.diskUnread++
+	q.mu.Unlock()
+
+	q.signal()
+	return true
+}
+
+// tailRegionLocked returns the last region of the FIFO, appending a new one
+// if it isn't of the requested kind. Must be called with q.mu held.
+func (q *hybridQueue) tailRegionLocked(onDisk bool) *region {
+	if n := len(q.regions); n > 0 && q.regions[n-1].onDisk == onDisk {
+		return q.regions[n-1]
+	}
+	r := &region{onDisk: onDisk}
+	q.regions = append(q.regions, r)
+	return r
+}
+
+// Get returns a batch of up to eventCount events (or all available events
+// if eventCount <= 0). A batch never mixes events from memory and disk. It
+// blocks until at least one event is available, and returns io.EOF once the
+// queue is closed and no events are left in memory.
+func (q *hybridQueue) Get(eventCount int) (queue.Batch[publisher.Event], error) {
+	q.getMu.Lock()
+	defer q.getMu.Unlock()
+
+	for {
+		q.mu.Lock()
+		// Drop the consumed regions at the head of the FIFO. The tail
+		// region is kept, since publish keeps appending to it.
+		for len(q.regions) > 1 && q.regions[0].consumed() {
+			q.regions[0] = nil
+			q.regions = q.regions[1:]
+		}
+		if q.forced || (q.closing && q.memQueued == 0) {
+			// Events left on disk are kept for the next run.
+			q.mu.Unlock()
+			return nil, io.EOF
+		}
+		if len(q.regions) > 0 && !q.regions[0].consumed() {
+			head := q.regions[0]
+			if !head.onDisk {
+				b := q.memoryBatchLocked(head, eventCount)
+				q.mu.Unlock()
+				q.observer.ConsumeEvents(b.Count(), b.byteCount)
+				return b, nil
+			}
+
+			count := head.unread
+			if eventCount > 0 {
+				count = min(count, eventCount)
+			}
+			q.mu.Unlock()
+			diskBatch, err := q.disk.Get(count)
+			if err != nil {
+				if q.isClosing() {
+					// The disk queue was closed while we were waiting.
+					return nil, io.EOF
+				}
+				return nil, err
+			}
+			q.mu.Lock()
+			b := q.diskBatchLocked(head, diskBatch)
+			q.mu.Unlock()
+			return b, nil
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.notify:
+		case <-q.closeCh:
+		}
+	}
+}
+
+// consumed reports whether every event in the region was handed to the
+// consumer.
+func (r *region) consumed() bool {
+	if r.onDisk {
+		return r.unread == 0
+	}
+	return len(r.entries) == 0
+}
+
+// memoryBatchLocked removes up to eventCount events from the given memory
+// region and returns them as a batch appended to the pending list. Must be
+// called with q.mu held.
+func (q *hybridQueue) memoryBatchLocked(head *region, eventCount int) *batch {
+	n := len(head.entries)
+	if eventCount > 0 {
+		n = min(n, eventCount)
+	}
+	b := &batch{queue: q, entries: head.entries[:n:n]}
+	head.entries = head.entries[n:]
+	q.memQueued -= n
+	for _, e := range b.entries {
+		b.byteCount += e.byteCount
+		b.addProducer(e.producer, 1)
+	}
+	q.appendPendingLocked(b)
+	return b
+}
+
+// diskBatchLocked wraps a batch read from the disk queue, attributing its
+// events to the producers recorded in the given disk region, and appends it
+// to the pending list. Must be called with q.mu held.
+func (q *hybridQueue) diskBatchLocked(head *region, diskBatch queue.Batch[publisher.Event]) *batch {
+	b := &batch{queue: q, disk: diskBatch}
+	n := diskBatch.Count()
+	head.unread -= n
+	q.diskUnread -= n
+	for n > 0 && len(head.runs) > 0 {
+		run := &head.runs[0]
+		count := min(n, run.count)
+		b.addProducer(run.producer, count)
+		run.count -= count
+		n -= count
+		if run.count == 0 {
+			head.runs = head.runs[1:]
+		}
+	}
+	q.appendPendingLocked(b)
+	return b
+}
+
+func (q *hybridQueue) appendPendingLocked(b *batch) {
+	if q.pendingTail != nil {
+		q.pendingTail.next = b
+	} else {
+		q.pendingHead = b
+	}
+	q.pendingTail = b
+}
+
+// drainReadyLocked removes the acknowledged batches at the head of the
+// pending list and returns them in Get order, so their producer ACK
+// callbacks can be fired once q.mu is released. Must be called with q.mu
+// held.
+func (q *hybridQueue) drainReadyLocked() *batch {
+	var head, tail *batch
+	for q.pendingHead != nil && q.pendingHead.done {
+		ready := q.pendingHead
+		q.pendingHead = ready.next
+		ready.next = nil
+		if tail == nil {
+			head = ready
+		} else {
+			tail.next = ready
+		}
+		tail = ready
+	}
+	if q.pendingHead == nil {
+		q.pendingTail = nil
+	}
+	q.maybeShutdownLocked()
+	return head
+}
+
+// Close shuts down the queue.
+//
+// With force=false the events still in memory keep being delivered, and the
+// disk queue is closed once they are all acknowledged. Events that are still
+// on disk at that point are kept for the next run.
+//
+// With force=true the events in memory are dropped, the disk queue is
+// closed immediately, and no further producer ACK callbacks are fired.
+func (q *hybridQueue) Close(force bool) error {
+	q.mu.Lock()
+	q.closing = true
+	var ackWaitProducers []*producer
+	if force {
+		q.forced = true
+		for p := range q.producers {
+			ackWaitProducers = append(ackWaitProducers, p)
+		}
+		q.producers = make(map[*producer]struct{})
+
+		dropped, droppedBytes := 0, 0
+		for _, r := range q.regions {
+			for _, e := range r.entries {
+				droppedBytes += e.byteCount
+			}
+			dropped += len(r.entries)
+		}
+		q.regions = nil
+		q.memUsed -= dropped
+		q.memQueued = 0
+		q.diskUnread = 0
+		if dropped > 0 {
+			q.observer.RemoveEvents(dropped, droppedBytes)
+		}
+		q.pendingHead = nil
+		q.pendingTail = nil
+	}
+	q.maybeShutdownLocked()
+	q.mu.Unlock()
+
+	q.closeOnce.Do(func() { close(q.closeCh) })
+	if force {
+		q.forceOnce.Do(func() { close(q.forceCh) })
+	}
+	for _, p := range ackWaitProducers {
+		p.forceCloseAckWait()
+	}
+	return nil
+}
+
+// maybeShutdownLocked closes the disk queue once the queue is closing and
+// nothing is left to deliver from memory or to acknowledge, and on a
+// graceful close, every spilled event was written. Done is closed when the
+// disk queue has shut down. Must be called with q.mu held.
+func (q *hybridQueue) maybeShutdownLocked() {
+	if !q.closing {
+		return
+	}
+	if !q.forced && (q.memQueued > 0 || q.pendingHead != nil) {
+		return
+	}
+	force := q.forced
+	q.doneOnce.Do(func() {
+		go func() {
+			for !force && q.written.Load() < q.spilled.Load() {
+				select {
+				case <-q.writtenCh:
+				case <-q.forceCh:
+					force = true
+				}
+			}
+			if err := q.disk.Close(force); err != nil {
+				q.logger.Errorf("Error closing disk queue: %v", err)
+			}
+			<-q.disk.Done()
+			close(q.doneCh)
+		}()
+	})
+}
+
+// diskWritten is the ACK callback of the disk producer.
+func (q *hybridQueue) diskWritten(count int) {
+	q.written.Add(uint64(count)) //nolint:gosec // G115: count is always positive
+	select {
+	case q.writtenCh <- struct{}{}:
+	default:
+	}
+}
+
+// Done returns a channel that is closed once the queue, including its disk
+// queue, has shut down.
+func (q *hybridQueue) Done() <-chan struct{} {
+	return q.doneCh
+}
+
+func (q *hybridQueue) QueueType() string {
+	return QueueType
+}
+
+// BufferConfig reports no event limit: once the in-memory budget is full,
+// the disk queue is bounded by size rather than by event count.
+func (q *hybridQueue) BufferConfig() queue.BufferConfig {
+	return queue.BufferConfig{MaxEvents: 0}
+}
+
+func (q *hybridQueue) isClosing() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.closing
+}
+
+// signal wakes a goroutine blocked in Get.
+func (q *hybridQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
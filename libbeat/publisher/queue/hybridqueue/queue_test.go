@@ -0,0 +1,298 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package hybridqueue
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/diskqueue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/queuetest"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+func TestProduceConsumer(t *testing.T) {
+	events := 2048
+	batchSize := 64
+
+	// A small memory budget makes most events go through the disk queue.
+	factory := func(t *testing.T) queue.Queue[publisher.Event] {
+		q, err := NewQueue(logptest.NewTestingLogger(t, ""), nil, testSettings(t, 32), nil, &paths.Path{})
+		require.NoError(t, err)
+		return q
+	}
+	t.Run("single", func(t *testing.T) {
+		t.Parallel()
+		queuetest.TestSingleProducerConsumer(t, events, batchSize, factory)
+	})
+	t.Run("multi", func(t *testing.T) {
+		t.Parallel()
+		queuetest.TestMultiProducerConsumer(t, events, batchSize, factory)
+	})
+}
+
+func TestSpillToDiskKeepsOrder(t *testing.T) {
+	q := newTestQueue(t, testSettings(t, 8))
+	acks := &ackRecorder{}
+	p := q.Producer(queue.ProducerConfig{ACK: acks.ack})
+
+	for i := range 20 {
+		_, ok := p.Publish(makeEvent(i))
+		require.True(t, ok)
+	}
+	q.mu.Lock()
+	assert.Equal(t, 8, q.memQueued, "the memory budget should be full")
+	assert.Equal(t, 12, q.diskUnread, "the remaining events should be on disk")
+	q.mu.Unlock()
+
+	// Consume the memory events, which frees the memory budget. New events
+	// must still go to disk, behind the ones that were spilled before them.
+	batch := getBatch(t, q, 0)
+	assertEvents(t, batch, 0, 8)
+	batch.Done()
+	_, ok := p.Publish(makeEvent(20))
+	require.True(t, ok)
+	q.mu.Lock()
+	assert.Equal(t, 13, q.diskUnread)
+	q.mu.Unlock()
+
+	next := 8
+	for next < 21 {
+		batch := getBatch(t, q, 5)
+		assertEvents(t, batch, next, batch.Count())
+		next += batch.Count()
+		batch.Done()
+	}
+
+	// Once the disk is drained, events are kept in memory again.
+	_, ok = p.Publish(makeEvent(21))
+	require.True(t, ok)
+	q.mu.Lock()
+	assert.Equal(t, 0, q.diskUnread)
+	assert.Equal(t, 1, q.memQueued)
+	q.mu.Unlock()
+	batch = getBatch(t, q, 0)
+	assertEvents(t, batch, 21, 1)
+	batch.Done()
+
+	assert.Equal(t, 22, acks.total())
+	closeAndWait(t, q)
+}
+
+func TestACKsInPublishOrder(t *testing.T) {
+	q := newTestQueue(t, testSettings(t, 4))
+	acks := &ackRecorder{}
+	p1 := q.Producer(queue.ProducerConfig{ACK: acks.ackFor("p1")})
+	p2 := q.Producer(queue.ProducerConfig{ACK: acks.ackFor("p2")})
+
+	// p1's events fit in memory, p2's are spilled to disk.
+	for i := range 4 {
+		_, ok := p1.Publish(makeEvent(i))
+		require.True(t, ok)
+	}
+	for i := 4; i < 8; i++ {
+		_, ok := p2.Publish(makeEvent(i))
+		require.True(t, ok)
+	}
+
+	memBatch := getBatch(t, q, 0)
+	assertEvents(t, memBatch, 0, 4)
+	var diskBatches []queue.Batch[publisher.Event]
+	for read := 0; read < 4; {
+		batch := getBatch(t, q, 0)
+		assertEvents(t, batch, 4+read, batch.Count())
+		read += batch.Count()
+		diskBatches = append(diskBatches, batch)
+	}
+
+	// Acknowledging the disk batches first must not fire p2's ACKs before
+	// p1's.
+	for _, batch := range diskBatches {
+		batch.Done()
+	}
+	assert.Empty(t, acks.calls())
+	memBatch.Done()
+	calls := acks.calls()
+	require.NotEmpty(t, calls)
+	assert.Equal(t, "p1:4", calls[0])
+	assert.Equal(t, 8, acks.total())
+
+	p1.Close()
+	p2.Close()
+	for _, p := range []queue.Producer[publisher.Event]{p1, p2} {
+		select {
+		case <-p.ACKWaitChan():
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "producer ACKWaitChan wasn't closed")
+		}
+	}
+	closeAndWait(t, q)
+}
+
+func TestRestartReplaysSpilledEvents(t *testing.T) {
+	settings := testSettings(t, 4)
+	q := newTestQueue(t, settings)
+	p := q.Producer(queue.ProducerConfig{})
+	for i := range 10 {
+		_, ok := p.Publish(makeEvent(i))
+		require.True(t, ok)
+	}
+	batch := getBatch(t, q, 0)
+	assertEvents(t, batch, 0, 4)
+	batch.Done()
+	p.Close()
+	closeAndWait(t, q)
+
+	// Events that were spilled to disk are served first on the next run.
+	q = newTestQueue(t, settings)
+	p = q.Producer(queue.ProducerConfig{})
+	_, ok := p.Publish(makeEvent(10))
+	require.True(t, ok)
+	for next := 4; next < 11; {
+		batch := getBatch(t, q, 0)
+		assertEvents(t, batch, next, batch.Count())
+		next += batch.Count()
+		batch.Done()
+	}
+	p.Close()
+	closeAndWait(t, q)
+}
+
+func TestForceCloseDropsMemoryEvents(t *testing.T) {
+	q := newTestQueue(t, testSettings(t, 4))
+	acks := &ackRecorder{}
+	p := q.Producer(queue.ProducerConfig{ACK: acks.ack})
+	for i := range 6 {
+		_, ok := p.Publish(makeEvent(i))
+		require.True(t, ok)
+	}
+	batch := getBatch(t, q, 2)
+
+	require.NoError(t, q.Close(true))
+	select {
+	case <-p.ACKWaitChan():
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "producer ACKWaitChan wasn't closed on force close")
+	}
+	_, err := q.Get(0)
+	assert.Error(t, err)
+	batch.Done()
+	assert.Zero(t, acks.total(), "no ACKs should be fired after force close")
+	select {
+	case <-q.Done():
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "queue did not close in time")
+	}
+}
+
+func testSettings(t *testing.T, events int) Settings {
+	disk := diskqueue.DefaultSettings()
+	disk.Path = t.TempDir()
+	return Settings{Events: events, Disk: disk}
+}
+
+func newTestQueue(t *testing.T, settings Settings) *hybridQueue {
+	q, err := NewQueue(logptest.NewTestingLogger(t, ""), nil, settings, nil, &paths.Path{})
+	require.NoError(t, err)
+	return q
+}
+
+func makeEvent(i int) publisher.Event {
+	return queuetest.MakeEvent(mapstr.M{"id": i})
+}
+
+func getBatch(t *testing.T, q *hybridQueue, count int) queue.Batch[publisher.Event] {
+	t.Helper()
+	type result struct {
+		batch queue.Batch[publisher.Event]
+		err   error
+	}
+	results := make(chan result, 1)
+	go func() {
+		batch, err := q.Get(count)
+		results <- result{batch, err}
+	}()
+	select {
+	case r := <-results:
+		require.NoError(t, r.err)
+		return r.batch
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for a batch")
+		return nil
+	}
+}
+
+func assertEvents(t *testing.T, batch queue.Batch[publisher.Event], first, count int) {
+	t.Helper()
+	require.Equal(t, count, batch.Count())
+	for i := range count {
+		id, err := batch.Entry(i).Content.Fields.GetValue("id")
+		require.NoError(t, err)
+		assert.EqualValues(t, first+i, id, "event %d of the batch", i)
+	}
+}
+
+func closeAndWait(t *testing.T, q *hybridQueue) {
+	t.Helper()
+	require.NoError(t, q.Close(false))
+	select {
+	case <-q.Done():
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "queue did not close in time")
+	}
+}
+
+type ackRecorder struct {
+	mu    sync.Mutex
+	log   []string
+	count int
+}
+
+func (r *ackRecorder) ack(n int) {
+	r.ackFor("")(n)
+}
+
+func (r *ackRecorder) ackFor(name string) func(int) {
+	return func(n int) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.log = append(r.log, fmt.Sprintf("%s:%d", name, n))
+		r.count += n
+	}
+}
+
+func (r *ackRecorder) calls() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.log...)
+}
+
+func (r *ackRecorder) total() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
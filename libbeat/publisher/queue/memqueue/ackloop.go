@@ -68,7 +68,7 @@ func (l *ackLoop[T]) handleBatchSig() int {
 
 	count := 0
 	for batch := ackedBatches.front(); batch != nil; batch = batch.next {
-		count += batch.count
+		count += batch.Count()
 	}
 
 	if count > 0 {
@@ -110,11 +110,13 @@ func (l *ackLoop[T]) collectAcked() batchList[T] {
 // input callbacks can't block the queue by occupying the runLoop goroutine.
 func (l *ackLoop[T]) processACK(lst batchList[T], N int) {
 	ackCallbacks := []func(){}
+	deleted := make([]int, 0, N)
 	// First we traverse the entries we're about to remove, collecting any callbacks
 	// we need to run.
 	lst.reverse()
 	for !lst.empty() {
 		batch := lst.pop()
+		deleted = append(deleted, batch.indices...)
 
 		// Cancelled batches (released via batch.Release rather than
 		// Done) are abandoned by the consumer: their events still
@@ -122,7 +124,7 @@ func (l *ackLoop[T]) processACK(lst batchList[T], N int) {
 		// above), but no producer ACK callback should fire because
 		// the events were never successfully delivered.
 		if batch.cancelled {
-			for i := batch.count - 1; i >= 0; i-- {
+			for i := batch.Count() - 1; i >= 0; i-- {
 				batch.rawEntry(i).producer = nil
 			}
 			continue
@@ -130,7 +132,7 @@ func (l *ackLoop[T]) processACK(lst batchList[T], N int) {
 
 		// Traverse entries from last to first, so we can acknowledge the most recent
 		// ones first and skip subsequent producer callbacks.
-		for i := batch.count - 1; i >= 0; i-- {
+		for i := batch.Count() - 1; i >= 0; i-- {
 			entry := batch.rawEntry(i)
 			if entry.producer == nil {
 				continue
@@ -149,7 +151,7 @@ func (l *ackLoop[T]) processACK(lst batchList[T], N int) {
 		}
 	}
 	// Signal runLoop to delete the events
-	l.broker.deleteChan <- N
+	l.broker.deleteChan <- deleted
 
 	// The events have been removed; notify their listeners.
 	for _, f := range ackCallbacks {
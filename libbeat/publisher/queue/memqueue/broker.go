@@ -74,8 +74,10 @@ type broker[T any] struct {
 	///////////////////////////
 	// api channels
 
-	// Producers send requests to pushChan to add events to the queue.
-	pushChan chan pushRequest[T]
+	// Producers send requests to the channel of their priority lane to add
+	// events to the queue. The runLoop admits requests from the lanes with
+	// weighted fair scheduling.
+	pushChans [queue.NumPriorities]chan pushRequest[T]
 
 	// Consumers send requests to getChan to read events from the queue.
 	getChan chan getRequest[T]
@@ -94,8 +96,8 @@ type broker[T any] struct {
 	// When batches are acknowledged, ackLoop saves any metadata needed
 	// for producer callbacks and such, then notifies runLoop that it's
 	// safe to free these events and advance the queue by sending the
-	// acknowledged event count to this channel.
-	deleteChan chan int
+	// buffer indices of the acknowledged events to this channel.
+	deleteChan chan []int
 
 	// closingChan is closed when the queue has processed a close request.
 	// It's used to prevent producers from blocking on a closing queue.
@@ -130,6 +132,11 @@ type queueEntry[T any] struct {
 
 	producer   *ackProducer[T]
 	producerID producerID // The order of this entry within its producer
+	priority   queue.Priority
+
+	// acked is set by the runLoop when the event is acknowledged. Its slot
+	// is freed once all the events before it are acknowledged too.
+	acked bool
 }
 
 type batch[T any] struct {
@@ -138,8 +145,9 @@ type batch[T any] struct {
 	// Next batch in the containing batchList
 	next *batch[T]
 
-	// Position and length of the events within the queue buffer
-	start, count int
+	// Positions of the events within the queue buffer. Events are picked
+	// across the priority lanes, so they are not necessarily contiguous.
+	indices []int
 
 	// batch.Done() sends to doneChan, where ackLoop reads it and handles
 	// acknowledgment / cleanup. batch.Release() also sends to doneChan
@@ -245,17 +253,19 @@ func newQueue[T any](
 		encoderFactory: encoderFactory,
 
 		// broker API channels
-		pushChan:  make(chan pushRequest[T], chanSize),
 		getChan:   make(chan getRequest[T]),
 		closeChan: make(chan bool),
 
 		// internal runLoop and ackLoop channels
 		consumedChan: make(chan batchList[T]),
-		deleteChan:   make(chan int),
+		deleteChan:   make(chan []int),
 		closingChan:  make(chan struct{}),
 
 		ackWaitProducers: make(map[*ackProducer[T]]struct{}),
 	}
+	for i := range b.pushChans {
+		b.pushChans[i] = make(chan pushRequest[T], chanSize)
+	}
 	b.ctx, b.ctxCancel = context.WithCancel(context.Background()) //nolint:gosec // G118 false positive: ctxCancel is stored on the broker and called during shutdown.
 
 	b.runLoop = newRunLoop(b, observer)
@@ -297,7 +307,7 @@ func (b *broker[T]) Producer(cfg queue.ProducerConfig) queue.Producer[T] {
 	if b.encoderFactory != nil {
 		encoder = b.encoderFactory()
 	}
-	return newProducer(b, cfg.ACK, encoder)
+	return newProducer(b, cfg.ACK, cfg.Priority, encoder)
 }
 
 // registerProducer adds an ack-tracking producer to the shutdown fan-out set.
@@ -344,11 +354,10 @@ func (b *broker[T]) Get(count int) (queue.Batch[T], error) {
 	return resp, nil
 }
 
-func newBatch[T any](queue *broker[T], start, count int) *batch[T] {
+func newBatch[T any](queue *broker[T], indices []int) *batch[T] {
 	return &batch[T]{
 		queue:    queue,
-		start:    start,
-		count:    count,
+		indices:  indices,
 		doneChan: make(chan batchDoneMsg, 1),
 	}
 }
@@ -434,13 +443,12 @@ func AdjustInputQueueSize(requested, mainQueueSize int) (actual int) {
 }
 
 func (b *batch[T]) Count() int {
-	return b.count
+	return len(b.indices)
 }
 
 // Return a pointer to the queueEntry for the i-th element of this batch
 func (b *batch[T]) rawEntry(i int) *queueEntry[T] {
-	// Indexes wrap around the end of the queue buffer
-	return &b.queue.buf[(b.start+i)%len(b.queue.buf)]
+	return &b.queue.buf[b.indices[i]]
 }
 
 // Return the event referenced by the i-th element of this batch
@@ -452,8 +460,7 @@ func (b *batch[T]) FreeEntries() {
 	// This signals that the event data has been copied out of the batch, and is
 	// safe to free from the queue buffer, so set all the event pointers to nil.
 	var empty T
-	for i := 0; i < b.count; i++ {
-		index := (b.start + i) % len(b.queue.buf)
+	for _, index := range b.indices {
 		b.queue.buf[index].event = empty
 	}
 }
@@ -35,7 +35,12 @@ type pushRequest[T any] struct {
 	// The index of the event in this producer only. Used to condense
 	// multiple acknowledgments for a producer to a single callback call.
 	producerID producerID
-	resp       chan queue.EntryID
+
+	// The priority of the producer, which selects the lane this request is
+	// admitted from.
+	priority queue.Priority
+
+	resp chan queue.EntryID
 }
 
 // consumer -> broker API
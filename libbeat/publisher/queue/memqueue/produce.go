@@ -59,6 +59,7 @@ type openState[T any] struct {
 	done         chan struct{}
 	queueClosing <-chan struct{}
 	events       chan pushRequest[T]
+	priority     queue.Priority
 	encoder      queue.Encoder[T]
 
 	// resp is used to receive the assigned EntryID after the runLoop
@@ -81,12 +82,14 @@ type produceState struct {
 
 type ackHandler func(count int)
 
-func newProducer[T any](b *broker[T], cb ackHandler, encoder queue.Encoder[T]) queue.Producer[T] {
+func newProducer[T any](b *broker[T], cb ackHandler, priority queue.Priority, encoder queue.Encoder[T]) queue.Producer[T] {
+	priority = queue.Priority(priority.Lane())
 	openState := openState[T]{
 		log:          b.logger,
 		done:         make(chan struct{}),
 		queueClosing: b.closingChan,
-		events:       b.pushChan,
+		events:       b.pushChans[priority],
+		priority:     priority,
 		encoder:      encoder,
 		resp:         make(chan queue.EntryID, 1),
 	}
@@ -119,8 +122,9 @@ func newProducer[T any](b *broker[T], cb ackHandler, encoder queue.Encoder[T]) q
 
 func (p *forgetfulProducer[T]) makePushRequest(event T) pushRequest[T] {
 	return pushRequest[T]{
-		event: event,
-		resp:  p.openState.resp}
+		event:    event,
+		priority: p.openState.priority,
+		resp:     p.openState.resp}
 }
 
 func (p *forgetfulProducer[T]) Publish(event T) (queue.EntryID, bool) {
@@ -143,6 +147,7 @@ func (p *ackProducer[T]) makePushRequest(event T, id producerID) pushRequest[T]
 		event:      event,
 		producer:   p,
 		producerID: id,
+		priority:   p.openState.priority,
 		resp:       p.openState.resp}
 }
 
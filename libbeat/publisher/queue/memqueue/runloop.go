@@ -37,14 +37,19 @@ type runLoop[T any] struct {
 	// event.
 	bufPos int
 
-	// The total number of events in the queue.
+	// The total number of events in the queue, including the acknowledged
+	// events whose slots can't be freed yet because an older event is still
+	// pending.
 	eventCount int
 
-	// The number of consumed events waiting for acknowledgment. The next Get
-	// request will return events starting at position
-	// (bufPos + consumedCount) % len(buf).
+	// The number of consumed events in the queue. The events that weren't
+	// consumed yet are in lanes.
 	consumedCount int
 
+	// lanes holds the buffer indices of the events that weren't consumed
+	// yet, in insertion order, for each priority.
+	lanes [queue.NumPriorities][]int
+
 	// The list of batches that have been consumed and are waiting to be sent
 	// to ackLoop for acknowledgment handling. (This list doesn't contain all
 	// outstanding batches, only the ones not yet forwarded to ackLoop.)
@@ -65,6 +70,15 @@ type runLoop[T any] struct {
 	// to Gets and Acks to allow pending events to complete on shutdown.
 	closing bool
 
+	// pushScheduler picks the priority lane to admit the next push request
+	// from when several lanes have requests waiting.
+	pushScheduler queue.Scheduler
+
+	// getScheduler picks the priority lane of each event added to the
+	// batches sent to consumers, so the events of a busy lane that fill the
+	// buffer don't delay the events of the other lanes.
+	getScheduler queue.Scheduler
+
 	// TODO (https://github.com/elastic/beats/issues/37893): entry IDs were a
 	// workaround for an external project that no longer exists. At this point
 	// they just complicate the API and should be removed.
@@ -98,10 +112,16 @@ func (l *runLoop[T]) run() {
 // Perform one iteration of the queue's main run loop. Broken out into a
 // standalone helper function to allow testing of loop invariants.
 func (l *runLoop[T]) runIteration() {
-	var pushChan chan pushRequest[T]
-	// Push requests are enabled if the queue isn't full or closing.
+	var pushChans [queue.NumPriorities]chan pushRequest[T]
+	// Push requests are enabled if the queue isn't full or closing. If some
+	// lanes have requests waiting, only the lane picked by the scheduler is
+	// enabled, otherwise we accept the first request to arrive on any lane.
 	if l.eventCount < len(l.broker.buf) && !l.closing {
-		pushChan = l.broker.pushChan
+		if p, ok := l.pushScheduler.Pick(l.pushPending); ok {
+			pushChans[p] = l.broker.pushChans[p]
+		} else {
+			pushChans = l.broker.pushChans
+		}
 	}
 
 	var getChan chan getRequest[T]
@@ -143,7 +163,13 @@ func (l *runLoop[T]) runIteration() {
 		// The queue is fully shut down, do nothing
 		return
 
-	case req := <-pushChan: // producer pushing new event
+	case req := <-pushChans[queue.PriorityHigh]: // producer pushing new event
+		l.handleInsert(&req)
+
+	case req := <-pushChans[queue.PriorityNormal]:
+		l.handleInsert(&req)
+
+	case req := <-pushChans[queue.PriorityLow]:
 		l.handleInsert(&req)
 
 	case req := <-getChan: // consumer asking for next batch
@@ -154,8 +180,8 @@ func (l *runLoop[T]) runIteration() {
 		// clear the pending list.
 		l.consumedBatches = batchList[T]{}
 
-	case count := <-l.broker.deleteChan:
-		l.handleDelete(count)
+	case indices := <-l.broker.deleteChan:
+		l.handleDelete(indices)
 
 	case <-timeoutChan:
 		// The get timer has expired, handle the blocked request
@@ -202,36 +228,67 @@ func (l *runLoop[T]) handleGetReply(req *getRequest[T]) {
 	eventsAvailable := l.eventCount - l.consumedCount
 	batchSize := min(eventsAvailable, req.entryCount)
 
-	startIndex := l.bufPos + l.consumedCount
-	batch := newBatch(l.broker, startIndex, batchSize)
-
+	indices := make([]int, batchSize)
 	batchBytes := 0
+	var priorityCounts [queue.NumPriorities]int
 	for i := range batchSize {
-		batchBytes += batch.rawEntry(i).eventSize
+		p, _ := l.getScheduler.Pick(l.lanePending)
+		l.getScheduler.Served(p, 1)
+		indices[i] = l.lanes[p][0]
+		l.lanes[p] = l.lanes[p][1:]
+		batchBytes += l.broker.buf[indices[i]].eventSize
+		priorityCounts[p]++
 	}
+	batch := newBatch(l.broker, indices)
 
 	// Send the batch to the caller and update internal state
 	req.responseChan <- batch
 	l.consumedBatches.append(batch)
 	l.consumedCount += batchSize
 	l.observer.ConsumeEvents(batchSize, batchBytes)
+	for p, count := range priorityCounts {
+		l.observer.ConsumePriorityEvents(queue.Priority(p), count)
+	}
 }
 
-func (l *runLoop[T]) handleDelete(count int) {
+func (l *runLoop[T]) handleDelete(indices []int) {
 	byteCount := 0
-	for i := range count {
-		entry := l.broker.buf[(l.bufPos+i)%len(l.broker.buf)]
+	var priorityCounts [queue.NumPriorities]int
+	for _, index := range indices {
+		entry := &l.broker.buf[index]
+		entry.acked = true
 		byteCount += entry.eventSize
+		priorityCounts[entry.priority]++
+	}
+	// Free the slots of the oldest events once they are all acknowledged.
+	// Event data was already cleared in batch.FreeEntries when the events
+	// were vended.
+	for l.eventCount > 0 && l.broker.buf[l.bufPos].acked {
+		l.broker.buf[l.bufPos].acked = false
+		l.bufPos = (l.bufPos + 1) % len(l.broker.buf)
+		l.eventCount--
+		l.consumedCount--
+	}
+	l.observer.RemoveEvents(len(indices), byteCount)
+	for p, count := range priorityCounts {
+		l.observer.RemovePriorityEvents(queue.Priority(p), count)
 	}
-	// Advance position and counters. Event data was already cleared in
-	// batch.FreeEntries when the events were vended.
-	l.bufPos = (l.bufPos + count) % len(l.broker.buf)
-	l.eventCount -= count
-	l.consumedCount -= count
-	l.observer.RemoveEvents(count, byteCount)
+}
+
+// lanePending reports whether the given lane has events that weren't
+// consumed yet.
+func (l *runLoop[T]) lanePending(p queue.Priority) bool {
+	return len(l.lanes[p]) > 0
+}
+
+// pushPending reports whether producers of the given priority have push
+// requests waiting.
+func (l *runLoop[T]) pushPending(p queue.Priority) bool {
+	return len(l.broker.pushChans[p]) > 0
 }
 
 func (l *runLoop[T]) handleInsert(req *pushRequest[T]) {
+	l.pushScheduler.Served(req.priority, 1)
 	l.insert(req, l.nextEntryID)
 	// Send back the new event id.
 	req.resp <- l.nextEntryID
@@ -266,6 +323,9 @@ func (l *runLoop[T]) insert(req *pushRequest[T], id queue.EntryID) {
 		id:         id,
 		producer:   req.producer,
 		producerID: req.producerID,
+		priority:   req.priority,
 	}
+	l.lanes[req.priority] = append(l.lanes[req.priority], index)
 	l.observer.AddEvent(req.eventSize)
+	l.observer.AddPriorityEvents(req.priority, 1)
 }
@@ -51,7 +51,7 @@ func TestFlushSettingsDoNotBlockFullBatches(t *testing.T) {
 		},
 		10, nil)
 
-	producer := newProducer(broker, nil, nil)
+	producer := newProducer(broker, nil, queue.PriorityNormal, nil)
 	rl := broker.runLoop
 	iterLock := sync.Mutex{}
 	for i := range 100 {
@@ -92,7 +92,7 @@ func TestFlushSettingsBlockPartialBatches(t *testing.T) {
 		},
 		10, nil)
 
-	producer := newProducer(broker, nil, nil)
+	producer := newProducer(broker, nil, queue.PriorityNormal, nil)
 	rl := broker.runLoop
 	iterLock := sync.Mutex{}
 	for range 100 {
@@ -191,6 +191,9 @@ func TestObserverConsumeEvents(t *testing.T) {
 	for i := range rl.broker.buf {
 		rl.broker.buf[i].eventSize = 123
 	}
+	for i := range rl.eventCount {
+		rl.lanes[queue.PriorityNormal] = append(rl.lanes[queue.PriorityNormal], i)
+	}
 	request := &getRequest[int]{
 		entryCount:   len(rl.broker.buf),
 		responseChan: make(chan *batch[int], 1),
@@ -209,23 +212,121 @@ func TestObserverRemoveEvents(t *testing.T) {
 		broker: &broker[int]{
 			ctx:        context.Background(),
 			buf:        make([]queueEntry[int], 100),
-			deleteChan: make(chan int, 1),
+			deleteChan: make(chan []int, 1),
 		},
-		eventCount: 50,
+		eventCount:    50,
+		consumedCount: 50,
 	}
 	// Initialize the queue entries to a test byte size
 	for i := range rl.broker.buf {
 		rl.broker.buf[i].eventSize = 123
 	}
 	const deleteCount = 25
-	rl.broker.deleteChan <- deleteCount
+	indices := make([]int, deleteCount)
+	for i := range indices {
+		indices[i] = i
+	}
+	rl.broker.deleteChan <- indices
 	// Run one iteration of the run loop, so it can handle the delete request
 	rl.runIteration()
+	assert.Equal(t, 25, rl.eventCount, "Deleting the oldest events should free their slots")
 	// It should have deleted 25 events, so we expect the size to be 25 * 123.
 	assertRegistryUint(t, reg, "queue.removed.events", deleteCount, "Deleting from the queue should report the removed events")
 	assertRegistryUint(t, reg, "queue.removed.bytes", deleteCount*123, "Deleting from the queue should report the removed bytes")
 }
 
+func TestPriorityLanes(t *testing.T) {
+	// When several priority lanes have events waiting, the run loop admits
+	// them in proportion to their weight instead of in arrival order.
+	reg := monitoring.NewRegistry()
+	logger := logptest.NewTestingLogger(t, "")
+	broker := newQueue[int](logger, queue.NewQueueObserver(reg), Settings{Events: 100}, 20, nil)
+	rl := broker.runLoop
+
+	// The low priority lane fills up first, as it would during a flood.
+	for _, p := range []queue.Priority{queue.PriorityLow, queue.PriorityHigh} {
+		for i := range 10 {
+			broker.pushChans[p] <- pushRequest[int]{
+				event:    i,
+				priority: p,
+				resp:     make(chan queue.EntryID, 1),
+			}
+		}
+	}
+	for range 10 {
+		rl.runIteration()
+	}
+	require.Equal(t, 10, rl.eventCount)
+
+	counts := map[queue.Priority]int{}
+	for _, entry := range broker.buf[:rl.eventCount] {
+		counts[entry.priority]++
+	}
+	assert.Equal(t, map[queue.Priority]int{queue.PriorityHigh: 8, queue.PriorityLow: 2}, counts)
+	assertRegistryUint(t, reg, "queue.priority.high.added.events", 8, "Admitted high priority events should be reported")
+	assertRegistryUint(t, reg, "queue.priority.low.added.events", 2, "Admitted low priority events should be reported")
+	assertRegistryUint(t, reg, "queue.priority.low.filled.events", 2, "Queued low priority events should be reported")
+}
+
+func TestPriorityLanesFullBuffer(t *testing.T) {
+	// When the buffer is already full of low priority events, a high
+	// priority event is handed out with the next batches instead of
+	// waiting for the whole buffer to be consumed.
+	logger := logptest.NewTestingLogger(t, "")
+	broker := newQueue[int](logger, nil, Settings{Events: 100, MaxGetRequest: 10}, 20, nil)
+	rl := broker.runLoop
+
+	push := func(p queue.Priority, event int) {
+		broker.pushChans[p] <- pushRequest[int]{
+			event:    event,
+			priority: p,
+			resp:     make(chan queue.EntryID, 1),
+		}
+		rl.runIteration()
+	}
+	get := func() *batch[int] {
+		responseChan := make(chan *batch[int], 1)
+		go func() {
+			broker.getChan <- getRequest[int]{entryCount: 10, responseChan: responseChan}
+		}()
+		rl.runIteration()
+		return <-responseChan
+	}
+	ack := func(b *batch[int]) {
+		go func() { broker.deleteChan <- b.indices }()
+		rl.runIteration()
+	}
+
+	for i := range 100 {
+		push(queue.PriorityLow, i)
+	}
+	require.Equal(t, 100, rl.eventCount)
+
+	// Free a batch of slots, so the high priority event can be admitted.
+	first := get()
+	ack(first)
+	push(queue.PriorityHigh, 1000)
+
+	// The high priority event is in the next batch, ahead of the 90 low
+	// priority events that were queued before it.
+	b := get()
+	require.Equal(t, 10, b.Count())
+	assert.Equal(t, 1000, b.Entry(0))
+	for i := 1; i < b.Count(); i++ {
+		assert.Equal(t, 10+i-1, b.Entry(i), "low priority events keep their order")
+	}
+
+	// Acknowledging the high priority event before the older low priority
+	// events doesn't free slots out of order.
+	ack(b)
+	assert.Equal(t, 81, rl.eventCount-rl.consumedCount)
+	for range 9 {
+		ack(get())
+	}
+	assert.Equal(t, 0, rl.eventCount)
+	assert.Equal(t, 0, rl.consumedCount)
+}
+
 func runIterationLocked[T any](rl *runLoop[T], lock *sync.Mutex) {
 	lock.Lock()
 	defer lock.Unlock()
@@ -36,6 +36,12 @@ type Observer interface {
 	AddEvent(byteCount int)
 	ConsumeEvents(eventCount int, byteCount int)
 	RemoveEvents(eventCount int, byteCount int)
+
+	// Per-priority event counts, reported in addition to the totals above by
+	// queues that schedule events by priority.
+	AddPriorityEvents(priority Priority, eventCount int)
+	ConsumePriorityEvents(priority Priority, eventCount int)
+	RemovePriorityEvents(priority Priority, eventCount int)
 }
 
 type queueObserver struct {
@@ -59,6 +65,16 @@ type queueObserver struct {
 	// extra variable and make sure to always change removedEvents and
 	// acked at the same time.
 	acked *monitoring.Uint
+
+	// Event counts of each priority lane, under "queue.priority.<name>".
+	priorities [NumPriorities]priorityObserver
+}
+
+type priorityObserver struct {
+	addedEvents    *monitoring.Uint
+	consumedEvents *monitoring.Uint
+	removedEvents  *monitoring.Uint
+	filledEvents   *monitoring.Uint // gauge
 }
 
 type nilObserver struct{}
@@ -96,6 +112,15 @@ func NewQueueObserver(metrics *monitoring.Registry) Observer {
 		// backwards compatibility: "acked" is an alias for "removed.events".
 		acked: monitoring.NewUint(queueMetrics, "acked"),
 	}
+	for p := range Priority(NumPriorities) {
+		prefix := "priority." + p.String() + "."
+		ob.priorities[p] = priorityObserver{
+			addedEvents:    monitoring.NewUint(queueMetrics, prefix+"added.events"),
+			consumedEvents: monitoring.NewUint(queueMetrics, prefix+"consumed.events"),
+			removedEvents:  monitoring.NewUint(queueMetrics, prefix+"removed.events"),
+			filledEvents:   monitoring.NewUint(queueMetrics, prefix+"filled.events"), // gauge
+		}
+	}
 	return ob
 }
 
@@ -145,6 +170,28 @@ func (ob *queueObserver) RemoveEvents(eventCount int, byteCount int) {
 	ob.updateFilledPct()
 }
 
+func (ob *queueObserver) AddPriorityEvents(priority Priority, eventCount int) {
+	if eventCount > 0 {
+		lane := &ob.priorities[priority.lane()]
+		lane.addedEvents.Add(uint64(eventCount))
+		lane.filledEvents.Add(uint64(eventCount))
+	}
+}
+
+func (ob *queueObserver) ConsumePriorityEvents(priority Priority, eventCount int) {
+	if eventCount > 0 {
+		ob.priorities[priority.lane()].consumedEvents.Add(uint64(eventCount))
+	}
+}
+
+func (ob *queueObserver) RemovePriorityEvents(priority Priority, eventCount int) {
+	if eventCount > 0 {
+		lane := &ob.priorities[priority.lane()]
+		lane.removedEvents.Add(uint64(eventCount))
+		lane.filledEvents.Sub(uint64(eventCount))
+	}
+}
+
 func (ob *queueObserver) updateFilledPct() {
 	if maxBytes := ob.maxBytes.Get(); maxBytes > 0 {
 		ob.filledPct.Set(float64(ob.filledBytes.Get()) / float64(maxBytes))
@@ -159,3 +206,7 @@ func (nilObserver) Restore(_ int, _ int)       {}
 func (nilObserver) AddEvent(_ int)             {}
 func (nilObserver) ConsumeEvents(_ int, _ int) {}
 func (nilObserver) RemoveEvents(_ int, _ int)  {}
+
+func (nilObserver) AddPriorityEvents(_ Priority, _ int)     {}
+func (nilObserver) ConsumePriorityEvents(_ Priority, _ int) {}
+func (nilObserver) RemovePriorityEvents(_ Priority, _ int)  {}
@@ -0,0 +1,128 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package queue
+
+// Priority is the scheduling class of a producer's events. Queues that
+// support priorities keep a separate lane per class and hand out events
+// across lanes with weighted fair scheduling, so a busy low priority
+// producer can't starve a high priority one.
+type Priority uint8
+
+const (
+	// PriorityNormal is the default priority.
+	PriorityNormal Priority = iota
+	PriorityHigh
+	PriorityLow
+
+	// NumPriorities is the number of priority classes.
+	NumPriorities = 3
+)
+
+// priorityCosts is the virtual time charged for each event served from a
+// lane. The shares are inversely proportional: while all lanes are backlogged,
+// high, normal and low priority events are served in a 4:2:1 ratio.
+var priorityCosts = [NumPriorities]uint64{
+	PriorityNormal: 2,
+	PriorityHigh:   1,
+	PriorityLow:    4,
+}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	case PriorityLow:
+		return "low"
+	default:
+		return "unknown"
+	}
+}
+
+// Weight returns the relative share of the queue's output this priority
+// gets while every lane has events waiting.
+func (p Priority) Weight() int {
+	return int(priorityCosts[PriorityLow] / priorityCosts[p.lane()])
+}
+
+// lane returns the index of the lane for p, treating unknown values as
+// PriorityNormal.
+func (p Priority) lane() Priority {
+	if p >= NumPriorities {
+		return PriorityNormal
+	}
+	return p
+}
+
+// Lane returns the index in [0, NumPriorities) of the lane events with
+// this priority are queued on.
+func (p Priority) Lane() int {
+	return int(p.lane())
+}
+
+// Scheduler implements start-time fair queuing over the priority lanes.
+// Each lane accumulates virtual time as its events are served, and the ready
+// lane with the earliest virtual time is picked next. A lane that was idle
+// resumes at the current virtual time, so it can't build up credit while it
+// has nothing to send.
+//
+// The zero value is ready to use. Scheduler is not safe for concurrent use.
+type Scheduler struct {
+	// finish is the virtual time at which each lane's last served event
+	// completes.
+	finish [NumPriorities]uint64
+
+	// now is the virtual start time of the last served event.
+	now uint64
+}
+
+// Pick returns the lane to serve the next event from, among the lanes for
+// which ready returns true, or false if no lane is ready. Pick doesn't change
+// the scheduler state, callers report the served events with Served.
+func (s *Scheduler) Pick(ready func(Priority) bool) (Priority, bool) {
+	var (
+		picked Priority
+		found  bool
+		start  uint64
+	)
+	for p := range Priority(NumPriorities) {
+		if !ready(p) {
+			continue
+		}
+		if t := s.start(p); !found || t < start {
+			picked, found, start = p, true, t
+		}
+	}
+	return picked, found
+}
+
+// Served records that n events were served from the given lane.
+func (s *Scheduler) Served(p Priority, n int) {
+	if n <= 0 {
+		return
+	}
+	p = p.lane()
+	start := s.start(p)
+	s.finish[p] = start + uint64(n)*priorityCosts[p]
+	s.now = start
+}
+
+func (s *Scheduler) start(p Priority) uint64 {
+	return max(s.finish[p], s.now)
+}
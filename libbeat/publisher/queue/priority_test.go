@@ -0,0 +1,71 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package queue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulerShares(t *testing.T) {
+	var s Scheduler
+	served := map[Priority]int{}
+	for range 700 {
+		p, ok := s.Pick(func(Priority) bool { return true })
+		assert.True(t, ok)
+		s.Served(p, 1)
+		served[p]++
+	}
+	assert.Equal(t, map[Priority]int{PriorityHigh: 400, PriorityNormal: 200, PriorityLow: 100}, served)
+}
+
+func TestSchedulerIdleLaneGetsNoCredit(t *testing.T) {
+	var s Scheduler
+	onlyLow := func(p Priority) bool { return p == PriorityLow }
+	for range 100 {
+		p, ok := s.Pick(onlyLow)
+		assert.True(t, ok)
+		assert.Equal(t, PriorityLow, p)
+		s.Served(p, 1)
+	}
+
+	// Once the high priority lane has events it gets its share, but it
+	// doesn't make up for the time it was idle.
+	served := map[Priority]int{}
+	for range 50 {
+		p, _ := s.Pick(func(p Priority) bool { return p != PriorityNormal })
+		s.Served(p, 1)
+		served[p]++
+	}
+	assert.InDelta(t, 40, served[PriorityHigh], 1)
+	assert.InDelta(t, 10, served[PriorityLow], 1)
+}
+
+func TestSchedulerNoReadyLane(t *testing.T) {
+	var s Scheduler
+	_, ok := s.Pick(func(Priority) bool { return false })
+	assert.False(t, ok)
+}
+
+func TestPriorityWeight(t *testing.T) {
+	assert.Equal(t, 4, PriorityHigh.Weight())
+	assert.Equal(t, 2, PriorityNormal.Weight())
+	assert.Equal(t, 1, PriorityLow.Weight())
+	assert.Equal(t, PriorityNormal.Lane(), Priority(42).Lane())
+}
@@ -78,6 +78,10 @@ type ProducerConfig struct {
 	// if ACK is set, the callback will be called with number of events produced
 	// by the producer instance and being ACKed by the queue.
 	ACK func(count int)
+
+	// Priority is the scheduling class of the producer's events. Queues that
+	// don't support priorities ignore it.
+	Priority Priority
 }
 
 type EntryID uint64
@@ -17,6 +17,8 @@
 
 package slabqueue
 
+import "github.com/elastic/beats/v7/libbeat/publisher/queue"
+
 // batch is a queue.Batch[T] over a (possibly non-contiguous) slice of slot
 // indices into the pool's backing array.
 //
@@ -96,12 +98,14 @@ func (b *batch[T]) Done() {
 	// grows under growMu and always covers an index this batch holds, so
 	// load it once instead of per slot.
 	var zero T
+	var removed [queue.NumPriorities]int
 	b.ackProducers = b.ackProducers[:0]
 	b.ackCounts = b.ackCounts[:0]
 	d := pool.dir.Load()
 	for _, i := range b.indices {
 		s := d.slot(i)
 		if s.producer != nil {
+			removed[s.producer.priority]++
 			found := false
 			for j, p := range b.ackProducers {
 				if p == s.producer {
@@ -126,6 +130,7 @@ func (b *batch[T]) Done() {
 	// producers can make progress regardless of where this batch is in
 	// the pending list.
 	pool.observer.RemoveEvents(len(b.indices), 0)
+	pool.removePriorityEvents(removed)
 	n := len(b.indices)
 	pool.releaseSlots(b.indices)
 	// These events left circulation; return their per-queue budget so producers
@@ -182,6 +187,14 @@ func (p *Pool[T]) fireAndRecycle(head *batch[T], forced bool) {
 	}
 }
 
+// removePriorityEvents reports the per-priority counts of events removed from
+// the pool to its observer.
+func (p *Pool[T]) removePriorityEvents(counts [queue.NumPriorities]int) {
+	for priority, count := range counts {
+		p.observer.RemovePriorityEvents(queue.Priority(priority), count)
+	}
+}
+
 // Release returns this batch's slot indices to the pool's free list
 // without firing producer ACK callbacks. Used by the pipeline on
 // shutdown when the consumer is abandoning a batch it cannot deliver;
@@ -213,12 +226,14 @@ func (b *batch[T]) Release() {
 	// event is "finished" for ackWait purposes, so a producer whose tail batch is
 	// Released does not strand its ACKWaitChan.
 	var zero T
+	var removed [queue.NumPriorities]int
 	d := pool.dir.Load()
 	b.ackProducers = b.ackProducers[:0]
 	b.ackCounts = b.ackCounts[:0]
 	for _, i := range b.indices {
 		s := d.slot(i)
 		if s.producer != nil {
+			removed[s.producer.priority]++
 			found := false
 			for j, pr := range b.ackProducers {
 				if pr == s.producer {
@@ -241,6 +256,7 @@ func (b *batch[T]) Release() {
 
 	// Return slots to the pool.
 	pool.observer.RemoveEvents(len(b.indices), 0)
+	pool.removePriorityEvents(removed)
 	n := len(b.indices)
 	pool.releaseSlots(b.indices)
 	// Return the per-queue budget for the abandoned events.
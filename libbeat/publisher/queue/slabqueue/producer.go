@@ -31,6 +31,10 @@ type producer[T any] struct {
 	queue *Queue[T]
 	cfg   queue.ProducerConfig
 
+	// priority selects the lane of the queue this producer's events are
+	// added to.
+	priority queue.Priority
+
 	// home is this producer's free-list shard hint, assigned once at creation.
 	// It only steers where acquire starts scanning, so it never needs to change
 	// even as the pool grows or shrinks.
@@ -172,18 +176,21 @@ func (p *producer[T]) fill(entry T, slotIdx int) (queue.EntryID, bool) {
 		p.unpublish()
 		return 0, false
 	}
-	if q.tail == -1 {
-		q.head = slotIdx
+	l := &q.lanes[p.priority]
+	if l.tail == -1 {
+		l.head = slotIdx
 	} else {
-		pool.slot(q.tail).next = slotIdx
+		pool.slot(l.tail).next = slotIdx
 	}
-	q.tail = slotIdx
+	l.tail = slotIdx
+	l.count++
 	q.count++
 	q.mu.Unlock()
 
 	// published was already incremented by Publish/TryPublish before this event
 	// could be enqueued, so no further accounting is needed on the success path.
 	pool.observer.AddEvent(0)
+	pool.observer.AddPriorityEvents(p.priority, 1)
 	q.signal()
 	return queue.EntryID(id), true
 }
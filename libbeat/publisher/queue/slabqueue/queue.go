@@ -49,11 +49,15 @@ var DefaultGetDebounce = 1 * time.Millisecond
 type Queue[T any] struct {
 	pool *Pool[T]
 
-	mu      sync.Mutex
-	head    int // index of the head slot in this pipeline's FIFO, or -1
-	tail    int // index of the tail slot, or -1
-	count   int
-	closing bool
+	mu sync.Mutex
+
+	// lanes holds this pipeline's FIFO for each producer priority. Get hands
+	// out events across the lanes with weighted fair scheduling, so events
+	// of a busy low priority producer don't delay high priority ones.
+	lanes     [queue.NumPriorities]lane
+	scheduler queue.Scheduler
+	count     int // total events across all lanes
+	closing   bool
 
 	// producers is the set of open producers publishing into this queue. It
 	// exists so Close can fan out and unblock every producer's ACKWaitChan on
@@ -102,17 +106,25 @@ type Queue[T any] struct {
 	debounce time.Duration // coalescing window for Get
 }
 
+// lane is a FIFO of slot indices threaded through slot.next.
+type lane struct {
+	head  int // index of the head slot, or -1
+	tail  int // index of the tail slot, or -1
+	count int
+}
+
 func newQueue[T any](pool *Pool[T]) *Queue[T] {
 	q := &Queue[T]{
 		pool:      pool,
-		head:      -1,
-		tail:      -1,
 		notify:    make(chan struct{}, 1),
 		closeCh:   make(chan struct{}),
 		doneCh:    make(chan struct{}),
 		producers: make(map[*producer[T]]struct{}),
 		debounce:  DefaultGetDebounce,
 	}
+	for i := range q.lanes {
+		q.lanes[i] = lane{head: -1, tail: -1}
+	}
 	q.limCond = sync.NewCond(&q.limMu)
 	return q
 }
@@ -224,7 +236,13 @@ func (q *Queue[T]) isClosing() bool { return chClosed(q.closeCh) }
 // shards.
 func (q *Queue[T]) Producer(cfg queue.ProducerConfig) queue.Producer[T] {
 	home := int((q.pool.homeCounter.Add(1) - 1) & uint64(q.pool.free.mask)) //nolint:gosec // G115: masked by the shard count, always a small non-negative index
-	p := &producer[T]{queue: q, cfg: cfg, home: home, ackWait: make(chan struct{})}
+	p := &producer[T]{
+		queue:    q,
+		cfg:      cfg,
+		priority: queue.Priority(cfg.Priority.Lane()),
+		home:     home,
+		ackWait:  make(chan struct{}),
+	}
 	q.mu.Lock()
 	if q.closing {
 		// The queue is already (force-)closing. A producer created now will
@@ -276,9 +294,12 @@ func (q *Queue[T]) Get(maxEvents int) (queue.Batch[T], error) {
 			if maxEvents > 0 {
 				n = min(n, maxEvents)
 			}
-			b := q.buildBatchLocked(n)
+			b, consumed := q.buildBatchLocked(n)
 			q.mu.Unlock()
 			q.pool.observer.ConsumeEvents(n, 0)
+			for p, count := range consumed {
+				q.pool.observer.ConsumePriorityEvents(queue.Priority(p), count)
+			}
 			return b, nil
 		}
 		if q.forced.Load() || q.closing {
@@ -299,22 +320,30 @@ func (q *Queue[T]) Get(maxEvents int) (queue.Batch[T], error) {
 	}
 }
 
-// buildBatchLocked removes the first n events from this pipeline's FIFO and
-// returns them as a recycled batch, appended to the pending-ack list so
-// producer ACK callbacks still fire in publish order. It must be called with
-// q.mu held and 0 < n <= q.count.
-func (q *Queue[T]) buildBatchLocked(n int) *batch[T] {
+// buildBatchLocked removes n events from this pipeline's lanes, picking the
+// lane of each event with the queue's scheduler, and returns them as a
+// recycled batch along with the number of events taken from each lane. The
+// batch is appended to the pending-ack list so producer ACK callbacks still
+// fire in publish order. It must be called with q.mu held and
+// 0 < n <= q.count.
+func (q *Queue[T]) buildBatchLocked(n int) (*batch[T], [queue.NumPriorities]int) {
 	b := q.pool.getBatch()
 	b.queue = q
 	d := q.pool.dir.Load()
-	cur := q.head
+	var consumed [queue.NumPriorities]int
+	ready := func(p queue.Priority) bool { return q.lanes[p].count > 0 }
 	for range n {
+		p, _ := q.scheduler.Pick(ready)
+		l := &q.lanes[p]
+		cur := l.head
 		b.indices = append(b.indices, cur)
-		cur = d.slot(cur).next
-	}
-	q.head = cur
-	if cur == -1 {
-		q.tail = -1
+		l.head = d.slot(cur).next
+		if l.head == -1 {
+			l.tail = -1
+		}
+		l.count--
+		consumed[p]++
+		q.scheduler.Served(p, 1)
 	}
 	q.count -= n
 	if q.pendingTail != nil {
@@ -323,7 +352,7 @@ func (q *Queue[T]) buildBatchLocked(n int) *batch[T] {
 		q.pendingHead = b
 	}
 	q.pendingTail = b
-	return b
+	return b, consumed
 }
 
 // Close shuts down the queue.
@@ -356,6 +385,7 @@ func (q *Queue[T]) Close(force bool) error {
 	// force-close, e.g. on timeout, can still fan out to them).
 	var ackWaitProducers []*producer[T]
 	var releaseIndices []int
+	var releaseCounts [queue.NumPriorities]int
 	if force {
 		if len(q.producers) > 0 {
 			ackWaitProducers = make([]*producer[T], 0, len(q.producers))
@@ -365,15 +395,15 @@ func (q *Queue[T]) Close(force bool) error {
 			q.producers = make(map[*producer[T]]struct{})
 		}
 		if q.count > 0 {
-			// Walk the FIFO and gather the slots so we can release them back to
+			// Walk the lanes and gather the slots so we can release them back to
 			// the pool below, outside the lock, to keep the critical section short.
-			cur := q.head
-			for cur != -1 {
-				releaseIndices = append(releaseIndices, cur)
-				cur = q.pool.slot(cur).next
+			for p := range q.lanes {
+				for cur := q.lanes[p].head; cur != -1; cur = q.pool.slot(cur).next {
+					releaseIndices = append(releaseIndices, cur)
+				}
+				releaseCounts[p] = q.lanes[p].count
+				q.lanes[p] = lane{head: -1, tail: -1}
 			}
-			q.head = -1
-			q.tail = -1
 			q.count = 0
 		}
 		// Drop the in-flight batch list. The batches themselves are still
@@ -414,6 +444,9 @@ func (q *Queue[T]) Close(force bool) error {
 			s.next = -1
 		}
 		q.pool.observer.RemoveEvents(len(releaseIndices), 0)
+		for p, count := range releaseCounts {
+			q.pool.observer.RemovePriorityEvents(queue.Priority(p), count)
+		}
 		q.pool.releaseSlots(releaseIndices)
 		// These FIFO events left circulation; return their per-queue budget.
 		q.releaseLive(len(releaseIndices))
@@ -1228,6 +1228,57 @@ func TestResizeUnderConcurrentTraffic(t *testing.T) {
 	pool.Shutdown()
 }
 
+// TestPriorityLanes verifies that Get hands out events across priority lanes
+// with weighted fair scheduling, and that ACKs still reach each producer in
+// publish order.
+func TestPriorityLanes(t *testing.T) {
+	pool := NewPool[int](Settings{Events: 64}, nil)
+	defer pool.Shutdown()
+	q := pool.Connect()
+
+	var lowACKed, highACKed atomic.Int64
+	low := q.Producer(queue.ProducerConfig{
+		Priority: queue.PriorityLow,
+		ACK:      func(n int) { lowACKed.Add(int64(n)) },
+	})
+	high := q.Producer(queue.ProducerConfig{
+		Priority: queue.PriorityHigh,
+		ACK:      func(n int) { highACKed.Add(int64(n)) },
+	})
+
+	// The low priority events are queued first, as they would be during a
+	// flood.
+	for i := range 20 {
+		_, ok := low.Publish(i)
+		require.True(t, ok)
+	}
+	for i := range 20 {
+		_, ok := high.Publish(100 + i)
+		require.True(t, ok)
+	}
+
+	b, err := q.Get(10)
+	require.NoError(t, err)
+	require.Equal(t, 10, b.Count())
+	var lowEvents, highEvents []int
+	for i := range b.Count() {
+		if e := b.Entry(i); e >= 100 {
+			highEvents = append(highEvents, e)
+		} else {
+			lowEvents = append(lowEvents, e)
+		}
+	}
+	assert.Equal(t, []int{100, 101, 102, 103, 104, 105, 106, 107}, highEvents)
+	assert.Equal(t, []int{0, 1}, lowEvents)
+
+	b.Done()
+	assert.Equal(t, int64(8), highACKed.Load())
+	assert.Equal(t, int64(2), lowACKed.Load())
+
+	// The remaining events are all delivered.
+	assert.Equal(t, 30, drainOnce(t, q))
+}
+
 // drainOnce returns and acks all currently-queued events on q. It assumes at
 // least one event is available so Get does not block.
 func drainOnce[T any](t *testing.T, q *Queue[T]) int {
@@ -0,0 +1,67 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package snapshot
+
+import (
+	"fmt"
+
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+// Settings configures the snapshot of an in-memory queue.
+type Settings struct {
+	// Enabled turns on saving the events that weren't acknowledged when the
+	// queue is closed, and restoring them when it is created.
+	Enabled bool
+
+	// Path is the snapshot file. If empty, queue.snapshot in the beat's
+	// data directory is used.
+	Path string
+}
+
+// userConfig holds the snapshot parameters that are configurable by the end
+// user in the memory queue section of the beats yml file.
+type userConfig struct {
+	Snapshot struct {
+		Enabled bool   `config:"enabled"`
+		Path    string `config:"path"`
+	} `config:"snapshot"`
+}
+
+// SettingsForUserConfig returns the snapshot settings in the given queue
+// config tree. Other queue settings are ignored.
+func SettingsForUserConfig(cfg *config.C) (Settings, error) {
+	var userConfig userConfig
+	if cfg != nil {
+		if err := cfg.Unpack(&userConfig); err != nil {
+			return Settings{}, fmt.Errorf("couldn't unpack queue snapshot config: %w", err)
+		}
+	}
+	return Settings{
+		Enabled: userConfig.Snapshot.Enabled,
+		Path:    userConfig.Snapshot.Path,
+	}, nil
+}
+
+func (settings Settings) snapshotPath(fallback *paths.Path) string {
+	if settings.Path == "" {
+		return fallback.Resolve(paths.Data, "queue.snapshot")
+	}
+	return settings.Path
+}
@@ -0,0 +1,323 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package snapshot wraps an in-memory queue so the events that weren't
+// acknowledged when it is closed survive a restart. They are saved to a
+// snapshot file, using the disk queue's serialization, and published again
+// before any new event when the queue is next created.
+//
+// Delivery is at least once: events that are acknowledged after the snapshot
+// was written, or while the beat is restarting, may be sent twice.
+package snapshot
+
+import (
+	"cmp"
+	"errors"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/diskqueue"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+type snapshotQueue struct {
+	queue.Queue[publisher.Event]
+
+	logger *logp.Logger
+	path   string
+
+	// mu guards producers and nextSeq, and serializes writing the snapshot.
+	mu        sync.Mutex
+	producers map[*producer]struct{}
+	nextSeq   uint64
+
+	// restored is closed once the events of the previous snapshot were
+	// published, new events are only accepted after that.
+	restored chan struct{}
+
+	closeOnce sync.Once
+	closing   chan struct{}
+	done      chan struct{}
+}
+
+type producer struct {
+	queue *snapshotQueue
+	inner queue.Producer[publisher.Event]
+	ack   func(count int)
+
+	// restore is set for the producer publishing the events of the previous
+	// snapshot.
+	restore bool
+
+	// pending holds the events published and not acknowledged yet, in
+	// publish order.
+	mu      sync.Mutex
+	pending []pendingEvent
+	closed  bool
+}
+
+type pendingEvent struct {
+	seq   uint64
+	event publisher.Event
+}
+
+// FactoryForSettings wraps the factory of an in-memory queue, so the queues
+// it creates save and restore a snapshot according to settings.
+func FactoryForSettings(
+	settings Settings,
+	paths *paths.Path,
+	factory queue.QueueFactory[publisher.Event],
+) queue.QueueFactory[publisher.Event] {
+	if !settings.Enabled {
+		return factory
+	}
+	return func(
+		logger *logp.Logger,
+		observer queue.Observer,
+		inputQueueSize int,
+		encoderFactory queue.EncoderFactory[publisher.Event],
+	) (queue.Queue[publisher.Event], error) {
+		inner, err := factory(logger, observer, inputQueueSize, encoderFactory)
+		if err != nil {
+			return nil, err
+		}
+		return NewQueue(logger, inner, settings.snapshotPath(paths)), nil
+	}
+}
+
+// NewQueue wraps inner, publishing the events saved in the snapshot at path
+// before any new event.
+func NewQueue(logger *logp.Logger, inner queue.Queue[publisher.Event], path string) *snapshotQueue {
+	q := &snapshotQueue{
+		Queue:     inner,
+		logger:    logger.Named("queue_snapshot"),
+		path:      path,
+		producers: make(map[*producer]struct{}),
+		restored:  make(chan struct{}),
+		closing:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	events, err := diskqueue.ReadSnapshot(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		q.logger.Errorf("Couldn't read queue snapshot, only %d events will be restored: %v", len(events), err)
+	}
+	if len(events) == 0 {
+		close(q.restored)
+		return q
+	}
+
+	q.logger.Infof("Restoring %d events from queue snapshot %s", len(events), path)
+	p := q.newProducer(queue.ProducerConfig{})
+	p.restore = true
+	// The restored events are pending from the start, so they are saved
+	// again if the queue is closed before they are all published.
+	p.pending = make([]pendingEvent, len(events))
+	for i, event := range events {
+		p.pending[i] = pendingEvent{seq: uint64(i), event: event} //nolint:gosec // G115: i is never negative
+	}
+	q.nextSeq = uint64(len(events))
+	go func() {
+		defer close(q.restored)
+		for _, event := range events {
+			if _, ok := p.inner.Publish(event); !ok {
+				return
+			}
+		}
+		p.Close()
+	}()
+	return q
+}
+
+func (q *snapshotQueue) Producer(cfg queue.ProducerConfig) queue.Producer[publisher.Event] {
+	return q.newProducer(cfg)
+}
+
+func (q *snapshotQueue) newProducer(cfg queue.ProducerConfig) *producer {
+	p := &producer{queue: q, ack: cfg.ACK}
+	p.inner = q.Queue.Producer(queue.ProducerConfig{ACK: p.onACK, Priority: cfg.Priority})
+	q.mu.Lock()
+	q.producers[p] = struct{}{}
+	q.mu.Unlock()
+	return p
+}
+
+// Close saves the events that weren't acknowledged yet to the snapshot
+// before closing the queue, since the beat may exit before the queue is
+// done. The snapshot is updated once the queue is done, removing the events
+// acknowledged in the meantime.
+func (q *snapshotQueue) Close(force bool) error {
+	q.closeOnce.Do(func() {
+		close(q.closing)
+		q.saveSnapshot()
+		go func() {
+			<-q.Queue.Done()
+			q.saveSnapshot()
+			close(q.done)
+		}()
+	})
+	return q.Queue.Close(force)
+}
+
+func (q *snapshotQueue) Done() <-chan struct{} {
+	return q.done
+}
+
+// saveSnapshot writes the events that weren't acknowledged yet to the
+// snapshot file, or removes it if there are none.
+func (q *snapshotQueue) saveSnapshot() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var pending []pendingEvent
+	for p := range q.producers {
+		p.mu.Lock()
+		pending = append(pending, p.pending...)
+		p.mu.Unlock()
+	}
+	if len(pending) == 0 {
+		if err := os.Remove(q.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			q.logger.Errorf("Couldn't remove queue snapshot: %v", err)
+		}
+		return
+	}
+
+	slices.SortFunc(pending, func(a, b pendingEvent) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+	events := make([]publisher.Event, len(pending))
+	for i, pe := range pending {
+		events[i] = pe.event
+	}
+	count, err := diskqueue.WriteSnapshot(q.path, events)
+	if err != nil {
+		q.logger.Errorf("Couldn't save %d pending events to the queue snapshot: %v", len(events), err)
+		return
+	}
+	if count < len(events) {
+		q.logger.Warnf("%d events couldn't be serialized and are missing from the queue snapshot", len(events)-count)
+	}
+	q.logger.Infof("Saved %d pending events to queue snapshot %s", count, q.path)
+}
+
+// removeProducer unregisters a closed producer once all its events are
+// acknowledged. Once the restored events are all acknowledged, the
+// snapshot they were read from isn't needed anymore.
+func (q *snapshotQueue) removeProducer(p *producer) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.producers, p)
+	if p.restore && !chClosed(q.closing) {
+		if err := os.Remove(q.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			q.logger.Errorf("Couldn't remove queue snapshot: %v", err)
+		}
+	}
+}
+
+func (p *producer) Publish(event publisher.Event) (queue.EntryID, bool) {
+	select {
+	case <-p.queue.restored:
+	case <-p.queue.closing:
+		return 0, false
+	}
+	p.track(event)
+	id, ok := p.inner.Publish(event)
+	if !ok {
+		p.untrack()
+	}
+	return id, ok
+}
+
+func (p *producer) TryPublish(event publisher.Event) (queue.EntryID, bool) {
+	if !chClosed(p.queue.restored) {
+		return 0, false
+	}
+	p.track(event)
+	id, ok := p.inner.TryPublish(event)
+	if !ok {
+		p.untrack()
+	}
+	return id, ok
+}
+
+// track records an event before it is published, so its acknowledgment
+// can't be received before it is recorded. Producers are used by a single
+// goroutine, so if publishing fails, the event is the last one recorded.
+func (p *producer) track(event publisher.Event) {
+	q := p.queue
+	q.mu.Lock()
+	seq := q.nextSeq
+	q.nextSeq++
+	q.mu.Unlock()
+
+	p.mu.Lock()
+	p.pending = append(p.pending, pendingEvent{seq: seq, event: event})
+	p.mu.Unlock()
+}
+
+func (p *producer) untrack() {
+	p.mu.Lock()
+	n := len(p.pending)
+	p.pending[n-1] = pendingEvent{}
+	p.pending = p.pending[:n-1]
+	p.mu.Unlock()
+}
+
+func (p *producer) onACK(count int) {
+	p.mu.Lock()
+	n := min(count, len(p.pending))
+	clear(p.pending[:n])
+	p.pending = p.pending[n:]
+	drained := p.closed && len(p.pending) == 0
+	p.mu.Unlock()
+
+	if drained {
+		p.queue.removeProducer(p)
+	}
+	if p.ack != nil {
+		p.ack(count)
+	}
+}
+
+func (p *producer) Close() {
+	p.mu.Lock()
+	p.closed = true
+	drained := len(p.pending) == 0
+	p.mu.Unlock()
+
+	if drained {
+		p.queue.removeProducer(p)
+	}
+	p.inner.Close()
+}
+
+func (p *producer) ACKWaitChan() <-chan struct{} {
+	return p.inner.ACKWaitChan()
+}
+
+func chClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
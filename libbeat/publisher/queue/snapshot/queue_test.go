@@ -0,0 +1,178 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package snapshot
+
+import (
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/publisher"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/diskqueue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/memqueue"
+	"github.com/elastic/beats/v7/libbeat/publisher/queue/queuetest"
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+func TestSnapshotRestoresPendingEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.snapshot")
+
+	// First run: 4 of 10 events are acknowledged before the queue is
+	// force-closed.
+	q := newTestQueue(t, path)
+	var acked atomic.Int64
+	p := q.Producer(queue.ProducerConfig{ACK: func(n int) { acked.Add(int64(n)) }})
+	for i := range 10 {
+		_, ok := p.Publish(makeEvent(i))
+		require.True(t, ok)
+	}
+	batch := getBatch(t, q, 4)
+	assertEvents(t, batch, 0, 4)
+	batch.Done()
+	require.Eventually(t, func() bool { return acked.Load() == 4 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, q.Close(true))
+	waitDone(t, q)
+
+	events, err := diskqueue.ReadSnapshot(path)
+	require.NoError(t, err)
+	assert.Len(t, events, 6)
+
+	// Second run: the pending events are published before new ones.
+	q = newTestQueue(t, path)
+	p = q.Producer(queue.ProducerConfig{})
+	_, ok := p.Publish(makeEvent(10))
+	require.True(t, ok)
+	for next := 4; next < 11; {
+		batch := getBatch(t, q, 0)
+		assertEvents(t, batch, next, batch.Count())
+		next += batch.Count()
+		batch.Done()
+	}
+	require.Eventually(t, func() bool {
+		_, err := diskqueue.ReadSnapshot(path)
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond, "the snapshot should be removed once its events are acknowledged")
+
+	// Everything was acknowledged, no snapshot is left behind.
+	p.Close()
+	require.NoError(t, q.Close(false))
+	waitDone(t, q)
+	assert.NoFileExists(t, path)
+}
+
+func TestSnapshotSavedOnClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.snapshot")
+	q := newTestQueue(t, path)
+	p := q.Producer(queue.ProducerConfig{})
+	for i := range 3 {
+		_, ok := p.Publish(makeEvent(i))
+		require.True(t, ok)
+	}
+
+	// The snapshot is written as soon as the queue is closed, the beat may
+	// exit before the queue is done.
+	require.NoError(t, q.Close(false))
+	events, err := diskqueue.ReadSnapshot(path)
+	require.NoError(t, err)
+	assert.Len(t, events, 3)
+
+	// Once the remaining events are delivered, the snapshot is removed.
+	batch := getBatch(t, q, 0)
+	assertEvents(t, batch, 0, 3)
+	batch.Done()
+	waitDone(t, q)
+	assert.NoFileExists(t, path)
+}
+
+func TestSettingsForUserConfig(t *testing.T) {
+	settings, err := SettingsForUserConfig(config.MustNewConfigFrom(map[string]any{"events": 4096}))
+	require.NoError(t, err)
+	assert.False(t, settings.Enabled)
+
+	settings, err = SettingsForUserConfig(config.MustNewConfigFrom(map[string]any{
+		"snapshot.enabled": true,
+	}))
+	require.NoError(t, err)
+	assert.True(t, settings.Enabled)
+	assert.Equal(t, filepath.Join("/data", "queue.snapshot"), settings.snapshotPath(&paths.Path{Data: "/data"}))
+
+	settings, err = SettingsForUserConfig(config.MustNewConfigFrom(map[string]any{
+		"snapshot.enabled": true,
+		"snapshot.path":    "/tmp/custom.snapshot",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/custom.snapshot", settings.snapshotPath(&paths.Path{Data: "/data"}))
+}
+
+func newTestQueue(t *testing.T, path string) *snapshotQueue {
+	logger := logptest.NewTestingLogger(t, "")
+	inner := memqueue.NewQueue[publisher.Event](logger, nil, memqueue.Settings{Events: 64}, 0, nil)
+	return NewQueue(logger, inner, path)
+}
+
+func makeEvent(i int) publisher.Event {
+	return queuetest.MakeEvent(mapstr.M{"id": i})
+}
+
+func getBatch(t *testing.T, q queue.Queue[publisher.Event], count int) queue.Batch[publisher.Event] {
+	t.Helper()
+	type result struct {
+		batch queue.Batch[publisher.Event]
+		err   error
+	}
+	results := make(chan result, 1)
+	go func() {
+		batch, err := q.Get(count)
+		results <- result{batch, err}
+	}()
+	select {
+	case r := <-results:
+		require.NoError(t, r.err)
+		return r.batch
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for a batch")
+		return nil
+	}
+}
+
+func assertEvents(t *testing.T, batch queue.Batch[publisher.Event], first, count int) {
+	t.Helper()
+	require.Equal(t, count, batch.Count())
+	for i := range count {
+		id, err := batch.Entry(i).Content.Fields.GetValue("id")
+		require.NoError(t, err)
+		assert.EqualValues(t, first+i, id, "event %d of the batch", i)
+	}
+}
+
+func waitDone(t *testing.T, q queue.Queue[publisher.Event]) {
+	t.Helper()
+	select {
+	case <-q.Done():
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "queue did not close in time")
+	}
+}
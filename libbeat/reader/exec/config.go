@@ -0,0 +1,49 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package exec
+
+import "time"
+
+// Config stores the configuration of the exec parser.
+type Config struct {
+	// Command is the path of the decoder executable.
+	Command string `config:"command" validate:"required"`
+	// Args are the arguments passed to the decoder.
+	Args []string `config:"args"`
+	// Timeout is the maximum time the decoder may take to decode a message,
+	// before it is restarted.
+	Timeout time.Duration `config:"timeout" validate:"positive"`
+	// MaxRetries is the number of times a message is sent again after the
+	// decoder failed, before it is passed on undecoded.
+	MaxRetries int `config:"max_retries" validate:"min=0"`
+	// RestartBackoff is the time waited before restarting a failed decoder.
+	RestartBackoff time.Duration `config:"restart_backoff" validate:"min=0"`
+	// IdleTimeout is the time a decoder that isn't used anymore keeps
+	// running, so it can be reused.
+	IdleTimeout time.Duration `config:"idle_timeout" validate:"min=0"`
+}
+
+// DefaultConfig returns a Config populated with default values.
+func DefaultConfig() Config {
+	return Config{
+		Timeout:        30 * time.Second,
+		MaxRetries:     3,
+		RestartBackoff: time.Second,
+		IdleTimeout:    time.Minute,
+	}
+}
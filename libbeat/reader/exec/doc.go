@@ -0,0 +1,44 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+/*
+Package exec provides a filestream parser that decodes messages with an
+external process, so proprietary formats can be decoded without changing
+Filebeat.
+
+The process is started with the configured command and runs until it is no
+longer used. The content of each message is written to its stdin as a frame,
+a 4 byte big endian length followed by that many bytes, and the process
+must write exactly one frame to its stdout in response, holding the decoded
+content. An empty response drops the message. Anything the process writes
+to its stderr is logged.
+
+Messages are sent one at a time, the next message is only read once the
+response to the previous one was received, so a slow process slows down the
+input instead of buffering messages. The decoded message keeps the size and
+offset of the original one, so the offsets saved in the registry are those
+of the file that is read.
+
+If the process exits, doesn't respond within the timeout or writes an invalid
+frame, it is restarted and the message is sent again. A message that fails
+more than max_retries times is passed on undecoded, with the error added
+under error.message.
+
+Parsers running the same command with the same arguments share a single
+process, which is stopped once it wasn't used for idle_timeout.
+*/
+package exec
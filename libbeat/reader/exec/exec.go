@@ -0,0 +1,94 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package exec
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/reader"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/go-concert/ctxtool"
+	"github.com/elastic/go-concert/timed"
+)
+
+// Parser decodes the content of messages with an external process.
+type Parser struct {
+	ctx      ctxtool.CancelContext
+	cfg      Config
+	maxBytes int
+	reader   reader.Reader
+	logger   *logp.Logger
+	process  *sharedProcess
+}
+
+// NewParser creates a new exec Parser. Responses larger than maxBytes are
+// rejected.
+func NewParser(r reader.Reader, cfg Config, maxBytes int, logger *logp.Logger) *Parser {
+	logger = logger.Named("reader_exec").With("command", cfg.Command)
+	return &Parser{
+		ctx:      ctxtool.WithCancelContext(context.Background()),
+		cfg:      cfg,
+		maxBytes: maxBytes,
+		reader:   r,
+		logger:   logger,
+		process:  processes.acquire(logger, cfg),
+	}
+}
+
+// Close releases the decoder and closes the underlying reader.
+func (p *Parser) Close() error {
+	p.ctx.Cancel()
+	processes.release(p.process, p.cfg.IdleTimeout)
+	return p.reader.Close()
+}
+
+// Next reads the next message and replaces its content with the content
+// decoded by the external process.
+func (p *Parser) Next() (reader.Message, error) {
+	msg, err := p.reader.Next()
+	if err != nil {
+		return msg, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		content, err := p.process.decode(msg.Content, p.cfg.Timeout, p.maxBytes)
+		if err == nil {
+			msg.Content = content
+			return msg, nil
+		}
+		if attempt >= p.cfg.MaxRetries {
+			p.logger.Errorf("Failed to decode message, passing it on undecoded: %v", err)
+			msg.AddFields(mapstr.M{
+				"error": mapstr.M{"message": fmt.Sprintf("exec parser failed to decode message: %v", err)},
+			})
+			return msg, nil
+		}
+		p.logger.Warnf("Decoder failed, restarting it in %v: %v", p.cfg.RestartBackoff, err)
+		if err := timed.Wait(p.ctx, p.cfg.RestartBackoff); err != nil {
+			return reader.Message{}, err
+		}
+	}
+}
+
+// SetReadDeadline delegates to the wrapped reader (see reader.DeadlineSetter).
+func (p *Parser) SetReadDeadline(t time.Time) bool {
+	return reader.SetReadDeadline(p.reader, t)
+}
@@ -0,0 +1,236 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/reader"
+	"github.com/elastic/elastic-agent-libs/logp/logptest"
+)
+
+const decoderEnv = "EXEC_PARSER_TEST_DECODER"
+
+// TestMain runs the test binary as a decoder when it is started by a parser.
+func TestMain(m *testing.M) {
+	if marker, ok := os.LookupEnv(decoderEnv); ok {
+		runDecoder(marker)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runDecoder upper-cases messages. Messages containing "crash" make it
+// exit, "crash-once" only the first time, "hang" makes it hang and "drop"
+// makes it respond with an empty frame.
+func runDecoder(marker string) {
+	in := bufio.NewReader(os.Stdin)
+	var header [4]byte
+	for {
+		if _, err := io.ReadFull(in, header[:]); err != nil {
+			return
+		}
+		content := make([]byte, binary.BigEndian.Uint32(header[:]))
+		if _, err := io.ReadFull(in, content); err != nil {
+			return
+		}
+
+		switch {
+		case bytes.Contains(content, []byte("crash-once")):
+			if _, err := os.Stat(marker); err != nil {
+				_ = os.WriteFile(marker, nil, 0o600)
+				os.Exit(1)
+			}
+		case bytes.Contains(content, []byte("crash")):
+			os.Stderr.WriteString("crashing\n")
+			os.Exit(1)
+		case bytes.Contains(content, []byte("hang")):
+			select {}
+		case bytes.Contains(content, []byte("drop")):
+			content = nil
+		}
+
+		response := bytes.ToUpper(content)
+		binary.BigEndian.PutUint32(header[:], uint32(len(response))) //nolint:gosec // test messages are small
+		_, _ = os.Stdout.Write(header[:])
+		_, _ = os.Stdout.Write(response)
+	}
+}
+
+func testConfig(t *testing.T) Config {
+	t.Setenv(decoderEnv, filepath.Join(t.TempDir(), "crashed"))
+	cfg := DefaultConfig()
+	cfg.Command = os.Args[0]
+	// The arguments make the decoder of each test unique.
+	cfg.Args = []string{"-test.run=^$", "-test.v=false", t.Name()}
+	cfg.RestartBackoff = 0
+	cfg.IdleTimeout = 0
+	return cfg
+}
+
+type testReader struct {
+	messages []reader.Message
+}
+
+func (r *testReader) Next() (reader.Message, error) {
+	if len(r.messages) == 0 {
+		return reader.Message{}, io.EOF
+	}
+	msg := r.messages[0]
+	r.messages = r.messages[1:]
+	return msg, nil
+}
+
+func (r *testReader) Close() error { return nil }
+
+func newTestReader(lines ...string) *testReader {
+	r := &testReader{}
+	offset := 0
+	for _, line := range lines {
+		r.messages = append(r.messages, reader.Message{
+			Content: []byte(line),
+			Bytes:   len(line),
+			Offset:  offset,
+		})
+		offset++
+	}
+	return r
+}
+
+func readAll(t *testing.T, p *Parser) []reader.Message {
+	t.Helper()
+	var messages []reader.Message
+	for {
+		msg, err := p.Next()
+		if err == io.EOF {
+			return messages
+		}
+		require.NoError(t, err)
+		messages = append(messages, msg)
+	}
+}
+
+func TestParserDecodes(t *testing.T) {
+	logger := logptest.NewTestingLogger(t, "")
+	p := NewParser(newTestReader("first\n", "drop this\n", "third\n"), testConfig(t), 1024, logger)
+	defer p.Close()
+
+	messages := readAll(t, p)
+	require.Len(t, messages, 3)
+	assert.Equal(t, "FIRST\n", string(messages[0].Content))
+	assert.Empty(t, messages[1].Content)
+	assert.True(t, messages[1].IsEmpty())
+	assert.Equal(t, "THIRD\n", string(messages[2].Content))
+	for i, msg := range messages {
+		assert.Equal(t, i, msg.Offset, "offsets are kept")
+	}
+	assert.Equal(t, len("drop this\n"), messages[1].Bytes, "sizes are kept")
+}
+
+func TestParserRestartsDecoder(t *testing.T) {
+	logger := logptest.NewTestingLogger(t, "")
+	p := NewParser(newTestReader("before\n", "crash-once\n", "after\n"), testConfig(t), 1024, logger)
+	defer p.Close()
+
+	messages := readAll(t, p)
+	require.Len(t, messages, 3)
+	assert.Equal(t, "BEFORE\n", string(messages[0].Content))
+	assert.Equal(t, "CRASH-ONCE\n", string(messages[1].Content))
+	assert.Equal(t, "AFTER\n", string(messages[2].Content))
+}
+
+func TestParserPassesOnUndecodedMessages(t *testing.T) {
+	logger := logptest.NewTestingLogger(t, "")
+	cfg := testConfig(t)
+	cfg.MaxRetries = 1
+	p := NewParser(newTestReader("crash\n", "after\n"), cfg, 1024, logger)
+	defer p.Close()
+
+	messages := readAll(t, p)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "crash\n", string(messages[0].Content))
+	errMsg, err := messages[0].Fields.GetValue("error.message")
+	require.NoError(t, err)
+	assert.Contains(t, errMsg, "decoder exited")
+	assert.Equal(t, "AFTER\n", string(messages[1].Content))
+}
+
+func TestParserTimeout(t *testing.T) {
+	logger := logptest.NewTestingLogger(t, "")
+	cfg := testConfig(t)
+	cfg.Timeout = 100 * time.Millisecond
+	cfg.MaxRetries = 0
+	p := NewParser(newTestReader("hang\n", "after\n"), cfg, 1024, logger)
+	defer p.Close()
+
+	messages := readAll(t, p)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "hang\n", string(messages[0].Content))
+	assert.Contains(t, messages[0].Fields, "error")
+	assert.Equal(t, "AFTER\n", string(messages[1].Content))
+}
+
+func TestParserRejectsLargeResponses(t *testing.T) {
+	logger := logptest.NewTestingLogger(t, "")
+	cfg := testConfig(t)
+	cfg.MaxRetries = 0
+	p := NewParser(newTestReader("too long\n", "ok\n"), cfg, 4, logger)
+	defer p.Close()
+
+	messages := readAll(t, p)
+	require.Len(t, messages, 2)
+	errMsg, err := messages[0].Fields.GetValue("error.message")
+	require.NoError(t, err)
+	assert.Contains(t, errMsg, errFrameTooLarge.Error())
+	assert.Equal(t, "OK\n", string(messages[1].Content))
+}
+
+func TestParsersShareDecoder(t *testing.T) {
+	logger := logptest.NewTestingLogger(t, "")
+	cfg := testConfig(t)
+	cfg.IdleTimeout = 50 * time.Millisecond
+
+	p1 := NewParser(newTestReader("a\n"), cfg, 1024, logger)
+	p2 := NewParser(newTestReader("b\n"), cfg, 1024, logger)
+	require.Same(t, p1.process, p2.process)
+	readAll(t, p1)
+	readAll(t, p2)
+	proc := p1.process.proc
+	require.NotNil(t, proc)
+
+	require.NoError(t, p1.Close())
+	require.NoError(t, p2.Close())
+	select {
+	case <-proc.exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the decoder didn't stop once it was idle")
+	}
+	processes.mu.Lock()
+	defer processes.mu.Unlock()
+	assert.NotContains(t, processes.processes, p1.process.key)
+}
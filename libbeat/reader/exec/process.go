@@ -0,0 +1,232 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package exec
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	osexec "os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// errFrameTooLarge is returned when the decoder responds with a frame larger
+// than the maximum message size.
+var errFrameTooLarge = errors.New("response frame exceeds max_bytes")
+
+// process is a running decoder.
+type process struct {
+	cmd    *osexec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	// exited is closed once the process exited.
+	exited chan struct{}
+}
+
+func startProcess(log *logp.Logger, command string, args []string) (*process, error) {
+	cmd := osexec.Command(command, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start decoder %q: %w", command, err)
+	}
+
+	p := &process{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewReader(stdout),
+		exited: make(chan struct{}),
+	}
+	go func() {
+		s := bufio.NewScanner(stderr)
+		for s.Scan() {
+			log.Warnf("Decoder: %s", s.Text())
+		}
+		err := cmd.Wait()
+		log.Debugf("Decoder exited: %v", err)
+		close(p.exited)
+	}()
+	return p, nil
+}
+
+// decode sends content to the process and returns its response. If it
+// fails, the process must not be used anymore.
+func (p *process) decode(content []byte, timeout time.Duration, maxBytes int) ([]byte, error) {
+	// Killing the process makes the pending read or write fail.
+	timer := time.AfterFunc(timeout, p.kill)
+	defer timer.Stop()
+
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(content))) //nolint:gosec // G115: messages are limited by max_bytes
+	if _, err := p.stdin.Write(header[:]); err != nil {
+		return nil, p.failure(err)
+	}
+	if _, err := p.stdin.Write(content); err != nil {
+		return nil, p.failure(err)
+	}
+
+	if _, err := io.ReadFull(p.stdout, header[:]); err != nil {
+		return nil, p.failure(err)
+	}
+	size := int(binary.BigEndian.Uint32(header[:]))
+	if maxBytes > 0 && size > maxBytes {
+		return nil, errFrameTooLarge
+	}
+	response := make([]byte, size)
+	if _, err := io.ReadFull(p.stdout, response); err != nil {
+		return nil, p.failure(err)
+	}
+	return response, nil
+}
+
+// failure explains an I/O error, which is most likely caused by the process
+// exiting.
+func (p *process) failure(err error) error {
+	select {
+	case <-p.exited:
+		return fmt.Errorf("decoder exited: %s", p.cmd.ProcessState)
+	case <-time.After(100 * time.Millisecond):
+		return fmt.Errorf("failed to communicate with decoder: %w", err)
+	}
+}
+
+func (p *process) kill() {
+	_ = p.cmd.Process.Kill()
+}
+
+// stop closes the stdin of the process, so it can exit, and kills it if it
+// doesn't exit in time.
+func (p *process) stop(timeout time.Duration) {
+	_ = p.stdin.Close()
+	select {
+	case <-p.exited:
+	case <-time.After(timeout):
+		p.kill()
+		<-p.exited
+	}
+}
+
+// sharedProcess is a decoder shared by all the parsers running the same
+// command. Messages are decoded one at a time.
+type sharedProcess struct {
+	key     string
+	command string
+	args    []string
+	log     *logp.Logger
+
+	// mu serializes the use of the process.
+	mu   sync.Mutex
+	proc *process
+
+	// refs and idle are guarded by the mutex of the pool.
+	refs int
+	idle *time.Timer
+}
+
+// decode decodes content, starting the process if it isn't running.
+func (s *sharedProcess) decode(content []byte, timeout time.Duration, maxBytes int) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.proc == nil {
+		proc, err := startProcess(s.log, s.command, s.args)
+		if err != nil {
+			return nil, err
+		}
+		s.proc = proc
+	}
+	response, err := s.proc.decode(content, timeout, maxBytes)
+	if err != nil {
+		s.proc.kill()
+		<-s.proc.exited
+		s.proc = nil
+	}
+	return response, err
+}
+
+func (s *sharedProcess) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.proc != nil {
+		s.proc.stop(5 * time.Second)
+		s.proc = nil
+	}
+}
+
+// pool holds the decoders in use.
+type pool struct {
+	mu        sync.Mutex
+	processes map[string]*sharedProcess
+}
+
+var processes = pool{processes: map[string]*sharedProcess{}}
+
+// acquire returns the decoder running the command of the config.
+func (p *pool) acquire(log *logp.Logger, c Config) *sharedProcess {
+	key := strings.Join(append([]string{c.Command}, c.Args...), "\x00")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s, ok := p.processes[key]
+	if !ok {
+		s = &sharedProcess{key: key, command: c.Command, args: c.Args, log: log}
+		p.processes[key] = s
+	}
+	if s.idle != nil {
+		s.idle.Stop()
+		s.idle = nil
+	}
+	s.refs++
+	return s
+}
+
+// release stops the decoder once it wasn't acquired again for idleTimeout.
+func (p *pool) release(s *sharedProcess, idleTimeout time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s.refs--
+	if s.refs > 0 {
+		return
+	}
+	s.idle = time.AfterFunc(idleTimeout, func() {
+		p.mu.Lock()
+		if s.refs > 0 || p.processes[s.key] != s {
+			p.mu.Unlock()
+			return
+		}
+		delete(p.processes, s.key)
+		p.mu.Unlock()
+		s.stop()
+	})
+}
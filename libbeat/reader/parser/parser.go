@@ -27,6 +27,7 @@ import (
 	"github.com/elastic/beats/v7/libbeat/common/cfgtype"
 	"github.com/elastic/beats/v7/libbeat/reader"
 	"github.com/elastic/beats/v7/libbeat/reader/auditd"
+	"github.com/elastic/beats/v7/libbeat/reader/exec"
 	"github.com/elastic/beats/v7/libbeat/reader/filter"
 	"github.com/elastic/beats/v7/libbeat/reader/multiline"
 	"github.com/elastic/beats/v7/libbeat/reader/readfile"
@@ -137,6 +138,13 @@ func NewConfig(pCfg CommonConfig, parsers []config.Namespace) (*Config, error) {
 			if err != nil {
 				return nil, fmt.Errorf("error while parsing auditd parser config: %w", err)
 			}
+		case "exec":
+			config := exec.DefaultConfig()
+			cfg := ns.Config()
+			err := cfg.Unpack(&config)
+			if err != nil {
+				return nil, fmt.Errorf("error while parsing exec parser config: %w", err)
+			}
 		default:
 			return nil, fmt.Errorf("%s: %w", name, ErrNoSuchParser)
 		}
@@ -206,6 +214,14 @@ func (c *Config) Create(in reader.Reader, log *logp.Logger) Parser {
 				return p
 			}
 			p = auditd.NewParser(p, config, log)
+		case "exec":
+			config := exec.DefaultConfig()
+			cfg := ns.Config()
+			err := cfg.Unpack(&config)
+			if err != nil {
+				return p
+			}
+			p = exec.NewParser(p, config, int(c.pCfg.MaxBytes), log)
 		default:
 			return p
 		}
@@ -770,6 +770,21 @@ func TestParserIncludeMessages(t *testing.T) {
 	require.Equal(t, expectedMessages, readMsgs, "fii")
 }
 
+func TestParserExecRequiresCommand(t *testing.T) {
+	cfg := config.MustNewConfigFrom(map[string]any{
+		"parsers": []map[string]any{
+			{
+				"exec": map[string]any{
+					"args": []string{"--decode"},
+				},
+			},
+		},
+	})
+	var c inputParsersConfig
+	err := cfg.Unpack(&c)
+	require.ErrorContains(t, err, "error while parsing exec parser config")
+}
+
 type testParsersConfig struct {
 	Parsers []config.Namespace `struct:"parsers"`
 }
@@ -0,0 +1,507 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package evtx
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// BinXML tokens.
+const (
+	tokenEndOfStream          = 0x00
+	tokenOpenStartElement     = 0x01
+	tokenCloseStartElement    = 0x02
+	tokenCloseEmptyElement    = 0x03
+	tokenEndElement           = 0x04
+	tokenValue                = 0x05
+	tokenAttribute            = 0x06
+	tokenCDATASection         = 0x07
+	tokenCharRef              = 0x08
+	tokenEntityRef            = 0x09
+	tokenPITarget             = 0x0a
+	tokenPIData               = 0x0b
+	tokenTemplateInstance     = 0x0c
+	tokenNormalSubstitution   = 0x0d
+	tokenOptionalSubstitution = 0x0e
+	tokenFragmentHeader       = 0x0f
+
+	// tokenHasMoreData is set on start element tokens of elements with
+	// attributes, and on attribute and value tokens followed by more of the
+	// same.
+	tokenHasMoreData = 0x40
+)
+
+// maxTemplateDepth bounds the nesting of templates through BinXML
+// substitution values, to guard against malformed files.
+const maxTemplateDepth = 16
+
+var errUnexpectedEnd = errors.New("unexpected end of binxml data")
+
+// chunk is a chunk of an EVTX file. The strings and templates referenced by
+// the records of a chunk are stored within the chunk, referenced by their
+// offset.
+type chunk struct {
+	data      []byte
+	freeSpace int
+	names     map[int]string
+}
+
+// render returns the XML for the BinXML data at data[start:end].
+func (c *chunk) render(start, end int) (string, error) {
+	var sb strings.Builder
+	d := decoder{chunk: c, data: c.data[:end], pos: start, out: &sb}
+	d.fragment(nil)
+	if d.err != nil {
+		return "", d.err
+	}
+	return sb.String(), nil
+}
+
+// value is a substitution value of a template instance.
+type value struct {
+	typ    byte
+	data   []byte
+	offset int // offset of data within the chunk
+}
+
+// decoder renders BinXML to XML. The first error encountered is kept in err,
+// after which all decoding methods are no-ops.
+type decoder struct {
+	chunk *chunk
+	data  []byte
+	pos   int
+	depth int
+	out   *strings.Builder
+	err   error
+
+	// inAttribute is set while decoding an attribute value, where quotes
+	// are escaped too.
+	inAttribute bool
+}
+
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+// bytes consumes the next n bytes, returning nil if not enough are left.
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || d.pos+n > len(d.data) {
+		d.fail(errUnexpectedEnd)
+		return nil
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b
+}
+
+func (d *decoder) u8() byte {
+	if b := d.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) u16() uint16 {
+	if b := d.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) u32() uint32 {
+	if b := d.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// peek returns the next token without consuming it.
+func (d *decoder) peek() byte {
+	if d.err != nil {
+		return tokenEndOfStream
+	}
+	if d.pos >= len(d.data) {
+		d.fail(errUnexpectedEnd)
+		return tokenEndOfStream
+	}
+	return d.data[d.pos]
+}
+
+// fragment decodes tokens up to the end of stream token.
+func (d *decoder) fragment(subs []value) {
+	for d.err == nil && d.pos < len(d.data) {
+		switch tok := d.data[d.pos]; tok &^ tokenHasMoreData {
+		case tokenEndOfStream:
+			d.pos++
+			return
+		case tokenFragmentHeader:
+			// Token, major and minor version, flags.
+			d.bytes(4)
+		case tokenTemplateInstance:
+			d.templateInstance()
+		case tokenOpenStartElement:
+			d.element(subs)
+		default:
+			d.text(subs)
+		}
+	}
+}
+
+// templateInstance decodes a template instance, rendering the template with
+// the instance's substitution values.
+func (d *decoder) templateInstance() {
+	if d.depth >= maxTemplateDepth {
+		d.fail(errors.New("templates nested too deep"))
+		return
+	}
+	// Token, unknown byte, template identifier.
+	d.bytes(6)
+	defOffset := int(d.u32())
+	if d.err != nil {
+		return
+	}
+	if defOffset+24 > len(d.data) {
+		d.fail(fmt.Errorf("invalid template offset %d", defOffset))
+		return
+	}
+	// The definition: next template offset, GUID, data size and data.
+	bodySize := int(binary.LittleEndian.Uint32(d.data[defOffset+20:]))
+	body := defOffset + 24
+	if body+bodySize > len(d.data) {
+		d.fail(fmt.Errorf("invalid size %d of template at %d", bodySize, defOffset))
+		return
+	}
+	if defOffset == d.pos {
+		// Templates are defined inline the first time a chunk uses them.
+		d.pos = body + bodySize
+	}
+
+	count := int(d.u32())
+	descriptors := d.bytes(4 * count)
+	if d.err != nil {
+		return
+	}
+	subs := make([]value, count)
+	for i := range subs {
+		size := int(binary.LittleEndian.Uint16(descriptors[4*i:]))
+		subs[i].typ = descriptors[4*i+2]
+		subs[i].offset = d.pos
+		subs[i].data = d.bytes(size)
+	}
+	if d.err != nil {
+		return
+	}
+
+	t := decoder{chunk: d.chunk, data: d.data[:body+bodySize], pos: body, depth: d.depth + 1, out: d.out}
+	t.fragment(subs)
+	d.fail(t.err)
+}
+
+// element decodes an element and its content. Like Windows does, elements
+// without attributes whose content is only optional substitutions without a
+// value are omitted.
+func (d *decoder) element(subs []value) {
+	tok := d.u8()
+	// Dependency identifier and data size.
+	d.bytes(6)
+	name := d.name()
+	if tok&tokenHasMoreData != 0 {
+		// Attribute list size.
+		d.bytes(4)
+	}
+	if d.err != nil {
+		return
+	}
+
+	out := d.out
+	var sb strings.Builder
+	d.out = &sb
+	defer func() { d.out = out }()
+
+	sb.WriteByte('<')
+	sb.WriteString(name)
+	for d.peek()&^tokenHasMoreData == tokenAttribute {
+		d.attribute(subs)
+	}
+	hasAttributes := sb.Len() > len(name)+1
+
+	switch tok := d.u8(); tok {
+	case tokenCloseEmptyElement:
+		sb.WriteString("/>")
+	case tokenCloseStartElement:
+		sb.WriteByte('>')
+		if items, ok := d.arrayContent(subs); ok {
+			// Windows repeats the element for each item of an array.
+			start := sb.String()
+			for _, item := range items {
+				out.WriteString(start)
+				out.WriteString(escape(item, false))
+				out.WriteString("</")
+				out.WriteString(name)
+				out.WriteByte('>')
+			}
+			return
+		}
+		if !d.content(subs) && !hasAttributes {
+			return
+		}
+		sb.WriteString("</")
+		sb.WriteString(name)
+		sb.WriteByte('>')
+	default:
+		d.fail(fmt.Errorf("unexpected token 0x%02x in element %s", tok, name))
+		return
+	}
+	out.WriteString(sb.String())
+}
+
+// arrayContent decodes the content of an element if it is only the
+// substitution of an array, returning the items of the array.
+func (d *decoder) arrayContent(subs []value) ([]string, bool) {
+	// Substitution token, identifier, value type and end element token.
+	if d.err != nil || d.pos+5 > len(d.data) {
+		return nil, false
+	}
+	switch d.data[d.pos] {
+	case tokenNormalSubstitution, tokenOptionalSubstitution:
+	default:
+		return nil, false
+	}
+	id := int(binary.LittleEndian.Uint16(d.data[d.pos+1:]))
+	if id >= len(subs) || subs[id].typ&typeArray == 0 || d.data[d.pos+4] != tokenEndElement {
+		return nil, false
+	}
+	items, err := formatArray(subs[id].typ&^typeArray, subs[id].data)
+	if err != nil {
+		d.fail(err)
+		return nil, false
+	}
+	d.pos += 5
+	return items, true
+}
+
+// content decodes the content of an element up to its end element token. It
+// returns false if the content was only optional substitutions without a
+// value.
+func (d *decoder) content(subs []value) bool {
+	present, empty := false, false
+	for d.err == nil {
+		switch d.peek() &^ tokenHasMoreData {
+		case tokenEndElement:
+			d.pos++
+			return present || !empty
+		case tokenOpenStartElement:
+			d.element(subs)
+			present = true
+		default:
+			if d.text(subs) {
+				present = true
+			} else {
+				empty = true
+			}
+		}
+	}
+	return true
+}
+
+// attribute decodes an attribute. Attributes whose value is only an optional
+// substitution without a value are omitted.
+func (d *decoder) attribute(subs []value) {
+	d.bytes(1)
+	name := d.name()
+
+	out := d.out
+	var sb strings.Builder
+	d.out = &sb
+	d.inAttribute = true
+	present := false
+	for d.err == nil {
+		tok := d.peek() &^ tokenHasMoreData
+		if tok == tokenAttribute || tok == tokenCloseStartElement || tok == tokenCloseEmptyElement {
+			break
+		}
+		if d.text(subs) {
+			present = true
+		}
+	}
+	d.out = out
+	d.inAttribute = false
+
+	if present {
+		d.out.WriteByte(' ')
+		d.out.WriteString(name)
+		d.out.WriteString("='")
+		d.out.WriteString(sb.String())
+		d.out.WriteByte('\'')
+	}
+}
+
+// text decodes a token that produces character data. It returns false if the
+// token was an optional substitution without a value.
+func (d *decoder) text(subs []value) bool {
+	switch tok := d.u8(); tok &^ tokenHasMoreData {
+	case tokenValue:
+		// Value type, always a string.
+		d.bytes(1)
+		d.out.WriteString(escape(d.string(), d.inAttribute))
+	case tokenNormalSubstitution, tokenOptionalSubstitution:
+		id := int(d.u16())
+		d.bytes(1) // value type
+		if d.err != nil {
+			return false
+		}
+		if id >= len(subs) {
+			d.fail(fmt.Errorf("substitution %d out of range", id))
+			return false
+		}
+		v := subs[id]
+		if tok == tokenOptionalSubstitution && (v.typ == typeNull || len(v.data) == 0) {
+			return false
+		}
+		d.substitution(v)
+	case tokenCDATASection:
+		d.out.WriteString("<![CDATA[")
+		d.out.WriteString(d.string())
+		d.out.WriteString("]]>")
+	case tokenCharRef:
+		d.out.WriteString("&#" + strconv.Itoa(int(d.u16())) + ";")
+	case tokenEntityRef:
+		d.out.WriteString("&" + d.name() + ";")
+	case tokenPITarget:
+		d.out.WriteString("<?" + d.name())
+	case tokenPIData:
+		d.out.WriteString(" " + d.string() + "?>")
+	case tokenTemplateInstance:
+		d.pos--
+		d.templateInstance()
+	default:
+		d.fail(fmt.Errorf("unexpected token 0x%02x at offset %d", tok, d.pos-1))
+	}
+	return true
+}
+
+// substitution renders a substitution value.
+func (d *decoder) substitution(v value) {
+	if v.typ == typeBinXML {
+		sub := decoder{chunk: d.chunk, data: d.data[:v.offset+len(v.data)], pos: v.offset, depth: d.depth + 1, out: d.out}
+		sub.fragment(nil)
+		d.fail(sub.err)
+		return
+	}
+	s, err := formatValue(v.typ, v.data)
+	if err != nil {
+		d.fail(err)
+		return
+	}
+	d.out.WriteString(escape(s, d.inAttribute))
+}
+
+// string decodes a length-prefixed UTF-16 string.
+func (d *decoder) string() string {
+	n := int(d.u16())
+	return utf16String(d.bytes(2 * n))
+}
+
+// name decodes a reference to a name. Names are stored in the chunk and
+// referenced by offset, they are defined inline the first time they are
+// used.
+func (d *decoder) name() string {
+	offset := int(d.u32())
+	if d.err != nil {
+		return ""
+	}
+	name, size, err := d.chunk.name(offset)
+	if err != nil {
+		d.fail(err)
+		return ""
+	}
+	if offset == d.pos {
+		d.bytes(size)
+	}
+	return name
+}
+
+// name returns the name at the given offset and the size it takes in the
+// chunk: the offset of the next name, hash, length, and the null terminated
+// UTF-16 string.
+func (c *chunk) name(offset int) (string, int, error) {
+	if offset+8 > len(c.data) {
+		return "", 0, fmt.Errorf("invalid name offset %d", offset)
+	}
+	n := int(binary.LittleEndian.Uint16(c.data[offset+6:]))
+	size := 10 + 2*n
+	if offset+size > len(c.data) {
+		return "", 0, fmt.Errorf("invalid name length %d at offset %d", n, offset)
+	}
+	if name, ok := c.names[offset]; ok {
+		return name, size, nil
+	}
+	name := utf16String(c.data[offset+8 : offset+8+2*n])
+	if c.names == nil {
+		c.names = make(map[int]string)
+	}
+	c.names[offset] = name
+	return name, size, nil
+}
+
+// utf16String decodes a UTF-16LE string, dropping any trailing null
+// characters.
+func utf16String(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	for len(u) > 0 && u[len(u)-1] == 0 {
+		u = u[:len(u)-1]
+	}
+	return string(utf16.Decode(u))
+}
+
+var (
+	textEscaper = strings.NewReplacer(
+		"&", "&amp;",
+		"<", "&lt;",
+		">", "&gt;",
+	)
+	attributeEscaper = strings.NewReplacer(
+		"&", "&amp;",
+		"<", "&lt;",
+		">", "&gt;",
+		"'", "&apos;",
+		"\"", "&quot;",
+	)
+)
+
+// escape escapes the markup characters of character data. In attribute
+// values, which are quoted with apostrophes, quotes are escaped too.
+func escape(s string, attribute bool) string {
+	if attribute {
+		return attributeEscaper.Replace(s)
+	}
+	return textEscaper.Replace(s)
+}
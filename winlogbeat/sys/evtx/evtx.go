@@ -0,0 +1,244 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package evtx reads Windows event log files (.evtx) without using the
+// Windows API, so exported event logs can be processed on any platform.
+//
+// An EVTX file is a file header followed by fixed size chunks. Each chunk
+// holds event records whose data is binary XML (BinXML), which references
+// the strings and templates stored in the same chunk. Records are rendered
+// to the same XML produced by the Windows EvtRender function, which can be
+// decoded with winevent.UnmarshalXML.
+package evtx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	fileHeaderSize  = 4096
+	chunkSize       = 65536
+	chunkHeaderSize = 512
+	recordHeaderLen = 24
+)
+
+var (
+	fileSignature   = []byte("ElfFile\x00")
+	chunkSignature  = []byte("ElfChnk\x00")
+	recordSignature = []byte{0x2a, 0x2a, 0x00, 0x00}
+)
+
+// ErrInvalidFile is returned when a file doesn't have the EVTX file header.
+var ErrInvalidFile = errors.New("not an evtx file")
+
+// Record is an event record read from an EVTX file.
+type Record struct {
+	// ID is the event record identifier, it increases monotonically within
+	// an event log.
+	ID uint64
+
+	// Written is the time the record was written to the log.
+	Written time.Time
+
+	// XML is the rendered event.
+	XML string
+}
+
+// RecordError is returned by Reader.Next when a record can't be read. The
+// reader moves on to the next record on the following call.
+type RecordError struct {
+	Chunk  int    // Index of the chunk holding the record.
+	Offset int    // Offset of the record within its chunk.
+	ID     uint64 // Record identifier, 0 if it couldn't be read.
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	if e.ID != 0 {
+		return fmt.Sprintf("failed to read record %d in chunk %d: %v", e.ID, e.Chunk, e.Err)
+	}
+	return fmt.Sprintf("failed to read record at offset %d of chunk %d: %v", e.Offset, e.Chunk, e.Err)
+}
+
+func (e *RecordError) Unwrap() error { return e.Err }
+
+// Reader reads the event records of an EVTX file in the order they are
+// stored, which is the order of their identifiers.
+type Reader struct {
+	r      io.ReaderAt
+	chunks int // number of chunks that fit in the file
+
+	chunkIndex int    // index of the current chunk
+	chunk      *chunk // current chunk, nil if not loaded yet
+	offset     int    // offset of the next record in the current chunk
+	buf        []byte
+}
+
+// File is a Reader over an open EVTX file.
+type File struct {
+	*Reader
+	f *os.File
+}
+
+// Open opens the EVTX file at path.
+func Open(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r, err := NewReader(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return &File{Reader: r, f: f}, nil
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	return f.f.Close()
+}
+
+// NewReader returns a Reader for the EVTX data of the given size.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	header := make([]byte, 128)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrInvalidFile
+		}
+		return nil, err
+	}
+	if !bytes.Equal(header[:8], fileSignature) {
+		return nil, ErrInvalidFile
+	}
+	if major := binary.LittleEndian.Uint16(header[38:]); major != 3 {
+		return nil, fmt.Errorf("unsupported evtx format version %d", major)
+	}
+	// The number of chunks in the header isn't reliable for files that
+	// weren't closed cleanly, so use the file size instead.
+	chunks := int((size - fileHeaderSize) / chunkSize)
+	return &Reader{
+		r:      r,
+		chunks: max(chunks, 0),
+		buf:    make([]byte, chunkSize),
+	}, nil
+}
+
+// Next returns the next record, or io.EOF once all records were read. If a
+// record can't be read, Next returns a *RecordError and the following call
+// continues with the next record. A chunk that fails its integrity checks
+// is reported as a *RecordError and skipped.
+func (r *Reader) Next() (Record, error) {
+	for {
+		if r.chunk == nil {
+			if r.chunkIndex >= r.chunks {
+				return Record{}, io.EOF
+			}
+			err := r.loadChunk()
+			if errors.Is(err, errEmptyChunk) {
+				r.chunkIndex++
+				continue
+			}
+			if err != nil {
+				r.chunkIndex++
+				return Record{}, &RecordError{Chunk: r.chunkIndex - 1, Err: err}
+			}
+		}
+
+		if r.offset+recordHeaderLen > r.chunk.freeSpace ||
+			!bytes.Equal(r.chunk.data[r.offset:r.offset+4], recordSignature) {
+			r.chunk = nil
+			r.chunkIndex++
+			continue
+		}
+
+		offset := r.offset
+		size := int(binary.LittleEndian.Uint32(r.chunk.data[offset+4:]))
+		if size < recordHeaderLen+4 || offset+size > r.chunk.freeSpace {
+			// Without a valid size the next record can't be found.
+			r.chunk = nil
+			r.chunkIndex++
+			return Record{}, &RecordError{Chunk: r.chunkIndex - 1, Offset: offset, Err: fmt.Errorf("invalid record size %d", size)}
+		}
+		r.offset += size
+
+		rec := Record{
+			ID:      binary.LittleEndian.Uint64(r.chunk.data[offset+8:]),
+			Written: filetimeToTime(binary.LittleEndian.Uint64(r.chunk.data[offset+16:])),
+		}
+		xml, err := r.chunk.render(offset+recordHeaderLen, offset+size-4)
+		if err != nil {
+			return rec, &RecordError{Chunk: r.chunkIndex, Offset: offset, ID: rec.ID, Err: err}
+		}
+		rec.XML = xml
+		return rec, nil
+	}
+}
+
+var errEmptyChunk = errors.New("empty chunk")
+
+// loadChunk reads and validates the chunk at r.chunkIndex.
+func (r *Reader) loadChunk() error {
+	n, err := r.r.ReadAt(r.buf, fileHeaderSize+int64(r.chunkIndex)*chunkSize)
+	if n < chunkSize {
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	data := r.buf
+	if !bytes.Equal(data[:8], chunkSignature) {
+		// Preallocated chunks that were never written are zeroed.
+		return errEmptyChunk
+	}
+	headerChecksum := crc32.NewIEEE()
+	headerChecksum.Write(data[:120])
+	headerChecksum.Write(data[128:chunkHeaderSize])
+	if headerChecksum.Sum32() != binary.LittleEndian.Uint32(data[124:]) {
+		return errors.New("chunk header checksum mismatch")
+	}
+	freeSpace := int(binary.LittleEndian.Uint32(data[48:]))
+	if freeSpace < chunkHeaderSize || freeSpace > chunkSize {
+		return fmt.Errorf("invalid chunk free space offset %d", freeSpace)
+	}
+	if crc32.ChecksumIEEE(data[chunkHeaderSize:freeSpace]) != binary.LittleEndian.Uint32(data[52:]) {
+		return errors.New("chunk records checksum mismatch")
+	}
+	r.chunk = &chunk{data: data, freeSpace: freeSpace}
+	r.offset = chunkHeaderSize
+	return nil
+}
+
+// filetimeToTime converts a Windows FILETIME, the number of 100-nanosecond
+// intervals since January 1, 1601 UTC, to a time.Time.
+func filetimeToTime(ft uint64) time.Time {
+	const epochDelta = 116444736000000000 // 1601-01-01 to 1970-01-01 in 100ns
+	t := int64(ft) - epochDelta           //nolint:gosec // G115: FILETIME values fit in an int64
+	return time.Unix(t/1e7, (t%1e7)*100).UTC()
+}
@@ -0,0 +1,134 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package evtx
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testdata = "../wineventlog/testdata"
+
+var recordIDRegexp = regexp.MustCompile(`<EventRecordID>(\d+)</EventRecordID>`)
+
+// TestReaderMatchesWindowsRendering compares the XML of the records with the
+// XML rendered by the Windows API for the same events. The record numbers
+// differ, since the files were exported from larger logs.
+func TestReaderMatchesWindowsRendering(t *testing.T) {
+	files := []string{
+		"application-windows-error-reporting",
+		"ec1",
+		"ec2",
+		"ec3",
+		"ec3and4",
+		"ec4",
+		"original",
+		"raw",
+		"sysmon-9.01",
+	}
+	for _, name := range files {
+		t.Run(name, func(t *testing.T) {
+			expected := readExpectedXML(t, filepath.Join(testdata, name+".xml"))
+
+			f, err := Open(filepath.Join(testdata, name+".evtx"))
+			require.NoError(t, err)
+			defer f.Close()
+
+			var actual []string
+			for {
+				r, err := f.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				require.NoError(t, err)
+				assert.False(t, r.Written.IsZero())
+				actual = append(actual, withoutRecordID(r.XML))
+			}
+			assert.ElementsMatch(t, expected, actual)
+		})
+	}
+}
+
+func TestReaderRecordsInOrder(t *testing.T) {
+	f, err := Open(filepath.Join(testdata, "sysmon-9.01.evtx"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	var last uint64
+	for {
+		r, err := f.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		assert.Greater(t, r.ID, last)
+		last = r.ID
+	}
+}
+
+func TestOpenInvalidFile(t *testing.T) {
+	_, err := Open(filepath.Join(testdata, "ec1.xml"))
+	assert.ErrorIs(t, err, ErrInvalidFile)
+
+	path := filepath.Join(t.TempDir(), "short.evtx")
+	require.NoError(t, os.WriteFile(path, []byte("ElfFile\x00"), 0o600))
+	_, err = Open(path)
+	assert.ErrorIs(t, err, ErrInvalidFile)
+}
+
+func TestReaderCorruptRecord(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(testdata, "ec3and4.evtx"))
+	require.NoError(t, err)
+	// Corrupt the data of the first record, after the chunk header.
+	data[fileHeaderSize+chunkHeaderSize+recordHeaderLen+8] ^= 0xff
+
+	r, err := NewReader(strings.NewReader(string(data)), int64(len(data)))
+	require.NoError(t, err)
+	_, err = r.Next()
+	var recordErr *RecordError
+	assert.ErrorAs(t, err, &recordErr)
+}
+
+// readExpectedXML reads the events of a file with one event per line. Events
+// may span several lines if their data contains line breaks.
+func readExpectedXML(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var events []string
+	for _, event := range strings.SplitAfter(strings.TrimSpace(string(data)), "</Event>") {
+		event = strings.TrimSpace(event)
+		if event != "" {
+			events = append(events, withoutRecordID(event))
+		}
+	}
+	return events
+}
+
+func withoutRecordID(xml string) string {
+	return recordIDRegexp.ReplaceAllString(xml, "<EventRecordID/>")
+}
@@ -0,0 +1,227 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package evtx
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Substitution value types.
+const (
+	typeNull       = 0x00
+	typeString     = 0x01
+	typeAnsiString = 0x02
+	typeInt8       = 0x03
+	typeUint8      = 0x04
+	typeInt16      = 0x05
+	typeUint16     = 0x06
+	typeInt32      = 0x07
+	typeUint32     = 0x08
+	typeInt64      = 0x09
+	typeUint64     = 0x0a
+	typeReal32     = 0x0b
+	typeReal64     = 0x0c
+	typeBool       = 0x0d
+	typeBinary     = 0x0e
+	typeGUID       = 0x0f
+	typeSizeT      = 0x10
+	typeFileTime   = 0x11
+	typeSystemTime = 0x12
+	typeSID        = 0x13
+	typeHexInt32   = 0x14
+	typeHexInt64   = 0x15
+	typeEvtHandle  = 0x20
+	typeBinXML     = 0x21
+	typeEvtXML     = 0x23
+
+	// typeArray is set on the type of arrays of the base type.
+	typeArray = 0x80
+)
+
+// formatValue renders a substitution value the way EvtRender does. Array
+// items are separated by commas.
+func formatValue(typ byte, data []byte) (string, error) {
+	if typ&typeArray == 0 {
+		return formatScalar(typ, data)
+	}
+	items, err := formatArray(typ, data)
+	return strings.Join(items, ","), err
+}
+
+// formatArray renders the items of an array value.
+func formatArray(typ byte, data []byte) ([]string, error) {
+
+	typ &^= typeArray
+	var items []string
+	switch typ {
+	case typeString:
+		items = strings.Split(utf16String(data), "\x00")
+	case typeAnsiString:
+		items = strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+	default:
+		size := scalarSize(typ, len(data))
+		if size == 0 || len(data)%size != 0 {
+			return nil, fmt.Errorf("unsupported array of value type 0x%02x", typ)
+		}
+		for i := 0; i < len(data); i += size {
+			s, err := formatScalar(typ, data[i:i+size])
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, s)
+		}
+	}
+	return items, nil
+}
+
+// scalarSize returns the size of a fixed size value type, or 0 for variable
+// size types.
+func scalarSize(typ byte, dataLen int) int {
+	switch typ {
+	case typeInt8, typeUint8:
+		return 1
+	case typeInt16, typeUint16:
+		return 2
+	case typeInt32, typeUint32, typeReal32, typeBool, typeHexInt32:
+		return 4
+	case typeInt64, typeUint64, typeReal64, typeFileTime, typeHexInt64:
+		return 8
+	case typeGUID, typeSystemTime:
+		return 16
+	case typeSizeT:
+		if dataLen%8 == 0 {
+			return 8
+		}
+		return 4
+	default:
+		return 0
+	}
+}
+
+func formatScalar(typ byte, data []byte) (string, error) {
+	if size := scalarSize(typ, len(data)); size != 0 && len(data) != size {
+		return "", fmt.Errorf("invalid size %d for value type 0x%02x", len(data), typ)
+	}
+
+	le := binary.LittleEndian
+	switch typ {
+	case typeNull:
+		return "", nil
+	case typeString, typeEvtXML:
+		return utf16String(data), nil
+	case typeAnsiString:
+		return strings.TrimRight(string(data), "\x00"), nil
+	case typeInt8:
+		return strconv.Itoa(int(int8(data[0]))), nil
+	case typeUint8:
+		return strconv.Itoa(int(data[0])), nil
+	case typeInt16:
+		return strconv.Itoa(int(int16(le.Uint16(data)))), nil //nolint:gosec // G115: reinterpreting the bits as signed
+	case typeUint16:
+		return strconv.Itoa(int(le.Uint16(data))), nil
+	case typeInt32:
+		return strconv.FormatInt(int64(int32(le.Uint32(data))), 10), nil //nolint:gosec // G115: reinterpreting the bits as signed
+	case typeUint32:
+		return strconv.FormatUint(uint64(le.Uint32(data)), 10), nil
+	case typeInt64:
+		return strconv.FormatInt(int64(le.Uint64(data)), 10), nil //nolint:gosec // G115: reinterpreting the bits as signed
+	case typeUint64:
+		return strconv.FormatUint(le.Uint64(data), 10), nil
+	case typeReal32:
+		return strconv.FormatFloat(float64(math.Float32frombits(le.Uint32(data))), 'g', -1, 32), nil
+	case typeReal64:
+		return strconv.FormatFloat(math.Float64frombits(le.Uint64(data)), 'g', -1, 64), nil
+	case typeBool:
+		return strconv.FormatBool(le.Uint32(data) != 0), nil
+	case typeBinary:
+		return strings.ToUpper(hex.EncodeToString(data)), nil
+	case typeGUID:
+		return formatGUID(data), nil
+	case typeSizeT, typeHexInt32, typeHexInt64, typeEvtHandle:
+		var v uint64
+		switch len(data) {
+		case 4:
+			v = uint64(le.Uint32(data))
+		case 8:
+			v = le.Uint64(data)
+		default:
+			return "", fmt.Errorf("invalid size %d for value type 0x%02x", len(data), typ)
+		}
+		if typ == typeSizeT {
+			return fmt.Sprintf("0x%0*x", 2*len(data), v), nil
+		}
+		return "0x" + strconv.FormatUint(v, 16), nil
+	case typeFileTime:
+		return filetimeToTime(le.Uint64(data)).Format("2006-01-02T15:04:05.0000000Z"), nil
+	case typeSystemTime:
+		return formatSystemTime(data), nil
+	case typeSID:
+		return formatSID(data)
+	default:
+		return "", fmt.Errorf("unsupported value type 0x%02x", typ)
+	}
+}
+
+// formatGUID renders a GUID in registry format.
+func formatGUID(b []byte) string {
+	le := binary.LittleEndian
+	return fmt.Sprintf("{%08x-%04x-%04x-%x-%x}",
+		le.Uint32(b), le.Uint16(b[4:]), le.Uint16(b[6:]), b[8:10], b[10:16])
+}
+
+// formatSystemTime renders a SYSTEMTIME structure.
+func formatSystemTime(b []byte) string {
+	le := binary.LittleEndian
+	field := func(i int) int { return int(le.Uint16(b[2*i:])) }
+	// Fields are year, month, day of week, day, hour, minute, second and
+	// millisecond.
+	t := time.Date(field(0), time.Month(field(1)), field(3), field(4), field(5), field(6), field(7)*int(time.Millisecond), time.UTC)
+	return t.Format("2006-01-02T15:04:05.000Z")
+}
+
+// formatSID renders a security identifier in its S-R-I-S-S... form.
+func formatSID(b []byte) (string, error) {
+	if len(b) < 8 {
+		return "", fmt.Errorf("invalid SID length %d", len(b))
+	}
+	count := int(b[1])
+	if len(b) < 8+4*count {
+		return "", fmt.Errorf("invalid SID length %d for %d sub-authorities", len(b), count)
+	}
+	var authority uint64
+	for _, v := range b[2:8] {
+		authority = authority<<8 | uint64(v)
+	}
+	var sb strings.Builder
+	sb.WriteString("S-" + strconv.Itoa(int(b[0])) + "-")
+	if authority >= 1<<32 {
+		fmt.Fprintf(&sb, "0x%012X", authority)
+	} else {
+		sb.WriteString(strconv.FormatUint(authority, 10))
+	}
+	for i := range count {
+		sb.WriteString("-" + strconv.FormatUint(uint64(binary.LittleEndian.Uint32(b[8+4*i:])), 10))
+	}
+	return sb.String(), nil
+}
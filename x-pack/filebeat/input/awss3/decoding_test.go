@@ -124,6 +124,36 @@ func TestDecoding(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "avro",
+			file:          "events.avro",
+			numEvents:     6,
+			assertAgainst: "events-avro.json",
+			config: &readerConfig{
+				Decoding: decoder.Config{
+					Codec: &decoder.CodecConfig{
+						Avro: &decoder.AvroCodecConfig{
+							Enabled: true,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:          "orc",
+			file:          "events.orc",
+			numEvents:     6,
+			assertAgainst: "events-orc.json",
+			config: &readerConfig{
+				Decoding: decoder.Config{
+					Codec: &decoder.CodecConfig{
+						ORC: &decoder.ORCCodecConfig{
+							Enabled: true,
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -90,6 +90,32 @@ func TestDecoding(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "avro",
+			file:          "events.avro",
+			numEvents:     6,
+			assertAgainst: "events-avro.json",
+			config: decoder.Config{
+				Codec: &decoder.CodecConfig{
+					Avro: &decoder.AvroCodecConfig{
+						Enabled: true,
+					},
+				},
+			},
+		},
+		{
+			name:          "orc",
+			file:          "events.orc",
+			numEvents:     6,
+			assertAgainst: "events-orc.json",
+			config: decoder.Config{
+				Codec: &decoder.CodecConfig{
+					ORC: &decoder.ORCCodecConfig{
+						Enabled: true,
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -133,6 +159,47 @@ func TestDecoding(t *testing.T) {
 	}
 }
 
+func TestDecodingResume(t *testing.T) {
+	log := logptest.NewTestingLogger(t, "")
+
+	f, err := os.Open(filepath.Join(testDataPath, "events.orc"))
+	if err != nil {
+		t.Fatalf("failed to open test data: %v", err)
+	}
+	defer f.Close()
+
+	// The test file has 3 stripes of 2 rows, the first one was published.
+	modified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	st := newState()
+	st.cp.PartialJobs["test_blob"] = PartialJob{Offset: 1, Updated: modified}
+	p := &pub{t: t}
+	item := &azcontainer.BlobItem{
+		Name: new("test_blob"),
+		Properties: &azcontainer.BlobProperties{
+			ContentType:  new("application/octet-stream"),
+			LastModified: &modified,
+		},
+	}
+	j := newJob(&blob.Client{}, item, "https://foo.blob.core.windows.net/", st, &Source{}, 0, p, noopReporter{}, nil, log)
+	j.src.ReaderConfig.Decoding = decoder.Config{Codec: &decoder.CodecConfig{ORC: &decoder.ORCCodecConfig{Enabled: true}}}
+	err = j.decode(context.Background(), f, "test")
+	if err != nil {
+		t.Fatalf("unexpected error calling decode: %v", err)
+	}
+
+	targetData := readJSONFromFile(t, filepath.Join(testDataPath, "events-orc.json"))
+	assert.Equal(t, len(targetData)-2, len(p.events))
+	for i, event := range p.events {
+		msg, err := event.Fields.GetValue("message")
+		assert.NoError(t, err)
+		assert.JSONEq(t, targetData[i+2], msg.(string))
+		offset, err := event.Fields.GetValue("log.offset")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(i+2), offset)
+	}
+	assert.Empty(t, st.cp.PartialJobs)
+}
+
 type pub struct {
 	t      *testing.T
 	events []beat.Event
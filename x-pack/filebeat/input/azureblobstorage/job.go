@@ -204,6 +204,10 @@ func (j *job) decode(ctx context.Context, r io.Reader, id string) error {
 	case decoder.ValueDecoder:
 		defer dec.Close()
 
+		if err := j.resume(dec); err != nil {
+			j.status.UpdateStatus(status.Degraded, err.Error())
+			return err
+		}
 		for dec.Next() {
 			offset, msg, _, err := dec.DecodeValue()
 			if err != nil {
@@ -214,7 +218,7 @@ func (j *job) decode(ctx context.Context, r io.Reader, id string) error {
 				return err
 			}
 			evt := j.createEvent(string(msg), offset)
-			j.publish(evt, !dec.More(), id)
+			j.publishRecord(evt, dec, offset, id)
 		}
 
 	case decoder.Decoder:
@@ -294,6 +298,45 @@ func (j *job) readJsonAndPublish(ctx context.Context, r io.Reader, id string) er
 	return nil
 }
 
+// resume skips the records of a partly processed blob that were already
+// published, if its decoder supports it and the blob wasn't modified since.
+func (j *job) resume(dec decoder.Decoder) error {
+	r, ok := dec.(decoder.Resumer)
+	if !ok {
+		return nil
+	}
+	p, ok := j.state.partialJob(*j.blob.Name)
+	if !ok || !p.Updated.Equal(*j.blob.Properties.LastModified) {
+		return nil
+	}
+	if err := r.Resume(p.Offset); err != nil {
+		return fmt.Errorf("failed to resume decoding blob: %s, with error: %w", *j.blob.Name, err)
+	}
+	j.log.Debugw("resuming partly processed blob", "blobName", *j.blob.Name, "offset", p.Offset)
+	return nil
+}
+
+// publishRecord publishes the event of a decoded record. If the record is
+// the last one of a block but not of the blob, its offset is saved so that
+// decoding can be resumed after it.
+func (j *job) publishRecord(evt beat.Event, dec decoder.Decoder, offset int64, id string) {
+	last := !dec.More()
+	if r, ok := dec.(decoder.Resumer); !ok || last || !r.EndOfBlock() {
+		j.publish(evt, last, id)
+		return
+	}
+	cp, done := j.state.savePartialForTx(*j.blob.Name, offset, *j.blob.Properties.LastModified)
+	err := j.publisher.Publish(evt, cp)
+	if err != nil {
+		j.metrics.errorsTotal.Inc()
+		j.status.UpdateStatus(status.Degraded, "failed to publish event: "+err.Error())
+		j.log.Errorf(jobErrString, id, err)
+	} else {
+		j.status.UpdateStatus(status.Running, "")
+	}
+	done()
+}
+
 func (j *job) publish(evt beat.Event, last bool, id string) {
 	if last {
 		// if this is the last object, then perform a complete state save
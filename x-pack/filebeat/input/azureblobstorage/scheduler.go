@@ -293,6 +293,10 @@ func (s *scheduler) fetchBlobPager(batchSize int32) *azruntime.Pager[azblob.List
 func (s *scheduler) moveToLastSeenJob(jobs []*job) []*job {
 	cp := s.state.checkpoint()
 	jobs = slices.DeleteFunc(jobs, func(j *job) bool {
+		if _, ok := s.state.partialJob(j.name()); ok {
+			// partly processed jobs are resumed
+			return false
+		}
 		return !(j.timestamp().After(cp.LatestEntryTime) || j.name() > cp.BlobName)
 	})
 
@@ -25,11 +25,25 @@ type Checkpoint struct {
 	BlobName string
 	// timestamp to denote which is the latest blob
 	LatestEntryTime time.Time
+	// list of partly processed blobs, whose decoding can be resumed after
+	// the last published block
+	PartialJobs map[string]PartialJob
+}
+
+// PartialJob is the position of the last record published from a blob at the
+// end of a block, when it wasn't fully processed yet.
+type PartialJob struct {
+	// offset of the last published record
+	Offset int64
+	// timestamp of the blob, the position is only valid if it wasn't modified
+	Updated time.Time
 }
 
 func newState() *state {
 	return &state{
-		cp: &Checkpoint{},
+		cp: &Checkpoint{
+			PartialJobs: make(map[string]PartialJob),
+		},
 	}
 }
 
@@ -39,6 +53,8 @@ func newState() *state {
 // more than once.
 func (s *state) saveForTx(name string, lastModifiedOn time.Time) (cp *Checkpoint, done func()) {
 	s.mu.Lock()
+	// the blob is fully processed
+	delete(s.cp.PartialJobs, name)
 	if len(s.cp.BlobName) == 0 {
 		s.cp.BlobName = name
 	} else if strings.ToLower(name) > strings.ToLower(s.cp.BlobName) {
@@ -52,8 +68,28 @@ func (s *state) saveForTx(name string, lastModifiedOn time.Time) (cp *Checkpoint
 	return s.cp, func() { s.mu.Unlock() }
 }
 
+// savePartialForTx records the offset of the last record of a block of a
+// blob, locks the state and returns the checkpoint with an unlock function,
+// done, like saveForTx.
+func (s *state) savePartialForTx(name string, offset int64, lastModifiedOn time.Time) (cp *Checkpoint, done func()) {
+	s.mu.Lock()
+	s.cp.PartialJobs[name] = PartialJob{Offset: offset, Updated: lastModifiedOn}
+	return s.cp, func() { s.mu.Unlock() }
+}
+
+// partialJob returns the position of a partly processed blob, if any.
+func (s *state) partialJob(name string) (PartialJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.cp.PartialJobs[name]
+	return p, ok
+}
+
 // setCheckpoint sets checkpoint from source to current state instance
 func (s *state) setCheckpoint(chkpt *Checkpoint) {
+	if chkpt.PartialJobs == nil {
+		chkpt.PartialJobs = make(map[string]PartialJob)
+	}
 	s.cp = chkpt
 }
 
@@ -8,10 +8,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"maps"
 	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/storage"
 	"github.com/google/go-cmp/cmp"
@@ -72,6 +74,32 @@ func TestDecoding(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "avro",
+			file:          "events.avro",
+			numEvents:     6,
+			assertAgainst: "events-avro.json",
+			config: decoder.Config{
+				Codec: &decoder.CodecConfig{
+					Avro: &decoder.AvroCodecConfig{
+						Enabled: true,
+					},
+				},
+			},
+		},
+		{
+			name:          "orc",
+			file:          "events.orc",
+			numEvents:     6,
+			assertAgainst: "events-orc.json",
+			config: decoder.Config{
+				Codec: &decoder.CodecConfig{
+					ORC: &decoder.ORCCodecConfig{
+						Enabled: true,
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -108,14 +136,108 @@ func TestDecoding(t *testing.T) {
 	}
 }
 
+func TestDecodingResume(t *testing.T) {
+	logp.TestingSetup()
+	log := logp.L()
+
+	updated := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, codec := range []string{"avro", "orc"} {
+		t.Run(codec, func(t *testing.T) {
+			config := decoder.Config{Codec: &decoder.CodecConfig{}}
+			if codec == "avro" {
+				config.Codec.Avro = &decoder.AvroCodecConfig{Enabled: true}
+			} else {
+				config.Codec.ORC = &decoder.ORCCodecConfig{Enabled: true}
+			}
+			targetData := readJSONFromFile(t, filepath.Join(testDataPath, "events-"+codec+".json"))
+
+			// The test files have 3 blocks of 2 records.
+			tests := []struct {
+				name    string
+				partial *PartialJob
+				want    []string
+			}{
+				{
+					name: "new",
+					want: targetData,
+				},
+				{
+					name:    "partial",
+					partial: &PartialJob{Offset: 1, Updated: updated},
+					want:    targetData[2:],
+				},
+				{
+					name:    "updated",
+					partial: &PartialJob{Offset: 1, Updated: updated.Add(-time.Hour)},
+					want:    targetData,
+				},
+			}
+			for _, test := range tests {
+				t.Run(test.name, func(t *testing.T) {
+					f, err := os.Open(filepath.Join(testDataPath, "events."+codec))
+					if err != nil {
+						t.Fatalf("failed to open test data: %v", err)
+					}
+					defer f.Close()
+
+					st := newState()
+					if test.partial != nil {
+						st.cp.PartialJobs["test_object"] = *test.partial
+					}
+					p := &pub{t: t}
+					j := newJob(&storage.BucketHandle{}, &storage.ObjectAttrs{Name: "test_object", Updated: updated}, "gs://test_uri", st, &Source{}, p, noopReporter{}, nil, log, false)
+					j.src.ReaderConfig.Decoding = config
+					err = j.decode(context.Background(), f, "test")
+					if err != nil {
+						t.Fatalf("unexpected error calling decode: %v", err)
+					}
+
+					assert.Equal(t, len(test.want), len(p.events))
+					for i, event := range p.events {
+						msg, err := event.Fields.GetValue("message")
+						assert.NoError(t, err)
+						assert.JSONEq(t, test.want[i], msg.(string))
+					}
+
+					// The end of each block but the last is saved, and the
+					// last event completes the object.
+					var offsets []int64
+					for i, cp := range p.cursors {
+						if cp != nil {
+							if i == len(p.cursors)-1 {
+								assert.Empty(t, cp.PartialJobs)
+							} else {
+								offsets = append(offsets, cp.PartialJobs["test_object"].Offset)
+							}
+						}
+					}
+					want := []int64{1, 3}
+					if test.partial != nil && test.partial.Updated.Equal(updated) {
+						want = []int64{3}
+					}
+					assert.Equal(t, want, offsets)
+				})
+			}
+		})
+	}
+}
+
 type pub struct {
-	t      *testing.T
-	events []beat.Event
+	t       *testing.T
+	events  []beat.Event
+	cursors []*Checkpoint
 }
 
-func (p *pub) Publish(e beat.Event, _cursor any) error {
+func (p *pub) Publish(e beat.Event, cursor any) error {
 	p.t.Logf("%v\n", e.Fields)
 	p.events = append(p.events, e)
+	// The checkpoint is shared by all events, copy the partly processed jobs
+	// at the time the event is published.
+	var cp *Checkpoint
+	if c, ok := cursor.(*Checkpoint); ok && c != nil {
+		cp = &Checkpoint{PartialJobs: maps.Clone(c.PartialJobs)}
+	}
+	p.cursors = append(p.cursors, cp)
 	return nil
 }
 
@@ -198,6 +320,31 @@ codec:
 `,
 		wantErr: errors.New(`single character option given more than one character: "this is too long" accessing 'codec.csv.comma'`),
 	},
+	{
+		name: "avro",
+		yaml: `
+codec:
+  avro:
+    enabled: true
+`,
+		want: decoder.Config{
+			Codec: &decoder.CodecConfig{
+				Avro: &decoder.AvroCodecConfig{
+					Enabled: true,
+				},
+			}},
+	},
+	{
+		name: "more_than_one_codec",
+		yaml: `
+codec:
+  avro:
+    enabled: true
+  orc:
+    enabled: true
+`,
+		wantErr: errors.New(`more than one decoder configured accessing 'codec'`),
+	},
 }
 
 func TestCodecConfig(t *testing.T) {
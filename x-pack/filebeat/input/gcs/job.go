@@ -196,32 +196,37 @@ func (j *job) decode(ctx context.Context, r io.Reader, id string) error {
 	case decoder.ValueDecoder:
 		defer dec.Close()
 
+		if err := j.resume(dec); err != nil {
+			j.status.UpdateStatus(status.Degraded, err.Error())
+			return err
+		}
+		_, resumable := dec.(decoder.Resumer)
 		for dec.Next() {
 			var (
-				msg []byte
-				val []mapstr.M
+				offset int64
+				msg    []byte
+				val    []mapstr.M
 			)
-			if j.src.ParseJSON {
+			switch {
+			case j.src.ParseJSON:
 				var v mapstr.M
-				_, msg, v, err = dec.DecodeValue()
-				if err != nil {
-					if err == io.EOF {
-						return nil
-					}
-					break
-				}
+				offset, msg, v, err = dec.DecodeValue()
 				val = []mapstr.M{v}
-			} else {
+			case resumable:
+				// The offset of the record is needed to resume decoding,
+				// resumable decoders return the same message as Decode.
+				offset, msg, _, err = dec.DecodeValue()
+			default:
 				msg, err = dec.Decode()
-				if err != nil {
-					if err == io.EOF {
-						return nil
-					}
-					break
+			}
+			if err != nil {
+				if err == io.EOF {
+					return nil
 				}
+				break
 			}
 			evt := j.createEvent(msg, val, evtOffset)
-			j.publish(evt, !dec.More(), id)
+			j.publishRecord(evt, dec, offset, id)
 		}
 
 	case decoder.Decoder:
@@ -316,6 +321,45 @@ func (j *job) readJsonAndPublish(ctx context.Context, r io.Reader, id string) er
 	return nil
 }
 
+// resume skips the records of a partly processed object that were already
+// published, if its decoder supports it and the object wasn't updated since.
+func (j *job) resume(dec decoder.Decoder) error {
+	r, ok := dec.(decoder.Resumer)
+	if !ok {
+		return nil
+	}
+	p, ok := j.state.partialJob(j.object.Name)
+	if !ok || !p.Updated.Equal(j.object.Updated) {
+		return nil
+	}
+	if err := r.Resume(p.Offset); err != nil {
+		return fmt.Errorf("failed to resume decoding object: %s, with error: %w", j.object.Name, err)
+	}
+	j.log.Debugw("resuming partly processed object", "objectName", j.object.Name, "offset", p.Offset)
+	return nil
+}
+
+// publishRecord publishes the event of a decoded record. If the record is
+// the last one of a block but not of the object, its offset is saved so that
+// decoding can be resumed after it.
+func (j *job) publishRecord(evt beat.Event, dec decoder.Decoder, offset int64, id string) {
+	last := !dec.More()
+	if r, ok := dec.(decoder.Resumer); !ok || last || !r.EndOfBlock() {
+		j.publish(evt, last, id)
+		return
+	}
+	cp, done := j.state.savePartialForTx(j.object.Name, offset, j.object.Updated)
+	err := j.publisher.Publish(evt, cp)
+	if err != nil {
+		j.metrics.errorsTotal.Inc()
+		j.status.UpdateStatus(status.Degraded, "failed to publish event: "+err.Error())
+		j.log.Errorw("job encountered an error while publishing event", "gcs.jobId", id, "error", err)
+	} else {
+		j.status.UpdateStatus(status.Running, "")
+	}
+	done()
+}
+
 func (j *job) publish(evt beat.Event, last bool, id string) {
 	if last {
 		// if this is the last object, then perform a complete state save
@@ -216,6 +216,10 @@ func (s *scheduler) fetchObjectPager(ctx context.Context, pageSize int) *iterato
 func (s *scheduler) moveToLastSeenJob(jobs []*job) []*job {
 	cp := s.state.checkpoint()
 	jobs = slices.DeleteFunc(jobs, func(j *job) bool {
+		if _, ok := s.state.partialJob(j.Name()); ok {
+			// partly processed jobs are resumed
+			return false
+		}
 		return !(j.Timestamp().After(cp.LatestEntryTime) || j.Name() > cp.ObjectName)
 	})
 
@@ -30,12 +30,25 @@ type Checkpoint struct {
 	LatestEntryTime time.Time
 	// list of failed jobs due to unexpected errors/download errors
 	FailedJobs map[string]int
+	// list of partly processed jobs, whose decoding can be resumed after
+	// the last published block
+	PartialJobs map[string]PartialJob
+}
+
+// PartialJob is the position of the last record published from an object at
+// the end of a block, when it wasn't fully processed yet.
+type PartialJob struct {
+	// offset of the last published record
+	Offset int64
+	// timestamp of the object, the position is only valid if it wasn't updated
+	Updated time.Time
 }
 
 func newState() *state {
 	return &state{
 		cp: &Checkpoint{
-			FailedJobs: make(map[string]int),
+			FailedJobs:  make(map[string]int),
+			PartialJobs: make(map[string]PartialJob),
 		},
 	}
 }
@@ -46,6 +59,8 @@ func newState() *state {
 // more than once.
 func (s *state) saveForTx(name string, lastModifiedOn time.Time, metrics *inputMetrics) (cp *Checkpoint, done func()) {
 	s.mu.Lock()
+	// the object is fully processed
+	delete(s.cp.PartialJobs, name)
 	if _, ok := s.cp.FailedJobs[name]; !ok {
 		if len(s.cp.ObjectName) == 0 {
 			s.cp.ObjectName = name
@@ -66,6 +81,23 @@ func (s *state) saveForTx(name string, lastModifiedOn time.Time, metrics *inputM
 	return s.cp, func() { s.mu.Unlock() }
 }
 
+// savePartialForTx records the offset of the last record of a block of an
+// object, locks the state and returns the checkpoint with an unlock function,
+// done, like saveForTx.
+func (s *state) savePartialForTx(name string, offset int64, lastModifiedOn time.Time) (cp *Checkpoint, done func()) {
+	s.mu.Lock()
+	s.cp.PartialJobs[name] = PartialJob{Offset: offset, Updated: lastModifiedOn}
+	return s.cp, func() { s.mu.Unlock() }
+}
+
+// partialJob returns the position of a partly processed object, if any.
+func (s *state) partialJob(name string) (PartialJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.cp.PartialJobs[name]
+	return p, ok
+}
+
 // updateFailedJobs, adds a job name to a failedJobs map, which helps
 // in keeping track of failed jobs during edge cases when the state might
 // move ahead in timestamp & objectName due to successful operations from other workers.
@@ -104,6 +136,9 @@ func (s *state) setCheckpoint(chkpt *Checkpoint) {
 	if chkpt.FailedJobs == nil {
 		chkpt.FailedJobs = make(map[string]int)
 	}
+	if chkpt.PartialJobs == nil {
+		chkpt.PartialJobs = make(map[string]PartialJob)
+	}
 	s.cp = chkpt
 }
 
@@ -0,0 +1,174 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package lumberjack
+
+import (
+	"context"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/elastic/go-lumber/lj"
+)
+
+// clientID returns the identifier of the client that sent the batch, used to
+// apply the client quotas.
+func clientID(c quotaConfig, batch *lj.Batch) string {
+	if c.ClientID == clientIDTLSCommonName && batch.TLS != nil && len(batch.TLS.PeerCertificates) > 0 {
+		if cn := batch.TLS.PeerCertificates[0].Subject.CommonName; cn != "" {
+			return cn
+		}
+	}
+	host, _, err := net.SplitHostPort(batch.RemoteAddr)
+	if err != nil {
+		return batch.RemoteAddr
+	}
+	return host
+}
+
+// clientQueue publishes the batches of a single client in the order they are
+// received, waiting for the client quotas before publishing each batch.
+// Batches are not ACKed before they are published, so a throttled client
+// stops sending batches once its window of unACKed batches is full.
+type clientQueue struct {
+	limiter     *rate.Limiter // nil if the rate is not limited.
+	maxInFlight int           // 0 if the in-flight events are not limited.
+	metrics     *clientMetrics
+
+	mutex    sync.Mutex // mutex synchronizes access to queue and inFlight.
+	queue    []*lj.Batch
+	inFlight int
+
+	received chan struct{} // Signaled when a batch is queued.
+	released chan struct{} // Signaled when in-flight events are ACKed.
+}
+
+func newClientQueue(c quotaConfig, metrics *clientMetrics) *clientQueue {
+	cl := &clientQueue{
+		maxInFlight: c.MaxInFlightEvents,
+		metrics:     metrics,
+		received:    make(chan struct{}, 1),
+		released:    make(chan struct{}, 1),
+	}
+	if c.EventsPerSecond > 0 {
+		burst := c.Burst
+		if burst == 0 {
+			burst = int(math.Ceil(c.EventsPerSecond))
+		}
+		cl.limiter = rate.NewLimiter(rate.Limit(c.EventsPerSecond), burst)
+	}
+	return cl
+}
+
+// enqueue adds a batch to the queue of the client.
+func (c *clientQueue) enqueue(batch *lj.Batch) {
+	c.mutex.Lock()
+	c.queue = append(c.queue, batch)
+	c.mutex.Unlock()
+	signal(c.received)
+}
+
+// next returns the next batch of the client. It returns false if the context
+// is done or no batch was received within idleTimeout.
+func (c *clientQueue) next(ctx context.Context, idleTimeout time.Duration) (*lj.Batch, bool) {
+	timer := time.NewTimer(idleTimeout)
+	defer timer.Stop()
+	for {
+		c.mutex.Lock()
+		if len(c.queue) > 0 {
+			batch := c.queue[0]
+			c.queue[0] = nil
+			c.queue = c.queue[1:]
+			c.mutex.Unlock()
+			return batch, true
+		}
+		c.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-timer.C:
+			return nil, false
+		case <-c.received:
+		}
+	}
+}
+
+// idle returns true if the client has no queued batch and no event pending
+// an ACK.
+func (c *clientQueue) idle() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.queue) == 0 && c.inFlight == 0
+}
+
+// acquire waits until n events of the client can be published without
+// exceeding the client quotas. A batch larger than the in-flight window is
+// published once all the previous events of the client are ACKed.
+func (c *clientQueue) acquire(ctx context.Context, n int) error {
+	start := time.Now()
+	throttled := false
+
+	for {
+		c.mutex.Lock()
+		if c.maxInFlight == 0 || c.inFlight == 0 || c.inFlight+n <= c.maxInFlight {
+			c.inFlight += n
+			c.mutex.Unlock()
+			break
+		}
+		c.mutex.Unlock()
+
+		throttled = true
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.released:
+		}
+	}
+	c.metrics.messagesInFlight.Add(uint64(n))
+
+	if c.limiter != nil {
+		// WaitN fails for more than burst events, so large batches wait for
+		// the tokens in chunks.
+		if c.limiter.TokensAt(start) < float64(n) {
+			throttled = true
+		}
+		for remaining := n; remaining > 0; {
+			chunk := min(remaining, c.limiter.Burst())
+			if err := c.limiter.WaitN(ctx, chunk); err != nil {
+				c.release(n)
+				return err
+			}
+			remaining -= chunk
+		}
+	}
+
+	if throttled {
+		c.metrics.batchesThrottledTotal.Inc()
+		c.metrics.throttledNanosecondsTotal.Add(uint64(time.Since(start).Nanoseconds())) //nolint:gosec // durations are positive
+	}
+	return nil
+}
+
+// release marks n in-flight events of the client as ACKed.
+func (c *clientQueue) release(n int) {
+	c.mutex.Lock()
+	c.inFlight -= n
+	c.mutex.Unlock()
+	c.metrics.messagesInFlight.Sub(uint64(n))
+	signal(c.released)
+}
+
+// signal notifies ch without blocking, a pending notification is enough for
+// the receiver to check the state again.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
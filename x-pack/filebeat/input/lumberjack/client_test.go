@@ -0,0 +1,225 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package lumberjack
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+	"github.com/elastic/go-lumber/lj"
+)
+
+func TestClientID(t *testing.T) {
+	tlsState := &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "relay.example.com"}}},
+	}
+
+	testCases := []struct {
+		name     string
+		clientID string
+		batch    *lj.Batch
+		want     string
+	}{
+		{
+			"source_ip",
+			clientIDSourceIP,
+			lj.NewBatchWithSourceMetadata(nil, "192.0.2.1:5000", tlsState),
+			"192.0.2.1",
+		},
+		{
+			"source_ip ipv6",
+			clientIDSourceIP,
+			lj.NewBatchWithSourceMetadata(nil, "[2001:db8::1]:5000", nil),
+			"2001:db8::1",
+		},
+		{
+			"tls_common_name",
+			clientIDTLSCommonName,
+			lj.NewBatchWithSourceMetadata(nil, "192.0.2.1:5000", tlsState),
+			"relay.example.com",
+		},
+		{
+			"tls_common_name without tls",
+			clientIDTLSCommonName,
+			lj.NewBatchWithSourceMetadata(nil, "192.0.2.1:5000", nil),
+			"192.0.2.1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, clientID(quotaConfig{ClientID: tc.clientID}, tc.batch))
+		})
+	}
+}
+
+func TestClientQueueAcquire(t *testing.T) {
+	newTestClientQueue := func(c quotaConfig) *clientQueue {
+		metrics := newInputMetrics(monitoring.NewRegistry(), logp.NewNopLogger())
+		return newClientQueue(c, metrics.newClientMetrics("test"))
+	}
+
+	t.Run("in-flight window", func(t *testing.T) {
+		c := newTestClientQueue(quotaConfig{MaxInFlightEvents: 3})
+
+		require.NoError(t, c.acquire(t.Context(), 2))
+		assert.Equal(t, uint64(2), c.metrics.messagesInFlight.Get())
+
+		acquired := make(chan error)
+		go func() { acquired <- c.acquire(t.Context(), 2) }()
+		select {
+		case <-acquired:
+			t.Fatal("events acquired while the in-flight window is full")
+		case <-time.After(50 * time.Millisecond):
+		}
+		c.release(2)
+		require.NoError(t, <-acquired)
+		assert.Equal(t, uint64(1), c.metrics.batchesThrottledTotal.Get())
+	})
+
+	t.Run("batch larger than the in-flight window", func(t *testing.T) {
+		c := newTestClientQueue(quotaConfig{MaxInFlightEvents: 3})
+
+		require.NoError(t, c.acquire(t.Context(), 10))
+		c.release(10)
+		assert.True(t, c.idle())
+		assert.Zero(t, c.metrics.batchesThrottledTotal.Get())
+	})
+
+	t.Run("rate", func(t *testing.T) {
+		c := newTestClientQueue(quotaConfig{EventsPerSecond: 100, Burst: 10})
+
+		start := time.Now()
+		// The burst is available immediately, the other 10 events take
+		// 100ms.
+		require.NoError(t, c.acquire(t.Context(), 20))
+		assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+		assert.Equal(t, uint64(1), c.metrics.batchesThrottledTotal.Get())
+		assert.NotZero(t, c.metrics.throttledNanosecondsTotal.Get())
+	})
+}
+
+func TestServerClientQuotas(t *testing.T) {
+	var c config
+	c.InitDefaults()
+	c.ClientQuotas.MaxInFlightEvents = 2
+
+	reg := monitoring.NewRegistry()
+	published := make(chan beat.Event, 10)
+	s := &server{
+		config:  c,
+		log:     logp.NewNopLogger(),
+		publish: func(e beat.Event) { published <- e },
+		metrics: newInputMetrics(reg, logp.NewNopLogger()),
+		clients: map[string]*clientQueue{},
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var wg sync.WaitGroup
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+
+	noisy1 := lj.NewBatchWithSourceMetadata([]any{"a", "b"}, "192.0.2.1:5000", nil)
+	noisy2 := lj.NewBatchWithSourceMetadata([]any{"c"}, "192.0.2.1:5001", nil)
+	other := lj.NewBatchWithSourceMetadata([]any{"d"}, "192.0.2.2:5000", nil)
+	s.dispatchBatch(ctx, &wg, noisy1)
+	s.dispatchBatch(ctx, &wg, noisy2)
+	s.dispatchBatch(ctx, &wg, other)
+
+	// The second batch of the noisy client waits for the first one to be
+	// ACKed, the other client is not affected.
+	events := map[string]beat.Event{}
+	for range 3 {
+		e := receiveEvent(t, published)
+		events[e.Fields["lumberjack"].(string)] = e
+	}
+	require.Contains(t, events, "a")
+	require.Contains(t, events, "b")
+	require.Contains(t, events, "d")
+	events["d"].Private.(*batchACKTracker).ACK()
+	require.Eventually(t, func() bool { return isACKed(other) }, time.Second, time.Millisecond)
+	select {
+	case e := <-published:
+		t.Fatalf("event %v published before the in-flight events of the client were ACKed", e.Fields)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	events["a"].Private.(*batchACKTracker).ACK()
+	events["b"].Private.(*batchACKTracker).ACK()
+	assert.Equal(t, "c", receiveEvent(t, published).Fields["lumberjack"])
+	require.True(t, isACKed(noisy1))
+
+	snapshot := monitoring.CollectStructSnapshot(reg, monitoring.Full, false)
+	assert.Equal(t, int64(2), snapshot["clients_active"])
+	clients, ok := snapshot["clients"].(map[string]any)
+	require.True(t, ok)
+	noisy, ok := clients["192_0_2_1"].(map[string]any)
+	require.True(t, ok, "missing metrics of the noisy client: %v", clients)
+	assert.Equal(t, "192.0.2.1", noisy["client"])
+	assert.Equal(t, int64(2), noisy["batches_received_total"])
+	assert.Equal(t, int64(3), noisy["messages_received_total"])
+	assert.Equal(t, int64(1), noisy["batches_acked_total"])
+	assert.Equal(t, int64(1), noisy["messages_in_flight"])
+	assert.Equal(t, int64(1), noisy["batches_throttled_total"])
+}
+
+func TestServerRemovesIdleClients(t *testing.T) {
+	var c config
+	c.InitDefaults()
+	c.ClientQuotas.IdleTimeout = 10 * time.Millisecond
+
+	reg := monitoring.NewRegistry()
+	s := &server{
+		config:  c,
+		log:     logp.NewNopLogger(),
+		publish: func(e beat.Event) { e.Private.(*batchACKTracker).ACK() },
+		metrics: newInputMetrics(reg, logp.NewNopLogger()),
+		clients: map[string]*clientQueue{},
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var wg sync.WaitGroup
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+
+	batch := lj.NewBatchWithSourceMetadata([]any{"a"}, "192.0.2.1:5000", nil)
+	s.dispatchBatch(ctx, &wg, batch)
+	require.Eventually(t, func() bool { return isACKed(batch) }, time.Second, time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		s.clientsMutex.Lock()
+		defer s.clientsMutex.Unlock()
+		return len(s.clients) == 0
+	}, time.Second, time.Millisecond)
+	assert.Zero(t, s.metrics.clientsActive.Get())
+	assert.Nil(t, s.metrics.clients.GetRegistry("192_0_2_1"))
+}
+
+func receiveEvent(t *testing.T, ch <-chan beat.Event) beat.Event {
+	t.Helper()
+
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(testTimeout):
+		t.Fatal("timeout waiting for event")
+		return beat.Event{}
+	}
+}
@@ -19,11 +19,31 @@ type config struct {
 	Keepalive      time.Duration           `config:"keepalive"       validate:"min=0"`  // Keepalive interval for notifying clients that batches that are not yet ACKed.
 	Timeout        time.Duration           `config:"timeout"         validate:"min=0"`  // Read / write timeouts for Lumberjack server.
 	MaxConnections int                     `config:"max_connections" validate:"min=0"`  // Maximum number of concurrent connections. Default is 0 which means no limit.
+	ClientQuotas   quotaConfig             `config:"client_quotas"`                     // Quotas applied to each client.
+}
+
+// Client identifiers used to apply the client quotas.
+const (
+	clientIDSourceIP      = "source_ip"       // IP address of the connection.
+	clientIDTLSCommonName = "tls_common_name" // Common name of the TLS client certificate, or the source IP if there is none.
+)
+
+// quotaConfig limits the events accepted from each client. The limits are
+// enforced by delaying the ACK of the client's batches, so that a noisy
+// client is throttled without affecting the others. Zero means no limit.
+type quotaConfig struct {
+	ClientID          string        `config:"client_id"`                                // How clients are identified, source_ip or tls_common_name.
+	EventsPerSecond   float64       `config:"events_per_second"    validate:"min=0"`    // Maximum rate of events published for a client.
+	Burst             int           `config:"burst"                validate:"min=0"`    // Maximum burst of events published for a client. Defaults to events_per_second.
+	MaxInFlightEvents int           `config:"max_in_flight_events" validate:"min=0"`    // Maximum number of events of a client that are published but not yet ACKed.
+	IdleTimeout       time.Duration `config:"idle_timeout"         validate:"positive"` // Time after which the state and metrics of an inactive client are removed.
 }
 
 func (c *config) InitDefaults() {
 	c.ListenAddress = "localhost:5044"
 	c.Versions = []string{"v1", "v2"}
+	c.ClientQuotas.ClientID = clientIDSourceIP
+	c.ClientQuotas.IdleTimeout = 5 * time.Minute
 }
 
 func (c *config) Validate() error {
@@ -35,5 +55,12 @@ func (c *config) Validate() error {
 		}
 	}
 
+	switch c.ClientQuotas.ClientID {
+	case clientIDSourceIP, clientIDTLSCommonName:
+	default:
+		return fmt.Errorf("invalid client_quotas.client_id %q: allowed values are %s and %s",
+			c.ClientQuotas.ClientID, clientIDSourceIP, clientIDTLSCommonName)
+	}
+
 	return nil
 }
@@ -6,6 +6,7 @@ package lumberjack
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -25,6 +26,10 @@ func TestConfig(t *testing.T) {
 			&config{
 				ListenAddress: "localhost:5044",
 				Versions:      []string{"v1", "v2"},
+				ClientQuotas: quotaConfig{
+					ClientID:    clientIDSourceIP,
+					IdleTimeout: 5 * time.Minute,
+				},
 			},
 			"",
 		},
@@ -52,6 +57,30 @@ func TestConfig(t *testing.T) {
 			nil,
 			`requires value >= 0 accessing 'max_connections'`,
 		},
+		{
+			"validate client_quotas.client_id",
+			map[string]any{
+				"client_quotas.client_id": "hostname",
+			},
+			nil,
+			`invalid client_quotas.client_id "hostname"`,
+		},
+		{
+			"validate client_quotas.events_per_second",
+			map[string]any{
+				"client_quotas.events_per_second": -1,
+			},
+			nil,
+			`requires value >= 0 accessing 'client_quotas.events_per_second'`,
+		},
+		{
+			"validate client_quotas.max_in_flight_events",
+			map[string]any{
+				"client_quotas.max_in_flight_events": -1,
+			},
+			nil,
+			`requires value >= 0 accessing 'client_quotas.max_in_flight_events'`,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -5,6 +5,8 @@
 package lumberjack
 
 import (
+	"strings"
+
 	"github.com/rcrowley/go-metrics"
 
 	"github.com/elastic/elastic-agent-libs/logp"
@@ -18,6 +20,8 @@ type inputMetrics struct {
 	batchesACKedTotal     *monitoring.Uint   // Number of Lumberjack batches ACKed.
 	messagesReceivedTotal *monitoring.Uint   // Number of Lumberjack messages received (not necessarily processed fully).
 	batchProcessingTime   metrics.Sample     // Histogram of the elapsed batch processing times in nanoseconds (time of receipt to time of ACK for non-empty batches).
+	clientsActive         *monitoring.Uint   // Number of clients with a state, see client_quotas.idle_timeout.
+	clients               *monitoring.Registry
 }
 
 func newInputMetrics(reg *monitoring.Registry, logger *logp.Logger) *inputMetrics {
@@ -27,9 +31,53 @@ func newInputMetrics(reg *monitoring.Registry, logger *logp.Logger) *inputMetric
 		batchesACKedTotal:     monitoring.NewUint(reg, "batches_acked_total"),
 		messagesReceivedTotal: monitoring.NewUint(reg, "messages_received_total"),
 		batchProcessingTime:   metrics.NewUniformSample(1024),
+		clientsActive:         monitoring.NewUint(reg, "clients_active"),
+		clients:               reg.GetOrCreateRegistry("clients"),
 	}
 	adapter.NewGoMetrics(reg, "batch_processing_time", logger, adapter.Accept).
 		Register("histogram", metrics.NewHistogram(out.batchProcessingTime)) //nolint:errcheck // A unique namespace is used so name collisions are impossible.
 
 	return out
 }
+
+// clientMetrics are the metrics of a single client, registered under the
+// "clients" registry of the input.
+type clientMetrics struct {
+	name                      string
+	client                    *monitoring.String // Identifier of the client, see client_quotas.client_id.
+	batchesReceivedTotal      *monitoring.Uint   // Number of Lumberjack batches received from the client.
+	batchesACKedTotal         *monitoring.Uint   // Number of Lumberjack batches of the client ACKed.
+	batchesThrottledTotal     *monitoring.Uint   // Number of Lumberjack batches of the client delayed by the client quotas.
+	messagesReceivedTotal     *monitoring.Uint   // Number of Lumberjack messages received from the client.
+	messagesInFlight          *monitoring.Uint   // Number of messages of the client published but not yet ACKed.
+	throttledNanosecondsTotal *monitoring.Uint   // Total time the batches of the client were delayed by the client quotas.
+}
+
+// clientMetricsName returns the name of the registry of the client metrics.
+// Dots would create nested registries.
+func clientMetricsName(id string) string {
+	return strings.ReplaceAll(id, ".", "_")
+}
+
+func (m *inputMetrics) newClientMetrics(id string) *clientMetrics {
+	name := clientMetricsName(id)
+	reg := m.clients.GetOrCreateRegistry(name)
+	m.clientsActive.Inc()
+	cm := &clientMetrics{
+		name:                      name,
+		client:                    monitoring.NewString(reg, "client"),
+		batchesReceivedTotal:      monitoring.NewUint(reg, "batches_received_total"),
+		batchesACKedTotal:         monitoring.NewUint(reg, "batches_acked_total"),
+		batchesThrottledTotal:     monitoring.NewUint(reg, "batches_throttled_total"),
+		messagesReceivedTotal:     monitoring.NewUint(reg, "messages_received_total"),
+		messagesInFlight:          monitoring.NewUint(reg, "messages_in_flight"),
+		throttledNanosecondsTotal: monitoring.NewUint(reg, "throttled_nanoseconds_total"),
+	}
+	cm.client.Set(id)
+	return cm
+}
+
+func (m *inputMetrics) removeClientMetrics(cm *clientMetrics) {
+	m.clients.Remove(cm.name)
+	m.clientsActive.Dec()
+}
@@ -5,6 +5,7 @@
 package lumberjack
 
 import (
+	"context"
 	"crypto/tls"
 	"net"
 	"strings"
@@ -31,6 +32,9 @@ type server struct {
 	ljSvr          lumber.Server
 	ljSvrCloseOnce sync.Once
 	bindAddress    string
+
+	clientsMutex sync.Mutex              // clientsMutex synchronizes access to clients.
+	clients      map[string]*clientQueue // Indexed by the name of the client metrics.
 }
 
 func newServer(c config, log *logp.Logger, pub func(beat.Event), stat status.StatusReporter, metrics *inputMetrics) (*server, error) {
@@ -62,6 +66,7 @@ func newServer(c config, log *logp.Logger, pub func(beat.Event), stat status.Sta
 		metrics:     metrics,
 		ljSvr:       ljSvr,
 		bindAddress: bindAddress,
+		clients:     map[string]*clientQueue{},
 	}, nil
 }
 
@@ -80,31 +85,103 @@ func (s *server) Close() error {
 }
 
 func (s *server) Run() error {
-	// Process batches until the input is stopped.
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	// Dispatch batches to their client until the input is stopped.
 	s.status.UpdateStatus(status.Running, "")
 	for batch := range s.ljSvr.ReceiveChan() {
-		s.processBatch(batch)
+		s.dispatchBatch(ctx, &wg, batch)
 	}
 	s.status.UpdateStatus(status.Stopped, "")
 	return nil
 }
 
-func (s *server) processBatch(batch *lj.Batch) {
+// dispatchBatch queues the batch for its client. The batches of each client
+// are published by their own goroutine, so that a client waiting for its
+// quotas does not delay the other clients.
+func (s *server) dispatchBatch(ctx context.Context, wg *sync.WaitGroup, batch *lj.Batch) {
 	s.metrics.batchesReceivedTotal.Inc()
 
+	id := clientID(s.config.ClientQuotas, batch)
+	s.clientsMutex.Lock()
+	defer s.clientsMutex.Unlock()
+
+	c, found := s.clients[clientMetricsName(id)]
+	if !found {
+		c = newClientQueue(s.config.ClientQuotas, s.metrics.newClientMetrics(id))
+		s.clients[c.metrics.name] = c
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runClient(ctx, c)
+		}()
+	}
+	c.metrics.batchesReceivedTotal.Inc()
+	c.enqueue(batch)
+}
+
+// runClient publishes the batches of the client until ctx is done. The client
+// is removed after being idle for client_quotas.idle_timeout.
+func (s *server) runClient(ctx context.Context, c *clientQueue) {
+	for {
+		batch, ok := c.next(ctx, s.config.ClientQuotas.IdleTimeout)
+		if !ok {
+			if ctx.Err() != nil || s.removeIdleClient(c) {
+				return
+			}
+			continue
+		}
+		if err := s.processBatch(ctx, c, batch); err != nil {
+			return
+		}
+	}
+}
+
+// removeIdleClient removes the client and its metrics if the client is
+// idle.
+func (s *server) removeIdleClient(c *clientQueue) bool {
+	s.clientsMutex.Lock()
+	defer s.clientsMutex.Unlock()
+
+	if !c.idle() {
+		return false
+	}
+	delete(s.clients, c.metrics.name)
+	s.metrics.removeClientMetrics(c.metrics)
+	return true
+}
+
+func (s *server) processBatch(ctx context.Context, c *clientQueue, batch *lj.Batch) error {
 	if len(batch.Events) == 0 {
 		batch.ACK()
 		s.metrics.batchesACKedTotal.Inc()
-		return
+		c.metrics.batchesACKedTotal.Inc()
+		return nil
+	}
+	n := len(batch.Events)
+	s.metrics.messagesReceivedTotal.Add(uint64(n))
+	c.metrics.messagesReceivedTotal.Add(uint64(n))
+
+	// Wait for the client quotas, the batch is not ACKed in the meantime.
+	// The wait is reported by the throttled_nanoseconds_total client metric,
+	// not by batch_processing_time.
+	if err := c.acquire(ctx, n); err != nil {
+		return err
 	}
-	s.metrics.messagesReceivedTotal.Add(uint64(len(batch.Events)))
 
 	// Track all the Beat events associated to the Lumberjack batch so that
 	// the batch can be ACKed after the Beat events are delivered successfully.
 	start := time.Now()
 	acker := newBatchACKTracker(func() {
 		batch.ACK()
+		c.release(n)
 		s.metrics.batchesACKedTotal.Inc()
+		c.metrics.batchesACKedTotal.Inc()
 		s.metrics.batchProcessingTime.Update(time.Since(start).Nanoseconds())
 	})
 
@@ -116,6 +193,7 @@ func (s *server) processBatch(batch *lj.Batch) {
 	// Mark the batch as "ready" after Beat events are generated for each
 	// Lumberjack event.
 	acker.Ready()
+	return nil
 }
 
 func makeEvent(remoteAddr string, tlsState *tls.ConnectionState, lumberjackEvent any, acker *batchACKTracker) beat.Event {
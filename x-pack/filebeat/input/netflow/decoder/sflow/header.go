@@ -0,0 +1,138 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sflow
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/record"
+)
+
+// Header protocols of sampled headers.
+const (
+	headerProtocolEthernet = 1
+	headerProtocolIPv4     = 11
+	headerProtocolIPv6     = 12
+)
+
+const (
+	etherTypeIPv4  = 0x0800
+	etherTypeIPv6  = 0x86dd
+	etherTypeVLAN  = 0x8100
+	etherTypeQinQ  = 0x88a8
+	ipProtoICMP    = 1
+	ipProtoTCP     = 6
+	ipProtoUDP     = 17
+	ipProtoICMPv6  = 58
+	ipProtoSCTP    = 132
+	ethernetHeader = 14
+	ipv4Header     = 20
+	ipv6Header     = 40
+)
+
+// decodeHeader decodes the layer 2 to 4 fields of a sampled packet header.
+// Headers are truncated by the agent, so the fields are decoded as far as
+// the header goes.
+func decodeHeader(protocol uint32, header []byte, fields record.Map) {
+	switch protocol {
+	case headerProtocolEthernet:
+		decodeEthernet(header, fields)
+	case headerProtocolIPv4:
+		decodeIPv4(header, fields)
+	case headerProtocolIPv6:
+		decodeIPv6(header, fields)
+	}
+}
+
+func decodeEthernet(b []byte, fields record.Map) {
+	if len(b) < ethernetHeader {
+		return
+	}
+	fields["destinationMacAddress"] = net.HardwareAddr(copyBytes(b[0:6]))
+	fields["sourceMacAddress"] = net.HardwareAddr(copyBytes(b[6:12]))
+	etherType := binary.BigEndian.Uint16(b[12:14])
+	b = b[ethernetHeader:]
+
+	// Only the outer VLAN tag is reported.
+	tagged := false
+	for (etherType == etherTypeVLAN || etherType == etherTypeQinQ) && len(b) >= 4 {
+		if !tagged {
+			tci := binary.BigEndian.Uint16(b[0:2])
+			fields["vlanId"] = uint64(tci & 0x0fff)
+			fields["dot1qPriority"] = uint64(tci >> 13)
+			tagged = true
+		}
+		etherType = binary.BigEndian.Uint16(b[2:4])
+		b = b[4:]
+	}
+	fields["ethernetType"] = uint64(etherType)
+
+	switch etherType {
+	case etherTypeIPv4:
+		decodeIPv4(b, fields)
+	case etherTypeIPv6:
+		decodeIPv6(b, fields)
+	}
+}
+
+func decodeIPv4(b []byte, fields record.Map) {
+	if len(b) < ipv4Header || b[0]>>4 != 4 {
+		return
+	}
+	headerLen := int(b[0]&0x0f) * 4
+	protocol := b[9]
+	fields["ipClassOfService"] = uint64(b[1])
+	fields["ipTotalLength"] = uint64(binary.BigEndian.Uint16(b[2:4]))
+	fields["ipTTL"] = uint64(b[8])
+	fields["protocolIdentifier"] = uint64(protocol)
+	fields["sourceIPv4Address"] = net.IP(copyBytes(b[12:16]))
+	fields["destinationIPv4Address"] = net.IP(copyBytes(b[16:20]))
+
+	// Only the first fragment has the transport header.
+	if binary.BigEndian.Uint16(b[6:8])&0x1fff != 0 || headerLen < ipv4Header || len(b) < headerLen {
+		return
+	}
+	decodeTransport(protocol, b[headerLen:], fields)
+}
+
+func decodeIPv6(b []byte, fields record.Map) {
+	if len(b) < ipv6Header || b[0]>>4 != 6 {
+		return
+	}
+	protocol := b[6]
+	fields["ipClassOfService"] = uint64(binary.BigEndian.Uint16(b[0:2]) >> 4 & 0xff)
+	fields["flowLabelIPv6"] = uint64(binary.BigEndian.Uint32(b[0:4]) & 0xfffff)
+	fields["ipTotalLength"] = uint64(binary.BigEndian.Uint16(b[4:6])) + ipv6Header
+	fields["ipTTL"] = uint64(b[7])
+	fields["protocolIdentifier"] = uint64(protocol)
+	fields["sourceIPv6Address"] = net.IP(copyBytes(b[8:24]))
+	fields["destinationIPv6Address"] = net.IP(copyBytes(b[24:40]))
+
+	// Extension headers aren't decoded.
+	decodeTransport(protocol, b[ipv6Header:], fields)
+}
+
+func decodeTransport(protocol uint8, b []byte, fields record.Map) {
+	switch protocol {
+	case ipProtoTCP, ipProtoUDP, ipProtoSCTP:
+		if len(b) < 4 {
+			return
+		}
+		fields["sourceTransportPort"] = uint64(binary.BigEndian.Uint16(b[0:2]))
+		fields["destinationTransportPort"] = uint64(binary.BigEndian.Uint16(b[2:4]))
+		if protocol == ipProtoTCP && len(b) >= 14 {
+			fields["tcpControlBits"] = uint64(binary.BigEndian.Uint16(b[12:14]) & 0x01ff)
+		}
+	case ipProtoICMP:
+		if len(b) >= 2 {
+			fields["icmpTypeCodeIPv4"] = uint64(binary.BigEndian.Uint16(b[0:2]))
+		}
+	case ipProtoICMPv6:
+		if len(b) >= 2 {
+			fields["icmpTypeCodeIPv6"] = uint64(binary.BigEndian.Uint16(b[0:2]))
+		}
+	}
+}
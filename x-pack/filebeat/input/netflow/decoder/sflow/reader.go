@@ -0,0 +1,109 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sflow
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+)
+
+// Address types of sFlow addresses.
+const (
+	addressUnknown = 0
+	addressIPv4    = 1
+	addressIPv6    = 2
+)
+
+// reader reads the XDR encoded values of sFlow datagrams. After an error,
+// the reads return zero values and the error is kept in err.
+type reader struct {
+	buf []byte
+	err error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.buf) {
+		r.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *reader) uint32() uint32 {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+func (r *reader) uint64() uint64 {
+	b := r.next(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+// opaque reads variable-length opaque data, which is padded to a multiple
+// of four bytes.
+func (r *reader) opaque() []byte {
+	n := int(r.uint32())
+	b := r.next(n)
+	if pad := n % 4; pad != 0 {
+		r.next(4 - pad)
+	}
+	return b
+}
+
+// fixed reads n bytes of fixed-length opaque data, which is padded to a
+// multiple of four bytes.
+func (r *reader) fixed(n int) []byte {
+	b := r.next(n)
+	if pad := n % 4; pad != 0 {
+		r.next(4 - pad)
+	}
+	return copyBytes(b)
+}
+
+// address reads an address, prefixed by its type. Unknown addresses are
+// returned as nil.
+func (r *reader) address() net.IP {
+	switch r.uint32() {
+	case addressUnknown:
+		return nil
+	case addressIPv4:
+		return r.ip(net.IPv4len)
+	case addressIPv6:
+		return r.ip(net.IPv6len)
+	default:
+		if r.err == nil {
+			r.err = errUnsupportedAddressType
+		}
+		return nil
+	}
+}
+
+func (r *reader) ip(n int) net.IP {
+	b := r.next(n)
+	if b == nil {
+		return nil
+	}
+	return net.IP(copyBytes(b))
+}
+
+// copyBytes copies b, so the records don't keep the packet buffer.
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
@@ -0,0 +1,220 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sflow
+
+import (
+	"net"
+
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/record"
+)
+
+// Flow record formats of the standard (zero) enterprise.
+const (
+	sampledHeaderFormat   = 1
+	sampledEthernetFormat = 2
+	sampledIPv4Format     = 3
+	sampledIPv6Format     = 4
+	extendedSwitchFormat  = 1001
+	extendedRouterFormat  = 1002
+)
+
+// Counter record formats of the standard (zero) enterprise.
+const (
+	genericInterfaceCountersFormat  = 1
+	ethernetInterfaceCountersFormat = 2
+	processorCountersFormat         = 1001
+)
+
+// readFlowRecord reads a flow record into fields. Records of unsupported
+// formats are ignored.
+func readFlowRecord(format uint32, r *reader, fields record.Map) error {
+	switch format {
+	case sampledHeaderFormat:
+		headerProtocol := r.uint32()
+		frameLength := r.uint32()
+		r.uint32() // stripped
+		header := r.opaque()
+		if r.err != nil {
+			return r.err
+		}
+		fields["sflowHeaderProtocol"] = uint64(headerProtocol)
+		fields["dataLinkFrameSize"] = uint64(frameLength)
+		fields["octetDeltaCount"] = uint64(frameLength)
+		fields["packetDeltaCount"] = uint64(1)
+		decodeHeader(headerProtocol, header, fields)
+
+	case sampledEthernetFormat:
+		length := r.uint32()
+		src := r.fixed(6)
+		dst := r.fixed(6)
+		ethernetType := r.uint32()
+		if r.err != nil {
+			return r.err
+		}
+		setDefault(fields, "octetDeltaCount", uint64(length))
+		setDefault(fields, "packetDeltaCount", uint64(1))
+		fields["sourceMacAddress"] = net.HardwareAddr(src)
+		fields["destinationMacAddress"] = net.HardwareAddr(dst)
+		fields["ethernetType"] = uint64(ethernetType)
+
+	case sampledIPv4Format, sampledIPv6Format:
+		ipLen := net.IPv4len
+		srcField, dstField := "sourceIPv4Address", "destinationIPv4Address"
+		if format == sampledIPv6Format {
+			ipLen = net.IPv6len
+			srcField, dstField = "sourceIPv6Address", "destinationIPv6Address"
+		}
+		length := r.uint32()
+		protocol := r.uint32()
+		src := r.ip(ipLen)
+		dst := r.ip(ipLen)
+		srcPort := r.uint32()
+		dstPort := r.uint32()
+		tcpFlags := r.uint32()
+		tos := r.uint32()
+		if r.err != nil {
+			return r.err
+		}
+		setDefault(fields, "octetDeltaCount", uint64(length))
+		setDefault(fields, "packetDeltaCount", uint64(1))
+		fields["protocolIdentifier"] = uint64(protocol)
+		fields[srcField] = src
+		fields[dstField] = dst
+		fields["sourceTransportPort"] = uint64(srcPort)
+		fields["destinationTransportPort"] = uint64(dstPort)
+		fields["tcpControlBits"] = uint64(tcpFlags)
+		fields["ipClassOfService"] = uint64(tos)
+
+	case extendedSwitchFormat:
+		srcVlan := r.uint32()
+		srcPriority := r.uint32()
+		dstVlan := r.uint32()
+		r.uint32() // destination priority
+		if r.err != nil {
+			return r.err
+		}
+		fields["vlanId"] = uint64(srcVlan)
+		fields["dot1qPriority"] = uint64(srcPriority)
+		fields["postVlanId"] = uint64(dstVlan)
+
+	case extendedRouterFormat:
+		nextHop := r.address()
+		srcMask := r.uint32()
+		dstMask := r.uint32()
+		if r.err != nil {
+			return r.err
+		}
+		if nextHop.To4() != nil {
+			fields["ipNextHopIPv4Address"] = nextHop
+			fields["sourceIPv4PrefixLength"] = uint64(srcMask)
+			fields["destinationIPv4PrefixLength"] = uint64(dstMask)
+		} else if nextHop != nil {
+			fields["ipNextHopIPv6Address"] = nextHop
+			fields["sourceIPv6PrefixLength"] = uint64(srcMask)
+			fields["destinationIPv6PrefixLength"] = uint64(dstMask)
+		}
+	}
+	return nil
+}
+
+// counter is a counter of a counter record. Its size is 4 or 8 bytes.
+type counter struct {
+	name string
+	size int
+}
+
+// The counters of the generic interface counters, see RFC 2233.
+var genericInterfaceCounters = []counter{
+	{"ifIndex", 4},
+	{"ifType", 4},
+	{"ifSpeed", 8},
+	{"ifDirection", 4},
+	{"ifStatus", 4},
+	{"ifInOctets", 8},
+	{"ifInUcastPkts", 4},
+	{"ifInMulticastPkts", 4},
+	{"ifInBroadcastPkts", 4},
+	{"ifInDiscards", 4},
+	{"ifInErrors", 4},
+	{"ifInUnknownProtos", 4},
+	{"ifOutOctets", 8},
+	{"ifOutUcastPkts", 4},
+	{"ifOutMulticastPkts", 4},
+	{"ifOutBroadcastPkts", 4},
+	{"ifOutDiscards", 4},
+	{"ifOutErrors", 4},
+	{"ifPromiscuousMode", 4},
+}
+
+// The counters of the Ethernet interface counters, see RFC 2358.
+var ethernetInterfaceCounters = []counter{
+	{"dot3StatsAlignmentErrors", 4},
+	{"dot3StatsFCSErrors", 4},
+	{"dot3StatsSingleCollisionFrames", 4},
+	{"dot3StatsMultipleCollisionFrames", 4},
+	{"dot3StatsSQETestErrors", 4},
+	{"dot3StatsDeferredTransmissions", 4},
+	{"dot3StatsLateCollisions", 4},
+	{"dot3StatsExcessiveCollisions", 4},
+	{"dot3StatsInternalMacTransmitErrors", 4},
+	{"dot3StatsCarrierSenseErrors", 4},
+	{"dot3StatsFrameTooLongs", 4},
+	{"dot3StatsInternalMacReceiveErrors", 4},
+	{"dot3StatsSymbolErrors", 4},
+}
+
+// readCounterRecord reads a counter record into fields. Records of
+// unsupported formats are ignored.
+func readCounterRecord(format uint32, r *reader, fields record.Map) error {
+	switch format {
+	case genericInterfaceCountersFormat:
+		readCounters(r, genericInterfaceCounters, fields)
+
+	case ethernetInterfaceCountersFormat:
+		readCounters(r, ethernetInterfaceCounters, fields)
+
+	case processorCountersFormat:
+		// The CPU utilizations are in hundredths of a percent.
+		cpu5s := r.uint32()
+		cpu1m := r.uint32()
+		cpu5m := r.uint32()
+		totalMemory := r.uint64()
+		freeMemory := r.uint64()
+		if r.err != nil {
+			return r.err
+		}
+		fields["cpuPercent5s"] = float64(cpu5s) / 100
+		fields["cpuPercent1m"] = float64(cpu1m) / 100
+		fields["cpuPercent5m"] = float64(cpu5m) / 100
+		fields["totalMemory"] = totalMemory
+		fields["freeMemory"] = freeMemory
+	}
+	return r.err
+}
+
+func readCounters(r *reader, counters []counter, fields record.Map) {
+	values := make([]uint64, len(counters))
+	for i, c := range counters {
+		if c.size == 8 {
+			values[i] = r.uint64()
+		} else {
+			values[i] = uint64(r.uint32())
+		}
+	}
+	if r.err != nil {
+		return
+	}
+	for i, c := range counters {
+		fields[c.name] = values[i]
+	}
+}
+
+// setDefault sets a field unless it's already set, so the sampled header
+// takes precedence over the records decoded from it.
+func setDefault(fields record.Map, key string, value interface{}) {
+	if _, found := fields[key]; !found {
+		fields[key] = value
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sflow
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/config"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/protocol"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/record"
+)
+
+const (
+	ProtocolName = "sflow"
+	LogPrefix    = "[sflow] "
+
+	// ProtocolID is the version sFlow datagrams are selected with. The version
+	// of sFlow datagrams is a 32-bit number, so the 16-bit version read by the
+	// decoder is always zero, a version not used by NetFlow.
+	ProtocolID uint16 = 0
+
+	// DatagramVersion is the only supported sFlow version.
+	DatagramVersion = 5
+)
+
+// Sample formats of the standard (zero) enterprise.
+const (
+	flowSampleFormat             = 1
+	countersSampleFormat         = 2
+	expandedFlowSampleFormat     = 3
+	expandedCountersSampleFormat = 4
+)
+
+// Interface formats of the input and output of flow samples. The other
+// format identifies packets sent to multiple interfaces.
+const (
+	interfaceIndex     = 0
+	interfaceDiscarded = 1
+)
+
+var errUnsupportedAddressType = errors.New("unsupported address type")
+
+type SFlowProtocol struct {
+	logger *logp.Logger
+	now    func() time.Time
+}
+
+func init() {
+	if err := protocol.Registry.Register(ProtocolName, New); err != nil {
+		panic(err)
+	}
+}
+
+func New(config config.Config) protocol.Protocol {
+	return &SFlowProtocol{
+		logger: config.LogOutput().Named(LogPrefix),
+		now:    time.Now,
+	}
+}
+
+func (*SFlowProtocol) Version() uint16 {
+	return ProtocolID
+}
+
+func (*SFlowProtocol) Start() error {
+	return nil
+}
+
+func (*SFlowProtocol) Stop() error {
+	return nil
+}
+
+// OnPacket decodes the flow and counter samples of an sFlow datagram. sFlow
+// datagrams don't have a timestamp, so the records have the time the datagram
+// is decoded.
+func (p *SFlowProtocol) OnPacket(buf *bytes.Buffer, source net.Addr) ([]record.Record, error) {
+	r := reader{buf: buf.Next(buf.Len())}
+	header, err := readDatagramHeader(&r)
+	if err != nil {
+		p.logger.Debugf("Failed parsing packet: %v", err)
+		return nil, fmt.Errorf("error reading sflow header: %w", err)
+	}
+
+	timestamp := p.now().UTC()
+	exporter := record.Map{
+		"version":      uint64(header.Version),
+		"timestamp":    timestamp,
+		"uptimeMillis": uint64(header.Uptime),
+		"address":      source.String(),
+		"subAgentId":   uint64(header.SubAgentID),
+	}
+	if header.AgentAddress != nil {
+		exporter["agentAddress"] = header.AgentAddress
+	}
+
+	// The number of samples comes from the datagram, so the records are not
+	// preallocated beyond what the datagram can hold: each sample takes at
+	// least 8 bytes for its format and length.
+	records := make([]record.Record, 0, min(header.NumSamples, uint32(len(r.buf)/8))) //nolint:gosec // datagrams are smaller than 4GiB
+	for i := uint32(0); i < header.NumSamples; i++ {
+		format := r.uint32()
+		data := r.opaque()
+		if r.err != nil {
+			return nil, fmt.Errorf("error reading sample %d: %w", i, r.err)
+		}
+
+		var (
+			rec record.Record
+			err error
+		)
+		sample := reader{buf: data}
+		switch format {
+		case flowSampleFormat:
+			rec, err = readFlowSample(&sample, false)
+		case expandedFlowSampleFormat:
+			rec, err = readFlowSample(&sample, true)
+		case countersSampleFormat:
+			rec, err = readCountersSample(&sample, false)
+		case expandedCountersSampleFormat:
+			rec, err = readCountersSample(&sample, true)
+		default:
+			// Samples of other enterprises, or unknown formats.
+			p.logger.Debugf("Skipping sample with unsupported format %d:%d", format>>12, format&0xfff)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing sample %d: %w", i, err)
+		}
+		rec.Timestamp = timestamp
+		rec.Exporter = exporter
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+type DatagramHeader struct {
+	Version        uint32
+	AgentAddress   net.IP
+	SubAgentID     uint32
+	SequenceNumber uint32
+	Uptime         uint32 // milliseconds
+	NumSamples     uint32
+}
+
+func readDatagramHeader(r *reader) (header DatagramHeader, err error) {
+	header.Version = r.uint32()
+	if r.err == nil && header.Version != DatagramVersion {
+		return header, fmt.Errorf("unsupported sflow version %d", header.Version)
+	}
+	header.AgentAddress = r.address()
+	header.SubAgentID = r.uint32()
+	header.SequenceNumber = r.uint32()
+	header.Uptime = r.uint32()
+	header.NumSamples = r.uint32()
+	return header, r.err
+}
+
+// readFlowSample reads a flow sample. The flow records of the sample are
+// merged into the fields of a single flow record.
+func readFlowSample(r *reader, expanded bool) (record.Record, error) {
+	fields := record.Map{
+		"sflowSequenceNumber": uint64(r.uint32()),
+	}
+	readDataSource(r, expanded, fields)
+	fields["samplingPacketInterval"] = uint64(r.uint32())
+	fields["sflowSamplePool"] = uint64(r.uint32())
+	fields["droppedPacketDeltaCount"] = uint64(r.uint32())
+
+	var inFormat, inValue, outFormat, outValue uint32
+	if expanded {
+		inFormat, inValue = r.uint32(), r.uint32()
+		outFormat, outValue = r.uint32(), r.uint32()
+	} else {
+		in, out := r.uint32(), r.uint32()
+		inFormat, inValue = in>>30, in&(1<<30-1)
+		outFormat, outValue = out>>30, out&(1<<30-1)
+	}
+	if inFormat == interfaceIndex {
+		fields["ingressInterface"] = uint64(inValue)
+	}
+	switch outFormat {
+	case interfaceIndex:
+		fields["egressInterface"] = uint64(outValue)
+	case interfaceDiscarded:
+		fields["sflowDiscardReason"] = uint64(outValue)
+	}
+
+	numRecords := r.uint32()
+	for i := uint32(0); i < numRecords && r.err == nil; i++ {
+		format := r.uint32()
+		data := r.opaque()
+		if r.err != nil {
+			break
+		}
+		if err := readFlowRecord(format, &reader{buf: data}, fields); err != nil {
+			return record.Record{}, fmt.Errorf("error parsing flow record %d: %w", i, err)
+		}
+	}
+	if r.err != nil {
+		return record.Record{}, r.err
+	}
+	return record.Record{Type: record.Flow, Fields: fields}, nil
+}
+
+// readCountersSample reads a counters sample. The counter records of the
+// sample are merged into the fields of a single counters record.
+func readCountersSample(r *reader, expanded bool) (record.Record, error) {
+	fields := record.Map{
+		"sflowSequenceNumber": uint64(r.uint32()),
+	}
+	readDataSource(r, expanded, fields)
+
+	numRecords := r.uint32()
+	for i := uint32(0); i < numRecords && r.err == nil; i++ {
+		format := r.uint32()
+		data := r.opaque()
+		if r.err != nil {
+			break
+		}
+		if err := readCounterRecord(format, &reader{buf: data}, fields); err != nil {
+			return record.Record{}, fmt.Errorf("error parsing counter record %d: %w", i, err)
+		}
+	}
+	if r.err != nil {
+		return record.Record{}, r.err
+	}
+	return record.Record{Type: record.Counters, Fields: fields}, nil
+}
+
+// readDataSource reads the data source of a sample, which is packed into a
+// single value in compact samples.
+func readDataSource(r *reader, expanded bool, fields record.Map) {
+	var sourceType, sourceIndex uint32
+	if expanded {
+		sourceType, sourceIndex = r.uint32(), r.uint32()
+	} else {
+		sourceID := r.uint32()
+		sourceType, sourceIndex = sourceID>>24, sourceID&(1<<24-1)
+	}
+	fields["sflowSourceIdType"] = uint64(sourceType)
+	fields["sflowSourceIdIndex"] = uint64(sourceIndex)
+}
@@ -0,0 +1,359 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sflow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/config"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/protocol"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/record"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/test"
+)
+
+func init() {
+	logp.TestingSetup()
+}
+
+var captureTime = time.Date(2026, 5, 4, 10, 30, 0, 0, time.UTC)
+
+// xdr builds XDR encoded sFlow datagrams.
+type xdr struct {
+	bytes.Buffer
+}
+
+func (x *xdr) u32(values ...uint32) *xdr {
+	for _, v := range values {
+		_ = binary.Write(x, binary.BigEndian, v)
+	}
+	return x
+}
+
+func (x *xdr) u64(v uint64) *xdr {
+	_ = binary.Write(x, binary.BigEndian, v)
+	return x
+}
+
+// opaque appends variable-length opaque data.
+func (x *xdr) opaque(b []byte) *xdr {
+	x.u32(uint32(len(b)))
+	x.Write(b)
+	if pad := len(b) % 4; pad != 0 {
+		x.Write(make([]byte, 4-pad))
+	}
+	return x
+}
+
+// record appends a sample or record with its format.
+func (x *xdr) record(format uint32, data *xdr) *xdr {
+	return x.u32(format).opaque(data.Bytes())
+}
+
+// datagram builds a datagram with numSamples samples.
+func datagram(numSamples uint32, samples *xdr) *bytes.Buffer {
+	var x xdr
+	x.u32(DatagramVersion, addressIPv4)
+	x.Write(net.ParseIP("192.0.2.1").To4())
+	x.u32(3, 100, 123456, numSamples)
+	x.Write(samples.Bytes())
+	return &x.Buffer
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func newTestProtocol() *SFlowProtocol {
+	p := New(config.Defaults(logp.L())).(*SFlowProtocol)
+	p.now = func() time.Time { return captureTime }
+	return p
+}
+
+var expectedExporter = record.Map{
+	"version":      uint64(5),
+	"timestamp":    captureTime,
+	"uptimeMillis": uint64(123456),
+	"address":      "127.0.0.1:6343",
+	"agentAddress": net.ParseIP("192.0.2.1").To4(),
+	"subAgentId":   uint64(3),
+}
+
+func TestSFlowProtocol_New(t *testing.T) {
+	factory, err := protocol.Registry.Get(ProtocolName)
+	require.NoError(t, err)
+	proto := factory(config.Defaults(logp.L()))
+
+	assert.Nil(t, proto.Start())
+	assert.Equal(t, uint16(0), proto.Version())
+	assert.Nil(t, proto.Stop())
+}
+
+func TestSFlowProtocol_FlowSample(t *testing.T) {
+	// Ethernet frame with a VLAN tag, of a TCP SYN from 10.0.0.1:49152 to
+	// 10.0.0.2:443.
+	frame := mustHex(t, ""+
+		"0a0b0c0d0e0f"+"001122334455"+"8100"+"6064"+"0800"+
+		"45100034abcd40004006"+"0000"+"0a000001"+"0a000002"+
+		"c00001bb"+"00000001"+"00000000"+"5002"+"ffff")
+
+	var header xdr
+	header.u32(headerProtocolEthernet, 1518, 4).opaque(frame)
+	var extSwitch xdr
+	extSwitch.u32(100, 3, 200, 0)
+
+	var sample xdr
+	sample.u32(
+		42,          // sequence number
+		0<<24|7,     // data source: interface 7
+		1000,        // sampling rate
+		5000,        // sample pool
+		2,           // drops
+		7,           // input
+		1<<30|0x102, // output: discarded
+		2,           // number of records
+	)
+	sample.record(sampledHeaderFormat, &header)
+	sample.record(extendedSwitchFormat, &extSwitch)
+
+	var samples xdr
+	samples.record(flowSampleFormat, &sample)
+
+	records, err := newTestProtocol().OnPacket(datagram(1, &samples), test.MakeAddress(t, "127.0.0.1:6343"))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	test.AssertRecordsEqual(t, record.Record{
+		Type:      record.Flow,
+		Timestamp: captureTime,
+		Exporter:  expectedExporter,
+		Fields: record.Map{
+			"sflowSequenceNumber":      uint64(42),
+			"sflowSourceIdType":        uint64(0),
+			"sflowSourceIdIndex":       uint64(7),
+			"samplingPacketInterval":   uint64(1000),
+			"sflowSamplePool":          uint64(5000),
+			"droppedPacketDeltaCount":  uint64(2),
+			"ingressInterface":         uint64(7),
+			"sflowDiscardReason":       uint64(0x102),
+			"sflowHeaderProtocol":      uint64(headerProtocolEthernet),
+			"dataLinkFrameSize":        uint64(1518),
+			"octetDeltaCount":          uint64(1518),
+			"packetDeltaCount":         uint64(1),
+			"destinationMacAddress":    net.HardwareAddr{0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f},
+			"sourceMacAddress":         net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55},
+			"vlanId":                   uint64(100),
+			"dot1qPriority":            uint64(3),
+			"postVlanId":               uint64(200),
+			"ethernetType":             uint64(0x0800),
+			"ipClassOfService":         uint64(0x10),
+			"ipTotalLength":            uint64(0x34),
+			"ipTTL":                    uint64(64),
+			"protocolIdentifier":       uint64(6),
+			"sourceIPv4Address":        net.ParseIP("10.0.0.1").To4(),
+			"destinationIPv4Address":   net.ParseIP("10.0.0.2").To4(),
+			"sourceTransportPort":      uint64(49152),
+			"destinationTransportPort": uint64(443),
+			"tcpControlBits":           uint64(0x02),
+		},
+	}, records[0])
+}
+
+func TestSFlowProtocol_ExpandedFlowSample(t *testing.T) {
+	// IPv6 packet of a UDP datagram from [2001:db8::1]:53 to [2001:db8::2]:5353.
+	packet := mustHex(t, ""+
+		"6a012345"+"0010"+"11"+"40"+
+		"20010db8000000000000000000000001"+
+		"20010db8000000000000000000000002"+
+		"003514e900100000")
+
+	var header xdr
+	header.u32(headerProtocolIPv6, 56, 0).opaque(packet)
+	var router xdr
+	router.u32(addressIPv6).Write(net.ParseIP("2001:db8::fe"))
+	router.u32(48, 64)
+	var ipv4 xdr
+	ipv4.u32(100, 17)
+	ipv4.Write(net.ParseIP("192.0.2.10").To4())
+	ipv4.Write(net.ParseIP("192.0.2.20").To4())
+	ipv4.u32(1000, 2000, 0, 0)
+
+	var sample xdr
+	sample.u32(
+		43,       // sequence number
+		0, 1<<20, // data source: interface 1048576
+		512,      // sampling rate
+		10000,    // sample pool
+		0,        // drops
+		0, 1<<20, // input
+		2, 3, // output: multiple interfaces
+		3, // number of records
+	)
+	sample.record(sampledHeaderFormat, &header)
+	sample.record(extendedRouterFormat, &router)
+	sample.record(1<<12|1, &ipv4) // unknown enterprise
+
+	var samples xdr
+	samples.record(expandedFlowSampleFormat, &sample)
+
+	records, err := newTestProtocol().OnPacket(datagram(1, &samples), test.MakeAddress(t, "127.0.0.1:6343"))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	test.AssertRecordsEqual(t, record.Record{
+		Type:      record.Flow,
+		Timestamp: captureTime,
+		Exporter:  expectedExporter,
+		Fields: record.Map{
+			"sflowSequenceNumber":         uint64(43),
+			"sflowSourceIdType":           uint64(0),
+			"sflowSourceIdIndex":          uint64(1 << 20),
+			"samplingPacketInterval":      uint64(512),
+			"sflowSamplePool":             uint64(10000),
+			"droppedPacketDeltaCount":     uint64(0),
+			"ingressInterface":            uint64(1 << 20),
+			"sflowHeaderProtocol":         uint64(headerProtocolIPv6),
+			"dataLinkFrameSize":           uint64(56),
+			"octetDeltaCount":             uint64(56),
+			"packetDeltaCount":            uint64(1),
+			"ipClassOfService":            uint64(0xa0),
+			"flowLabelIPv6":               uint64(0x12345),
+			"ipTotalLength":               uint64(56),
+			"ipTTL":                       uint64(64),
+			"protocolIdentifier":          uint64(17),
+			"sourceIPv6Address":           net.ParseIP("2001:db8::1"),
+			"destinationIPv6Address":      net.ParseIP("2001:db8::2"),
+			"sourceTransportPort":         uint64(53),
+			"destinationTransportPort":    uint64(5353),
+			"ipNextHopIPv6Address":        net.ParseIP("2001:db8::fe"),
+			"sourceIPv6PrefixLength":      uint64(48),
+			"destinationIPv6PrefixLength": uint64(64),
+		},
+	}, records[0])
+}
+
+func TestSFlowProtocol_CountersSample(t *testing.T) {
+	var generic xdr
+	generic.u32(7, 6).u64(10_000_000_000).u32(1, 3)
+	generic.u64(1<<40).u32(1, 2, 3, 4, 5, 6)
+	generic.u64(1<<41).u32(7, 8, 9, 10, 11, 0)
+	var ethernet xdr
+	ethernet.u32(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13)
+	var processor xdr
+	processor.u32(1250, 2500, 5000).u64(8 << 30).u64(2 << 30)
+
+	var compact xdr
+	compact.u32(10, 0<<24|7, 2)
+	compact.record(genericInterfaceCountersFormat, &generic)
+	compact.record(ethernetInterfaceCountersFormat, &ethernet)
+
+	var expanded xdr
+	expanded.u32(11, 2, 1, 1)
+	expanded.record(processorCountersFormat, &processor)
+
+	var samples xdr
+	samples.record(countersSampleFormat, &compact)
+	samples.record(4321<<12|1, &xdr{}) // unknown enterprise
+	samples.record(expandedCountersSampleFormat, &expanded)
+
+	records, err := newTestProtocol().OnPacket(datagram(3, &samples), test.MakeAddress(t, "127.0.0.1:6343"))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	test.AssertRecordsEqual(t, record.Record{
+		Type:      record.Counters,
+		Timestamp: captureTime,
+		Exporter:  expectedExporter,
+		Fields: record.Map{
+			"sflowSequenceNumber":                uint64(10),
+			"sflowSourceIdType":                  uint64(0),
+			"sflowSourceIdIndex":                 uint64(7),
+			"ifIndex":                            uint64(7),
+			"ifType":                             uint64(6),
+			"ifSpeed":                            uint64(10_000_000_000),
+			"ifDirection":                        uint64(1),
+			"ifStatus":                           uint64(3),
+			"ifInOctets":                         uint64(1 << 40),
+			"ifInUcastPkts":                      uint64(1),
+			"ifInMulticastPkts":                  uint64(2),
+			"ifInBroadcastPkts":                  uint64(3),
+			"ifInDiscards":                       uint64(4),
+			"ifInErrors":                         uint64(5),
+			"ifInUnknownProtos":                  uint64(6),
+			"ifOutOctets":                        uint64(1 << 41),
+			"ifOutUcastPkts":                     uint64(7),
+			"ifOutMulticastPkts":                 uint64(8),
+			"ifOutBroadcastPkts":                 uint64(9),
+			"ifOutDiscards":                      uint64(10),
+			"ifOutErrors":                        uint64(11),
+			"ifPromiscuousMode":                  uint64(0),
+			"dot3StatsAlignmentErrors":           uint64(1),
+			"dot3StatsFCSErrors":                 uint64(2),
+			"dot3StatsSingleCollisionFrames":     uint64(3),
+			"dot3StatsMultipleCollisionFrames":   uint64(4),
+			"dot3StatsSQETestErrors":             uint64(5),
+			"dot3StatsDeferredTransmissions":     uint64(6),
+			"dot3StatsLateCollisions":            uint64(7),
+			"dot3StatsExcessiveCollisions":       uint64(8),
+			"dot3StatsInternalMacTransmitErrors": uint64(9),
+			"dot3StatsCarrierSenseErrors":        uint64(10),
+			"dot3StatsFrameTooLongs":             uint64(11),
+			"dot3StatsInternalMacReceiveErrors":  uint64(12),
+			"dot3StatsSymbolErrors":              uint64(13),
+		},
+	}, records[0])
+	test.AssertRecordsEqual(t, record.Record{
+		Type:      record.Counters,
+		Timestamp: captureTime,
+		Exporter:  expectedExporter,
+		Fields: record.Map{
+			"sflowSequenceNumber": uint64(11),
+			"sflowSourceIdType":   uint64(2),
+			"sflowSourceIdIndex":  uint64(1),
+			"cpuPercent5s":        12.5,
+			"cpuPercent1m":        25.0,
+			"cpuPercent5m":        50.0,
+			"totalMemory":         uint64(8 << 30),
+			"freeMemory":          uint64(2 << 30),
+		},
+	}, records[1])
+}
+
+func TestSFlowProtocol_BadPacket(t *testing.T) {
+	var truncated xdr
+	truncated.u32(flowSampleFormat, 100, 1, 2)
+
+	var badRecord xdr
+	badRecord.u32(1, 0, 1, 0, 0, 0, 0, 1)
+	badRecord.record(sampledIPv4Format, new(xdr).u32(1, 2))
+	var badSample xdr
+	badSample.record(flowSampleFormat, &badRecord)
+
+	tests := map[string]*bytes.Buffer{
+		"short header":     bytes.NewBuffer(mustHex(t, "0000000500000001c0")),
+		"wrong version":    bytes.NewBuffer(mustHex(t, "0000000400000001c000020100000000")),
+		"bad address type": bytes.NewBuffer(mustHex(t, "0000000500000007c0000201")),
+		"truncated sample": datagram(1, &truncated),
+		"truncated record": datagram(1, &badSample),
+		"missing samples":  datagram(2, new(xdr)),
+		"too many samples": datagram(0xffffffff, new(xdr)),
+	}
+	for name, packet := range tests {
+		t.Run(name, func(t *testing.T) {
+			records, err := newTestProtocol().OnPacket(packet, test.MakeAddress(t, "127.0.0.1:6343"))
+			assert.Error(t, err)
+			assert.Empty(t, records)
+		})
+	}
+}
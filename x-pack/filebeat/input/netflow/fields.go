@@ -19,5 +19,5 @@ func init() {
 // AssetNetflow returns asset data.
 // This is the base64 encoded zlib format compressed contents of input/netflow.
 func AssetNetflow() string {
-	return "eJysXc2S2ziSvvspFDOHvWx32K6y2+7DnmYndg67O4c57A0BkSkKXSTAAkCp1E+/AfBHpAhQyAR96Imx9X34YSIBZCYy/5r459NfD/86C3M4iRoOwhwqkKC5hfLXw9/UQSp7aFQpTrdfPyUz/nJ4g9vvBwn2VKvrp8PBClvD74e//A/Yv9fq+pdPh0MJptCitULJ3w//8elwOBz+LqAuzeGkVXMYfnngsjz8459//8f/HRyV+fXT4XDyP/vdQ345SN7AvCn3x95a+P1QadW1w98EWnva4q/Dz+btzdt0rUx/OTb6Brer0uXs7yNNuz//OoOHHdRpal5DoXQ5oI5QHo63g3XfBy4g7a+fVt2Aj1ZpC3rVlfn4n3Tkv8Hyklt+0FC7T3+w6mDPMHEfSriIAg72zO1dQPp+9R3+dcb3OGHz3vKy1GDM4t/ic/ek2+7Pfw5d/DfjhOCq9NvYxkHIwz/++bv758NJ6YbPZ2/eJ6M6XQATjy33vaqVrHBd+t+jAX3h7p8PpWq468ff3JRez6I4z2ftcARHbyIds6IBY3nTBjtWcgu4jv1LNODl20Gd0PXfN9J617r2WSPqWoQ/GH5q/ktdPWopXa1WhftgZ24ORwB50J2UQlb/7j5h3z4USpaxebqANkLJYB+FtFAtVkdCN8fFOBAfOgNlYOnxo1F1Z4GB1mq9AEvVHWsIwHr5ZK1SNTuL6szsWYM5q7pccfgp3mao1TWDQFvW8LYVssrtyoxpty61oFlnQBP7dipYq5VVhapXkFEwNlHM/VVUya+hUslbI/70a5+dal4ZRLsLsIXiLMV7t249TtC2tSh6+LEzQoIxv2io4cJlseaJzNmMpOAWKqVv2FmYUcwWGo3A72IZHThb27JOC2Yst8JYUaw/iTkrbVNoDOhfeAXSUijEWmwTUPSRm+64xxe0mp9OovilqLlZT11EiLRlRS1AWjZsyqzfS/iHaLoml0XIHVgMgaHlxRtY1CyoTlrmDlVMg2mVNICHS7iyQkkJhfsieDy95QnJzsJYVWnesGPnJuHLjlxfd+R62ZHrdUeubztyfd+R6zcCl9VcmkYYQ5JGj+ZoUc5UJJkaJEN1jHNP7fkDHt31JR7Xd3eRAZ0790EW9DhCLKTR5H6QMA11PPmfB78rWWV5nT0PQRb0NIRYcLPQM8zUSt6A1kTEMa2IEMMyBppjDSU7aV417vDilWcqvCvFgAgYhiKYC2heAXPXC821Fhc3BBE4Nobxx6p1p30rZH9u5IbJrjkmt+/wEj4s4+UfvHAjJjOcVctEe3lla2vTcIVqn6O/o9Gthkte7wdLFAV64bUohb35e876m0VuGkdxcnf1Uuj+bJmOkyVhih3Krwp/yXf/Sb/d+vtH6BIVnpP+57hrj8eI03jzAVkJCYh7W6HqGhaK4266WnF4812MQmlWgLZ9X5K/5h2M/jQLKE7071Dyp1VNoyRrtWrdoAHzmZU8iRJkAayGC6zNPDEjnLtPUaZJyoxhjlc4txOwstPDRT0iIdERjyzzDQYzY9JqXrylQ0puOTveLKA2IY+qhXxze5jb/XAqZgUXfyJsYY/ooLNmG92b6A3TUAt+FLWwtxXDUakauAwwQG0588oVNWezDRQtnCtwq+EkPnKwrAZZ2XPyN1uy4PTICkzv/vf87je8iPY+uoHMCahaohQVGMvO3Jzdph4wAEdER7i2i/GWrU5sOSnR0dDoNmSTSBiXllTC0ZGXP9aJaYdh3rnQI5SG8c6elRaWW3FJPgg4YIE7+zhIYEOIi6k0rPlg8FGcuayQDTUffoGChpBjYrNNaUrkqc6jPljvicXMYGs1pa13AkRjNygH6kDfposzZmBaozdjaZhRnMFHK3SyRhpAuPvziNJw0mDWuvsZyur1Nr2NMaAFX58cN0ENQTCM4poC0xfsFjKitHCqYz0fT5GW6woILV5BVGckzlrM3NsPy9zxDDGHyn55Z0VnrGpAsxIE4hj3iM09ICz5oh8otgyX8GFn2aMnl5oH7xDxL+fxhMmkjdmAvgi3i0pjuXyM1tkUmjB+p25YXuEY8BOtVdtCyWp+A/2VqcKCZf31AnWzCNH0dkoKTW43ctvvbd4ZHRgICD0wdvSJMyFLWF9QwjiofHjLUSteFtxYeg8GJm+oPfHkyI5HWPjIsYltzzcjCl5T2+6k2GXoF31KXv8gC31rLZR9SI2qVbVe9FE1ibYBDoDg3MYg9gxagmVn4CVo5KV1Qrf8VitexuBRBTMR9J+DDkedYPuTJNG/0UcO4qWwh03BXeu4we2Z7uGmsC0zVgNvUIp8gM/sArHQtM323ZzV6jqYpyhraKRpwBheQQ4FVY2PoZ8UK/eERRvI5kicbWpCUq06PYEPaeyDXdMPMPBhQUtejx1mGni9vk1FZuskNFx5XTMfP45AGcv8YZ5JJRk0rb1Nm+7oLUqOpOvpVkQ4k25PMkCPXMqAfyyqx30sJuOyZIY3be1kIHX+/WrjhRWX3jutOsR378HWanHsbCAAaxuI9sv1qNGx0IhCK5x34ZFgw4G1QQCyHI5m1C44hk1s2H02w6JdbxNWckltVgM3yK/lYMTWzM2wrsU46T0UJ/mirKly/wY3d0R22j0QHr/RZs2PUHstjUH5le1UrHe++sPBJdmuE2AwLS+EXN/vtgighmLaovG3kyUJ9ZK1ZCFflWY0SjNeV+7CfF7vPNtCYCzXNlMb9BxUfTCiaRqhRxN1Qg+mA2kL3PJjDexUd+bcb/v4z95TtMDfcFilr1yXbgkZy22XfkIYrwHhRxPPUKIEaafIjdTOjmh1OhmMtfN0ZceaF2+q8982WZR9FPdJVJ27+GB8ew5pDTO6YKZK/hbX4eOHAyNSQIit4srgw0YOmVsTOaEYrzEjm2AlmAJxDryyM69PTLUgcZI9B55FleqYWOB06JoTxTX8gxlARVmfrkx3NcLBcLoy0zUN1zfWviH1xJX9qSSwlgvMaXqOCjpDwriqVsfZHSjrzVqlwZ1NEn/tPfGDV151tg0cg6Imb4/tzwJh92N0aXikkMIK7rYtjdIXPbidTEIRBZcGDt7SNqD9HDHNZeWDcOnYwHxtYKcDD6nlR3R629a2Tq9bp4yCNrDo4vPQ0QoTM0Zto/sLB2vPmgd8wM+w750PNVFmLRtp0AbsWa2XXRo44mTcBvfHClaoEmE6mF7vsfDrvWiTomha35Y3NqUeSxao9RVmC+V+sdFgdIRr8LrdBDB6lCMqfZTVgEoHSOXOS7OYPILBcWQZ3F9DUGAuDdX8OdLk4sl+FCH7jAg+zLkGfwr2E4z6MiESynvfEBHGsh7EB11zKIrg6QQ1iH4vOUKVvJvEWUCmHm9CHAYaLjGPn0MknRSBZ0Ybc7qby3Okwnp8VjiM03MEN11tM/2WIxXZgToSZHtQRyKMC3U4hjJbtLj7eQ+0SveaLvUWc4fhnrbdvzJRDY1w7Mq3oBsoBbeAd+94NNm9I1rWv3RRpzE4BQEtxamH9aeGVol0F5Foaf7qO270AuFcHCLnkZfIeeM1uxNFhhz5wnccecithgLKYLhsHGSgYKZdR2hFu7kZARBHBQL44p1COt39JxZnRAuXV6ZazOtyD9Gqs6BZwJYUFoXLdwYfFqTx2UG8QCe3N9tSkof1IbjbSK8m7LpMgSG1moP2eb+wDQ4oSnvSMC7NFePanYBeEQZ8rM+RPpSagBuvIx+YK6UHm/6BKGGGjGWFsNiMLnesOyvoW/gCnQ4ntl5zK2wXaPlUKx4VJwdUsqIhNVQ+lxFtvANa4tFD3BkrRHtGi/MIfoMbOiBrQYAzSHmoN5aclUFfgCZwp9d7TgqMYqBxaNEYwUx37FMmYue6/o3xtkUc2GYgwgzV3IIsUo3PHqLhQvYFTwRkN7BnMLogKiyHpCosj6UrrDmc2DpJYXkgSWE5JF1hzdCE4dqzBm4jdsfQGWgJCtgbn4BwuqnmuoJRijHyuzD54cU/02KYGbYfgJuu8be9947r5JRemQbLADynGwaqmJFvC7e8PYWfAUXOpUECrN8/SIILYqyFBFZwXSLGriqm2uSBqitoVghWi0asxxZLh9Bw/ZbYn4Z/sKM4MpBWi+Qv71ADYsrqiYEOsdeoSBoHzIkmfMCj44cWeHwE0QJOgA7hLW6+pQ/J0TDkFUo9fTgaUqzuLHSBICf3oxwCJZquYTQbxogetFMGA8IU0gCXQyR/elSIBw1fBAXrfcxN+Y0VZyjeQpmoov3ssaZQ6a6hBixoUrR7A1YrBpciBIkeDu4ojAOrEUdW8NZ2esw7hvWSeAYlrQ9Hwj4VmoNx5zWH9A4udHitQzaq7GqsecYB1fEPKIg+vhl+DNYLbAAbMzWAyb02N2kDISEpUB96w44h59fzDvdgXC62FbziXSDHQiJ4nQL9mfZeMcQzaoQO+St8f240VofCqVOnUOFW1gLrV7cVxVvyhvLI0EkjKgnJakwcfQLmDVmPASXxsCOG7YQcLL1iwB94lgyEI8+SAA3OOQQM6IxDwMCAOQSoo6jBG7AQmqkHNUaYEqOBlRRW+V15fBtxP9hipzrANZMaLFtbm+HNh7G8eGMltOmz/gjGfbNHNNI1F8N/+ZzLsE6KjSRYZ8JGEiRHgsUI1jmvkQTJUWUxgnV2ayTBj1yCnygCkn95gaR5mD2FVe0wAvhItjc8INEe+TUe55R/wJPyvj1w0BXBnQOzBzwgMRcYB720svdesz73WNUJE/I4RTlsMR3vMLeXFh0O1EPGaCIfb2jgvfPZO1EJb3ui8dI9WhORJgrPoaEAcQHNrHqD1CC9e0CYhnuhjBMv0u9hkscey0TmzgGENKIEZi4FJjpyQCLT5jiU0qLy6Y5kRYtO8iSdpfbalzdC9dgjcFdEB3vvlOUMPgqAEsrIh4m36g76kcvNZrPTuxV0ixglIcGagrtTZsHHSijoxwYjiWacc2+8Rc7yHY92m66xOIdtAC9kwfWQyAuleZZcFprW13bJGVAnhc0d1b3ojzuEd+30hH8XMsT70ztRwYszMA2l0KPUzVIXF0pjlFEiKykh8oy8J9Q2eSneobOhnbmQPsIQ42HboErfk4MklInotPaPQEUB0oBjM12TbPy4E5VHVtSWEk2y4HBHhSM39HVWHlmtKhGryZYwjO2KcE+/R3lkGt7xu8QjAUXxD2DT4q4Va3jkSTcOnjcHHT6Q8Y73liWaBPSv/Hr90FfIKIYaAWVYPSZ0J8bZG1L2YNUgS9C7dXKg26N/pPeSDyT9k1qyNIxvEccUy3+GN0wMU6HUm8jrzEnpKzsdaQI6EdQZBLjnoAEC7KPQAIUuLlmT4PA5c9Anp0ZEEgY4Or02AaDwhHDIAM1FIHLlBvAfQ04PKJ1oZVCZXNk2ubJtWK0iGUIww8gTTpMpnIYZHwCXqWnmNGtTczJPf6ggjiV+pIibBSYKd5t1F6bBm93ywMEmYRgjDTpQYUVBLNka57Ggm6maBOUKtkmIyta55CzOXEqfHSa1CmgU78vK4I9jS5bU+qFR/C69SK08GsXv0ovUmqVR/C69SK12GsXn9KI/rGbdOWc8Yu0KCfkhgtiad7II2eJTF1pP4+2n2qqIQYFAdhWyVFekxTxIl6VHPIXvUAk1T41KjJL8IWx6jE2UZXhzfK9zmznjw9fDG4IeKT6yF0VviN+hLzarL3fbFL5CxZJqeGnYV/BgUpH6k3mPdBQ0o4JDejVFl/76t7uuwbwrWrEM1WizWLy2Yw04PS4MIlHkkmes8Nu1JbcQt16k9OmBa8N0kcJGXTlj6eIMCRso9tkK5mQ7bAU9XaYen5Fk6PEZy356fEaaJwF5+vceUJ+xIEaSqqO4FeYMGgZFnuz/D/MYIPuY5jTDCvfJdpiB9Tt+Atnw8IJEleMjHf0uNGu/381aXgHZy3ZnIIE/Zo4jigesMeLu3AvaOZ7OgVadrzUsiJI1aEhrCYaeCUsw8pibqVU1JgWkyM7AgK1vtCJw02Ysb9bXn2djyPTCdvJNqqv8+ts6hDIZirdLTFC8MWGC4i0AExR/bZ+g+Lv2BE1Nrx6AriMrk6HrmMpk6DqaMhX6gy5NP+jS9IMuTT/o0vSDLk0/6NL0gy5NP+jS9IMuTT/o0vSTLk0/6dL0ky5NP+nS9JMuTT/p0vSTLk0/6dL0ky5NP8nS9PKZLE0vn8nS9PKZLE0vn8nS9PKZLE0vn8nS9PKZLE0vn8nS9PKZLE0vn+nSFHh6kgylS9MXujR9oUvTF7o0faFL05e1NCUczCc0XaC+0AXqy1qgEH3+upapBNvJhKaL1Ve6WH1dixWmz4jk2wH0WrgwaETu7gCaLl9f1/KFaXgtYgj0S5aIvaxFDIOmS9nLWsoQ6+plLWUYNF2FvdA3xBe6fL3Q9dcLfUN8pW+Ir3TN9UqXqVf6hvhK3xBf6dL0SpemV7o0vWZpq9esDfHbWqYw6LVYYdBryUKM+xtduL7RhesbXbi+0YXrG124vic/n15jA6abZCzdPPBCv7y+0m9lr/Rb2Sv9pPIaOGukTvFrYLdPx9I/7St95b1+T5/k6zy+Ap9wsE+P3idox9R1WRX3RTWqLDN99T91JaQnnPCZaQ4nnmwCcn0GdTSgL32M5hCYkuxDCWBxHsg5gS8GQGu7h2Kcl3O0z9VDSF8T4EAnsFlx4FPYrCgo8LGCLEF+iIlGszOMUpdMdk5R1fL3McdUcijC8LS9ZvRq8kEKXPKKicJ9bMMK1bQ12OR3lg/woWwKFd5qMOmv0CdwlsIy7CRkBZq1OlR6JK6osKmolcG/eW+/tsxCcZaqVhWiPAA50zZ502h56RN84tbAkDIGM7A+xwxIq1WbHFqwTGqDrE3XqloUN/auhvoOU5FfdhaguS7OqeFmM6b3DnxevFCJsDRwqVWbqqAesbh2NWIT9r+OlwvbmOQJJ7uGuf+LeJMzQ/v4SiISWmQQYtuHzho7vmlpeBFVv3Gh9izKfnlnRWesakCzS82DWuxJVzwJDZtRx2nE5xRzGjnwZYY8MjPJ+ZqDoAIdR+OT8uzRmwBTVp926MwOvaDvTCsOaj/Iicba/j1Pu1zyPtbMqxH3H+Si82xGdbqAXKJlr9BnyRgL7jg5sQxjonfjTkDoQZasZ0l5nnznSfYwZfQ9CL9xaHHpo5Qt6FYLg8yi5p+8as54izkUDyCfjiW2Uz1Fj/kfCm6hUsH08E854rXJtocLH0PBxVAc/sZk92h/LT/CmV8E5jX8CK8qQ5jsjJwZC4qTqAGXBGYBr7msOlRY8hKOfny/gKNzMjygaRXLgyT4q+SSxbRKGkA/fl+woNJLLJGExBIjQTBT8bboCFmoZuN2mojGlYJdwc2Zt+5/URe3GEnsldzTLzcVeyXpHreAhhSrwSQtwR35Efsdj41kmt6eNNXZSpE/+4SmffYJnvPZVyTkz95q1YIOPMZ4ut7eFdzFb9y5VjSRkmJBklEEKSTTjOT0ZCKh9kRzguLS0CgLxMVzBxNWj5aBmrLPJGZ4Q+Qawxk65wSko5khnVDuJXu2Sh48pSEm1BzhfmcbjxlsVsIeRdXn+cNXKWkNdKViV6F9xkSrVc18I4/4iNqZwfMukzOi9NPxDBQ8YUZFVgM3yYlG+x8zC5iSuRpqbsUFGGgdOHTHapr156wSkNXn7zDc3qPBHQuB8aNRdWfxvR3gUslbM2SjiyQW2PgWIRLvxBDvHeqjDkSz9EHDZQ1bSzNERSohFCKqtOqw+YRDPJhiUyF8fg9Md8ye4Qto/2jXJ5PUWlx4jXn+OvIcq3ahgbjBGRbmPD6ehJd/8MLdXbOZSFX/Yyw429acpdVw2WdUg9koh+LCa1EKe/P3yWTD/cjQux3SkwEscTRBffDkoSunTTyqrsfUz08jPrYncqBSmhWgbd83ysiUPAnvQGE1XGB9tn2m/mfBW6Zrpjze0SiSZ6PKy4098vhaDcebxb3oX6BrId+GKrqx0hpPZ3dFhPIXxliwx50Zi4ZC6dIwDbXgR1EjHtxPPN5Y7c2KpLnNOSlGSfoyKntwIEuxRNhomnpFkj+s7/sNi+QpCBFRXVcTl6jAWHbm5twXu8OKoC87Uwwhn0ydHiYtOso82g1ZzySOSxuWePKj7c+44/BTvH1POJfxEyWsjcTPVsYjw14LZcmLTYsSoclx9kUo0e6/JQ95yvNmZDAzEQoMPeHZuVuWIyoOLLnoH0artoUyNx5lkw7vrH6k26tbe/WH7MOPEGX0yFi3t55OokDFCo54qHzFrKNWvMwLnXlg9Pf7Ew9Yd3Hw8Mk3iaM934zwHqW8vnRS7Do1F31CayCQhb61FkpStO+dhXiJHYDBb/EMas+gJUwPdmiH0onlSfXJpwpvItosnougIV3MxurJhW2ZsRp4Q9LeU1R/lj2gZyFVwh8pTkLDldc1rl7gDG0sW73Oot2ce7KB4silxPh4JpKaV4ZxWTLDm9anS0RPibst88L7BKxoQHWEa09PYq0Wxy6UyjiNoN+nMgxPPYvQuHKjS/RoJNp8NZXSjTtR3nhAltPM5HWpr1C9B0f+iLYegyVSRJxlKV95SEaa07q5GYbLtbqgEGWdu97uVX4pHfAKwylTb+0dK4/nM5mWF6GaZClEUENhx/d69FPrkiz3UL5kyz5Sz+iUZryu3NXsjEjwvSTySbj3UQ9j0fl9WPJURM+SqSR6knwC+krvyz35uCdMvb8Jr3lfjhlVGnqFHsMvIiGjiX1Qp5MBgq6qNLA3SH1ZNqK8BXWwpqrOtgEt+WT4nqHXJP4pJb7nnmEosS0MaJJFtydppwtBZBpxJMEjZwJFP5PDM7GGY2/hAY7AvCZwTOo0qyePLOi+iKJp+xdeor2s03Y8EbAFOjm5wwLtfrnRgacSuiZBPAddk5BnYUTjZ6Ea0HigVBrKuesww+Ixsg32wMGXuRddrjlvFnzHoAavjf24SRMXIssJIQoRUowEQZ6gqZBERYuuCDH12sYXR9jhQ8afGOO5DDRcWlGgjwohsk4KzMvlO9XuptqRkmofXeEpxtqRpOlqu5OddaTMNgCPRLtZgEdCigl4ODAxW7S0c+uQe0OhY00f4bSY07uUZKrFkYaqeSzoBkrBLdANnhlv4WcUOc/hZzRZlvU7fjS7UiOfxB7xj2KP8MfZiR71kjCA32FS8AkLZmADBTNt6nu2GXDTv/Ecbdcmq+edvUTeHD5FXl6ZOBNavLwy5fUIWhk5qFadBc1M4O3LpnBdvvvHRdKXYeoXD7r92YaHHXbNdQWUFHZ3gtlpnG5x2+lQv1PoQYCGlKoryLfT6DIyiD3wGahiF4IU/FKr+UsPXrUFqajWrCAZzQNYCwms4LokzI66gmaFYLUI1eJ7Fhjd8I/JtUuyjjqCPVxKDzxkm/GCh241XtDQKbL8wg3/EE3XZG6NI8uwDHdgIuy0Q+U11pTfWHGG4s10az/H0+U7sphC4a0dDVjQWSEDDVitGFwK0rumO5piq2mEzFynQu7k21kx0dfqkiljtS6JyCR7LLeBZYflNjBRlpuSwio9PQe/8PquV6nTE+CcSQCVta3N4L42lhdvrIQWP1OPJLT5fmQh36BiTIEaQmSudbZtMhWi1sYzKkThjWdU69zcZCpEYaFnVOvc6mSqdV55MhWi6MKCKsvksGDINTrQM9hFGMjmnDUPzaLzwJP1wOmBaw/1dGej7C8PDJQDjaO4tLI3aQzPZ6pOmDMl+vFuitdwf/x94oUN5ArYFmvJLS0m1AGH/M0+NHUQGqaB1+vDbgJZq3zCDEL/PZJmbHZwyvfMLpFwJ6KVSpjwOSUT7iT7lD4Y+bJrCISIkLUE4hSU742rLZDeo9wX56haA0gqIk2mmXA3+2C2EO9lEdyuLfB8DeRXGdikou33mVUHIjTY6gMRGlwVghVJTjWCiYxalWBGQNSZhCoFI5ZarWDC5wYJZzvw0fUIHoDYugSPcFJ9gokElah/gSIk7A/gCYn7AyzIBP5BBnQi/zvLHgn9l2w7PbLOSfC/4NghuGGPhP+PXHQ/+h4FAOJcGdpkt4IAzxh36eOOnduxV/mbwg6FA5Zc2eaXfQsJrFkzCwo8EGYWFnjGRjtBZhca2CDK6NEua2iX1bPPutlnxeyRq4RWoGBCZxYquPOQc7NOFHk5WkM0+6xPSs7WEDhoIXn6hcgZVkc4MdPqI5wW/apPxcu3b5/ZH8K6i3GGfWfFRLbuPDDRbTva3gMmKeYvhzfw3vmchJFl91Q+Nl7qP2l+RDaqRHd9xNIu0yNac1mqhvpaeHooHH/1mjIKx9C/oc7sRh88QHqvPHHk9mHYl3aicYPBPh64c6i2qylPRO8MWh1jqRuf6b2JhPC2cZe37Otn7OhueKWUm9NjDCocjJNlbB94rmgyQx3755V9wtRB56G30z0euk8c9NbF+DiwN4yOhYF3ohvfoWbTtW92x855tty+EfcLMD7onBSzZxpe1zl+vNyb1BxPSHO6hhMDAHIvcnN81jgyM7XucWvayRJgdJGVh85YniFXlmdOguWy5Lr09drGwhx5+ekDlLmpy8zNWGi8EyvbsWuLlvHiLePePjBIda2hrIa3wJQbsyMar7lH0tNYxzC/4dJE2L+rFDJzSrLe4jkC2nsmh2zNObPz2uRYchzDs1tcAkOvkOjf0Nxyv2Gnq3wGtx68u4Nyl3UcVyFLd48qeE2wlswZSI6ueaw1MYvGfVPJtXrOtqdcq6XtpIQ6yw3dlXvoG8cyvsGgbgmOI2vBdNI7FqhPyru2zXkv5Wth0U7CPmWQD3T0uRG9JzZcNvPJEC5C266Pvr9v/P59OeVdSpyNOMwHvn1Yuo40Nqpt/aLZidbxq2uyOHO/ZPGGPQ83hjLYq22JZ8p8Q2++gTfbsEs16OYYcvEGXIrhlmawzTPU0g20WYbZHIMs1RCbaYDNMbzSDa5ZhlaKgTXLsEo3qOYZUrMNqFTDaY7BNMdQijeQ7mQY3ckguo8hdB8DKNHwORbJwKIIZlIj6jfG2yFqByElVLMq1ZxKNKNmm0+pZlOiuTTbTJpjHs00iwbhJnJLirMQjarG1L4iADOgBa9jB7H44Ce8qCS3ncYsvRE7VXyUyjJ+CplBkCRHOClaV3BpiUbcUFDB50RFtipa1MM7j6kFSFpfVdPqQePh7sAOrY5/QBF5tbTZ4QEYzt27iWy7Yy0K9ga3jV05lSGiEzbhQ+XyyGeKL6oBh9yMKK4NsktjR1cG3oUREzOU46LkNnDKpjsssh0VdAdFnmOC7pAgOyLwDgi644HucKA7GsgOBrpjge5QyHEk0B0IZMeBhaat3X6JMRHak3VCXgNOp3pY0OoTh4gGjOXNOhY/Mvvj7+/F8u9JW35BXtkznCnjARBjhst3vOzgcMlwtOQ5WHIcK2SHCtGRQnSgEBwnDoJDZLtYLqK1UHOfmCLZhILyx0Qb3scLk+d9SfK6xEaA9bVcmivXgfKJ3FrEtjGwrBN7k2gsSN5nwbcRpRc4+YaxgdeuaVjcKl4SjG85kj/aAj2ztxA6P7N+0NGkwV8+nOwN6fG0CpwdYsP3yxz76gXvHLxybtwhtRF/8sEq7JOhprZIdCoSnIlkJ+KHP0jNt8Ax/41oWaI8rTlw4tDjB0miNp8kiP8fAAD//4qByOE="
+	return "eJy0fc2S6ziu5v48heP2YjZdFfnjk5VZi1n1dEwtZqYXvZgdg5ZgmZUSySQpO11PfwOUZMs2ZQugum5F3ehz/H0EQRAiQRD828x/fvxt9e+d8qutqmGl/KoCDU4GKH9d/cOstAmrxpRqe/z1x4B49M+PX1afcPx9pSFsa3P4sVoFFWr4ffVf/xfCP2tz+K8fq1UJvnDKBmX076v/+WO1Wq3+qaAu/WrrTLPqf7mSulz98a9//vH/V0jlf/2xWm3jz36PkF9WWjYwbgr/Lxwt/L6qnGlt/yeJ1h62+Gv/s3F74zaxldMfDo1+wvFgXDn684mm8d9/7yDCVmZ7at5BYVzZq2cD5WpzXAUcH9iDDr/+uBEDvq1xAdyI+bb/DwT5PxBkKYNcOahx6FfBrMIOTtyrEvaqgFXYyXA2kE6uTuBBWSmFjaWVZenA+4u/m9bdA7Hx3//Vi/g/PBrBwbjPoY2V0qs//vU7/vVqa1wjx9q7kKkCHcQ9yZSlCfXHv05CmG3UpI+DG1v6+0qi1lAuKFFI/HvUfuVkMyGiN60rQKhr5XTi1UZXNAH/38aD20v861VpGomq+geO+mGnit14YFcbQHo/JVi7EbFXy8n2xz8ulebbzS+xibvml5QuqAZ8kM318HWilTIATbR/qwaiS0IoStlNkInWW4vti0bVtfILKed/m0NEXU5P60wB3q920q82AHrlWq2Vrv6O5tW1D4XR5dQo7sF5ZXTa9nWAChxNzMGb9cSr1sN4jIZ25cabug0gwDlz68FK025qSMC6uSWsMbXYqWonws6B35m6/JFU8X2G2hwyCFwQjbRW6SpXlBHTYiJZcKL14JiybQthnQmmMPWPKcO4ixJI9GPK099CtdHHRv0VPZPY1rLyhHYvwAGKnVZfLRAIrK1V0bW9ab3S4P0vDmrYS13AXJ2NSAoZoDLuSNXCiGI00XgEcRmQIcAuBCtap4QPMigfVHE7JH5nXJhD48F1vpxDoUoOit9z326WGMHg5Haril+KWno/14hcEEWt8Lvar2pE9y2R36ppm1wWpRdg8QwGK4tPCCQtmFYHgatS4cBboz3Q4RoOojBaQ4EjQsfzWz4hxU75YHCJJzYtKuF5Qa6XBbleF+RaL8j1c0GutwW5fmNwBSe1b5T3LGuMaEk25UxHkulBMlzHoHuu5Fd4pbPwNNlxmwUuV/dJFqUXYGH1JndA0jRKL0HD6RD9qxRMkHW2HpIsSi/AQtNCJ8fIreR16JZI6WWICN3yHppNDaXYOlk1uHiJznMuvC1VjwA3F7MHJysQuL1w0jm1xy6oBmbiN5UVJfigdLf8lF7ottnMbh/xGr4xkvWnLLDHbIadsULZ/ToRFOu3UPYx+o2Mtg72edL3cTIOdC9rVapwjPscmLvT2CiMS4tSuW5tOR+nS4aKlS67WRE3+fifW9zUtjzuP1KbqLROup/Ttj0Ro7bDzgd0pfRV1PKuVgpT13DhOM6hqxsOjMFNUhgnCnChkwWI7RtHH5oL6BsTyh5a0zRGY9DFYqeBMsxGb1UJugBRwx7q2UE43E9x1KR1RjeHLRyuIEXZun6jPmEhkz0eWMYfGIrGdHCy+CRAbCssOHTKz818DZ9RP3koPxuF5w9icwxA+kxGVK30J35lcdlBc4I3cPUXzLeGa3TyPO4+ujvi8MJBreRG1Socbxg2xtQgdYIB6iBFdP8knY0+8eTpM14fRLB1sFXfOVhRg67CbvaYXbLQPN2VCG+9CDnYDPEbWUxKP/mJGxNw/VipKvBB7KTfib2sW5hrOhh+1UW/MBVme7FeVHayNzy6/XppwrdswuEg1C7HtF8vyEXuofZCtmFnnMLw+h5mG7L2oqCtzkqdXBdMm6n2ovkW8F3spK6IDTXfcXKDg9TRyd02tS+J607smf4W3Uk2RYM2OE5bXwyIo36gtBdfLbjjaWtP6Zhz5I+x9sIbKeDbKgcE80UQbYc/oBxs8TySjAruSMR4cErWNFDDMAxvpOPA3J76CRlQThmXWrY8RAbpKmC0eABV7Yi4ECi6D99B4OKOoEMTnr9E0fpgGnCiBEVYxl1jcxcIl3yTAzQ1DS/h/ZdlCUn2tUzucqZHLuIZyuT1GWOxCr/I2gc8b0/JOmE0afxCYgRZ0Rg4in6NYScvZK0qHcOVMSXGz9fAwFBg6BHQ/WM0mMtSwhacg1JwjqpGPPCNiUlqj/tKjOXwWLaFZ/ek3xwaI/CnDAIcNqdlHSehgwLUnq/XC7Jet/yxjsfiOYpt2jooW49Iup08g8orXS1D9AUigOdrxR+bjamJcGeshVLU8gjuRZgiQBDdDp+0uU/RdKciHJpcMXLb7w7GMgToCRgS+HjOjYkzQukSvmfioMKNnNg4I8tC+sCXoGeKE3YrC2DC0qv+u1i7O3pVyPpMQsO3Wi3S9b3bzv4Egy7c0Qb8YGDenalNdZy/UiEfFPSApG6nIGEHTkMQO5AlOGLc6IS28lgbWU7BJ7/xJ4JuOPjwVJenYXEzxzwE7ZKb6VbYwYa8UXGbXHxf0x3cF8EKHxzIhrSW6uGj0FwvB619PDjGyG4XIebMoYGmAe/xWDiDguvGewLWUdgJezdApux95BsPyQ2sdgSYit1nxKdMZ0pX/cKsF1g4kHUzV1tb5eAg61rEWzoEFC5zcD8ttNECGhuOg9s+HSl7Gt0NEe1UpZOph26k1uDm+/GYsC2kLoWXja3Bzdd/PEfBXI99l8JiWsK4d+AQnNq0ATwRSD6871DD6WOjCmemDqnvdHZEcOeU+w4B6LJfmnFFQIa72PQZ+whLPp8/YbXU3GYdSE8cLYQxW/NHL1pLyeSJUJrlq7Lm2v0nHHGJjN7dOEqbtdxAHb00BRVnNrpYlLZbHOxlzWfwVhZKVyQCwCyN4RNN351cknA3WZcsvcfMojFOyLrCeO6uIRqBD5h/l+cNOg6uPxjQPI/QoZk+oQPzgbwJHuSmBrGtW7/rPvv0Ye8oLMhPGta4g3QlTkKMeLR+tisctgHpm1WPUJgn1K8jU1/LCWEHtNluPeXAYesARAONmX3asz2ITS2LT9NGa/DzYTELqmox4Ek5kEckRqxcIXw1e/QOvbmk863mgAjLqoPAlND0snRa9SOUkHVgNIZH/wVh5XgQO1lvhbGgaXNhDMRLjhycS22MJnGN/BYePCXkuj0I19ZA0odvm0a6o7CfRM9yEH8ZDcJKRVl/j1HJE8w0rqrNZrRryroKWzkQnzB3tsf0mT6VxrTBtmH+OVXExk/ARM7A5NSISKVVUBI/dI7kLzqwPQWRJlziPHByX3cH2ulIOMzlwMsKfKzSFOxpicRq+Ro9v228t1oYHUBPRM0mJ1+8qzrEbabCV/fR3RZF2J2THsjYrxbPP3bGBya0gbAzJRM8kRlwH9wtRERhSiBMpuFSsEhfCp5sUhUNDm4Zc67WcxcyF6g3Egp/cafByR7egt84YHIvB9T8Xm7vXGJIzzC1FUqPj1o+Z9+h6qCl8oV0JQ1EOtbr2omnnDwR4+aQBml5LbX6U5tDTOIPhgSdfzqmtgLXxtwhQyx9zBBFHTTEcEcNsdRhQwxn3KwzjfJFa1ovmpTrm0R6CzB3XaS2U/u8yd8nP3gTv64am/79lKuotMGd0iiFnnE4MbD0R+VDmkYmDfeoZKDJxfdhIAaB7mpUYUQZaoiHdVHBpJFJkXAKiKSIVEn4cqXwyWN8EgUtszLF0K1BN1DNXkdOs4AuMzg8NFJTqqmkSFqtgqfodLH0iIGKejp8g0u7nvvg0achV352soXSC2VbKE1Pt+g3oCIUlhbL64B415D2iTzBaHflz6PMdEMDnDrzA7gGSoX5ceSjYKVzjoKVFfEabLxj0qWkEqCl2nawbrNhjdKBAGfltpxxvQ0Tbw6onFvjV2BawsAoGjLR5YkRPuPYXbaYClomb7dMgzwUwls1X8y72ULTqFAThCIm6OBeVKgdoYX9WhhLKVcTm3CmDZjHXMw0hf0bRp9BY1y2N+jZ7Y0+KbO79a0kbn4PPp3mMAdG9GoI7SqxUhvsUZz28IKa9gdwDGB0hOAZyHjziYEbtiPfgQr2XcUJhoZ8EAUWjuBica3gjunQ2Xw4s/VaBhXaRMvb2shJc0Kg0RUP6aDCKcrsb4/WdHSfoyoKZXfgmGDMtphwx9PL7jFBcrl7v+0YFd4ZT94AncCtUywYJzSLaNV4JXy7wTVfqgzLfXT9m5DWpnzchPsegRgawlsUuph77BQhWKiGmzdyImCnjEQG7wqmw0Ik12FFLN9hjeHM1lkOC9vlOSxE8h3WCM3oLpbrlWHixME+BL2RQTTfVOM50bCAptjvRciPbv4XcPp+u4dzp28CjsVocLf31UoHnsOT2YsOniOGhyHFh4S73D2lb+1OrEuTBNQcoSQJLeG5xhJVeAxF6LuphLGzO2oO4EShRK0addu3qYo8jXSfM+XBhJSN2gjQwanZI4+oHnEqE06B9vc0SFl32OYpsZaRaXiFJ+caXuDp2YYXcAa0T4XzwoKO6XuYJhALFc5dfSDNYO4kMx8lLTHs5LyUI6Cw5qPgxTAGdO+dMhgIoZAGpO5v/czPB4ugfkQIaWRDdklT/hTFDorPVGnLSTk7rC+MhfmgAI51M6aB4IyAfZGCTC4OzqhwJEipsOS6Da0bCplST0kiA2b9fAd6/cExmLZeQ2S8p0pOxUdkY8q2poZnEGg2f0LBPOMb4YfEXnAUTfVgttT+qIP8ZkFj0p3YpA6/HgvcgWnFXW/glWwr4IIHL30Nn/beNwzTBbCUnYHvlp0+uNTVi7kqNKpkY+PsDqr49FwlttqrSs9OokA8vuhwx9angJq52FFaXFyNoC93rhnoC55LBsaS55KADM5ZBCiduwhQmrwIMBtVQwxgETxTB2q88iXFAxutgsEpeLpHdV7YUlWd4BpZDZXN1r6/H+YDljotwc7X+jWYNmbX6H5bN3sAJ/DPT7kML7kEr7kE61yCn7kEb7kEv+USvOcSfJAIWOfLF0jeCXOkCMb2PYBvy0SST+Rv8W85eFaZ1iuOi/FjclC+AVdIygYGoXuru9Nr0ZUdrVrlUydOkxyY1tOrnLJ7seR0oA4yZBNh+E54+Goxq4FWQb8jGjbdQzSRGKKIHH2FLSeC+QQ9t/lTQpiD88tbW1nM34dp2d9cm6s7BCjtVQnC7wtVzu9ojyRWuUOUcaqK1Ql1xctOiiRt4EodX1wkSRwRtC0iNvTVmoC1SAuAEsqJgZluFRf6E5ubu82ebqyRW6REOTQEX0hcZRayfxiNfs1oIHFCShlPaolaPuPJx6a3WNqBbQKvdCFdX3eT5HkuuQI0Fs9zszqEWbW5vTo5obgIb+2pXsgiZIS76meiQhY7EA5K5QarG72FUBhHcUYzWVkvLIzIO0IXAh066tpOKh3TEyknbHeoVEmd6hckHEW0zqEmalXEup6F0b5tgE5UbkRRB042yQUHLhU20vPnWbkRtamUnlivzOjGqcBYUoSH41Fu8KIl/StxTcBx/D3YW9q24hZOuhY0Bc/TQUtPZDzjY3iMZwExQal3ODHi0XsZ0GW6lMcMcaY4ow9fhNWBxkTrpYTs6ZaQj3VT+oqku0zPtobhFvLwIsJf6Q8mhakw5lPlCbM17iC2G56BngjqDALaRfAEAfU6eILCFfssJSA+RwfdWxIuqw+tq/PwjHTIBM1eySz8d1//BwtgGZdB5XNt2+fathe1KXJnuc80Tp9pnF54CPmeZkzzwufpFhXMvkwvKabDAicKVUjcrAyn2VaGHacbAw05UeGG4rRt4n6QrnnwVtnwOETya0sV7IIw9eWdqXZ8oUdjsLZ8Zsk0wsdX4Hwmy0sufhEpXnPxi0ixzsUvIsXPXHyOFN1iNWvPOeJRdtY5RBJby1YXqVj83InWdSXGT10wEym7DLKD0qU5ECPmSbrkCp5EEQUqoZZzsxInSf5UYX6OzSRLf+f4/HB+psb70Qu5crnv7EkRefwCsoQsWc6xqclHc2ZquL9p2D24JbRhyZO5j8Qu8YIKiIxuim/99W/9sBDvFd2w9M/bZ7FEbycaQD+ufMMdVA3hYNynaC3m+UxHL+bIdMV1J3Qxh407c3rl5lhYT7HMp2BMtsCnoKPL9OMjkgw/PmJZzo+PSPMsIM//nhPqMybEQFK1qqQHkscMePk5OnKfx+OBfcY0pulneCy2g+kvS5D1Fy9YVDlnpMO5S3Jx9VC58WtmMWefe8p2ZmCBv0cHRykv9ZDBq+GrNhHneKgDZ1r8bDjFtKzeQ4aw3fCxjCCPP/raVMMzLhzb6RmozxHeEOCE9EE2ltyHzFPYvjThy29PfOgzH/rCh77yoWs+9Ccf+saH/saHvvOhH2zoO9+a3vnW9M63pne+Nb3zremdb03vfGt651vTO9+a3vnW9MG3pg++NX3wremDb00ffGv64FvTB9+aPvjW9MG3pg+2Nb0+sa3p9YltTa9PbGt6fWJb0+sT25pen9jW9PrEtqbXJ7Y1vT6xren1iW9Nz098KN+anvnW9My3pme+NT3zren5jbMwP6H5BvXMN6jnjxyZX544sZMTmm9WL3yzennNknmdhf6ZhX7LQvPt6+U9q+GPHPRrlom9Pmeh+Vb2+pozr17XWWi+C3t940P59vXK91+vH2zo+okP5XuuNd+m1q986JoP5VvTmm9Na741rbO81fojZ+r9fMpCP2ehX3L6/ZNvXD/5xvWTb1w/+cb1k29cb6/0yOmAfc/A8sMDr/zN65q/K1vzd2Vr/kpl/fLBVvH69SUDyx/aNX/mrd/mK/kwzq+gFxzsaqvHetakF51uHgInNWqw1kd8KdQc+tIbLHyiTiGLJ5ugVwKdwWzw6KnL0ewTU1TJx9JOIMcE8TEAXtsdlHJ4OUbjwROnfE2Cg1zA5oaDXsLmhoIDH16bZtgPs9BodoVR7pTJrilqrPwaakz52QbXXW2v+1pL4OiVK5IUbzwK9HteFKaxNYTZ9yyv4P2zKVy4deDn30I/gRNOZ77T8GKrdAVOWJd6emTaUVFLURtPv/NuX/CGd7HTpjbVkYDjVtpmfzSsLGOBT9oc6EvGUDoWAbGip7FHYjt4UaQKu4la0VMbGGtqVRzFl+nfdzg9CC52Cpx0xe44V0lnpq8WWiC9ZTgSI4JLZ6xnYmntOkKZ5Pjr6efCJneJI5xuG4H/07PQMb+SiQRLTEK0Xeos1rzpLKKRxaT7nTbqyGLC85coWh9MA07sa5n0Yg9EiSQ8bMY7TgM+5zGngYP+zFBEXiy7GX7vhoPhApGjKeQ1E1OaBFOWTAsIs4AU/C/TDQdXDnahsQjX0l5O+ZhrFt0I/oc46SKbN60rIJfoUiryWnKK5Y3H0veJL8aZgCFBlq1nWXmefedZdq8y/jeI/uFwat9lKQdw1ilPrKIWr7w6vCJKWRT3oFiOZepL9RA91H8oZIDKJMvDP+To7/6okthdfLYtPrjoAmWe92jcK4kN7OReUW7DD/Cq8gxlZ9TMuKDYYt1YUhGYC3gtddXKits6/fL9BZxck+EK/dWCD+TiFEkS+lbyksVbg+n91MvvFyyk8hKXSEZhiYEgWan4vukoXZjmzu50Jrq/zsOF+520+P9JG7cpkqlbcg9HTukc34MTqC+xmizSouwM7BsdO1Fp+r7STBsqwx72E5o37Cd4zrDfkLCH3TpjwYUjfb59GTib3/DlmvukWJJEaT7JSSPwvQAJVxInA12RDhoTgDl5zmDG7HG6oFtMf4cIG6MFOscErKWZZ61Qzk/23Hvy4CENs6DmAMc7d6dlhhg9YU+i6ur83enG1A7VQ1sacVAOb95hjLQWsZFr/ITbGcHzNpMjIsVpPbnCnDRZfFXK6JnNdD8W+GDO/DFxgM8w7kGAc4lF99SbZt06Cw+ySZ+gM4z27XGAy0IQcuNN3Qa6tD1cG31s+mp0E4UF7oxFiiQeYqivFhhEo/JB/WaN+pZmior1hFCKqHKmtQsIpMo8fL4EWEQ6V8N7cPHSLn5YpcPwQE25/jrwbCp7EYiSnhZYGPPEfBJZ/ikL3O9nM7Fe/Z9ieWOzWHySd5Fe9WGjHIq9rFWJL+zifhLmfq0GhniqlrL/WTieoV6d5JFfTjvxmLruM50eZ3zcV2RPZZwowOF3v5CB0zOjt7hyKDDOsoea7P5HyVuYDTHU8RbcXuXVxh54sABzrPhDioteoGulP/tXdKee1nio3Rsi0nnhFAt1uTNiwRoYrsQaI7WSG1UTLtyfeGKwOoYVWbrNWSlOknTPqCzBMRVufKiVCzaep74hye/W23LdYp0UpIi4R1cnLlVhZHQn/a577I5qgvHZmaJP+cQT7LF0d94OzKPdr/9TxG+LEfdfd2WXZ9yv/wOc7J5f5k+UoMgz45phqYlyyUstizJB02tsScnIx3+XPGyV52mkDzMxHhh6wLOwWEESXhy45OIPjDPWQpmbj3KXjn5YfU23lFhLycM+w58gypDIB1w1b7eqIOUKDnio0DuLjTOyzEuduWJEy3NbWUAmPL3yncVhd0ev4olSniytVouqZu+2ZA8EunBHG6BkZfueWZib2B6YHItH0LADp+F0YYe3KD2xXCbq0h3eieju47kEmpRKHsP715OLgOXlHciG5b1PWf1Z8YCOhfUS/kCxVQ4Osq4nHol7MLhb5TAp7Pp2Fi3T9pKsp9hgsW5HV0kMaQuJpRUl3ndwDJXgbhljGfvu4X7TBkZnIkkITm3aVCnjeQTdhzMj8BR3/qVytOdGL9FDkOjurak5YpyJ8vqDhTMHzeSJhEzLcOT36N5lsJkUE4dlc0a5L0aa07o/ekGrtXpBoco6d76dX/nlCBAdBjpTFOL08ng+k7eySL1JNocIMGw83Nfjr1ovyXIX5ZdsvcNehM44IWtM2Qw7QoHvSyK8JLKQexgenV+GJc9FdCyZTqIjySfgz/TuuSecZRP5iQ/wTnbPMaePrh95ugE9pF9MpIzOlMFstx4C3U4rB+ITjsRmYwS1j6aaNtg2ULsfGeIwdlcp6ZJHhu4mKBqzY0V0OxJ7elR+Qo00kuSScwZFp8n+mlgjv/M5lOZwnNxpliTXLGRZVNHY7oYXxoCpBnaBfmOh8Zd3BHhoobckbzkkbC0MaLoWqh5NB2rjoBwfHWZEPAa2Ph7Yn2UuRZcbzhsl3wmoIXrj2G+W4lJkOSlEKelUyTDDFE8yVMii4mVXpJg6nxUfR1hgIKevGNO5PDRSB1WQlwopMnxL3XN0v3ioVumrYGsuPu1x5pE0bR0WirMqvVAAWOmFI8BK80PA/YJJhMLy1q0dQTDkXNNrOC/n9GwlmW5xoOF6HnxiEkqsQsIPeGbchR9R5FyHH9FkRdbP+N662ZlPaon8xyuSNy7JsKKfUMrD0R3wCyiFXrBgBPZQCG8VvQN3zzceo0PNEHbqzuFD5H4t1I7R4n4tTPQj/nEXb6HOtFgSyRdE49q/xctFGkv19JOH3P7og0ftdi1dNTwdxvoKXazG+RG3Cxr+N7GnyQ0nJmhYpbqSfAv1LqOC2BWfhyEExcJferW42aO7tiQVN5qVJOOdANZ4xl1IVzK0Yw54mqpErVJv8T1KjG7k9+lolxUdRYLTSUhGxPiKhx0zvuDhR40vaPgUg3WwrKKR36ppm8xP48DST8MFmBhf2v7lNdGUP0Wxg+LTtw19+g4svjD0aEcDAVxWykADwRkB+yIFnSH8gObEahqlM+ep0gud7TRKL3S+c8WUMVsvidgkS0w3pZeabkqzp5vRKhh3ug6ON9ZOfpWrngTnyAK4rFi5qju+9kEWn6IEG3a5JDx9X7P033TGVJ9gen5ajutlOarX5ajWy1H9XI7qbTmq35ajel+O6oNJlRVyuGC4XAYzxWFVsJtgYIdzbnneluDJuuB0xXUx9tm65nxfrhg4Cxqk2FvdhTT66zNVq/yOk/14DsU7OF/+3kq8eko0ayzUx8oJRWBfvxm7MhiNcCDrhkNmTSyYwZA/InnBZuwFZ4Ga/UTCmYj3VMIJn/Nkwpmkf3EgEQzh8CXKefOGJ+MtgWkKzniPWfpb4uwdRYKLvae44eLvKm6omDSZYcLF4oPZRrxURPD+2wKP50DqiQDexz5J9ZZHxXt1YIKG+vrABA3tFYIbkoT7orsd7qsEIwKmz2S8UjBgua8VnPC5ScL9R4w/a/sVOkd01rsE13DW+wQnElKh/gsUo2B/As8o3J9gIRbwTzKQC/mfWZYo6H/JttAl65wC/xccCyQ3LFHw/5qLf46+xAMA01wZ3uRcsn5J6RZ5GCDBuKBwC0rV+/RFxMr/PuQ9IHBBs9hDAresfW2IhQgvpWQvH6fY3vLY+r7mi3UmypBokTm0yOxZZt4sM2OWqFXCe6DghM58qODMw67NeqLIq9Gaollmfo4IVY40yQjJwxFiV1gd4MxKq9dwXvar2xavP38+iT9VwI1xRnznhokd3bli4sd2XDgnTCYH94HFI97juwW6mJx2D+3jzk39B80PyMaUwMXyNtMD2kldmuZ0FExU/+mi8PSt1zm9wDA7hluyxYgkvPvKJ45cGfrv0kI02Blgcxjb1pwromcGZzZTpRsf+b0TSXK/O1OAnLvslyQsTUbvllvToz/hG4KT/QV2z3A0mamO3fVKFOXk88if0yUuup84+K2r4XIgTnnfx/hhKbrhHmo2nf0MCwoX2XJlY34vwMekc1bOnm+wQk8/izhL9dyd1BjPKHN6C2cmAORu5Mb4rH5kVmpdYte0UCTAuyKrDp0PMsOugsxUQpC6xIRxfK+t3yVl1qdPUF7G8Bk6PvoAuEhUIftgFy8RYtoLf9/eM2hzqKGsuqutrB0zEg3b3A3raiwyjHe4PBNGlq3SmSrpcy14ExpF4N1nQqT1u0zhnc+J5ITi8S5uBkPnkPhj6I+5Y9i6Kp8B50NMCAHH68dB6RL3UYWsIY+BddA1zrVmVtE4f1Ryo56jz1Nu1DK0WkOddQzdlkv4G2QZ7mBwPwnI0X/BmUJo7AD7Snlrbc59qfgWFm8lHEsGxUTHWBsRezHxbOaDLuyVCy3m4ITRh38r05uyx2JNsjG7ecW3DEvbsvrGja3vndjyBD9gk8UOq3gmkzcfjG2Ee69KRsvBMteU+YHe/ABvdmCXG9DNCeQOwdD548wJ3A4YmkHmBWr5AdqswGxOQPaE5bbZxxky4ZRw4RlLDbhmBVo5AdaswCo/oJoXSM0OoHIDpzkB05xA6QlLby0vMLpQQHSZQOgyAdATC9Hv9o9kUFGMMKnvq1z7WDqA9GJoB+23/kMGAAkb5zTueqm4Bx/bu9g+DlmSInbXWMrywKv6E5/r7BKjCBORG7nmRqyZkepbGDGyy41MMyPStzCevKyFco8dbe1JO9gk3E9sRKdZmHFr7+v4CCPmtmJRtonpN935E15VWobWAQN7elQTL1jJbQCXS7KBreGJQqv8NOD6Nyti8Vpiq8qS7jZGTK1A82Q1DV768PQwA7ZrNn9CMXEx7K7APTBdHvku0rabWhVYH/rOwmcuw4RPuAvvH4efGKbpSdXjiN97zukRHjnxHNdyp0X0U6IpMyOdDZX4TswNB/9MKPssiH8GlHf2wz/zYZ/10M94+Gc7/DMd/lkO+wyHf3bDP7PJOavhn9Gwz2YCNLaWIbn9nQZtAxp5DTSfGmHJwNo0RDXgg2zsXO0Pvxdy402NlQvOdXF+IUZFMs6rOmgDjXHHua31a0bK7if/OGyBY7CM46+8Y6+c4y72MRfzeIt5rMU4zkIIDZF98LVXNkAtY7mQ2YGt6XMtRWh4ioOogSuePHTbEnpAPQHbNwfpTg8/njssQ3BkFqUXoQmgZfc2QZjwk3Yu9o2Lpc3iS4LJ+NrUoF2gezfC7PgZ/cZHszq//67lqWihMzXM7r7VjLtI9CPbg5Qe17WN+kv2sfpYonZui8yjXsYRL/to9zuuvcafwJ4CbyfPtKdbDpo5dPjekrjNzzLE/x4AAw/t3g=="
 }
@@ -0,0 +1,327 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package avro
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+	"type": "record", "name": "event", "namespace": "test",
+	"fields": [
+		{"name": "id", "type": "long"},
+		{"name": "ts", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "tag", "type": ["null", "string"]},
+		{"name": "level", "type": {"type": "enum", "name": "level", "symbols": ["INFO", "WARN"]}}
+	]
+}`
+
+var testSync = []byte("0123456789abcdef")
+
+func TestReaderFiles(t *testing.T) {
+	tests := []struct {
+		file    string
+		records int
+		first   map[string]any
+	}{
+		{
+			file:    "testdata/githubsamplecommits.avro",
+			records: 100,
+		},
+		{
+			file:    "testdata/arrayrecordmap.avro",
+			records: 1,
+			first: map[string]any{
+				"array": []any{map[string]any{
+					"a":   int64(42),
+					"b":   []any{"bacon", "tofu"},
+					"map": map[string]any{"arrayrecordmap": map[string]any{"name": []any{"jenny", "jenny"}, "number": int64(8675309)}},
+				}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			f, err := os.Open(test.file)
+			require.NoError(t, err)
+			defer f.Close()
+
+			r, err := NewReader(f)
+			require.NoError(t, err)
+			defer r.Close()
+			var records []any
+			for r.Next() {
+				records = append(records, r.Value())
+			}
+			require.NoError(t, r.Err())
+			require.Len(t, records, test.records)
+			if test.first != nil {
+				assert.Equal(t, test.first, records[0])
+			}
+		})
+	}
+}
+
+func TestReaderCodecs(t *testing.T) {
+	for _, codec := range []string{"null", "deflate", "snappy", "zstandard"} {
+		t.Run(codec, func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(writeTestFile(t, codec, 3, 4)))
+			require.NoError(t, err)
+			defer r.Close()
+
+			var n int64
+			for r.Next() {
+				assert.Equal(t, testRecord(n), r.Value())
+				assert.Equal(t, n/4, r.Block())
+				assert.Equal(t, n%4 == 3, r.EndOfBlock())
+				n++
+			}
+			require.NoError(t, r.Err())
+			assert.Equal(t, int64(12), n)
+		})
+	}
+}
+
+func TestReaderSkip(t *testing.T) {
+	for _, skip := range []int64{0, 3, 4, 5, 11} {
+		t.Run(strconv.FormatInt(skip, 10), func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(writeTestFile(t, "deflate", 3, 4)))
+			require.NoError(t, err)
+			defer r.Close()
+
+			require.NoError(t, r.Skip(skip))
+			n := skip
+			for r.Next() {
+				assert.Equal(t, testRecord(n), r.Value())
+				n++
+			}
+			require.NoError(t, r.Err())
+			assert.Equal(t, int64(12), n)
+		})
+	}
+
+	t.Run("past end", func(t *testing.T) {
+		r, err := NewReader(bytes.NewReader(writeTestFile(t, "null", 1, 4)))
+		require.NoError(t, err)
+		assert.Error(t, r.Skip(5))
+	})
+}
+
+func TestReaderErrors(t *testing.T) {
+	t.Run("not avro", func(t *testing.T) {
+		_, err := NewReader(bytes.NewReader([]byte("PAR1 not an avro file")))
+		assert.ErrorContains(t, err, "not an avro container file")
+	})
+
+	t.Run("unsupported codec", func(t *testing.T) {
+		_, err := NewReader(bytes.NewReader(writeTestFile(t, "bzip2", 0, 0)))
+		assert.ErrorContains(t, err, `unsupported avro codec "bzip2"`)
+	})
+
+	t.Run("corrupt sync marker", func(t *testing.T) {
+		data := writeTestFile(t, "null", 2, 2)
+		data[len(data)-1] ^= 0xff
+		r, err := NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		var n int
+		for r.Next() {
+			n++
+		}
+		assert.Equal(t, 2, n)
+		assert.ErrorContains(t, r.Err(), "invalid sync marker after block 1")
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		data := writeTestFile(t, "null", 1, 2)
+		r, err := NewReader(bytes.NewReader(data[:len(data)-20]))
+		require.NoError(t, err)
+		assert.False(t, r.Next())
+		assert.Error(t, r.Err())
+	})
+}
+
+func TestParseSchemaErrors(t *testing.T) {
+	tests := map[string]struct {
+		schema string
+		err    string
+	}{
+		"decimal scale too large": {
+			`{"type": "bytes", "logicalType": "decimal", "precision": 60, "scale": 39}`,
+			"invalid decimal scale 39",
+		},
+		"negative decimal scale": {
+			`{"type": "fixed", "name": "d", "size": 8, "logicalType": "decimal", "precision": 10, "scale": -1}`,
+			"invalid decimal scale -1",
+		},
+		"record containing itself": {
+			`{"type": "record", "name": "r", "fields": [{"name": "self", "type": "r"}]}`,
+			"record r contains itself",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseSchema([]byte(tc.schema))
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+
+	// Records can reference themselves through unions and collections.
+	_, err := parseSchema([]byte(`{"type": "record", "name": "node", "fields": [
+		{"name": "next", "type": ["null", "node"]},
+		{"name": "children", "type": {"type": "array", "items": "node"}}
+	]}`))
+	assert.NoError(t, err)
+}
+
+func TestDecoderItemCounts(t *testing.T) {
+	tests := map[string]struct {
+		schema string
+		count  int64
+		err    string
+	}{
+		"longs": {
+			`{"type": "array", "items": "long"}`,
+			1 << 40,
+			errShortBuffer.Error(),
+		},
+		"map": {
+			`{"type": "map", "values": "null"}`,
+			1 << 40,
+			errShortBuffer.Error(),
+		},
+		"nulls": {
+			`{"type": "array", "items": "null"}`,
+			math.MaxInt64,
+			"more than 1048576 empty items",
+		},
+		"empty records": {
+			`{"type": "array", "items": {"type": "record", "name": "empty", "fields": []}}`,
+			maxEmptyItems + 1,
+			"more than 1048576 empty items",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := parseSchema([]byte(tc.schema))
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			writeLong(&buf, tc.count)
+			writeLong(&buf, 0)
+			d := decoder{buf: buf.Bytes()}
+			_, err = d.value(s)
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+
+	t.Run("nulls within the limit", func(t *testing.T) {
+		s, err := parseSchema([]byte(`{"type": "array", "items": "null"}`))
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		writeLong(&buf, 3)
+		writeLong(&buf, 0)
+		d := decoder{buf: buf.Bytes()}
+		v, err := d.value(s)
+		require.NoError(t, err)
+		assert.Equal(t, []any{nil, nil, nil}, v)
+	})
+}
+
+func testRecord(n int64) map[string]any {
+	tag := any(nil)
+	if n%2 == 1 {
+		tag = "tag-" + strconv.FormatInt(n, 10)
+	}
+	return map[string]any{
+		"id":    n,
+		"ts":    time.UnixMilli(1_700_000_000_000 + n).UTC(),
+		"tag":   tag,
+		"level": []string{"INFO", "WARN"}[n%2],
+	}
+}
+
+// writeTestFile writes a container file holding blocks of records created by
+// testRecord.
+func writeTestFile(t *testing.T, codec string, blocks, records int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	buf.Write(magic)
+	writeLong(&buf, 2)
+	writeBytes(&buf, []byte("avro.schema"))
+	writeBytes(&buf, []byte(testSchema))
+	writeBytes(&buf, []byte("avro.codec"))
+	writeBytes(&buf, []byte(codec))
+	writeLong(&buf, 0)
+	buf.Write(testSync)
+
+	var n int64
+	for range blocks {
+		var block bytes.Buffer
+		for range records {
+			writeLong(&block, n)
+			writeLong(&block, 1_700_000_000_000+n)
+			if n%2 == 1 {
+				writeLong(&block, 1)
+				writeBytes(&block, []byte("tag-"+strconv.FormatInt(n, 10)))
+			} else {
+				writeLong(&block, 0)
+			}
+			writeLong(&block, n%2)
+			n++
+		}
+		data := compressTestBlock(t, codec, block.Bytes())
+		writeLong(&buf, int64(records))
+		writeLong(&buf, int64(len(data)))
+		buf.Write(data)
+		buf.Write(testSync)
+	}
+	return buf.Bytes()
+}
+
+func compressTestBlock(t *testing.T, codec string, data []byte) []byte {
+	switch codec {
+	case "deflate":
+		var buf bytes.Buffer
+		w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	case "snappy":
+		return binary.BigEndian.AppendUint32(snappy.Encode(nil, data), crc32.ChecksumIEEE(data))
+	case "zstandard":
+		w, err := zstd.NewWriter(nil)
+		require.NoError(t, err)
+		defer w.Close()
+		return w.EncodeAll(data, nil)
+	default:
+		return data
+	}
+}
+
+func writeLong(buf *bytes.Buffer, v int64) {
+	buf.Write(binary.AppendUvarint(nil, uint64(v<<1)^uint64(v>>63))) //nolint:gosec // G115: zig-zag encoding
+}
+
+func writeBytes(buf *bytes.Buffer, b []byte) {
+	writeLong(buf, int64(len(b)))
+	buf.Write(b)
+}
@@ -0,0 +1,232 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package avro
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+)
+
+var errShortBuffer = errors.New("unexpected end of block")
+
+// maxEmptyItems bounds the number of items of arrays whose items may take no
+// space, like arrays of nulls, whose counts can't be checked against the
+// size of the block.
+const maxEmptyItems = 1 << 20
+
+// decoder decodes binary encoded values from a block.
+type decoder struct {
+	buf []byte
+	pos int
+}
+
+func (d *decoder) long() (int64, error) {
+	u, n := binary.Uvarint(d.buf[d.pos:])
+	if n <= 0 {
+		return 0, errShortBuffer
+	}
+	d.pos += n
+	// Zig-zag decoding.
+	return int64(u>>1) ^ -int64(u&1), nil //nolint:gosec // G115: zig-zag decoding
+}
+
+func (d *decoder) bytes(n int64) ([]byte, error) {
+	if n < 0 || n > int64(len(d.buf)-d.pos) {
+		return nil, errShortBuffer
+	}
+	b := d.buf[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+func (d *decoder) lengthPrefixed() ([]byte, error) {
+	n, err := d.long()
+	if err != nil {
+		return nil, err
+	}
+	return d.bytes(n)
+}
+
+// value decodes a value of schema s into a value that can be serialized
+// to JSON.
+func (d *decoder) value(s *schema) (any, error) {
+	switch s.kind {
+	case kindNull:
+		return nil, nil
+	case kindBoolean:
+		b, err := d.bytes(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+	case kindInt, kindLong:
+		v, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		return logicalLong(s, v), nil
+	case kindFloat:
+		b, err := d.bytes(4)
+		if err != nil {
+			return nil, err
+		}
+		return finite(math.Float32frombits(binary.LittleEndian.Uint32(b))), nil
+	case kindDouble:
+		b, err := d.bytes(8)
+		if err != nil {
+			return nil, err
+		}
+		return finite(math.Float64frombits(binary.LittleEndian.Uint64(b))), nil
+	case kindBytes:
+		b, err := d.lengthPrefixed()
+		if err != nil {
+			return nil, err
+		}
+		return logicalBytes(s, b), nil
+	case kindString:
+		b, err := d.lengthPrefixed()
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case kindFixed:
+		b, err := d.bytes(int64(s.size))
+		if err != nil {
+			return nil, err
+		}
+		return logicalBytes(s, b), nil
+	case kindEnum:
+		i, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(s.symbols)) {
+			return nil, fmt.Errorf("invalid symbol %d of enum %s", i, s.name)
+		}
+		return s.symbols[i], nil
+	case kindUnion:
+		i, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(s.branches)) {
+			return nil, fmt.Errorf("invalid union branch %d", i)
+		}
+		return d.value(s.branches[i])
+	case kindRecord:
+		m := make(map[string]any, len(s.fields))
+		for _, f := range s.fields {
+			v, err := d.value(f.schema)
+			if err != nil {
+				return nil, err
+			}
+			m[f.name] = v
+		}
+		return m, nil
+	case kindArray:
+		items := []any{}
+		err := d.blocks(s.itemSize, func() error {
+			v, err := d.value(s.items)
+			items = append(items, v)
+			return err
+		})
+		return items, err
+	case kindMap:
+		m := map[string]any{}
+		err := d.blocks(s.itemSize, func() error {
+			k, err := d.lengthPrefixed()
+			if err != nil {
+				return err
+			}
+			v, err := d.value(s.values)
+			m[string(k)] = v
+			return err
+		})
+		return m, err
+	default:
+		return nil, fmt.Errorf("unsupported type %d", s.kind)
+	}
+}
+
+// blocks decodes the blocks of items of an array or map, whose items take
+// at least itemSize bytes.
+func (d *decoder) blocks(itemSize int, item func() error) error {
+	var total int64
+	for {
+		count, err := d.long()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if count < 0 {
+			// The count is followed by the size of the block in bytes.
+			count = -count
+			if _, err := d.long(); err != nil {
+				return err
+			}
+		}
+		if itemSize > 0 {
+			if count > int64((len(d.buf)-d.pos)/itemSize) {
+				return errShortBuffer
+			}
+		} else {
+			total += count
+			if count > maxEmptyItems || total > maxEmptyItems {
+				return fmt.Errorf("more than %d empty items", maxEmptyItems)
+			}
+		}
+		for ; count > 0; count-- {
+			if err := item(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func logicalLong(s *schema, v int64) any {
+	switch s.logical {
+	case "date":
+		return time.Unix(v*24*60*60, 0).UTC().Format(time.DateOnly)
+	case "timestamp-millis", "local-timestamp-millis":
+		return time.UnixMilli(v).UTC()
+	case "timestamp-micros", "local-timestamp-micros":
+		return time.UnixMicro(v).UTC()
+	case "timestamp-nanos", "local-timestamp-nanos":
+		return time.Unix(0, v).UTC()
+	}
+	return v
+}
+
+func logicalBytes(s *schema, b []byte) any {
+	if s.logical == "decimal" {
+		// Big-endian two's complement unscaled value.
+		unscaled := new(big.Int).SetBytes(b)
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+		}
+		return new(big.Rat).SetFrac(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(s.scale)), nil)).FloatString(s.scale)
+	}
+	// The block is reused, so the value must be copied.
+	return append([]byte(nil), b...)
+}
+
+// finite returns f, or its name if it can't be represented in JSON.
+func finite[F float32 | float64](f F) any {
+	switch g := float64(f); {
+	case math.IsNaN(g):
+		return "NaN"
+	case math.IsInf(g, 1):
+		return "Infinity"
+	case math.IsInf(g, -1):
+		return "-Infinity"
+	}
+	return f
+}
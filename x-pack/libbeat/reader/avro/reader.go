@@ -0,0 +1,312 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Package avro reads Avro object container files.
+//
+// A container file is a header holding the schema, followed by blocks of
+// records, each of them optionally compressed. Records are decoded to values
+// that can be serialized to JSON: records and maps are decoded to
+// map[string]any, arrays to []any, enums to their symbol and unions to the
+// value of their branch.
+package avro
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+var magic = []byte("Obj\x01")
+
+const syncSize = 16
+
+// maxBlockSize limits the size of the blocks, to protect against corrupt
+// files.
+const maxBlockSize = 1 << 30
+
+// Reader reads the records of an Avro container file.
+type Reader struct {
+	r      *bufio.Reader
+	schema *schema
+	codec  string
+	sync   [syncSize]byte
+
+	// block is the index of the current block, -1 before the first one.
+	block int64
+	// remaining is the number of records of the current block that weren't
+	// read yet.
+	remaining int64
+	data      decoder
+	buf       []byte
+	zstd      *zstd.Decoder
+
+	value any
+	err   error
+}
+
+// NewReader reads the header of a container file.
+func NewReader(r io.Reader) (*Reader, error) {
+	ar := &Reader{r: bufio.NewReader(r), block: -1}
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(ar.r, header); err != nil {
+		return nil, fmt.Errorf("failed to read avro header: %w", err)
+	}
+	if !bytes.Equal(header, magic) {
+		return nil, errors.New("not an avro container file")
+	}
+
+	meta, err := ar.readMetadata()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read avro header: %w", err)
+	}
+	if _, err := io.ReadFull(ar.r, ar.sync[:]); err != nil {
+		return nil, fmt.Errorf("failed to read avro header: %w", err)
+	}
+
+	ar.schema, err = parseSchema(meta["avro.schema"])
+	if err != nil {
+		return nil, err
+	}
+	ar.codec = string(meta["avro.codec"])
+	switch ar.codec {
+	case "", "null", "deflate", "snappy":
+	case "zstandard":
+		ar.zstd, err = zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported avro codec %q", ar.codec)
+	}
+	return ar, nil
+}
+
+func (r *Reader) readMetadata() (map[string][]byte, error) {
+	meta := map[string][]byte{}
+	for {
+		count, err := r.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return meta, nil
+		}
+		if count < 0 {
+			count = -count
+			if _, err := r.readLong(); err != nil {
+				return nil, err
+			}
+		}
+		for ; count > 0; count-- {
+			k, err := r.readBytes()
+			if err != nil {
+				return nil, err
+			}
+			v, err := r.readBytes()
+			if err != nil {
+				return nil, err
+			}
+			meta[string(k)] = v
+		}
+	}
+}
+
+func (r *Reader) readLong() (int64, error) {
+	u, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return 0, err
+	}
+	return int64(u>>1) ^ -int64(u&1), nil //nolint:gosec // G115: zig-zag decoding
+}
+
+func (r *Reader) readBytes() ([]byte, error) {
+	n, err := r.readLong()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > maxBlockSize {
+		return nil, fmt.Errorf("invalid length %d", n)
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(r.r, b)
+	return b, err
+}
+
+// Next advances to the next record. It returns false at the end of the file
+// or if an error occurred, which is returned by Err.
+func (r *Reader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	for r.remaining == 0 {
+		if !r.nextBlock(true) {
+			return false
+		}
+	}
+	r.value, r.err = r.data.value(r.schema)
+	if r.err != nil {
+		r.err = fmt.Errorf("failed to decode record of block %d: %w", r.block, r.err)
+		return false
+	}
+	r.remaining--
+	return true
+}
+
+// Value returns the current record.
+func (r *Reader) Value() any {
+	return r.value
+}
+
+// Err returns the error that stopped the reader, if any.
+func (r *Reader) Err() error {
+	if errors.Is(r.err, io.EOF) {
+		return nil
+	}
+	return r.err
+}
+
+// Block returns the index of the block of the current record.
+func (r *Reader) Block() int64 {
+	return r.block
+}
+
+// EndOfBlock returns whether the current record is the last one of its
+// block.
+func (r *Reader) EndOfBlock() bool {
+	return r.remaining == 0
+}
+
+// Skip skips n records. Blocks holding only skipped records are neither
+// decompressed nor decoded.
+func (r *Reader) Skip(n int64) error {
+	for n > 0 && r.err == nil {
+		if r.remaining == 0 {
+			count, err := r.peekBlockCount()
+			if err != nil {
+				r.err = err
+				break
+			}
+			if count <= n {
+				if r.nextBlock(false) {
+					n -= count
+				}
+				continue
+			}
+		}
+		if !r.Next() {
+			break
+		}
+		n--
+	}
+	if r.err == nil && n > 0 {
+		r.err = io.EOF
+	}
+	return r.err
+}
+
+// peekBlockCount returns the number of records of the next block.
+func (r *Reader) peekBlockCount() (int64, error) {
+	b, err := r.r.Peek(binary.MaxVarintLen64)
+	if len(b) == 0 {
+		return 0, err
+	}
+	u, n := binary.Uvarint(b)
+	if n <= 0 {
+		return 0, errors.New("invalid block header")
+	}
+	return int64(u>>1) ^ -int64(u&1), nil //nolint:gosec // G115: zig-zag decoding
+}
+
+// nextBlock reads the next block, decompressing it if decode is true.
+func (r *Reader) nextBlock(decode bool) bool {
+	count, err := r.readLong()
+	if err != nil {
+		r.err = err
+		if !errors.Is(err, io.EOF) {
+			r.err = fmt.Errorf("failed to read block header: %w", err)
+		}
+		return false
+	}
+	size, err := r.readLong()
+	if err != nil {
+		r.err = fmt.Errorf("failed to read block header: %w", io.ErrUnexpectedEOF)
+		return false
+	}
+	if count < 0 || size < 0 || size > maxBlockSize {
+		r.err = fmt.Errorf("invalid block header: %d records of %d bytes", count, size)
+		return false
+	}
+
+	r.block++
+	if decode {
+		r.buf = append(r.buf[:0], make([]byte, size)...)
+		_, err = io.ReadFull(r.r, r.buf)
+	} else {
+		_, err = r.r.Discard(int(size))
+	}
+	if err != nil {
+		r.err = fmt.Errorf("failed to read block %d: %w", r.block, io.ErrUnexpectedEOF)
+		return false
+	}
+
+	var sync [syncSize]byte
+	if _, err := io.ReadFull(r.r, sync[:]); err != nil || sync != r.sync {
+		r.err = fmt.Errorf("invalid sync marker after block %d", r.block)
+		return false
+	}
+
+	if decode {
+		data, err := r.decompress(r.buf)
+		if err != nil {
+			r.err = fmt.Errorf("failed to decompress block %d: %w", r.block, err)
+			return false
+		}
+		r.data = decoder{buf: data}
+		r.remaining = count
+	} else {
+		r.remaining = 0
+	}
+	return true
+}
+
+func (r *Reader) decompress(b []byte) ([]byte, error) {
+	switch r.codec {
+	case "deflate":
+		return io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(b)), maxBlockSize))
+	case "snappy":
+		// The compressed data is followed by the CRC32 of the uncompressed
+		// data.
+		if len(b) < 4 {
+			return nil, errShortBuffer
+		}
+		data, err := snappy.Decode(nil, b[:len(b)-4])
+		if err != nil {
+			return nil, err
+		}
+		if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(b[len(b)-4:]) {
+			return nil, errors.New("checksum mismatch")
+		}
+		return data, nil
+	case "zstandard":
+		return r.zstd.DecodeAll(b, nil)
+	default:
+		return b, nil
+	}
+}
+
+// Close releases the resources of the reader.
+func (r *Reader) Close() error {
+	if r.zstd != nil {
+		r.zstd.Close()
+	}
+	return nil
+}
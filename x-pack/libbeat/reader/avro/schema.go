@@ -0,0 +1,310 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package avro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+type kind int
+
+const (
+	kindNull kind = iota
+	kindBoolean
+	kindInt
+	kindLong
+	kindFloat
+	kindDouble
+	kindBytes
+	kindString
+	kindRecord
+	kindEnum
+	kindArray
+	kindMap
+	kindUnion
+	kindFixed
+)
+
+// maxDecimalScale bounds the scale of decimals, as decoding a decimal
+// computes 10^scale.
+const maxDecimalScale = 38
+
+var primitives = map[string]kind{
+	"null":    kindNull,
+	"boolean": kindBoolean,
+	"int":     kindInt,
+	"long":    kindLong,
+	"float":   kindFloat,
+	"double":  kindDouble,
+	"bytes":   kindBytes,
+	"string":  kindString,
+}
+
+// schema is a parsed Avro schema.
+type schema struct {
+	kind kind
+	// name is the full name of named types.
+	name string
+	// logical is the logical type annotating the type, if any.
+	logical string
+	// scale is the scale of decimals.
+	scale int
+
+	fields   []field   // record fields
+	symbols  []string  // enum symbols
+	items    *schema   // array items
+	values   *schema   // map values
+	branches []*schema // union branches
+	size     int       // fixed size
+
+	// itemSize is the minimum size of the encoded items of arrays and maps,
+	// used to check the item counts against the size of the block.
+	itemSize int
+}
+
+type field struct {
+	name   string
+	schema *schema
+}
+
+// parseSchema parses the JSON representation of a schema.
+func parseSchema(data []byte) (*schema, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	p := schemaParser{named: map[string]*schema{}}
+	s, err := p.parse(v, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// The sizes are computed once the whole schema is parsed, as the items
+	// can reference records whose fields were not parsed yet.
+	for _, c := range p.collections {
+		if c.kind == kindMap {
+			// Map items start with their key.
+			c.itemSize = 1
+			continue
+		}
+		c.itemSize, err = c.items.minSize(map[*schema]bool{})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range p.named {
+		if _, err := r.minSize(map[*schema]bool{}); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// minSize returns the minimum size of the encoded values of s. It fails for
+// records that contain themselves, whose values can't be encoded.
+func (s *schema) minSize(visiting map[*schema]bool) (int, error) {
+	switch s.kind {
+	case kindNull:
+		return 0, nil
+	case kindFloat:
+		return 4, nil
+	case kindDouble:
+		return 8, nil
+	case kindFixed:
+		return s.size, nil
+	case kindRecord:
+		if visiting[s] {
+			return 0, fmt.Errorf("record %s contains itself", s.name)
+		}
+		visiting[s] = true
+		defer delete(visiting, s)
+		size := 0
+		for _, f := range s.fields {
+			n, err := f.schema.minSize(visiting)
+			if err != nil {
+				return 0, err
+			}
+			size += n
+		}
+		return size, nil
+	default:
+		// Other values start with at least one byte: a boolean, a length,
+		// a count, an index or a variable-length integer.
+		return 1, nil
+	}
+}
+
+// schemaParser keeps the named types defined so far, so they can be
+// referenced by name.
+type schemaParser struct {
+	named map[string]*schema
+	// collections are the array and map types.
+	collections []*schema
+}
+
+func (p *schemaParser) parse(v any, namespace string) (*schema, error) {
+	switch v := v.(type) {
+	case string:
+		return p.reference(v, namespace)
+	case []any:
+		s := &schema{kind: kindUnion}
+		for _, b := range v {
+			branch, err := p.parse(b, namespace)
+			if err != nil {
+				return nil, err
+			}
+			s.branches = append(s.branches, branch)
+		}
+		return s, nil
+	case map[string]any:
+		return p.parseComplex(v, namespace)
+	default:
+		return nil, fmt.Errorf("invalid schema %v", v)
+	}
+}
+
+func (p *schemaParser) reference(name, namespace string) (*schema, error) {
+	if k, ok := primitives[name]; ok {
+		return &schema{kind: k}, nil
+	}
+	if s, ok := p.named[fullName(name, namespace)]; ok {
+		return s, nil
+	}
+	if s, ok := p.named[name]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("unknown type %q", name)
+}
+
+func (p *schemaParser) parseComplex(v map[string]any, namespace string) (*schema, error) {
+	typ, ok := v["type"]
+	if !ok {
+		return nil, errors.New("schema without type")
+	}
+	name, ok := typ.(string)
+	if !ok {
+		// The type is itself a schema, e.g. {"type": {"type": "array", ...}}.
+		return p.parse(typ, namespace)
+	}
+
+	logical, _ := v["logicalType"].(string)
+	if k, ok := primitives[name]; ok {
+		s := &schema{kind: k, logical: logical}
+		if err := s.parseScale(v); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	switch name {
+	case "record", "error":
+		s, namespace, err := p.define(v, kindRecord, namespace)
+		if err != nil {
+			return nil, err
+		}
+		fields, _ := v["fields"].([]any)
+		for _, f := range fields {
+			f, ok := f.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid field in record %s", s.name)
+			}
+			fieldName, _ := f["name"].(string)
+			fieldSchema, err := p.parse(f["type"], namespace)
+			if err != nil {
+				return nil, fmt.Errorf("field %s of record %s: %w", fieldName, s.name, err)
+			}
+			s.fields = append(s.fields, field{name: fieldName, schema: fieldSchema})
+		}
+		return s, nil
+	case "enum":
+		s, _, err := p.define(v, kindEnum, namespace)
+		if err != nil {
+			return nil, err
+		}
+		symbols, _ := v["symbols"].([]any)
+		for _, sym := range symbols {
+			str, _ := sym.(string)
+			s.symbols = append(s.symbols, str)
+		}
+		return s, nil
+	case "fixed":
+		s, _, err := p.define(v, kindFixed, namespace)
+		if err != nil {
+			return nil, err
+		}
+		size, _ := v["size"].(float64)
+		s.size = int(size)
+		s.logical = logical
+		if err := s.parseScale(v); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "array":
+		items, err := p.parse(v["items"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		s := &schema{kind: kindArray, items: items}
+		p.collections = append(p.collections, s)
+		return s, nil
+	case "map":
+		values, err := p.parse(v["values"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		s := &schema{kind: kindMap, values: values}
+		p.collections = append(p.collections, s)
+		return s, nil
+	default:
+		// A named type used as {"type": "Name"}.
+		s, err := p.reference(name, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+}
+
+// parseScale parses the scale of decimals.
+func (s *schema) parseScale(v map[string]any) error {
+	scale, ok := v["scale"].(float64)
+	if !ok || s.logical != "decimal" {
+		return nil
+	}
+	if scale < 0 || scale > maxDecimalScale || scale != math.Trunc(scale) {
+		return fmt.Errorf("invalid decimal scale %v", scale)
+	}
+	s.scale = int(scale)
+	return nil
+}
+
+// define registers a named type before its definition is parsed, so it can
+// reference itself. It returns the namespace of the definition.
+func (p *schemaParser) define(v map[string]any, k kind, namespace string) (*schema, string, error) {
+	name, _ := v["name"].(string)
+	if name == "" {
+		return nil, "", fmt.Errorf("unnamed %v type", v["type"])
+	}
+	if ns, ok := v["namespace"].(string); ok {
+		namespace = ns
+	}
+	s := &schema{kind: k, name: fullName(name, namespace)}
+	p.named[s.name] = s
+	if i := strings.LastIndexByte(s.name, '.'); i >= 0 {
+		namespace = s.name[:i]
+	}
+	return s, namespace, nil
+}
+
+func fullName(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
@@ -0,0 +1,26 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package decoder
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/elastic/beats/v7/x-pack/libbeat/reader/avro"
+)
+
+type avroDecoder struct {
+	recordDecoder
+}
+
+// NewAvroDecoder returns a decoder of the records of an Avro container file.
+// The blocks of the file are decoded as they are read.
+func NewAvroDecoder(_ AvroCodecConfig, r io.Reader) (Decoder, error) {
+	reader, err := avro.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create avro decoder: %w", err)
+	}
+	return &avroDecoder{recordDecoder{reader: reader, endOfBlock: reader.EndOfBlock}}, nil
+}
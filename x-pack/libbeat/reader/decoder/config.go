@@ -19,6 +19,8 @@ type Config struct {
 type CodecConfig struct {
 	CSV     *CSVCodecConfig     `config:"csv"`
 	Parquet *ParquetCodecConfig `config:"parquet"`
+	Avro    *AvroCodecConfig    `config:"avro"`
+	ORC     *ORCCodecConfig     `config:"orc"`
 }
 
 func (c *CodecConfig) Validate() error {
@@ -33,6 +35,12 @@ func (c *CodecConfig) Validate() error {
 	if c.CSV != nil {
 		count++
 	}
+	if c.Avro != nil {
+		count++
+	}
+	if c.ORC != nil {
+		count++
+	}
 
 	if count > 1 {
 		return errors.New("more than one decoder configured")
@@ -85,3 +93,11 @@ type ParquetCodecConfig struct {
 	// BatchSize is the number of rows to read at a time from the file.
 	BatchSize int `config:"batch_size" default:"1"`
 }
+
+type AvroCodecConfig struct {
+	Enabled bool `config:"enabled"`
+}
+
+type ORCCodecConfig struct {
+	Enabled bool `config:"enabled"`
+}
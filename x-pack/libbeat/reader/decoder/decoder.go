@@ -34,6 +34,18 @@ type ValueDecoder interface {
 	DecodeValue() (int64, []byte, map[string]any, error)
 }
 
+// Resumer is implemented by decoders of formats storing records in blocks,
+// so the decoding of an object can be resumed after the last record of a
+// block without decoding the blocks before it.
+type Resumer interface {
+	// Resume skips the records up to and including the one at offset, as
+	// returned by DecodeValue. It must be called before Next.
+	Resume(offset int64) error
+	// EndOfBlock returns whether the last decoded record is the last one of
+	// its block.
+	EndOfBlock() bool
+}
+
 // newDecoder creates a new decoder based on the codec type.
 // It returns a decoder type and an error if the codec type is not supported.
 // If the reader config codec option is not set, it returns a nil decoder and nil error.
@@ -54,6 +66,10 @@ func NewDecoder(cfg Config, r io.Reader, logger *logp.Logger) (Decoder, error) {
 	case cfg.Codec.Parquet != nil:
 		pqt := codec.Parquet
 		result, _ = NewParquetDecoder(*pqt, r, logger)
+	case cfg.Codec.Avro != nil:
+		return NewAvroDecoder(*codec.Avro, r)
+	case cfg.Codec.ORC != nil:
+		return NewORCDecoder(*codec.ORC, r)
 	default:
 		return nil, fmt.Errorf("unsupported config value: %v", cfg)
 	}
@@ -0,0 +1,27 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package decoder
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/elastic/beats/v7/x-pack/libbeat/reader/orc"
+)
+
+type orcDecoder struct {
+	recordDecoder
+}
+
+// NewORCDecoder returns a decoder of the rows of an ORC file. The whole file
+// is read before the first row is decoded, since its metadata is at its end,
+// and each stripe of the file is a block.
+func NewORCDecoder(_ ORCCodecConfig, r io.Reader) (Decoder, error) {
+	reader, err := orc.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create orc decoder: %w", err)
+	}
+	return &orcDecoder{recordDecoder{reader: reader, endOfBlock: reader.EndOfStripe}}, nil
+}
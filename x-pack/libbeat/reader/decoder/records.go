@@ -0,0 +1,102 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package decoder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// recordReader reads the records of a file stored in blocks.
+type recordReader interface {
+	Next() bool
+	Value() any
+	Err() error
+	Skip(n int64) error
+	Close() error
+}
+
+// recordDecoder decodes the records of a recordReader. The offset of a
+// record is its index in the object.
+type recordDecoder struct {
+	reader     recordReader
+	endOfBlock func() bool
+
+	// next is the offset of the next record read.
+	next int64
+
+	// current is the record read ahead by Next or More, and currentEnd
+	// whether it ends its block.
+	current    any
+	currentEnd bool
+	hasCurrent bool
+
+	// lastEnd is whether the last decoded record ends its block.
+	lastEnd bool
+}
+
+func (d *recordDecoder) Resume(offset int64) error {
+	if d.next != 0 || d.hasCurrent {
+		return errors.New("resume called after next")
+	}
+	if err := d.reader.Skip(offset + 1); err != nil {
+		return fmt.Errorf("failed to resume after record %d: %w", offset, err)
+	}
+	d.next = offset + 1
+	return nil
+}
+
+func (d *recordDecoder) EndOfBlock() bool {
+	return d.lastEnd
+}
+
+func (d *recordDecoder) More() bool { return d.step() }
+
+func (d *recordDecoder) Next() bool { return d.step() }
+
+func (d *recordDecoder) step() bool {
+	if d.hasCurrent {
+		return true
+	}
+	if !d.reader.Next() {
+		return false
+	}
+	d.current = d.reader.Value()
+	d.currentEnd = d.endOfBlock()
+	d.hasCurrent = true
+	return true
+}
+
+func (d *recordDecoder) Decode() ([]byte, error) {
+	_, b, _, err := d.DecodeValue()
+	return b, err
+}
+
+func (d *recordDecoder) DecodeValue() (int64, []byte, map[string]any, error) {
+	offset := d.next
+	if !d.hasCurrent {
+		if err := d.reader.Err(); err != nil {
+			return offset, nil, nil, err
+		}
+		return offset, nil, nil, fmt.Errorf("decode called before next")
+	}
+	m, ok := d.current.(map[string]any)
+	if !ok {
+		m = map[string]any{"value": d.current}
+	}
+	d.current = nil
+	d.hasCurrent = false
+	d.lastEnd = d.currentEnd
+	d.next++
+
+	b, err := json.Marshal(m)
+	return offset, b, m, err
+}
+
+func (d *recordDecoder) Close() error {
+	err := d.reader.Err()
+	return errors.Join(err, d.reader.Close())
+}
@@ -0,0 +1,480 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package orc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+)
+
+// column reads the values of a column of a stripe, one row at a time.
+type column struct {
+	present *boolStream
+	read    func() (any, error)
+}
+
+// next returns the value of the next row, nil if it is null.
+func (c *column) next() (any, error) {
+	if c.present != nil {
+		present, err := c.present.next()
+		if err != nil || !present {
+			return nil, err
+		}
+	}
+	return c.read()
+}
+
+type streamKey struct {
+	column uint64
+	kind   streamKind
+}
+
+// stripe holds the decompressed streams of a stripe.
+type stripe struct {
+	streams   map[streamKey][]byte
+	encodings []encodingKind
+	location  *time.Location
+}
+
+func (s *stripe) encoding(id int) encodingKind {
+	if id < len(s.encodings) {
+		return s.encodings[id]
+	}
+	return encodingDirect
+}
+
+func (s *stripe) bytes(id int, kind streamKind) *byteStream {
+	return &byteStream{data: s.streams[streamKey{uint64(id), kind}]} //nolint:gosec // G115: id is never negative
+}
+
+func (s *stripe) bools(id int, kind streamKind) (*boolStream, error) {
+	data, ok := s.streams[streamKey{uint64(id), kind}] //nolint:gosec // G115: id is never negative
+	if !ok {
+		return nil, nil
+	}
+	b, err := decodeByteRLE(data)
+	if err != nil {
+		return nil, fmt.Errorf("column %d: %w", id, err)
+	}
+	return &boolStream{data: b}, nil
+}
+
+func (s *stripe) ints(id int, kind streamKind, signed bool) (*intStream, error) {
+	v, err := decodeIntRLE(s.streams[streamKey{uint64(id), kind}], signed, s.encoding(id).v2()) //nolint:gosec // G115: id is never negative
+	if err != nil {
+		return nil, fmt.Errorf("column %d: %w", id, err)
+	}
+	return &intStream{values: v}, nil
+}
+
+type byteStream struct {
+	data []byte
+	pos  int
+}
+
+func (s *byteStream) read(n int64) ([]byte, error) {
+	if n < 0 || n > int64(len(s.data)-s.pos) {
+		return nil, errShortStream
+	}
+	b := s.data[s.pos : s.pos+int(n)]
+	s.pos += int(n)
+	return b, nil
+}
+
+type boolStream struct {
+	data []byte
+	pos  int
+}
+
+func (s *boolStream) next() (bool, error) {
+	if s.pos >= len(s.data)*8 {
+		return false, errShortStream
+	}
+	bit := s.data[s.pos/8]&(0x80>>(s.pos%8)) != 0
+	s.pos++
+	return bit, nil
+}
+
+type intStream struct {
+	values []int64
+	pos    int
+}
+
+func (s *intStream) next() (int64, error) {
+	if s.pos >= len(s.values) {
+		return 0, errShortStream
+	}
+	v := s.values[s.pos]
+	s.pos++
+	return v, nil
+}
+
+// Timestamps are stored as seconds since 2015-01-01.
+var timestampBase = time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newColumn creates the reader of column id, and of its children.
+func newColumn(types []orcType, s *stripe, id int) (*column, error) {
+	present, err := s.bools(id, streamPresent)
+	if err != nil {
+		return nil, err
+	}
+	c := &column{present: present}
+	t := types[id]
+	switch t.kind {
+	case kindBoolean:
+		data, err := s.bools(id, streamData)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			data = &boolStream{}
+		}
+		c.read = func() (any, error) { return data.next() }
+
+	case kindByte:
+		b, err := decodeByteRLE(s.streams[streamKey{uint64(id), streamData}]) //nolint:gosec // G115: id is never negative
+		if err != nil {
+			return nil, fmt.Errorf("column %d: %w", id, err)
+		}
+		data := &byteStream{data: b}
+		c.read = func() (any, error) {
+			b, err := data.read(1)
+			if err != nil {
+				return nil, err
+			}
+			return int64(int8(b[0])), nil
+		}
+
+	case kindShort, kindInt, kindLong:
+		data, err := s.ints(id, streamData, true)
+		if err != nil {
+			return nil, err
+		}
+		c.read = func() (any, error) { return data.next() }
+
+	case kindFloat:
+		data := s.bytes(id, streamData)
+		c.read = func() (any, error) {
+			b, err := data.read(4)
+			if err != nil {
+				return nil, err
+			}
+			return finite(math.Float32frombits(binary.LittleEndian.Uint32(b))), nil
+		}
+
+	case kindDouble:
+		data := s.bytes(id, streamData)
+		c.read = func() (any, error) {
+			b, err := data.read(8)
+			if err != nil {
+				return nil, err
+			}
+			return finite(math.Float64frombits(binary.LittleEndian.Uint64(b))), nil
+		}
+
+	case kindString, kindVarchar, kindChar, kindBinary:
+		binary := t.kind == kindBinary
+		read, err := newBytesReader(s, id)
+		if err != nil {
+			return nil, err
+		}
+		c.read = func() (any, error) {
+			b, err := read()
+			if err != nil {
+				return nil, err
+			}
+			if binary {
+				return append([]byte(nil), b...), nil
+			}
+			return string(b), nil
+		}
+
+	case kindTimestamp, kindTimestampInstant:
+		seconds, err := s.ints(id, streamData, true)
+		if err != nil {
+			return nil, err
+		}
+		nanos, err := s.ints(id, streamSecondary, false)
+		if err != nil {
+			return nil, err
+		}
+		// Timestamps without a time zone hold the wall clock time of the
+		// writer.
+		location := time.UTC
+		if t.kind == kindTimestamp {
+			location = s.location
+		}
+		c.read = func() (any, error) {
+			sec, err := seconds.next()
+			if err != nil {
+				return nil, err
+			}
+			ns, err := nanos.next()
+			if err != nil {
+				return nil, err
+			}
+			// The 3 low bits hold the number of trailing zeros of the
+			// nanoseconds, minus one.
+			if zeros := ns & 7; zeros != 0 {
+				ns >>= 3
+				for range zeros + 1 {
+					ns *= 10
+				}
+			} else {
+				ns >>= 3
+			}
+			// Writers truncate the seconds of timestamps before the epoch
+			// towards zero.
+			if timestampBase.Unix()+sec < 0 && ns > 999999 {
+				sec--
+			}
+			wall := time.Unix(timestampBase.Unix()+sec, 0).UTC()
+			return time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), int(ns), location).UTC(), nil
+		}
+
+	case kindDate:
+		days, err := s.ints(id, streamData, true)
+		if err != nil {
+			return nil, err
+		}
+		c.read = func() (any, error) {
+			d, err := days.next()
+			if err != nil {
+				return nil, err
+			}
+			return time.Unix(d*24*60*60, 0).UTC().Format(time.DateOnly), nil
+		}
+
+	case kindDecimal:
+		data := s.bytes(id, streamData)
+		scales, err := s.ints(id, streamSecondary, true)
+		if err != nil {
+			return nil, err
+		}
+		c.read = func() (any, error) {
+			v, err := readBigVarint(data)
+			if err != nil {
+				return nil, err
+			}
+			scale, err := scales.next()
+			if err != nil {
+				return nil, err
+			}
+			return formatDecimal(v, scale)
+		}
+
+	case kindStruct:
+		children := make([]*column, len(t.subtypes))
+		for i, sub := range t.subtypes {
+			children[i], err = newColumn(types, s, int(sub))
+			if err != nil {
+				return nil, err
+			}
+		}
+		c.read = func() (any, error) {
+			m := make(map[string]any, len(children))
+			for i, child := range children {
+				v, err := child.next()
+				if err != nil {
+					return nil, err
+				}
+				m[t.names[i]] = v
+			}
+			return m, nil
+		}
+
+	case kindList:
+		lengths, err := s.ints(id, streamLength, false)
+		if err != nil {
+			return nil, err
+		}
+		items, err := newColumn(types, s, int(t.subtypes[0]))
+		if err != nil {
+			return nil, err
+		}
+		c.read = func() (any, error) {
+			n, err := lengths.next()
+			if err != nil {
+				return nil, err
+			}
+			// The length isn't trusted to size the list, a corrupt
+			// length fails once the items are exhausted.
+			l := make([]any, 0, min(n, 1024))
+			for range n {
+				v, err := items.next()
+				if err != nil {
+					return nil, err
+				}
+				l = append(l, v)
+			}
+			return l, nil
+		}
+
+	case kindMap:
+		lengths, err := s.ints(id, streamLength, false)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := newColumn(types, s, int(t.subtypes[0]))
+		if err != nil {
+			return nil, err
+		}
+		values, err := newColumn(types, s, int(t.subtypes[1]))
+		if err != nil {
+			return nil, err
+		}
+		c.read = func() (any, error) {
+			n, err := lengths.next()
+			if err != nil {
+				return nil, err
+			}
+			m := make(map[string]any)
+			for range n {
+				k, err := keys.next()
+				if err != nil {
+					return nil, err
+				}
+				v, err := values.next()
+				if err != nil {
+					return nil, err
+				}
+				if s, ok := k.(string); ok {
+					m[s] = v
+				} else {
+					m[fmt.Sprint(k)] = v
+				}
+			}
+			return m, nil
+		}
+
+	case kindUnion:
+		b, err := decodeByteRLE(s.streams[streamKey{uint64(id), streamData}]) //nolint:gosec // G115: id is never negative
+		if err != nil {
+			return nil, fmt.Errorf("column %d: %w", id, err)
+		}
+		tags := &byteStream{data: b}
+		children := make([]*column, len(t.subtypes))
+		for i, sub := range t.subtypes {
+			children[i], err = newColumn(types, s, int(sub))
+			if err != nil {
+				return nil, err
+			}
+		}
+		c.read = func() (any, error) {
+			tag, err := tags.read(1)
+			if err != nil {
+				return nil, err
+			}
+			if int(tag[0]) >= len(children) {
+				return nil, fmt.Errorf("invalid union tag %d", tag[0])
+			}
+			return children[tag[0]].next()
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported type %d of column %d", t.kind, id)
+	}
+	return c, nil
+}
+
+// newBytesReader returns a function reading the values of a string or
+// binary column, using the direct or the dictionary encoding.
+func newBytesReader(s *stripe, id int) (func() ([]byte, error), error) {
+	lengths, err := s.ints(id, streamLength, false)
+	if err != nil {
+		return nil, err
+	}
+	if !s.encoding(id).dictionary() {
+		data := s.bytes(id, streamData)
+		return func() ([]byte, error) {
+			n, err := lengths.next()
+			if err != nil {
+				return nil, err
+			}
+			return data.read(n)
+		}, nil
+	}
+
+	// The lengths are the lengths of the dictionary entries, and the data
+	// holds the index of the entry of each value.
+	dictData := s.bytes(id, streamDictionaryData)
+	dict := make([][]byte, len(lengths.values))
+	for i, n := range lengths.values {
+		dict[i], err = dictData.read(n)
+		if err != nil {
+			return nil, fmt.Errorf("column %d dictionary: %w", id, err)
+		}
+	}
+	indexes, err := s.ints(id, streamData, false)
+	if err != nil {
+		return nil, err
+	}
+	return func() ([]byte, error) {
+		i, err := indexes.next()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(dict)) {
+			return nil, fmt.Errorf("invalid dictionary index %d", i)
+		}
+		return dict[i], nil
+	}, nil
+}
+
+// readBigVarint reads a zig-zag encoded varint of any size.
+func readBigVarint(s *byteStream) (*big.Int, error) {
+	v := new(big.Int)
+	var shift uint
+	for {
+		b, err := s.read(1)
+		if err != nil {
+			return nil, err
+		}
+		v.Or(v, new(big.Int).Lsh(big.NewInt(int64(b[0]&0x7f)), shift))
+		shift += 7
+		if b[0]&0x80 == 0 {
+			break
+		}
+	}
+	negative := v.Bit(0) == 1
+	v.Rsh(v, 1)
+	if negative {
+		v.Neg(v).Sub(v, big.NewInt(1))
+	}
+	return v, nil
+}
+
+// maxDecimalScale is the maximum precision of ORC decimals, which bounds
+// their scale.
+const maxDecimalScale = 38
+
+// formatDecimal formats a decimal as a string, to keep its precision.
+func formatDecimal(unscaled *big.Int, scale int64) (string, error) {
+	if scale < -maxDecimalScale || scale > maxDecimalScale {
+		return "", fmt.Errorf("invalid decimal scale %d", scale)
+	}
+	if scale <= 0 {
+		return unscaled.Mul(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(-scale), nil)).String(), nil
+	}
+	denom := new(big.Int).Exp(big.NewInt(10), big.NewInt(scale), nil)
+	return new(big.Rat).SetFrac(unscaled, denom).FloatString(int(scale)), nil
+}
+
+// finite returns f, or a string representation for NaN and infinite values
+// that can't be serialized to JSON.
+func finite[F float32 | float64](f F) any {
+	switch g := float64(f); {
+	case math.IsNaN(g):
+		return "NaN"
+	case math.IsInf(g, 1):
+		return "Infinity"
+	case math.IsInf(g, -1):
+		return "-Infinity"
+	}
+	return f
+}
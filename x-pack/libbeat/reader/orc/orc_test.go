@@ -0,0 +1,447 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package orc
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"math"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestReaderCompressions(t *testing.T) {
+	compressions := map[string]compressionKind{
+		"none":   compressionNone,
+		"zlib":   compressionZlib,
+		"snappy": compressionSnappy,
+		"lz4":    compressionLZ4,
+		"zstd":   compressionZstd,
+	}
+	for name, compression := range compressions {
+		for _, v2 := range []bool{false, true} {
+			t.Run(name+"/v2="+strconv.FormatBool(v2), func(t *testing.T) {
+				r, err := NewReader(bytes.NewReader(writeTestFile(t, compression, v2, 3, 5)))
+				require.NoError(t, err)
+				defer r.Close()
+
+				var n int64
+				for r.Next() {
+					assert.Equal(t, testRow(n), r.Value())
+					assert.Equal(t, n/5, r.Stripe())
+					assert.Equal(t, n%5 == 4, r.EndOfStripe())
+					n++
+				}
+				require.NoError(t, r.Err())
+				assert.Equal(t, int64(15), n)
+			})
+		}
+	}
+}
+
+func TestReaderSkip(t *testing.T) {
+	for _, skip := range []int64{0, 4, 5, 6, 14} {
+		t.Run(strconv.FormatInt(skip, 10), func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(writeTestFile(t, compressionZlib, true, 3, 5)))
+			require.NoError(t, err)
+			defer r.Close()
+
+			require.NoError(t, r.Skip(skip))
+			n := skip
+			for r.Next() {
+				assert.Equal(t, testRow(n), r.Value())
+				n++
+			}
+			require.NoError(t, r.Err())
+			assert.Equal(t, int64(15), n)
+		})
+	}
+
+	t.Run("past end", func(t *testing.T) {
+		r, err := NewReader(bytes.NewReader(writeTestFile(t, compressionNone, true, 1, 5)))
+		require.NoError(t, err)
+		assert.Error(t, r.Skip(6))
+	})
+}
+
+func TestReaderErrors(t *testing.T) {
+	t.Run("not orc", func(t *testing.T) {
+		_, err := NewReader(bytes.NewReader([]byte("Obj\x01 not an orc file")))
+		assert.ErrorContains(t, err, "not an orc file")
+	})
+
+	t.Run("unsupported compression", func(t *testing.T) {
+		_, err := NewReader(bytes.NewReader(writeTestFile(t, compressionLZO, true, 0, 0)))
+		assert.ErrorContains(t, err, "unsupported orc compression LZO")
+	})
+
+	t.Run("block size too large", func(t *testing.T) {
+		_, err := NewReader(bytes.NewReader(writeTestFileWithBlockSize(t, compressionLZ4, true, 1, 5, maxBlockSize+1)))
+		assert.ErrorContains(t, err, "unsupported orc compression block size")
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		data := writeTestFile(t, compressionNone, true, 1, 5)
+		_, err := NewReader(bytes.NewReader(data[:len(data)-10]))
+		assert.Error(t, err)
+	})
+
+	t.Run("corrupt stripe", func(t *testing.T) {
+		data := writeTestFile(t, compressionNone, true, 2, 5)
+		// Truncate the lengths of the first stripe.
+		copy(data[len(magic):], bytes.Repeat([]byte{0xff}, 16))
+		r, err := NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		var n int
+		for r.Next() {
+			n++
+		}
+		assert.Less(t, n, 10)
+		assert.Error(t, r.Err())
+	})
+}
+
+func TestReaderDefaultBlockSize(t *testing.T) {
+	r, err := NewReader(bytes.NewReader(writeTestFileWithBlockSize(t, compressionLZ4, true, 2, 5, 0)))
+	require.NoError(t, err)
+	defer r.Close()
+
+	var n int64
+	for r.Next() {
+		assert.Equal(t, testRow(n), r.Value())
+		n++
+	}
+	require.NoError(t, r.Err())
+	assert.Equal(t, int64(10), n)
+}
+
+func TestFormatDecimal(t *testing.T) {
+	tests := []struct {
+		unscaled int64
+		scale    int64
+		want     string
+		err      string
+	}{
+		{unscaled: 12345, scale: 2, want: "123.45"},
+		{unscaled: -5, scale: 3, want: "-0.005"},
+		{unscaled: 12, scale: -2, want: "1200"},
+		{unscaled: 1, scale: 38, want: "0.00000000000000000000000000000000000001"},
+		{unscaled: 1, scale: 39, err: "invalid decimal scale 39"},
+		{unscaled: 1, scale: -39, err: "invalid decimal scale -39"},
+		{unscaled: 1, scale: 0x60000000000002, err: "invalid decimal scale"},
+	}
+	for _, tc := range tests {
+		got, err := formatDecimal(big.NewInt(tc.unscaled), tc.scale)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got)
+	}
+}
+
+func testRow(n int64) map[string]any {
+	var name any
+	if n%3 != 0 {
+		name = "name-" + strconv.FormatInt(n%2, 10)
+	}
+	tags := []any{}
+	for i := range n % 3 {
+		tags = append(tags, "tag-"+strconv.FormatInt(i, 10))
+	}
+	return map[string]any{
+		"id":    n,
+		"name":  name,
+		"tags":  tags,
+		"ok":    n%2 == 0,
+		"ts":    time.Date(2020, 1, 1, 0, 0, int(n), int(n)*1000, time.UTC),
+		"price": strconv.FormatInt(n, 10) + ".05",
+		"score": float32(n) / 2,
+	}
+}
+
+// writeTestFile writes an ORC file holding stripes of rows created by
+// testRow. Its schema is
+// struct<id:bigint,name:string,tags:array<string>,ok:boolean,ts:timestamp,price:decimal(10,2),score:float>.
+func writeTestFile(t *testing.T, compression compressionKind, v2 bool, stripes, rows int) []byte {
+	t.Helper()
+	return writeTestFileWithBlockSize(t, compression, v2, stripes, rows, 256*1024)
+}
+
+// writeTestFileWithBlockSize writes a test file with the compression block
+// size in its postscript, which is omitted if blockSize is 0.
+func writeTestFileWithBlockSize(t *testing.T, compression compressionKind, v2 bool, stripes, rows int, blockSize uint64) []byte {
+	t.Helper()
+
+	w := testWriter{t: t, compression: compression, v2: v2}
+	w.buf.WriteString(magic)
+
+	var stripeInfos [][]byte
+	var n int64
+	for range stripes {
+		offset := w.buf.Len()
+		var (
+			ids, nameIndexes, tagLengths, tagItemLengths, seconds, nanos, prices, scales []int64
+			present, oks                                                                 []bool
+			tagItems                                                                     []byte
+			scores                                                                       []byte
+		)
+		for range rows {
+			row := testRow(n)
+			ids = append(ids, n)
+			present = append(present, row["name"] != nil)
+			if row["name"] != nil {
+				nameIndexes = append(nameIndexes, n%2)
+			}
+			tags := row["tags"].([]any)
+			tagLengths = append(tagLengths, int64(len(tags)))
+			for _, tag := range tags {
+				tagItems = append(tagItems, tag.(string)...)
+				tagItemLengths = append(tagItemLengths, int64(len(tag.(string))))
+			}
+			oks = append(oks, row["ok"].(bool))
+			ts := row["ts"].(time.Time)
+			seconds = append(seconds, ts.Unix()-timestampBase.Unix())
+			nanos = append(nanos, encodeNanos(int64(ts.Nanosecond())))
+			prices = append(prices, n*100+5)
+			scales = append(scales, 2)
+			scores = binary.LittleEndian.AppendUint32(scores, math.Float32bits(row["score"].(float32)))
+			n++
+		}
+
+		var priceData []byte
+		for _, p := range prices {
+			priceData = binary.AppendUvarint(priceData, uint64(p<<1)^uint64(p>>63)) //nolint:gosec // G115: zig-zag encoding
+		}
+		streams := []struct {
+			column int
+			kind   streamKind
+			data   []byte
+		}{
+			{1, streamData, w.ints(ids, true)},
+			{2, streamPresent, encodeBools(present)},
+			{2, streamData, w.ints(nameIndexes, false)},
+			{2, streamLength, w.ints([]int64{6, 6}, false)},
+			{2, streamDictionaryData, []byte("name-0name-1")},
+			{3, streamLength, w.ints(tagLengths, false)},
+			{4, streamData, tagItems},
+			{4, streamLength, w.ints(tagItemLengths, false)},
+			{5, streamData, encodeBools(oks)},
+			{6, streamData, w.ints(seconds, true)},
+			{6, streamSecondary, w.ints(nanos, false)},
+			{7, streamData, priceData},
+			{7, streamSecondary, w.ints(scales, true)},
+			{8, streamData, scores},
+		}
+
+		var stripeFooter []byte
+		for _, s := range streams {
+			data := w.compress(s.data)
+			w.buf.Write(data)
+			var stream []byte
+			stream = appendVarintField(stream, 1, uint64(s.kind))
+			stream = appendVarintField(stream, 2, uint64(s.column)) //nolint:gosec // G115: test column ids are positive
+			stream = appendVarintField(stream, 3, uint64(len(data)))
+			stripeFooter = appendBytesField(stripeFooter, 1, stream)
+		}
+		direct, dictionary := encodingDirect, encodingDictionary
+		if v2 {
+			direct, dictionary = encodingDirectV2, encodingDictionaryV2
+		}
+		for column := range 9 {
+			e := direct
+			if column == 2 {
+				e = dictionary
+			}
+			stripeFooter = appendBytesField(stripeFooter, 2, appendVarintField(nil, 1, uint64(e)))
+		}
+		dataLength := w.buf.Len() - offset
+		stripeFooter = w.compress(stripeFooter)
+		w.buf.Write(stripeFooter)
+
+		var info []byte
+		info = appendVarintField(info, 1, uint64(offset))
+		info = appendVarintField(info, 2, 0)
+		info = appendVarintField(info, 3, uint64(dataLength))
+		info = appendVarintField(info, 4, uint64(len(stripeFooter)))
+		info = appendVarintField(info, 5, uint64(rows))
+		stripeInfos = append(stripeInfos, info)
+	}
+
+	var footer []byte
+	for _, info := range stripeInfos {
+		footer = appendBytesField(footer, 3, info)
+	}
+	root := appendVarintField(nil, 1, uint64(kindStruct))
+	for i, name := range []string{"id", "name", "tags", "ok", "ts", "price", "score"} {
+		sub := i + 1
+		if sub > 3 {
+			sub++ // the item type of tags
+		}
+		root = appendVarintField(root, 2, uint64(sub)) //nolint:gosec // G115: test type ids are positive
+		root = appendBytesField(root, 3, []byte(name))
+	}
+	types := [][]byte{
+		root,
+		appendVarintField(nil, 1, uint64(kindLong)),
+		appendVarintField(nil, 1, uint64(kindString)),
+		appendVarintField(appendVarintField(nil, 1, uint64(kindList)), 2, 4),
+		appendVarintField(nil, 1, uint64(kindString)),
+		appendVarintField(nil, 1, uint64(kindBoolean)),
+		appendVarintField(nil, 1, uint64(kindTimestamp)),
+		appendVarintField(nil, 1, uint64(kindDecimal)),
+		appendVarintField(nil, 1, uint64(kindFloat)),
+	}
+	for _, typ := range types {
+		footer = appendBytesField(footer, 4, typ)
+	}
+	footer = appendVarintField(footer, 6, uint64(n)) //nolint:gosec // G115: n is positive
+	footer = w.compress(footer)
+	w.buf.Write(footer)
+
+	var ps []byte
+	ps = appendVarintField(ps, 1, uint64(len(footer)))
+	ps = appendVarintField(ps, 2, uint64(compression))
+	if blockSize > 0 {
+		ps = appendVarintField(ps, 3, blockSize)
+	}
+	ps = appendBytesField(ps, 8000, []byte(magic))
+	w.buf.Write(ps)
+	w.buf.WriteByte(byte(len(ps)))
+	return w.buf.Bytes()
+}
+
+type testWriter struct {
+	t           *testing.T
+	buf         bytes.Buffer
+	compression compressionKind
+	v2          bool
+}
+
+// ints encodes integers as literals, with version 1 of integer run length
+// encoding, or as direct runs of 64 bits wide values with version 2.
+func (w *testWriter) ints(values []int64, signed bool) []byte {
+	var b []byte
+	for len(values) > 0 {
+		n := min(len(values), 128)
+		if w.v2 {
+			b = append(b, byte(direct<<6|31<<1|(n-1)>>8), byte(n-1))
+		} else {
+			b = append(b, byte(-n))
+		}
+		for _, v := range values[:n] {
+			u := uint64(v) //nolint:gosec // G115: unsigned values are stored in int64
+			if signed {
+				u = uint64(v<<1) ^ uint64(v>>63) //nolint:gosec // G115: zig-zag encoding
+			}
+			if w.v2 {
+				b = binary.BigEndian.AppendUint64(b, u)
+			} else {
+				b = binary.AppendUvarint(b, u)
+			}
+		}
+		values = values[n:]
+	}
+	return b
+}
+
+// compress compresses data as a single chunk, stored uncompressed if it
+// doesn't get smaller.
+func (w *testWriter) compress(data []byte) []byte {
+	var compressed []byte
+	switch w.compression {
+	case compressionNone, compressionLZO:
+		return data
+	case compressionZlib:
+		var buf bytes.Buffer
+		fw, err := flate.NewWriter(&buf, flate.BestCompression)
+		require.NoError(w.t, err)
+		_, err = fw.Write(data)
+		require.NoError(w.t, err)
+		require.NoError(w.t, fw.Close())
+		compressed = buf.Bytes()
+	case compressionSnappy:
+		compressed = snappy.Encode(nil, data)
+	case compressionLZ4:
+		compressed = make([]byte, lz4.CompressBlockBound(len(data)))
+		n, err := lz4.CompressBlock(data, compressed, nil)
+		require.NoError(w.t, err)
+		compressed = compressed[:n]
+		if n == 0 {
+			// Incompressible data.
+			compressed = data
+		}
+	case compressionZstd:
+		zw, err := zstd.NewWriter(nil)
+		require.NoError(w.t, err)
+		compressed = zw.EncodeAll(data, nil)
+		zw.Close()
+	}
+	header := len(compressed) << 1
+	if len(compressed) >= len(data) {
+		compressed = data
+		header = len(data)<<1 | 1
+	}
+	return append([]byte{byte(header), byte(header >> 8), byte(header >> 16)}, compressed...)
+}
+
+// encodeBools encodes booleans as byte literals.
+func encodeBools(values []bool) []byte {
+	packed := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			packed[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	var b []byte
+	for len(packed) > 0 {
+		n := min(len(packed), 128)
+		b = append(b, byte(-n))
+		b = append(b, packed[:n]...)
+		packed = packed[n:]
+	}
+	return b
+}
+
+// encodeNanos encodes nanoseconds, moving the number of trailing zeros to
+// the 3 low bits.
+func encodeNanos(ns int64) int64 {
+	if ns == 0 {
+		return 0
+	}
+	zeros := int64(0)
+	for ns%10 == 0 && zeros < 8 {
+		ns /= 10
+		zeros++
+	}
+	if zeros < 2 {
+		for range zeros {
+			ns *= 10
+		}
+		return ns << 3
+	}
+	return ns<<3 | (zeros - 1)
+}
+
+func appendVarintField(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendBytesField(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
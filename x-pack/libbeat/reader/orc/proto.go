@@ -0,0 +1,318 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package orc
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The metadata of ORC files is made of protocol buffers messages, only the
+// fields needed to read the rows are decoded.
+
+type compressionKind uint64
+
+const (
+	compressionNone compressionKind = iota
+	compressionZlib
+	compressionSnappy
+	compressionLZO
+	compressionLZ4
+	compressionZstd
+)
+
+type postScript struct {
+	footerLength uint64
+	compression  compressionKind
+	blockSize    uint64
+	magic        string
+}
+
+type footer struct {
+	stripes []stripeInfo
+	types   []orcType
+	rows    uint64
+}
+
+type stripeInfo struct {
+	offset       uint64
+	indexLength  uint64
+	dataLength   uint64
+	footerLength uint64
+	rows         uint64
+}
+
+type typeKind uint64
+
+const (
+	kindBoolean typeKind = iota
+	kindByte
+	kindShort
+	kindInt
+	kindLong
+	kindFloat
+	kindDouble
+	kindString
+	kindBinary
+	kindTimestamp
+	kindList
+	kindMap
+	kindStruct
+	kindUnion
+	kindDecimal
+	kindDate
+	kindVarchar
+	kindChar
+	kindTimestampInstant
+)
+
+type orcType struct {
+	kind     typeKind
+	subtypes []uint32
+	names    []string
+}
+
+type stripeFooter struct {
+	streams   []streamInfo
+	encodings []encodingKind
+	timezone  string
+}
+
+type streamKind uint64
+
+const (
+	streamPresent streamKind = iota
+	streamData
+	streamLength
+	streamDictionaryData
+	streamDictionaryCount
+	streamSecondary
+)
+
+type streamInfo struct {
+	kind   streamKind
+	column uint64
+	length uint64
+}
+
+type encodingKind uint64
+
+const (
+	encodingDirect encodingKind = iota
+	encodingDictionary
+	encodingDirectV2
+	encodingDictionaryV2
+)
+
+func (k encodingKind) v2() bool {
+	return k == encodingDirectV2 || k == encodingDictionaryV2
+}
+
+func (k encodingKind) dictionary() bool {
+	return k == encodingDictionary || k == encodingDictionaryV2
+}
+
+var errInvalidMessage = errors.New("invalid protocol buffers message")
+
+// parseMessage calls fn for each field of a message. v holds the value of
+// varint fields and b the value of length-delimited fields.
+func parseMessage(data []byte, fn func(num protowire.Number, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return errInvalidMessage
+		}
+		data = data[n:]
+
+		var (
+			v uint64
+			b []byte
+		)
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(data)
+		case protowire.BytesType:
+			b, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return errInvalidMessage
+			}
+			data = data[n:]
+			continue
+		}
+		if n < 0 {
+			return errInvalidMessage
+		}
+		data = data[n:]
+		if err := fn(num, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parsePostScript(data []byte) (postScript, error) {
+	var ps postScript
+	err := parseMessage(data, func(num protowire.Number, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			ps.footerLength = v
+		case 2:
+			ps.compression = compressionKind(v)
+		case 3:
+			ps.blockSize = v
+		case 8000:
+			ps.magic = string(b)
+		}
+		return nil
+	})
+	return ps, err
+}
+
+func parseFooter(data []byte) (footer, error) {
+	var f footer
+	err := parseMessage(data, func(num protowire.Number, v uint64, b []byte) error {
+		switch num {
+		case 3:
+			s, err := parseStripeInfo(b)
+			if err != nil {
+				return err
+			}
+			f.stripes = append(f.stripes, s)
+		case 4:
+			t, err := parseType(b)
+			if err != nil {
+				return err
+			}
+			f.types = append(f.types, t)
+		case 6:
+			f.rows = v
+		}
+		return nil
+	})
+	return f, err
+}
+
+func parseStripeInfo(data []byte) (stripeInfo, error) {
+	var s stripeInfo
+	err := parseMessage(data, func(num protowire.Number, v uint64, _ []byte) error {
+		switch num {
+		case 1:
+			s.offset = v
+		case 2:
+			s.indexLength = v
+		case 3:
+			s.dataLength = v
+		case 4:
+			s.footerLength = v
+		case 5:
+			s.rows = v
+		}
+		return nil
+	})
+	return s, err
+}
+
+func parseType(data []byte) (orcType, error) {
+	var t orcType
+	err := parseMessage(data, func(num protowire.Number, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			t.kind = typeKind(v)
+		case 2:
+			// Repeated scalar fields may be packed or not.
+			if b == nil {
+				t.subtypes = append(t.subtypes, uint32(v)) //nolint:gosec // G115: subtypes are uint32
+				break
+			}
+			for len(b) > 0 {
+				v, n := protowire.ConsumeVarint(b)
+				if n < 0 {
+					return errInvalidMessage
+				}
+				t.subtypes = append(t.subtypes, uint32(v)) //nolint:gosec // G115: subtypes are uint32
+				b = b[n:]
+			}
+		case 3:
+			t.names = append(t.names, string(b))
+		}
+		return nil
+	})
+	return t, err
+}
+
+func parseStripeFooter(data []byte) (stripeFooter, error) {
+	var f stripeFooter
+	err := parseMessage(data, func(num protowire.Number, _ uint64, b []byte) error {
+		switch num {
+		case 1:
+			var s streamInfo
+			err := parseMessage(b, func(num protowire.Number, v uint64, _ []byte) error {
+				switch num {
+				case 1:
+					s.kind = streamKind(v)
+				case 2:
+					s.column = v
+				case 3:
+					s.length = v
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			f.streams = append(f.streams, s)
+		case 2:
+			var e encodingKind
+			err := parseMessage(b, func(num protowire.Number, v uint64, _ []byte) error {
+				if num == 1 {
+					e = encodingKind(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			f.encodings = append(f.encodings, e)
+		case 3:
+			f.timezone = string(b)
+		}
+		return nil
+	})
+	return f, err
+}
+
+// validateTypes checks that the subtypes of each type are valid and come
+// after it, so the type tree can't have cycles.
+func validateTypes(types []orcType) error {
+	if len(types) == 0 {
+		return errors.New("file has no schema")
+	}
+	for i, t := range types {
+		for _, sub := range t.subtypes {
+			if int(sub) <= i || int(sub) >= len(types) {
+				return fmt.Errorf("invalid subtype %d of type %d", sub, i)
+			}
+		}
+		var want int
+		switch t.kind {
+		case kindList:
+			want = 1
+		case kindMap:
+			want = 2
+		case kindStruct:
+			want = len(t.names)
+		case kindUnion:
+			want = len(t.subtypes)
+		}
+		if len(t.subtypes) != want {
+			return fmt.Errorf("invalid number of subtypes of type %d", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Package orc reads Apache ORC files.
+//
+// The rows of a file are stored in stripes, each of them holding the
+// compressed columns of a group of rows. Rows are decoded to values that can
+// be serialized to JSON: structs and maps are decoded to map[string]any,
+// lists to []any, and unions to the value of their branch.
+package orc
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+const magic = "ORC"
+
+const (
+	// defaultBlockSize is the size of the compression chunks of files that
+	// don't record it in their postscript.
+	defaultBlockSize = 256 * 1024
+	// maxBlockSize bounds the size of the compression chunks, since a
+	// buffer of that size is allocated to decompress LZ4 chunks.
+	maxBlockSize = 4 * 1024 * 1024
+)
+
+// Reader reads the rows of an ORC file. The whole file is held in memory,
+// since its metadata is at its end.
+type Reader struct {
+	data        []byte
+	compression compressionKind
+	blockSize   uint64
+	types       []orcType
+	stripes     []stripeInfo
+	zstd        *zstd.Decoder
+	lz4         []byte // Buffer of blockSize bytes the LZ4 chunks are decompressed in.
+
+	// stripe is the index of the current stripe, -1 before the first one.
+	stripe int
+	// remaining is the number of rows of the current stripe that weren't
+	// read yet.
+	remaining uint64
+	root      *column
+
+	value any
+	err   error
+}
+
+// NewReader reads an ORC file and its metadata.
+func NewReader(r io.Reader) (*Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read orc file: %w", err)
+	}
+	if len(data) < len(magic)+1 || string(data[:len(magic)]) != magic {
+		return nil, errors.New("not an orc file")
+	}
+
+	// The file ends with the postscript, followed by its length.
+	psLength := int(data[len(data)-1])
+	psStart := len(data) - 1 - psLength
+	if psStart < len(magic) {
+		return nil, errors.New("invalid orc postscript")
+	}
+	ps, err := parsePostScript(data[psStart : len(data)-1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid orc postscript: %w", err)
+	}
+	if ps.magic != magic {
+		return nil, errors.New("invalid orc postscript")
+	}
+	switch ps.compression {
+	case compressionNone, compressionZlib, compressionSnappy, compressionLZ4, compressionZstd:
+	case compressionLZO:
+		return nil, errors.New("unsupported orc compression LZO")
+	default:
+		return nil, fmt.Errorf("unsupported orc compression %d", ps.compression)
+	}
+
+	if ps.blockSize == 0 {
+		ps.blockSize = defaultBlockSize
+	}
+	if ps.blockSize > maxBlockSize {
+		return nil, fmt.Errorf("unsupported orc compression block size %d", ps.blockSize)
+	}
+
+	or := &Reader{
+		data:        data,
+		compression: ps.compression,
+		blockSize:   ps.blockSize,
+		stripe:      -1,
+	}
+	if or.compression == compressionZstd {
+		or.zstd, err = zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if ps.footerLength > uint64(psStart-len(magic)) { //nolint:gosec // G115: psStart is positive
+		return nil, errors.New("invalid orc footer length")
+	}
+	b, err := or.section(uint64(psStart)-ps.footerLength, ps.footerLength) //nolint:gosec // G115: psStart is positive
+	if err != nil {
+		return nil, fmt.Errorf("failed to read orc footer: %w", err)
+	}
+	f, err := parseFooter(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid orc footer: %w", err)
+	}
+	if err := validateTypes(f.types); err != nil {
+		return nil, fmt.Errorf("invalid orc schema: %w", err)
+	}
+	or.types = f.types
+	or.stripes = f.stripes
+	return or, nil
+}
+
+// section returns the decompressed content of a section of the file.
+func (r *Reader) section(offset, length uint64) ([]byte, error) {
+	if offset > uint64(len(r.data)) || length > uint64(len(r.data))-offset {
+		return nil, errors.New("section out of file bounds")
+	}
+	return r.decompress(r.data[offset : offset+length])
+}
+
+// decompress decompresses a sequence of compressed chunks. Each of them
+// starts with a 3 bytes header holding its length and whether it is stored
+// uncompressed.
+func (r *Reader) decompress(b []byte) ([]byte, error) {
+	if r.compression == compressionNone {
+		return b, nil
+	}
+	var out []byte
+	for len(b) > 0 {
+		if len(b) < 3 {
+			return nil, errShortStream
+		}
+		header := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+		n := int(header >> 1)
+		if len(b)-3 < n {
+			return nil, errShortStream
+		}
+		chunk := b[3 : 3+n]
+		b = b[3+n:]
+		if header&1 == 1 {
+			out = append(out, chunk...)
+			continue
+		}
+
+		var err error
+		switch r.compression {
+		case compressionZlib:
+			var buf bytes.Buffer
+			_, err = io.Copy(&buf, flate.NewReader(bytes.NewReader(chunk)))
+			out = append(out, buf.Bytes()...)
+		case compressionSnappy:
+			var dec []byte
+			dec, err = snappy.Decode(nil, chunk)
+			out = append(out, dec...)
+		case compressionLZ4:
+			if r.lz4 == nil {
+				r.lz4 = make([]byte, r.blockSize)
+			}
+			n, err = lz4.UncompressBlock(chunk, r.lz4)
+			out = append(out, r.lz4[:max(n, 0)]...)
+		case compressionZstd:
+			out, err = r.zstd.DecodeAll(chunk, out)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Next advances to the next row. It returns false at the end of the file or
+// if an error occurred, which is returned by Err.
+func (r *Reader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	for r.remaining == 0 {
+		if r.stripe+1 >= len(r.stripes) {
+			return false
+		}
+		r.err = r.loadStripe(r.stripe + 1)
+		if r.err != nil {
+			return false
+		}
+	}
+	r.value, r.err = r.root.next()
+	if r.err != nil {
+		r.err = fmt.Errorf("failed to decode row of stripe %d: %w", r.stripe, r.err)
+		return false
+	}
+	r.remaining--
+	return true
+}
+
+// Value returns the current row.
+func (r *Reader) Value() any {
+	return r.value
+}
+
+// Err returns the error that stopped the reader, if any.
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Stripe returns the index of the stripe of the current row.
+func (r *Reader) Stripe() int64 {
+	return int64(r.stripe)
+}
+
+// EndOfStripe returns whether the current row is the last one of its
+// stripe.
+func (r *Reader) EndOfStripe() bool {
+	return r.remaining == 0
+}
+
+// Skip skips n rows. Stripes holding only skipped rows are neither
+// decompressed nor decoded.
+func (r *Reader) Skip(n int64) error {
+	for n > 0 && r.err == nil {
+		if r.remaining == 0 && r.stripe+1 < len(r.stripes) {
+			if rows := r.stripes[r.stripe+1].rows; rows <= uint64(n) {
+				r.stripe++
+				n -= int64(rows) //nolint:gosec // G115: rows is at most n
+				continue
+			}
+		}
+		if !r.Next() {
+			if r.err == nil {
+				r.err = io.EOF
+			}
+			break
+		}
+		n--
+	}
+	return r.err
+}
+
+func (r *Reader) loadStripe(i int) error {
+	info := r.stripes[i]
+	b, err := r.section(info.offset+info.indexLength+info.dataLength, info.footerLength)
+	if err != nil {
+		return fmt.Errorf("failed to read footer of stripe %d: %w", i, err)
+	}
+	f, err := parseStripeFooter(b)
+	if err != nil {
+		return fmt.Errorf("invalid footer of stripe %d: %w", i, err)
+	}
+
+	s := &stripe{
+		streams:   make(map[streamKey][]byte),
+		encodings: f.encodings,
+		location:  time.UTC,
+	}
+	if f.timezone != "" {
+		if loc, err := time.LoadLocation(f.timezone); err == nil {
+			s.location = loc
+		}
+	}
+	// The streams are stored one after the other, the index streams first.
+	offset := info.offset
+	for _, stream := range f.streams {
+		if stream.kind <= streamSecondary {
+			s.streams[streamKey{stream.column, stream.kind}], err = r.section(offset, stream.length)
+			if err != nil {
+				return fmt.Errorf("failed to read stream of column %d of stripe %d: %w", stream.column, i, err)
+			}
+		}
+		offset += stream.length
+	}
+
+	r.root, err = newColumn(r.types, s, 0)
+	if err != nil {
+		return fmt.Errorf("invalid stripe %d: %w", i, err)
+	}
+	r.stripe = i
+	r.remaining = info.rows
+	return nil
+}
+
+// Close releases the resources of the reader.
+func (r *Reader) Close() error {
+	if r.zstd != nil {
+		r.zstd.Close()
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package orc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var errShortStream = errors.New("unexpected end of stream")
+
+// decodeByteRLE decodes a stream of bytes encoded with byte run length
+// encoding.
+func decodeByteRLE(b []byte) ([]byte, error) {
+	var out []byte
+	for len(b) > 0 {
+		control := int8(b[0])
+		b = b[1:]
+		if control >= 0 {
+			// A run of control+3 copies of the next byte.
+			if len(b) < 1 {
+				return nil, errShortStream
+			}
+			for range int(control) + 3 {
+				out = append(out, b[0])
+			}
+			b = b[1:]
+			continue
+		}
+		// -control literal bytes.
+		n := -int(control)
+		if len(b) < n {
+			return nil, errShortStream
+		}
+		out = append(out, b[:n]...)
+		b = b[n:]
+	}
+	return out, nil
+}
+
+// decodeIntRLE decodes a stream of integers encoded with version 1 or 2 of
+// integer run length encoding.
+func decodeIntRLE(b []byte, signed, v2 bool) ([]int64, error) {
+	d := intDecoder{buf: b, signed: signed}
+	for len(d.buf) > 0 {
+		var err error
+		if v2 {
+			err = d.runV2()
+		} else {
+			err = d.runV1()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return d.out, nil
+}
+
+type intDecoder struct {
+	buf    []byte
+	signed bool
+	out    []int64
+}
+
+func (d *intDecoder) byte() (byte, error) {
+	if len(d.buf) == 0 {
+		return 0, errShortStream
+	}
+	c := d.buf[0]
+	d.buf = d.buf[1:]
+	return c, nil
+}
+
+func (d *intDecoder) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		return 0, errShortStream
+	}
+	d.buf = d.buf[n:]
+	return v, nil
+}
+
+func (d *intDecoder) varint(signed bool) (int64, error) {
+	v, err := d.uvarint()
+	if signed {
+		return unzigzag(v), err
+	}
+	return int64(v), err //nolint:gosec // G115: unsigned values are stored in int64
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1) //nolint:gosec // G115: zig-zag decoding
+}
+
+func (d *intDecoder) runV1() error {
+	control, err := d.byte()
+	if err != nil {
+		return err
+	}
+	if int8(control) >= 0 {
+		// A run of control+3 values, starting at a base value and changing
+		// by a delta.
+		delta, err := d.byte()
+		if err != nil {
+			return err
+		}
+		base, err := d.varint(d.signed)
+		if err != nil {
+			return err
+		}
+		for i := range int64(control) + 3 {
+			d.out = append(d.out, base+i*int64(int8(delta)))
+		}
+		return nil
+	}
+	for range -int(int8(control)) {
+		v, err := d.varint(d.signed)
+		if err != nil {
+			return err
+		}
+		d.out = append(d.out, v)
+	}
+	return nil
+}
+
+// Sub-encodings of version 2 of integer run length encoding.
+const (
+	shortRepeat = iota
+	direct
+	patchedBase
+	delta
+)
+
+func (d *intDecoder) runV2() error {
+	header, err := d.byte()
+	if err != nil {
+		return err
+	}
+	switch header >> 6 {
+	case shortRepeat:
+		width := int((header>>3)&7) + 1
+		count := int(header&7) + 3
+		if len(d.buf) < width {
+			return errShortStream
+		}
+		var v uint64
+		for _, c := range d.buf[:width] {
+			v = v<<8 | uint64(c)
+		}
+		d.buf = d.buf[width:]
+		value := int64(v) //nolint:gosec // G115: unsigned values are stored in int64
+		if d.signed {
+			value = unzigzag(v)
+		}
+		for range count {
+			d.out = append(d.out, value)
+		}
+		return nil
+
+	case direct:
+		width := decodeWidth(header >> 1 & 0x1f)
+		count, err := d.runLength(header)
+		if err != nil {
+			return err
+		}
+		values, err := d.unpack(count, width)
+		if err != nil {
+			return err
+		}
+		for _, v := range values {
+			if d.signed {
+				d.out = append(d.out, unzigzag(v))
+			} else {
+				d.out = append(d.out, int64(v)) //nolint:gosec // G115: unsigned values are stored in int64
+			}
+		}
+		return nil
+
+	case patchedBase:
+		return d.patchedBase(header)
+
+	default:
+		return d.delta(header)
+	}
+}
+
+// runLength returns the length of a direct, patched base or delta run,
+// stored in the 9 bits following the width.
+func (d *intDecoder) runLength(header byte) (int, error) {
+	c, err := d.byte()
+	if err != nil {
+		return 0, err
+	}
+	return (int(header&1)<<8 | int(c)) + 1, nil
+}
+
+func (d *intDecoder) patchedBase(header byte) error {
+	width := decodeWidth(header >> 1 & 0x1f)
+	count, err := d.runLength(header)
+	if err != nil {
+		return err
+	}
+	if len(d.buf) < 2 {
+		return errShortStream
+	}
+	baseWidth := int(d.buf[0]>>5) + 1
+	patchWidth := decodeWidth(d.buf[0] & 0x1f)
+	gapWidth := int(d.buf[1]>>5) + 1
+	patches := int(d.buf[1] & 0x1f)
+	d.buf = d.buf[2:]
+
+	// The base value is stored big-endian, its most significant bit being
+	// the sign.
+	if len(d.buf) < baseWidth {
+		return errShortStream
+	}
+	var base uint64
+	for _, c := range d.buf[:baseWidth] {
+		base = base<<8 | uint64(c)
+	}
+	d.buf = d.buf[baseWidth:]
+	signBit := uint64(1) << (baseWidth*8 - 1)
+	baseValue := int64(base &^ signBit) //nolint:gosec // G115: the sign bit was cleared
+	if base&signBit != 0 {
+		baseValue = -baseValue
+	}
+
+	values, err := d.unpack(count, width)
+	if err != nil {
+		return err
+	}
+	if gapWidth+patchWidth > 64 {
+		return fmt.Errorf("invalid patch width %d", gapWidth+patchWidth)
+	}
+	list, err := d.unpack(patches, closestFixedWidth(gapWidth+patchWidth))
+	if err != nil {
+		return err
+	}
+	pos := 0
+	for _, patch := range list {
+		pos += int(patch >> patchWidth) //nolint:gosec // G115: the gap is at most 8 bits wide
+		if pos >= count {
+			return errors.New("invalid patch position")
+		}
+		values[pos] |= (patch & (1<<patchWidth - 1)) << width
+	}
+	for _, v := range values {
+		d.out = append(d.out, baseValue+int64(v)) //nolint:gosec // G115: patched values fit in int64
+	}
+	return nil
+}
+
+func (d *intDecoder) delta(header byte) error {
+	width := 0
+	if w := header >> 1 & 0x1f; w != 0 {
+		width = decodeWidth(w)
+	}
+	count, err := d.runLength(header)
+	if err != nil {
+		return err
+	}
+	base, err := d.varint(d.signed)
+	if err != nil {
+		return err
+	}
+	deltaBase, err := d.varint(true)
+	if err != nil {
+		return err
+	}
+
+	d.out = append(d.out, base)
+	if count == 1 {
+		return nil
+	}
+	if width == 0 {
+		// All values change by the same delta.
+		for i := range int64(count - 1) {
+			d.out = append(d.out, base+(i+1)*deltaBase)
+		}
+		return nil
+	}
+
+	// The next value changes by the delta base, and the following ones by
+	// packed deltas with its sign.
+	v := base + deltaBase
+	d.out = append(d.out, v)
+	deltas, err := d.unpack(count-2, width)
+	if err != nil {
+		return err
+	}
+	for _, delta := range deltas {
+		if deltaBase < 0 {
+			v -= int64(delta) //nolint:gosec // G115: deltas fit in int64
+		} else {
+			v += int64(delta) //nolint:gosec // G115: deltas fit in int64
+		}
+		d.out = append(d.out, v)
+	}
+	return nil
+}
+
+// unpack reads count values packed big-endian on width bits.
+func (d *intDecoder) unpack(count, width int) ([]uint64, error) {
+	size := (count*width + 7) / 8
+	if len(d.buf) < size {
+		return nil, errShortStream
+	}
+	values := make([]uint64, count)
+	var bits, acc uint64
+	b := d.buf[:size]
+	for i := range values {
+		var v uint64
+		for need := width; need > 0; {
+			if bits == 0 {
+				acc = uint64(b[0])
+				b = b[1:]
+				bits = 8
+			}
+			n := min(uint64(need), bits)
+			v = v<<n | (acc>>(bits-n))&(1<<n-1)
+			bits -= n
+			need -= int(n) //nolint:gosec // G115: n is at most 8
+		}
+		values[i] = v
+	}
+	d.buf = d.buf[size:]
+	return values, nil
+}
+
+// decodeWidth returns the bit width encoded on 5 bits.
+func decodeWidth(w byte) int {
+	if w < 24 {
+		return int(w) + 1
+	}
+	return [...]int{26, 28, 30, 32, 40, 48, 56, 64}[w-24]
+}
+
+// closestFixedWidth returns the smallest width that can be encoded on 5
+// bits holding w bits.
+func closestFixedWidth(w int) int {
+	if w <= 24 {
+		return max(w, 1)
+	}
+	for _, fixed := range []int{26, 28, 30, 32, 40, 48, 56} {
+		if w <= fixed {
+			return fixed
+		}
+	}
+	return 64
+}
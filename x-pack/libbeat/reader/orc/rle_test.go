@@ -0,0 +1,112 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package orc
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The examples of the ORC specification.
+
+func TestDecodeByteRLE(t *testing.T) {
+	got, err := decodeByteRLE([]byte{0x61, 0x00, 0xfe, 0x44, 0x45})
+	require.NoError(t, err)
+	assert.Equal(t, append(make([]byte, 100), 0x44, 0x45), got)
+
+	_, err = decodeByteRLE([]byte{0xfe, 0x44})
+	assert.ErrorIs(t, err, errShortStream)
+}
+
+func TestDecodeIntRLEv1(t *testing.T) {
+	tests := map[string]struct {
+		data []byte
+		want []int64
+	}{
+		"run": {
+			data: []byte{0x61, 0x00, 0x07},
+			want: slices.Repeat([]int64{7}, 100),
+		},
+		"run with delta": {
+			data: []byte{0x61, 0xff, 0x64},
+			want: countdown(100),
+		},
+		"literals": {
+			data: []byte{0xfb, 0x02, 0x03, 0x06, 0x07, 0x0b},
+			want: []int64{2, 3, 6, 7, 11},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := decodeIntRLE(test.data, false, false)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestDecodeIntRLEv2(t *testing.T) {
+	tests := map[string]struct {
+		data   []byte
+		signed bool
+		want   []int64
+	}{
+		"short repeat": {
+			data: []byte{0x0a, 0x27, 0x10},
+			want: []int64{10000, 10000, 10000, 10000, 10000},
+		},
+		"direct": {
+			data: []byte{0x5e, 0x03, 0x5c, 0xa1, 0xab, 0x1e, 0xde, 0xad, 0xbe, 0xef},
+			want: []int64{23713, 43806, 57005, 48879},
+		},
+		"patched base": {
+			data: []byte{
+				0x8e, 0x13, 0x2b, 0x21, 0x07, 0xd0, 0x1e, 0x00, 0x14, 0x70, 0x28, 0x32, 0x3c, 0x46,
+				0x50, 0x5a, 0x64, 0x6e, 0x78, 0x82, 0x8c, 0x96, 0xa0, 0xaa, 0xb4, 0xbe, 0xfc, 0xe8,
+			},
+			want: []int64{
+				2030, 2000, 2020, 1000000, 2040, 2050, 2060, 2070, 2080, 2090,
+				2100, 2110, 2120, 2130, 2140, 2150, 2160, 2170, 2180, 2190,
+			},
+		},
+		"delta": {
+			data: []byte{0xc6, 0x09, 0x02, 0x02, 0x22, 0x42, 0x42, 0x46},
+			want: []int64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29},
+		},
+		"fixed delta": {
+			data:   []byte{0xc0, 0x04, 0x13, 0x03},
+			signed: true,
+			want:   []int64{-10, -12, -14, -16, -18},
+		},
+		"signed short repeat": {
+			data:   []byte{0x02, 0x03},
+			signed: true,
+			want:   []int64{-2, -2, -2, -2, -2},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := decodeIntRLE(test.data, test.signed, true)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		_, err := decodeIntRLE([]byte{0x5e, 0x03, 0x5c, 0xa1}, false, true)
+		assert.ErrorIs(t, err, errShortStream)
+	})
+}
+
+func countdown(n int64) []int64 {
+	var values []int64
+	for i := n; i > 0; i-- {
+		values = append(values, i)
+	}
+	return values
+}